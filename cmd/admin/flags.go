@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bounds for the flags shared by the admin commands. Values outside them are clamped with a
+// warning so a mistyped flag cannot exhaust the database pool or leave a job running for days.
+const (
+	minWorkers = 1
+	maxWorkers = 64
+	maxTimeout = 6 * time.Hour
+)
+
+// clampWorkers keeps a --workers value within [minWorkers, maxWorkers]
+func clampWorkers(n int) int {
+	switch {
+	case n < minWorkers:
+		log.Printf("Warning: --workers=%d is below %d, using %d", n, minWorkers, minWorkers)
+		return minWorkers
+	case n > maxWorkers:
+		log.Printf("Warning: --workers=%d is above %d, using %d", n, maxWorkers, maxWorkers)
+		return maxWorkers
+	}
+	return n
+}
+
+// dbOpsMargin is how many pool connections --max-db-ops always leaves free, so the bill lookups
+// and other queries that run outside the detection limit still get a connection
+const dbOpsMargin = 2
+
+// clampDBOps keeps a --max-db-ops value between 1 and the pool size minus dbOpsMargin. The
+// limit is shared by every user and worker, so it bounds the whole run, not each user.
+func clampDBOps(n, maxOpenConns int) int {
+	limit := max(1, maxOpenConns-dbOpsMargin)
+	switch {
+	case n < 1:
+		log.Printf("Warning: --max-db-ops=%d is below 1, using 1", n)
+		return 1
+	case n > limit:
+		log.Printf("Warning: --max-db-ops=%d leaves fewer than %d of %d pool connections free, using %d", n, dbOpsMargin, maxOpenConns, limit)
+		return limit
+	}
+	return n
+}
+
+// parseTimeout parses a --timeout value, exiting on invalid input
+func parseTimeout(s string) time.Duration {
+	timeout, err := clampTimeout(s)
+	if err != nil {
+		log.Fatalf("Invalid timeout: %v", err)
+	}
+	return timeout
+}
+
+// clampTimeout parses a --timeout value, which must be positive, capping it at maxTimeout
+func clampTimeout(s string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("%q must be positive", s)
+	}
+	if timeout > maxTimeout {
+		log.Printf("Warning: --timeout=%s is above %s, using %s", timeout, maxTimeout, maxTimeout)
+		return maxTimeout, nil
+	}
+	return timeout, nil
+}
+
+// parseUserIDs parses a comma-separated list of user IDs, exiting on invalid input
+func parseUserIDs(s string) []int64 {
+	userIDs, err := splitUserIDs(s)
+	if err != nil {
+		log.Fatalf("Invalid --user-id: %v", err)
+	}
+	return userIDs
+}
+
+// splitUserIDs parses a comma-separated list of positive user IDs, skipping empty entries
+func splitUserIDs(s string) ([]int64, error) {
+	var userIDs []int64
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("user ID '%s': %w", p, err)
+		}
+		if id <= 0 {
+			return nil, fmt.Errorf("user ID '%s' must be positive", p)
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClampWorkers(t *testing.T) {
+	tests := []struct {
+		in   int
+		want int
+	}{
+		{in: 0, want: minWorkers},
+		{in: -3, want: minWorkers},
+		{in: 1, want: 1},
+		{in: 8, want: 8},
+		{in: maxWorkers, want: maxWorkers},
+		{in: 10000, want: maxWorkers},
+	}
+
+	for _, tt := range tests {
+		if got := clampWorkers(tt.in); got != tt.want {
+			t.Errorf("clampWorkers(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClampDBOps(t *testing.T) {
+	tests := []struct {
+		in           int
+		maxOpenConns int
+		want         int
+	}{
+		{in: 0, maxOpenConns: 25, want: 1},
+		{in: 8, maxOpenConns: 25, want: 8},
+		{in: 23, maxOpenConns: 25, want: 23},
+		{in: 24, maxOpenConns: 25, want: 23},
+		{in: 100, maxOpenConns: 25, want: 23},
+		{in: 5, maxOpenConns: 2, want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := clampDBOps(tt.in, tt.maxOpenConns); got != tt.want {
+			t.Errorf("clampDBOps(%d, %d) = %d, want %d", tt.in, tt.maxOpenConns, got, tt.want)
+		}
+	}
+}
+
+func TestClampTimeout(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "5m", want: 5 * time.Minute},
+		{in: "6h", want: maxTimeout},
+		{in: "72h", want: maxTimeout},
+		{in: "0s", wantErr: true},
+		{in: "-1m", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := clampTimeout(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("clampTimeout(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("clampTimeout(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitUserIDs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int64
+		wantErr bool
+	}{
+		{in: "1", want: []int64{1}},
+		{in: " 1, 2 ,,3 ", want: []int64{1, 2, 3}},
+		{in: "", want: nil},
+		{in: "1,0", wantErr: true},
+		{in: "-5", wantErr: true},
+		{in: "1,abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := splitUserIDs(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitUserIDs(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitUserIDs(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
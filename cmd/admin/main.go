@@ -2,19 +2,27 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"parsa/internal/domain/account"
 	"parsa/internal/domain/bill"
+	"parsa/internal/domain/cousin"
+	"parsa/internal/domain/openfinance"
+	"parsa/internal/domain/stats"
 	"parsa/internal/domain/transaction"
 	"parsa/internal/infrastructure/crypto"
+	ofclient "parsa/internal/infrastructure/openfinance"
 	"parsa/internal/infrastructure/postgres"
 	"parsa/internal/shared/config"
+	"parsa/internal/shared/logging"
 )
 
 const usage = `Parsa Admin CLI - Management commands for the Parsa API
@@ -24,6 +32,11 @@ Usage:
 
 Commands:
   duplicate-check    Run duplicate transaction detection on existing transactions
+  duplicate-preview  List the duplicate pairs duplicate-check would find, without marking anything
+  cousin-check       Report transactions whose cousin references a missing cousin
+  stats              Print per-user usage statistics
+  full-sync          Fetch every transaction since the configured start date, ignoring the sync window
+  merge-accounts     Merge duplicate accounts (same name, type and subtype) into one
 
 Examples:
   # Check all transactions for a specific user
@@ -40,6 +53,37 @@ Examples:
 
   # Run with timeout
   admin duplicate-check --user-id=1 --timeout=5m
+
+  # Emit per-user results as JSON for dashboards or CI
+  admin duplicate-check --all --output=json
+
+  # Match bill payments made up to a week from the due date
+  admin duplicate-check --user-id=1 --bill-window=168h
+
+  # Only match duplicates within the same account
+  admin duplicate-check --user-id=1 --same-account
+
+  # Cap concurrent database calls across all users and workers (keep it a few below
+  # DB_MAX_OPEN_CONNS, e.g. 20 for a pool of 25)
+  admin duplicate-check --all --workers=8 --max-db-ops=20
+
+  # Review the pairs detection would match before running it for real
+  admin duplicate-preview --user-id=1
+
+  # Report dangling cousin references, then clear them
+  admin cousin-check
+  admin cousin-check --fix
+
+  # Per-user statistics as a table, or as JSON for piping
+  admin stats --all
+  admin stats --user-id=1,2 --format=json
+
+  # Refresh a user's whole transaction history from the provider
+  admin full-sync --user-id=1
+
+  # Preview duplicate account merges, then apply them
+  admin merge-accounts --user-id=1
+  admin merge-accounts --user-id=1 --dry-run=false
 `
 
 func main() {
@@ -53,6 +97,16 @@ func main() {
 	switch command {
 	case "duplicate-check":
 		runDuplicateCheck(os.Args[2:])
+	case "duplicate-preview":
+		runDuplicatePreview(os.Args[2:])
+	case "cousin-check":
+		runCousinCheck(os.Args[2:])
+	case "stats":
+		runStats(os.Args[2:])
+	case "full-sync":
+		runFullSync(os.Args[2:])
+	case "merge-accounts":
+		runMergeAccounts(os.Args[2:])
 	case "help", "-h", "--help":
 		fmt.Print(usage)
 	default:
@@ -67,8 +121,12 @@ func runDuplicateCheck(args []string) {
 
 	userIDStr := fs.String("user-id", "", "User ID(s) to check (comma-separated for multiple)")
 	allUsers := fs.Bool("all", false, "Check all users with transactions")
-	workers := fs.Int("workers", transaction.DefaultWorkerCount, "Number of concurrent workers")
-	timeoutStr := fs.String("timeout", "30m", "Timeout for the operation (e.g., 5m, 1h)")
+	workers := fs.Int("workers", transaction.DefaultWorkerCount, "Number of concurrent workers (1-64)")
+	timeoutStr := fs.String("timeout", "30m", "Timeout for the operation (e.g., 5m, 1h; at most 6h)")
+	output := fs.String("output", "text", "Output format: text or json (per-user results keyed by user ID)")
+	billWindowStr := fs.String("bill-window", transaction.BillDuplicateTimeDelta.String(), "How far from a bill's due date a transaction may be to match it (e.g., 72h, 168h)")
+	sameAccount := fs.Bool("same-account", false, "Only match duplicates within the transaction's own account instead of across all of the user's accounts")
+	maxDBOps := fs.Int("max-db-ops", 0, "Concurrent database calls shared by all users and workers (0 = DB_DETECTION_MAX_OPS; at most DB_MAX_OPEN_CONNS-2)")
 
 	fs.Usage = func() {
 		fmt.Println("Usage: admin duplicate-check [options]")
@@ -79,6 +137,10 @@ func runDuplicateCheck(args []string) {
 		fmt.Println("  admin duplicate-check --user-id=1,2,3")
 		fmt.Println("  admin duplicate-check --all")
 		fmt.Println("  admin duplicate-check --all --workers=8 --timeout=1h")
+		fmt.Println("  admin duplicate-check --all --output=json > results.json")
+		fmt.Println("  admin duplicate-check --user-id=1 --bill-window=168h")
+		fmt.Println("  admin duplicate-check --user-id=1 --same-account")
+		fmt.Println("  admin duplicate-check --all --workers=8 --max-db-ops=20")
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -90,11 +152,18 @@ func runDuplicateCheck(args []string) {
 		fs.Usage()
 		os.Exit(1)
 	}
+	if *output != "text" && *output != "json" {
+		fmt.Printf("Error: unknown output %q (use text or json)\n", *output)
+		os.Exit(1)
+	}
 
-	// Parse timeout
-	timeout, err := time.ParseDuration(*timeoutStr)
-	if err != nil {
-		log.Fatalf("Invalid timeout format: %v", err)
+	// Parse timeout and keep the worker count within bounds
+	timeout := parseTimeout(*timeoutStr)
+	*workers = clampWorkers(*workers)
+
+	billWindow, err := time.ParseDuration(*billWindowStr)
+	if err != nil || billWindow <= 0 {
+		log.Fatalf("Invalid bill window %q: must be a positive duration", *billWindowStr)
 	}
 
 	// Load configuration
@@ -102,6 +171,12 @@ func runDuplicateCheck(args []string) {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
 
 	// Connect to database
 	db, err := postgres.New(cfg.Database.ConnectionString())
@@ -109,14 +184,36 @@ func runDuplicateCheck(args []string) {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
 	log.Println("Connected to database")
 
 	// Initialize repositories
 	transactionRepo := postgres.NewTransactionRepository(db)
 	billRepo := postgres.NewBillRepository(db)
 
+	// Use the same note texts as the API
+	notes, err := transaction.LoadNotes(cfg.Notes.Locale, cfg.Notes.Duplicate, cfg.Notes.BillPayment)
+	if err != nil {
+		log.Fatalf("Failed to load transaction notes: %v", err)
+	}
+
+	// Users run --workers at a time and each checks with --workers more, so the outer and inner
+	// levels share the service's one limit on database calls instead of multiplying
+	dbOpsLimit := cfg.Database.DetectionMaxOps
+	if *maxDBOps != 0 {
+		dbOpsLimit = clampDBOps(*maxDBOps, cfg.Database.MaxOpenConns)
+	}
+
 	// Initialize duplicate check service
 	dupService := transaction.NewDuplicateCheckServiceWithWorkers(transactionRepo, *workers)
+	dupService.SetNotes(notes)
+	dupService.SetBillPaymentCategories(cfg.OpenFinance.BillPaymentCategories)
+	dupService.SetMaxConcurrentDBOps(dbOpsLimit)
+	dupService.SetBillWindow(billWindow)
+	dupService.SetSameAccountOnly(*sameAccount)
+	if cfg.OpenFinance.LinkDuplicateCousins {
+		dupService.SetCousinResolver(cousin.DuplicatePairResolver(postgres.NewCousinRepository(db)))
+	}
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -142,19 +239,7 @@ func runDuplicateCheck(args []string) {
 		}
 		log.Printf("Found %d users with provider keys", len(userIDs))
 	} else {
-		// Parse user IDs from comma-separated string
-		parts := strings.Split(*userIDStr, ",")
-		for _, p := range parts {
-			p = strings.TrimSpace(p)
-			if p == "" {
-				continue
-			}
-			id, err := strconv.ParseInt(p, 10, 64)
-			if err != nil {
-				log.Fatalf("Invalid user ID '%s': %v", p, err)
-			}
-			userIDs = append(userIDs, id)
-		}
+		userIDs = parseUserIDs(*userIDStr)
 	}
 
 	if len(userIDs) == 0 {
@@ -162,32 +247,47 @@ func runDuplicateCheck(args []string) {
 		return
 	}
 
-	log.Printf("Starting duplicate check for %d user(s) with %d workers", len(userIDs), *workers)
+	log.Printf("Starting duplicate check for %d user(s) with %d workers and at most %d concurrent database calls", len(userIDs), *workers, dbOpsLimit)
 	startTime := time.Now()
 
 	// Run duplicate check
+	var results map[int64]*transaction.DuplicateCheckResult
 	if len(userIDs) == 1 {
 		// Single user - run directly
-		result, err := dupService.CheckAllUserTransactions(ctx, userIDs[0])
+		// Progress goes to stderr so it never mixes with the JSON output
+		progress := transaction.WithProgress(func(processed, found, marked int) {
+			fmt.Fprintf(os.Stderr, "\rChecked %d transactions, %d duplicates found, %d marked", processed, found, marked)
+		})
+		result, err := dupService.CheckAllUserTransactions(ctx, userIDs[0], progress)
+		fmt.Fprintln(os.Stderr)
 		if err != nil {
 			log.Fatalf("Duplicate check failed: %v", err)
 		}
-		printResult(userIDs[0], result)
-
-		// Run bill duplicate check
-		found, marked := checkBillDuplicates(ctx, userIDs[0], dupService, billRepo)
-		printBillResult(userIDs[0], found, marked)
+		results = map[int64]*transaction.DuplicateCheckResult{userIDs[0]: result}
 	} else {
 		// Multiple users - run concurrently
-		results := dupService.CheckAllUsersTransactions(ctx, userIDs)
-		for uid, result := range results {
-			printResult(uid, result)
-		}
+		results = dupService.CheckAllUsersTransactions(ctx, userIDs)
+	}
 
-		// Run bill duplicate check for all users
+	// Run bill duplicate check
+	billResults := make(map[int64]billDuplicateResult, len(userIDs))
+	for _, uid := range userIDs {
+		found, marked := checkBillDuplicates(ctx, uid, dupService, billRepo)
+		billResults[uid] = billDuplicateResult{DuplicatesFound: found, DuplicatesMarked: marked}
+	}
+
+	if *output == "json" {
+		users := make(map[int64]userDuplicateCheckOutput, len(userIDs))
 		for _, uid := range userIDs {
-			found, marked := checkBillDuplicates(ctx, uid, dupService, billRepo)
-			printBillResult(uid, found, marked)
+			users[uid] = userDuplicateCheckOutput{Transactions: results[uid], Bills: billResults[uid]}
+		}
+		printJSON(duplicateCheckOutput{Users: users, ElapsedSeconds: time.Since(startTime).Seconds()})
+	} else {
+		for _, uid := range userIDs {
+			printResult(uid, results[uid])
+		}
+		for _, uid := range userIDs {
+			printBillResult(uid, billResults[uid].DuplicatesFound, billResults[uid].DuplicatesMarked)
 		}
 	}
 
@@ -195,6 +295,657 @@ func runDuplicateCheck(args []string) {
 	log.Printf("Duplicate check completed in %v", elapsed)
 }
 
+func runDuplicatePreview(args []string) {
+	fs := flag.NewFlagSet("duplicate-preview", flag.ExitOnError)
+
+	userIDStr := fs.String("user-id", "", "User ID(s) to preview (comma-separated for multiple)")
+	timeoutStr := fs.String("timeout", "30m", "Timeout for the operation (e.g., 5m, 1h; at most 6h)")
+	output := fs.String("output", "text", "Output format: text or json (groups keyed by user ID)")
+	sameAccount := fs.Bool("same-account", false, "Only match duplicates within the transaction's own account instead of across all of the user's accounts")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: admin duplicate-preview [options]")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Println("  admin duplicate-preview --user-id=1")
+		fmt.Println("  admin duplicate-preview --user-id=1 --same-account")
+		fmt.Println("  admin duplicate-preview --user-id=1,2 --output=json > preview.json")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *userIDStr == "" {
+		fmt.Println("Error: must specify --user-id")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Printf("Error: unknown output %q (use text or json)\n", *output)
+		os.Exit(1)
+	}
+
+	timeout := parseTimeout(*timeoutStr)
+	userIDs := parseUserIDs(*userIDStr)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+
+	db, err := postgres.New(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	log.Println("Connected to database")
+
+	// Only the search half of the service is used, so nothing is updated
+	dupService := transaction.NewDuplicateCheckService(postgres.NewTransactionRepository(db))
+	dupService.SetMaxConcurrentDBOps(cfg.Database.DetectionMaxOps)
+	dupService.SetSameAccountOnly(*sameAccount)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	groups := make(map[int64][]transaction.DuplicateGroup, len(userIDs))
+	for _, uid := range userIDs {
+		userGroups, err := dupService.PreviewAllUserDuplicates(ctx, uid)
+		if err != nil {
+			log.Fatalf("Duplicate preview failed for user %d: %v", uid, err)
+		}
+		groups[uid] = userGroups
+	}
+
+	if *output == "json" {
+		users := make(map[int64][]duplicatePreviewGroup, len(userIDs))
+		for _, uid := range userIDs {
+			users[uid] = toDuplicatePreviewGroups(groups[uid])
+		}
+		printJSON(duplicatePreviewOutput{Users: users})
+		return
+	}
+	for _, uid := range userIDs {
+		printPreview(uid, groups[uid])
+	}
+}
+
+func runCousinCheck(args []string) {
+	fs := flag.NewFlagSet("cousin-check", flag.ExitOnError)
+
+	fix := fs.Bool("fix", false, "Clear (set to NULL) cousin references that point at missing cousins")
+	limit := fs.Int("limit", 20, "Maximum number of orphaned references to list")
+	timeoutStr := fs.String("timeout", "10m", "Timeout for the operation (e.g., 5m, 1h; at most 6h)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: admin cousin-check [options]")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Println("  admin cousin-check")
+		fmt.Println("  admin cousin-check --limit=100")
+		fmt.Println("  admin cousin-check --fix")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	timeout := parseTimeout(*timeoutStr)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+
+	db, err := postgres.New(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	log.Println("Connected to database")
+
+	transactionRepo := postgres.NewTransactionRepository(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	hasFK, err := transactionRepo.HasCousinForeignKey(ctx)
+	if err != nil {
+		log.Fatalf("Cousin check failed: %v", err)
+	}
+
+	count, err := transactionRepo.CountOrphanedCousinRefs(ctx)
+	if err != nil {
+		log.Fatalf("Cousin check failed: %v", err)
+	}
+
+	fmt.Printf("\n=== Cousin References ===\n")
+	fmt.Printf("  Foreign key validated: %t\n", hasFK)
+	fmt.Printf("  Orphaned references:   %d\n", count)
+
+	if count > 0 {
+		refs, err := transactionRepo.ListOrphanedCousinRefs(ctx, *limit)
+		if err != nil {
+			log.Fatalf("Cousin check failed: %v", err)
+		}
+		for _, ref := range refs {
+			fmt.Printf("    - transaction %s -> cousin %d\n", ref.TransactionID, ref.CousinID)
+		}
+		if count > int64(len(refs)) {
+			fmt.Printf("    ... and %d more\n", count-int64(len(refs)))
+		}
+	}
+
+	if !*fix {
+		if count > 0 {
+			fmt.Println("\nRun with --fix to clear them")
+		}
+		return
+	}
+
+	cleared, err := transactionRepo.ClearOrphanedCousinRefs(ctx)
+	if err != nil {
+		log.Fatalf("Failed to clear orphaned cousin references: %v", err)
+	}
+	fmt.Printf("  Cleared:               %d\n", cleared)
+}
+
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+
+	userIDStr := fs.String("user-id", "", "User ID(s) to report (comma-separated for multiple)")
+	allUsers := fs.Bool("all", false, "Report all users")
+	format := fs.String("format", "table", "Output format: table or json")
+	timeoutStr := fs.String("timeout", "10m", "Timeout for the operation (e.g., 5m, 1h; at most 6h)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: admin stats [options]")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Println("  admin stats --all")
+		fmt.Println("  admin stats --user-id=1,2,3")
+		fmt.Println("  admin stats --all --format=json > stats.json")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *userIDStr == "" && !*allUsers {
+		fmt.Println("Error: must specify --user-id or --all")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Printf("Error: unknown format %q (use table or json)\n", *format)
+		os.Exit(1)
+	}
+
+	timeout := parseTimeout(*timeoutStr)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+
+	db, err := postgres.New(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	log.Println("Connected to database")
+
+	encryptor, err := crypto.NewEncryptor(cfg.Encryption.Key)
+	if err != nil {
+		log.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	statsService := stats.NewService(
+		postgres.NewStatsRepository(db),
+		postgres.NewUserRepository(db, encryptor),
+		postgres.NewTransactionRepository(db),
+		postgres.NewBillRepository(db),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var result []*stats.UserStats
+	if *allUsers {
+		result, err = statsService.ListUserStats(ctx)
+		if err != nil {
+			log.Fatalf("Stats failed: %v", err)
+		}
+	} else {
+		for _, id := range parseUserIDs(*userIDStr) {
+			st, err := statsService.GetUserStats(ctx, id)
+			if err != nil {
+				log.Fatalf("Stats failed: %v", err)
+			}
+			result = append(result, st)
+		}
+	}
+
+	if *format == "json" {
+		printJSON(result)
+		return
+	}
+	printStatsTable(result)
+}
+
+func runFullSync(args []string) {
+	fs := flag.NewFlagSet("full-sync", flag.ExitOnError)
+
+	userIDStr := fs.String("user-id", "", "User ID(s) to sync (comma-separated for multiple)")
+	allUsers := fs.Bool("all", false, "Sync all users with a provider key")
+	timeoutStr := fs.String("timeout", "1h", "Timeout for the operation (e.g., 30m, 2h; at most 6h)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: admin full-sync [options]")
+		fmt.Println("\nFetches every transaction since OPENFINANCE_TRANSACTION_SYNC_START_DATE, including")
+		fmt.Println("those older than the sync window scheduled syncs are limited to.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Println("  admin full-sync --user-id=1")
+		fmt.Println("  admin full-sync --all --timeout=6h")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *userIDStr == "" && !*allUsers {
+		fmt.Println("Error: must specify --user-id or --all")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	timeout := parseTimeout(*timeoutStr)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+
+	db, err := postgres.New(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	log.Println("Connected to database")
+
+	encryptor, err := crypto.NewEncryptor(cfg.Encryption.Key)
+	if err != nil {
+		log.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	// Detection runs on the new transactions, so use the same settings as the API
+	notes, err := transaction.LoadNotes(cfg.Notes.Locale, cfg.Notes.Duplicate, cfg.Notes.BillPayment)
+	if err != nil {
+		log.Fatalf("Failed to load transaction notes: %v", err)
+	}
+
+	userRepo := postgres.NewUserRepository(db, encryptor)
+	transactionRepo := postgres.NewTransactionRepository(db)
+	accountRepo := postgres.NewAccountRepository(db)
+	accountService := account.NewService(accountRepo, postgres.NewItemRepository(db), transactionRepo)
+	accountService.SetSubtypeAliases(cfg.OpenFinance.SubtypeAliases)
+	accountService.SetSyncedSubtypes(cfg.OpenFinance.SyncedSubtypes)
+	ofClient := ofclient.NewClientWithTimeouts(ofclient.Timeouts{
+		Accounts:     cfg.OpenFinance.AccountsTimeout,
+		Transactions: cfg.OpenFinance.TransactionsTimeout,
+		Bills:        cfg.OpenFinance.BillsTimeout,
+	})
+	syncService := openfinance.NewTransactionSyncService(ofClient, userRepo, accountService, accountRepo, transactionRepo,
+		postgres.NewCreditCardDataRepository(db), postgres.NewBankRepository(db), postgres.NewMerchantRepository(db),
+		postgres.NewDocumentRepository(db), cfg.OpenFinance.TransactionSyncStartDate, cfg.OpenFinance.UpdateSyncDays)
+	syncService.SetRemoveMissing(cfg.OpenFinance.RemoveMissingTransactions)
+	syncService.SetPrunePendingDays(cfg.OpenFinance.PrunePendingDays)
+	dupService := transaction.NewDuplicateCheckService(transactionRepo)
+	dupService.SetNotes(notes)
+	dupService.SetBillPaymentCategories(cfg.OpenFinance.BillPaymentCategories)
+	dupService.SetMaxConcurrentDBOps(cfg.Database.DetectionMaxOps)
+	syncService.SetDuplicateCheckService(dupService)
+	syncLocker := postgres.NewSyncLocker(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var userIDs []int64
+	if *allUsers {
+		users, err := userRepo.ListUsersWithProviderKey(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list users: %v", err)
+		}
+		for _, u := range users {
+			userIDs = append(userIDs, u.ID)
+		}
+		log.Printf("Found %d users with provider keys", len(userIDs))
+	} else {
+		userIDs = parseUserIDs(*userIDStr)
+	}
+
+	startTime := time.Now()
+	failed := 0
+	for _, uid := range userIDs {
+		result, err := fullSyncUser(ctx, syncService, syncLocker, uid)
+		if err != nil {
+			log.Printf("Full sync failed for user %d: %v", uid, err)
+			failed++
+			continue
+		}
+		printFullSyncResult(result)
+	}
+
+	log.Printf("Full sync completed in %v (%d of %d users failed)", time.Since(startTime), failed, len(userIDs))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// fullSyncUser fetches the user's whole transaction history under their sync lock, waiting
+// for a running scheduled sync to finish first
+func fullSyncUser(ctx context.Context, syncService *openfinance.TransactionSyncService, locker openfinance.SyncLocker, userID int64) (*openfinance.TransactionSyncResult, error) {
+	unlock, err := openfinance.LockUserSync(ctx, locker, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock sync: %w", err)
+	}
+	defer unlock()
+
+	ctx, releaseKey := openfinance.WithProviderKeyScope(ctx, userID)
+	defer releaseKey()
+
+	return syncService.SyncUserTransactions(ctx, userID, true)
+}
+
+func printFullSyncResult(result *openfinance.TransactionSyncResult) {
+	fmt.Printf("\n=== User %d (Full Sync) ===\n", result.UserID)
+	fmt.Printf("  Transactions found: %d\n", result.TransactionsFound)
+	fmt.Printf("  Created:            %d\n", result.Created)
+	fmt.Printf("  Updated:            %d\n", result.Updated)
+	fmt.Printf("  Skipped:            %d\n", result.Skipped)
+	fmt.Printf("  Removed:            %d\n", result.Removed)
+	if len(result.PendingPruned) > 0 {
+		accountIDs := make([]string, 0, len(result.PendingPruned))
+		for accountID := range result.PendingPruned {
+			accountIDs = append(accountIDs, accountID)
+		}
+		sort.Strings(accountIDs)
+		fmt.Printf("  Pending pruned:\n")
+		for _, accountID := range accountIDs {
+			fmt.Printf("    - %s: %d\n", accountID, result.PendingPruned[accountID])
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Printf("  Errors:             %d\n", len(result.Errors))
+		for i, e := range result.Errors {
+			if i >= 5 {
+				fmt.Printf("    ... and %d more errors\n", len(result.Errors)-5)
+				break
+			}
+			fmt.Printf("    - %s\n", e)
+		}
+	}
+}
+
+func runMergeAccounts(args []string) {
+	fs := flag.NewFlagSet("merge-accounts", flag.ExitOnError)
+
+	userIDStr := fs.String("user-id", "", "User ID(s) to check (comma-separated for multiple)")
+	allUsers := fs.Bool("all", false, "Check all users with a provider key")
+	dryRun := fs.Bool("dry-run", true, "Only list the merges; pass --dry-run=false to apply them")
+	timeoutStr := fs.String("timeout", "10m", "Timeout for the operation (e.g., 5m, 1h; at most 6h)")
+
+	fs.Usage = func() {
+		fmt.Println("Usage: admin merge-accounts [options]")
+		fmt.Println("\nAccounts matching on name, type and subtype are merged into the most recently")
+		fmt.Println("updated one that is not removed: transactions and bills move to it, user-set")
+		fmt.Println("fields it lacks are copied over and the duplicate is deleted.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Println("  admin merge-accounts --user-id=1")
+		fmt.Println("  admin merge-accounts --user-id=1 --dry-run=false")
+		fmt.Println("  admin merge-accounts --all")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *userIDStr == "" && !*allUsers {
+		fmt.Println("Error: must specify --user-id or --all")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	timeout := parseTimeout(*timeoutStr)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+
+	db, err := postgres.New(cfg.Database.ConnectionString())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	log.Println("Connected to database")
+
+	encryptor, err := crypto.NewEncryptor(cfg.Encryption.Key)
+	if err != nil {
+		log.Fatalf("Failed to create encryptor: %v", err)
+	}
+
+	userRepo := postgres.NewUserRepository(db, encryptor)
+	accountService := account.NewService(postgres.NewAccountRepository(db), postgres.NewItemRepository(db),
+		postgres.NewTransactionRepository(db))
+	accountService.SetSubtypeAliases(cfg.OpenFinance.SubtypeAliases)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var userIDs []int64
+	if *allUsers {
+		users, err := userRepo.ListUsersWithProviderKey(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list users: %v", err)
+		}
+		for _, u := range users {
+			userIDs = append(userIDs, u.ID)
+		}
+	} else {
+		userIDs = parseUserIDs(*userIDStr)
+	}
+
+	for _, userID := range userIDs {
+		merges, err := accountService.PlanAccountMerges(ctx, userID)
+		if err != nil {
+			log.Printf("User %d: failed to find duplicate accounts: %v", userID, err)
+			continue
+		}
+
+		failed := 0
+		if !*dryRun {
+			applied := make([]account.AccountMerge, 0, len(merges))
+			for _, merge := range merges {
+				if err := accountService.ApplyAccountMerge(ctx, &merge); err != nil {
+					log.Printf("User %d: failed to merge account %s into %s: %v",
+						userID, merge.Duplicate.ID, merge.Canonical.ID, err)
+					failed++
+					continue
+				}
+				applied = append(applied, merge)
+			}
+			merges = applied
+		}
+		printMergeResult(userID, merges, failed, *dryRun)
+	}
+
+	if *dryRun {
+		fmt.Println("\nDry run: nothing was changed. Run with --dry-run=false to apply the merges")
+	}
+}
+
+func printMergeResult(userID int64, merges []account.AccountMerge, failed int, dryRun bool) {
+	mode := "Merged"
+	if dryRun {
+		mode = "Would merge"
+	}
+	fmt.Printf("\n=== User %d (%s %d account(s)) ===\n", userID, strings.ToLower(mode), len(merges))
+	for _, merge := range merges {
+		fmt.Printf("  %s %s into %s (%q, %s/%s): %d transactions",
+			mode, merge.Duplicate.ID, merge.Canonical.ID, merge.Canonical.Name,
+			merge.Canonical.AccountType, merge.Canonical.Subtype, merge.Transactions)
+		if !dryRun {
+			fmt.Printf(", %d bills", merge.Bills)
+		}
+		fmt.Println()
+	}
+	if failed > 0 {
+		fmt.Printf("  Failed: %d\n", failed)
+	}
+}
+
+func printStatsTable(result []*stats.UserStats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tEMAIL\tACCOUNTS\tTRANSACTIONS\tCONSIDERED\tEXCLUDED\tBILLS\tTAGS\tCOUSIN RULES\tLAST SYNC\tBALANCE")
+	for _, st := range result {
+		lastSync := "-"
+		if st.LastSyncAt != nil {
+			lastSync = st.LastSyncAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			st.UserID, st.Email, st.Accounts, st.Transactions, st.ConsideredTransactions, st.ExcludedTransactions,
+			st.Bills, st.Tags, st.CousinRules, lastSync, formatBalances(st.TotalBalance))
+	}
+	w.Flush()
+}
+
+// formatBalances renders per-currency totals as "BRL 10.00, USD 2.50", ordered by currency
+func formatBalances(balances map[string]float64) string {
+	if len(balances) == 0 {
+		return "-"
+	}
+	currencies := make([]string, 0, len(balances))
+	for currency := range balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		parts = append(parts, fmt.Sprintf("%s %.2f", currency, balances[currency]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// duplicateCheckOutput is the --output=json document of duplicate-check
+type duplicateCheckOutput struct {
+	Users          map[int64]userDuplicateCheckOutput `json:"users"`
+	ElapsedSeconds float64                            `json:"elapsedSeconds"`
+}
+
+type userDuplicateCheckOutput struct {
+	Transactions *transaction.DuplicateCheckResult `json:"transactions"`
+	Bills        billDuplicateResult               `json:"bills"`
+}
+
+type billDuplicateResult struct {
+	DuplicatesFound  int `json:"duplicatesFound"`
+	DuplicatesMarked int `json:"duplicatesMarked"`
+}
+
+// duplicatePreviewOutput is the --output=json document of duplicate-preview
+type duplicatePreviewOutput struct {
+	Users map[int64][]duplicatePreviewGroup `json:"users"`
+}
+
+type duplicatePreviewGroup struct {
+	Source     duplicatePreviewTransaction   `json:"source"`
+	Duplicates []duplicatePreviewTransaction `json:"duplicates"`
+}
+
+type duplicatePreviewTransaction struct {
+	ID               string  `json:"id"`
+	AccountID        string  `json:"accountId"`
+	Amount           float64 `json:"amount"`
+	TransactionDate  string  `json:"transactionDate"`
+	Description      string  `json:"description"`
+	ConsideredReason *string `json:"consideredReason,omitempty"`
+}
+
+func toDuplicatePreviewGroups(groups []transaction.DuplicateGroup) []duplicatePreviewGroup {
+	result := make([]duplicatePreviewGroup, 0, len(groups))
+	for _, g := range groups {
+		group := duplicatePreviewGroup{Source: toDuplicatePreviewTransaction(g.Source)}
+		for _, dup := range g.Duplicates {
+			group.Duplicates = append(group.Duplicates, toDuplicatePreviewTransaction(dup))
+		}
+		result = append(result, group)
+	}
+	return result
+}
+
+func toDuplicatePreviewTransaction(txn *transaction.Transaction) duplicatePreviewTransaction {
+	return duplicatePreviewTransaction{
+		ID:               txn.ID,
+		AccountID:        txn.AccountID,
+		Amount:           txn.Amount,
+		TransactionDate:  txn.TransactionDate.Format(time.RFC3339),
+		Description:      txn.Description,
+		ConsideredReason: txn.ConsideredReason,
+	}
+}
+
+// printJSON writes v to stdout as indented JSON; logs go to stderr so the output can be piped
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("Failed to encode output: %v", err)
+	}
+}
+
 func printResult(userID int64, result *transaction.DuplicateCheckResult) {
 	fmt.Printf("\n=== User %d (Transaction Duplicates) ===\n", userID)
 	fmt.Printf("  Transactions checked: %d\n", result.TransactionsChecked)
@@ -213,6 +964,37 @@ func printResult(userID int64, result *transaction.DuplicateCheckResult) {
 	}
 }
 
+func printPreview(userID int64, groups []transaction.DuplicateGroup) {
+	fmt.Printf("\n=== User %d (Duplicate Preview) ===\n", userID)
+	if len(groups) == 0 {
+		fmt.Println("  No duplicates found")
+		return
+	}
+
+	pairs := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, g := range groups {
+		fmt.Fprintf(w, "  Group %d\t\t\t\t\n", i+1)
+		fmt.Fprintf(w, "    source\t%s\n", formatPreviewTransaction(g.Source))
+		for _, dup := range g.Duplicates {
+			fmt.Fprintf(w, "    duplicate\t%s\n", formatPreviewTransaction(dup))
+		}
+		pairs += len(g.Duplicates)
+	}
+	w.Flush()
+	fmt.Printf("  Groups: %d, pairs: %d (nothing was marked)\n", len(groups), pairs)
+}
+
+// formatPreviewTransaction renders a transaction as tab-separated ID, amount, date and
+// description, noting when its considered state was already decided and a real run would leave it
+func formatPreviewTransaction(txn *transaction.Transaction) string {
+	line := fmt.Sprintf("%s\t%.2f\t%s\t%q", txn.ID, txn.Amount, txn.TransactionDate.Format("2006-01-02 15:04"), txn.Description)
+	if txn.HasConsideredReason() {
+		line += fmt.Sprintf(" (already decided: %s)", *txn.ConsideredReason)
+	}
+	return line
+}
+
 func checkBillDuplicates(ctx context.Context, userID int64, dupService *transaction.DuplicateCheckService, billRepo bill.Repository) (found int, marked int) {
 	bills, err := billRepo.ListByUserID(ctx, userID, 1000, 0)
 	if err != nil {
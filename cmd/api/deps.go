@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
 	"parsa/internal/domain/account"
+	"parsa/internal/domain/attachment"
+	"parsa/internal/domain/audit"
+	"parsa/internal/domain/cousin"
 	"parsa/internal/domain/cousinrule"
 	"parsa/internal/domain/notification"
 	"parsa/internal/domain/openfinance"
+	"parsa/internal/domain/transaction"
+	"parsa/internal/infrastructure/blobstore"
 	"parsa/internal/infrastructure/crypto"
 	fcmclient "parsa/internal/infrastructure/firebase"
 	ofclient "parsa/internal/infrastructure/openfinance"
@@ -17,8 +23,10 @@ import (
 	"parsa/internal/infrastructure/postgres/listener"
 	httphandlers "parsa/internal/interfaces/http"
 	"parsa/internal/shared/auth"
+	"parsa/internal/shared/branding"
 	"parsa/internal/shared/config"
 	"parsa/internal/shared/messages"
+	"parsa/internal/shared/telemetry"
 )
 
 // Dependencies holds all initialized application components.
@@ -26,14 +34,20 @@ type Dependencies struct {
 	DB *postgres.DB
 
 	// Handlers
+	HealthHandler       *httphandlers.HealthHandler
 	AuthHandler         *httphandlers.AuthHandler
 	UserHandler         *httphandlers.UserHandler
 	AccountHandler      *httphandlers.AccountHandler
+	ItemHandler         *httphandlers.ItemHandler
 	TransactionHandler  *httphandlers.TransactionHandler
 	TagHandler          *httphandlers.TagHandler
+	CousinHandler       *httphandlers.CousinHandler
 	CousinRuleHandler   *httphandlers.CousinRuleHandler
 	NotificationHandler *httphandlers.NotificationHandler
+	CategoryHandler     *httphandlers.CategoryHandler
 	ForecastHandler     *httphandlers.ForecastHandler
+	AuditHandler        *httphandlers.AuditHandler
+	AttachmentHandler   *httphandlers.AttachmentHandler
 
 	// Auth
 	JWT           *auth.JWT
@@ -43,6 +57,8 @@ type Dependencies struct {
 	AccountSyncService     *openfinance.AccountSyncService
 	TransactionSyncService *openfinance.TransactionSyncService
 	BillSyncService        *openfinance.BillSyncService
+	SyncLocker             *postgres.SyncLocker
+	LeaderLock             *postgres.LeaderLock
 
 	// Repositories (for scheduler job provider)
 	UserRepo *postgres.UserRepository
@@ -58,6 +74,7 @@ func NewDependencies(cfg *config.Config) (*Dependencies, error) {
 	if err != nil {
 		return nil, err
 	}
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
 	log.Println("Connected to database")
 
 	// Initialize encryptor
@@ -76,6 +93,8 @@ func NewDependencies(cfg *config.Config) (*Dependencies, error) {
 
 	// Initialize domain services
 	accountService := account.NewService(accountRepo, itemRepo, transactionRepo)
+	accountService.SetSubtypeAliases(cfg.OpenFinance.SubtypeAliases)
+	accountService.SetSyncedSubtypes(cfg.OpenFinance.SyncedSubtypes)
 
 	// Initialize bill repository
 	billRepo := postgres.NewBillRepository(db)
@@ -87,8 +106,42 @@ func NewDependencies(cfg *config.Config) (*Dependencies, error) {
 		return nil, fmt.Errorf("failed to load notification messages: %w", err)
 	}
 
+	// Load bank brand colors/logos, with deployment overrides
+	bankBranding, err := branding.Load(cfg.Branding.BankFile, cfg.Branding.LogoBaseURL)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	// One duplicate checker with the deployment's settings, shared by the syncs and handlers so
+	// they also share its limit on concurrent database calls
+	duplicateNotes, err := transaction.LoadNotes(cfg.Notes.Locale, cfg.Notes.Duplicate, cfg.Notes.BillPayment)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to load transaction notes: %w", err)
+	}
+	duplicateCheckService := transaction.NewDuplicateCheckService(transactionRepo)
+	duplicateCheckService.SetNotes(duplicateNotes)
+	duplicateCheckService.SetBillPaymentCategories(cfg.OpenFinance.BillPaymentCategories)
+	duplicateCheckService.SetMaxConcurrentDBOps(cfg.Database.DetectionMaxOps)
+	if cfg.OpenFinance.LinkDuplicateCousins {
+		duplicateCheckService.SetCousinResolver(cousin.DuplicatePairResolver(postgres.NewCousinRepository(db)))
+	}
+
 	// Initialize Open Finance client
-	ofClient := ofclient.NewClient()
+	ofClient := ofclient.NewClientWithTimeouts(ofclient.Timeouts{
+		Accounts:     cfg.OpenFinance.AccountsTimeout,
+		Transactions: cfg.OpenFinance.TransactionsTimeout,
+		Bills:        cfg.OpenFinance.BillsTimeout,
+	})
+	if cfg.Telemetry.Enabled {
+		ofMetrics, err := telemetry.NewOpenFinanceMetrics()
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create Open Finance metrics: %w", err)
+		}
+		ofClient.SetMetrics(ofMetrics)
+	}
 
 	// Initialize notification components (needed for account sync provider-key-cleared notification)
 	notificationRepo := postgres.NewNotificationRepository(db)
@@ -112,21 +165,47 @@ func NewDependencies(cfg *config.Config) (*Dependencies, error) {
 	documentRepo := postgres.NewDocumentRepository(db)
 
 	// Initialize sync services (account sync needs notification service for provider_key_cleared)
-	accountSyncService := openfinance.NewAccountSyncService(ofClient, userRepo, accountService, itemRepo, notificationService, msgs)
+	accountSyncService := openfinance.NewAccountSyncService(ofClient, userRepo, accountService, itemRepo, bankRepo, notificationService, msgs)
+	accountSyncService.SetBankBranding(bankBranding)
 	transactionSyncService := openfinance.NewTransactionSyncService(ofClient, userRepo, accountService, accountRepo, transactionRepo, creditCardDataRepo, bankRepo, merchantRepo, documentRepo, cfg.OpenFinance.TransactionSyncStartDate, cfg.OpenFinance.UpdateSyncDays)
+	transactionSyncService.SetRemoveMissing(cfg.OpenFinance.RemoveMissingTransactions)
+	transactionSyncService.SetSyncWindowDays(cfg.OpenFinance.SyncWindowDays)
+	transactionSyncService.SetPrunePendingDays(cfg.OpenFinance.PrunePendingDays)
+	transactionSyncService.SetDuplicateCheckService(duplicateCheckService)
 	billSyncService := openfinance.NewBillSyncService(ofClient, userRepo, accountService, accountRepo, billRepo, transactionRepo)
+	billSyncService.SetDuplicateCheckService(duplicateCheckService)
+
+	// Per-user sync lock (Postgres advisory lock) shared by scheduled and on-demand syncs
+	syncLocker := postgres.NewSyncLocker(db)
+
+	// Scheduler leadership (Postgres advisory lock) so one replica runs the scheduled jobs
+	var leaderLock *postgres.LeaderLock
+	if cfg.Scheduler.LeaderElection {
+		leaderLock = postgres.NewLeaderLock(db)
+	}
 
 	// Initialize auth components
-	jwt := auth.NewJWT(cfg.JWT.Secret)
+	jwt, err := newJWT(cfg.JWT)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 	authCodeStore := auth.NewAuthCodeStore(5 * time.Minute)
 	googleOAuth := auth.NewGoogleOAuthProvider(
 		cfg.OAuth.Google.ClientID,
 		cfg.OAuth.Google.ClientSecret,
 		cfg.OAuth.Google.WebCallbackURL,
+		cfg.OAuth.Google.Scopes,
+		cfg.OAuth.Google.Prompt,
 	)
 
 	// Initialize handlers
 	authHandler := httphandlers.NewAuthHandler(userRepo, googleOAuth, jwt, authCodeStore, cfg.OAuth.Google.MobileCallbackURL, cfg.OAuth.Google.WebCallbackURL, cfg.OAuth.MobileAppScheme)
+	authHandler.SetCookieOptions(httphandlers.CookieOptions{
+		Domain:   cfg.Cookie.Domain,
+		SameSite: cookieSameSite(cfg.Cookie.SameSite),
+		Secure:   cfg.Cookie.Secure,
+	})
 
 	// Initialize Apple OAuth if configured
 	if cfg.OAuth.Apple.PrivateKeyPath != "" {
@@ -136,57 +215,176 @@ func NewDependencies(cfg *config.Config) (*Dependencies, error) {
 			cfg.OAuth.Apple.ClientID,
 			cfg.OAuth.Apple.PrivateKeyPath,
 			cfg.OAuth.Apple.MobileCallbackURL,
+			cfg.OAuth.Apple.Scopes,
 		)
 		if err != nil {
 			log.Printf("Warning: Failed to initialize Apple OAuth: %v", err)
 		} else {
 			authHandler.SetAppleOAuthProvider(appleOAuth, cfg.OAuth.Apple.MobileCallbackURL)
+			if err := authHandler.CheckAppleCallbackTemplate(); err != nil {
+				log.Printf("Warning: Apple callback template unavailable, falling back to direct app redirects: %v", err)
+			}
 		}
 	}
+	if !authHandler.AppleOAuthEnabled() {
+		log.Println("Apple OAuth not configured, Apple sign-in routes will return 503")
+	}
 
 	notificationHandler := httphandlers.NewNotificationHandler(notificationService)
 
 	userHandler := httphandlers.NewUserHandler(userRepo, accountRepo, ofClient, accountSyncService, transactionSyncService, billSyncService, notificationService, msgs)
+	userHandler.SetSyncLocker(syncLocker)
 	accountHandler := httphandlers.NewAccountHandler(accountService, transactionSyncService, billSyncService)
+	accountHandler.SetSyncLocker(syncLocker)
+	itemHandler := httphandlers.NewItemHandler(accountService)
 	tagRepo := postgres.NewTagRepository(db)
 	tagHandler := httphandlers.NewTagHandler(tagRepo)
 
+	// Initialize cousin (counterparty group) components
+	cousinRepo := postgres.NewCousinRepository(db)
+	cousinService := cousin.NewService(cousinRepo, transactionRepo, accountRepo)
+	cousinHandler := httphandlers.NewCousinHandler(cousinService)
+
 	// Initialize cousin rule components
 	cousinRuleRepo := postgres.NewCousinRuleRepository(db)
 	cousinRuleService := cousinrule.NewService(cousinRuleRepo, transactionRepo)
+	cousinRuleService.SetTagRepository(tagRepo)
 	cousinRuleHandler := httphandlers.NewCousinRuleHandler(cousinRuleService)
 
 	// Initialize transaction handler with cousin rule repo for dont_ask_again lookups
 	transactionHandler := httphandlers.NewTransactionHandler(transactionRepo, accountRepo, cousinRuleRepo)
+	transactionHandler.SetCousinService(cousinService)
+	transactionHandler.SetBillRepository(billRepo)
+	transactionHandler.SetUserRepository(userRepo)
+	transactionHandler.SetDuplicateCheckService(duplicateCheckService)
+	transactionHandler.SetRecheckDuplicatesOnEdit(cfg.OpenFinance.RecheckDuplicatesOnEdit)
+
+	// Initialize audit logging for transaction mutations
+	auditRepo := postgres.NewAuditRepository(db)
+	auditService := audit.NewService(auditRepo)
+	transactionHandler.SetAuditService(auditService)
+	auditHandler := httphandlers.NewAuditHandler(auditService)
+
+	// Initialize transaction attachments (files on local disk, references in Postgres)
+	attachmentStore, err := blobstore.NewLocalStore(cfg.Attachments.Dir)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	attachmentRepo := postgres.NewAttachmentRepository(db)
+	attachmentService := attachment.NewService(attachmentRepo, attachmentStore, transactionRepo, accountRepo, attachment.Limits{
+		MaxSize:           cfg.Attachments.MaxBytes,
+		MaxPerTransaction: cfg.Attachments.MaxPerTransaction,
+	})
+	attachmentHandler := httphandlers.NewAttachmentHandler(attachmentService)
+	transactionHandler.SetAttachmentService(attachmentService)
+	accountHandler.SetAttachmentService(attachmentService)
+	// Blobs of attachments removed with a user or item are only queued; purge them at startup
+	go func() {
+		if purged, err := attachmentService.PurgeDeletedBlobs(context.Background()); err != nil {
+			log.Printf("Error purging deleted attachment blobs: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted attachment blobs", purged)
+		}
+	}()
+
+	// Category mapping is static; its response is serialized once here
+	categoryHandler, err := httphandlers.NewCategoryHandler()
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
 
 	// Initialize forecast handler
 	forecastRepo := postgres.NewForecastRepository(db)
 	forecastHandler := httphandlers.NewForecastHandler(forecastRepo)
 
 	// Initialize and start cousin notification listener
-	cousinListener := listener.NewCousinListener(cfg.Database.ConnectionString(), cousinRuleRepo, db.DB)
+	cousinListener := listener.NewCousinListener(cfg.Database.ConnectionString(), cousinRuleService, db.DB)
 	cousinListener.Start(context.Background())
 
 	return &Dependencies{
 		DB:                     db,
+		HealthHandler:          httphandlers.NewHealthHandler(),
 		AuthHandler:            authHandler,
 		UserHandler:            userHandler,
 		AccountHandler:         accountHandler,
+		ItemHandler:            itemHandler,
 		TransactionHandler:     transactionHandler,
 		TagHandler:             tagHandler,
+		CousinHandler:          cousinHandler,
 		CousinRuleHandler:      cousinRuleHandler,
 		NotificationHandler:    notificationHandler,
+		CategoryHandler:        categoryHandler,
 		ForecastHandler:        forecastHandler,
+		AuditHandler:           auditHandler,
+		AttachmentHandler:      attachmentHandler,
 		JWT:                    jwt,
 		AuthCodeStore:          authCodeStore,
 		AccountSyncService:     accountSyncService,
 		TransactionSyncService: transactionSyncService,
 		BillSyncService:        billSyncService,
+		SyncLocker:             syncLocker,
+		LeaderLock:             leaderLock,
 		UserRepo:               userRepo,
 		CousinListener:         cousinListener,
 	}, nil
 }
 
+// newJWT builds the token signer from config. With RS256/ES256, JWT_SECRET (if still set)
+// keeps verifying the HS256 tokens issued before the switch; with HS256 and a key ID, the
+// secret also verifies the tokens issued before the key ID was set.
+func newJWT(cfg config.JWTConfig) (*auth.JWT, error) {
+	var primary auth.JWTKey
+	var previous []auth.JWTKey
+
+	if cfg.Algorithm == auth.AlgHS256 {
+		if cfg.KeyID == "" && len(cfg.VerifyKeys) == 0 {
+			return auth.NewJWT(cfg.Secret), nil
+		}
+		primary = auth.JWTKey{ID: cfg.KeyID, Algorithm: auth.AlgHS256, Secret: []byte(cfg.Secret)}
+		// Tokens issued before the key ID was set carry no kid; keep verifying them
+		if cfg.KeyID != "" {
+			previous = append(previous, auth.JWTKey{Algorithm: auth.AlgHS256, Secret: []byte(cfg.Secret)})
+		}
+	} else {
+		key, err := auth.LoadJWTKey(cfg.KeyID, cfg.Algorithm, cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		primary = key
+		if cfg.Secret != "" {
+			previous = append(previous, auth.JWTKey{Algorithm: auth.AlgHS256, Secret: []byte(cfg.Secret)})
+		}
+	}
+
+	for kid, path := range cfg.VerifyKeys {
+		key, err := auth.LoadJWTKey(kid, "", path)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+
+	jwt, err := auth.NewJWTWithKeys(primary, previous...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure JWT keys: %w", err)
+	}
+	return jwt, nil
+}
+
+// cookieSameSite maps the validated COOKIE_SAMESITE value to its http.SameSite mode.
+func cookieSameSite(mode string) http.SameSite {
+	switch mode {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
 // Close releases all resources held by dependencies.
 func (d *Dependencies) Close() {
 	if d.CousinListener != nil {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -10,6 +11,7 @@ import (
 
 	"parsa/internal/interfaces/scheduler"
 	"parsa/internal/shared/config"
+	"parsa/internal/shared/logging"
 	"parsa/internal/shared/telemetry"
 )
 
@@ -27,6 +29,12 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := logging.Setup(cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		return err
+	}
 
 	// Initialize telemetry if enabled
 	if cfg.Telemetry.Enabled {
@@ -55,6 +63,7 @@ func run() error {
 			return err
 		}
 		sched.Start()
+		deps.HealthHandler.SetScheduler(sched)
 		log.Println("Sync scheduler started (accounts + transactions)")
 	}
 
@@ -83,7 +92,7 @@ func setupScheduler(deps *Dependencies, cfg *config.Config) (*scheduler.Schedule
 
 		jobs := make([]scheduler.Job, 0, len(users))
 		for _, user := range users {
-			job := scheduler.NewUserSyncJob(user.ID, deps.AccountSyncService, deps.TransactionSyncService, deps.BillSyncService)
+			job := scheduler.NewUserSyncJob(user.ID, deps.AccountSyncService, deps.TransactionSyncService, deps.BillSyncService, deps.SyncLocker)
 			jobs = append(jobs, job)
 		}
 
@@ -91,12 +100,27 @@ func setupScheduler(deps *Dependencies, cfg *config.Config) (*scheduler.Schedule
 		return jobs, nil
 	}
 
-	return scheduler.NewScheduler(scheduler.SchedulerConfig{
-		ScheduleTimes: cfg.Scheduler.ScheduleTimes,
-		WorkerCount:   cfg.Scheduler.WorkerCount,
-		JobDelay:      cfg.Scheduler.JobDelay,
-		QueueSize:     cfg.Scheduler.QueueSize,
-		RunOnStartup:  cfg.Scheduler.RunOnStartup,
-		JobProvider:   jobProvider,
-	})
+	schedCfg := scheduler.SchedulerConfig{
+		ScheduleTimes:   cfg.Scheduler.ScheduleTimes,
+		WorkerCount:     cfg.Scheduler.WorkerCount,
+		JobDelay:        cfg.Scheduler.JobDelay,
+		QueueSize:       cfg.Scheduler.QueueSize,
+		QueueFullPolicy: scheduler.QueueFullPolicy(cfg.Scheduler.QueueFullPolicy),
+		RunOnStartup:    cfg.Scheduler.RunOnStartup,
+		JobProvider:     jobProvider,
+		StaleAfter:      cfg.Scheduler.StaleAfter,
+	}
+	if cfg.Telemetry.Enabled {
+		schedMetrics, err := telemetry.NewSchedulerMetrics()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scheduler metrics: %w", err)
+		}
+		schedCfg.Metrics = schedMetrics
+	}
+	// Only set the elector when enabled; a nil *LeaderLock in the interface would not be nil
+	if deps.LeaderLock != nil {
+		schedCfg.Elector = deps.LeaderLock
+	}
+
+	return scheduler.NewScheduler(schedCfg)
 }
@@ -21,7 +21,7 @@ func SetupRoutes(deps *Dependencies, cfg *config.Config) http.Handler {
 	mux.HandleFunc("/oauth-callback", httphandlers.HandleOAuthCallback)
 
 	// Health check
-	mux.HandleFunc("/health", httphandlers.HandleHealth)
+	mux.HandleFunc("/health", deps.HealthHandler.HandleHealth)
 
 	// Public auth routes
 	mux.HandleFunc("/api/auth/register", deps.AuthHandler.HandleRegister)
@@ -37,7 +37,8 @@ func SetupRoutes(deps *Dependencies, cfg *config.Config) http.Handler {
 	mux.HandleFunc("/api/auth/oauth/mobile/callback", deps.AuthHandler.HandleMobileAuthCallback)
 	mux.HandleFunc("/api/auth/oauth/mobile/exchange", deps.AuthHandler.HandleMobileAuthExchange)
 
-	// Apple OAuth (Mobile)
+	// Apple OAuth (Mobile). Registered even when Apple is not configured so the
+	// handlers answer with a JSON 503 instead of falling through to the "/" page.
 	mux.HandleFunc("/api/auth/oauth/apple/mobile/start", deps.AuthHandler.HandleAppleMobileAuthStart)
 	mux.HandleFunc("/api/auth/oauth/apple/mobile/callback", deps.AuthHandler.HandleAppleMobileAuthCallback)
 
@@ -45,26 +46,57 @@ func SetupRoutes(deps *Dependencies, cfg *config.Config) http.Handler {
 	authMiddleware := middleware.Auth(deps.JWT)
 
 	mux.Handle("/api/users/me", authMiddleware(http.HandlerFunc(deps.UserHandler.HandleMe)))
+	mux.Handle("/api/users/me/insights-start-date", authMiddleware(http.HandlerFunc(deps.UserHandler.HandleInsightsStartDate)))
+	mux.Handle("/api/provider-key", authMiddleware(http.HandlerFunc(deps.UserHandler.HandleProviderKey)))
 	mux.Handle("/api/accounts/", authMiddleware(http.HandlerFunc(deps.AccountHandler.HandleListAccounts)))
+	mux.Handle("/api/accounts/summary", authMiddleware(http.HandlerFunc(deps.AccountHandler.HandleAccountSummary)))
 	mux.Handle("/api/accounts/remove/{id}", authMiddleware(http.HandlerFunc(deps.AccountHandler.HandleRemoveAccount)))
 	mux.Handle("/api/accounts/restore/{id}", authMiddleware(http.HandlerFunc(deps.AccountHandler.HandleRestoreAccount)))
 	mux.Handle("/api/accounts/delete-bank/{id}", authMiddleware(http.HandlerFunc(deps.AccountHandler.HandleDeleteBank)))
 	mux.Handle("/api/accounts/{id}", authMiddleware(http.HandlerFunc(deps.AccountHandler.HandleAccountByID)))
+	mux.Handle("/api/accounts/{id}/{resource}", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleListAccountTransactions)))
+	mux.Handle("/api/items/", authMiddleware(http.HandlerFunc(deps.ItemHandler.HandleListItems)))
+	mux.Handle("/api/items/{id}", authMiddleware(http.HandlerFunc(deps.ItemHandler.HandleItemByID)))
 	mux.Handle("/api/transactions/", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleListTransactions)))
 	mux.Handle("/api/transactions/update", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleBatchTransactions)))
+	mux.Handle("/api/transactions/reconsider", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleReconsider)))
+	mux.Handle("/api/transactions/recategorize", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleRecategorize)))
+	mux.Handle("/api/transactions/merchants", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleDescriptionSuggestions)))
+	mux.Handle("/api/transactions/counts", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleTransactionCounts)))
+	mux.Handle("/api/transactions/trend", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleTransactionTrend)))
 	mux.Handle("/api/transactions/{id}", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleGetTransaction)))
+	mux.Handle("/api/transactions/{id}/cousin", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleTransactionCousin)))
+	mux.Handle("/api/transactions/{id}/considered", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleTransactionConsidered)))
+	mux.Handle("/api/transactions/{id}/transfer", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleMarkTransfer)))
+	mux.Handle("/api/transactions/{id}/duplicates", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleTransactionDuplicates)))
+	mux.Handle("/api/transactions/{id}/history", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleTransactionHistory)))
+	mux.Handle("/api/bills/{id}/matches", authMiddleware(http.HandlerFunc(deps.TransactionHandler.HandleBillMatches)))
+	mux.Handle("/api/transactions/{id}/attachments", authMiddleware(http.HandlerFunc(deps.AttachmentHandler.HandleTransactionAttachments)))
+	mux.Handle("/api/transactions/{id}/attachments/{attachmentId}", authMiddleware(http.HandlerFunc(deps.AttachmentHandler.HandleAttachmentByID)))
+	mux.Handle("/api/categories", authMiddleware(http.HandlerFunc(deps.CategoryHandler.HandleListCategories)))
 	mux.Handle("/api/tags/", authMiddleware(http.HandlerFunc(deps.TagHandler.HandleTags)))
 	mux.Handle("/api/tags/{id}", authMiddleware(http.HandlerFunc(deps.TagHandler.HandleTagByID)))
 	mux.Handle("/api/forecasts/{uuid}", authMiddleware(http.HandlerFunc(deps.ForecastHandler.HandleForecastByUUID)))
 	mux.Handle("/api/forecasts/", authMiddleware(http.HandlerFunc(deps.ForecastHandler.HandleForecasts)))
+	mux.Handle("/api/cousins/", authMiddleware(http.HandlerFunc(deps.CousinHandler.HandleCousins)))
+	mux.Handle("/api/cousins/{id}", authMiddleware(http.HandlerFunc(deps.CousinHandler.HandleCousinByID)))
+	mux.Handle("/api/cousins/{id}/transactions", authMiddleware(http.HandlerFunc(deps.CousinHandler.HandleCousinTransactions)))
 	mux.Handle("/api/cousin-rules/", authMiddleware(http.HandlerFunc(deps.CousinRuleHandler.HandleCousinRules)))
+	mux.Handle("/api/cousin-rules/export", authMiddleware(http.HandlerFunc(deps.CousinRuleHandler.HandleExportRules)))
+	mux.Handle("/api/cousin-rules/import", authMiddleware(http.HandlerFunc(deps.CousinRuleHandler.HandleImportRules)))
 	mux.Handle("/api/cousin-rules/{id}", authMiddleware(http.HandlerFunc(deps.CousinRuleHandler.HandleCousinRuleByID)))
+	mux.Handle("/api/cousin-rules/{cousinId}/{action}", authMiddleware(http.HandlerFunc(deps.CousinRuleHandler.HandleCousinRuleAction)))
 	mux.Handle("/api/notifications/register-device/", authMiddleware(http.HandlerFunc(deps.NotificationHandler.HandleRegisterDevice)))
 	mux.Handle("/api/notifications/preferences/", authMiddleware(http.HandlerFunc(deps.NotificationHandler.HandlePreferences)))
 	mux.Handle("/api/notifications/open/", authMiddleware(http.HandlerFunc(deps.NotificationHandler.HandleOpen)))
 	mux.Handle("/api/notifications/{id}", authMiddleware(http.HandlerFunc(deps.NotificationHandler.HandleNotificationByID)))
 	mux.Handle("/api/notifications/", authMiddleware(http.HandlerFunc(deps.NotificationHandler.HandleNotifications)))
 
+	// Admin routes (authenticated + email in ADMIN_EMAILS)
+	adminMiddleware := middleware.RequireAdmin(cfg.Admin.Emails)
+
+	mux.Handle("/api/admin/audit", authMiddleware(adminMiddleware(http.HandlerFunc(deps.AuditHandler.HandleListAudit))))
+
 	// Apply global middleware
 	handler := middleware.Logging(middleware.CORS(cfg.Server.AllowedHosts)(mux))
 
@@ -79,5 +111,8 @@ func SetupRoutes(deps *Dependencies, cfg *config.Config) http.Handler {
 		handler = telemetry.Middleware(handler)
 	}
 
+	// Recover from handler panics (outermost, so it also covers the middleware above)
+	handler = middleware.Recover(handler)
+
 	return handler
 }
@@ -72,9 +72,9 @@ func GracefulShutdown(srv, redirectSrv *http.Server, sched *scheduler.Scheduler,
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// Shutdown scheduler if running
+	// Let in-flight sync jobs finish before the deadline
 	if sched != nil {
-		sched.Shutdown(timeout)
+		sched.Stop(ctx)
 	}
 
 	// Shutdown HTTP redirect server if running
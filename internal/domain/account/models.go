@@ -2,7 +2,10 @@ package account
 
 import (
 	"errors"
+	"math"
 	"time"
+
+	"parsa/internal/models"
 )
 
 var (
@@ -27,6 +30,9 @@ var (
 	}
 )
 
+// DefaultCurrency is used for accounts created without a currency
+const DefaultCurrency = "BRL"
+
 // Domain errors
 var (
 	ErrInvalidAccountType    = errors.New("invalid account type")
@@ -38,6 +44,7 @@ var (
 	ErrAccountAlreadyRemoved = errors.New("account is already removed")
 	ErrAccountNotRemoved     = errors.New("account is not removed")
 	ErrAccountNoItem         = errors.New("account has no associated item")
+	ErrItemNotFound          = errors.New("item not found")
 )
 
 // Account represents a financial account domain entity
@@ -46,8 +53,11 @@ type Account struct {
 	UserID               int64     `json:"userId"`
 	ItemID               string    `json:"itemId"`
 	Name                 string    `json:"name"`
+	MarketingName        string    `json:"marketingName"`          // Provider's display name, empty when not reported
+	ProviderCode         string    `json:"providerCode,omitempty"` // Provider's institution code
 	AccountType          string    `json:"accountType"`
-	Subtype              string    `json:"subtype"`
+	Subtype              string    `json:"subtype"`                   // Canonical subtype
+	ProviderSubtype      string    `json:"providerSubtype,omitempty"` // Provider's subtype before normalization
 	Currency             string    `json:"currency"`
 	Balance              float64   `json:"balance"`
 	BankID               int64     `json:"bankId"`
@@ -71,6 +81,17 @@ type AccountWithBank struct {
 	BankUIName       string `json:"bankUIName"`
 	BankConnector    string `json:"bankConnector"`
 	BankPrimaryColor string `json:"bankPrimaryColor"`
+	BankLogoURL      string `json:"bankLogoUrl"`
+
+	// ConsideredBalance is the initial balance plus the signed amounts of the account's
+	// considered, not removed transactions: credits add and debits subtract
+	ConsideredBalance float64 `json:"consideredBalance"`
+}
+
+// ItemWithAccounts groups a bank connection (item) with the accounts it holds
+type ItemWithAccounts struct {
+	models.ItemSummary
+	Accounts []*AccountWithBank `json:"accounts"`
 }
 
 // CreateParams contains parameters for creating a new account
@@ -128,8 +149,11 @@ type UpsertParams struct {
 	UserID               int64
 	ItemID               string
 	Name                 string
+	MarketingName        string // Empty is stored as NULL
+	ProviderCode         string // Empty is stored as NULL
 	AccountType          string
 	Subtype              *string
+	ProviderSubtype      string // Empty is stored as NULL; UpsertAccount fills it from Subtype
 	Currency             string
 	Balance              float64
 	BankID               *int64
@@ -189,3 +213,64 @@ func IsValidCurrency(c string) bool {
 	_, ok := validCurrencies[c]
 	return ok
 }
+
+// SummaryGroup aggregates accounts sharing the same type, subtype and currency.
+// Balance is signed: liabilities (credit accounts) are reported as negative values.
+type SummaryGroup struct {
+	AccountType string  `json:"accountType"`
+	Subtype     string  `json:"subtype"`
+	Currency    string  `json:"currency"`
+	Count       int     `json:"count"`
+	Balance     float64 `json:"balance"`
+}
+
+// CurrencyTotal holds the net totals for all summarized accounts in a currency
+type CurrencyTotal struct {
+	Currency    string  `json:"currency"`
+	Count       int     `json:"count"`
+	Assets      float64 `json:"assets"`
+	Liabilities float64 `json:"liabilities"`
+	NetWorth    float64 `json:"netWorth"`
+}
+
+// Summary is the grouped balance overview for a user's accounts
+type Summary struct {
+	Groups []SummaryGroup  `json:"groups"`
+	Totals []CurrencyTotal `json:"totals"`
+}
+
+// SummaryOptions controls which accounts are included in a summary
+type SummaryOptions struct {
+	IncludeHidden  bool
+	IncludeRemoved bool
+}
+
+// AccountMerge folds a duplicate account into the canonical account it matches on name, type
+// and subtype. Transactions is the number of transactions on the duplicate when planned and the
+// number moved once applied; Bills is only known once applied.
+type AccountMerge struct {
+	Canonical    *Account
+	Duplicate    *Account
+	Transactions int64
+	Bills        int64
+}
+
+// MergeCounts are the rows MergeInto moved from the duplicate to the canonical account
+type MergeCounts struct {
+	Transactions int64
+	Bills        int64
+}
+
+// IsLiability reports whether the account's balance represents money owed
+func (a *Account) IsLiability() bool {
+	return a.AccountType == "CREDIT" || a.Subtype == "CREDIT_CARD"
+}
+
+// SignedBalance returns the balance with liabilities expressed as negative values.
+// Providers report credit card balances as positive amounts owed.
+func (a *Account) SignedBalance() float64 {
+	if a.IsLiability() {
+		return -math.Abs(a.Balance)
+	}
+	return a.Balance
+}
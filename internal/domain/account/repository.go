@@ -17,6 +17,9 @@ type Repository interface {
 	// ListByUserIDWithBank retrieves all accounts for a specific user with bank data (JOIN)
 	ListByUserIDWithBank(ctx context.Context, userID int64) ([]*AccountWithBank, error)
 
+	// GetByIDWithBank retrieves an account by its ID with bank data (JOIN)
+	GetByIDWithBank(ctx context.Context, id string) (*AccountWithBank, error)
+
 	// Delete removes an account
 	Delete(ctx context.Context, id string) error
 
@@ -50,6 +53,22 @@ type Repository interface {
 	// ListByItemID retrieves all accounts belonging to an item
 	ListByItemID(ctx context.Context, itemID string) ([]*Account, error)
 
+	// MarkRemoved sets removed_at (and closed_at when unset) on the given accounts that are
+	// not already removed, flagging them as removed by the provider. Returns the number of
+	// accounts marked.
+	MarkRemoved(ctx context.Context, ids []string) (int64, error)
+
+	// RestoreProviderRemoved clears removed_at (and the closed_at MarkRemoved set) on an
+	// account that MarkRemoved removed, leaving accounts the user removed alone. Reports
+	// whether the account was restored.
+	RestoreProviderRemoved(ctx context.Context, id string) (bool, error)
+
+	// MergeInto atomically moves the transactions, bills and forecasts of account duplicateID to
+	// account canonicalID, copies the user-set fields canonicalID still has at their defaults
+	// (description, order, hidden_by_user, initial_balance) and deletes duplicateID. Both
+	// accounts must belong to the same user.
+	MergeInto(ctx context.Context, duplicateID, canonicalID string) (MergeCounts, error)
+
 	// DeleteBankData atomically deletes all transactions for the item's accounts,
 	// deletes the accounts, and soft-deletes the item in a single transaction.
 	DeleteBankData(ctx context.Context, itemID string) error
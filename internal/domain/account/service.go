@@ -3,9 +3,12 @@ package account
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 
 	"parsa/internal/domain/transaction"
 	"parsa/internal/models"
+	"parsa/internal/shared/money"
 )
 
 // Service contains the business logic for account operations
@@ -13,6 +16,9 @@ type Service struct {
 	repo            Repository
 	itemRepo        models.ItemRepository
 	transactionRepo transaction.Repository
+	subtypeAliases  map[string]string
+	syncedList      []string            // as configured, before normalization
+	syncedSubtypes  map[string]struct{} // nil syncs every subtype
 }
 
 // NewService creates a new account service
@@ -21,6 +27,33 @@ func NewService(repo Repository, itemRepo models.ItemRepository, transactionRepo
 		repo:            repo,
 		itemRepo:        itemRepo,
 		transactionRepo: transactionRepo,
+		subtypeAliases:  mergeSubtypeAliases(nil),
+	}
+}
+
+// SetSubtypeAliases adds provider subtype aliases on top of DefaultSubtypeAliases, replacing
+// defaults with the same provider value
+func (s *Service) SetSubtypeAliases(overrides map[string]string) {
+	s.subtypeAliases = mergeSubtypeAliases(overrides)
+	s.indexSyncedSubtypes()
+}
+
+// SetSyncedSubtypes limits provider sync to accounts whose canonical subtype is listed.
+// Accounts without a subtype are then left out too; empty (the default) syncs every account.
+func (s *Service) SetSyncedSubtypes(subtypes []string) {
+	s.syncedList = subtypes
+	s.indexSyncedSubtypes()
+}
+
+// indexSyncedSubtypes rebuilds the synced subtype set with the current aliases
+func (s *Service) indexSyncedSubtypes() {
+	s.syncedSubtypes = nil
+	if len(s.syncedList) == 0 {
+		return
+	}
+	s.syncedSubtypes = make(map[string]struct{}, len(s.syncedList))
+	for _, subtype := range s.syncedList {
+		s.syncedSubtypes[s.NormalizeSubtype(subtype)] = struct{}{}
 	}
 }
 
@@ -28,7 +61,7 @@ func NewService(repo Repository, itemRepo models.ItemRepository, transactionRepo
 func (s *Service) CreateAccount(ctx context.Context, params CreateParams) (*Account, error) {
 	// Apply default currency if not provided
 	if params.Currency == "" {
-		params.Currency = "BRL"
+		params.Currency = DefaultCurrency
 	}
 
 	// Validate parameters
@@ -59,6 +92,21 @@ func (s *Service) GetAccount(ctx context.Context, accountID string, userID int64
 	return account, nil
 }
 
+// GetAccountWithBank retrieves an account with its bank data and verifies user ownership.
+// Accounts of other users are reported as ErrAccountNotFound so their IDs are not disclosed.
+func (s *Service) GetAccountWithBank(ctx context.Context, accountID string, userID int64) (*AccountWithBank, error) {
+	account, err := s.repo.GetByIDWithBank(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.UserID != userID {
+		return nil, ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
 // ListAccountsByUserID retrieves all accounts for a specific user
 func (s *Service) ListAccountsByUserID(ctx context.Context, userID int64) ([]*Account, error) {
 	if userID <= 0 {
@@ -77,6 +125,169 @@ func (s *Service) ListAccountsWithBankByUserID(ctx context.Context, userID int64
 	return s.repo.ListByUserIDWithBank(ctx, userID)
 }
 
+// ListItemsWithAccounts returns the user's bank connections with their accounts nested.
+// Accounts without an item are not included since they don't belong to any connection.
+func (s *Service) ListItemsWithAccounts(ctx context.Context, userID int64) ([]*ItemWithAccounts, error) {
+	if userID <= 0 {
+		return nil, errors.New("valid user ID is required")
+	}
+
+	summaries, err := s.itemRepo.ListSummariesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.repo.ListByUserIDWithBank(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byItem := make(map[string][]*AccountWithBank)
+	for _, acc := range accounts {
+		if acc.ItemID == "" {
+			continue
+		}
+		byItem[acc.ItemID] = append(byItem[acc.ItemID], acc)
+	}
+
+	items := make([]*ItemWithAccounts, 0, len(summaries))
+	for _, summary := range summaries {
+		itemAccounts := byItem[summary.ID]
+		if itemAccounts == nil {
+			itemAccounts = []*AccountWithBank{}
+		}
+		items = append(items, &ItemWithAccounts{
+			ItemSummary: *summary,
+			Accounts:    itemAccounts,
+		})
+	}
+
+	return items, nil
+}
+
+// GetItemWithAccounts returns one of the user's bank connections with its accounts nested,
+// shaped like the entries of ListItemsWithAccounts
+func (s *Service) GetItemWithAccounts(ctx context.Context, itemID string, userID int64) (*ItemWithAccounts, error) {
+	items, err := s.ListItemsWithAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == itemID {
+			return item, nil
+		}
+	}
+	return nil, ErrItemNotFound
+}
+
+// UpdateItem changes an item's reconnect/disabled flags after verifying ownership
+func (s *Service) UpdateItem(ctx context.Context, itemID string, params models.UpdateItemParams, userID int64) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || item.DeletedAt != nil {
+		return nil, ErrItemNotFound
+	}
+
+	// Business rule: verify ownership
+	if item.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	return s.itemRepo.Update(ctx, itemID, params)
+}
+
+// ListSyncExcludedItemIDs returns the IDs of the user's items whose accounts must be
+// skipped during sync (disabled or waiting for the user to reconnect)
+func (s *Service) ListSyncExcludedItemIDs(ctx context.Context, userID int64) (map[string]struct{}, error) {
+	items, err := s.itemRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]struct{})
+	for _, item := range items {
+		if item.ExcludedFromSync() {
+			excluded[item.ID] = struct{}{}
+		}
+	}
+
+	return excluded, nil
+}
+
+// FlagItemsNeedReconnect marks or clears needs_reconnect on all of the user's items
+func (s *Service) FlagItemsNeedReconnect(ctx context.Context, userID int64, value bool) error {
+	return s.itemRepo.SetNeedsReconnectByUserID(ctx, userID, value)
+}
+
+// GetAccountSummary groups a user's account balances by type, subtype and currency.
+// Hidden and removed accounts are excluded unless requested through opts.
+// Totals are reported per currency since accounts can be held in different currencies,
+// and are summed in cents so they do not drift.
+func (s *Service) GetAccountSummary(ctx context.Context, userID int64, opts SummaryOptions) (*Summary, error) {
+	if userID <= 0 {
+		return nil, errors.New("valid user ID is required")
+	}
+
+	accounts, err := s.repo.ListByUserIDWithBank(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type groupKey struct {
+		accountType, subtype, currency string
+	}
+
+	groupIndex := make(map[groupKey]int)
+	totalIndex := make(map[string]int)
+	summary := &Summary{
+		Groups: []SummaryGroup{},
+		Totals: []CurrencyTotal{},
+	}
+
+	for _, acc := range accounts {
+		if acc.HiddenByUser && !opts.IncludeHidden {
+			continue
+		}
+		if acc.RemovedAt != nil && !opts.IncludeRemoved {
+			continue
+		}
+
+		balance := acc.SignedBalance()
+
+		key := groupKey{acc.AccountType, acc.Subtype, acc.Currency}
+		gi, ok := groupIndex[key]
+		if !ok {
+			gi = len(summary.Groups)
+			groupIndex[key] = gi
+			summary.Groups = append(summary.Groups, SummaryGroup{
+				AccountType: acc.AccountType,
+				Subtype:     acc.Subtype,
+				Currency:    acc.Currency,
+			})
+		}
+		summary.Groups[gi].Count++
+		summary.Groups[gi].Balance = money.Add(summary.Groups[gi].Balance, balance)
+
+		ti, ok := totalIndex[acc.Currency]
+		if !ok {
+			ti = len(summary.Totals)
+			totalIndex[acc.Currency] = ti
+			summary.Totals = append(summary.Totals, CurrencyTotal{Currency: acc.Currency})
+		}
+		summary.Totals[ti].Count++
+		if balance < 0 {
+			summary.Totals[ti].Liabilities = money.Add(summary.Totals[ti].Liabilities, balance)
+		} else {
+			summary.Totals[ti].Assets = money.Add(summary.Totals[ti].Assets, balance)
+		}
+		summary.Totals[ti].NetWorth = money.Add(summary.Totals[ti].NetWorth, balance)
+	}
+
+	return summary, nil
+}
+
 // DeleteAccount deletes an account after verifying ownership
 func (s *Service) DeleteAccount(ctx context.Context, accountID string, userID int64) error {
 	// Verify ownership before deletion
@@ -107,11 +318,20 @@ func (s *Service) UpdateAccount(ctx context.Context, accountID string, params Up
 	return s.repo.Update(ctx, accountID, params)
 }
 
-// UpsertAccount creates or updates an account with validation
+// UpsertAccount creates or updates an account with validation. The subtype is normalized to
+// its canonical value (see SetSubtypeAliases) and the provider's value kept in ProviderSubtype.
 func (s *Service) UpsertAccount(ctx context.Context, params UpsertParams) (*Account, error) {
 	// Apply default currency if not provided
 	if params.Currency == "" {
-		params.Currency = "BRL"
+		params.Currency = DefaultCurrency
+	}
+
+	if params.Subtype != nil {
+		if params.ProviderSubtype == "" {
+			params.ProviderSubtype = *params.Subtype
+		}
+		subtype := s.NormalizeSubtype(*params.Subtype)
+		params.Subtype = &subtype
 	}
 
 	// Validate parameters
@@ -122,6 +342,21 @@ func (s *Service) UpsertAccount(ctx context.Context, params UpsertParams) (*Acco
 	return s.repo.Upsert(ctx, params)
 }
 
+// NormalizeSubtype returns the canonical subtype a provider subtype is stored as
+func (s *Service) NormalizeSubtype(subtype string) string {
+	return normalizeSubtype(s.subtypeAliases, subtype)
+}
+
+// SyncsSubtype reports whether provider accounts with subtype are synced (see
+// SetSyncedSubtypes). An empty subtype only passes when there is no allowlist.
+func (s *Service) SyncsSubtype(subtype string) bool {
+	if s.syncedSubtypes == nil {
+		return true
+	}
+	_, ok := s.syncedSubtypes[s.NormalizeSubtype(subtype)]
+	return ok
+}
+
 // GetAccountByID retrieves an account by ID without ownership check (for internal/sync use)
 func (s *Service) GetAccountByID(ctx context.Context, accountID string) (*Account, error) {
 	return s.repo.GetByID(ctx, accountID)
@@ -135,9 +370,81 @@ func (s *Service) AccountExists(ctx context.Context, accountID string) (bool, er
 // FindAccountByMatch finds an account by matching criteria
 // Note: This method does not validate account type/subtype to allow matching
 // against accounts that may have been synced from external APIs with types
-// not in our predefined list. Validation only occurs during create/upsert.
+// not in our predefined list. Validation only occurs during create/upsert. The subtype is
+// normalized like UpsertAccount stores it.
 func (s *Service) FindAccountByMatch(ctx context.Context, userID int64, name, accountType, subtype string) (*Account, error) {
-	return s.repo.FindByMatch(ctx, userID, name, accountType, subtype)
+	return s.repo.FindByMatch(ctx, userID, name, accountType, s.NormalizeSubtype(subtype))
+}
+
+// PlanAccountMerges finds the user's provider-synced accounts that match on name, type and
+// subtype, as FindByMatch does, which sync creates when a provider account ID changes. Removed
+// accounts are candidates too, since sync marks the account under the old provider ID removed.
+// In each group the most recently updated account that is not removed is canonical, since sync
+// still writes to it, and every other account is a duplicate to merge into it. Groups where
+// every account is removed and manual accounts are ignored.
+func (s *Service) PlanAccountMerges(ctx context.Context, userID int64) ([]AccountMerge, error) {
+	accounts, err := s.repo.ListByUserIDWithBank(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type matchKey struct {
+		name, accountType, subtype string
+	}
+
+	groups := make(map[matchKey][]*Account)
+	var order []matchKey
+	for _, acc := range accounts {
+		if acc.ItemID == "" {
+			continue
+		}
+		key := matchKey{acc.Name, acc.AccountType, acc.Subtype}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], &acc.Account)
+	}
+
+	var merges []AccountMerge
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			if removedI, removedJ := group[i].RemovedAt != nil, group[j].RemovedAt != nil; removedI != removedJ {
+				return removedJ
+			}
+			if !group[i].UpdatedAt.Equal(group[j].UpdatedAt) {
+				return group[i].UpdatedAt.After(group[j].UpdatedAt)
+			}
+			return group[i].ID < group[j].ID
+		})
+		if group[0].RemovedAt != nil {
+			continue
+		}
+		for _, duplicate := range group[1:] {
+			count, err := s.transactionRepo.CountByAccountID(ctx, duplicate.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count transactions of account %s: %w", duplicate.ID, err)
+			}
+			merges = append(merges, AccountMerge{Canonical: group[0], Duplicate: duplicate, Transactions: count})
+		}
+	}
+
+	return merges, nil
+}
+
+// ApplyAccountMerge moves the duplicate's transactions and bills to the canonical account and
+// deletes the duplicate in one database transaction, updating the counts in merge
+func (s *Service) ApplyAccountMerge(ctx context.Context, merge *AccountMerge) error {
+	counts, err := s.repo.MergeInto(ctx, merge.Duplicate.ID, merge.Canonical.ID)
+	if err != nil {
+		return err
+	}
+	merge.Transactions = counts.Transactions
+	merge.Bills = counts.Bills
+	return nil
 }
 
 // UpdateAccountBankID updates the bank ID for an account
@@ -178,6 +485,43 @@ func (s *Service) RestoreAccount(ctx context.Context, accountID string, userID i
 	return s.repo.Restore(ctx, accountID)
 }
 
+// ReconcileItemAccounts marks the user's accounts under itemID as removed when they are
+// missing from presentIDs (the provider's current account IDs for that item).
+// Accounts are never hard-deleted so historical transactions stay intact.
+func (s *Service) ReconcileItemAccounts(ctx context.Context, userID int64, itemID string, presentIDs map[string]struct{}) (int64, error) {
+	if itemID == "" {
+		return 0, ErrAccountNoItem
+	}
+
+	stored, err := s.repo.ListByItemID(ctx, itemID)
+	if err != nil {
+		return 0, err
+	}
+
+	var missing []string
+	for _, acc := range stored {
+		if acc.UserID != userID || acc.RemovedAt != nil {
+			continue
+		}
+		if _, ok := presentIDs[acc.ID]; !ok {
+			missing = append(missing, acc.ID)
+		}
+	}
+
+	if len(missing) == 0 {
+		return 0, nil
+	}
+
+	return s.repo.MarkRemoved(ctx, missing)
+}
+
+// RestoreProviderRemovedAccount restores an account that ReconcileItemAccounts removed because
+// the provider stopped reporting it. Accounts the user removed stay removed. Reports whether
+// the account was restored.
+func (s *Service) RestoreProviderRemovedAccount(ctx context.Context, accountID string) (bool, error) {
+	return s.repo.RestoreProviderRemoved(ctx, accountID)
+}
+
 // DeleteBank deletes all accounts and their transactions for the bank connection (item)
 // associated with the given account, then soft-deletes the item itself.
 // All destructive operations run inside a single database transaction.
@@ -19,6 +19,18 @@ func (noopItemRepo) FindOrCreate(ctx context.Context, id string, userID int64) (
 func (noopItemRepo) ListByUserID(ctx context.Context, userID int64) ([]*models.Item, error) {
 	return nil, nil
 }
+func (noopItemRepo) ListSummariesByUserID(ctx context.Context, userID int64) ([]*models.ItemSummary, error) {
+	return nil, nil
+}
+func (noopItemRepo) GetByID(ctx context.Context, id string) (*models.Item, error) {
+	return nil, nil
+}
+func (noopItemRepo) Update(ctx context.Context, id string, params models.UpdateItemParams) (*models.Item, error) {
+	return nil, nil
+}
+func (noopItemRepo) SetNeedsReconnectByUserID(ctx context.Context, userID int64, value bool) error {
+	return nil
+}
 func (noopItemRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
@@ -44,6 +56,42 @@ func (noopTransactionRepo) ListByUserID(ctx context.Context, userID int64, limit
 func (noopTransactionRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
 	return 0, nil
 }
+func (noopTransactionRepo) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) ListByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) Recategorize(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	return false, nil
+}
+func (noopTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (noopTransactionRepo) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (noopTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	return nil
+}
+func (noopTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return 0, nil
+}
 func (noopTransactionRepo) Update(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
 	return nil, nil
 }
@@ -69,6 +117,33 @@ func (noopTransactionRepo) GetTransactionTags(ctx context.Context, transactionID
 	return nil, nil
 }
 
+func (noopTransactionRepo) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	return nil
+}
+
+func (noopTransactionRepo) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) CountGroups(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	return 0, nil
+}
+
 func newTestService(repo Repository) *Service {
 	return NewService(repo, noopItemRepo{}, noopTransactionRepo{})
 }
@@ -79,6 +154,7 @@ type MockRepository struct {
 	GetByIDFunc                func(ctx context.Context, id string) (*Account, error)
 	ListByUserIDFunc           func(ctx context.Context, userID int64) ([]*Account, error)
 	ListByUserIDWithBankFunc   func(ctx context.Context, userID int64) ([]*AccountWithBank, error)
+	GetByIDWithBankFunc        func(ctx context.Context, id string) (*AccountWithBank, error)
 	DeleteFunc                 func(ctx context.Context, id string) error
 	UpdateFunc                 func(ctx context.Context, id string, params UpdateParams) (*Account, error)
 	UpsertFunc                 func(ctx context.Context, params UpsertParams) (*Account, error)
@@ -90,6 +166,9 @@ type MockRepository struct {
 	RestoreFunc                func(ctx context.Context, id string) error
 	DeleteByItemIDFunc         func(ctx context.Context, itemID string) error
 	ListByItemIDFunc           func(ctx context.Context, itemID string) ([]*Account, error)
+	MarkRemovedFunc            func(ctx context.Context, ids []string) (int64, error)
+	RestoreProviderRemovedFunc func(ctx context.Context, id string) (bool, error)
+	MergeIntoFunc              func(ctx context.Context, duplicateID, canonicalID string) (MergeCounts, error)
 	DeleteBankDataFunc         func(ctx context.Context, itemID string) error
 }
 
@@ -171,6 +250,13 @@ func (m *MockRepository) ListByUserIDWithBank(ctx context.Context, userID int64)
 	return nil, nil
 }
 
+func (m *MockRepository) GetByIDWithBank(ctx context.Context, id string) (*AccountWithBank, error) {
+	if m.GetByIDWithBankFunc != nil {
+		return m.GetByIDWithBankFunc(ctx, id)
+	}
+	return nil, nil
+}
+
 func (m *MockRepository) SoftRemove(ctx context.Context, id string) error {
 	if m.SoftRemoveFunc != nil {
 		return m.SoftRemoveFunc(ctx, id)
@@ -199,6 +285,26 @@ func (m *MockRepository) ListByItemID(ctx context.Context, itemID string) ([]*Ac
 	return nil, nil
 }
 
+func (m *MockRepository) MarkRemoved(ctx context.Context, ids []string) (int64, error) {
+	if m.MarkRemovedFunc != nil {
+		return m.MarkRemovedFunc(ctx, ids)
+	}
+	return 0, nil
+}
+
+func (m *MockRepository) RestoreProviderRemoved(ctx context.Context, id string) (bool, error) {
+	if m.RestoreProviderRemovedFunc != nil {
+		return m.RestoreProviderRemovedFunc(ctx, id)
+	}
+	return false, nil
+}
+
+func (m *MockRepository) MergeInto(ctx context.Context, duplicateID, canonicalID string) (MergeCounts, error) {
+	if m.MergeIntoFunc != nil {
+		return m.MergeIntoFunc(ctx, duplicateID, canonicalID)
+	}
+	return MergeCounts{}, nil
+}
 func (m *MockRepository) DeleteBankData(ctx context.Context, itemID string) error {
 	if m.DeleteBankDataFunc != nil {
 		return m.DeleteBankDataFunc(ctx, itemID)
@@ -663,6 +769,29 @@ func TestUpsertAccount(t *testing.T) {
 			wantErr: true,
 			errType: ErrInvalidAccountType,
 		},
+		{
+			name: "Provider Subtype Normalized",
+			params: UpsertParams{
+				ID:          "acc-123",
+				UserID:      1,
+				Name:        "Test Account",
+				AccountType: "BANK",
+				Currency:    "BRL",
+				Subtype:     strPtr("CONTA_POUPANCA"),
+			},
+			mock: func() *MockRepository {
+				return &MockRepository{
+					UpsertFunc: func(ctx context.Context, params UpsertParams) (*Account, error) {
+						if *params.Subtype != "SAVINGS_ACCOUNT" || params.ProviderSubtype != "CONTA_POUPANCA" {
+							t.Errorf("Subtype = %s, ProviderSubtype = %s, want SAVINGS_ACCOUNT from CONTA_POUPANCA",
+								*params.Subtype, params.ProviderSubtype)
+						}
+						return &Account{ID: params.ID}, nil
+					},
+				}
+			},
+			wantErr: false,
+		},
 		{
 			name: "Invalid Subtype",
 			params: UpsertParams{
@@ -708,3 +837,155 @@ func TestUpsertAccount(t *testing.T) {
 }
 
 func strPtr(s string) *string { return &s }
+
+func TestGetAccountSummary(t *testing.T) {
+	ctx := context.Background()
+	removedAt := time.Now()
+
+	repo := &MockRepository{
+		ListByUserIDWithBankFunc: func(ctx context.Context, userID int64) ([]*AccountWithBank, error) {
+			return []*AccountWithBank{
+				{Account: Account{ID: "acc-1", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", Currency: "BRL", Balance: 1000}},
+				{Account: Account{ID: "acc-2", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", Currency: "BRL", Balance: 500}},
+				{Account: Account{ID: "acc-3", AccountType: "CREDIT", Subtype: "CREDIT_CARD", Currency: "BRL", Balance: 300}},
+				{Account: Account{ID: "acc-4", AccountType: "BANK", Subtype: "SAVINGS_ACCOUNT", Currency: "USD", Balance: 200}},
+				{Account: Account{ID: "acc-5", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", Currency: "BRL", Balance: 50, HiddenByUser: true}},
+				{Account: Account{ID: "acc-6", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", Currency: "BRL", Balance: 70, RemovedAt: &removedAt}},
+			}, nil
+		},
+	}
+	service := newTestService(repo)
+
+	tests := []struct {
+		name        string
+		opts        SummaryOptions
+		wantGroups  int
+		wantBRLNet  float64
+		wantBRLLiab float64
+		wantBRLSize int
+	}{
+		{
+			name:        "Default excludes hidden and removed",
+			opts:        SummaryOptions{},
+			wantGroups:  3,
+			wantBRLNet:  1200,
+			wantBRLLiab: -300,
+			wantBRLSize: 3,
+		},
+		{
+			name:        "Include hidden and removed",
+			opts:        SummaryOptions{IncludeHidden: true, IncludeRemoved: true},
+			wantGroups:  3,
+			wantBRLNet:  1320,
+			wantBRLLiab: -300,
+			wantBRLSize: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := service.GetAccountSummary(ctx, 1, tt.opts)
+			if err != nil {
+				t.Fatalf("GetAccountSummary() unexpected error: %v", err)
+			}
+			if len(summary.Groups) != tt.wantGroups {
+				t.Errorf("GetAccountSummary() got %d groups, want %d", len(summary.Groups), tt.wantGroups)
+			}
+			if len(summary.Totals) != 2 {
+				t.Fatalf("GetAccountSummary() got %d currency totals, want 2", len(summary.Totals))
+			}
+			brl := summary.Totals[0]
+			if brl.Currency != "BRL" {
+				t.Fatalf("GetAccountSummary() first total currency = %s, want BRL", brl.Currency)
+			}
+			if brl.NetWorth != tt.wantBRLNet {
+				t.Errorf("GetAccountSummary() BRL net worth = %v, want %v", brl.NetWorth, tt.wantBRLNet)
+			}
+			if brl.Liabilities != tt.wantBRLLiab {
+				t.Errorf("GetAccountSummary() BRL liabilities = %v, want %v", brl.Liabilities, tt.wantBRLLiab)
+			}
+			if brl.Count != tt.wantBRLSize {
+				t.Errorf("GetAccountSummary() BRL count = %d, want %d", brl.Count, tt.wantBRLSize)
+			}
+		})
+	}
+
+	if _, err := service.GetAccountSummary(ctx, 0, SummaryOptions{}); err == nil {
+		t.Errorf("GetAccountSummary() expected error for invalid user ID, got nil")
+	}
+}
+
+func TestGetAccountSummary_SumsInCents(t *testing.T) {
+	repo := &MockRepository{
+		ListByUserIDWithBankFunc: func(ctx context.Context, userID int64) ([]*AccountWithBank, error) {
+			return []*AccountWithBank{
+				{Account: Account{ID: "acc-1", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", Currency: "BRL", Balance: 10.10}},
+				{Account: Account{ID: "acc-2", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", Currency: "BRL", Balance: 0.20}},
+				{Account: Account{ID: "acc-3", AccountType: "CREDIT", Subtype: "CREDIT_CARD", Currency: "BRL", Balance: 0.10}},
+			}, nil
+		},
+	}
+	service := newTestService(repo)
+
+	summary, err := service.GetAccountSummary(context.Background(), 1, SummaryOptions{})
+	if err != nil {
+		t.Fatalf("GetAccountSummary() unexpected error: %v", err)
+	}
+	if got := summary.Groups[0].Balance; got != 10.30 {
+		t.Errorf("GetAccountSummary() checking balance = %v, want 10.30", got)
+	}
+	if got := summary.Totals[0].Assets; got != 10.30 {
+		t.Errorf("GetAccountSummary() assets = %v, want 10.30", got)
+	}
+	if got := summary.Totals[0].NetWorth; got != 10.20 {
+		t.Errorf("GetAccountSummary() net worth = %v, want 10.20", got)
+	}
+}
+
+func TestPlanAccountMerges(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(24 * time.Hour)
+	removedAt := newer
+
+	repo := &MockRepository{
+		ListByUserIDWithBankFunc: func(ctx context.Context, userID int64) ([]*AccountWithBank, error) {
+			return []*AccountWithBank{
+				{Account: Account{ID: "old", ItemID: "item-1", Name: "Conta", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", UpdatedAt: older}},
+				{Account: Account{ID: "new", ItemID: "item-2", Name: "Conta", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", UpdatedAt: newer}},
+				{Account: Account{ID: "card", ItemID: "item-1", Name: "Conta", AccountType: "CREDIT", Subtype: "CREDIT_CARD", UpdatedAt: older}},
+				{Account: Account{ID: "manual", Name: "Conta", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", UpdatedAt: older}},
+				{Account: Account{ID: "gone", ItemID: "item-1", Name: "Conta", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT", UpdatedAt: newer.Add(time.Hour), RemovedAt: &removedAt}},
+				{Account: Account{ID: "closed-1", ItemID: "item-1", Name: "Poupança", AccountType: "BANK", Subtype: "SAVINGS_ACCOUNT", UpdatedAt: older, RemovedAt: &removedAt}},
+				{Account: Account{ID: "closed-2", ItemID: "item-2", Name: "Poupança", AccountType: "BANK", Subtype: "SAVINGS_ACCOUNT", UpdatedAt: newer, RemovedAt: &removedAt}},
+			}, nil
+		},
+	}
+	service := newTestService(repo)
+
+	merges, err := service.PlanAccountMerges(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("PlanAccountMerges() unexpected error: %v", err)
+	}
+	if len(merges) != 2 {
+		t.Fatalf("PlanAccountMerges() = %d merges, want 2 (manual accounts and all-removed groups ignored)", len(merges))
+	}
+	if merges[0].Canonical.ID != "new" || merges[0].Duplicate.ID != "old" {
+		t.Errorf("PlanAccountMerges() merges %s into %s, want old into new", merges[0].Duplicate.ID, merges[0].Canonical.ID)
+	}
+	if merges[1].Canonical.ID != "new" || merges[1].Duplicate.ID != "gone" {
+		t.Errorf("PlanAccountMerges() merges %s into %s, want removed gone into new", merges[1].Duplicate.ID, merges[1].Canonical.ID)
+	}
+
+	repo.MergeIntoFunc = func(ctx context.Context, duplicateID, canonicalID string) (MergeCounts, error) {
+		if duplicateID != "old" || canonicalID != "new" {
+			t.Errorf("MergeInto(%s, %s), want (old, new)", duplicateID, canonicalID)
+		}
+		return MergeCounts{Transactions: 12, Bills: 2}, nil
+	}
+	if err := service.ApplyAccountMerge(context.Background(), &merges[0]); err != nil {
+		t.Fatalf("ApplyAccountMerge() unexpected error: %v", err)
+	}
+	if merges[0].Transactions != 12 || merges[0].Bills != 2 {
+		t.Errorf("ApplyAccountMerge() counts = %d transactions, %d bills, want 12 and 2", merges[0].Transactions, merges[0].Bills)
+	}
+}
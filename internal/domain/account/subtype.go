@@ -0,0 +1,43 @@
+package account
+
+import "strings"
+
+// DefaultSubtypeAliases maps provider account subtypes to the canonical subtypes Parsa
+// classifies balances by (CHECKING_ACCOUNT, SAVINGS_ACCOUNT, CREDIT_CARD). Canonical values
+// map to themselves without an entry.
+var DefaultSubtypeAliases = map[string]string{
+	"CHECKING":                 "CHECKING_ACCOUNT",
+	"CONTA_CORRENTE":           "CHECKING_ACCOUNT",
+	"CONTA_DEPOSITO_A_VISTA":   "CHECKING_ACCOUNT",
+	"CONTA_PAGAMENTO_PRE_PAGA": "CHECKING_ACCOUNT",
+	"SAVINGS":                  "SAVINGS_ACCOUNT",
+	"CONTA_POUPANCA":           "SAVINGS_ACCOUNT",
+	"CARTAO_CREDITO":           "CREDIT_CARD",
+	"CARTAO_DE_CREDITO":        "CREDIT_CARD",
+}
+
+// mergeSubtypeAliases returns DefaultSubtypeAliases with overrides applied, keyed the way
+// normalizeSubtype looks them up
+func mergeSubtypeAliases(overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(DefaultSubtypeAliases)+len(overrides))
+	for _, aliases := range []map[string]string{DefaultSubtypeAliases, overrides} {
+		for provider, canonical := range aliases {
+			merged[subtypeKey(provider)] = subtypeKey(canonical)
+		}
+	}
+	return merged
+}
+
+func subtypeKey(subtype string) string {
+	return strings.ToUpper(strings.TrimSpace(subtype))
+}
+
+// normalizeSubtype returns the canonical subtype for a provider value, or the value itself
+// (trimmed and upper-cased) when it has no alias
+func normalizeSubtype(aliases map[string]string, subtype string) string {
+	key := subtypeKey(subtype)
+	if canonical, ok := aliases[key]; ok {
+		return canonical
+	}
+	return key
+}
@@ -0,0 +1,66 @@
+package account
+
+import "testing"
+
+func TestNormalizeSubtype(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		subtype   string
+		want      string
+	}{
+		{name: "canonical subtype", subtype: "CREDIT_CARD", want: "CREDIT_CARD"},
+		{name: "default alias", subtype: "CONTA_DEPOSITO_A_VISTA", want: "CHECKING_ACCOUNT"},
+		{name: "case and spaces", subtype: " conta_poupanca ", want: "SAVINGS_ACCOUNT"},
+		{name: "unknown subtype kept", subtype: "Investment", want: "INVESTMENT"},
+		{name: "empty subtype", subtype: "", want: ""},
+		{
+			name:      "override adds an alias",
+			overrides: map[string]string{"conta_salario": "checking_account"},
+			subtype:   "CONTA_SALARIO",
+			want:      "CHECKING_ACCOUNT",
+		},
+		{
+			name:      "override replaces a default",
+			overrides: map[string]string{"CONTA_PAGAMENTO_PRE_PAGA": "SAVINGS_ACCOUNT"},
+			subtype:   "CONTA_PAGAMENTO_PRE_PAGA",
+			want:      "SAVINGS_ACCOUNT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(&MockRepository{}, nil, nil)
+			service.SetSubtypeAliases(tt.overrides)
+			if got := service.NormalizeSubtype(tt.subtype); got != tt.want {
+				t.Errorf("NormalizeSubtype(%q) = %q, want %q", tt.subtype, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncsSubtype(t *testing.T) {
+	service := NewService(&MockRepository{}, nil, nil)
+	if !service.SyncsSubtype("") {
+		t.Error("SyncsSubtype(\"\") = false without an allowlist, want every account synced")
+	}
+
+	// Aliases set after the allowlist still apply to it
+	service.SetSyncedSubtypes([]string{"conta_salario", "CREDIT_CARD"})
+	service.SetSubtypeAliases(map[string]string{"CONTA_SALARIO": "CHECKING_ACCOUNT"})
+
+	tests := []struct {
+		subtype string
+		want    bool
+	}{
+		{subtype: "CONTA_CORRENTE", want: true},
+		{subtype: "CARTAO_CREDITO", want: true},
+		{subtype: "SAVINGS_ACCOUNT", want: false},
+		{subtype: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := service.SyncsSubtype(tt.subtype); got != tt.want {
+			t.Errorf("SyncsSubtype(%q) = %v, want %v", tt.subtype, got, tt.want)
+		}
+	}
+}
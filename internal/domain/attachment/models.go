@@ -0,0 +1,76 @@
+package attachment
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrForbidden          = errors.New("forbidden: attachment does not belong to user")
+	ErrTooManyAttachments = errors.New("transaction has reached the attachment limit")
+	ErrFileTooLarge       = errors.New("file exceeds the maximum attachment size")
+	ErrUnsupportedType    = errors.New("unsupported attachment type")
+	ErrEmptyFile          = errors.New("file is empty")
+	ErrBlobNotFound       = errors.New("blob not found")
+)
+
+const (
+	// DefaultMaxSize is the largest file accepted when no limit is configured
+	DefaultMaxSize int64 = 10 << 20 // 10 MiB
+	// DefaultMaxPerTransaction is the attachment cap per transaction when none is configured
+	DefaultMaxPerTransaction = 10
+	// maxFilenameLength matches the filename column
+	maxFilenameLength = 255
+	// purgeBatchSize is how many deleted blobs PurgeDeletedBlobs handles per query
+	purgeBatchSize = 100
+)
+
+// allowedContentTypes maps the accepted (sniffed) content types to the extension used in storage keys
+var allowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// Attachment is a file (usually a receipt) attached to a transaction. The content lives
+// in the blob store under StorageKey; only this reference is stored in the database.
+type Attachment struct {
+	ID            int64     `json:"id"`
+	TransactionID string    `json:"transactionId"`
+	UserID        int64     `json:"-"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"contentType"`
+	SizeBytes     int64     `json:"sizeBytes"`
+	StorageKey    string    `json:"-"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// CreateAttachmentParams contains the parameters for recording an uploaded attachment
+type CreateAttachmentParams struct {
+	TransactionID     string
+	UserID            int64
+	Filename          string
+	ContentType       string
+	SizeBytes         int64
+	StorageKey        string
+	MaxPerTransaction int // Create fails with ErrTooManyAttachments once the transaction has this many
+}
+
+// Limits bounds the attachments a user can upload. Zero values use the defaults.
+type Limits struct {
+	MaxSize           int64
+	MaxPerTransaction int
+}
+
+// withDefaults fills unset limits with the package defaults
+func (l Limits) withDefaults() Limits {
+	if l.MaxSize <= 0 {
+		l.MaxSize = DefaultMaxSize
+	}
+	if l.MaxPerTransaction <= 0 {
+		l.MaxPerTransaction = DefaultMaxPerTransaction
+	}
+	return l
+}
@@ -0,0 +1,46 @@
+package attachment
+
+import (
+	"context"
+	"io"
+)
+
+// Repository defines the interface for attachment data access
+type Repository interface {
+	// Create records an attachment whose content is already in the blob store. The count
+	// against params.MaxPerTransaction and the insert are atomic; returns ErrTooManyAttachments
+	// when the transaction is at the cap.
+	Create(ctx context.Context, params CreateAttachmentParams) (*Attachment, error)
+
+	// GetByID returns an attachment by its ID, or nil if it does not exist
+	GetByID(ctx context.Context, id int64) (*Attachment, error)
+
+	// ListByTransactionID returns a transaction's attachments, oldest first
+	ListByTransactionID(ctx context.Context, transactionID string) ([]*Attachment, error)
+
+	// CountByTransactionID returns how many attachments a transaction has
+	CountByTransactionID(ctx context.Context, transactionID string) (int, error)
+
+	// Delete deletes an attachment record
+	Delete(ctx context.Context, id int64) error
+
+	// ListDeletedBlobKeys returns up to limit storage keys of deleted attachments, including
+	// ones removed by a cascade, whose blobs may still exist
+	ListDeletedBlobKeys(ctx context.Context, limit int) ([]string, error)
+
+	// ForgetDeletedBlobKeys removes storage keys whose blobs have been deleted from the queue
+	ForgetDeletedBlobKeys(ctx context.Context, keys []string) error
+}
+
+// BlobStore stores attachment content by key. Implementations may be backed by local
+// disk or an object store such as S3.
+type BlobStore interface {
+	// Put stores the content read from r under key and returns the number of bytes written
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+
+	// Get opens the content stored under key. Returns ErrBlobNotFound if it does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the content stored under key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
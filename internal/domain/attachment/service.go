@@ -0,0 +1,294 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/transaction"
+)
+
+// sniffLen is how many leading bytes are read to detect the content type
+const sniffLen = 512
+
+// Service handles business logic for transaction attachments
+type Service struct {
+	repo            Repository
+	blobs           BlobStore
+	transactionRepo transaction.Repository
+	accountRepo     account.Repository
+	limits          Limits
+}
+
+// NewService creates a new attachment service
+func NewService(repo Repository, blobs BlobStore, transactionRepo transaction.Repository, accountRepo account.Repository, limits Limits) *Service {
+	return &Service{
+		repo:            repo,
+		blobs:           blobs,
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		limits:          limits.withDefaults(),
+	}
+}
+
+// MaxSize returns the largest file Upload accepts
+func (s *Service) MaxSize() int64 {
+	return s.limits.MaxSize
+}
+
+// Upload stores the content read from r as an attachment of one of the user's transactions.
+// The content type is detected from the content itself (the client's claim is ignored) and
+// must be one of the allowed image/PDF types.
+func (s *Service) Upload(ctx context.Context, userID int64, transactionID, filename string, r io.Reader) (*Attachment, error) {
+	if _, err := s.ownedTransaction(ctx, userID, transactionID); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	if count >= s.limits.MaxPerTransaction {
+		return nil, ErrTooManyAttachments
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	contentType := detectContentType(head)
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	key, err := newStorageKey(userID, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read one byte past the limit so oversized files are detected without buffering them
+	content := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.limits.MaxSize+1)
+	size, err := s.blobs.Put(ctx, key, content)
+	if err != nil {
+		s.deleteBlob(ctx, key)
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if size > s.limits.MaxSize {
+		s.deleteBlob(ctx, key)
+		return nil, ErrFileTooLarge
+	}
+
+	// Create checks the cap again atomically; the count above only avoids storing blobs for
+	// transactions that are already full
+	att, err := s.repo.Create(ctx, CreateAttachmentParams{
+		TransactionID:     transactionID,
+		UserID:            userID,
+		Filename:          sanitizeFilename(filename, ext),
+		ContentType:       contentType,
+		SizeBytes:         size,
+		StorageKey:        key,
+		MaxPerTransaction: s.limits.MaxPerTransaction,
+	})
+	if err != nil {
+		s.deleteBlob(ctx, key)
+		if errors.Is(err, ErrTooManyAttachments) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return att, nil
+}
+
+// ListAttachments returns the attachments of one of the user's transactions
+func (s *Service) ListAttachments(ctx context.Context, userID int64, transactionID string) ([]*Attachment, error) {
+	if _, err := s.ownedTransaction(ctx, userID, transactionID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repo.ListByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns an attachment of a transaction, verifying ownership
+func (s *Service) GetAttachment(ctx context.Context, userID int64, transactionID string, id int64) (*Attachment, error) {
+	att, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if att == nil || att.TransactionID != transactionID {
+		return nil, ErrAttachmentNotFound
+	}
+	if att.UserID != userID {
+		return nil, ErrForbidden
+	}
+	return att, nil
+}
+
+// OpenAttachment returns an attachment and a reader over its content. The caller must
+// close the reader.
+func (s *Service) OpenAttachment(ctx context.Context, userID int64, transactionID string, id int64) (*Attachment, io.ReadCloser, error) {
+	att, err := s.GetAttachment(ctx, userID, transactionID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.blobs.Get(ctx, att.StorageKey)
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return att, content, nil
+}
+
+// DeleteAttachment deletes an attachment and its content, verifying ownership
+func (s *Service) DeleteAttachment(ctx context.Context, userID int64, transactionID string, id int64) error {
+	att, err := s.GetAttachment(ctx, userID, transactionID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, att.ID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	if s.deleteBlob(ctx, att.StorageKey) {
+		if err := s.repo.ForgetDeletedBlobKeys(context.WithoutCancel(ctx), []string{att.StorageKey}); err != nil {
+			log.Printf("Failed to forget deleted attachment blob %s: %v", att.StorageKey, err)
+		}
+	}
+	return nil
+}
+
+// PurgeDeletedBlobs deletes the blobs of attachments whose records were deleted, including
+// the ones removed along with their transaction, account or user. Blobs that fail to delete
+// stay queued for the next run. Returns how many blobs were deleted.
+func (s *Service) PurgeDeletedBlobs(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		keys, err := s.repo.ListDeletedBlobKeys(ctx, purgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list deleted attachment blobs: %w", err)
+		}
+
+		deleted := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if s.deleteBlob(ctx, key) {
+				deleted = append(deleted, key)
+			}
+		}
+		if err := s.repo.ForgetDeletedBlobKeys(ctx, deleted); err != nil {
+			return purged, fmt.Errorf("failed to forget deleted attachment blobs: %w", err)
+		}
+		purged += len(deleted)
+
+		// Stop on a short batch, or when failures would make the next batch repeat them
+		if len(keys) < purgeBatchSize || len(deleted) < len(keys) {
+			return purged, nil
+		}
+	}
+}
+
+// deleteBlob removes stored content that no record points to and reports whether it did.
+// Failures only leave an orphaned blob behind, so they are logged rather than returned. The
+// request's cancellation is ignored so a client disconnect doesn't skip the cleanup.
+func (s *Service) deleteBlob(ctx context.Context, key string) bool {
+	if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Printf("Failed to delete attachment blob %s: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// ownedTransaction returns the transaction if it belongs to one of the user's accounts.
+// Transactions of other users are reported as not found.
+func (s *Service) ownedTransaction(ctx context.Context, userID int64, id string) (*transaction.Transaction, error) {
+	txn, err := s.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if txn == nil {
+		return nil, transaction.ErrTransactionNotFound
+	}
+
+	acc, err := s.accountRepo.GetByID(ctx, txn.AccountID)
+	if errors.Is(err, account.ErrAccountNotFound) {
+		return nil, transaction.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil || acc.UserID != userID {
+		return nil, transaction.ErrTransactionNotFound
+	}
+
+	return txn, nil
+}
+
+// detectContentType identifies the allowed attachment types by their magic numbers.
+// Anything else is reported as application/octet-stream.
+func detectContentType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\xFF\xD8\xFF")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1A\n")):
+		return "image/png"
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return "image/webp"
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// newStorageKey returns a random, unguessable blob key grouped by user
+func newStorageKey(userID int64, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate storage key: %w", err)
+	}
+	return fmt.Sprintf("%d/%s%s", userID, hex.EncodeToString(b), ext), nil
+}
+
+// sanitizeFilename keeps the base name of a client-supplied filename without control
+// characters, truncated to the column size. Empty names fall back to "attachment<ext>".
+func sanitizeFilename(name, ext string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == ".." {
+		return "attachment" + ext
+	}
+	for len(name) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,503 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/transaction"
+)
+
+// MockAttachmentRepo implements Repository as an in-memory store. Deleting a record queues
+// its storage key in DeletedKeys, as the database trigger does.
+type MockAttachmentRepo struct {
+	Attachments map[int64]*Attachment
+	DeletedKeys []string
+	CreateErr   error
+	StaleCount  bool // CountByTransactionID reports 0, as if another upload raced this one
+	nextID      int64
+}
+
+func newMockAttachmentRepo(existing ...*Attachment) *MockAttachmentRepo {
+	m := &MockAttachmentRepo{Attachments: make(map[int64]*Attachment)}
+	for _, a := range existing {
+		m.Attachments[a.ID] = a
+		m.nextID = max(m.nextID, a.ID)
+	}
+	return m
+}
+
+func (m *MockAttachmentRepo) Create(ctx context.Context, params CreateAttachmentParams) (*Attachment, error) {
+	if m.CreateErr != nil {
+		return nil, m.CreateErr
+	}
+	if params.MaxPerTransaction > 0 {
+		if attachments, _ := m.ListByTransactionID(ctx, params.TransactionID); len(attachments) >= params.MaxPerTransaction {
+			return nil, ErrTooManyAttachments
+		}
+	}
+	m.nextID++
+	a := &Attachment{
+		ID:            m.nextID,
+		TransactionID: params.TransactionID,
+		UserID:        params.UserID,
+		Filename:      params.Filename,
+		ContentType:   params.ContentType,
+		SizeBytes:     params.SizeBytes,
+		StorageKey:    params.StorageKey,
+		CreatedAt:     time.Now(),
+	}
+	m.Attachments[a.ID] = a
+	return a, nil
+}
+
+func (m *MockAttachmentRepo) GetByID(ctx context.Context, id int64) (*Attachment, error) {
+	return m.Attachments[id], nil
+}
+
+func (m *MockAttachmentRepo) ListByTransactionID(ctx context.Context, transactionID string) ([]*Attachment, error) {
+	var attachments []*Attachment
+	for _, a := range m.Attachments {
+		if a.TransactionID == transactionID {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments, nil
+}
+
+func (m *MockAttachmentRepo) CountByTransactionID(ctx context.Context, transactionID string) (int, error) {
+	if m.StaleCount {
+		return 0, nil
+	}
+	attachments, _ := m.ListByTransactionID(ctx, transactionID)
+	return len(attachments), nil
+}
+
+func (m *MockAttachmentRepo) Delete(ctx context.Context, id int64) error {
+	a, ok := m.Attachments[id]
+	if !ok {
+		return ErrAttachmentNotFound
+	}
+	delete(m.Attachments, id)
+	m.DeletedKeys = append(m.DeletedKeys, a.StorageKey)
+	return nil
+}
+
+func (m *MockAttachmentRepo) ListDeletedBlobKeys(ctx context.Context, limit int) ([]string, error) {
+	return m.DeletedKeys[:min(limit, len(m.DeletedKeys))], nil
+}
+
+func (m *MockAttachmentRepo) ForgetDeletedBlobKeys(ctx context.Context, keys []string) error {
+	m.DeletedKeys = slices.DeleteFunc(m.DeletedKeys, func(key string) bool {
+		return slices.Contains(keys, key)
+	})
+	return nil
+}
+
+// memoryBlobStore implements BlobStore in memory
+type memoryBlobStore struct {
+	blobs map[string][]byte
+}
+
+func newMemoryBlobStore() *memoryBlobStore {
+	return &memoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memoryBlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	s.blobs[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *memoryBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+// MockTransactionRepo implements transaction.Repository for testing; the attachment service
+// only looks transactions up
+type MockTransactionRepo struct {
+	transaction.Repository
+	GetByIDFunc func(ctx context.Context, id string) (*transaction.Transaction, error)
+}
+
+func (m *MockTransactionRepo) GetByID(ctx context.Context, id string) (*transaction.Transaction, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+// MockAccountRepo implements account.Repository for testing
+type MockAccountRepo struct {
+	GetByIDFunc func(ctx context.Context, id string) (*account.Account, error)
+}
+
+func (m *MockAccountRepo) Create(ctx context.Context, params account.CreateParams) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) GetByID(ctx context.Context, id string) (*account.Account, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (m *MockAccountRepo) ListByUserID(ctx context.Context, userID int64) ([]*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) ListByUserIDWithBank(ctx context.Context, userID int64) ([]*account.AccountWithBank, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) GetByIDWithBank(ctx context.Context, id string) (*account.AccountWithBank, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) Delete(ctx context.Context, id string) error { return nil }
+func (m *MockAccountRepo) Update(ctx context.Context, id string, params account.UpdateParams) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) Upsert(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) Exists(ctx context.Context, id string) (bool, error) { return false, nil }
+func (m *MockAccountRepo) FindByMatch(ctx context.Context, userID int64, name, accountType, subtype string) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) UpdateBankID(ctx context.Context, accountID string, bankID int64) error {
+	return nil
+}
+func (m *MockAccountRepo) GetBalanceSumBySubtype(ctx context.Context, userID int64, subtypes []string) (float64, error) {
+	return 0, nil
+}
+func (m *MockAccountRepo) SoftRemove(ctx context.Context, id string) error         { return nil }
+func (m *MockAccountRepo) Restore(ctx context.Context, id string) error            { return nil }
+func (m *MockAccountRepo) DeleteByItemID(ctx context.Context, itemID string) error { return nil }
+func (m *MockAccountRepo) ListByItemID(ctx context.Context, itemID string) ([]*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) MarkRemoved(ctx context.Context, ids []string) (int64, error) {
+	return 0, nil
+}
+func (m *MockAccountRepo) RestoreProviderRemoved(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+func (m *MockAccountRepo) MergeInto(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error) {
+	return account.MergeCounts{}, nil
+}
+func (m *MockAccountRepo) DeleteBankData(ctx context.Context, itemID string) error { return nil }
+
+var (
+	pngHeader = "\x89PNG\r\n\x1A\n"
+	pdfHeader = "%PDF-1.7\n"
+)
+
+// newAttachmentFixture returns a service where transaction "t1" (account "a1") belongs to
+// user 1 and "t2" (account "a2") belongs to user 2
+func newAttachmentFixture(repo *MockAttachmentRepo, blobs *memoryBlobStore, limits Limits) *Service {
+	txns := map[string]*transaction.Transaction{
+		"t1": {ID: "t1", AccountID: "a1"},
+		"t2": {ID: "t2", AccountID: "a2"},
+	}
+	owners := map[string]int64{"a1": 1, "a2": 2}
+
+	txRepo := &MockTransactionRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+			return txns[id], nil
+		},
+	}
+	accRepo := &MockAccountRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+			userID, ok := owners[id]
+			if !ok {
+				return nil, account.ErrAccountNotFound
+			}
+			return &account.Account{ID: id, UserID: userID}, nil
+		},
+	}
+	return NewService(repo, blobs, txRepo, accRepo, limits)
+}
+
+func TestUpload(t *testing.T) {
+	tests := []struct {
+		name            string
+		transactionID   string
+		filename        string
+		content         string
+		existing        int
+		staleCount      bool
+		limits          Limits
+		createErr       error
+		wantErr         error
+		wantContentType string
+		wantFilename    string
+	}{
+		{
+			name:            "Stores PNG receipt",
+			transactionID:   "t1",
+			filename:        "receipt.png",
+			content:         pngHeader + "image data",
+			wantContentType: "image/png",
+			wantFilename:    "receipt.png",
+		},
+		{
+			name:            "Detects type from content, not name",
+			transactionID:   "t1",
+			filename:        "../../scan.txt",
+			content:         pdfHeader + "pdf data",
+			wantContentType: "application/pdf",
+			wantFilename:    "scan.txt",
+		},
+		{
+			name:            "Falls back to a default name",
+			transactionID:   "t1",
+			filename:        "  ",
+			content:         "\xFF\xD8\xFF\xE0 jpeg data",
+			wantContentType: "image/jpeg",
+			wantFilename:    "attachment.jpg",
+		},
+		{
+			name:          "Rejects unsupported type",
+			transactionID: "t1",
+			filename:      "page.html",
+			content:       "<html></html>",
+			wantErr:       ErrUnsupportedType,
+		},
+		{
+			name:          "Rejects empty file",
+			transactionID: "t1",
+			filename:      "empty.png",
+			wantErr:       ErrEmptyFile,
+		},
+		{
+			name:          "Rejects file over the size limit",
+			transactionID: "t1",
+			filename:      "big.png",
+			content:       pngHeader + strings.Repeat("x", 100),
+			limits:        Limits{MaxSize: 50},
+			wantErr:       ErrFileTooLarge,
+		},
+		{
+			name:          "Rejects upload past the per-transaction cap",
+			transactionID: "t1",
+			filename:      "receipt.png",
+			content:       pngHeader,
+			existing:      2,
+			limits:        Limits{MaxPerTransaction: 2},
+			wantErr:       ErrTooManyAttachments,
+		},
+		{
+			name:          "Rejects upload that reaches the cap after the count",
+			transactionID: "t1",
+			filename:      "receipt.png",
+			content:       pngHeader,
+			existing:      2,
+			staleCount:    true,
+			limits:        Limits{MaxPerTransaction: 2},
+			wantErr:       ErrTooManyAttachments,
+		},
+		{
+			name:          "Other user's transaction is not found",
+			transactionID: "t2",
+			filename:      "receipt.png",
+			content:       pngHeader,
+			wantErr:       transaction.ErrTransactionNotFound,
+		},
+		{
+			name:          "Missing transaction is not found",
+			transactionID: "t9",
+			filename:      "receipt.png",
+			content:       pngHeader,
+			wantErr:       transaction.ErrTransactionNotFound,
+		},
+		{
+			name:          "Removes the blob when the record cannot be created",
+			transactionID: "t1",
+			filename:      "receipt.png",
+			content:       pngHeader,
+			createErr:     errors.New("db down"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockAttachmentRepo()
+			for i := 0; i < tt.existing; i++ {
+				repo.Create(context.Background(), CreateAttachmentParams{TransactionID: tt.transactionID})
+			}
+			repo.CreateErr = tt.createErr
+			repo.StaleCount = tt.staleCount
+			blobs := newMemoryBlobStore()
+			svc := newAttachmentFixture(repo, blobs, tt.limits)
+
+			got, err := svc.Upload(context.Background(), 1, tt.transactionID, tt.filename, strings.NewReader(tt.content))
+
+			if tt.wantErr != nil || tt.createErr != nil {
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("Upload() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil {
+					t.Error("Upload() expected error, got nil")
+				}
+				if len(blobs.blobs) != 0 {
+					t.Errorf("Upload() left %d blobs behind after failing", len(blobs.blobs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upload() unexpected error: %v", err)
+			}
+			if got.ContentType != tt.wantContentType {
+				t.Errorf("ContentType = %q, want %q", got.ContentType, tt.wantContentType)
+			}
+			if got.Filename != tt.wantFilename {
+				t.Errorf("Filename = %q, want %q", got.Filename, tt.wantFilename)
+			}
+			if got.SizeBytes != int64(len(tt.content)) {
+				t.Errorf("SizeBytes = %d, want %d", got.SizeBytes, len(tt.content))
+			}
+			if !strings.HasPrefix(got.StorageKey, "1/") {
+				t.Errorf("StorageKey = %q, want it under the user's prefix", got.StorageKey)
+			}
+			if stored := string(blobs.blobs[got.StorageKey]); stored != tt.content {
+				t.Errorf("stored content = %q, want %q", stored, tt.content)
+			}
+		})
+	}
+}
+
+func TestOpenAttachment(t *testing.T) {
+	repo := newMockAttachmentRepo(
+		&Attachment{ID: 1, TransactionID: "t1", UserID: 1, StorageKey: "1/a.png"},
+		&Attachment{ID: 2, TransactionID: "t2", UserID: 2, StorageKey: "2/b.png"},
+		&Attachment{ID: 3, TransactionID: "t1", UserID: 1, StorageKey: "1/missing.png"},
+	)
+	blobs := newMemoryBlobStore()
+	blobs.blobs["1/a.png"] = []byte("receipt")
+	blobs.blobs["2/b.png"] = []byte("other")
+	svc := newAttachmentFixture(repo, blobs, Limits{})
+
+	tests := []struct {
+		name          string
+		transactionID string
+		attachmentID  int64
+		wantErr       error
+		wantContent   string
+	}{
+		{name: "Streams own attachment", transactionID: "t1", attachmentID: 1, wantContent: "receipt"},
+		{name: "Other user's attachment is forbidden", transactionID: "t2", attachmentID: 2, wantErr: ErrForbidden},
+		{name: "Attachment of another transaction is not found", transactionID: "t2", attachmentID: 1, wantErr: ErrAttachmentNotFound},
+		{name: "Unknown attachment is not found", transactionID: "t1", attachmentID: 9, wantErr: ErrAttachmentNotFound},
+		{name: "Missing blob is not found", transactionID: "t1", attachmentID: 3, wantErr: ErrAttachmentNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, content, err := svc.OpenAttachment(context.Background(), 1, tt.transactionID, tt.attachmentID)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("OpenAttachment() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenAttachment() unexpected error: %v", err)
+			}
+			defer content.Close()
+			data, _ := io.ReadAll(content)
+			if string(data) != tt.wantContent {
+				t.Errorf("content = %q, want %q", data, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	repo := newMockAttachmentRepo(&Attachment{ID: 1, TransactionID: "t1", UserID: 1, StorageKey: "1/a.png"})
+	blobs := newMemoryBlobStore()
+	blobs.blobs["1/a.png"] = []byte("receipt")
+	svc := newAttachmentFixture(repo, blobs, Limits{})
+
+	if err := svc.DeleteAttachment(context.Background(), 2, "t1", 1); !errors.Is(err, ErrForbidden) {
+		t.Errorf("DeleteAttachment() by another user error = %v, want %v", err, ErrForbidden)
+	}
+	if err := svc.DeleteAttachment(context.Background(), 1, "t1", 1); err != nil {
+		t.Fatalf("DeleteAttachment() unexpected error: %v", err)
+	}
+	if _, ok := repo.Attachments[1]; ok {
+		t.Error("DeleteAttachment() kept the record")
+	}
+	if _, ok := blobs.blobs["1/a.png"]; ok {
+		t.Error("DeleteAttachment() kept the blob")
+	}
+	if len(repo.DeletedKeys) != 0 {
+		t.Errorf("DeleteAttachment() left %v queued", repo.DeletedKeys)
+	}
+}
+
+func TestPurgeDeletedBlobs(t *testing.T) {
+	repo := newMockAttachmentRepo()
+	blobs := newMemoryBlobStore()
+	// Records removed by a cascade: only their keys are queued
+	for i := range purgeBatchSize + 1 {
+		key := fmt.Sprintf("1/%d.png", i)
+		blobs.blobs[key] = []byte("receipt")
+		repo.DeletedKeys = append(repo.DeletedKeys, key)
+	}
+	blobs.blobs["1/kept.png"] = []byte("still attached")
+	svc := newAttachmentFixture(repo, blobs, Limits{})
+
+	purged, err := svc.PurgeDeletedBlobs(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeDeletedBlobs() unexpected error: %v", err)
+	}
+	if purged != purgeBatchSize+1 {
+		t.Errorf("PurgeDeletedBlobs() = %d, want %d", purged, purgeBatchSize+1)
+	}
+	if len(repo.DeletedKeys) != 0 {
+		t.Errorf("PurgeDeletedBlobs() left %d keys queued", len(repo.DeletedKeys))
+	}
+	if len(blobs.blobs) != 1 || blobs.blobs["1/kept.png"] == nil {
+		t.Errorf("PurgeDeletedBlobs() left blobs %v, want only the attached one", slices.Collect(maps.Keys(blobs.blobs)))
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "receipt.pdf", want: "receipt.pdf"},
+		{name: "unix path", in: "/home/user/receipt.pdf", want: "receipt.pdf"},
+		{name: "windows path", in: `C:\Users\me\receipt.pdf`, want: "receipt.pdf"},
+		{name: "control characters", in: "re\x00ce\nipt.pdf", want: "receipt.pdf"},
+		{name: "dot dot", in: "..", want: "attachment.pdf"},
+		{name: "truncated", in: strings.Repeat("a", 300), want: strings.Repeat("a", maxFilenameLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeFilename(tt.in, ".pdf"); got != tt.want {
+				t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
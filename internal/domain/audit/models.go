@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"errors"
+	"time"
+
+	"parsa/internal/domain/transaction"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreate = "CREATE"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+)
+
+// Sources of the audited changes
+const (
+	SourceUser      = "user"      // The user's own edit
+	SourceRule      = "rule"      // A cousin rule applied to the transaction
+	SourceDetection = "detection" // Duplicate or bill-payment detection
+)
+
+// Entity types recorded in the audit log
+const (
+	EntityTransaction = "transaction"
+)
+
+// Domain errors
+var (
+	ErrInvalidEntity = errors.New("entity type and ID are required")
+)
+
+// Change holds the previous and new value of a single audited field
+type Change struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Entry is a single audit log record
+type Entry struct {
+	ID         int64             `json:"id"`
+	UserID     int64             `json:"userId"`
+	EntityType string            `json:"entityType"`
+	EntityID   string            `json:"entityId"`
+	Action     string            `json:"action"`
+	Source     string            `json:"source"`
+	Changes    map[string]Change `json:"changes"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+// TransactionUpdate is the state of one transaction before and after a bulk change
+type TransactionUpdate struct {
+	Old *transaction.Transaction
+	New *transaction.Transaction
+}
@@ -0,0 +1,15 @@
+package audit
+
+import "context"
+
+// Repository defines the interface for audit log data access
+type Repository interface {
+	// Create stores a new audit entry
+	Create(ctx context.Context, entry *Entry) error
+
+	// CreateBatch stores several audit entries with a single write
+	CreateBatch(ctx context.Context, entries []*Entry) error
+
+	// ListByEntity retrieves the audit entries for an entity, newest first
+	ListByEntity(ctx context.Context, entityType, entityID string, limit int) ([]*Entry, error)
+}
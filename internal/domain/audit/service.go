@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"parsa/internal/domain/transaction"
+)
+
+// recordTimeout bounds a single asynchronous audit write
+const recordTimeout = 10 * time.Second
+
+// DefaultListLimit caps the number of entries returned by ListEntityHistory
+const DefaultListLimit = 100
+
+// Service contains the business logic for audit logging
+type Service struct {
+	repo Repository
+}
+
+// NewService creates a new audit service
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record stores an audit entry in the background so the caller's request is never
+// blocked or failed by audit logging. Failures are logged and otherwise ignored.
+func (s *Service) Record(entry *Entry) {
+	if entry == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+		defer cancel()
+
+		if err := s.repo.Create(ctx, entry); err != nil {
+			log.Printf("Error recording audit entry for %s %s: %v", entry.EntityType, entry.EntityID, err)
+		}
+	}()
+}
+
+// RecordBatch stores entries in the background with a single write, so bulk operations do
+// not start one write per entity. Failures are logged and otherwise ignored.
+func (s *Service) RecordBatch(entries []*Entry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+		defer cancel()
+
+		if err := s.repo.CreateBatch(ctx, entries); err != nil {
+			log.Printf("Error recording %d audit entries: %v", len(entries), err)
+		}
+	}()
+}
+
+// RecordTransaction records a transaction mutation made by the user. For updates, old and
+// new are compared and nothing is recorded when none of the audited fields changed.
+func (s *Service) RecordTransaction(userID int64, action string, old, new *transaction.Transaction) {
+	s.RecordTransactionFrom(userID, SourceUser, action, old, new)
+}
+
+// RecordTransactionFrom records a transaction mutation made by source, like RecordTransaction
+func (s *Service) RecordTransactionFrom(userID int64, source, action string, old, new *transaction.Transaction) {
+	if entry := transactionEntry(userID, source, action, old, new); entry != nil {
+		s.Record(entry)
+	}
+}
+
+// RecordTransactions records the same user mutation for many transactions with a single
+// write, skipping updates that changed none of the audited fields
+func (s *Service) RecordTransactions(userID int64, action string, updates []TransactionUpdate) {
+	s.RecordTransactionsFrom(userID, SourceUser, action, updates)
+}
+
+// RecordTransactionsFrom records the same mutation made by source for many transactions, like
+// RecordTransactions
+func (s *Service) RecordTransactionsFrom(userID int64, source, action string, updates []TransactionUpdate) {
+	entries := make([]*Entry, 0, len(updates))
+	for _, u := range updates {
+		if entry := transactionEntry(userID, source, action, u.Old, u.New); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	s.RecordBatch(entries)
+}
+
+// transactionEntry builds the audit entry of a transaction mutation, or returns nil when
+// there is nothing to record
+func transactionEntry(userID int64, source, action string, old, new *transaction.Transaction) *Entry {
+	var entityID string
+	switch {
+	case new != nil:
+		entityID = new.ID
+	case old != nil:
+		entityID = old.ID
+	default:
+		return nil
+	}
+
+	changes := TransactionChanges(old, new)
+	if action == ActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	return &Entry{
+		UserID:     userID,
+		EntityType: EntityTransaction,
+		EntityID:   entityID,
+		Action:     action,
+		Source:     source,
+		Changes:    changes,
+	}
+}
+
+// ListEntityHistory returns the audit entries for an entity, newest first
+func (s *Service) ListEntityHistory(ctx context.Context, entityType, entityID string, limit int) ([]*Entry, error) {
+	if entityType == "" || entityID == "" {
+		return nil, ErrInvalidEntity
+	}
+	if limit <= 0 || limit > DefaultListLimit {
+		limit = DefaultListLimit
+	}
+
+	return s.repo.ListByEntity(ctx, entityType, entityID, limit)
+}
+
+// TransactionHistory returns the latest audit entries of a transaction in chronological
+// order, oldest first. Ownership is the caller's to check.
+func (s *Service) TransactionHistory(ctx context.Context, transactionID string, limit int) ([]*Entry, error) {
+	entries, err := s.ListEntityHistory(ctx, EntityTransaction, transactionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// TransactionChanges returns the audited fields (description, category, considered, notes)
+// that differ between old and new. A nil old (create) or nil new (delete) reports every
+// audited field with the missing side left empty.
+func TransactionChanges(old, new *transaction.Transaction) map[string]Change {
+	changes := make(map[string]Change)
+
+	add := func(field string, oldVal, newVal any) {
+		if old != nil && new != nil && oldVal == newVal {
+			return
+		}
+		changes[field] = Change{Old: oldVal, New: newVal}
+	}
+
+	var before, after transactionFields
+	if old != nil {
+		before = fieldsOf(old)
+	}
+	if new != nil {
+		after = fieldsOf(new)
+	}
+
+	add("description", before.description, after.description)
+	add("category", before.category, after.category)
+	add("considered", before.considered, after.considered)
+	add("notes", before.notes, after.notes)
+
+	return changes
+}
+
+// transactionFields holds the audited values of a transaction with pointers dereferenced
+// so they compare by value
+type transactionFields struct {
+	description any
+	category    any
+	considered  any
+	notes       any
+}
+
+func fieldsOf(t *transaction.Transaction) transactionFields {
+	f := transactionFields{
+		description: t.Description,
+		considered:  t.Considered,
+	}
+	if t.Category != nil {
+		f.category = *t.Category
+	}
+	if t.Notes != nil {
+		f.notes = *t.Notes
+	}
+	return f
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"parsa/internal/domain/transaction"
+)
+
+func strPtr(s string) *string { return &s }
+
+// fakeRepository returns its entries newest first, like the postgres repository
+type fakeRepository struct {
+	entries   []*Entry
+	gotEntity string
+	gotLimit  int
+	batches   chan []*Entry
+}
+
+func (r *fakeRepository) Create(ctx context.Context, entry *Entry) error { return nil }
+
+func (r *fakeRepository) CreateBatch(ctx context.Context, entries []*Entry) error {
+	if r.batches != nil {
+		r.batches <- entries
+	}
+	return nil
+}
+
+func (r *fakeRepository) ListByEntity(ctx context.Context, entityType, entityID string, limit int) ([]*Entry, error) {
+	r.gotEntity = entityType + "/" + entityID
+	r.gotLimit = limit
+	return r.entries, nil
+}
+
+func TestTransactionChanges(t *testing.T) {
+	base := func() *transaction.Transaction {
+		return &transaction.Transaction{
+			ID:          "txn-1",
+			Description: "Coffee",
+			Category:    strPtr("food"),
+			Considered:  true,
+			Notes:       strPtr("morning"),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		old        *transaction.Transaction
+		new        *transaction.Transaction
+		wantFields []string
+	}{
+		{
+			name:       "create reports all audited fields",
+			old:        nil,
+			new:        base(),
+			wantFields: []string{"description", "category", "considered", "notes"},
+		},
+		{
+			name:       "delete reports all audited fields",
+			old:        base(),
+			new:        nil,
+			wantFields: []string{"description", "category", "considered", "notes"},
+		},
+		{
+			name:       "unchanged update reports nothing",
+			old:        base(),
+			new:        base(),
+			wantFields: nil,
+		},
+		{
+			name: "update reports only changed fields",
+			old:  base(),
+			new: func() *transaction.Transaction {
+				txn := base()
+				txn.Category = strPtr("transport")
+				txn.Considered = false
+				return txn
+			}(),
+			wantFields: []string{"category", "considered"},
+		},
+		{
+			name: "clearing notes is a change",
+			old:  base(),
+			new: func() *transaction.Transaction {
+				txn := base()
+				txn.Notes = nil
+				return txn
+			}(),
+			wantFields: []string{"notes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := TransactionChanges(tt.old, tt.new)
+
+			if len(changes) != len(tt.wantFields) {
+				t.Fatalf("got %d changes (%v), want %d", len(changes), changes, len(tt.wantFields))
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := changes[field]; !ok {
+					t.Errorf("expected change for %q", field)
+				}
+			}
+		})
+	}
+
+	changes := TransactionChanges(base(), func() *transaction.Transaction {
+		txn := base()
+		txn.Description = "Tea"
+		return txn
+	}())
+	if got := changes["description"]; got.Old != "Coffee" || got.New != "Tea" {
+		t.Errorf("description change = %+v, want Coffee -> Tea", got)
+	}
+}
+
+func TestTransactionHistory(t *testing.T) {
+	repo := &fakeRepository{entries: []*Entry{{ID: 3}, {ID: 2}, {ID: 1}}}
+	service := NewService(repo)
+
+	entries, err := service.TransactionHistory(context.Background(), "txn-1", 0)
+	if err != nil {
+		t.Fatalf("TransactionHistory() error = %v", err)
+	}
+	if repo.gotEntity != "transaction/txn-1" || repo.gotLimit != DefaultListLimit {
+		t.Errorf("listed %s with limit %d, want transaction/txn-1 with %d", repo.gotEntity, repo.gotLimit, DefaultListLimit)
+	}
+	var ids []int64
+	for _, e := range entries {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Errorf("entry IDs = %v, want oldest first [1 2 3]", ids)
+	}
+
+	if _, err := service.TransactionHistory(context.Background(), "", 0); err != ErrInvalidEntity {
+		t.Errorf("TransactionHistory() without an ID error = %v, want ErrInvalidEntity", err)
+	}
+}
+
+func TestRecordTransactions(t *testing.T) {
+	repo := &fakeRepository{batches: make(chan []*Entry, 1)}
+	service := NewService(repo)
+
+	service.RecordTransactions(7, ActionUpdate, []TransactionUpdate{
+		{Old: &transaction.Transaction{ID: "txn-1", Category: strPtr("food")}, New: &transaction.Transaction{ID: "txn-1", Category: strPtr("groceries")}},
+		{Old: &transaction.Transaction{ID: "txn-2", Category: strPtr("food")}, New: &transaction.Transaction{ID: "txn-2", Category: strPtr("food")}},
+		{Old: &transaction.Transaction{ID: "txn-3", Category: strPtr("food")}, New: &transaction.Transaction{ID: "txn-3", Category: strPtr("groceries")}},
+	})
+
+	select {
+	case entries := <-repo.batches:
+		if len(entries) != 2 || entries[0].EntityID != "txn-1" || entries[1].EntityID != "txn-3" {
+			t.Fatalf("batch = %+v, want the two changed transactions txn-1 and txn-3", entries)
+		}
+		for _, e := range entries {
+			if e.UserID != 7 || e.Action != ActionUpdate || e.EntityType != EntityTransaction || e.Source != SourceUser {
+				t.Errorf("entry = %+v, want an update of a transaction by user 7", e)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RecordTransactions() wrote no batch")
+	}
+}
+
+func TestRecordTransactionsFrom(t *testing.T) {
+	repo := &fakeRepository{batches: make(chan []*Entry, 1)}
+	service := NewService(repo)
+
+	service.RecordTransactionsFrom(7, SourceDetection, ActionUpdate, []TransactionUpdate{
+		{Old: &transaction.Transaction{ID: "txn-1", Considered: false}, New: &transaction.Transaction{ID: "txn-1", Considered: true}},
+	})
+
+	select {
+	case entries := <-repo.batches:
+		if len(entries) != 1 || entries[0].Source != SourceDetection {
+			t.Fatalf("batch = %+v, want one entry from detection", entries)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RecordTransactionsFrom() wrote no batch")
+	}
+}
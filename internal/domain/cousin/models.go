@@ -0,0 +1,55 @@
+package cousin
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCousinNotFound = errors.New("cousin not found")
+	ErrForbidden      = errors.New("forbidden: cousin does not belong to user")
+)
+
+// Cousin groups transactions with the same counterparty (merchant or individual).
+// Cousins created through the API belong to a user; shared cousins created by
+// transaction grouping have no UserID and are not managed through the API.
+type Cousin struct {
+	ID           int64     `json:"id"`
+	UserID       *int64    `json:"-"`
+	Name         string    `json:"name"`
+	BusinessName *string   `json:"businessName,omitempty"`
+	DocumentID   *int64    `json:"documentId,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// IsOwnedBy reports whether the cousin belongs to userID
+func (c *Cousin) IsOwnedBy(userID int64) bool {
+	return c.UserID != nil && *c.UserID == userID
+}
+
+// CreateCousinParams contains the parameters for creating a cousin
+type CreateCousinParams struct {
+	Name         string
+	BusinessName *string
+}
+
+// Validate validates the create parameters
+func (p *CreateCousinParams) Validate() error {
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(p.Name) > 255 {
+		return errors.New("name must be 255 characters or less")
+	}
+	if p.BusinessName != nil && len(*p.BusinessName) > 255 {
+		return errors.New("business name must be 255 characters or less")
+	}
+	return nil
+}
+
+// AssignResult is the outcome of assigning (or unassigning) one transaction
+type AssignResult struct {
+	TransactionID string
+	Err           error // nil on success
+}
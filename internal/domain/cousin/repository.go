@@ -0,0 +1,25 @@
+package cousin
+
+import (
+	"context"
+)
+
+// Repository defines the interface for cousin data access
+type Repository interface {
+	// Create creates a cousin owned by userID
+	Create(ctx context.Context, userID int64, params CreateCousinParams) (*Cousin, error)
+
+	// FindOrCreate returns the cousin of documentID (the user's own or a shared one) or else
+	// the user's cousin named name, creating a user cousin when neither exists. Calls for the
+	// same user are serialized in the database, so concurrent calls never create two.
+	FindOrCreate(ctx context.Context, userID int64, name string, documentID *int64) (*Cousin, error)
+
+	// GetByID returns a cousin by its ID, or nil if it does not exist
+	GetByID(ctx context.Context, id int64) (*Cousin, error)
+
+	// ListByUserID returns the cousins owned by a user
+	ListByUserID(ctx context.Context, userID int64) ([]*Cousin, error)
+
+	// Delete deletes a cousin. Its transactions keep no cousin and its rules are removed.
+	Delete(ctx context.Context, id int64) error
+}
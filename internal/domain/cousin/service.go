@@ -0,0 +1,148 @@
+package cousin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/transaction"
+)
+
+// Service handles business logic for cousins
+type Service struct {
+	repo            Repository
+	transactionRepo transaction.Repository
+	accountRepo     account.Repository
+}
+
+// NewService creates a new cousin service
+func NewService(repo Repository, transactionRepo transaction.Repository, accountRepo account.Repository) *Service {
+	return &Service{
+		repo:            repo,
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+	}
+}
+
+// CreateCousin validates params and creates a cousin owned by userID
+func (s *Service) CreateCousin(ctx context.Context, userID int64, params CreateCousinParams) (*Cousin, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, userID, params)
+}
+
+// GetCousin returns a cousin by ID, verifying ownership
+func (s *Service) GetCousin(ctx context.Context, id, userID int64) (*Cousin, error) {
+	c, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCousinNotFound
+	}
+	if !c.IsOwnedBy(userID) {
+		return nil, ErrForbidden
+	}
+	return c, nil
+}
+
+// ListCousins returns the cousins owned by a user
+func (s *Service) ListCousins(ctx context.Context, userID int64) ([]*Cousin, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// DeleteCousin deletes a cousin, verifying ownership
+func (s *Service) DeleteCousin(ctx context.Context, id, userID int64) error {
+	if _, err := s.GetCousin(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// AssignTransactions puts the given transactions in a cousin group. The cousin and every
+// transaction must belong to userID; failures are reported per transaction.
+func (s *Service) AssignTransactions(ctx context.Context, userID, cousinID int64, transactionIDs []string) ([]AssignResult, error) {
+	if _, err := s.GetCousin(ctx, cousinID, userID); err != nil {
+		return nil, err
+	}
+
+	results := make([]AssignResult, 0, len(transactionIDs))
+	for _, id := range transactionIDs {
+		if _, err := s.ownedTransaction(ctx, userID, id); err != nil {
+			results = append(results, AssignResult{TransactionID: id, Err: err})
+			continue
+		}
+		results = append(results, AssignResult{TransactionID: id, Err: s.transactionRepo.SetCousin(ctx, id, cousinID)})
+	}
+
+	return results, nil
+}
+
+// UnassignTransactions removes the given transactions from a cousin group. Transactions
+// that are not in the group are reported as not found.
+func (s *Service) UnassignTransactions(ctx context.Context, userID, cousinID int64, transactionIDs []string) ([]AssignResult, error) {
+	if _, err := s.GetCousin(ctx, cousinID, userID); err != nil {
+		return nil, err
+	}
+
+	results := make([]AssignResult, 0, len(transactionIDs))
+	for _, id := range transactionIDs {
+		txn, err := s.ownedTransaction(ctx, userID, id)
+		if err != nil {
+			results = append(results, AssignResult{TransactionID: id, Err: err})
+			continue
+		}
+		if txn.Cousin == nil || *txn.Cousin != cousinID {
+			results = append(results, AssignResult{TransactionID: id, Err: transaction.ErrTransactionNotFound})
+			continue
+		}
+		results = append(results, AssignResult{TransactionID: id, Err: s.transactionRepo.ClearCousin(ctx, id)})
+	}
+
+	return results, nil
+}
+
+// ownedTransaction returns the transaction if it belongs to one of the user's accounts.
+// Transactions of other users are reported as not found.
+func (s *Service) ownedTransaction(ctx context.Context, userID int64, id string) (*transaction.Transaction, error) {
+	txn, err := s.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if txn == nil {
+		return nil, transaction.ErrTransactionNotFound
+	}
+
+	acc, err := s.accountRepo.GetByID(ctx, txn.AccountID)
+	if errors.Is(err, account.ErrAccountNotFound) {
+		return nil, transaction.ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if acc == nil || acc.UserID != userID {
+		return nil, transaction.ErrTransactionNotFound
+	}
+
+	return txn, nil
+}
+
+// DuplicatePairResolver adapts repo for DuplicateCheckService.SetCousinResolver: each duplicate
+// pair is grouped under the cousin of its document or the user's cousin of the same name, and
+// only gets a new cousin owned by the user, which the user can manage and attach rules to,
+// when neither exists
+func DuplicatePairResolver(repo Repository) transaction.DuplicateCousinResolver {
+	return func(ctx context.Context, userID int64, name string, documentID *int64) (int64, error) {
+		params := CreateCousinParams{Name: name}
+		if err := params.Validate(); err != nil {
+			return 0, err
+		}
+		c, err := repo.FindOrCreate(ctx, userID, name, documentID)
+		if err != nil {
+			return 0, err
+		}
+		return c.ID, nil
+	}
+}
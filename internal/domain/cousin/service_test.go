@@ -0,0 +1,317 @@
+package cousin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/transaction"
+)
+
+// MockCousinRepo implements Repository for testing
+type MockCousinRepo struct {
+	CreateFunc       func(ctx context.Context, userID int64, params CreateCousinParams) (*Cousin, error)
+	FindOrCreateFunc func(ctx context.Context, userID int64, name string, documentID *int64) (*Cousin, error)
+	GetByIDFunc      func(ctx context.Context, id int64) (*Cousin, error)
+	ListByUserIDFunc func(ctx context.Context, userID int64) ([]*Cousin, error)
+	DeleteFunc       func(ctx context.Context, id int64) error
+}
+
+func (m *MockCousinRepo) Create(ctx context.Context, userID int64, params CreateCousinParams) (*Cousin, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, userID, params)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) FindOrCreate(ctx context.Context, userID int64, name string, documentID *int64) (*Cousin, error) {
+	if m.FindOrCreateFunc != nil {
+		return m.FindOrCreateFunc(ctx, userID, name, documentID)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) GetByID(ctx context.Context, id int64) (*Cousin, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) ListByUserID(ctx context.Context, userID int64) ([]*Cousin, error) {
+	if m.ListByUserIDFunc != nil {
+		return m.ListByUserIDFunc(ctx, userID)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) Delete(ctx context.Context, id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	return nil
+}
+
+// MockTransactionRepo implements transaction.Repository for testing; only the lookup and
+// cousin assignment the cousin service uses are implemented
+type MockTransactionRepo struct {
+	transaction.Repository
+	GetByIDFunc     func(ctx context.Context, id string) (*transaction.Transaction, error)
+	SetCousinFunc   func(ctx context.Context, transactionID string, cousinID int64) error
+	ClearCousinFunc func(ctx context.Context, transactionID string) error
+}
+
+func (m *MockTransactionRepo) GetByID(ctx context.Context, id string) (*transaction.Transaction, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	if m.SetCousinFunc != nil {
+		return m.SetCousinFunc(ctx, transactionID, cousinID)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	if m.ClearCousinFunc != nil {
+		return m.ClearCousinFunc(ctx, transactionID)
+	}
+	return nil
+}
+
+// MockAccountRepo implements account.Repository for testing
+type MockAccountRepo struct {
+	GetByIDFunc func(ctx context.Context, id string) (*account.Account, error)
+}
+
+func (m *MockAccountRepo) Create(ctx context.Context, params account.CreateParams) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) GetByID(ctx context.Context, id string) (*account.Account, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (m *MockAccountRepo) ListByUserID(ctx context.Context, userID int64) ([]*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) ListByUserIDWithBank(ctx context.Context, userID int64) ([]*account.AccountWithBank, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) GetByIDWithBank(ctx context.Context, id string) (*account.AccountWithBank, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) Delete(ctx context.Context, id string) error { return nil }
+func (m *MockAccountRepo) Update(ctx context.Context, id string, params account.UpdateParams) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) Upsert(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) Exists(ctx context.Context, id string) (bool, error) { return false, nil }
+func (m *MockAccountRepo) FindByMatch(ctx context.Context, userID int64, name, accountType, subtype string) (*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) UpdateBankID(ctx context.Context, accountID string, bankID int64) error {
+	return nil
+}
+func (m *MockAccountRepo) GetBalanceSumBySubtype(ctx context.Context, userID int64, subtypes []string) (float64, error) {
+	return 0, nil
+}
+func (m *MockAccountRepo) SoftRemove(ctx context.Context, id string) error         { return nil }
+func (m *MockAccountRepo) Restore(ctx context.Context, id string) error            { return nil }
+func (m *MockAccountRepo) DeleteByItemID(ctx context.Context, itemID string) error { return nil }
+func (m *MockAccountRepo) ListByItemID(ctx context.Context, itemID string) ([]*account.Account, error) {
+	return nil, nil
+}
+func (m *MockAccountRepo) MarkRemoved(ctx context.Context, ids []string) (int64, error) {
+	return 0, nil
+}
+func (m *MockAccountRepo) RestoreProviderRemoved(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+func (m *MockAccountRepo) MergeInto(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error) {
+	return account.MergeCounts{}, nil
+}
+func (m *MockAccountRepo) DeleteBankData(ctx context.Context, itemID string) error { return nil }
+
+func int64Ptr(v int64) *int64 { return &v }
+
+// newAssignFixture returns a service where cousin 10 belongs to user 1, transactions
+// "t1" (account "a1", user 1) and "t2" (account "a2", user 2) exist, and "t1" is in cousin 10.
+func newAssignFixture(setCalls, clearCalls *[]string) *Service {
+	cousinRepo := &MockCousinRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*Cousin, error) {
+			if id != 10 {
+				return nil, nil
+			}
+			return &Cousin{ID: 10, UserID: int64Ptr(1), Name: "Padaria"}, nil
+		},
+	}
+	txRepo := &MockTransactionRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+			switch id {
+			case "t1":
+				return &transaction.Transaction{ID: "t1", AccountID: "a1", Cousin: int64Ptr(10)}, nil
+			case "t2":
+				return &transaction.Transaction{ID: "t2", AccountID: "a2"}, nil
+			}
+			return nil, nil
+		},
+		SetCousinFunc: func(ctx context.Context, id string, cousinID int64) error {
+			*setCalls = append(*setCalls, id)
+			return nil
+		},
+		ClearCousinFunc: func(ctx context.Context, id string) error {
+			*clearCalls = append(*clearCalls, id)
+			return nil
+		},
+	}
+	accRepo := &MockAccountRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+			switch id {
+			case "a1":
+				return &account.Account{ID: "a1", UserID: 1}, nil
+			case "a2":
+				return &account.Account{ID: "a2", UserID: 2}, nil
+			}
+			return nil, account.ErrAccountNotFound
+		},
+	}
+	return NewService(cousinRepo, txRepo, accRepo)
+}
+
+func TestGetCousin_Ownership(t *testing.T) {
+	repo := &MockCousinRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*Cousin, error) {
+			switch id {
+			case 1:
+				return &Cousin{ID: 1, UserID: int64Ptr(1)}, nil
+			case 2:
+				return &Cousin{ID: 2}, nil
+			}
+			return nil, nil
+		},
+	}
+	service := NewService(repo, &MockTransactionRepo{}, &MockAccountRepo{})
+
+	tests := []struct {
+		name    string
+		id      int64
+		userID  int64
+		wantErr error
+	}{
+		{"owner", 1, 1, nil},
+		{"other user", 1, 2, ErrForbidden},
+		{"shared cousin without owner", 2, 1, ErrForbidden},
+		{"missing", 3, 1, ErrCousinNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetCousin(context.Background(), tt.id, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetCousin() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateCousin_Validation(t *testing.T) {
+	called := false
+	repo := &MockCousinRepo{
+		CreateFunc: func(ctx context.Context, userID int64, params CreateCousinParams) (*Cousin, error) {
+			called = true
+			return &Cousin{ID: 1, UserID: int64Ptr(userID), Name: params.Name}, nil
+		},
+	}
+	service := NewService(repo, &MockTransactionRepo{}, &MockAccountRepo{})
+
+	if _, err := service.CreateCousin(context.Background(), 1, CreateCousinParams{}); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if called {
+		t.Error("repository should not be called for invalid params")
+	}
+
+	c, err := service.CreateCousin(context.Background(), 1, CreateCousinParams{Name: "Padaria"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !c.IsOwnedBy(1) {
+		t.Error("expected cousin to be owned by user 1")
+	}
+}
+
+func TestDeleteCousin_OtherUser(t *testing.T) {
+	deleted := false
+	repo := &MockCousinRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*Cousin, error) {
+			return &Cousin{ID: id, UserID: int64Ptr(2)}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id int64) error {
+			deleted = true
+			return nil
+		},
+	}
+	service := NewService(repo, &MockTransactionRepo{}, &MockAccountRepo{})
+
+	if err := service.DeleteCousin(context.Background(), 5, 1); !errors.Is(err, ErrForbidden) {
+		t.Errorf("DeleteCousin() error = %v, want %v", err, ErrForbidden)
+	}
+	if deleted {
+		t.Error("cousin of another user must not be deleted")
+	}
+}
+
+func TestAssignTransactions(t *testing.T) {
+	var setCalls, clearCalls []string
+	service := newAssignFixture(&setCalls, &clearCalls)
+
+	results, err := service.AssignTransactions(context.Background(), 1, 10, []string{"t1", "t2", "missing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Err != nil {
+		t.Errorf("t1: unexpected error %v", results[0].Err)
+	}
+	for _, r := range results[1:] {
+		if !errors.Is(r.Err, transaction.ErrTransactionNotFound) {
+			t.Errorf("%s: error = %v, want %v", r.TransactionID, r.Err, transaction.ErrTransactionNotFound)
+		}
+	}
+	if len(setCalls) != 1 || setCalls[0] != "t1" {
+		t.Errorf("SetCousin calls = %v, want [t1]", setCalls)
+	}
+
+	if _, err := service.AssignTransactions(context.Background(), 2, 10, []string{"t2"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("assign to another user's cousin: error = %v, want %v", err, ErrForbidden)
+	}
+}
+
+func TestUnassignTransactions(t *testing.T) {
+	var setCalls, clearCalls []string
+	service := newAssignFixture(&setCalls, &clearCalls)
+
+	results, err := service.UnassignTransactions(context.Background(), 1, 10, []string{"t1", "t2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Err != nil {
+		t.Errorf("t1: unexpected error %v", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, transaction.ErrTransactionNotFound) {
+		t.Errorf("t2: error = %v, want %v", results[1].Err, transaction.ErrTransactionNotFound)
+	}
+	if len(clearCalls) != 1 || clearCalls[0] != "t1" {
+		t.Errorf("ClearCousin calls = %v, want [t1]", clearCalls)
+	}
+
+	if _, err := service.UnassignTransactions(context.Background(), 1, 99, []string{"t1"}); !errors.Is(err, ErrCousinNotFound) {
+		t.Errorf("unassign from missing cousin: error = %v, want %v", err, ErrCousinNotFound)
+	}
+}
@@ -3,12 +3,19 @@ package cousinrule
 import (
 	"errors"
 	"time"
+
+	"parsa/internal/domain/transaction"
 )
 
 var (
 	ErrRuleNotFound = errors.New("cousin rule not found")
 	ErrForbidden    = errors.New("forbidden: rule does not belong to user")
 	ErrNoChanges    = errors.New("no changes provided")
+	ErrInvalidType  = errors.New("type must be DEBIT or CREDIT")
+
+	// ErrUnknownCousin is wrapped with the offending IDs when an import references cousins
+	// that do not exist
+	ErrUnknownCousin = errors.New("unknown cousin IDs")
 )
 
 // CousinRule represents a user's rule for transactions with a specific cousin (merchant/counterparty)
@@ -27,6 +34,27 @@ type CousinRule struct {
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+// RuleTier identifies which rule won when resolving the effective rule for a transaction
+type RuleTier string
+
+const (
+	RuleTierNone         RuleTier = ""              // The user has no rule for the cousin and type
+	RuleTierTypeSpecific RuleTier = "type_specific" // A rule for the transaction's exact type
+	RuleTierTypeAgnostic RuleTier = "type_agnostic" // A rule with no type, matching both DEBIT and CREDIT
+)
+
+// EffectiveRule is the result of resolving which rule applies to a transaction.
+// Rule is nil when Tier is RuleTierNone.
+type EffectiveRule struct {
+	Rule *CousinRule
+	Tier RuleTier
+}
+
+// DontAskAgain reports whether the effective rule asks not to prompt the user again
+func (e *EffectiveRule) DontAskAgain() bool {
+	return e.Rule != nil && e.Rule.DontAskAgain
+}
+
 // CreateCousinRuleParams contains the parameters for creating a cousin rule
 type CreateCousinRuleParams struct {
 	UserID       int64
@@ -45,8 +73,8 @@ func (p *CreateCousinRuleParams) Validate() error {
 	if p.CousinID == 0 {
 		return errors.New("cousin_id is required")
 	}
-	if p.Type != nil && *p.Type != "DEBIT" && *p.Type != "CREDIT" {
-		return errors.New("type must be DEBIT or CREDIT")
+	if !isValidType(p.Type) {
+		return ErrInvalidType
 	}
 	return nil
 }
@@ -65,6 +93,7 @@ type UpdateCousinRuleParams struct {
 type ApplyRuleParams struct {
 	CousinID             int64
 	TriggeringID         string   // Transaction ID that triggered this action
+	Type                 *string  // Used when TriggeringID is empty; nil matches both types
 	CreateRule           bool     // Whether to persist the rule for future transactions
 	DontAskAgain         bool     // Mark rule as "don't ask again"
 	Changes              Changes  // Changes to apply
@@ -86,7 +115,39 @@ func (c *Changes) IsEmpty() bool {
 
 // ApplyRuleResult contains the result of applying a rule
 type ApplyRuleResult struct {
-	TransactionsUpdated int  `json:"transactionsUpdated"`
-	RuleCreated         bool `json:"ruleCreated"`
-	RuleUpdated         bool `json:"ruleUpdated"`
+	TransactionsUpdated int      `json:"transactionsUpdated"`
+	TransactionIDs      []string `json:"transactionIds"` // IDs of the updated transactions
+	RuleCreated         bool     `json:"ruleCreated"`
+	RuleUpdated         bool     `json:"ruleUpdated"`
+}
+
+// RuleWithCount is a rule with the number of transactions it currently matches
+type RuleWithCount struct {
+	Rule             *CousinRule
+	TransactionCount int64
+}
+
+// PreviewRuleResult lists the transactions a rule apply would change
+type PreviewRuleResult struct {
+	Transactions []*transaction.Transaction
+	Count        int
+	CountByType  map[string]int // Keyed by transaction type ("DEBIT"/"CREDIT")
+}
+
+// ImportResult reports what ImportRules did with each entry
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped []ImportSkip
+}
+
+// ImportSkip is an entry ImportRules left out, identified by its position in the input
+type ImportSkip struct {
+	Index  int
+	Reason string
+}
+
+// isValidType reports whether txType is nil (both types) or DEBIT/CREDIT
+func isValidType(txType *string) bool {
+	return txType == nil || *txType == "DEBIT" || *txType == "CREDIT"
 }
@@ -2,6 +2,8 @@ package cousinrule
 
 import (
 	"context"
+
+	"parsa/internal/domain/transaction"
 )
 
 // Repository defines the interface for cousin rule data access
@@ -16,18 +18,39 @@ type Repository interface {
 	// Type can be nil to match rules that apply to all transaction types
 	GetByCousinAndType(ctx context.Context, userID, cousinID int64, txType *string) (*CousinRule, error)
 
-	// ListByUserID returns all cousin rules for a user
-	ListByUserID(ctx context.Context, userID int64) ([]*CousinRule, error)
+	// ListByUserID returns a page of a user's cousin rules, newest first
+	ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error)
+
+	// ListAllByUserID returns every cousin rule for a user, newest first, for callers that
+	// need the full set rather than a page
+	ListAllByUserID(ctx context.Context, userID int64) ([]*CousinRule, error)
+
+	// CountByUserID returns how many cousin rules a user has
+	CountByUserID(ctx context.Context, userID int64) (int64, error)
+
+	// ListByUserIDWithCounts returns a page of a user's cousin rules with the number of
+	// transactions each currently matches (same cousin and, for typed rules, same type)
+	ListByUserIDWithCounts(ctx context.Context, userID int64, limit, offset int) ([]*RuleWithCount, error)
 
 	// ListByCousinID returns all rules for a specific cousin across a user's rules
 	ListByCousinID(ctx context.Context, userID, cousinID int64) ([]*CousinRule, error)
 
+	// ListByCousinIDs returns a user's rules for any of the given cousins in one query
+	ListByCousinIDs(ctx context.Context, userID int64, cousinIDs []int64) ([]*CousinRule, error)
+
 	// Update updates a cousin rule
 	Update(ctx context.Context, id int64, params UpdateCousinRuleParams) (*CousinRule, error)
 
 	// Upsert creates or updates a cousin rule based on user_id, cousin_id, and type
 	Upsert(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, bool, error) // returns rule, wasCreated, error
 
+	// ImportRules upserts every rule and replaces its tags in one transaction, returning how
+	// many rules were created and updated
+	ImportRules(ctx context.Context, rules []CreateCousinRuleParams) (created, updated int, err error)
+
+	// MissingCousinIDs returns the IDs in ids that do not match any cousin
+	MissingCousinIDs(ctx context.Context, ids []int64) ([]int64, error)
+
 	// Delete deletes a cousin rule
 	Delete(ctx context.Context, id int64) error
 
@@ -38,9 +61,10 @@ type Repository interface {
 	GetRuleTags(ctx context.Context, ruleID int64) ([]string, error)
 
 	// ApplyRuleToTransactions applies a rule to all matching transactions
-	// Returns the number of transactions updated
-	ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) (int, error)
+	// Returns the IDs of the transactions updated
+	ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) ([]string, error)
 
-	// CheckDontAskAgain checks if a user has marked "don't ask again" for a cousin/type combination
-	CheckDontAskAgain(ctx context.Context, userID, cousinID int64, txType string) (bool, error)
+	// ListMatchingTransactions returns the transactions ApplyRuleToTransactions would update
+	// for the same user, cousin and optional type
+	ListMatchingTransactions(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error)
 }
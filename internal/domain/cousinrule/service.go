@@ -3,7 +3,11 @@ package cousinrule
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
+	"parsa/internal/domain/tag"
 	"parsa/internal/domain/transaction"
 )
 
@@ -11,6 +15,7 @@ import (
 type Service struct {
 	repo            Repository
 	transactionRepo transaction.Repository
+	tagRepo         tag.Repository
 }
 
 // NewService creates a new cousin rule service
@@ -21,12 +26,21 @@ func NewService(repo Repository, transactionRepo transaction.Repository) *Servic
 	}
 }
 
+// SetTagRepository lets ImportRules check that imported tag IDs belong to the importing user.
+// Without it tag IDs are imported unchecked.
+func (s *Service) SetTagRepository(tagRepo tag.Repository) {
+	s.tagRepo = tagRepo
+}
+
 // ApplyRule applies changes to transactions and optionally creates/updates a rule
 func (s *Service) ApplyRule(ctx context.Context, userID int64, params ApplyRuleParams) (*ApplyRuleResult, error) {
 	result := &ApplyRuleResult{}
 
-	// Get the triggering transaction to determine its type
-	var txType *string
+	// Get the triggering transaction to determine its type, falling back to an explicit type
+	txType := params.Type
+	if !isValidType(txType) {
+		return nil, ErrInvalidType
+	}
 	if params.TriggeringID != "" {
 		txn, err := s.transactionRepo.GetByID(ctx, params.TriggeringID)
 		if err != nil {
@@ -58,11 +72,12 @@ func (s *Service) ApplyRule(ctx context.Context, userID int64, params ApplyRuleP
 	}
 
 	// Apply changes to existing transactions with this cousin
-	count, err := s.repo.ApplyRuleToTransactions(ctx, userID, params.CousinID, txType, params.Changes)
+	txnIDs, err := s.repo.ApplyRuleToTransactions(ctx, userID, params.CousinID, txType, params.Changes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply rule to transactions: %w", err)
 	}
-	result.TransactionsUpdated = count
+	result.TransactionsUpdated = len(txnIDs)
+	result.TransactionIDs = txnIDs
 
 	// Create or update rule if requested
 	if params.CreateRule {
@@ -103,6 +118,30 @@ func (s *Service) ApplyRule(ctx context.Context, userID int64, params ApplyRuleP
 	return result, nil
 }
 
+// PreviewRule returns the transactions ApplyRule would change for a cousin and optional type,
+// without changing anything
+func (s *Service) PreviewRule(ctx context.Context, userID, cousinID int64, txType *string) (*PreviewRuleResult, error) {
+	if !isValidType(txType) {
+		return nil, ErrInvalidType
+	}
+
+	txns, err := s.repo.ListMatchingTransactions(ctx, userID, cousinID, txType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list matching transactions: %w", err)
+	}
+
+	result := &PreviewRuleResult{
+		Transactions: txns,
+		Count:        len(txns),
+		CountByType:  make(map[string]int),
+	}
+	for _, txn := range txns {
+		result.CountByType[txn.Type]++
+	}
+
+	return result, nil
+}
+
 // GetRule returns a cousin rule by ID, verifying ownership
 func (s *Service) GetRule(ctx context.Context, ruleID, userID int64) (*CousinRule, error) {
 	rule, err := s.repo.GetByID(ctx, ruleID)
@@ -126,58 +165,311 @@ func (s *Service) GetRule(ctx context.Context, ruleID, userID int64) (*CousinRul
 	return rule, nil
 }
 
-// ListRulesByUser returns all cousin rules for a user
-func (s *Service) ListRulesByUser(ctx context.Context, userID int64) ([]*CousinRule, error) {
-	rules, err := s.repo.ListByUserID(ctx, userID)
+// ListRulesByUser returns a page of a user's cousin rules, newest first
+func (s *Service) ListRulesByUser(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error) {
+	rules, err := s.repo.ListByUserID(ctx, userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.loadRuleTags(ctx, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
 
-	// Load tags for each rule
+// ListAllRulesByUser returns every cousin rule for a user. Use it for internal work that
+// needs the whole set; API listings go through ListRulesByUser.
+func (s *Service) ListAllRulesByUser(ctx context.Context, userID int64) ([]*CousinRule, error) {
+	rules, err := s.repo.ListAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadRuleTags(ctx, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ImportRules upserts rules for userID, replacing the tags of each one. UserID on the entries
+// is ignored so an import only ever writes the importing user's rules. Entries that fail
+// validation or reference tags the user does not own are skipped and reported. Entries that
+// reference cousins that do not exist fail the whole import with ErrUnknownCousin listing
+// them; otherwise the kept entries are written in one transaction.
+func (s *Service) ImportRules(ctx context.Context, userID int64, rules []CreateCousinRuleParams) (*ImportResult, error) {
+	ownedTags, err := s.userTagIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Skipped: []ImportSkip{}}
+	kept := make([]CreateCousinRuleParams, 0, len(rules))
+	cousinIDs := make([]int64, 0, len(rules))
+	seen := make(map[int64]bool, len(rules))
+	for i, params := range rules {
+		params.UserID = userID
+		if err := params.Validate(); err != nil {
+			result.Skipped = append(result.Skipped, ImportSkip{Index: i, Reason: err.Error()})
+			continue
+		}
+		if ownedTags != nil {
+			if unknown := firstUnknownTag(params.Tags, ownedTags); unknown != "" {
+				result.Skipped = append(result.Skipped, ImportSkip{Index: i, Reason: fmt.Sprintf("unknown tag %s", unknown)})
+				continue
+			}
+		}
+		kept = append(kept, params)
+		if !seen[params.CousinID] {
+			seen[params.CousinID] = true
+			cousinIDs = append(cousinIDs, params.CousinID)
+		}
+	}
+	if len(kept) == 0 {
+		return result, nil
+	}
+
+	missing, err := s.repo.MissingCousinIDs(ctx, cousinIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cousins: %w", err)
+	}
+	if len(missing) > 0 {
+		ids := make([]string, len(missing))
+		for i, id := range missing {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCousin, strings.Join(ids, ", "))
+	}
+
+	result.Created, result.Updated, err = s.repo.ImportRules(ctx, kept)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// userTagIDs returns the IDs of the user's tags, or nil when no tag repository is set
+func (s *Service) userTagIDs(ctx context.Context, userID int64) (map[string]bool, error) {
+	if s.tagRepo == nil {
+		return nil, nil
+	}
+	tags, err := s.tagRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	owned := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		owned[t.ID] = true
+	}
+	return owned, nil
+}
+
+// firstUnknownTag returns the first tag ID not in owned, or "" when all are known
+func firstUnknownTag(tagIDs []string, owned map[string]bool) string {
+	for _, id := range tagIDs {
+		if !owned[id] {
+			return id
+		}
+	}
+	return ""
+}
+
+// CountRulesByUser returns how many cousin rules a user has
+func (s *Service) CountRulesByUser(ctx context.Context, userID int64) (int64, error) {
+	return s.repo.CountByUserID(ctx, userID)
+}
+
+// loadRuleTags fills in the tags of each rule
+func (s *Service) loadRuleTags(ctx context.Context, rules []*CousinRule) error {
 	for _, rule := range rules {
 		tags, err := s.repo.GetRuleTags(ctx, rule.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get rule tags: %w", err)
+			return fmt.Errorf("failed to get rule tags: %w", err)
 		}
 		rule.Tags = tags
 	}
+	return nil
+}
 
-	return rules, nil
+// ResolveEffectiveRule returns the rule that applies to a transaction of txType with a cousin.
+// This is the single precedence path for rules: a rule for the exact type wins over a
+// type-agnostic rule, and Tier reports which one matched (RuleTierNone when neither exists).
+func (s *Service) ResolveEffectiveRule(ctx context.Context, userID, cousinID int64, txType string) (*EffectiveRule, error) {
+	effective, err := s.resolveRule(ctx, userID, cousinID, txType)
+	if err != nil {
+		return nil, err
+	}
+
+	if effective.Rule != nil {
+		tags, err := s.repo.GetRuleTags(ctx, effective.Rule.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rule tags: %w", err)
+		}
+		effective.Rule.Tags = tags
+	}
+
+	return effective, nil
 }
 
-// GetRuleForCousin returns the rule for a specific cousin and transaction type
-func (s *Service) GetRuleForCousin(ctx context.Context, userID, cousinID int64, txType string) (*CousinRule, error) {
+// resolveRule applies the rule precedence without loading the rule's tags
+func (s *Service) resolveRule(ctx context.Context, userID, cousinID int64, txType string) (*EffectiveRule, error) {
 	// First try to find a type-specific rule
 	rule, err := s.repo.GetByCousinAndType(ctx, userID, cousinID, &txType)
 	if err != nil {
 		return nil, err
 	}
+	if rule != nil {
+		return &EffectiveRule{Rule: rule, Tier: RuleTierTypeSpecific}, nil
+	}
 
 	// If no type-specific rule, try to find a rule that applies to all types
-	if rule == nil {
-		rule, err = s.repo.GetByCousinAndType(ctx, userID, cousinID, nil)
+	rule, err = s.repo.GetByCousinAndType(ctx, userID, cousinID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		return &EffectiveRule{Rule: rule, Tier: RuleTierTypeAgnostic}, nil
+	}
+
+	return &EffectiveRule{Tier: RuleTierNone}, nil
+}
+
+// ListRulesByUserWithCounts returns a page of a user's cousin rules along with how many
+// transactions each one matches. Use ListRulesByUser when the counts are not needed.
+func (s *Service) ListRulesByUserWithCounts(ctx context.Context, userID int64, limit, offset int) ([]*RuleWithCount, error) {
+	results, err := s.repo.ListByUserIDWithCounts(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load tags for each rule
+	for _, result := range results {
+		tags, err := s.repo.GetRuleTags(ctx, result.Rule.ID)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to get rule tags: %w", err)
 		}
+		result.Rule.Tags = tags
 	}
 
-	if rule == nil {
-		return nil, nil
+	return results, nil
+}
+
+// GetRuleForCousin returns the rule for a specific cousin and transaction type
+func (s *Service) GetRuleForCousin(ctx context.Context, userID, cousinID int64, txType string) (*CousinRule, error) {
+	effective, err := s.ResolveEffectiveRule(ctx, userID, cousinID, txType)
+	if err != nil {
+		return nil, err
 	}
+	return effective.Rule, nil
+}
 
-	// Load tags
-	tags, err := s.repo.GetRuleTags(ctx, rule.ID)
+// CheckDontAskAgain checks if user has set "don't ask again" for a cousin/type,
+// using the same precedence as ResolveEffectiveRule
+func (s *Service) CheckDontAskAgain(ctx context.Context, userID, cousinID int64, txType string) (bool, error) {
+	effective, err := s.resolveRule(ctx, userID, cousinID, txType)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get rule tags: %w", err)
+		return false, err
 	}
-	rule.Tags = tags
+	return effective.DontAskAgain(), nil
+}
 
-	return rule, nil
+// DontAskAgainForTransactions reports, by transaction ID, whether the effective rule of each
+// transaction with a cousin asks not to prompt the user again. It follows the precedence of
+// ResolveEffectiveRule but loads the rules of all the cousins in one query, for list pages.
+func (s *Service) DontAskAgainForTransactions(ctx context.Context, userID int64, txns []*transaction.Transaction) (map[string]bool, error) {
+	var cousinIDs []int64
+	for _, txn := range txns {
+		if txn.Cousin != nil && *txn.Cousin != 0 && !slices.Contains(cousinIDs, *txn.Cousin) {
+			cousinIDs = append(cousinIDs, *txn.Cousin)
+		}
+	}
+	result := make(map[string]bool)
+	if len(cousinIDs) == 0 {
+		return result, nil
+	}
+
+	rules, err := s.repo.ListByCousinIDs(ctx, userID, cousinIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keyed by cousin and type; the type-agnostic rule has an empty type
+	type ruleKey struct {
+		cousinID int64
+		txType   string
+	}
+	byKey := make(map[ruleKey]*CousinRule, len(rules))
+	for _, rule := range rules {
+		key := ruleKey{cousinID: rule.CousinID}
+		if rule.Type != nil {
+			key.txType = *rule.Type
+		}
+		byKey[key] = rule
+	}
+
+	for _, txn := range txns {
+		if txn.Cousin == nil || *txn.Cousin == 0 {
+			continue
+		}
+		rule, ok := byKey[ruleKey{cousinID: *txn.Cousin, txType: txn.Type}]
+		if !ok {
+			rule, ok = byKey[ruleKey{cousinID: *txn.Cousin}]
+		}
+		result[txn.ID] = ok && rule.DontAskAgain
+	}
+	return result, nil
 }
 
-// CheckDontAskAgain checks if user has set "don't ask again" for a cousin/type
-func (s *Service) CheckDontAskAgain(ctx context.Context, userID, cousinID int64, txType string) (bool, error) {
-	return s.repo.CheckDontAskAgain(ctx, userID, cousinID, txType)
+// ApplyEffectiveRuleToTransaction applies the user's effective rule for the transaction's
+// cousin and type to that single transaction and marks it manipulated, like the bulk apply.
+// Rule tags are added to the existing ones. Returns the updated transaction, or the original one when no rule has changes to apply.
+func (s *Service) ApplyEffectiveRuleToTransaction(ctx context.Context, userID int64, txn *transaction.Transaction) (*transaction.Transaction, error) {
+	if txn.Cousin == nil {
+		return txn, nil
+	}
+
+	effective, err := s.ResolveEffectiveRule(ctx, userID, *txn.Cousin, txn.Type)
+	if err != nil {
+		return nil, err
+	}
+	rule := effective.Rule
+	if rule == nil || (rule.Category == nil && rule.Description == nil && rule.Notes == nil && rule.Considered == nil && len(rule.Tags) == 0) {
+		return txn, nil
+	}
+
+	params := transaction.UpdateTransactionParams{
+		Category:    rule.Category,
+		Description: rule.Description,
+		Notes:       rule.Notes,
+		Considered:  rule.Considered,
+		Manipulated: true,
+	}
+	if rule.Considered != nil {
+		reason := transaction.ConsideredReasonUser
+		params.ConsideredReason = &reason
+	}
+
+	updated, err := s.transactionRepo.Update(ctx, txn.ID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply rule to transaction: %w", err)
+	}
+
+	if len(rule.Tags) > 0 {
+		tags, err := s.transactionRepo.GetTransactionTags(ctx, txn.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction tags: %w", err)
+		}
+		for _, tagID := range rule.Tags {
+			if !slices.Contains(tags, tagID) {
+				tags = append(tags, tagID)
+			}
+		}
+		if err := s.transactionRepo.SetTransactionTags(ctx, txn.ID, tags); err != nil {
+			return nil, fmt.Errorf("failed to set transaction tags: %w", err)
+		}
+		updated.Tags = tags
+	}
+
+	return updated, nil
 }
 
 // DeleteRule deletes a cousin rule, verifying ownership
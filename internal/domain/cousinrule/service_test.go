@@ -3,26 +3,35 @@ package cousinrule
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"parsa/internal/domain/tag"
 	"parsa/internal/domain/transaction"
 )
 
 // MockCousinRuleRepo implements Repository for testing
 type MockCousinRuleRepo struct {
-	CreateFunc                  func(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, error)
-	GetByIDFunc                 func(ctx context.Context, id int64) (*CousinRule, error)
-	GetByCousinAndTypeFunc      func(ctx context.Context, userID, cousinID int64, txType *string) (*CousinRule, error)
-	ListByUserIDFunc            func(ctx context.Context, userID int64) ([]*CousinRule, error)
-	ListByCousinIDFunc          func(ctx context.Context, userID, cousinID int64) ([]*CousinRule, error)
-	UpdateFunc                  func(ctx context.Context, id int64, params UpdateCousinRuleParams) (*CousinRule, error)
-	UpsertFunc                  func(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, bool, error)
-	DeleteFunc                  func(ctx context.Context, id int64) error
-	SetRuleTagsFunc             func(ctx context.Context, ruleID int64, tagIDs []string) error
-	GetRuleTagsFunc             func(ctx context.Context, ruleID int64) ([]string, error)
-	ApplyRuleToTransactionsFunc func(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) (int, error)
-	CheckDontAskAgainFunc       func(ctx context.Context, userID, cousinID int64, txType string) (bool, error)
+	CreateFunc                   func(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, error)
+	GetByIDFunc                  func(ctx context.Context, id int64) (*CousinRule, error)
+	GetByCousinAndTypeFunc       func(ctx context.Context, userID, cousinID int64, txType *string) (*CousinRule, error)
+	ListByUserIDFunc             func(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error)
+	ListAllByUserIDFunc          func(ctx context.Context, userID int64) ([]*CousinRule, error)
+	CountByUserIDFunc            func(ctx context.Context, userID int64) (int64, error)
+	ListByUserIDWithCountsFunc   func(ctx context.Context, userID int64, limit, offset int) ([]*RuleWithCount, error)
+	ListByCousinIDFunc           func(ctx context.Context, userID, cousinID int64) ([]*CousinRule, error)
+	ListByCousinIDsFunc          func(ctx context.Context, userID int64, cousinIDs []int64) ([]*CousinRule, error)
+	UpdateFunc                   func(ctx context.Context, id int64, params UpdateCousinRuleParams) (*CousinRule, error)
+	UpsertFunc                   func(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, bool, error)
+	ImportRulesFunc              func(ctx context.Context, rules []CreateCousinRuleParams) (int, int, error)
+	MissingCousinIDsFunc         func(ctx context.Context, ids []int64) ([]int64, error)
+	DeleteFunc                   func(ctx context.Context, id int64) error
+	SetRuleTagsFunc              func(ctx context.Context, ruleID int64, tagIDs []string) error
+	GetRuleTagsFunc              func(ctx context.Context, ruleID int64) ([]string, error)
+	ApplyRuleToTransactionsFunc  func(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) ([]string, error)
+	ListMatchingTransactionsFunc func(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error)
 }
 
 func (m *MockCousinRuleRepo) Create(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, error) {
@@ -43,9 +52,27 @@ func (m *MockCousinRuleRepo) GetByCousinAndType(ctx context.Context, userID, cou
 	}
 	return nil, nil
 }
-func (m *MockCousinRuleRepo) ListByUserID(ctx context.Context, userID int64) ([]*CousinRule, error) {
+func (m *MockCousinRuleRepo) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error) {
 	if m.ListByUserIDFunc != nil {
-		return m.ListByUserIDFunc(ctx, userID)
+		return m.ListByUserIDFunc(ctx, userID, limit, offset)
+	}
+	return nil, nil
+}
+func (m *MockCousinRuleRepo) ListAllByUserID(ctx context.Context, userID int64) ([]*CousinRule, error) {
+	if m.ListAllByUserIDFunc != nil {
+		return m.ListAllByUserIDFunc(ctx, userID)
+	}
+	return nil, nil
+}
+func (m *MockCousinRuleRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	if m.CountByUserIDFunc != nil {
+		return m.CountByUserIDFunc(ctx, userID)
+	}
+	return 0, nil
+}
+func (m *MockCousinRuleRepo) ListByUserIDWithCounts(ctx context.Context, userID int64, limit, offset int) ([]*RuleWithCount, error) {
+	if m.ListByUserIDWithCountsFunc != nil {
+		return m.ListByUserIDWithCountsFunc(ctx, userID, limit, offset)
 	}
 	return nil, nil
 }
@@ -55,6 +82,12 @@ func (m *MockCousinRuleRepo) ListByCousinID(ctx context.Context, userID, cousinI
 	}
 	return nil, nil
 }
+func (m *MockCousinRuleRepo) ListByCousinIDs(ctx context.Context, userID int64, cousinIDs []int64) ([]*CousinRule, error) {
+	if m.ListByCousinIDsFunc != nil {
+		return m.ListByCousinIDsFunc(ctx, userID, cousinIDs)
+	}
+	return nil, nil
+}
 func (m *MockCousinRuleRepo) Update(ctx context.Context, id int64, params UpdateCousinRuleParams) (*CousinRule, error) {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(ctx, id, params)
@@ -67,6 +100,18 @@ func (m *MockCousinRuleRepo) Upsert(ctx context.Context, params CreateCousinRule
 	}
 	return nil, false, nil
 }
+func (m *MockCousinRuleRepo) ImportRules(ctx context.Context, rules []CreateCousinRuleParams) (int, int, error) {
+	if m.ImportRulesFunc != nil {
+		return m.ImportRulesFunc(ctx, rules)
+	}
+	return 0, 0, nil
+}
+func (m *MockCousinRuleRepo) MissingCousinIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	if m.MissingCousinIDsFunc != nil {
+		return m.MissingCousinIDsFunc(ctx, ids)
+	}
+	return nil, nil
+}
 func (m *MockCousinRuleRepo) Delete(ctx context.Context, id int64) error {
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(ctx, id)
@@ -85,17 +130,17 @@ func (m *MockCousinRuleRepo) GetRuleTags(ctx context.Context, ruleID int64) ([]s
 	}
 	return []string{}, nil
 }
-func (m *MockCousinRuleRepo) ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) (int, error) {
+func (m *MockCousinRuleRepo) ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) ([]string, error) {
 	if m.ApplyRuleToTransactionsFunc != nil {
 		return m.ApplyRuleToTransactionsFunc(ctx, userID, cousinID, txType, changes)
 	}
-	return 0, nil
+	return nil, nil
 }
-func (m *MockCousinRuleRepo) CheckDontAskAgain(ctx context.Context, userID, cousinID int64, txType string) (bool, error) {
-	if m.CheckDontAskAgainFunc != nil {
-		return m.CheckDontAskAgainFunc(ctx, userID, cousinID, txType)
+func (m *MockCousinRuleRepo) ListMatchingTransactions(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error) {
+	if m.ListMatchingTransactionsFunc != nil {
+		return m.ListMatchingTransactionsFunc(ctx, userID, cousinID, txType)
 	}
-	return false, nil
+	return nil, nil
 }
 
 // MockTransactionRepo implements transaction.Repository for testing
@@ -121,6 +166,42 @@ func (m *MockTransactionRepo) ListByUserID(ctx context.Context, userID int64, li
 func (m *MockTransactionRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
 	return 0, nil
 }
+func (m *MockTransactionRepo) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ListByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) Recategorize(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	return false, nil
+}
+func (m *MockTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (m *MockTransactionRepo) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (m *MockTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	return nil
+}
+func (m *MockTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return 0, nil
+}
 func (m *MockTransactionRepo) Update(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
 	return nil, nil
 }
@@ -144,6 +225,33 @@ func (m *MockTransactionRepo) GetTransactionTags(ctx context.Context, transactio
 	return nil, nil
 }
 
+func (m *MockTransactionRepo) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	return nil
+}
+
+func (m *MockTransactionRepo) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) CountGroups(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	return 0, nil
+}
+
 func TestChanges_IsEmpty(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -259,8 +367,8 @@ func TestApplyRule_DontAskAgainOnly(t *testing.T) {
 
 func TestApplyRule_WithChangesAndCreateRule(t *testing.T) {
 	repo := &MockCousinRuleRepo{
-		ApplyRuleToTransactionsFunc: func(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) (int, error) {
-			return 5, nil
+		ApplyRuleToTransactionsFunc: func(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) ([]string, error) {
+			return []string{"tx-1", "tx-2", "tx-3", "tx-4", "tx-5"}, nil
 		},
 		UpsertFunc: func(ctx context.Context, params CreateCousinRuleParams) (*CousinRule, bool, error) {
 			return &CousinRule{ID: 1}, true, nil
@@ -290,6 +398,113 @@ func TestApplyRule_WithChangesAndCreateRule(t *testing.T) {
 	}
 }
 
+func TestApplyRule_ExplicitType(t *testing.T) {
+	var gotType *string
+	repo := &MockCousinRuleRepo{
+		ApplyRuleToTransactionsFunc: func(ctx context.Context, userID, cousinID int64, txType *string, changes Changes) ([]string, error) {
+			gotType = txType
+			return []string{"tx-1", "tx-2"}, nil
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+
+	result, err := svc.ApplyRule(context.Background(), 1, ApplyRuleParams{
+		CousinID: 42,
+		Type:     strPtr("CREDIT"),
+		Changes:  Changes{Category: strPtr("Salary")},
+	})
+	if err != nil {
+		t.Fatalf("ApplyRule() error: %v", err)
+	}
+	if gotType == nil || *gotType != "CREDIT" {
+		t.Errorf("txType = %v, want CREDIT", gotType)
+	}
+	if result.TransactionsUpdated != 2 || len(result.TransactionIDs) != 2 || result.TransactionIDs[0] != "tx-1" {
+		t.Errorf("result = %+v, want 2 updated with IDs [tx-1 tx-2]", result)
+	}
+
+	_, err = svc.ApplyRule(context.Background(), 1, ApplyRuleParams{
+		CousinID: 42,
+		Type:     strPtr("TRANSFER"),
+		Changes:  Changes{Category: strPtr("Salary")},
+	})
+	if err != ErrInvalidType {
+		t.Errorf("ApplyRule() error = %v, want ErrInvalidType", err)
+	}
+}
+
+func TestPreviewRule(t *testing.T) {
+	tests := []struct {
+		name       string
+		txType     *string
+		txns       []*transaction.Transaction
+		repoErr    error
+		wantErr    error
+		wantCount  int
+		wantByType map[string]int
+	}{
+		{
+			name: "counts by type",
+			txns: []*transaction.Transaction{
+				{ID: "tx-1", Type: "DEBIT"},
+				{ID: "tx-2", Type: "DEBIT"},
+				{ID: "tx-3", Type: "CREDIT"},
+			},
+			wantCount:  3,
+			wantByType: map[string]int{"DEBIT": 2, "CREDIT": 1},
+		},
+		{
+			name:       "no matches",
+			txType:     strPtr("DEBIT"),
+			wantCount:  0,
+			wantByType: map[string]int{},
+		},
+		{
+			name:    "invalid type",
+			txType:  strPtr("debit"),
+			wantErr: ErrInvalidType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotType *string
+			repo := &MockCousinRuleRepo{
+				ListMatchingTransactionsFunc: func(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error) {
+					gotType = txType
+					return tt.txns, tt.repoErr
+				},
+			}
+			svc := NewService(repo, &MockTransactionRepo{})
+
+			result, err := svc.PreviewRule(context.Background(), 1, 42, tt.txType)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("PreviewRule() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PreviewRule() error: %v", err)
+			}
+			if gotType != tt.txType {
+				t.Errorf("repo called with type %v, want %v", gotType, tt.txType)
+			}
+			if result.Count != tt.wantCount {
+				t.Errorf("Count = %d, want %d", result.Count, tt.wantCount)
+			}
+			if len(result.CountByType) != len(tt.wantByType) {
+				t.Errorf("CountByType = %v, want %v", result.CountByType, tt.wantByType)
+			}
+			for txType, want := range tt.wantByType {
+				if result.CountByType[txType] != want {
+					t.Errorf("CountByType[%s] = %d, want %d", txType, result.CountByType[txType], want)
+				}
+			}
+		})
+	}
+}
+
 func TestGetRule_Success(t *testing.T) {
 	repo := &MockCousinRuleRepo{
 		GetByIDFunc: func(ctx context.Context, id int64) (*CousinRule, error) {
@@ -477,7 +692,10 @@ func TestGetRuleForCousin_NoRule(t *testing.T) {
 
 func TestListRulesByUser(t *testing.T) {
 	repo := &MockCousinRuleRepo{
-		ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*CousinRule, error) {
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error) {
+			if limit != 100 || offset != 200 {
+				t.Errorf("ListByUserID limit/offset = %d/%d, want 100/200", limit, offset)
+			}
 			return []*CousinRule{
 				{ID: 1, UserID: userID, CreatedAt: time.Now()},
 				{ID: 2, UserID: userID, CreatedAt: time.Now()},
@@ -490,7 +708,7 @@ func TestListRulesByUser(t *testing.T) {
 	txRepo := &MockTransactionRepo{}
 	svc := NewService(repo, txRepo)
 
-	rules, err := svc.ListRulesByUser(context.Background(), 1)
+	rules, err := svc.ListRulesByUser(context.Background(), 1, 100, 200)
 	if err != nil {
 		t.Fatalf("ListRulesByUser() error: %v", err)
 	}
@@ -499,25 +717,222 @@ func TestListRulesByUser(t *testing.T) {
 	}
 }
 
+func TestListAllRulesByUser(t *testing.T) {
+	repo := &MockCousinRuleRepo{
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error) {
+			t.Error("ListAllRulesByUser must not page")
+			return nil, nil
+		},
+		ListAllByUserIDFunc: func(ctx context.Context, userID int64) ([]*CousinRule, error) {
+			return []*CousinRule{{ID: 1, UserID: userID}, {ID: 2, UserID: userID}, {ID: 3, UserID: userID}}, nil
+		},
+		GetRuleTagsFunc: func(ctx context.Context, ruleID int64) ([]string, error) {
+			return []string{"tag1"}, nil
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+
+	rules, err := svc.ListAllRulesByUser(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ListAllRulesByUser() error: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("ListAllRulesByUser() returned %d rules, want 3", len(rules))
+	}
+	if len(rules[2].Tags) != 1 {
+		t.Errorf("Tags = %v, want tags loaded", rules[2].Tags)
+	}
+}
+
+func TestListRulesByUserWithCounts(t *testing.T) {
+	repo := &MockCousinRuleRepo{
+		ListByUserIDWithCountsFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*RuleWithCount, error) {
+			return []*RuleWithCount{
+				{Rule: &CousinRule{ID: 1, UserID: userID}, TransactionCount: 8},
+				{Rule: &CousinRule{ID: 2, UserID: userID}, TransactionCount: 0},
+			}, nil
+		},
+		GetRuleTagsFunc: func(ctx context.Context, ruleID int64) ([]string, error) {
+			return []string{"tag1"}, nil
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+
+	results, err := svc.ListRulesByUserWithCounts(context.Background(), 1, 100, 0)
+	if err != nil {
+		t.Fatalf("ListRulesByUserWithCounts() error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("ListRulesByUserWithCounts() returned %d rules, want 2", len(results))
+	}
+	if results[0].TransactionCount != 8 || results[1].TransactionCount != 0 {
+		t.Errorf("counts = %d, %d, want 8, 0", results[0].TransactionCount, results[1].TransactionCount)
+	}
+	if len(results[0].Rule.Tags) != 1 {
+		t.Errorf("Rule.Tags = %v, want tags loaded", results[0].Rule.Tags)
+	}
+}
+
 func TestListRulesByUser_RepoError(t *testing.T) {
 	repo := &MockCousinRuleRepo{
-		ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*CousinRule, error) {
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*CousinRule, error) {
 			return nil, errors.New("db error")
 		},
 	}
 	txRepo := &MockTransactionRepo{}
 	svc := NewService(repo, txRepo)
 
-	_, err := svc.ListRulesByUser(context.Background(), 1)
+	_, err := svc.ListRulesByUser(context.Background(), 1, 100, 0)
 	if err == nil {
 		t.Error("ListRulesByUser() expected error, got nil")
 	}
 }
 
+func TestResolveEffectiveRule_Precedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		typeSpecific *CousinRule // rule stored for the transaction's type
+		typeAgnostic *CousinRule // rule stored with no type
+		wantTier     RuleTier
+		wantRuleID   int64
+		wantDontAsk  bool
+	}{
+		{
+			name:     "no rules",
+			wantTier: RuleTierNone,
+		},
+		{
+			name:         "only type-specific",
+			typeSpecific: &CousinRule{ID: 1},
+			wantTier:     RuleTierTypeSpecific,
+			wantRuleID:   1,
+		},
+		{
+			name:         "only type-agnostic",
+			typeAgnostic: &CousinRule{ID: 2, DontAskAgain: true},
+			wantTier:     RuleTierTypeAgnostic,
+			wantRuleID:   2,
+			wantDontAsk:  true,
+		},
+		{
+			name:         "type-specific wins over type-agnostic",
+			typeSpecific: &CousinRule{ID: 1},
+			typeAgnostic: &CousinRule{ID: 2, DontAskAgain: true},
+			wantTier:     RuleTierTypeSpecific,
+			wantRuleID:   1,
+			wantDontAsk:  false,
+		},
+		{
+			name:         "type-specific dont ask again wins",
+			typeSpecific: &CousinRule{ID: 1, DontAskAgain: true},
+			typeAgnostic: &CousinRule{ID: 2},
+			wantTier:     RuleTierTypeSpecific,
+			wantRuleID:   1,
+			wantDontAsk:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockCousinRuleRepo{
+				GetByCousinAndTypeFunc: func(ctx context.Context, userID, cousinID int64, txType *string) (*CousinRule, error) {
+					if txType == nil {
+						return tt.typeAgnostic, nil
+					}
+					if *txType != "DEBIT" {
+						t.Errorf("looked up type %q, want DEBIT", *txType)
+					}
+					return tt.typeSpecific, nil
+				},
+				GetRuleTagsFunc: func(ctx context.Context, ruleID int64) ([]string, error) {
+					return []string{"tag1"}, nil
+				},
+				ListByCousinIDsFunc: func(ctx context.Context, userID int64, cousinIDs []int64) ([]*CousinRule, error) {
+					debit := "DEBIT"
+					var rules []*CousinRule
+					if tt.typeSpecific != nil {
+						rule := *tt.typeSpecific
+						rule.CousinID, rule.Type = 42, &debit
+						rules = append(rules, &rule)
+					}
+					if tt.typeAgnostic != nil {
+						rule := *tt.typeAgnostic
+						rule.CousinID = 42
+						rules = append(rules, &rule)
+					}
+					return rules, nil
+				},
+			}
+			svc := NewService(repo, &MockTransactionRepo{})
+
+			effective, err := svc.ResolveEffectiveRule(context.Background(), 1, 42, "DEBIT")
+			if err != nil {
+				t.Fatalf("ResolveEffectiveRule() error: %v", err)
+			}
+			if effective.Tier != tt.wantTier {
+				t.Errorf("Tier = %q, want %q", effective.Tier, tt.wantTier)
+			}
+			if tt.wantRuleID == 0 {
+				if effective.Rule != nil {
+					t.Errorf("Rule = %+v, want nil", effective.Rule)
+				}
+			} else if effective.Rule == nil || effective.Rule.ID != tt.wantRuleID {
+				t.Errorf("Rule = %+v, want ID %d", effective.Rule, tt.wantRuleID)
+			} else if len(effective.Rule.Tags) != 1 {
+				t.Errorf("Rule.Tags = %v, want tags loaded", effective.Rule.Tags)
+			}
+
+			// CheckDontAskAgain must agree with the resolved rule
+			dontAsk, err := svc.CheckDontAskAgain(context.Background(), 1, 42, "DEBIT")
+			if err != nil {
+				t.Fatalf("CheckDontAskAgain() error: %v", err)
+			}
+			if dontAsk != tt.wantDontAsk || effective.DontAskAgain() != tt.wantDontAsk {
+				t.Errorf("dont ask again = %v (effective %v), want %v", dontAsk, effective.DontAskAgain(), tt.wantDontAsk)
+			}
+
+			// So must the batch lookup used by list pages
+			cousinID := int64(42)
+			flags, err := svc.DontAskAgainForTransactions(context.Background(), 1, []*transaction.Transaction{
+				{ID: "tx-1", Type: "DEBIT", Cousin: &cousinID},
+				{ID: "tx-2", Type: "DEBIT"},
+			})
+			if err != nil {
+				t.Fatalf("DontAskAgainForTransactions() error: %v", err)
+			}
+			if flags["tx-1"] != tt.wantDontAsk {
+				t.Errorf("batch dont ask again = %v, want %v", flags["tx-1"], tt.wantDontAsk)
+			}
+			if _, ok := flags["tx-2"]; ok {
+				t.Error("transaction without a cousin should have no flag")
+			}
+		})
+	}
+}
+
+func TestResolveEffectiveRule_RepoError(t *testing.T) {
+	repo := &MockCousinRuleRepo{
+		GetByCousinAndTypeFunc: func(ctx context.Context, userID, cousinID int64, txType *string) (*CousinRule, error) {
+			return nil, errors.New("db error")
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+
+	if _, err := svc.ResolveEffectiveRule(context.Background(), 1, 42, "DEBIT"); err == nil {
+		t.Error("ResolveEffectiveRule() expected error, got nil")
+	}
+	if _, err := svc.CheckDontAskAgain(context.Background(), 1, 42, "DEBIT"); err == nil {
+		t.Error("CheckDontAskAgain() expected error, got nil")
+	}
+}
+
 func TestCheckDontAskAgain(t *testing.T) {
 	repo := &MockCousinRuleRepo{
-		CheckDontAskAgainFunc: func(ctx context.Context, userID, cousinID int64, txType string) (bool, error) {
-			return true, nil
+		GetByCousinAndTypeFunc: func(ctx context.Context, userID, cousinID int64, txType *string) (*CousinRule, error) {
+			if txType != nil && *txType == "DEBIT" {
+				return &CousinRule{ID: 1, UserID: userID, Type: txType, DontAskAgain: true}, nil
+			}
+			return nil, nil
 		},
 	}
 	txRepo := &MockTransactionRepo{}
@@ -534,3 +949,109 @@ func TestCheckDontAskAgain(t *testing.T) {
 
 func strPtr(s string) *string  { return &s }
 func boolPtr(b bool) *bool     { return &b }
+
+// stubTagRepo implements tag.Repository with a fixed set of tags per user
+type stubTagRepo struct {
+	tag.Repository
+	tagsByUser map[int64][]*tag.Tag
+}
+
+func (s *stubTagRepo) ListByUserID(ctx context.Context, userID int64) ([]*tag.Tag, error) {
+	return s.tagsByUser[userID], nil
+}
+
+func TestImportRules(t *testing.T) {
+	debit := "DEBIT"
+	invalidType := "TRANSFER"
+	var imported []CreateCousinRuleParams
+	var checkedCousins []int64
+
+	repo := &MockCousinRuleRepo{
+		MissingCousinIDsFunc: func(ctx context.Context, ids []int64) ([]int64, error) {
+			checkedCousins = ids
+			return nil, nil
+		},
+		ImportRulesFunc: func(ctx context.Context, rules []CreateCousinRuleParams) (int, int, error) {
+			imported = rules
+			return 1, 1, nil
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+	svc.SetTagRepository(&stubTagRepo{tagsByUser: map[int64][]*tag.Tag{
+		7: {{ID: "tag-mine", UserID: 7}},
+		8: {{ID: "tag-theirs", UserID: 8}},
+	}})
+
+	result, err := svc.ImportRules(context.Background(), 7, []CreateCousinRuleParams{
+		{UserID: 8, CousinID: 1, Tags: []string{"tag-mine"}},
+		{CousinID: 2, Type: &debit},
+		{CousinID: 0},
+		{CousinID: 3, Type: &invalidType},
+		{CousinID: 4, Tags: []string{"tag-theirs"}},
+		{CousinID: 2},
+	})
+	if err != nil {
+		t.Fatalf("ImportRules() error: %v", err)
+	}
+
+	if result.Created != 1 || result.Updated != 1 {
+		t.Errorf("created/updated = %d/%d, want 1/1", result.Created, result.Updated)
+	}
+	if len(result.Skipped) != 3 {
+		t.Fatalf("skipped %d entries, want 3: %+v", len(result.Skipped), result.Skipped)
+	}
+	for i, wantIndex := range []int{2, 3, 4} {
+		if result.Skipped[i].Index != wantIndex {
+			t.Errorf("Skipped[%d].Index = %d, want %d", i, result.Skipped[i].Index, wantIndex)
+		}
+	}
+	if len(imported) != 3 {
+		t.Fatalf("imported %d rules in one call, want 3", len(imported))
+	}
+	for _, params := range imported {
+		if params.UserID != 7 {
+			t.Errorf("imported rule for user %d, want the importing user 7", params.UserID)
+		}
+	}
+	if !slices.Equal(checkedCousins, []int64{1, 2}) {
+		t.Errorf("checked cousins %v, want [1 2] without skipped entries or repeats", checkedCousins)
+	}
+}
+
+func TestImportRules_UnknownCousins(t *testing.T) {
+	imported := false
+	repo := &MockCousinRuleRepo{
+		MissingCousinIDsFunc: func(ctx context.Context, ids []int64) ([]int64, error) {
+			return []int64{3, 7}, nil
+		},
+		ImportRulesFunc: func(ctx context.Context, rules []CreateCousinRuleParams) (int, int, error) {
+			imported = true
+			return len(rules), 0, nil
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+
+	_, err := svc.ImportRules(context.Background(), 1, []CreateCousinRuleParams{{CousinID: 1}, {CousinID: 3}, {CousinID: 7}})
+	if !errors.Is(err, ErrUnknownCousin) {
+		t.Fatalf("ImportRules() error = %v, want ErrUnknownCousin", err)
+	}
+	if !strings.Contains(err.Error(), "3, 7") {
+		t.Errorf("error %q does not list the unknown cousins", err)
+	}
+	if imported {
+		t.Error("rules were written although the import references unknown cousins")
+	}
+}
+
+func TestImportRules_RepoError(t *testing.T) {
+	repo := &MockCousinRuleRepo{
+		ImportRulesFunc: func(ctx context.Context, rules []CreateCousinRuleParams) (int, int, error) {
+			return 0, 0, errors.New("db error")
+		},
+	}
+	svc := NewService(repo, &MockTransactionRepo{})
+
+	if _, err := svc.ImportRules(context.Background(), 1, []CreateCousinRuleParams{{CousinID: 1}}); err == nil {
+		t.Error("ImportRules() expected error, got nil")
+	}
+}
@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"parsa/internal/domain/account"
 	"parsa/internal/domain/notification"
 	"parsa/internal/domain/user"
 	ofclient "parsa/internal/infrastructure/openfinance"
 	"parsa/internal/models"
+	"parsa/internal/shared/branding"
 	"parsa/internal/shared/messages"
 )
 
@@ -20,21 +23,75 @@ import (
 // Callers should stop the entire sync for this user.
 var ErrProviderUnauthorized = errors.New("provider key unauthorized")
 
-// SyncResult contains the results of a sync operation
+// ProviderKeyMaxFailures is the number of consecutive provider 401s after which the key is
+// cleared. A single 401 can be transient on the provider side, so the key is kept until then.
+const ProviderKeyMaxFailures = 3
+
+// SyncResult contains the results of a sync operation. Accounts are synced one by one, so a
+// malformed or failing account is reported in Failures while the others are still saved.
 type SyncResult struct {
 	UserID        int64
 	AccountsFound int
 	Created       int
 	Updated       int
+	Removed       int
+	Skipped       int // Accounts belonging to disabled or needs-reconnect items
+	Excluded      int // Accounts whose subtype is not in the sync allowlist
+	Failed        int
+	Failures      []AccountFailure
 	Errors        []string
 }
 
+// AccountFailure is a provider account the sync could not save
+type AccountFailure struct {
+	AccountID string
+	Error     string
+}
+
+// Synced returns how many accounts were created or updated
+func (r *SyncResult) Synced() int {
+	return r.Created + r.Updated
+}
+
+// addFailure records that the provider account accountID could not be synced
+func (r *SyncResult) addFailure(accountID string, err error) {
+	r.Failed++
+	r.Failures = append(r.Failures, AccountFailure{AccountID: accountID, Error: err.Error()})
+	r.Errors = append(r.Errors, fmt.Sprintf("failed to sync account %s: %v", accountID, err))
+}
+
+// providerAccountValues are the fields of a provider account that need parsing
+type providerAccountValues struct {
+	balance   float64
+	createdAt *time.Time
+	updatedAt *time.Time
+}
+
+// parseProviderAccount parses the balance and timestamps of a provider account
+func parseProviderAccount(apiAccount ofclient.Account) (providerAccountValues, error) {
+	balance, err := apiAccount.GetBalance()
+	if err != nil {
+		return providerAccountValues{}, fmt.Errorf("failed to parse balance: %w", err)
+	}
+	createdAt, err := apiAccount.GetCreatedAt()
+	if err != nil {
+		return providerAccountValues{}, fmt.Errorf("failed to parse createdAt: %w", err)
+	}
+	updatedAt, err := apiAccount.GetUpdatedAt()
+	if err != nil {
+		return providerAccountValues{}, fmt.Errorf("failed to parse updatedAt: %w", err)
+	}
+	return providerAccountValues{balance: balance, createdAt: createdAt, updatedAt: updatedAt}, nil
+}
+
 // AccountSyncService handles syncing accounts from the Open Finance API
 type AccountSyncService struct {
 	client               ofclient.ClientInterface
 	userRepo             user.Repository
 	accountService       *account.Service
 	itemRepo             models.ItemRepository
+	bankRepo             models.BankRepository
+	bankBranding         *branding.Catalog
 	notificationService  *notification.Service
 	notificationMessages *messages.Messages
 }
@@ -45,6 +102,7 @@ func NewAccountSyncService(
 	userRepo user.Repository,
 	accountService *account.Service,
 	itemRepo models.ItemRepository,
+	bankRepo models.BankRepository,
 	notificationService *notification.Service,
 	notificationMessages *messages.Messages,
 ) *AccountSyncService {
@@ -53,11 +111,17 @@ func NewAccountSyncService(
 		userRepo:             userRepo,
 		accountService:       accountService,
 		itemRepo:             itemRepo,
+		bankRepo:             bankRepo,
 		notificationService:  notificationService,
 		notificationMessages: notificationMessages,
 	}
 }
 
+// SetBankBranding enables applying brand colors and logos to the banks linked during sync
+func (s *AccountSyncService) SetBankBranding(catalog *branding.Catalog) {
+	s.bankBranding = catalog
+}
+
 // SyncUserAccountsWithData syncs accounts using pre-fetched account data.
 func (s *AccountSyncService) SyncUserAccountsWithData(ctx context.Context, userID int64, accountResp *ofclient.AccountResponse) (*SyncResult, error) {
 	if accountResp == nil {
@@ -76,70 +140,146 @@ func (s *AccountSyncService) SyncUserAccountsWithData(ctx context.Context, userI
 
 	log.Printf("User %d: Syncing %d accounts", userID, result.AccountsFound)
 
+	// Items that are disabled or need reconnection are left untouched until fixed
+	excludedItems, err := s.accountService.ListSyncExcludedItemIDs(ctx, userID)
+	if err != nil {
+		return result, fmt.Errorf("failed to load item states: %w", err)
+	}
+
+	// Track which account IDs the provider still reports for each item
+	presentByItem := make(map[string]map[string]struct{})
+
 	for _, apiAccount := range accountResp.Data {
-		if err := s.syncAccount(ctx, userID, apiAccount, result); err != nil {
-			errMsg := fmt.Sprintf("failed to sync account %s: %v", apiAccount.AccountID, err)
-			result.Errors = append(result.Errors, errMsg)
-			log.Printf("User %d: %s", userID, errMsg)
+		if _, excluded := excludedItems[apiAccount.ItemID]; excluded && apiAccount.ItemID != "" {
+			log.Printf("User %d: Skipping account %s (item %s is disabled or needs reconnect)", userID, apiAccount.AccountID, apiAccount.ItemID)
+			result.Skipped++
+			continue
+		}
+
+		if apiAccount.ItemID != "" {
+			if presentByItem[apiAccount.ItemID] == nil {
+				presentByItem[apiAccount.ItemID] = make(map[string]struct{})
+			}
+			presentByItem[apiAccount.ItemID][apiAccount.AccountID] = struct{}{}
+		}
+
+		// Excluded accounts still count as present above, so accounts stored before the
+		// allowlist was set are kept rather than marked removed
+		if !s.accountService.SyncsSubtype(apiAccount.AccountSubtype) {
+			log.Printf("User %d: Skipping account %s (subtype %q is not synced)", userID, apiAccount.AccountID, apiAccount.AccountSubtype)
+			result.Excluded++
+			continue
+		}
+
+		// A malformed account is still present at the provider, so it stays out of the
+		// reconciliation above, but it is not saved
+		values, err := parseProviderAccount(apiAccount)
+		if err != nil {
+			result.addFailure(apiAccount.AccountID, err)
+			log.Printf("User %d: Skipping account %s: malformed provider data: %v", userID, apiAccount.AccountID, err)
+			continue
+		}
+
+		if err := s.syncAccount(ctx, userID, apiAccount, values, result); err != nil {
+			result.addFailure(apiAccount.AccountID, err)
+			log.Printf("User %d: failed to sync account %s: %v", userID, apiAccount.AccountID, err)
 		}
 	}
 
-	log.Printf("User %d: Sync complete - Created: %d, Updated: %d, Errors: %d",
-		userID, result.Created, result.Updated, len(result.Errors))
+	s.reconcileRemovedAccounts(ctx, userID, presentByItem, result)
+
+	log.Printf("User %d: Sync complete - Synced: %d (Created: %d, Updated: %d), Failed: %d, Removed: %d, Skipped: %d, Excluded: %d, Errors: %d",
+		userID, result.Synced(), result.Created, result.Updated, result.Failed, result.Removed, result.Skipped, result.Excluded, len(result.Errors))
 
 	return result, nil
 }
 
 // SyncUserAccounts syncs accounts for a specific user by fetching from API.
-// Returns ErrProviderUnauthorized if the provider rejects the API key (401),
-// in which case the key is cleared and callers should stop the entire sync.
+// Returns ErrProviderUnauthorized if the provider rejects the API key (401), in which case
+// callers should stop the entire sync. The key is cleared once the provider has rejected it
+// ProviderKeyMaxFailures times in a row.
 func (s *AccountSyncService) SyncUserAccounts(ctx context.Context, userID int64) (*SyncResult, error) {
-	u, err := s.userRepo.GetByID(ctx, userID)
+	providerKey, err := loadProviderKey(ctx, s.userRepo, userID)
 	if err != nil {
 		return &SyncResult{UserID: userID, Errors: []string{}}, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	if u.ProviderKey == nil || *u.ProviderKey == "" {
+	if providerKey == "" {
 		return &SyncResult{UserID: userID, Errors: []string{}}, fmt.Errorf("user has no provider key")
 	}
 
-	accountResp, statusCode, err := s.client.GetAccountsWithStatus(ctx, *u.ProviderKey)
+	accountResp, statusCode, err := s.client.GetAccountsWithStatus(ctx, providerKey)
 	if err != nil {
 		if statusCode == http.StatusUnauthorized {
-			log.Printf("User %d: Provider returned 401 — clearing provider_key and stopping sync", userID)
-			if clearErr := s.userRepo.ClearProviderKey(ctx, userID); clearErr != nil {
-				return &SyncResult{UserID: userID, Errors: []string{}},
-					fmt.Errorf("provider unauthorized and failed to clear provider key: %w", clearErr)
-			}
-			if s.notificationService != nil && s.notificationMessages != nil {
-				s.notificationService.SendProviderKeyCleared(ctx, userID, s.notificationMessages)
-			}
-			return &SyncResult{UserID: userID, Errors: []string{}}, ErrProviderUnauthorized
+			return &SyncResult{UserID: userID, Errors: []string{}}, s.handleProviderUnauthorized(ctx, userID)
 		}
 		return &SyncResult{UserID: userID, Errors: []string{}}, fmt.Errorf("failed to fetch accounts from API: %w", err)
 	}
 
+	if err := s.userRepo.MarkProviderKeyValid(ctx, userID); err != nil {
+		log.Printf("User %d: Failed to record provider key validation: %v", userID, err)
+	}
+
 	return s.SyncUserAccountsWithData(ctx, userID, accountResp)
 }
 
-// syncAccount syncs a single account
-func (s *AccountSyncService) syncAccount(ctx context.Context, userID int64, apiAccount ofclient.Account, result *SyncResult) error {
-	// Parse balance from string
-	balance, err := apiAccount.GetBalance()
+// handleProviderUnauthorized records a provider 401 for the user. After ProviderKeyMaxFailures
+// consecutive failures the key is cleared, the user's items are flagged as needing reconnect
+// and the user is notified. Always returns ErrProviderUnauthorized unless clearing the key fails.
+func (s *AccountSyncService) handleProviderUnauthorized(ctx context.Context, userID int64) error {
+	forgetProviderKey(ctx)
+
+	failures, err := s.userRepo.RecordProviderKeyFailure(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("failed to parse balance: %w", err)
+		log.Printf("User %d: Failed to record provider key failure: %v", userID, err)
+		failures = ProviderKeyMaxFailures
 	}
 
-	// Parse timestamps from API response
-	createdAt, err := apiAccount.GetCreatedAt()
-	if err != nil {
-		return fmt.Errorf("failed to parse createdAt: %w", err)
+	if failures < ProviderKeyMaxFailures {
+		log.Printf("User %d: Provider returned 401 (%d/%d) — stopping sync", userID, failures, ProviderKeyMaxFailures)
+		return ErrProviderUnauthorized
 	}
-	updatedAt, err := apiAccount.GetUpdatedAt()
-	if err != nil {
-		return fmt.Errorf("failed to parse updatedAt: %w", err)
+
+	log.Printf("User %d: Provider returned 401 %d times — clearing provider_key and stopping sync", userID, failures)
+	if clearErr := s.userRepo.ClearProviderKey(ctx, userID); clearErr != nil {
+		return fmt.Errorf("provider unauthorized and failed to clear provider key: %w", clearErr)
 	}
+	if flagErr := s.accountService.FlagItemsNeedReconnect(ctx, userID, true); flagErr != nil {
+		log.Printf("User %d: Failed to flag items as needing reconnect: %v", userID, flagErr)
+	}
+	if s.notificationService != nil && s.notificationMessages != nil {
+		s.notificationService.SendProviderKeyCleared(ctx, userID, s.notificationMessages)
+	}
+	return ErrProviderUnauthorized
+}
 
+// ResetReconnectFlags clears needs_reconnect on all of the user's items.
+// Called after the user provides a new, validated provider key.
+func (s *AccountSyncService) ResetReconnectFlags(ctx context.Context, userID int64) error {
+	return s.accountService.FlagItemsNeedReconnect(ctx, userID, false)
+}
+
+// reconcileRemovedAccounts marks stored accounts as removed when the provider no longer
+// reports them. Only items present in the provider response are reconciled, so other
+// bank connections are never touched.
+func (s *AccountSyncService) reconcileRemovedAccounts(ctx context.Context, userID int64, presentByItem map[string]map[string]struct{}, result *SyncResult) {
+	for itemID, presentIDs := range presentByItem {
+		removed, err := s.accountService.ReconcileItemAccounts(ctx, userID, itemID, presentIDs)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to reconcile accounts for item %s: %v", itemID, err)
+			result.Errors = append(result.Errors, errMsg)
+			log.Printf("User %d: %s", userID, errMsg)
+			continue
+		}
+		if removed > 0 {
+			result.Removed += int(removed)
+			log.Printf("User %d: Marked %d account(s) removed for item %s (no longer reported by provider)", userID, removed, itemID)
+		}
+	}
+}
+
+// syncAccount syncs a single account whose provider values were already parsed
+func (s *AccountSyncService) syncAccount(ctx context.Context, userID int64, apiAccount ofclient.Account, values providerAccountValues, result *SyncResult) error {
 	// Find or create Item for this bank connection
 	var itemID string
 	if apiAccount.ItemID != "" {
@@ -157,15 +297,23 @@ func (s *AccountSyncService) syncAccount(ctx context.Context, userID int64, apiA
 		return fmt.Errorf("failed to check account existence: %w", err)
 	}
 
-	// Skip removed accounts — don't re-sync them
+	// Skip removed accounts — don't re-sync them — unless sync removed them because the
+	// provider stopped reporting them, in which case they are back
 	if exists {
 		existing, err := s.accountService.GetAccountByID(ctx, apiAccount.AccountID)
 		if err != nil {
 			return fmt.Errorf("failed to load existing account: %w", err)
 		}
 		if existing != nil && existing.RemovedAt != nil {
-			log.Printf("User %d: Skipping removed account %s", userID, apiAccount.AccountID)
-			return nil
+			restored, err := s.accountService.RestoreProviderRemovedAccount(ctx, apiAccount.AccountID)
+			if err != nil {
+				return fmt.Errorf("failed to restore account: %w", err)
+			}
+			if !restored {
+				log.Printf("User %d: Skipping removed account %s", userID, apiAccount.AccountID)
+				return nil
+			}
+			log.Printf("User %d: Restored account %s (reported by provider again)", userID, apiAccount.AccountID)
 		}
 	}
 
@@ -175,11 +323,13 @@ func (s *AccountSyncService) syncAccount(ctx context.Context, userID int64, apiA
 		UserID:            userID,
 		ItemID:            itemID,
 		Name:              apiAccount.AccountName,
+		MarketingName:     strings.TrimSpace(apiAccount.AccountMarketingName),
+		ProviderCode:      apiAccount.ProviderCode,
 		AccountType:       apiAccount.AccountType,
 		Currency:          apiAccount.AccountCurrencyCode,
-		Balance:           balance,
-		ProviderCreatedAt: createdAt,
-		ProviderUpdatedAt: updatedAt,
+		Balance:           values.balance,
+		ProviderCreatedAt: values.createdAt,
+		ProviderUpdatedAt: values.updatedAt,
 	}
 
 	// Set subtype if available
@@ -188,11 +338,16 @@ func (s *AccountSyncService) syncAccount(ctx context.Context, userID int64, apiA
 	}
 
 	// Upsert the account
-	_, err = s.accountService.UpsertAccount(ctx, params)
+	acc, err := s.accountService.UpsertAccount(ctx, params)
 	if err != nil {
 		return fmt.Errorf("failed to upsert account: %w", err)
 	}
 
+	// Bank metadata is best-effort and never fails the account sync
+	if err := s.syncAccountBank(ctx, userID, acc, apiAccount.ProviderCode); err != nil {
+		log.Printf("User %d: Warning: failed to sync bank for account %s: %v", userID, apiAccount.AccountID, err)
+	}
+
 	if exists {
 		result.Updated++
 		log.Printf("User %d: Updated account %s (%s)", userID, apiAccount.AccountName, apiAccount.AccountID)
@@ -203,3 +358,64 @@ func (s *AccountSyncService) syncAccount(ctx context.Context, userID int64, apiA
 
 	return nil
 }
+
+// syncAccountBank links an account to the bank identified by the provider's connector code.
+// Banks are matched by connector to avoid duplicates; an account already linked to a bank
+// without a connector (matched by name during transaction sync) gets the code backfilled
+// onto that bank instead. A bank known only by its code is named after it until a
+// transaction reports the bank name (see TransactionSyncService). The linked bank then
+// gets the connector's branding, when known.
+func (s *AccountSyncService) syncAccountBank(ctx context.Context, userID int64, acc *account.Account, providerCode string) error {
+	if s.bankRepo == nil || acc == nil || providerCode == "" {
+		return nil
+	}
+
+	bankID, err := s.linkAccountBank(ctx, userID, acc, providerCode)
+	if err != nil {
+		return err
+	}
+
+	if primaryColor, logoURL, ok := s.bankBranding.Lookup(providerCode); ok {
+		if err := s.bankRepo.ApplyBranding(ctx, bankID, primaryColor, logoURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkAccountBank resolves the bank for providerCode and links the account to it,
+// returning the bank ID
+func (s *AccountSyncService) linkAccountBank(ctx context.Context, userID int64, acc *account.Account, providerCode string) (int64, error) {
+	bank, err := s.bankRepo.FindByConnector(ctx, providerCode)
+	if err != nil {
+		return 0, err
+	}
+
+	if bank == nil && acc.BankID != 0 {
+		backfilled, err := s.bankRepo.SetConnector(ctx, acc.BankID, providerCode)
+		if err != nil {
+			return 0, err
+		}
+		if backfilled {
+			log.Printf("User %d: Set connector %s on bank %d of account %s", userID, providerCode, acc.BankID, acc.ID)
+			return acc.BankID, nil
+		}
+	}
+
+	if bank == nil {
+		bank, err = s.bankRepo.FindOrCreateByConnector(ctx, providerCode, providerCode)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if bank.ID == acc.BankID {
+		return bank.ID, nil
+	}
+	if err := s.accountService.UpdateAccountBankID(ctx, acc.ID, bank.ID, userID); err != nil {
+		return 0, err
+	}
+	acc.BankID = bank.ID
+	log.Printf("User %d: Linked account %s to bank %d (connector %s)", userID, acc.ID, bank.ID, providerCode)
+	return bank.ID, nil
+}
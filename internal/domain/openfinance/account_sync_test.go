@@ -2,18 +2,24 @@ package openfinance
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
+	"time"
 
 	"parsa/internal/domain/account"
 	"parsa/internal/domain/user"
 	ofclient "parsa/internal/infrastructure/openfinance"
 	"parsa/internal/models"
+	"parsa/internal/shared/branding"
 )
 
 // MockClient implements ofclient.ClientInterface
 type MockClient struct {
 	GetAccountsFunc     func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error)
 	GetTransactionsFunc func(ctx context.Context, apiKey string, startDate string) (*ofclient.TransactionResponse, error)
+	GetBillsFunc        func(ctx context.Context, apiKey string) (*ofclient.BillResponse, error)
+	StatusCode          int // Status returned by GetAccountsWithStatus on error (defaults to 500)
 }
 
 func (m *MockClient) GetAccounts(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
@@ -33,20 +39,27 @@ func (m *MockClient) GetTransactions(ctx context.Context, apiKey string, startDa
 func (m *MockClient) GetAccountsWithStatus(ctx context.Context, apiKey string) (*ofclient.AccountResponse, int, error) {
 	resp, err := m.GetAccounts(ctx, apiKey)
 	if err != nil {
+		if m.StatusCode != 0 {
+			return nil, m.StatusCode, err
+		}
 		return nil, 500, err
 	}
 	return resp, 200, nil
 }
 
 func (m *MockClient) GetBills(ctx context.Context, apiKey string) (*ofclient.BillResponse, error) {
+	if m.GetBillsFunc != nil {
+		return m.GetBillsFunc(ctx, apiKey)
+	}
 	return &ofclient.BillResponse{Success: true, Data: []ofclient.Bill{}}, nil
 }
 
 // MockItemRepo implements models.ItemRepository
 type MockItemRepo struct {
-	FindOrCreateFunc func(ctx context.Context, id string, userID int64) (*models.Item, error)
-	ListByUserIDFunc func(ctx context.Context, userID int64) ([]*models.Item, error)
-	DeleteFunc       func(ctx context.Context, id string) error
+	FindOrCreateFunc              func(ctx context.Context, id string, userID int64) (*models.Item, error)
+	ListByUserIDFunc              func(ctx context.Context, userID int64) ([]*models.Item, error)
+	DeleteFunc                    func(ctx context.Context, id string) error
+	SetNeedsReconnectByUserIDFunc func(ctx context.Context, userID int64, value bool) error
 }
 
 func (m *MockItemRepo) FindOrCreate(ctx context.Context, id string, userID int64) (*models.Item, error) {
@@ -63,6 +76,25 @@ func (m *MockItemRepo) ListByUserID(ctx context.Context, userID int64) ([]*model
 	return nil, nil
 }
 
+func (m *MockItemRepo) ListSummariesByUserID(ctx context.Context, userID int64) ([]*models.ItemSummary, error) {
+	return nil, nil
+}
+
+func (m *MockItemRepo) GetByID(ctx context.Context, id string) (*models.Item, error) {
+	return nil, nil
+}
+
+func (m *MockItemRepo) Update(ctx context.Context, id string, params models.UpdateItemParams) (*models.Item, error) {
+	return nil, nil
+}
+
+func (m *MockItemRepo) SetNeedsReconnectByUserID(ctx context.Context, userID int64, value bool) error {
+	if m.SetNeedsReconnectByUserIDFunc != nil {
+		return m.SetNeedsReconnectByUserIDFunc(ctx, userID, value)
+	}
+	return nil
+}
+
 func (m *MockItemRepo) Delete(ctx context.Context, id string) error {
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(ctx, id)
@@ -76,7 +108,10 @@ func (m *MockItemRepo) SoftDelete(ctx context.Context, id string) error {
 
 // MockUserRepo implements user.Repository (Minimal implementation for sync)
 type MockUserRepo struct {
-	GetByIDFunc func(ctx context.Context, id int64) (*user.User, error)
+	GetByIDFunc                  func(ctx context.Context, id int64) (*user.User, error)
+	ClearProviderKeyFunc         func(ctx context.Context, userID int64) error
+	MarkProviderKeyValidFunc     func(ctx context.Context, userID int64) error
+	RecordProviderKeyFailureFunc func(ctx context.Context, userID int64) (int, error)
 	// Other methods can be nil for this test file
 }
 
@@ -103,11 +138,29 @@ func (m *MockUserRepo) ListUsersWithProviderKey(ctx context.Context) ([]*user.Us
 	return nil, nil
 }
 func (m *MockUserRepo) ClearProviderKey(ctx context.Context, userID int64) error {
+	if m.ClearProviderKeyFunc != nil {
+		return m.ClearProviderKeyFunc(ctx, userID)
+	}
+	return nil
+}
+func (m *MockUserRepo) MarkProviderKeyValid(ctx context.Context, userID int64) error {
+	if m.MarkProviderKeyValidFunc != nil {
+		return m.MarkProviderKeyValidFunc(ctx, userID)
+	}
 	return nil
 }
+func (m *MockUserRepo) RecordProviderKeyFailure(ctx context.Context, userID int64) (int, error) {
+	if m.RecordProviderKeyFailureFunc != nil {
+		return m.RecordProviderKeyFailureFunc(ctx, userID)
+	}
+	return 0, nil
+}
 func (m *MockUserRepo) SetHasFinishedOpenfinanceFlow(ctx context.Context, userID int64, value bool) error {
 	return nil
 }
+func (m *MockUserRepo) SetInsightsStartDate(ctx context.Context, userID int64, date *time.Time) error {
+	return nil
+}
 
 // MockAccountRepo for Service
 type MockAccountRepo struct {
@@ -115,6 +168,7 @@ type MockAccountRepo struct {
 	GetByIDFunc                func(ctx context.Context, id string) (*account.Account, error)
 	ListByUserIDFunc           func(ctx context.Context, userID int64) ([]*account.Account, error)
 	ListByUserIDWithBankFunc   func(ctx context.Context, userID int64) ([]*account.AccountWithBank, error)
+	GetByIDWithBankFunc        func(ctx context.Context, id string) (*account.AccountWithBank, error)
 	DeleteFunc                 func(ctx context.Context, id string) error
 	UpdateFunc                 func(ctx context.Context, id string, params account.UpdateParams) (*account.Account, error)
 	UpsertFunc                 func(ctx context.Context, params account.UpsertParams) (*account.Account, error)
@@ -126,6 +180,9 @@ type MockAccountRepo struct {
 	RestoreFunc                func(ctx context.Context, id string) error
 	DeleteByItemIDFunc         func(ctx context.Context, itemID string) error
 	ListByItemIDFunc           func(ctx context.Context, itemID string) ([]*account.Account, error)
+	MarkRemovedFunc            func(ctx context.Context, ids []string) (int64, error)
+	RestoreProviderRemovedFunc func(ctx context.Context, id string) (bool, error)
+	MergeIntoFunc              func(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error)
 	DeleteBankDataFunc         func(ctx context.Context, itemID string) error
 }
 
@@ -140,6 +197,9 @@ func (m *MockAccountRepo) Create(ctx context.Context, params account.CreateParam
 	return nil, nil
 }
 func (m *MockAccountRepo) GetByID(ctx context.Context, id string) (*account.Account, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
 	return nil, nil
 }
 func (m *MockAccountRepo) ListByUserID(ctx context.Context, userID int64) ([]*account.Account, error) {
@@ -168,9 +228,15 @@ func (m *MockAccountRepo) Exists(ctx context.Context, id string) (bool, error) {
 	return false, nil
 }
 func (m *MockAccountRepo) FindByMatch(ctx context.Context, userID int64, name, accountType, subtype string) (*account.Account, error) {
+	if m.FindByMatchFunc != nil {
+		return m.FindByMatchFunc(ctx, userID, name, accountType, subtype)
+	}
 	return nil, nil
 }
 func (m *MockAccountRepo) UpdateBankID(ctx context.Context, accountID string, bankID int64) error {
+	if m.UpdateBankIDFunc != nil {
+		return m.UpdateBankIDFunc(ctx, accountID, bankID)
+	}
 	return nil
 }
 func (m *MockAccountRepo) ListByUserIDWithBank(ctx context.Context, userID int64) ([]*account.AccountWithBank, error) {
@@ -180,6 +246,13 @@ func (m *MockAccountRepo) ListByUserIDWithBank(ctx context.Context, userID int64
 	return nil, nil
 }
 
+func (m *MockAccountRepo) GetByIDWithBank(ctx context.Context, id string) (*account.AccountWithBank, error) {
+	if m.GetByIDWithBankFunc != nil {
+		return m.GetByIDWithBankFunc(ctx, id)
+	}
+	return nil, nil
+}
+
 func (m *MockAccountRepo) SoftRemove(ctx context.Context, id string) error {
 	if m.SoftRemoveFunc != nil {
 		return m.SoftRemoveFunc(ctx, id)
@@ -207,6 +280,25 @@ func (m *MockAccountRepo) ListByItemID(ctx context.Context, itemID string) ([]*a
 	}
 	return nil, nil
 }
+
+func (m *MockAccountRepo) MarkRemoved(ctx context.Context, ids []string) (int64, error) {
+	if m.MarkRemovedFunc != nil {
+		return m.MarkRemovedFunc(ctx, ids)
+	}
+	return 0, nil
+}
+func (m *MockAccountRepo) RestoreProviderRemoved(ctx context.Context, id string) (bool, error) {
+	if m.RestoreProviderRemovedFunc != nil {
+		return m.RestoreProviderRemovedFunc(ctx, id)
+	}
+	return false, nil
+}
+func (m *MockAccountRepo) MergeInto(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error) {
+	if m.MergeIntoFunc != nil {
+		return m.MergeIntoFunc(ctx, duplicateID, canonicalID)
+	}
+	return account.MergeCounts{}, nil
+}
 func (m *MockAccountRepo) DeleteBankData(ctx context.Context, itemID string) error {
 	if m.DeleteBankDataFunc != nil {
 		return m.DeleteBankDataFunc(ctx, itemID)
@@ -246,12 +338,14 @@ func TestSyncUserAccounts(t *testing.T) {
 							Success: true,
 							Data: []ofclient.Account{
 								{
-									AccountID:           "acc-1",
-									ItemID:              "item-1",
-									AccountName:         "My Bank",
-									AccountType:         "BANK",
-									AccountCurrencyCode: "USD",
-									BalanceString:       "100.50",
+									AccountID:            "acc-1",
+									ItemID:               "item-1",
+									AccountName:          "My Bank",
+									AccountMarketingName: " Ultravioleta ",
+									ProviderCode:         "260",
+									AccountType:          "BANK",
+									AccountCurrencyCode:  "USD",
+									BalanceString:        "100.50",
 								},
 							},
 						}, nil
@@ -277,6 +371,12 @@ func TestSyncUserAccounts(t *testing.T) {
 						if params.Balance != 100.50 {
 							t.Errorf("Upsert Balance = %f, want 100.50", params.Balance)
 						}
+						if params.MarketingName != "Ultravioleta" {
+							t.Errorf("Upsert MarketingName = %q, want Ultravioleta", params.MarketingName)
+						}
+						if params.ProviderCode != "260" {
+							t.Errorf("Upsert ProviderCode = %q, want 260", params.ProviderCode)
+						}
 						return &account.Account{ID: params.ID}, nil
 					},
 				}
@@ -341,6 +441,7 @@ func TestSyncUserAccounts(t *testing.T) {
 				tt.mockUser(),
 				accService,
 				tt.mockItem(),
+				nil,      // bank sync not needed for this test
 				nil, nil, // notification service and msgs not needed for this test
 			)
 
@@ -364,3 +465,526 @@ func TestSyncUserAccounts(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncUserAccounts_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	key := "valid-key"
+
+	var upserted, markedIDs []string
+	accRepo := &MockAccountRepo{
+		ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+			return id == "acc-existing", nil
+		},
+		UpsertFunc: func(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+			upserted = append(upserted, params.ID)
+			return &account.Account{ID: params.ID}, nil
+		},
+		ListByItemIDFunc: func(ctx context.Context, itemID string) ([]*account.Account, error) {
+			return []*account.Account{
+				{ID: "acc-new", UserID: 1, ItemID: "item-1"},
+				{ID: "acc-bad-balance", UserID: 1, ItemID: "item-1"},
+				{ID: "acc-existing", UserID: 1, ItemID: "item-1"},
+			}, nil
+		},
+		MarkRemovedFunc: func(ctx context.Context, ids []string) (int64, error) {
+			markedIDs = ids
+			return int64(len(ids)), nil
+		},
+	}
+	itemRepo := &MockItemRepo{
+		FindOrCreateFunc: func(ctx context.Context, id string, userID int64) (*models.Item, error) {
+			return &models.Item{ID: id, UserID: userID}, nil
+		},
+	}
+	client := &MockClient{
+		GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+			return &ofclient.AccountResponse{
+				Success: true,
+				Data: []ofclient.Account{
+					{AccountID: "acc-new", ItemID: "item-1", AccountName: "New", AccountType: "BANK", BalanceString: "10.00"},
+					{AccountID: "acc-bad-balance", ItemID: "item-1", AccountName: "Bad balance", AccountType: "BANK", BalanceString: "R$ 10,00"},
+					{AccountID: "acc-bad-date", ItemID: "item-1", AccountName: "Bad date", AccountType: "BANK", CreatedAt: "yesterday"},
+					{AccountID: "acc-existing", ItemID: "item-1", AccountName: "Existing", AccountType: "BANK", BalanceString: "5"},
+				},
+			}, nil
+		},
+	}
+	userRepo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return &user.User{ID: 1, ProviderKey: &key}, nil
+		},
+	}
+
+	accService := account.NewService(accRepo, itemRepo, &MockTransactionRepo{})
+	svc := NewAccountSyncService(client, userRepo, accService, itemRepo, nil, nil, nil)
+
+	got, err := svc.SyncUserAccounts(ctx, 1)
+	if err != nil {
+		t.Fatalf("SyncUserAccounts() unexpected error: %v", err)
+	}
+	if got.Synced() != 2 || got.Created != 1 || got.Updated != 1 {
+		t.Errorf("synced = %d (created %d, updated %d), want 2 (1, 1)", got.Synced(), got.Created, got.Updated)
+	}
+	if got.Failed != 2 || len(got.Failures) != 2 || len(got.Errors) != 2 {
+		t.Fatalf("failed = %d, failures = %+v, errors = %v, want 2 of each", got.Failed, got.Failures, got.Errors)
+	}
+	if got.Failures[0].AccountID != "acc-bad-balance" || got.Failures[1].AccountID != "acc-bad-date" {
+		t.Errorf("failures = %+v, want acc-bad-balance and acc-bad-date", got.Failures)
+	}
+	if len(upserted) != 2 || upserted[0] != "acc-new" || upserted[1] != "acc-existing" {
+		t.Errorf("upserted = %v, want [acc-new acc-existing]", upserted)
+	}
+	// A malformed account is still reported by the provider and must not be removed
+	if len(markedIDs) != 0 {
+		t.Errorf("marked removed = %v, want none", markedIDs)
+	}
+}
+
+func TestSyncUserAccounts_ReconcilesRemovedAccounts(t *testing.T) {
+	ctx := context.Background()
+	key := "valid-key"
+
+	var markedIDs []string
+	accRepo := &MockAccountRepo{
+		ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+			return false, nil
+		},
+		UpsertFunc: func(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+			return &account.Account{ID: params.ID}, nil
+		},
+		ListByItemIDFunc: func(ctx context.Context, itemID string) ([]*account.Account, error) {
+			if itemID != "item-1" {
+				t.Errorf("ListByItemID itemID = %s, want item-1", itemID)
+			}
+			return []*account.Account{
+				{ID: "acc-1", UserID: 1, ItemID: "item-1"},
+				{ID: "acc-closed", UserID: 1, ItemID: "item-1"},
+			}, nil
+		},
+		MarkRemovedFunc: func(ctx context.Context, ids []string) (int64, error) {
+			markedIDs = ids
+			return int64(len(ids)), nil
+		},
+	}
+	itemRepo := &MockItemRepo{
+		FindOrCreateFunc: func(ctx context.Context, id string, userID int64) (*models.Item, error) {
+			return &models.Item{ID: id, UserID: userID}, nil
+		},
+	}
+	client := &MockClient{
+		GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+			return &ofclient.AccountResponse{
+				Success: true,
+				Data: []ofclient.Account{
+					{AccountID: "acc-1", ItemID: "item-1", AccountName: "My Bank", AccountType: "BANK", AccountCurrencyCode: "BRL"},
+				},
+			}, nil
+		},
+	}
+	userRepo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return &user.User{ID: 1, ProviderKey: &key}, nil
+		},
+	}
+
+	accService := account.NewService(accRepo, itemRepo, &MockTransactionRepo{})
+	svc := NewAccountSyncService(client, userRepo, accService, itemRepo, nil, nil, nil)
+
+	got, err := svc.SyncUserAccounts(ctx, 1)
+	if err != nil {
+		t.Fatalf("SyncUserAccounts() unexpected error: %v", err)
+	}
+	if got.Removed != 1 {
+		t.Errorf("SyncUserAccounts() removed = %d, want 1", got.Removed)
+	}
+	if len(markedIDs) != 1 || markedIDs[0] != "acc-closed" {
+		t.Errorf("MarkRemoved ids = %v, want [acc-closed]", markedIDs)
+	}
+}
+
+func TestSyncUserAccounts_RestoresProviderRemovedAccounts(t *testing.T) {
+	key := "valid-key"
+
+	tests := []struct {
+		name         string
+		restorable   bool
+		wantUpserted bool
+	}{
+		{name: "removed by the provider", restorable: true, wantUpserted: true},
+		{name: "removed by the user", restorable: false, wantUpserted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removedAt := time.Now()
+			var restoreCalls int
+			var upserted bool
+			accRepo := &MockAccountRepo{
+				ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+					return true, nil
+				},
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					return &account.Account{ID: id, UserID: 1, ItemID: "item-1", RemovedAt: &removedAt}, nil
+				},
+				RestoreProviderRemovedFunc: func(ctx context.Context, id string) (bool, error) {
+					restoreCalls++
+					return tt.restorable, nil
+				},
+				UpsertFunc: func(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+					upserted = true
+					return &account.Account{ID: params.ID}, nil
+				},
+			}
+			itemRepo := &MockItemRepo{
+				FindOrCreateFunc: func(ctx context.Context, id string, userID int64) (*models.Item, error) {
+					return &models.Item{ID: id, UserID: userID}, nil
+				},
+			}
+			client := &MockClient{
+				GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+					return &ofclient.AccountResponse{
+						Success: true,
+						Data: []ofclient.Account{
+							{AccountID: "acc-1", ItemID: "item-1", AccountName: "My Bank", AccountType: "BANK", AccountCurrencyCode: "BRL"},
+						},
+					}, nil
+				},
+			}
+			userRepo := &MockUserRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+					return &user.User{ID: 1, ProviderKey: &key}, nil
+				},
+			}
+
+			accService := account.NewService(accRepo, itemRepo, &MockTransactionRepo{})
+			svc := NewAccountSyncService(client, userRepo, accService, itemRepo, nil, nil, nil)
+
+			if _, err := svc.SyncUserAccounts(context.Background(), 1); err != nil {
+				t.Fatalf("SyncUserAccounts() unexpected error: %v", err)
+			}
+			if restoreCalls != 1 {
+				t.Errorf("RestoreProviderRemoved calls = %d, want 1", restoreCalls)
+			}
+			if upserted != tt.wantUpserted {
+				t.Errorf("account upserted = %v, want %v", upserted, tt.wantUpserted)
+			}
+		})
+	}
+}
+
+func TestSyncUserAccounts_SkipsExcludedItems(t *testing.T) {
+	ctx := context.Background()
+	key := "valid-key"
+
+	accRepo := &MockAccountRepo{
+		UpsertFunc: func(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+			if params.ItemID == "item-disabled" {
+				t.Errorf("Upsert called for account %s of disabled item", params.ID)
+			}
+			return &account.Account{ID: params.ID}, nil
+		},
+		MarkRemovedFunc: func(ctx context.Context, ids []string) (int64, error) {
+			t.Errorf("MarkRemoved should not be called, got %v", ids)
+			return 0, nil
+		},
+	}
+	itemRepo := &MockItemRepo{
+		FindOrCreateFunc: func(ctx context.Context, id string, userID int64) (*models.Item, error) {
+			return &models.Item{ID: id, UserID: userID}, nil
+		},
+		ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*models.Item, error) {
+			return []*models.Item{
+				{ID: "item-ok", UserID: userID},
+				{ID: "item-disabled", UserID: userID, Disabled: true},
+			}, nil
+		},
+	}
+	client := &MockClient{
+		GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+			return &ofclient.AccountResponse{
+				Success: true,
+				Data: []ofclient.Account{
+					{AccountID: "acc-1", ItemID: "item-ok", AccountName: "Checking", AccountType: "BANK", AccountCurrencyCode: "BRL"},
+					{AccountID: "acc-2", ItemID: "item-disabled", AccountName: "Card", AccountType: "CREDIT", AccountCurrencyCode: "BRL"},
+				},
+			}, nil
+		},
+	}
+	userRepo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return &user.User{ID: 1, ProviderKey: &key}, nil
+		},
+	}
+
+	accService := account.NewService(accRepo, itemRepo, &MockTransactionRepo{})
+	svc := NewAccountSyncService(client, userRepo, accService, itemRepo, nil, nil, nil)
+
+	got, err := svc.SyncUserAccounts(ctx, 1)
+	if err != nil {
+		t.Fatalf("SyncUserAccounts() unexpected error: %v", err)
+	}
+	if got.Created != 1 {
+		t.Errorf("SyncUserAccounts() created = %d, want 1", got.Created)
+	}
+	if got.Skipped != 1 {
+		t.Errorf("SyncUserAccounts() skipped = %d, want 1", got.Skipped)
+	}
+}
+
+func TestSyncUserAccounts_SkipsExcludedSubtypes(t *testing.T) {
+	ctx := context.Background()
+	key := "valid-key"
+
+	var upserted []string
+	accRepo := &MockAccountRepo{
+		UpsertFunc: func(ctx context.Context, params account.UpsertParams) (*account.Account, error) {
+			upserted = append(upserted, params.ID)
+			return &account.Account{ID: params.ID}, nil
+		},
+		MarkRemovedFunc: func(ctx context.Context, ids []string) (int64, error) {
+			t.Errorf("MarkRemoved should not be called for excluded accounts, got %v", ids)
+			return 0, nil
+		},
+	}
+	itemRepo := &MockItemRepo{
+		FindOrCreateFunc: func(ctx context.Context, id string, userID int64) (*models.Item, error) {
+			return &models.Item{ID: id, UserID: userID}, nil
+		},
+	}
+	client := &MockClient{
+		GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+			return &ofclient.AccountResponse{
+				Success: true,
+				Data: []ofclient.Account{
+					{AccountID: "acc-1", ItemID: "item-1", AccountName: "Checking", AccountType: "BANK", AccountSubtype: "CONTA_CORRENTE", AccountCurrencyCode: "BRL"},
+					{AccountID: "acc-2", ItemID: "item-1", AccountName: "Savings", AccountType: "BANK", AccountSubtype: "SAVINGS_ACCOUNT", AccountCurrencyCode: "BRL"},
+					{AccountID: "acc-3", ItemID: "item-1", AccountName: "Investments", AccountType: "INVESTMENT", AccountCurrencyCode: "BRL"},
+				},
+			}, nil
+		},
+	}
+	userRepo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return &user.User{ID: 1, ProviderKey: &key}, nil
+		},
+	}
+
+	accService := account.NewService(accRepo, itemRepo, &MockTransactionRepo{})
+	accService.SetSyncedSubtypes([]string{"CHECKING_ACCOUNT", "CREDIT_CARD"})
+	svc := NewAccountSyncService(client, userRepo, accService, itemRepo, nil, nil, nil)
+
+	got, err := svc.SyncUserAccounts(ctx, 1)
+	if err != nil {
+		t.Fatalf("SyncUserAccounts() unexpected error: %v", err)
+	}
+	if len(upserted) != 1 || upserted[0] != "acc-1" {
+		t.Errorf("upserted accounts = %v, want [acc-1]", upserted)
+	}
+	if got.Excluded != 2 {
+		t.Errorf("SyncUserAccounts() excluded = %d, want 2", got.Excluded)
+	}
+}
+
+func TestSyncUserAccounts_ProviderUnauthorized(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		wantCleared bool
+		wantFlagged bool
+	}{
+		{name: "first 401 keeps the key", failures: 1, wantCleared: false, wantFlagged: false},
+		{name: "below threshold keeps the key", failures: ProviderKeyMaxFailures - 1, wantCleared: false, wantFlagged: false},
+		{name: "threshold clears the key", failures: ProviderKeyMaxFailures, wantCleared: true, wantFlagged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			key := "expired-key"
+			cleared := false
+			flagged := false
+
+			client := &MockClient{
+				GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+					return nil, errors.New("unauthorized")
+				},
+				StatusCode: http.StatusUnauthorized,
+			}
+			userRepo := &MockUserRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+					return &user.User{ID: 1, ProviderKey: &key}, nil
+				},
+				RecordProviderKeyFailureFunc: func(ctx context.Context, userID int64) (int, error) {
+					return tt.failures, nil
+				},
+				ClearProviderKeyFunc: func(ctx context.Context, userID int64) error {
+					cleared = true
+					return nil
+				},
+				MarkProviderKeyValidFunc: func(ctx context.Context, userID int64) error {
+					t.Error("MarkProviderKeyValid should not be called on 401")
+					return nil
+				},
+			}
+			itemRepo := &MockItemRepo{
+				SetNeedsReconnectByUserIDFunc: func(ctx context.Context, userID int64, value bool) error {
+					flagged = value
+					return nil
+				},
+			}
+
+			accService := account.NewService(&MockAccountRepo{}, itemRepo, &MockTransactionRepo{})
+			svc := NewAccountSyncService(client, userRepo, accService, itemRepo, nil, nil, nil)
+
+			_, err := svc.SyncUserAccounts(ctx, 1)
+			if !errors.Is(err, ErrProviderUnauthorized) {
+				t.Fatalf("SyncUserAccounts() error = %v, want ErrProviderUnauthorized", err)
+			}
+			if cleared != tt.wantCleared {
+				t.Errorf("provider key cleared = %v, want %v", cleared, tt.wantCleared)
+			}
+			if flagged != tt.wantFlagged {
+				t.Errorf("items flagged = %v, want %v", flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestSyncUserAccounts_MarksProviderKeyValid(t *testing.T) {
+	key := "valid-key"
+	marked := false
+
+	userRepo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return &user.User{ID: 1, ProviderKey: &key}, nil
+		},
+		MarkProviderKeyValidFunc: func(ctx context.Context, userID int64) error {
+			marked = true
+			return nil
+		},
+	}
+
+	itemRepo := &MockItemRepo{}
+	accService := account.NewService(&MockAccountRepo{}, itemRepo, &MockTransactionRepo{})
+	svc := NewAccountSyncService(&MockClient{}, userRepo, accService, itemRepo, nil, nil, nil)
+
+	if _, err := svc.SyncUserAccounts(context.Background(), 1); err != nil {
+		t.Fatalf("SyncUserAccounts() unexpected error: %v", err)
+	}
+	if !marked {
+		t.Error("expected provider key to be marked valid after a successful fetch")
+	}
+}
+
+func TestSyncAccountBank(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentBankID int64
+		connectorBank *models.Bank // bank already registered for the connector
+		backfill      bool         // SetConnector succeeds on the current bank
+		wantBankID    int64        // expected UpdateBankID value, 0 when no update
+		wantCreated   bool
+	}{
+		{name: "links to bank with connector", connectorBank: &models.Bank{ID: 5, Name: "Nubank", Connector: "212"}, wantBankID: 5},
+		{name: "already linked", currentBankID: 5, connectorBank: &models.Bank{ID: 5, Name: "Nubank", Connector: "212"}},
+		{name: "backfills connector on name-matched bank", currentBankID: 3, backfill: true},
+		{name: "creates placeholder bank", wantBankID: 1, wantCreated: true},
+		{name: "current bank has another connector", currentBankID: 3, wantBankID: 1, wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updatedBankID int64
+			created := false
+
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					return &account.Account{ID: id, UserID: 1, BankID: tt.currentBankID}, nil
+				},
+				UpdateBankIDFunc: func(ctx context.Context, accountID string, bankID int64) error {
+					updatedBankID = bankID
+					return nil
+				},
+			}
+			bankRepo := &MockBankRepo{
+				FindByConnectorFunc: func(ctx context.Context, connector string) (*models.Bank, error) {
+					return tt.connectorBank, nil
+				},
+				SetConnectorFunc: func(ctx context.Context, id int64, connector string) (bool, error) {
+					return tt.backfill, nil
+				},
+				FindOrCreateByConnectorFunc: func(ctx context.Context, name, connector string) (*models.Bank, error) {
+					created = true
+					return &models.Bank{ID: 1, Name: name, Connector: connector}, nil
+				},
+			}
+			itemRepo := &MockItemRepo{}
+			accService := account.NewService(accRepo, itemRepo, &MockTransactionRepo{})
+			svc := NewAccountSyncService(&MockClient{}, &MockUserRepo{}, accService, itemRepo, bankRepo, nil, nil)
+
+			acc := &account.Account{ID: "acc-1", UserID: 1, BankID: tt.currentBankID}
+			if err := svc.syncAccountBank(context.Background(), 1, acc, "212"); err != nil {
+				t.Fatalf("syncAccountBank() unexpected error: %v", err)
+			}
+
+			if updatedBankID != tt.wantBankID {
+				t.Errorf("UpdateBankID called with %d, want %d", updatedBankID, tt.wantBankID)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("bank created = %v, want %v", created, tt.wantCreated)
+			}
+		})
+	}
+}
+
+func TestSyncAccountBank_AppliesBranding(t *testing.T) {
+	catalog, err := branding.Load("", "https://cdn.example.com/banks")
+	if err != nil {
+		t.Fatalf("branding.Load() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		connector string
+		wantColor string
+		wantLogo  string
+		wantCall  bool
+	}{
+		{name: "known connector", connector: "260", wantColor: "820AD1", wantLogo: "https://cdn.example.com/banks/nubank.png", wantCall: true},
+		{name: "unknown connector", connector: "999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			var gotID int64
+			var gotColor, gotLogo string
+			bankRepo := &MockBankRepo{
+				FindByConnectorFunc: func(ctx context.Context, connector string) (*models.Bank, error) {
+					return &models.Bank{ID: 4, Connector: connector}, nil
+				},
+				ApplyBrandingFunc: func(ctx context.Context, id int64, primaryColor, logoURL string) error {
+					called = true
+					gotID, gotColor, gotLogo = id, primaryColor, logoURL
+					return nil
+				},
+			}
+			itemRepo := &MockItemRepo{}
+			accService := account.NewService(&MockAccountRepo{}, itemRepo, &MockTransactionRepo{})
+			svc := NewAccountSyncService(&MockClient{}, &MockUserRepo{}, accService, itemRepo, bankRepo, nil, nil)
+			svc.SetBankBranding(catalog)
+
+			acc := &account.Account{ID: "acc-1", UserID: 1, BankID: 4}
+			if err := svc.syncAccountBank(context.Background(), 1, acc, tt.connector); err != nil {
+				t.Fatalf("syncAccountBank() unexpected error: %v", err)
+			}
+
+			if called != tt.wantCall {
+				t.Fatalf("ApplyBranding called = %v, want %v", called, tt.wantCall)
+			}
+			if called && (gotID != 4 || gotColor != tt.wantColor || gotLogo != tt.wantLogo) {
+				t.Errorf("ApplyBranding(%d, %q, %q), want (4, %q, %q)", gotID, gotColor, gotLogo, tt.wantColor, tt.wantLogo)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 
 	"parsa/internal/domain/account"
 	"parsa/internal/domain/bill"
@@ -56,6 +57,12 @@ func NewBillSyncService(
 	}
 }
 
+// SetDuplicateCheckService replaces the default duplicate checker, so bill payments are
+// matched with the deployment's duplicate settings
+func (s *BillSyncService) SetDuplicateCheckService(duplicateCheckService *transaction.DuplicateCheckService) {
+	s.duplicateCheckService = duplicateCheckService
+}
+
 // SyncUserBills syncs all past due credit card bills for a specific user
 func (s *BillSyncService) SyncUserBills(ctx context.Context, userID int64) (*BillSyncResult, error) {
 	result := &BillSyncResult{
@@ -64,17 +71,17 @@ func (s *BillSyncService) SyncUserBills(ctx context.Context, userID int64) (*Bil
 	}
 
 	// Get user's API key
-	user, err := s.userRepo.GetByID(ctx, userID)
+	providerKey, err := loadProviderKey(ctx, s.userRepo, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	if user.ProviderKey == nil || *user.ProviderKey == "" {
+	if providerKey == "" {
 		return nil, fmt.Errorf("user has no provider API key configured")
 	}
 
 	// Fetch past due bills from provider
-	billResp, err := s.client.GetBills(ctx, *user.ProviderKey)
+	billResp, err := s.client.GetBills(ctx, providerKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch bills from provider: %w", err)
 	}
@@ -139,9 +146,11 @@ func (s *BillSyncService) processBill(
 		}
 	}
 
-	// If no direct match, try matching by account name/type/subtype
+	// If no direct match, try matching by account name/type/subtype. Stored subtypes are
+	// canonical, so the bill's subtype is normalized the same way first.
 	if matchedAccount == nil && apiBill.AccountName != "" {
-		matchKey := fmt.Sprintf("%s|%s|%s", apiBill.AccountName, apiBill.AccountType, apiBill.AccountSubtype)
+		subtype := s.accountService.NormalizeSubtype(apiBill.AccountSubtype)
+		matchKey := fmt.Sprintf("%s|%s|%s", apiBill.AccountName, apiBill.AccountType, subtype)
 		if acc, ok := accountCache[matchKey]; ok {
 			matchedAccount = acc
 		}
@@ -157,8 +166,8 @@ func (s *BillSyncService) processBill(
 	}
 
 	if matchedAccount == nil {
-		log.Printf("Skipping bill %s: no matching account found for id=%s, name=%s, type=%s, subtype=%s",
-			apiBill.ID, apiBill.AccountID, apiBill.AccountName, apiBill.AccountType, apiBill.AccountSubtype)
+		slog.Debug("Skipping bill: no matching account found", "bill_id", apiBill.ID, "account_id", apiBill.AccountID,
+			"account_name", apiBill.AccountName, "account_type", apiBill.AccountType, "account_subtype", apiBill.AccountSubtype)
 		result.Skipped++
 		return nil
 	}
@@ -220,7 +229,7 @@ func (s *BillSyncService) processBill(
 		userID,
 	)
 	if err != nil {
-		log.Printf("Error checking bill duplicates for bill %s: %v", savedBill.ID, err)
+		slog.Warn("Failed to check bill duplicates", "bill_id", savedBill.ID, "error", err)
 	} else {
 		result.DuplicatesFound += found
 		result.DuplicatesMarked += marked
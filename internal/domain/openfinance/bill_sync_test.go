@@ -0,0 +1,235 @@
+package openfinance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/user"
+	ofclient "parsa/internal/infrastructure/openfinance"
+)
+
+// MockBillRepo implements bill.Repository as an in-memory store keyed by bill ID
+type MockBillRepo struct {
+	Bills      map[string]*bill.Bill
+	UpsertFunc func(ctx context.Context, params bill.UpsertParams) (*bill.Bill, error)
+}
+
+func newMockBillRepo(existing ...*bill.Bill) *MockBillRepo {
+	m := &MockBillRepo{Bills: make(map[string]*bill.Bill)}
+	for _, b := range existing {
+		m.Bills[b.ID] = b
+	}
+	return m
+}
+
+func (m *MockBillRepo) Create(ctx context.Context, params bill.CreateParams) (*bill.Bill, error) {
+	b := &bill.Bill{ID: params.ID, AccountID: params.AccountID, DueDate: params.DueDate, TotalAmount: params.TotalAmount}
+	m.Bills[b.ID] = b
+	return b, nil
+}
+
+func (m *MockBillRepo) GetByID(ctx context.Context, id string) (*bill.Bill, error) {
+	if b, ok := m.Bills[id]; ok {
+		return b, nil
+	}
+	return nil, bill.ErrBillNotFound
+}
+
+func (m *MockBillRepo) ListByAccountID(ctx context.Context, accountID string, limit, offset int) ([]*bill.Bill, error) {
+	var bills []*bill.Bill
+	for _, b := range m.Bills {
+		if b.AccountID == accountID {
+			bills = append(bills, b)
+		}
+	}
+	return bills, nil
+}
+
+func (m *MockBillRepo) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*bill.Bill, error) {
+	return nil, nil
+}
+
+func (m *MockBillRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	return int64(len(m.Bills)), nil
+}
+
+func (m *MockBillRepo) Update(ctx context.Context, id string, params bill.UpdateParams) (*bill.Bill, error) {
+	return m.Bills[id], nil
+}
+
+func (m *MockBillRepo) Delete(ctx context.Context, id string) error {
+	delete(m.Bills, id)
+	return nil
+}
+
+func (m *MockBillRepo) Upsert(ctx context.Context, params bill.UpsertParams) (*bill.Bill, error) {
+	if m.UpsertFunc != nil {
+		return m.UpsertFunc(ctx, params)
+	}
+	b := &bill.Bill{
+		ID:            params.ID,
+		AccountID:     params.AccountID,
+		DueDate:       params.DueDate,
+		TotalAmount:   params.TotalAmount,
+		IsOpenFinance: true,
+	}
+	m.Bills[b.ID] = b
+	return b, nil
+}
+
+func TestSyncUserBills(t *testing.T) {
+	ctx := context.Background()
+	key := "valid-key"
+	removedAt := time.Now()
+
+	accounts := []*account.Account{
+		{ID: "cc-1", Name: "Nubank Card", AccountType: "CREDIT", Subtype: "CREDIT_CARD"},
+		{ID: "cc-old", Name: "Old Card", AccountType: "CREDIT", Subtype: "CREDIT_CARD", RemovedAt: &removedAt},
+	}
+
+	tests := []struct {
+		name          string
+		bills         []ofclient.Bill
+		existing      []*bill.Bill
+		findByMatch   func(ctx context.Context, userID int64, name, accountType, subtype string) (*account.Account, error)
+		clientErr     error
+		wantErr       bool
+		wantCreated   int
+		wantUpdated   int
+		wantSkipped   int
+		wantErrors    int
+		wantAccountID map[string]string // bill ID -> account the stored bill points to
+	}{
+		{
+			name: "Creates bill matched by account ID",
+			bills: []ofclient.Bill{
+				{ID: "bill-1", AccountID: "cc-1", DueDateString: "2024-03-10", TotalAmountString: "1500.75"},
+			},
+			wantCreated:   1,
+			wantAccountID: map[string]string{"bill-1": "cc-1"},
+		},
+		{
+			name: "Updates existing bill",
+			bills: []ofclient.Bill{
+				{ID: "bill-1", AccountID: "cc-1", DueDateString: "2024-03-10", TotalAmountString: "1600.00"},
+			},
+			existing:      []*bill.Bill{{ID: "bill-1", AccountID: "cc-1", TotalAmount: 1500.75}},
+			wantUpdated:   1,
+			wantAccountID: map[string]string{"bill-1": "cc-1"},
+		},
+		{
+			name: "Matches by account name, type and subtype",
+			bills: []ofclient.Bill{
+				{ID: "bill-2", AccountID: "provider-other-id", AccountName: "Nubank Card", AccountType: "CREDIT", AccountSubtype: "CREDIT_CARD", DueDateString: "2024-03-10T00:00:00Z"},
+			},
+			wantCreated:   1,
+			wantAccountID: map[string]string{"bill-2": "cc-1"},
+		},
+		{
+			name: "Falls back to database match",
+			bills: []ofclient.Bill{
+				{ID: "bill-3", AccountName: "Inter Card", AccountType: "CREDIT", AccountSubtype: "CREDIT_CARD", DueDateString: "2024-03-10 00:00:00"},
+			},
+			findByMatch: func(ctx context.Context, userID int64, name, accountType, subtype string) (*account.Account, error) {
+				if name == "Inter Card" {
+					return &account.Account{ID: "cc-2", Name: name}, nil
+				}
+				return nil, nil
+			},
+			wantCreated:   1,
+			wantAccountID: map[string]string{"bill-3": "cc-2"},
+		},
+		{
+			name: "Skips bills for removed or unknown accounts",
+			bills: []ofclient.Bill{
+				{ID: "bill-4", AccountID: "cc-old", DueDateString: "2024-03-10"},
+				{ID: "bill-5", AccountID: "cc-missing", DueDateString: "2024-03-10"},
+			},
+			wantSkipped: 2,
+		},
+		{
+			name: "Records errors for unparseable bills and keeps going",
+			bills: []ofclient.Bill{
+				{ID: "bill-6", AccountID: "cc-1", TotalAmountString: "1500.75"},
+				{ID: "bill-7", AccountID: "cc-1", DueDateString: "2024-03-10", TotalAmountString: "abc"},
+				{ID: "bill-8", AccountID: "cc-1", DueDateString: "2024-03-10", TotalAmountString: "99.90"},
+			},
+			wantCreated:   1,
+			wantErrors:    2,
+			wantAccountID: map[string]string{"bill-8": "cc-1"},
+		},
+		{
+			name:      "Provider error fails the sync",
+			clientErr: context.DeadlineExceeded,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{
+				GetBillsFunc: func(ctx context.Context, apiKey string) (*ofclient.BillResponse, error) {
+					if tt.clientErr != nil {
+						return nil, tt.clientErr
+					}
+					return &ofclient.BillResponse{Success: true, Data: tt.bills}, nil
+				},
+			}
+			userRepo := &MockUserRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+					return &user.User{ID: 1, ProviderKey: &key}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*account.Account, error) {
+					return accounts, nil
+				},
+				FindByMatchFunc: tt.findByMatch,
+			}
+			txRepo := &MockTransactionRepo{}
+			billRepo := newMockBillRepo(tt.existing...)
+			accService := account.NewService(accRepo, &MockItemRepo{}, txRepo)
+
+			svc := NewBillSyncService(client, userRepo, accService, accRepo, billRepo, txRepo)
+			got, err := svc.SyncUserBills(ctx, 1)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SyncUserBills() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SyncUserBills() unexpected error: %v", err)
+			}
+			if got.BillsFound != len(tt.bills) {
+				t.Errorf("SyncUserBills() found = %d, want %d", got.BillsFound, len(tt.bills))
+			}
+			if got.Created != tt.wantCreated {
+				t.Errorf("SyncUserBills() created = %d, want %d", got.Created, tt.wantCreated)
+			}
+			if got.Updated != tt.wantUpdated {
+				t.Errorf("SyncUserBills() updated = %d, want %d", got.Updated, tt.wantUpdated)
+			}
+			if got.Skipped != tt.wantSkipped {
+				t.Errorf("SyncUserBills() skipped = %d, want %d", got.Skipped, tt.wantSkipped)
+			}
+			if len(got.Errors) != tt.wantErrors {
+				t.Errorf("SyncUserBills() errors = %v, want %d", got.Errors, tt.wantErrors)
+			}
+			for billID, accountID := range tt.wantAccountID {
+				stored, ok := billRepo.Bills[billID]
+				if !ok {
+					t.Errorf("bill %s was not stored", billID)
+					continue
+				}
+				if stored.AccountID != accountID {
+					t.Errorf("bill %s account = %s, want %s", billID, stored.AccountID, accountID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package openfinance
+
+import (
+	"context"
+	"sync"
+
+	"parsa/internal/domain/user"
+)
+
+// providerKeyScope holds one user's decrypted provider key for the length of a sync job, so the
+// account, transaction and bill syncs of the job share a single decryption. It only lives on
+// the job's context; no service keeps a reference to it.
+type providerKeyScope struct {
+	mu     sync.Mutex
+	userID int64
+	key    []byte
+	loaded bool
+}
+
+type providerKeyScopeKey struct{}
+
+// WithProviderKeyScope returns a context on which the sync services load userID's provider key
+// at most once. The returned release func wipes the cached key and must be called when the
+// job ends; it is safe to call more than once.
+func WithProviderKeyScope(ctx context.Context, userID int64) (context.Context, func()) {
+	scope := &providerKeyScope{userID: userID}
+	return context.WithValue(ctx, providerKeyScopeKey{}, scope), scope.wipe
+}
+
+// loadProviderKey returns the user's decrypted provider key, or "" when the user has none.
+// Inside a scope for the same user the key is read from userRepo (which decrypts it) only
+// the first time; outside one every call reads it.
+func loadProviderKey(ctx context.Context, userRepo user.Repository, userID int64) (string, error) {
+	scope, _ := ctx.Value(providerKeyScopeKey{}).(*providerKeyScope)
+	if scope == nil || scope.userID != userID {
+		return readProviderKey(ctx, userRepo, userID)
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if !scope.loaded {
+		key, err := readProviderKey(ctx, userRepo, userID)
+		if err != nil {
+			return "", err
+		}
+		scope.key = []byte(key)
+		scope.loaded = true
+	}
+	return string(scope.key), nil
+}
+
+// forgetProviderKey drops the scoped key, e.g. after the provider rejected it, so later
+// steps of the job read the stored key again
+func forgetProviderKey(ctx context.Context) {
+	if scope, _ := ctx.Value(providerKeyScopeKey{}).(*providerKeyScope); scope != nil {
+		scope.wipe()
+	}
+}
+
+// readProviderKey reads the user and returns their decrypted provider key
+func readProviderKey(ctx context.Context, userRepo user.Repository, userID int64) (string, error) {
+	u, err := userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if u.ProviderKey == nil {
+		return "", nil
+	}
+	return *u.ProviderKey, nil
+}
+
+// wipe zeroes and drops the cached key
+func (s *providerKeyScope) wipe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.key)
+	s.key = nil
+	s.loaded = false
+}
@@ -0,0 +1,243 @@
+package openfinance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/user"
+	ofclient "parsa/internal/infrastructure/openfinance"
+)
+
+// countingUserRepo returns a MockUserRepo whose GetByID hands out key and counts the reads
+func countingUserRepo(key string, reads *atomic.Int32) *MockUserRepo {
+	return &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			reads.Add(1)
+			k := key
+			return &user.User{ID: id, ProviderKey: &k}, nil
+		},
+	}
+}
+
+func TestLoadProviderKey_Scope(t *testing.T) {
+	var reads atomic.Int32
+	repo := countingUserRepo("secret-key", &reads)
+
+	ctx, release := WithProviderKeyScope(context.Background(), 1)
+	for range 3 {
+		key, err := loadProviderKey(ctx, repo, 1)
+		if err != nil {
+			t.Fatalf("loadProviderKey: %v", err)
+		}
+		if key != "secret-key" {
+			t.Fatalf("key = %q, want secret-key", key)
+		}
+	}
+	if got := reads.Load(); got != 1 {
+		t.Errorf("reads within a scope = %d, want 1", got)
+	}
+
+	// Another user's key is never served from the scope
+	if _, err := loadProviderKey(ctx, repo, 2); err != nil {
+		t.Fatalf("loadProviderKey: %v", err)
+	}
+	if got := reads.Load(); got != 2 {
+		t.Errorf("reads after another user's load = %d, want 2", got)
+	}
+
+	scope := ctx.Value(providerKeyScopeKey{}).(*providerKeyScope)
+	cached := scope.key
+	release()
+	if scope.key != nil || scope.loaded {
+		t.Error("release left the key in the scope")
+	}
+	if strings.Trim(string(cached), "\x00") != "" {
+		t.Errorf("release did not zero the key bytes: %q", cached)
+	}
+	release() // releasing twice is harmless
+
+	// A released scope reads the key again instead of serving a stale one
+	if _, err := loadProviderKey(ctx, repo, 1); err != nil {
+		t.Fatalf("loadProviderKey: %v", err)
+	}
+	if got := reads.Load(); got != 3 {
+		t.Errorf("reads after release = %d, want 3", got)
+	}
+}
+
+func TestLoadProviderKey_WithoutScope(t *testing.T) {
+	var reads atomic.Int32
+	repo := countingUserRepo("secret-key", &reads)
+
+	for range 2 {
+		if _, err := loadProviderKey(context.Background(), repo, 1); err != nil {
+			t.Fatalf("loadProviderKey: %v", err)
+		}
+	}
+	if got := reads.Load(); got != 2 {
+		t.Errorf("reads without a scope = %d, want 2", got)
+	}
+}
+
+func TestLoadProviderKey_Concurrent(t *testing.T) {
+	var reads atomic.Int32
+	repo := countingUserRepo("secret-key", &reads)
+
+	ctx, release := WithProviderKeyScope(context.Background(), 1)
+	defer release()
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if key, err := loadProviderKey(ctx, repo, 1); err != nil || key != "secret-key" {
+				t.Errorf("loadProviderKey = %q, %v", key, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := reads.Load(); got != 1 {
+		t.Errorf("concurrent reads = %d, want 1", got)
+	}
+}
+
+func TestLoadProviderKey_RepoError(t *testing.T) {
+	repo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return nil, errors.New("db down")
+		},
+	}
+	ctx, release := WithProviderKeyScope(context.Background(), 1)
+	defer release()
+
+	if _, err := loadProviderKey(ctx, repo, 1); err == nil {
+		t.Fatal("expected an error")
+	}
+	if scope := ctx.Value(providerKeyScopeKey{}).(*providerKeyScope); scope.loaded {
+		t.Error("a failed read must not be cached")
+	}
+}
+
+// TestSyncServices_DoNotRetainProviderKey runs the three syncs of a job on one scope and then
+// checks that none of the long-lived services holds the plaintext key anywhere in its fields.
+func TestSyncServices_DoNotRetainProviderKey(t *testing.T) {
+	const key = "plaintext-provider-key"
+	var reads atomic.Int32
+	userRepo := countingUserRepo(key, &reads)
+
+	errStop := errors.New("stop after the key was used")
+	var seen []string
+	client := &MockClient{
+		GetAccountsFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, error) {
+			seen = append(seen, apiKey)
+			return &ofclient.AccountResponse{Success: true, Data: []ofclient.Account{}}, nil
+		},
+		GetTransactionsFunc: func(ctx context.Context, apiKey string, startDate string) (*ofclient.TransactionResponse, error) {
+			seen = append(seen, apiKey)
+			return nil, errStop
+		},
+		GetBillsFunc: func(ctx context.Context, apiKey string) (*ofclient.BillResponse, error) {
+			seen = append(seen, apiKey)
+			return nil, errStop
+		},
+	}
+
+	accRepo := &MockAccountRepo{}
+	txRepo := &MockTransactionRepo{}
+	accService := account.NewService(accRepo, &MockItemRepo{}, txRepo)
+	accountSync := NewAccountSyncService(client, userRepo, accService, &MockItemRepo{}, nil, nil, nil)
+	txSync := NewTransactionSyncService(client, userRepo, accService, accRepo, txRepo,
+		&MockCreditCardDataRepo{}, &MockBankRepo{}, &MockMerchantRepo{}, &MockDocumentRepo{}, "2023-01-01", 7)
+	billSync := NewBillSyncService(client, userRepo, accService, accRepo, newMockBillRepo(), txRepo)
+
+	ctx, release := WithProviderKeyScope(context.Background(), 1)
+	if _, err := accountSync.SyncUserAccounts(ctx, 1); err != nil {
+		t.Fatalf("SyncUserAccounts: %v", err)
+	}
+	if _, err := txSync.SyncUserTransactions(ctx, 1, false); !errors.Is(err, errStop) {
+		t.Fatalf("SyncUserTransactions error = %v, want errStop", err)
+	}
+	if _, err := billSync.SyncUserBills(ctx, 1); !errors.Is(err, errStop) {
+		t.Fatalf("SyncUserBills error = %v, want errStop", err)
+	}
+	release()
+
+	if got := reads.Load(); got != 1 {
+		t.Errorf("key reads in one job = %d, want 1", got)
+	}
+	if len(seen) != 3 || seen[0] != key || seen[1] != key || seen[2] != key {
+		t.Errorf("client saw keys %q, want the key on all three calls", seen)
+	}
+	for name, svc := range map[string]any{"account sync": accountSync, "transaction sync": txSync, "bill sync": billSync} {
+		if path := findString(reflect.ValueOf(svc), key, "", map[uintptr]bool{}); path != "" {
+			t.Errorf("%s retains the provider key at %s", name, path)
+		}
+	}
+}
+
+// findString walks v and returns the path of the first string or byte slice containing
+// needle, or "" when there is none. Pointers are visited once.
+func findString(v reflect.Value, needle, path string, seen map[uintptr]bool) string {
+	switch v.Kind() {
+	case reflect.String:
+		if strings.Contains(v.String(), needle) {
+			return path
+		}
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return ""
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			if strings.Contains(string(v.Bytes()), needle) {
+				return path
+			}
+			return ""
+		}
+		if seen[v.Pointer()] {
+			return ""
+		}
+		seen[v.Pointer()] = true
+		switch v.Kind() {
+		case reflect.Pointer:
+			return findString(v.Elem(), needle, path, seen)
+		case reflect.Map:
+			iter := v.MapRange()
+			for iter.Next() {
+				if p := findString(iter.Value(), needle, path+"[]", seen); p != "" {
+					return p
+				}
+			}
+		default:
+			for i := range v.Len() {
+				if p := findString(v.Index(i), needle, path+"[]", seen); p != "" {
+					return p
+				}
+			}
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			return findString(v.Elem(), needle, path, seen)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if p := findString(v.Field(i), needle, path+"."+v.Type().Field(i).Name, seen); p != "" {
+				return p
+			}
+		}
+	case reflect.Array:
+		for i := range v.Len() {
+			if p := findString(v.Index(i), needle, path+"[]", seen); p != "" {
+				return p
+			}
+		}
+	}
+	return ""
+}
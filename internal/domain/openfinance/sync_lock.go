@@ -0,0 +1,41 @@
+package openfinance
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSyncInProgress is returned when another sync for the same user holds the sync lock
+var ErrSyncInProgress = errors.New("a sync is already running for this user")
+
+// SyncLocker serializes the syncs of a user so overlapping runs don't race on upserts.
+// Implementations backed by the database make this hold across API instances.
+type SyncLocker interface {
+	// TryLock takes the user's sync lock without waiting, returning ErrSyncInProgress when
+	// it is held. On success the returned unlock func must be called to release the lock.
+	TryLock(ctx context.Context, userID int64) (unlock func(), err error)
+
+	// Lock waits for the user's sync lock until ctx is done
+	Lock(ctx context.Context, userID int64) (unlock func(), err error)
+}
+
+// LockUserSync takes the user's sync lock, waiting for a running sync when wait is true.
+// A nil locker always succeeds. The returned unlock func is never nil, so callers can
+// defer it right away and the lock is released even if the sync panics.
+func LockUserSync(ctx context.Context, locker SyncLocker, userID int64, wait bool) (func(), error) {
+	if locker == nil {
+		return func() {}, nil
+	}
+
+	var unlock func()
+	var err error
+	if wait {
+		unlock, err = locker.Lock(ctx, userID)
+	} else {
+		unlock, err = locker.TryLock(ctx, userID)
+	}
+	if err != nil {
+		return func() {}, err
+	}
+	return unlock, nil
+}
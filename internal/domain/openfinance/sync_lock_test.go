@@ -0,0 +1,100 @@
+package openfinance
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// memorySyncLocker is an in-process SyncLocker keyed by user ID
+type memorySyncLocker struct {
+	mu     sync.Mutex
+	held   map[int64]bool
+	waited int
+}
+
+func (l *memorySyncLocker) TryLock(ctx context.Context, userID int64) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[userID] {
+		return nil, ErrSyncInProgress
+	}
+	l.held[userID] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, userID)
+	}, nil
+}
+
+func (l *memorySyncLocker) Lock(ctx context.Context, userID int64) (func(), error) {
+	l.mu.Lock()
+	l.waited++
+	held := l.held[userID]
+	l.mu.Unlock()
+	if held {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return l.TryLock(ctx, userID)
+}
+
+func TestLockUserSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("nil locker always succeeds", func(t *testing.T) {
+		unlock, err := LockUserSync(ctx, nil, 1, false)
+		if err != nil || unlock == nil {
+			t.Fatalf("LockUserSync(nil) error = %v, want a no-op unlock", err)
+		}
+		unlock()
+	})
+
+	t.Run("second try is rejected until unlocked", func(t *testing.T) {
+		locker := &memorySyncLocker{held: map[int64]bool{}}
+
+		unlock, err := LockUserSync(ctx, locker, 1, false)
+		if err != nil {
+			t.Fatalf("first lock: unexpected error: %v", err)
+		}
+		second, err := LockUserSync(ctx, locker, 1, false)
+		if !errors.Is(err, ErrSyncInProgress) {
+			t.Fatalf("second lock error = %v, want %v", err, ErrSyncInProgress)
+		}
+		second() // never nil, safe to defer
+		if _, err := LockUserSync(ctx, locker, 2, false); err != nil {
+			t.Errorf("other user: unexpected error: %v", err)
+		}
+
+		unlock()
+		if _, err := LockUserSync(ctx, locker, 1, false); err != nil {
+			t.Errorf("after unlock: unexpected error: %v", err)
+		}
+	})
+
+	t.Run("wait uses the blocking lock", func(t *testing.T) {
+		locker := &memorySyncLocker{held: map[int64]bool{}}
+		unlock, err := LockUserSync(ctx, locker, 1, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer unlock()
+		if locker.waited != 1 {
+			t.Errorf("Lock called %d times, want 1", locker.waited)
+		}
+	})
+
+	t.Run("released when the sync panics", func(t *testing.T) {
+		locker := &memorySyncLocker{held: map[int64]bool{}}
+		func() {
+			defer func() { _ = recover() }()
+			unlock, _ := LockUserSync(ctx, locker, 1, false)
+			defer unlock()
+			panic("sync blew up")
+		}()
+		if locker.held[1] {
+			t.Errorf("lock still held after panic")
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"time"
 
 	"parsa/internal/domain/account"
@@ -24,6 +25,12 @@ type TransactionSyncResult struct {
 	// Duplicate check results
 	DuplicatesFound  int
 	DuplicatesMarked int
+	// Transactions excluded because their category is a configured bill payment category
+	BillPaymentsMarked int
+	// Stored transactions marked removed because the provider no longer returns them
+	Removed int
+	// Stale PENDING transactions marked removed, keyed by account ID
+	PendingPruned map[string]int
 }
 
 // TransactionSyncService handles syncing transactions from the Open Finance API
@@ -40,6 +47,9 @@ type TransactionSyncService struct {
 	duplicateCheckService *transaction.DuplicateCheckService
 	fullHistoryStartDate  string
 	updateSyncDays        int
+	syncWindowDays        int
+	removeMissing         bool
+	prunePendingDays      int
 }
 
 // NewTransactionSyncService creates a new transaction sync service
@@ -72,37 +82,88 @@ func NewTransactionSyncService(
 	}
 }
 
+// SetDuplicateCheckService replaces the default duplicate checker, so synced transactions are
+// checked with the deployment's duplicate settings
+func (s *TransactionSyncService) SetDuplicateCheckService(duplicateCheckService *transaction.DuplicateCheckService) {
+	s.duplicateCheckService = duplicateCheckService
+}
+
+// SetRemoveMissing enables marking transactions the provider no longer returns as removed
+// during full syncs
+func (s *TransactionSyncService) SetRemoveMissing(enabled bool) {
+	s.removeMissing = enabled
+}
+
+// SetSyncWindowDays bounds the fetches of SyncRecentTransactions to the last days; 0 removes
+// the bound
+func (s *TransactionSyncService) SetSyncWindowDays(days int) {
+	s.syncWindowDays = days
+}
+
+// SetPrunePendingDays enables marking PENDING transactions older than days that the latest
+// fetch did not return as removed; 0 disables pruning
+func (s *TransactionSyncService) SetPrunePendingDays(days int) {
+	s.prunePendingDays = days
+}
+
 // SyncUserTransactions syncs all transactions for a specific user.
 // If hasNewAccounts is true, fetches full history from the configured start date.
 // Otherwise, fetches the last N days (configured via OPENFINANCE_UPDATE_SYNC_DAYS) for incremental sync.
 func (s *TransactionSyncService) SyncUserTransactions(ctx context.Context, userID int64, hasNewAccounts bool) (*TransactionSyncResult, error) {
+	return s.syncUserTransactions(ctx, userID, hasNewAccounts, s.startDate(hasNewAccounts, false))
+}
+
+// SyncRecentTransactions is SyncUserTransactions for scheduled runs: incremental fetches never
+// reach further back than the sync window (OPENFINANCE_SYNC_WINDOW_DAYS). New accounts still get
+// their full history. Provider edits to older transactions are only picked up by a full sync.
+func (s *TransactionSyncService) SyncRecentTransactions(ctx context.Context, userID int64, hasNewAccounts bool) (*TransactionSyncResult, error) {
+	return s.syncUserTransactions(ctx, userID, hasNewAccounts, s.startDate(hasNewAccounts, true))
+}
+
+// startDate returns the first transaction date (YYYY-MM-DD) to fetch: the configured history
+// start for full syncs, the last updateSyncDays otherwise. When windowed is set, incremental
+// fetches are clamped to the sync window; full syncs never are, so new accounts get their
+// whole history.
+func (s *TransactionSyncService) startDate(full, windowed bool) string {
+	now := time.Now()
+	start := now.AddDate(0, 0, -s.updateSyncDays).Format("2006-01-02")
+	if full {
+		start = s.fullHistoryStartDate
+	}
+	if windowed && !full && s.syncWindowDays > 0 {
+		// Dates share the YYYY-MM-DD layout, so they compare as strings
+		if windowStart := now.AddDate(0, 0, -s.syncWindowDays).Format("2006-01-02"); start < windowStart {
+			start = windowStart
+		}
+	}
+	return start
+}
+
+func (s *TransactionSyncService) syncUserTransactions(ctx context.Context, userID int64, hasNewAccounts bool, startDate string) (*TransactionSyncResult, error) {
 	result := &TransactionSyncResult{
-		UserID: userID,
-		Errors: []string{},
+		UserID:        userID,
+		Errors:        []string{},
+		PendingPruned: map[string]int{},
 	}
 
 	// Get user's API key
-	user, err := s.userRepo.GetByID(ctx, userID)
+	providerKey, err := loadProviderKey(ctx, s.userRepo, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	if user.ProviderKey == nil || *user.ProviderKey == "" {
+	if providerKey == "" {
 		return nil, fmt.Errorf("user has no provider API key configured")
 	}
 
-	// Determine start date based on whether new accounts were created
-	var startDate string
 	if hasNewAccounts {
-		startDate = s.fullHistoryStartDate
-		log.Printf("User %d: New accounts detected, fetching full transaction history from %s", userID, startDate)
+		log.Printf("User %d: New accounts detected, fetching transaction history from %s", userID, startDate)
 	} else {
-		startDate = time.Now().AddDate(0, 0, -s.updateSyncDays).Format("2006-01-02")
 		log.Printf("User %d: Incremental sync, fetching transactions from %s", userID, startDate)
 	}
 
 	// Fetch transactions from provider
-	txResp, err := s.client.GetTransactions(ctx, *user.ProviderKey, startDate)
+	txResp, err := s.client.GetTransactions(ctx, providerKey, startDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch transactions from provider: %w", err)
 	}
@@ -117,20 +178,41 @@ func (s *TransactionSyncService) SyncUserTransactions(ctx context.Context, userI
 	if err != nil {
 		return nil, fmt.Errorf("failed to list user accounts: %w", err)
 	}
+	// Accounts of disabled or needs-reconnect items, and accounts whose subtype is not
+	// synced, are left out of the cache and their transactions skipped below
+	excludedItems, err := s.accountService.ListSyncExcludedItemIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load item states: %w", err)
+	}
+	excludedAccounts := make(map[string]struct{})
 	for i := range accounts {
+		if _, excluded := excludedItems[accounts[i].ItemID]; excluded && accounts[i].ItemID != "" {
+			excludedAccounts[accounts[i].ID] = struct{}{}
+			continue
+		}
+		if !s.accountService.SyncsSubtype(accounts[i].Subtype) {
+			excludedAccounts[accounts[i].ID] = struct{}{}
+			continue
+		}
 		accountIDMap[accounts[i].ID] = accounts[i]
 	}
 
+	s.resolveBankNames(ctx, txResp.Data, accountIDMap)
+
 	// Collect newly created transactions for duplicate checking
 	createdTransactions := make([]*transaction.Transaction, 0, len(txResp.Data))
 
 	// Process each transaction
 	for _, apiTx := range txResp.Data {
+		if _, excluded := excludedAccounts[apiTx.AccountID]; excluded {
+			result.Skipped++
+			continue
+		}
 		txn, wasCreated, err := s.processTransaction(ctx, userID, &apiTx, accountIDMap, result)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to process transaction %s: %v", apiTx.ID, err)
 			result.Errors = append(result.Errors, errMsg)
-			log.Printf("Error: %s", errMsg)
+			slog.Warn("Failed to process transaction", "user_id", userID, "transaction_id", apiTx.ID, "error", err)
 			continue
 		}
 		if wasCreated && txn != nil {
@@ -138,8 +220,15 @@ func (s *TransactionSyncService) SyncUserTransactions(ctx context.Context, userI
 		}
 	}
 
-	log.Printf("Transaction sync completed for user %d: found=%d, created=%d, updated=%d, skipped=%d, errors=%d",
-		userID, result.TransactionsFound, result.Created, result.Updated, result.Skipped, len(result.Errors))
+	if hasNewAccounts && s.removeMissing {
+		s.markMissingAsRemoved(ctx, startDate, txResp.Data, accountIDMap, result)
+	}
+	if s.prunePendingDays > 0 {
+		s.pruneStalePending(ctx, userID, startDate, txResp.Data, accountIDMap, result)
+	}
+
+	log.Printf("Transaction sync completed for user %d: found=%d, created=%d, updated=%d, skipped=%d, removed=%d, errors=%d",
+		userID, result.TransactionsFound, result.Created, result.Updated, result.Skipped, result.Removed, len(result.Errors))
 
 	// Run duplicate check on newly created transactions
 	if len(createdTransactions) > 0 {
@@ -156,6 +245,120 @@ func (s *TransactionSyncService) SyncUserTransactions(ctx context.Context, userI
 	return result, nil
 }
 
+// markMissingAsRemoved marks the stored transactions of each synced account that the provider
+// did not return for the fetched window as removed. Accounts without any returned transaction
+// are left alone: an empty answer is more likely a provider hiccup than a wiped history.
+func (s *TransactionSyncService) markMissingAsRemoved(
+	ctx context.Context,
+	startDate string,
+	apiTxs []ofclient.Transaction,
+	accountIDMap map[string]*account.Account,
+	result *TransactionSyncResult,
+) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid sync start date %q: %v", startDate, err))
+		return
+	}
+	window := transaction.DateWindow{Start: start, End: time.Now()}
+
+	presentByAccount := make(map[string][]string)
+	for _, apiTx := range apiTxs {
+		if _, ok := accountIDMap[apiTx.AccountID]; ok {
+			presentByAccount[apiTx.AccountID] = append(presentByAccount[apiTx.AccountID], apiTx.ID)
+		}
+	}
+
+	for accountID, presentIDs := range presentByAccount {
+		removed, err := s.transactionRepo.MarkMissingAsRemoved(ctx, accountID, presentIDs, window)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to remove missing transactions of account %s: %v", accountID, err)
+			result.Errors = append(result.Errors, errMsg)
+			log.Printf("Error: %s", errMsg)
+			continue
+		}
+		result.Removed += int(removed)
+	}
+}
+
+// pruneStalePending marks the PENDING transactions of each synced account that are older than
+// the pruning threshold and were not returned by this fetch as removed: the provider dropped
+// them without ever posting them. Only transactions dated on or after startDate are considered,
+// since older ones were not part of the fetch. Like markMissingAsRemoved, accounts without any
+// returned transaction are left alone.
+func (s *TransactionSyncService) pruneStalePending(
+	ctx context.Context,
+	userID int64,
+	startDate string,
+	apiTxs []ofclient.Transaction,
+	accountIDMap map[string]*account.Account,
+	result *TransactionSyncResult,
+) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("invalid sync start date %q: %v", startDate, err))
+		return
+	}
+	window := transaction.DateWindow{Start: start, End: time.Now().AddDate(0, 0, -s.prunePendingDays)}
+	if window.End.Before(window.Start) {
+		return
+	}
+
+	presentByAccount := make(map[string][]string)
+	for _, apiTx := range apiTxs {
+		if _, ok := accountIDMap[apiTx.AccountID]; ok {
+			presentByAccount[apiTx.AccountID] = append(presentByAccount[apiTx.AccountID], apiTx.ID)
+		}
+	}
+
+	for accountID, presentIDs := range presentByAccount {
+		pruned, err := s.transactionRepo.MarkStalePending(ctx, accountID, presentIDs, window)
+		if err != nil {
+			errMsg := fmt.Sprintf("failed to prune stale pending transactions of account %s: %v", accountID, err)
+			result.Errors = append(result.Errors, errMsg)
+			log.Printf("Error: %s", errMsg)
+			continue
+		}
+		if pruned > 0 {
+			result.PendingPruned[accountID] = int(pruned)
+			slog.Info("Pruned stale pending transactions", "user_id", userID, "account_id", accountID, "pruned", pruned)
+		}
+	}
+}
+
+// resolveBankNames names the banks that account sync created from a connector code alone,
+// using the item_bank_name of the first transaction seen for each linked account.
+// Accounts without a bank are linked by name in processTransaction.
+func (s *TransactionSyncService) resolveBankNames(ctx context.Context, apiTxs []ofclient.Transaction, accountIDMap map[string]*account.Account) {
+	resolved := make(map[int64]struct{})
+	for _, apiTx := range apiTxs {
+		acc, ok := accountIDMap[apiTx.AccountID]
+		if !ok || acc.BankID == 0 || apiTx.ItemBankName == "" {
+			continue
+		}
+		if _, done := resolved[acc.BankID]; done {
+			continue
+		}
+		resolved[acc.BankID] = struct{}{}
+
+		placeholderID := acc.BankID
+		bank, err := s.bankRepo.ResolvePlaceholderName(ctx, placeholderID, apiTx.ItemBankName)
+		if err != nil {
+			log.Printf("Warning: failed to resolve name of bank %d to '%s': %v", placeholderID, apiTx.ItemBankName, err)
+			continue
+		}
+		if bank.ID != placeholderID {
+			// Merged into an existing bank: update every cached account of the placeholder
+			for _, other := range accountIDMap {
+				if other.BankID == placeholderID {
+					other.BankID = bank.ID
+				}
+			}
+			resolved[bank.ID] = struct{}{}
+		}
+	}
+}
+
 // processTransaction processes a single transaction from the API
 // Returns the transaction (if created/updated), whether it was newly created, and any error
 func (s *TransactionSyncService) processTransaction(
@@ -167,8 +370,8 @@ func (s *TransactionSyncService) processTransaction(
 ) (*transaction.Transaction, bool, error) {
 	// Transactions link to accounts strictly by account_id (provider UUID == accounts.id).
 	if apiTx.AccountID == "" {
-		log.Printf("Skipping transaction %s: provider returned no account_id (name=%s, type=%s, subtype=%s)",
-			apiTx.ID, apiTx.AccountName, apiTx.AccountType, apiTx.AccountSubtype)
+		slog.Debug("Skipping transaction: provider returned no account_id", "transaction_id", apiTx.ID,
+			"account_name", apiTx.AccountName, "account_type", apiTx.AccountType, "account_subtype", apiTx.AccountSubtype)
 		result.Skipped++
 		return nil, false, nil
 	}
@@ -183,8 +386,8 @@ func (s *TransactionSyncService) processTransaction(
 		// GetAccountByID bypasses ownership checks; enforce it here so a bogus
 		// provider payload cannot attach a transaction to another user's account.
 		if dbAcc == nil || dbAcc.UserID != userID {
-			log.Printf("Skipping transaction %s: no account found with id=%s for user %d",
-				apiTx.ID, apiTx.AccountID, userID)
+			slog.Debug("Skipping transaction: no account found", "transaction_id", apiTx.ID,
+				"account_id", apiTx.AccountID, "user_id", userID)
 			result.Skipped++
 			return nil, false, nil
 		}
@@ -198,13 +401,13 @@ func (s *TransactionSyncService) processTransaction(
 	if acc.BankID == 0 && apiTx.ItemBankName != "" {
 		bank, err := s.bankRepo.FindOrCreateByName(ctx, apiTx.ItemBankName)
 		if err != nil {
-			log.Printf("Warning: failed to find/create bank '%s': %v", apiTx.ItemBankName, err)
+			slog.Warn("Failed to find/create bank", "bank_name", apiTx.ItemBankName, "error", err)
 		} else {
 			if err := s.accountRepo.UpdateBankID(ctx, acc.ID, bank.ID); err != nil {
-				log.Printf("Warning: failed to update account %s with bank_id %d: %v", acc.ID, bank.ID, err)
+				slog.Warn("Failed to update account bank", "account_id", acc.ID, "bank_id", bank.ID, "error", err)
 			} else {
 				acc.BankID = bank.ID
-				log.Printf("Assigned bank '%s' (id=%d) to account %s", bank.Name, bank.ID, acc.ID)
+				slog.Debug("Assigned bank to account", "bank_name", bank.Name, "bank_id", bank.ID, "account_id", acc.ID)
 			}
 		}
 	}
@@ -241,7 +444,7 @@ func (s *TransactionSyncService) processTransaction(
 	if apiTx.Merchant != nil && apiTx.Merchant.Name != nil && *apiTx.Merchant.Name != "" {
 		merchant, err := s.merchantRepo.FindOrCreateByName(ctx, *apiTx.Merchant.Name)
 		if err != nil {
-			log.Printf("Warning: failed to find/create merchant '%s': %v", *apiTx.Merchant.Name, err)
+			slog.Warn("Failed to find/create merchant", "merchant_name", *apiTx.Merchant.Name, "error", err)
 		} else {
 			merchantID = &merchant.ID
 		}
@@ -254,7 +457,7 @@ func (s *TransactionSyncService) processTransaction(
 		if businessName != "" {
 			doc, err := s.documentRepo.FindOrCreateByBusinessName(ctx, businessName)
 			if err != nil {
-				log.Printf("Warning: failed to find/create document for '%s': %v", businessName, err)
+				slog.Warn("Failed to find/create document", "business_name", businessName, "error", err)
 			} else {
 				documentID = &doc.ID
 			}
@@ -289,6 +492,14 @@ func (s *TransactionSyncService) processTransaction(
 		result.Updated++
 	}
 
+	// Exclude bill payments by category, even when no matching bill was synced
+	marked, err := s.duplicateCheckService.CheckBillPaymentCategory(ctx, txn)
+	if err != nil {
+		slog.Warn("Failed to check bill payment category", "transaction_id", txn.ID, "error", err)
+	} else if marked {
+		result.BillPaymentsMarked++
+	}
+
 	// Process credit card data if present
 	if apiTx.CreditCardData != nil {
 		if err := s.processCreditCardData(ctx, apiTx.ID, apiTx.CreditCardData); err != nil {
@@ -301,7 +512,7 @@ func (s *TransactionSyncService) processTransaction(
 	if wasCreated {
 		_, _, err := s.duplicateCheckService.CheckTransactionForDuplicates(ctx, txn, userID)
 		if err != nil {
-			log.Printf("Error checking duplicates for transaction %s: %v", txn.ID, err)
+			slog.Warn("Failed to check duplicates", "transaction_id", txn.ID, "error", err)
 		}
 	}
 
@@ -371,7 +582,7 @@ func extractPaymentBusinessName(pd *ofclient.TransactionPaymentData, txType stri
 }
 
 // SyncAllUsersTransactions syncs transactions for all users with provider keys.
-// Uses incremental sync for all users when called in batch.
+// Uses incremental sync, bounded by the sync window, for all users when called in batch.
 func (s *TransactionSyncService) SyncAllUsersTransactions(ctx context.Context) ([]*TransactionSyncResult, error) {
 	users, err := s.userRepo.ListUsersWithProviderKey(ctx)
 	if err != nil {
@@ -380,7 +591,7 @@ func (s *TransactionSyncService) SyncAllUsersTransactions(ctx context.Context) (
 
 	var results []*TransactionSyncResult
 	for _, user := range users {
-		result, err := s.SyncUserTransactions(ctx, user.ID, false)
+		result, err := s.SyncRecentTransactions(ctx, user.ID, false)
 		if err != nil {
 			log.Printf("Failed to sync transactions for user %d: %v", user.ID, err)
 			results = append(results, &TransactionSyncResult{
@@ -3,6 +3,7 @@ package openfinance
 import (
 	"context"
 	"testing"
+	"time"
 
 	"parsa/internal/domain/account"
 	"parsa/internal/domain/transaction"
@@ -26,6 +27,8 @@ type MockTransactionRepo struct {
 	FindPotentialDuplicatesForBillFunc func(ctx context.Context, criteria transaction.DuplicateCriteria) ([]*transaction.Transaction, error)
 	SetTransactionTagsFunc func(ctx context.Context, transactionID string, tagIDs []string) error
 	GetTransactionTagsFunc func(ctx context.Context, transactionID string) ([]string, error)
+	MarkMissingAsRemovedFunc func(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error)
+	MarkStalePendingFunc     func(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error)
 }
 
 func (m *MockTransactionRepo) Create(ctx context.Context, params transaction.CreateTransactionParams) (*transaction.Transaction, error) {
@@ -52,6 +55,42 @@ func (m *MockTransactionRepo) CountByUserID(ctx context.Context, userID int64) (
 	}
 	return 0, nil
 }
+func (m *MockTransactionRepo) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ListByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) Recategorize(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	return false, nil
+}
+func (m *MockTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (m *MockTransactionRepo) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (m *MockTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	return nil
+}
+func (m *MockTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return 0, nil
+}
 func (m *MockTransactionRepo) Update(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
 	return nil, nil
 }
@@ -90,6 +129,39 @@ func (m *MockTransactionRepo) GetTransactionTags(ctx context.Context, transactio
 	return nil, nil
 }
 
+func (m *MockTransactionRepo) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	return nil
+}
+
+func (m *MockTransactionRepo) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) CountGroups(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	if m.MarkMissingAsRemovedFunc != nil {
+		return m.MarkMissingAsRemovedFunc(ctx, accountID, presentIDs, window)
+	}
+	return 0, nil
+}
+func (m *MockTransactionRepo) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	if m.MarkStalePendingFunc != nil {
+		return m.MarkStalePendingFunc(ctx, accountID, presentIDs, window)
+	}
+	return 0, nil
+}
+
 type MockCreditCardDataRepo struct {
 	UpsertFunc func(ctx context.Context, transactionID string, params models.CreateCreditCardDataParams) (*models.CreditCardData, error)
 }
@@ -102,7 +174,12 @@ func (m *MockCreditCardDataRepo) Upsert(ctx context.Context, transactionID strin
 }
 
 type MockBankRepo struct {
-	FindOrCreateByNameFunc func(ctx context.Context, name string) (*models.Bank, error)
+	FindOrCreateByNameFunc      func(ctx context.Context, name string) (*models.Bank, error)
+	FindByConnectorFunc         func(ctx context.Context, connector string) (*models.Bank, error)
+	FindOrCreateByConnectorFunc func(ctx context.Context, name, connector string) (*models.Bank, error)
+	SetConnectorFunc            func(ctx context.Context, id int64, connector string) (bool, error)
+	ResolvePlaceholderNameFunc  func(ctx context.Context, id int64, name string) (*models.Bank, error)
+	ApplyBrandingFunc           func(ctx context.Context, id int64, primaryColor, logoURL string) error
 }
 
 func (m *MockBankRepo) FindOrCreateByName(ctx context.Context, name string) (*models.Bank, error) {
@@ -112,6 +189,41 @@ func (m *MockBankRepo) FindOrCreateByName(ctx context.Context, name string) (*mo
 	return nil, nil
 }
 
+func (m *MockBankRepo) FindByConnector(ctx context.Context, connector string) (*models.Bank, error) {
+	if m.FindByConnectorFunc != nil {
+		return m.FindByConnectorFunc(ctx, connector)
+	}
+	return nil, nil
+}
+
+func (m *MockBankRepo) FindOrCreateByConnector(ctx context.Context, name, connector string) (*models.Bank, error) {
+	if m.FindOrCreateByConnectorFunc != nil {
+		return m.FindOrCreateByConnectorFunc(ctx, name, connector)
+	}
+	return &models.Bank{ID: 1, Name: name, Connector: connector}, nil
+}
+
+func (m *MockBankRepo) SetConnector(ctx context.Context, id int64, connector string) (bool, error) {
+	if m.SetConnectorFunc != nil {
+		return m.SetConnectorFunc(ctx, id, connector)
+	}
+	return false, nil
+}
+
+func (m *MockBankRepo) ResolvePlaceholderName(ctx context.Context, id int64, name string) (*models.Bank, error) {
+	if m.ResolvePlaceholderNameFunc != nil {
+		return m.ResolvePlaceholderNameFunc(ctx, id, name)
+	}
+	return &models.Bank{ID: id, Name: name}, nil
+}
+
+func (m *MockBankRepo) ApplyBranding(ctx context.Context, id int64, primaryColor, logoURL string) error {
+	if m.ApplyBrandingFunc != nil {
+		return m.ApplyBrandingFunc(ctx, id, primaryColor, logoURL)
+	}
+	return nil
+}
+
 type MockMerchantRepo struct {
 	FindOrCreateByNameFunc func(ctx context.Context, name string) (*models.Merchant, error)
 }
@@ -309,3 +421,364 @@ func TestSyncUserTransactions(t *testing.T) {
 		})
 	}
 }
+
+// newInMemoryTransactionRepo returns a MockTransactionRepo backed by a map whose Upsert
+// follows the postgres conflict rules: manipulated transactions keep their description,
+// category and status, everything else is overwritten by the provider's values.
+func newInMemoryTransactionRepo() (*MockTransactionRepo, map[string]*transaction.Transaction) {
+	store := make(map[string]*transaction.Transaction)
+	repo := &MockTransactionRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+			return store[id], nil
+		},
+		UpsertFunc: func(ctx context.Context, params transaction.UpsertTransactionParams) (*transaction.Transaction, error) {
+			txn, ok := store[params.ID]
+			if !ok {
+				txn = &transaction.Transaction{ID: params.ID, Considered: true, IsOpenFinance: true}
+				store[params.ID] = txn
+			}
+			txn.AccountID = params.AccountID
+			txn.Amount = params.Amount
+			txn.ProviderCategoryID = params.ProviderCategoryID
+			txn.TransactionDate = params.TransactionDate
+			txn.Type = params.Type
+			if !txn.Manipulated {
+				txn.Description = params.Description
+				txn.Category = params.Category
+				txn.Status = params.Status
+			}
+			copied := *txn
+			return &copied, nil
+		},
+	}
+	return repo, store
+}
+
+func TestSyncUserTransactions_InMemory(t *testing.T) {
+	ctx := context.Background()
+	key := "valid-key"
+	category := "Renda" // OpenFinance name of category 01000000
+	categoryCode := "01000000"
+
+	apiTxs := []ofclient.Transaction{
+		{ID: "tx-1", AccountID: "acc-1", Description: "PIX RECEBIDO", AmountString: "1200.00",
+			DateString: "2024-03-01 10:00:00", Type: "CREDIT", Status: "POSTED", Category: &category},
+		{ID: "tx-2", AccountID: "acc-1", Description: "SALARIO", AmountString: "5000.00",
+			DateString: "2024-03-05 10:00:00", Type: "CREDIT", Status: "PENDING", Category: &categoryCode},
+	}
+
+	txRepo, store := newInMemoryTransactionRepo()
+	accRepo := &MockAccountRepo{
+		ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*account.Account, error) {
+			return []*account.Account{{ID: "acc-1", Name: "Checking", AccountType: "BANK", Subtype: "CHECKING_ACCOUNT"}}, nil
+		},
+	}
+	client := &MockClient{
+		GetTransactionsFunc: func(ctx context.Context, apiKey string, startDate string) (*ofclient.TransactionResponse, error) {
+			return &ofclient.TransactionResponse{Success: true, Data: apiTxs}, nil
+		},
+	}
+	userRepo := &MockUserRepo{
+		GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+			return &user.User{ID: 1, ProviderKey: &key}, nil
+		},
+	}
+	svc := NewTransactionSyncService(client, userRepo, account.NewService(accRepo, &MockItemRepo{}, txRepo), accRepo,
+		txRepo, &MockCreditCardDataRepo{}, &MockBankRepo{}, &MockMerchantRepo{}, &MockDocumentRepo{}, "2023-01-01", 7)
+
+	// First sync creates both transactions with the translated category
+	got, err := svc.SyncUserTransactions(ctx, 1, false)
+	if err != nil {
+		t.Fatalf("first sync: unexpected error: %v", err)
+	}
+	if got.Created != 2 || got.Updated != 0 {
+		t.Errorf("first sync: created = %d, updated = %d, want 2 and 0", got.Created, got.Updated)
+	}
+	wantCategory := transaction.CategoryMapping[categoryCode].ParsaName
+	for _, id := range []string{"tx-1", "tx-2"} {
+		txn := store[id]
+		if txn == nil {
+			t.Fatalf("first sync: %s was not stored", id)
+		}
+		if txn.Category == nil || *txn.Category != wantCategory {
+			t.Errorf("first sync: %s category = %v, want %q", id, txn.Category, wantCategory)
+		}
+		if txn.ProviderCategoryID == nil || *txn.ProviderCategoryID != categoryCode {
+			t.Errorf("first sync: %s provider category = %v, want %q", id, txn.ProviderCategoryID, categoryCode)
+		}
+	}
+
+	// The user edits tx-1, then the provider sends new values for both transactions
+	userCategory := "Transferências"
+	store["tx-1"].Description = "Rent from Ana"
+	store["tx-1"].Category = &userCategory
+	store["tx-1"].Manipulated = true
+	apiTxs[0].Description = "PIX RECEBIDO ANA"
+	apiTxs[0].AmountString = "1250.00"
+	apiTxs[1].Description = "SALARIO MARCO"
+	apiTxs[1].Status = "POSTED"
+
+	got, err = svc.SyncUserTransactions(ctx, 1, false)
+	if err != nil {
+		t.Fatalf("second sync: unexpected error: %v", err)
+	}
+	if got.Created != 0 || got.Updated != 2 {
+		t.Errorf("second sync: created = %d, updated = %d, want 0 and 2", got.Created, got.Updated)
+	}
+
+	manipulated := store["tx-1"]
+	if manipulated.Description != "Rent from Ana" {
+		t.Errorf("manipulated description = %q, want the user's edit", manipulated.Description)
+	}
+	if manipulated.Category == nil || *manipulated.Category != userCategory {
+		t.Errorf("manipulated category = %v, want %q", manipulated.Category, userCategory)
+	}
+	if manipulated.Amount != 1250 {
+		t.Errorf("manipulated amount = %v, want the provider's 1250", manipulated.Amount)
+	}
+
+	untouched := store["tx-2"]
+	if untouched.Description != "SALARIO MARCO" || untouched.Status != "POSTED" {
+		t.Errorf("untouched transaction = (%q, %q), want the provider's values", untouched.Description, untouched.Status)
+	}
+}
+
+func TestSyncUserTransactions_RemoveMissing(t *testing.T) {
+	key := "valid-key"
+	apiTxs := []ofclient.Transaction{
+		{ID: "tx-1", AccountID: "acc-1", Description: "PIX", AmountString: "10.00",
+			DateString: "2024-03-01 10:00:00", Type: "DEBIT", Status: "POSTED"},
+		{ID: "tx-2", AccountID: "acc-1", Description: "TED", AmountString: "20.00",
+			DateString: "2024-03-02 10:00:00", Type: "DEBIT", Status: "POSTED"},
+	}
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		hasNewAccounts bool
+		wantCalls      int
+	}{
+		{name: "full sync with removal enabled", enabled: true, hasNewAccounts: true, wantCalls: 1},
+		{name: "incremental sync", enabled: true, hasNewAccounts: false, wantCalls: 0},
+		{name: "removal disabled", enabled: false, hasNewAccounts: true, wantCalls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var gotAccount string
+			var gotIDs []string
+			var gotWindow transaction.DateWindow
+
+			txRepo, _ := newInMemoryTransactionRepo()
+			txRepo.MarkMissingAsRemovedFunc = func(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+				calls++
+				gotAccount, gotIDs, gotWindow = accountID, presentIDs, window
+				return 3, nil
+			}
+			accRepo := &MockAccountRepo{
+				ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*account.Account, error) {
+					return []*account.Account{
+						{ID: "acc-1", Name: "Checking", AccountType: "BANK"},
+						{ID: "acc-2", Name: "Savings", AccountType: "BANK"}, // no transactions returned
+					}, nil
+				},
+			}
+			client := &MockClient{
+				GetTransactionsFunc: func(ctx context.Context, apiKey string, startDate string) (*ofclient.TransactionResponse, error) {
+					return &ofclient.TransactionResponse{Success: true, Data: apiTxs}, nil
+				},
+			}
+			userRepo := &MockUserRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+					return &user.User{ID: 1, ProviderKey: &key}, nil
+				},
+			}
+			svc := NewTransactionSyncService(client, userRepo, account.NewService(accRepo, &MockItemRepo{}, txRepo), accRepo,
+				txRepo, &MockCreditCardDataRepo{}, &MockBankRepo{}, &MockMerchantRepo{}, &MockDocumentRepo{}, "2023-01-01", 7)
+			svc.SetRemoveMissing(tt.enabled)
+
+			got, err := svc.SyncUserTransactions(context.Background(), 1, tt.hasNewAccounts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("MarkMissingAsRemoved calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if calls == 0 {
+				if got.Removed != 0 {
+					t.Errorf("removed = %d, want 0", got.Removed)
+				}
+				return
+			}
+
+			if gotAccount != "acc-1" {
+				t.Errorf("account = %q, want acc-1 (acc-2 returned nothing)", gotAccount)
+			}
+			if len(gotIDs) != 2 || gotIDs[0] != "tx-1" || gotIDs[1] != "tx-2" {
+				t.Errorf("present IDs = %v, want [tx-1 tx-2]", gotIDs)
+			}
+			if want := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC); !gotWindow.Start.Equal(want) {
+				t.Errorf("window start = %v, want %v", gotWindow.Start, want)
+			}
+			if got.Removed != 3 {
+				t.Errorf("removed = %d, want 3", got.Removed)
+			}
+		})
+	}
+}
+
+func TestSyncUserTransactions_PrunePending(t *testing.T) {
+	key := "valid-key"
+	apiTxs := []ofclient.Transaction{
+		{ID: "tx-1", AccountID: "acc-1", Description: "PIX", AmountString: "10.00",
+			DateString: "2024-03-01 10:00:00", Type: "DEBIT", Status: "PENDING"},
+	}
+
+	tests := []struct {
+		name       string
+		days       int
+		wantCalls  int
+		wantPruned map[string]int
+	}{
+		{name: "pruning enabled", days: 3, wantCalls: 1, wantPruned: map[string]int{"acc-1": 2}},
+		{name: "threshold before fetch window", days: 30, wantCalls: 0, wantPruned: map[string]int{}},
+		{name: "pruning disabled", days: 0, wantCalls: 0, wantPruned: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			var gotAccount string
+			var gotIDs []string
+			var gotWindow transaction.DateWindow
+
+			txRepo, _ := newInMemoryTransactionRepo()
+			txRepo.MarkStalePendingFunc = func(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+				calls++
+				gotAccount, gotIDs, gotWindow = accountID, presentIDs, window
+				return 2, nil
+			}
+			accRepo := &MockAccountRepo{
+				ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*account.Account, error) {
+					return []*account.Account{
+						{ID: "acc-1", Name: "Checking", AccountType: "BANK"},
+						{ID: "acc-2", Name: "Savings", AccountType: "BANK"}, // no transactions returned
+					}, nil
+				},
+			}
+			client := &MockClient{
+				GetTransactionsFunc: func(ctx context.Context, apiKey string, startDate string) (*ofclient.TransactionResponse, error) {
+					return &ofclient.TransactionResponse{Success: true, Data: apiTxs}, nil
+				},
+			}
+			userRepo := &MockUserRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+					return &user.User{ID: 1, ProviderKey: &key}, nil
+				},
+			}
+			svc := NewTransactionSyncService(client, userRepo, account.NewService(accRepo, &MockItemRepo{}, txRepo), accRepo,
+				txRepo, &MockCreditCardDataRepo{}, &MockBankRepo{}, &MockMerchantRepo{}, &MockDocumentRepo{}, "2023-01-01", 7)
+			svc.SetPrunePendingDays(tt.days)
+
+			got, err := svc.SyncUserTransactions(context.Background(), 1, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("MarkStalePending calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if len(got.PendingPruned) != len(tt.wantPruned) || got.PendingPruned["acc-1"] != tt.wantPruned["acc-1"] {
+				t.Errorf("pending pruned = %v, want %v", got.PendingPruned, tt.wantPruned)
+			}
+			if calls == 0 {
+				return
+			}
+
+			if gotAccount != "acc-1" {
+				t.Errorf("account = %q, want acc-1 (acc-2 returned nothing)", gotAccount)
+			}
+			if len(gotIDs) != 1 || gotIDs[0] != "tx-1" {
+				t.Errorf("present IDs = %v, want [tx-1]", gotIDs)
+			}
+			if got, want := gotWindow.Start.Format("2006-01-02"), time.Now().AddDate(0, 0, -7).Format("2006-01-02"); got != want {
+				t.Errorf("window start = %s, want the fetch start %s", got, want)
+			}
+			want := time.Now().AddDate(0, 0, -tt.days)
+			if diff := want.Sub(gotWindow.End); diff < 0 || diff > time.Minute {
+				t.Errorf("window end = %v, want about %v", gotWindow.End, want)
+			}
+		})
+	}
+}
+
+func TestTransactionSyncStartDate(t *testing.T) {
+	day := func(daysAgo int) string { return time.Now().AddDate(0, 0, -daysAgo).Format("2006-01-02") }
+
+	tests := []struct {
+		name       string
+		windowDays int
+		full       bool
+		windowed   bool
+		want       string
+	}{
+		{name: "full history", windowDays: 90, full: true, want: "2023-01-01"},
+		{name: "full history not clamped to the window", windowDays: 90, full: true, windowed: true, want: "2023-01-01"},
+		{name: "incremental inside the window", windowDays: 90, windowed: true, want: day(7)},
+		{name: "incremental longer than the window", windowDays: 3, windowed: true, want: day(3)},
+		{name: "no window", windowDays: 0, full: true, windowed: true, want: "2023-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTransactionSyncService(&MockClient{}, &MockUserRepo{}, nil, &MockAccountRepo{}, &MockTransactionRepo{},
+				&MockCreditCardDataRepo{}, &MockBankRepo{}, &MockMerchantRepo{}, &MockDocumentRepo{}, "2023-01-01", 7)
+			svc.SetSyncWindowDays(tt.windowDays)
+
+			if got := svc.startDate(tt.full, tt.windowed); got != tt.want {
+				t.Errorf("startDate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveBankNames(t *testing.T) {
+	var calls []int64
+	bankRepo := &MockBankRepo{
+		ResolvePlaceholderNameFunc: func(ctx context.Context, id int64, name string) (*models.Bank, error) {
+			calls = append(calls, id)
+			if id == 7 {
+				// Placeholder merged into the existing "Nubank" bank
+				return &models.Bank{ID: 2, Name: name, Connector: "212"}, nil
+			}
+			return &models.Bank{ID: id, Name: name}, nil
+		},
+	}
+	svc := NewTransactionSyncService(&MockClient{}, &MockUserRepo{}, nil, &MockAccountRepo{}, &MockTransactionRepo{},
+		&MockCreditCardDataRepo{}, bankRepo, &MockMerchantRepo{}, &MockDocumentRepo{}, "2023-01-01", 7)
+
+	accountIDMap := map[string]*account.Account{
+		"acc-1": {ID: "acc-1", BankID: 7},
+		"acc-2": {ID: "acc-2", BankID: 7},
+		"acc-3": {ID: "acc-3"},
+	}
+	apiTxs := []ofclient.Transaction{
+		{ID: "tx-1", AccountID: "acc-1", ItemBankName: "Nubank"},
+		{ID: "tx-2", AccountID: "acc-2", ItemBankName: "Nubank"},
+		{ID: "tx-3", AccountID: "acc-3", ItemBankName: "Itaú"},
+	}
+
+	svc.resolveBankNames(context.Background(), apiTxs, accountIDMap)
+
+	if len(calls) != 1 || calls[0] != 7 {
+		t.Errorf("ResolvePlaceholderName calls = %v, want [7]", calls)
+	}
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if got := accountIDMap[id].BankID; got != 2 {
+			t.Errorf("%s bank = %d, want 2 after merge", id, got)
+		}
+	}
+	if got := accountIDMap["acc-3"].BankID; got != 0 {
+		t.Errorf("acc-3 bank = %d, want 0 (linked by name in processTransaction)", got)
+	}
+}
@@ -0,0 +1,18 @@
+package stats
+
+import "time"
+
+// UserStats holds per-user usage counts for product analytics
+type UserStats struct {
+	UserID                 int64              `json:"userId"`
+	Email                  string             `json:"email"`
+	Accounts               int64              `json:"accounts"`
+	Transactions           int64              `json:"transactions"`
+	ConsideredTransactions int64              `json:"consideredTransactions"`
+	ExcludedTransactions   int64              `json:"excludedTransactions"`
+	Bills                  int64              `json:"bills"`
+	Tags                   int64              `json:"tags"`
+	CousinRules            int64              `json:"cousinRules"`
+	LastSyncAt             *time.Time         `json:"lastSyncAt"`
+	TotalBalance           map[string]float64 `json:"totalBalance"` // by currency
+}
@@ -0,0 +1,26 @@
+package stats
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the count queries the stats service needs beyond the other domains' repositories
+type Repository interface {
+	// CountAccounts returns the number of the user's accounts that are not removed
+	CountAccounts(ctx context.Context, userID int64) (int64, error)
+
+	// SumBalancesByCurrency returns the balance of the user's accounts that are not removed,
+	// summed per currency
+	SumBalancesByCurrency(ctx context.Context, userID int64) (map[string]float64, error)
+
+	// CountTags returns the number of tags the user has created
+	CountTags(ctx context.Context, userID int64) (int64, error)
+
+	// CountCousinRules returns the number of cousin rules the user has created
+	CountCousinRules(ctx context.Context, userID int64) (int64, error)
+
+	// GetLastSyncAt returns when any of the user's active items was last synced, or nil if
+	// the user has none
+	GetLastSyncAt(ctx context.Context, userID int64) (*time.Time, error)
+}
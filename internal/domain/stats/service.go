@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/transaction"
+	"parsa/internal/domain/user"
+)
+
+// Service computes per-user statistics by composing counts from the other domains
+type Service struct {
+	repo            Repository
+	userRepo        user.Repository
+	transactionRepo transaction.Repository
+	billRepo        bill.Repository
+}
+
+// NewService creates a new stats service
+func NewService(repo Repository, userRepo user.Repository, transactionRepo transaction.Repository, billRepo bill.Repository) *Service {
+	return &Service{
+		repo:            repo,
+		userRepo:        userRepo,
+		transactionRepo: transactionRepo,
+		billRepo:        billRepo,
+	}
+}
+
+// GetUserStats returns the statistics of a single user
+func (s *Service) GetUserStats(ctx context.Context, userID int64) (*UserStats, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+	return s.collect(ctx, u)
+}
+
+// ListUserStats returns the statistics of every user, in the order the user repository lists them
+func (s *Service) ListUserStats(ctx context.Context) ([]*UserStats, error) {
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	result := make([]*UserStats, 0, len(users))
+	for _, u := range users {
+		st, err := s.collect(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, st)
+	}
+	return result, nil
+}
+
+// collect runs the count queries for one user
+func (s *Service) collect(ctx context.Context, u *user.User) (*UserStats, error) {
+	st := &UserStats{UserID: u.ID, Email: u.Email}
+	var err error
+
+	if st.Accounts, err = s.repo.CountAccounts(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to count accounts for user %d: %w", u.ID, err)
+	}
+	if st.TotalBalance, err = s.repo.SumBalancesByCurrency(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to sum balances for user %d: %w", u.ID, err)
+	}
+	if st.Transactions, err = s.transactionRepo.CountByUserID(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to count transactions for user %d: %w", u.ID, err)
+	}
+
+	considered := true
+	if st.ConsideredTransactions, err = s.transactionRepo.CountByUserIDFiltered(ctx, u.ID, transaction.ListFilter{Considered: &considered}); err != nil {
+		return nil, fmt.Errorf("failed to count considered transactions for user %d: %w", u.ID, err)
+	}
+	st.ExcludedTransactions = st.Transactions - st.ConsideredTransactions
+
+	if st.Bills, err = s.billRepo.CountByUserID(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to count bills for user %d: %w", u.ID, err)
+	}
+	if st.Tags, err = s.repo.CountTags(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to count tags for user %d: %w", u.ID, err)
+	}
+	if st.CousinRules, err = s.repo.CountCousinRules(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to count cousin rules for user %d: %w", u.ID, err)
+	}
+	if st.LastSyncAt, err = s.repo.GetLastSyncAt(ctx, u.ID); err != nil {
+		return nil, fmt.Errorf("failed to get last sync for user %d: %w", u.ID, err)
+	}
+
+	return st, nil
+}
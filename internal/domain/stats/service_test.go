@@ -0,0 +1,192 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/transaction"
+	"parsa/internal/domain/user"
+)
+
+// MockStatsRepo implements Repository with fixed per-user values
+type MockStatsRepo struct {
+	accounts    map[int64]int64
+	balances    map[int64]map[string]float64
+	tags        map[int64]int64
+	cousinRules map[int64]int64
+	lastSync    map[int64]*time.Time
+	err         error
+}
+
+func (m *MockStatsRepo) CountAccounts(ctx context.Context, userID int64) (int64, error) {
+	return m.accounts[userID], m.err
+}
+func (m *MockStatsRepo) SumBalancesByCurrency(ctx context.Context, userID int64) (map[string]float64, error) {
+	return m.balances[userID], m.err
+}
+func (m *MockStatsRepo) CountTags(ctx context.Context, userID int64) (int64, error) {
+	return m.tags[userID], m.err
+}
+func (m *MockStatsRepo) CountCousinRules(ctx context.Context, userID int64) (int64, error) {
+	return m.cousinRules[userID], m.err
+}
+func (m *MockStatsRepo) GetLastSyncAt(ctx context.Context, userID int64) (*time.Time, error) {
+	return m.lastSync[userID], m.err
+}
+
+// MockUserRepo implements user.Repository over a fixed list of users
+type MockUserRepo struct {
+	users []*user.User
+}
+
+func (m *MockUserRepo) Create(ctx context.Context, params user.CreateUserParams) (*user.User, error) {
+	return nil, nil
+}
+func (m *MockUserRepo) GetByID(ctx context.Context, id int64) (*user.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	return nil, nil
+}
+func (m *MockUserRepo) GetByOAuth(ctx context.Context, provider, oauthID string) (*user.User, error) {
+	return nil, nil
+}
+func (m *MockUserRepo) List(ctx context.Context) ([]*user.User, error) { return m.users, nil }
+func (m *MockUserRepo) Update(ctx context.Context, userID int64, params user.UpdateUserParams) (*user.User, error) {
+	return nil, nil
+}
+func (m *MockUserRepo) ListUsersWithProviderKey(ctx context.Context) ([]*user.User, error) {
+	return nil, nil
+}
+func (m *MockUserRepo) ClearProviderKey(ctx context.Context, userID int64) error { return nil }
+func (m *MockUserRepo) MarkProviderKeyValid(ctx context.Context, userID int64) error {
+	return nil
+}
+func (m *MockUserRepo) RecordProviderKeyFailure(ctx context.Context, userID int64) (int, error) {
+	return 0, nil
+}
+func (m *MockUserRepo) SetHasFinishedOpenfinanceFlow(ctx context.Context, userID int64, value bool) error {
+	return nil
+}
+func (m *MockUserRepo) SetInsightsStartDate(ctx context.Context, userID int64, date *time.Time) error {
+	return nil
+}
+
+// MockBillRepo implements bill.Repository with fixed per-user counts
+type MockBillRepo struct {
+	counts map[int64]int64
+}
+
+func (m *MockBillRepo) Create(ctx context.Context, params bill.CreateParams) (*bill.Bill, error) {
+	return nil, nil
+}
+func (m *MockBillRepo) GetByID(ctx context.Context, id string) (*bill.Bill, error) { return nil, nil }
+func (m *MockBillRepo) ListByAccountID(ctx context.Context, accountID string, limit, offset int) ([]*bill.Bill, error) {
+	return nil, nil
+}
+func (m *MockBillRepo) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*bill.Bill, error) {
+	return nil, nil
+}
+func (m *MockBillRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	return m.counts[userID], nil
+}
+func (m *MockBillRepo) Update(ctx context.Context, id string, params bill.UpdateParams) (*bill.Bill, error) {
+	return nil, nil
+}
+func (m *MockBillRepo) Delete(ctx context.Context, id string) error { return nil }
+func (m *MockBillRepo) Upsert(ctx context.Context, params bill.UpsertParams) (*bill.Bill, error) {
+	return nil, nil
+}
+
+// MockTransactionRepo implements transaction.Repository for testing; only the counts the
+// stats service reads are implemented
+type MockTransactionRepo struct {
+	transaction.Repository
+	CountByUserIDFunc         func(ctx context.Context, userID int64) (int64, error)
+	CountByUserIDFilteredFunc func(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error)
+}
+
+func (m *MockTransactionRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	if m.CountByUserIDFunc != nil {
+		return m.CountByUserIDFunc(ctx, userID)
+	}
+	return 0, nil
+}
+func (m *MockTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	if m.CountByUserIDFilteredFunc != nil {
+		return m.CountByUserIDFilteredFunc(ctx, userID, filter)
+	}
+	return 0, nil
+}
+
+func TestListUserStats(t *testing.T) {
+	lastSync := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	users := &MockUserRepo{users: []*user.User{{ID: 1, Email: "a@example.com"}, {ID: 2, Email: "b@example.com"}}}
+	repo := &MockStatsRepo{
+		accounts:    map[int64]int64{1: 3},
+		balances:    map[int64]map[string]float64{1: {"BRL": 1500.5, "USD": 20}},
+		tags:        map[int64]int64{1: 4, 2: 1},
+		cousinRules: map[int64]int64{1: 2},
+		lastSync:    map[int64]*time.Time{1: &lastSync},
+	}
+	txRepo := &MockTransactionRepo{
+		CountByUserIDFunc: func(ctx context.Context, userID int64) (int64, error) {
+			return map[int64]int64{1: 100, 2: 5}[userID], nil
+		},
+		CountByUserIDFilteredFunc: func(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+			if filter.Considered == nil || !*filter.Considered || filter.ConsideredReason != nil {
+				t.Errorf("unexpected filter %+v", filter)
+			}
+			return map[int64]int64{1: 90, 2: 5}[userID], nil
+		},
+	}
+	billRepo := &MockBillRepo{counts: map[int64]int64{1: 6}}
+
+	service := NewService(repo, users, txRepo, billRepo)
+	got, err := service.ListUserStats(context.Background())
+	if err != nil {
+		t.Fatalf("ListUserStats() error = %v", err)
+	}
+
+	want := []*UserStats{
+		{
+			UserID: 1, Email: "a@example.com", Accounts: 3, Transactions: 100, ConsideredTransactions: 90,
+			ExcludedTransactions: 10, Bills: 6, Tags: 4, CousinRules: 2, LastSyncAt: &lastSync,
+			TotalBalance: map[string]float64{"BRL": 1500.5, "USD": 20},
+		},
+		{
+			UserID: 2, Email: "b@example.com", Transactions: 5, ConsideredTransactions: 5, Tags: 1,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListUserStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetUserStats_Errors(t *testing.T) {
+	users := &MockUserRepo{users: []*user.User{{ID: 1}}}
+
+	t.Run("unknown user", func(t *testing.T) {
+		service := NewService(&MockStatsRepo{}, users, &MockTransactionRepo{}, &MockBillRepo{})
+		if _, err := service.GetUserStats(context.Background(), 2); err == nil {
+			t.Error("expected an error for an unknown user")
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repoErr := errors.New("connection refused")
+		service := NewService(&MockStatsRepo{err: repoErr}, users, &MockTransactionRepo{}, &MockBillRepo{})
+		if _, err := service.GetUserStats(context.Background(), 1); !errors.Is(err, repoErr) {
+			t.Errorf("GetUserStats() error = %v, want %v", err, repoErr)
+		}
+	})
+}
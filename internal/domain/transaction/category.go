@@ -1,5 +1,17 @@
 package transaction
 
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CategoryMappingVersion identifies the contents of CategoryMapping. Clients cache the
+// category list by it, so bump it whenever an entry is added, removed or renamed.
+const CategoryMappingVersion = 1
+
 type TransactionCategory struct {
 	ID              int64  `json:"id"`
 	OpenFinanceName string `json:"openFinanceName"`
@@ -604,9 +616,61 @@ var CategoryMapping = map[string]TransactionCategory{
 	},
 }
 
+// CategoryEntry is one CategoryMapping entry with its code
+type CategoryEntry struct {
+	Code            string `json:"code"`
+	OpenFinanceName string `json:"openFinanceName"`
+	ParsaName       string `json:"parsaName"`
+}
+
+// Reverse indexes and sorted views over CategoryMapping, built once at init so sync lookups
+// and the categories endpoint don't scan the map. Names are keyed by normalizeText so lookups
+// ignore case and accents.
+var (
+	keyByOpenFinanceName map[string]string
+	keysByParsaName      map[string][]string
+	sortedCategories     []CategoryEntry
+	sortedParsaNames     []string
+)
+
+func init() {
+	keyByOpenFinanceName = make(map[string]string, len(CategoryMapping))
+	keysByParsaName = make(map[string][]string)
+	for key, cat := range CategoryMapping {
+		keyByOpenFinanceName[normalizeText(cat.OpenFinanceName)] = key
+		parsaName := normalizeText(cat.ParsaName)
+		keysByParsaName[parsaName] = append(keysByParsaName[parsaName], key)
+	}
+	for _, keys := range keysByParsaName {
+		sort.Strings(keys)
+	}
+
+	sortedCategories = make([]CategoryEntry, 0, len(CategoryMapping))
+	seen := make(map[string]bool)
+	for key, cat := range CategoryMapping {
+		sortedCategories = append(sortedCategories, CategoryEntry{Code: key, OpenFinanceName: cat.OpenFinanceName, ParsaName: cat.ParsaName})
+		if !seen[cat.ParsaName] {
+			seen[cat.ParsaName] = true
+			sortedParsaNames = append(sortedParsaNames, cat.ParsaName)
+		}
+	}
+	sort.Slice(sortedCategories, func(i, j int) bool { return sortedCategories[i].Code < sortedCategories[j].Code })
+	sort.Strings(sortedParsaNames)
+}
+
+// Categories returns every CategoryMapping entry sorted by code
+func Categories() []CategoryEntry {
+	return append([]CategoryEntry(nil), sortedCategories...)
+}
+
+// ParsaNames returns the distinct ParsaNames in CategoryMapping, sorted
+func ParsaNames() []string {
+	return append([]string(nil), sortedParsaNames...)
+}
+
 // GetCategoryKey returns the category code (Key) from OpenFinanceName or code
 // If category is already a code (8 digits), returns it as-is
-// If category is an OpenFinanceName, performs reverse lookup to find the Key
+// If category is an OpenFinanceName, performs reverse lookup to find the Key (ignoring case and accents)
 // Returns nil if no mapping is found
 func GetCategoryKey(category *string) *string {
 	if category == nil || *category == "" {
@@ -618,11 +682,9 @@ func GetCategoryKey(category *string) *string {
 		return category
 	}
 
-	// Search by OpenFinanceName to find the Key
-	for key, cat := range CategoryMapping {
-		if cat.OpenFinanceName == *category {
-			return &key
-		}
+	// Reverse lookup by OpenFinanceName to find the Key
+	if key, ok := keyByOpenFinanceName[normalizeText(*category)]; ok {
+		return &key
 	}
 
 	// No mapping found
@@ -632,7 +694,7 @@ func GetCategoryKey(category *string) *string {
 // TranslateCategory translates an OpenFinance category (code or name) to ParsaName
 // It handles two cases:
 // 1. If category is a code (e.g., "01000000"), looks it up directly in CategoryMapping
-// 2. If category is a name (e.g., "Renda"), searches by OpenFinanceName
+// 2. If category is a name (e.g., "Renda"), searches by OpenFinanceName ignoring case and accents
 // Returns the ParsaName if found, otherwise returns the original category as fallback
 func TranslateCategory(category *string) *string {
 	if category == nil || *category == "" {
@@ -645,14 +707,56 @@ func TranslateCategory(category *string) *string {
 		return &parsaName
 	}
 
-	// If not found by code, search by OpenFinanceName
-	for _, cat := range CategoryMapping {
-		if cat.OpenFinanceName == *category {
-			parsaName := cat.ParsaName
-			return &parsaName
-		}
+	// If not found by code, look up by OpenFinanceName
+	if key, ok := keyByOpenFinanceName[normalizeText(*category)]; ok {
+		parsaName := CategoryMapping[key].ParsaName
+		return &parsaName
 	}
 
 	// Fallback: return original category if no mapping found
 	return category
 }
+
+// LookupParsaName returns the ParsaName in CategoryMapping that name matches, ignoring case
+// and accents, and whether there is one
+func LookupParsaName(name string) (string, bool) {
+	keys, ok := keysByParsaName[normalizeText(name)]
+	if !ok {
+		return "", false
+	}
+	return CategoryMapping[keys[0]].ParsaName, true
+}
+
+// GetCategoryKeysByParsaName returns the category codes that translate to a ParsaName,
+// sorted ascending. Several codes can share one ParsaName. The name is matched ignoring
+// case and accents. Returns nil if none match.
+func GetCategoryKeysByParsaName(parsaName string) []string {
+	keys, ok := keysByParsaName[normalizeText(parsaName)]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), keys...)
+}
+
+// normalizeText folds text for matching: accents are stripped, letters lowercased and runs of
+// whitespace collapsed to one space, so "Salário" and " salario" compare equal
+func normalizeText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range norm.NFD.String(strings.TrimSpace(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -1,6 +1,11 @@
 package transaction
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
 	"testing"
 )
 
@@ -121,3 +126,152 @@ func TestTranslateCategory_SpecificMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestCategoryIndexes_MatchLinearScan(t *testing.T) {
+	for key, cat := range CategoryMapping {
+		name := cat.OpenFinanceName
+
+		// Linear scan equivalent of the original reverse lookup
+		var wantKey string
+		for k, c := range CategoryMapping {
+			if c.OpenFinanceName == name {
+				wantKey = k
+				break
+			}
+		}
+		if wantKey != key {
+			t.Fatalf("OpenFinanceName %q maps to both %q and %q", name, wantKey, key)
+		}
+
+		gotKey := GetCategoryKey(&name)
+		if gotKey == nil || *gotKey != wantKey {
+			t.Errorf("GetCategoryKey(%q) = %v, want %q", name, gotKey, wantKey)
+		}
+		gotName := TranslateCategory(&name)
+		if gotName == nil || *gotName != cat.ParsaName {
+			t.Errorf("TranslateCategory(%q) = %v, want %q", name, gotName, cat.ParsaName)
+		}
+
+		var wantKeys []string
+		for k, c := range CategoryMapping {
+			if c.ParsaName == cat.ParsaName {
+				wantKeys = append(wantKeys, k)
+			}
+		}
+		sort.Strings(wantKeys)
+		if got := GetCategoryKeysByParsaName(cat.ParsaName); !slices.Equal(got, wantKeys) {
+			t.Errorf("GetCategoryKeysByParsaName(%q) = %v, want %v", cat.ParsaName, got, wantKeys)
+		}
+	}
+
+	if got := GetCategoryKeysByParsaName("NonExistentCategory"); got != nil {
+		t.Errorf("GetCategoryKeysByParsaName(unknown) = %v, want nil", got)
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Salário", "salario"},
+		{"SALÁRIO", "salario"},
+		{"Alimentação", "alimentacao"},
+		{"Despesa Não Classificada", "despesa nao classificada"},
+		{"  Saúde   e\tBem-estar ", "saude e bem-estar"},
+		{"Café", "cafe"},
+		{"Café", "cafe"}, // decomposed e + combining acute
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeText(tt.in); got != tt.want {
+			t.Errorf("normalizeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCategoryLookups_IgnoreCaseAndAccents(t *testing.T) {
+	for _, name := range []string{"Salário", "salario", "SALARIO", " salário "} {
+		name := name
+		if key := GetCategoryKey(&name); key == nil || *key != "01010000" {
+			t.Errorf("GetCategoryKey(%q) = %v, want 01010000", name, key)
+		}
+		if got := TranslateCategory(&name); got == nil || *got != "Salário" {
+			t.Errorf("TranslateCategory(%q) = %v, want Salário", name, got)
+		}
+	}
+
+	unclassified := "despesa nao classificada"
+	if key := GetCategoryKey(&unclassified); key == nil || CategoryMapping[*key].OpenFinanceName != "Despesa Não Classificada" {
+		t.Errorf("GetCategoryKey(%q) = %v, want the Despesa Não Classificada code", unclassified, key)
+	}
+
+	if keys := GetCategoryKeysByParsaName("renda ativa"); !slices.Contains(keys, "01000000") {
+		t.Errorf("GetCategoryKeysByParsaName(renda ativa) = %v, want it to contain 01000000", keys)
+	}
+
+	// Unknown names still fall back to the original text
+	unknown := "Categoria Inexistente"
+	if got := TranslateCategory(&unknown); got == nil || *got != unknown {
+		t.Errorf("TranslateCategory(%q) = %v, want the input back", unknown, got)
+	}
+}
+
+func TestLookupParsaName(t *testing.T) {
+	for _, name := range []string{"Salário", "salario", " SALÁRIO "} {
+		if got, ok := LookupParsaName(name); !ok || got != "Salário" {
+			t.Errorf("LookupParsaName(%q) = %q, %v, want Salário, true", name, got, ok)
+		}
+	}
+
+	// OpenFinance names and codes are not ParsaNames
+	for _, name := range []string{"01010000", "Categoria Inexistente", ""} {
+		if got, ok := LookupParsaName(name); ok {
+			t.Errorf("LookupParsaName(%q) = %q, want no match", name, got)
+		}
+	}
+}
+
+func TestCategories_SortedSnapshot(t *testing.T) {
+	cats := Categories()
+	if len(cats) != len(CategoryMapping) {
+		t.Fatalf("Categories() returned %d entries, want %d", len(cats), len(CategoryMapping))
+	}
+	for i, c := range cats {
+		if i > 0 && cats[i-1].Code >= c.Code {
+			t.Errorf("Categories() not sorted by code at %d: %q after %q", i, c.Code, cats[i-1].Code)
+		}
+		if m := CategoryMapping[c.Code]; m.OpenFinanceName != c.OpenFinanceName || m.ParsaName != c.ParsaName {
+			t.Errorf("entry %q = %+v, want %+v", c.Code, c, m)
+		}
+	}
+
+	names := ParsaNames()
+	if !slices.IsSorted(names) {
+		t.Error("ParsaNames() is not sorted")
+	}
+	if len(slices.Compact(slices.Clone(names))) != len(names) {
+		t.Error("ParsaNames() contains duplicates")
+	}
+
+	// Callers get copies, not the shared snapshot
+	cats[0].ParsaName = "changed"
+	if Categories()[0].ParsaName == "changed" {
+		t.Error("Categories() exposes the shared snapshot")
+	}
+}
+
+// categoryMappingChecksum is the SHA-256 of CategoryMapping at CategoryMappingVersion. When this
+// test fails after editing the mapping, bump CategoryMappingVersion and update the checksum.
+const categoryMappingChecksum = "23cd867cae64f143013adb45a1e7010a6d13305db4a38a07b468bdbbec0fc6b7"
+
+func TestCategoryMappingVersion(t *testing.T) {
+	h := sha256.New()
+	for _, c := range Categories() {
+		fmt.Fprintf(h, "%s\t%s\t%s\n", c.Code, c.OpenFinanceName, c.ParsaName)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != categoryMappingChecksum {
+		t.Errorf("CategoryMapping changed (checksum %s): bump CategoryMappingVersion (now %d) and update categoryMappingChecksum", got, CategoryMappingVersion)
+	}
+}
@@ -0,0 +1,38 @@
+package transaction
+
+// CountGroup is the number of a user's transactions sharing a type, status and considered flag
+type CountGroup struct {
+	Type       string
+	Status     string
+	Considered bool
+	Count      int64
+}
+
+// Counts summarizes a user's transactions along each dimension separately. ByType always holds
+// DEBIT and CREDIT and ByStatus PENDING and POSTED, zero when there are none.
+type Counts struct {
+	Total         int64
+	ByType        map[string]int64
+	ByStatus      map[string]int64
+	Considered    int64
+	NotConsidered int64
+}
+
+// SummarizeCounts folds grouped counts into per-dimension totals
+func SummarizeCounts(groups []CountGroup) Counts {
+	counts := Counts{
+		ByType:   map[string]int64{"DEBIT": 0, "CREDIT": 0},
+		ByStatus: map[string]int64{"PENDING": 0, "POSTED": 0},
+	}
+	for _, g := range groups {
+		counts.Total += g.Count
+		counts.ByType[g.Type] += g.Count
+		counts.ByStatus[g.Status] += g.Count
+		if g.Considered {
+			counts.Considered += g.Count
+		} else {
+			counts.NotConsidered += g.Count
+		}
+	}
+	return counts
+}
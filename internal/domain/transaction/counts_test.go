@@ -0,0 +1,36 @@
+package transaction
+
+import "testing"
+
+func TestSummarizeCounts(t *testing.T) {
+	counts := SummarizeCounts([]CountGroup{
+		{Type: "DEBIT", Status: "POSTED", Considered: true, Count: 10},
+		{Type: "DEBIT", Status: "PENDING", Considered: true, Count: 2},
+		{Type: "CREDIT", Status: "POSTED", Considered: false, Count: 3},
+	})
+
+	if counts.Total != 15 {
+		t.Errorf("total = %d, want 15", counts.Total)
+	}
+	if counts.ByType["DEBIT"] != 12 || counts.ByType["CREDIT"] != 3 {
+		t.Errorf("byType = %v, want DEBIT 12 and CREDIT 3", counts.ByType)
+	}
+	if counts.ByStatus["POSTED"] != 13 || counts.ByStatus["PENDING"] != 2 {
+		t.Errorf("byStatus = %v, want POSTED 13 and PENDING 2", counts.ByStatus)
+	}
+	if counts.Considered != 12 || counts.NotConsidered != 3 {
+		t.Errorf("considered = %d, notConsidered = %d, want 12 and 3", counts.Considered, counts.NotConsidered)
+	}
+
+	empty := SummarizeCounts(nil)
+	for _, key := range []string{"DEBIT", "CREDIT"} {
+		if v, ok := empty.ByType[key]; !ok || v != 0 {
+			t.Errorf("empty byType[%s] = %d, %v, want a zero entry", key, v, ok)
+		}
+	}
+	for _, key := range []string{"PENDING", "POSTED"} {
+		if v, ok := empty.ByStatus[key]; !ok || v != 0 {
+			t.Errorf("empty byStatus[%s] = %d, %v, want a zero entry", key, v, ok)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCousinNameLength is the longest cousin name the cousins table accepts
+const maxCousinNameLength = 255
+
+// DuplicateCousinResolver returns the cousin that groups a duplicate pair of userID: the one of
+// documentID or the user's cousin named name when either exists, or else a new one. It must be
+// safe to call concurrently for the same pair.
+type DuplicateCousinResolver func(ctx context.Context, userID int64, name string, documentID *int64) (int64, error)
+
+// SetCousinResolver makes the service group every duplicate pair under a shared cousin, so
+// cousin rules can act on both transactions. nil (the default) disables it.
+func (s *DuplicateCheckService) SetCousinResolver(resolve DuplicateCousinResolver) {
+	s.resolveCousin = resolve
+}
+
+// linkDuplicateCousins gives a duplicate pair a shared cousin: the one either transaction
+// already has, or the resolver's when neither has any. Pairs already split across two cousins
+// are left alone, so running it again never regroups anything. Both sides of a pair may be
+// checked at once, possibly by different processes; they resolve the same name and document,
+// so the resolver hands both the same cousin.
+func (s *DuplicateCheckService) linkDuplicateCousins(ctx context.Context, userID int64, a, b *Transaction) error {
+	if s.resolveCousin == nil {
+		return nil
+	}
+
+	var err error
+	if a, err = s.reloadForLink(ctx, a.ID); err != nil || a == nil {
+		return err
+	}
+	if b, err = s.reloadForLink(ctx, b.ID); err != nil || b == nil {
+		return err
+	}
+
+	switch {
+	case a.Cousin != nil && b.Cousin != nil:
+		return nil
+	case a.Cousin != nil:
+		return s.setCousinForLink(ctx, b.ID, *a.Cousin)
+	case b.Cousin != nil:
+		return s.setCousinForLink(ctx, a.ID, *b.Cousin)
+	}
+
+	name, documentID := pairCousinKey(a, b)
+	var cousinID int64
+	err = s.withDBSlot(ctx, func() (err error) {
+		cousinID, err = s.resolveCousin(ctx, userID, name, documentID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to resolve cousin for duplicate pair: %w", err)
+	}
+	if err := s.setCousinForLink(ctx, a.ID, cousinID); err != nil {
+		return err
+	}
+	if err := s.setCousinForLink(ctx, b.ID, cousinID); err != nil {
+		return err
+	}
+
+	slog.Debug("Grouped duplicate pair under a cousin", "user_id", userID, "cousin_id", cousinID,
+		"transaction_id", a.ID, "duplicate_id", b.ID)
+	return nil
+}
+
+// linkDuplicatePair runs linkDuplicateCousins, logging failures: the pair is already marked,
+// which matters more than the grouping
+func (s *DuplicateCheckService) linkDuplicatePair(ctx context.Context, userID int64, a, b *Transaction) {
+	if err := s.linkDuplicateCousins(ctx, userID, a, b); err != nil {
+		slog.Warn("Failed to group duplicate pair under a cousin", "transaction_id", a.ID, "duplicate_id", b.ID, "error", err)
+	}
+}
+
+func (s *DuplicateCheckService) reloadForLink(ctx context.Context, id string) (txn *Transaction, err error) {
+	err = s.withDBSlot(ctx, func() (err error) {
+		txn, err = s.repo.GetByID(ctx, id)
+		return err
+	})
+	return txn, err
+}
+
+// setCousinForLink assigns the pair's cousin without applying its rules: a reused cousin's rule
+// could otherwise re-include the duplicate the check just excluded and overwrite its notes
+func (s *DuplicateCheckService) setCousinForLink(ctx context.Context, transactionID string, cousinID int64) error {
+	return s.withDBSlot(ctx, func() error {
+		return s.repo.SetCousinWithoutRules(ctx, transactionID, cousinID)
+	})
+}
+
+// pairCousinKey returns the name and document a pair's cousin is resolved by. The pair is
+// ordered by ID first so both sides of a pair resolve the same key: the name comes from the
+// first transaction with a description and the document from the first that has one.
+func pairCousinKey(a, b *Transaction) (string, *int64) {
+	if b.ID < a.ID {
+		a, b = b, a
+	}
+	named := a
+	if strings.TrimSpace(a.Description) == "" && strings.TrimSpace(b.Description) != "" {
+		named = b
+	}
+	documentID := a.DocumentID
+	if documentID == nil {
+		documentID = b.DocumentID
+	}
+	return duplicateCousinName(named), documentID
+}
+
+// duplicateCousinName names a duplicate pair's cousin after the transaction's description,
+// cut to the longest name a cousin may have
+func duplicateCousinName(txn *Transaction) string {
+	name := strings.TrimSpace(txn.Description)
+	if name == "" {
+		return "Transaction " + txn.ID
+	}
+	for len(name) > maxCousinNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -2,11 +2,16 @@ package transaction
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"math"
 	"strings"
-	"sync"
 	"time"
+
+	"golang.org/x/sync/semaphore"
+
+	"parsa/internal/shared/messages"
+	"parsa/internal/shared/money"
+	"parsa/internal/shared/pool"
 )
 
 const (
@@ -16,49 +21,121 @@ const (
 	// BillDuplicateTimeDelta is the time window for finding potential duplicates related to bills (120 hours / 5 days)
 	BillDuplicateTimeDelta = 120 * time.Hour
 
-	// DuplicateNote is the note added to transactions marked as potential duplicates
+	// DuplicateNote is the default note added to transactions marked as potential duplicates
 	DuplicateNote = "Esta transação não será considerada no cálculo de saldos e insights. Possíveis motivos incluem estornos, pagamentos e créditos da fatura do cartão de crédito, etc."
 
+	// BillPaymentNote is the default note added to transactions matched against a credit card bill
+	BillPaymentNote = "Esta transação não será considerada no cálculo de saldos e insights, pois corresponde ao pagamento de uma fatura de cartão de crédito."
+
 	// DefaultWorkerCount is the default number of concurrent workers for duplicate checking
 	DefaultWorkerCount = 4
 
 	// DefaultBatchSize is the default batch size for fetching transactions during full check
 	DefaultBatchSize = 500
+
+	// DefaultMaxConcurrentDBOps is the default limit on repository calls running at once
+	// across all duplicate and bill payment checks
+	DefaultMaxConcurrentDBOps = 10
 )
 
+// Notes holds the human-readable texts appended to transactions marked by the duplicate check
+type Notes struct {
+	Duplicate   string
+	BillPayment string
+}
+
+// LoadNotes returns the note texts of locale from the message catalog, with duplicate and
+// billPayment, when set, overriding the catalog's
+func LoadNotes(locale, duplicate, billPayment string) (Notes, error) {
+	catalog, err := messages.LoadNotes(locale)
+	if err != nil {
+		return Notes{}, err
+	}
+	notes := Notes{Duplicate: catalog.Duplicate, BillPayment: catalog.BillPayment}
+	if duplicate != "" {
+		notes.Duplicate = duplicate
+	}
+	if billPayment != "" {
+		notes.BillPayment = billPayment
+	}
+	return notes, nil
+}
+
+// DefaultBillPaymentCategories are the provider category codes excluded as credit card bill
+// payments on import ("Pagamento de cartão de crédito")
+var DefaultBillPaymentCategories = []string{"05100000"}
+
+func categorySet(codes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return set
+}
+
+// appendNote returns existing notes followed by text
+func appendNote(existing *string, text string) string {
+	if existing != nil && *existing != "" {
+		return *existing + " " + text
+	}
+	return text
+}
+
+// RemoveDetectionNotes returns notes without the texts this service's duplicate and bill payment
+// checks append when they exclude a transaction, so a user override doesn't keep the auto-exclusion
+// explanation. Reports false when notes carry none of them.
+func (s *DuplicateCheckService) RemoveDetectionNotes(notes string) (string, bool) {
+	cleaned := notes
+	for _, text := range []string{s.notes.Duplicate, s.notes.BillPayment} {
+		// appendNote joins with a space, so drop it along with the text
+		cleaned = strings.ReplaceAll(cleaned, " "+text, "")
+		cleaned = strings.ReplaceAll(cleaned, text, "")
+	}
+	if cleaned == notes {
+		return notes, false
+	}
+	return strings.TrimSpace(cleaned), true
+}
+
 // DuplicateCheckResult contains the results of a duplicate check operation
 type DuplicateCheckResult struct {
-	TransactionsChecked int
-	DuplicatesFound     int
-	DuplicatesMarked    int
-	Errors              []string
+	TransactionsChecked int      `json:"transactionsChecked"`
+	DuplicatesFound     int      `json:"duplicatesFound"`
+	DuplicatesMarked    int      `json:"duplicatesMarked"`
+	DuplicatesReleased  int      `json:"duplicatesReleased,omitempty"`
+	Errors              []string `json:"errors,omitempty"`
+
+	// Releases holds the transactions a recheck re-included, for the caller to audit
+	Releases []Release `json:"-"`
 }
 
-// duplicateCheckJob represents a single transaction to check for duplicates
-type duplicateCheckJob struct {
-	transaction *Transaction
-	userID      int64
+// Release is a transaction a duplicate recheck re-included, as it was before and after
+type Release struct {
+	Before *Transaction
+	After  *Transaction
 }
 
-// duplicateCheckWorkerResult represents the result of processing a single job
-type duplicateCheckWorkerResult struct {
-	duplicatesFound  int
-	duplicatesMarked int
-	err              error
+// duplicateCheckCounts is the outcome of checking a single transaction
+type duplicateCheckCounts struct {
+	found  int
+	marked int
 }
 
 // DuplicateCheckService handles checking transactions for potential duplicates
 type DuplicateCheckService struct {
-	repo        Repository
-	workerCount int
+	repo                  Repository
+	workerCount           int
+	notes                 Notes
+	billPaymentCategories map[string]struct{}
+	billWindow            time.Duration
+	sameAccountOnly       bool
+	resolveCousin         DuplicateCousinResolver
+	dbOps                 *semaphore.Weighted
 }
 
 // NewDuplicateCheckService creates a new duplicate check service
 func NewDuplicateCheckService(repo Repository) *DuplicateCheckService {
-	return &DuplicateCheckService{
-		repo:        repo,
-		workerCount: DefaultWorkerCount,
-	}
+	return NewDuplicateCheckServiceWithWorkers(repo, DefaultWorkerCount)
 }
 
 // NewDuplicateCheckServiceWithWorkers creates a new duplicate check service with custom worker count
@@ -67,11 +144,72 @@ func NewDuplicateCheckServiceWithWorkers(repo Repository, workerCount int) *Dupl
 		workerCount = DefaultWorkerCount
 	}
 	return &DuplicateCheckService{
-		repo:        repo,
-		workerCount: workerCount,
+		repo:                  repo,
+		workerCount:           workerCount,
+		notes:                 Notes{Duplicate: DuplicateNote, BillPayment: BillPaymentNote},
+		billPaymentCategories: categorySet(DefaultBillPaymentCategories),
+		billWindow:            BillDuplicateTimeDelta,
+		dbOps:                 semaphore.NewWeighted(DefaultMaxConcurrentDBOps),
 	}
 }
 
+// SetNotes overrides the note texts appended to the transactions the checks exclude. Empty
+// fields keep the built-in Portuguese text.
+func (s *DuplicateCheckService) SetNotes(notes Notes) {
+	if notes.Duplicate != "" {
+		s.notes.Duplicate = notes.Duplicate
+	}
+	if notes.BillPayment != "" {
+		s.notes.BillPayment = notes.BillPayment
+	}
+}
+
+// SetBillPaymentCategories replaces the provider category codes excluded as bill payments.
+// An empty list disables the check.
+func (s *DuplicateCheckService) SetBillPaymentCategories(codes []string) {
+	s.billPaymentCategories = categorySet(codes)
+}
+
+// SetMaxConcurrentDBOps sets how many repository calls the duplicate and bill payment checks
+// of this service may run at once. Share one service between concurrent syncs so they cannot
+// exhaust the database pool together. Values below 1 restore the default.
+func (s *DuplicateCheckService) SetMaxConcurrentDBOps(n int) {
+	if n < 1 {
+		n = DefaultMaxConcurrentDBOps
+	}
+	s.dbOps = semaphore.NewWeighted(int64(n))
+}
+
+// withDBSlot runs fn while holding one slot of the service's repository call limit
+func (s *DuplicateCheckService) withDBSlot(ctx context.Context, fn func() error) error {
+	if err := s.dbOps.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer s.dbOps.Release(1)
+	return fn()
+}
+
+// SetBillWindow sets how far from a bill's due date a transaction may be to match it.
+// Values below or equal to zero restore BillDuplicateTimeDelta.
+func (s *DuplicateCheckService) SetBillWindow(window time.Duration) {
+	if window <= 0 {
+		window = BillDuplicateTimeDelta
+	}
+	s.billWindow = window
+}
+
+// BillWindow returns how far from a bill's due date a transaction may be to match it
+func (s *DuplicateCheckService) BillWindow() time.Duration {
+	return s.billWindow
+}
+
+// SetSameAccountOnly limits duplicate matches to the transaction's own account, which avoids
+// cross-account false positives for users with many accounts. By default matches span all
+// of the user's accounts.
+func (s *DuplicateCheckService) SetSameAccountOnly(sameAccountOnly bool) {
+	s.sameAccountOnly = sameAccountOnly
+}
+
 // CheckBatchForDuplicates checks a batch of transactions for potential duplicates concurrently
 // This is the main entry point for duplicate checking after batch operations
 func (s *DuplicateCheckService) CheckBatchForDuplicates(ctx context.Context, transactions []*Transaction, userID int64) *DuplicateCheckResult {
@@ -84,69 +222,35 @@ func (s *DuplicateCheckService) CheckBatchForDuplicates(ctx context.Context, tra
 		return result
 	}
 
-	// Create channels for job distribution and result collection
-	jobs := make(chan duplicateCheckJob, len(transactions))
-	results := make(chan duplicateCheckWorkerResult, len(transactions))
-
-	// Start worker pool
-	var wg sync.WaitGroup
-	for i := 0; i < s.workerCount; i++ {
-		wg.Add(1)
-		go s.duplicateCheckWorker(ctx, jobs, results, &wg)
-	}
-
-	// Send jobs to workers
-	for _, txn := range transactions {
-		jobs <- duplicateCheckJob{
-			transaction: txn,
-			userID:      userID,
-		}
-	}
-	close(jobs)
-
-	// Wait for all workers to complete and close results channel
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Collect results
-	for workerResult := range results {
-		if workerResult.err != nil {
-			result.Errors = append(result.Errors, workerResult.err.Error())
-		}
-		result.DuplicatesFound += workerResult.duplicatesFound
-		result.DuplicatesMarked += workerResult.duplicatesMarked
-	}
+	s.collectResults(result, s.checkConcurrently(ctx, transactions, userID, s.workerCount))
 
-	log.Printf("Duplicate check completed: checked=%d, found=%d, marked=%d, errors=%d",
-		result.TransactionsChecked, result.DuplicatesFound, result.DuplicatesMarked, len(result.Errors))
+	slog.Debug("Duplicate check completed", "user_id", userID, "checked", result.TransactionsChecked,
+		"found", result.DuplicatesFound, "marked", result.DuplicatesMarked, "errors", len(result.Errors))
 
 	return result
 }
 
-// duplicateCheckWorker is a worker goroutine that processes duplicate check jobs
-func (s *DuplicateCheckService) duplicateCheckWorker(
+// checkConcurrently checks each transaction with at most concurrency checks running at once
+func (s *DuplicateCheckService) checkConcurrently(
 	ctx context.Context,
-	jobs <-chan duplicateCheckJob,
-	results chan<- duplicateCheckWorkerResult,
-	wg *sync.WaitGroup,
-) {
-	defer wg.Done()
+	transactions []*Transaction,
+	userID int64,
+	concurrency int,
+) []pool.Result[duplicateCheckCounts] {
+	return pool.Map(ctx, transactions, concurrency, func(ctx context.Context, txn *Transaction) (duplicateCheckCounts, error) {
+		found, marked, err := s.checkTransactionForDuplicates(ctx, txn, userID)
+		return duplicateCheckCounts{found: found, marked: marked}, err
+	})
+}
 
-	for job := range jobs {
-		select {
-		case <-ctx.Done():
-			results <- duplicateCheckWorkerResult{err: ctx.Err()}
-			return
-		default:
-			found, marked, err := s.checkTransactionForDuplicates(ctx, job.transaction, job.userID)
-			results <- duplicateCheckWorkerResult{
-				duplicatesFound:  found,
-				duplicatesMarked: marked,
-				err:              err,
-			}
+// collectResults adds the per-transaction outcomes to result
+func (s *DuplicateCheckService) collectResults(result *DuplicateCheckResult, outcomes []pool.Result[duplicateCheckCounts]) {
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			result.Errors = append(result.Errors, outcome.Err.Error())
 		}
+		result.DuplicatesFound += outcome.Value.found
+		result.DuplicatesMarked += outcome.Value.marked
 	}
 }
 
@@ -156,28 +260,7 @@ func (s *DuplicateCheckService) checkTransactionForDuplicates(
 	txn *Transaction,
 	userID int64,
 ) (found int, marked int, err error) {
-	// Determine the opposite type
-	oppositeType := "CREDIT"
-	if txn.Type == "CREDIT" {
-		oppositeType = "DEBIT"
-	}
-
-	// Calculate time bounds
-	lowerBound := txn.TransactionDate.Add(-DuplicateTimeDelta)
-	upperBound := txn.TransactionDate.Add(DuplicateTimeDelta)
-
-	// Build search criteria
-	criteria := DuplicateCriteria{
-		ExcludeID:      txn.ID,
-		OppositeType:   oppositeType,
-		AbsoluteAmount: math.Abs(txn.Amount),
-		DateLowerBound: lowerBound,
-		DateUpperBound: upperBound,
-		UserID:         userID,
-	}
-
-	// Find potential duplicates
-	duplicates, err := s.repo.FindPotentialDuplicates(ctx, criteria)
+	duplicates, err := s.FindDuplicateCandidates(ctx, txn, userID)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -189,36 +272,77 @@ func (s *DuplicateCheckService) checkTransactionForDuplicates(
 
 	// Mark duplicates as not considered
 	for _, dup := range duplicates {
-		// Check if note already contains the duplicate message
-		if dup.Notes != nil {
-			if strings.Contains(*dup.Notes, "Esta transação não será considerada") ||
-				strings.Contains(*dup.Notes, "desconsiderada") {
-				continue // Already marked
+		// Skip transactions whose considered state was already decided (user or detection),
+		// still grouping pairs an earlier run marked
+		if dup.HasConsideredReason() {
+			if *dup.ConsideredReason == ConsideredReasonDuplicate {
+				s.linkDuplicatePair(ctx, userID, txn, dup)
 			}
+			continue
 		}
 
 		// Update the duplicate transaction
 		considered := false
-		newNotes := DuplicateNote
-		if dup.Notes != nil && *dup.Notes != "" {
-			newNotes = *dup.Notes + " " + DuplicateNote
-		}
-
-		_, err := s.repo.Update(ctx, dup.ID, UpdateTransactionParams{
-			Considered: &considered,
-			Notes:      &newNotes,
+		newNotes := appendNote(dup.Notes, s.notes.Duplicate)
+		reason := ConsideredReasonDuplicate
+
+		err := s.withDBSlot(ctx, func() error {
+			_, err := s.repo.Update(ctx, dup.ID, UpdateTransactionParams{
+				Considered:       &considered,
+				Notes:            &newNotes,
+				ConsideredReason: &reason,
+			})
+			return err
 		})
 		if err != nil {
-			log.Printf("Failed to mark transaction %s as duplicate: %v", dup.ID, err)
+			slog.Warn("Failed to mark transaction as duplicate", "transaction_id", dup.ID, "error", err)
 			continue
 		}
 
 		marked++
+		s.linkDuplicatePair(ctx, userID, txn, dup)
 	}
 
 	return found, marked, nil
 }
 
+// duplicateCriteria builds the search for transactions that could duplicate txn: the
+// opposite type with the same absolute amount within DuplicateTimeDelta, for the same user
+// (and the same account when sameAccountOnly is set)
+func duplicateCriteria(txn *Transaction, userID int64, sameAccountOnly bool) DuplicateCriteria {
+	oppositeType := "CREDIT"
+	if txn.Type == "CREDIT" {
+		oppositeType = "DEBIT"
+	}
+
+	return DuplicateCriteria{
+		ExcludeID:       txn.ID,
+		OppositeType:    oppositeType,
+		AbsoluteAmount:  money.Round(math.Abs(txn.Amount)),
+		DateLowerBound:  txn.TransactionDate.Add(-DuplicateTimeDelta),
+		DateUpperBound:  txn.TransactionDate.Add(DuplicateTimeDelta),
+		UserID:          userID,
+		AccountID:       txn.AccountID,
+		SameAccountOnly: sameAccountOnly,
+	}
+}
+
+// FindDuplicateCandidates returns the transactions the duplicate check matches against txn,
+// without marking anything
+func (s *DuplicateCheckService) FindDuplicateCandidates(ctx context.Context, txn *Transaction, userID int64) ([]*Transaction, error) {
+	criteria := duplicateCriteria(txn, userID, s.sameAccountOnly)
+
+	var duplicates []*Transaction
+	err := s.withDBSlot(ctx, func() (err error) {
+		duplicates, err = s.repo.FindPotentialDuplicates(ctx, criteria)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return duplicates, nil
+}
+
 // CheckTransactionForDuplicates checks a single transaction for duplicates
 // This is useful for checking individual transactions during sync
 func (s *DuplicateCheckService) CheckTransactionForDuplicates(
@@ -229,8 +353,98 @@ func (s *DuplicateCheckService) CheckTransactionForDuplicates(
 	return s.checkTransactionForDuplicates(ctx, txn, userID)
 }
 
+// DuplicateFieldsChanged reports whether an edit changed any field the duplicate check
+// matches on: the absolute amount, the transaction date or the type
+func DuplicateFieldsChanged(before, after *Transaction) bool {
+	return !money.Equal(math.Abs(before.Amount), math.Abs(after.Amount)) ||
+		!before.TransactionDate.Equal(after.TransactionDate) ||
+		before.Type != after.Type
+}
+
+// RecheckAfterEdit re-runs the duplicate check for a transaction a user edited; before and
+// after are the transaction as it was and as it is now. The transactions the old values marked
+// as duplicates, and the edited transaction itself, are re-included when nothing matches them
+// any more, then after is checked like a newly synced transaction. Only DUPLICATE exclusions
+// are ever released, and marking skips transactions that already have a reason, so a user's
+// own decision (reason USER) always wins.
+func (s *DuplicateCheckService) RecheckAfterEdit(ctx context.Context, before, after *Transaction, userID int64) *DuplicateCheckResult {
+	result := &DuplicateCheckResult{
+		TransactionsChecked: 1,
+		Errors:              []string{},
+	}
+
+	formerMatches, err := s.FindDuplicateCandidates(ctx, before, userID)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	for _, txn := range append(formerMatches, after) {
+		released, err := s.releaseIfUnmatched(ctx, txn, userID)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		if released != nil {
+			result.DuplicatesReleased++
+			result.Releases = append(result.Releases, Release{Before: txn, After: released})
+		}
+	}
+
+	found, marked, err := s.checkTransactionForDuplicates(ctx, after, userID)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	result.DuplicatesFound = found
+	result.DuplicatesMarked = marked
+
+	slog.Debug("Duplicate recheck after edit completed", "transaction_id", after.ID, "user_id", userID,
+		"released", result.DuplicatesReleased, "found", found, "marked", marked, "errors", len(result.Errors))
+
+	return result
+}
+
+// releaseIfUnmatched re-includes txn when the duplicate check excluded it and no transaction
+// matches it any more. Returns txn as released, or nil when it was left alone.
+func (s *DuplicateCheckService) releaseIfUnmatched(ctx context.Context, txn *Transaction, userID int64) (*Transaction, error) {
+	if txn.ConsideredReason == nil || *txn.ConsideredReason != ConsideredReasonDuplicate {
+		return nil, nil
+	}
+
+	matches, err := s.FindDuplicateCandidates(ctx, txn, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 0 {
+		return nil, nil
+	}
+
+	var notes *string
+	if txn.Notes != nil {
+		if cleaned, changed := s.RemoveDetectionNotes(*txn.Notes); changed {
+			notes = &cleaned
+		}
+	}
+
+	var released bool
+	err = s.withDBSlot(ctx, func() (err error) {
+		released, err = s.repo.ReleaseDetection(ctx, txn.ID, ConsideredReasonDuplicate, notes)
+		return err
+	})
+	if err != nil || !released {
+		return nil, err
+	}
+
+	after := *txn
+	after.Considered = true
+	after.ConsideredReason = nil
+	if notes != nil {
+		after.Notes = notes
+	}
+	return &after, nil
+}
+
 // CheckBillForDuplicates checks for transactions that could be duplicates related to a bill
-// Uses +/-120 hours from the bill's due date and matches transactions with the same absolute amount (any type)
+// Uses +/- the bill window (120 hours by default) from the bill's due date and matches transactions
+// with the same absolute amount (any type)
 func (s *DuplicateCheckService) CheckBillForDuplicates(
 	ctx context.Context,
 	billAccountID string,
@@ -238,21 +452,7 @@ func (s *DuplicateCheckService) CheckBillForDuplicates(
 	billTotalAmount float64,
 	userID int64,
 ) (duplicatesFound int, duplicatesMarked int, err error) {
-	// Calculate time bounds based on bill due date +/- 120 hours
-	lowerBound := billDueDate.Add(-BillDuplicateTimeDelta)
-	upperBound := billDueDate.Add(BillDuplicateTimeDelta)
-
-	// Build search criteria (no ExcludeID needed - we're checking all transactions against the bill)
-	criteria := DuplicateCriteria{
-		ExcludeID:      "", // Empty string - we want to check all transactions
-		AbsoluteAmount: math.Abs(billTotalAmount),
-		DateLowerBound: lowerBound,
-		DateUpperBound: upperBound,
-		UserID:         userID,
-	}
-
-	// Find potential duplicates using bill-specific method (no type restriction)
-	duplicates, err := s.repo.FindPotentialDuplicatesForBill(ctx, criteria)
+	duplicates, err := s.findBillCandidates(ctx, billDueDate, billTotalAmount, userID)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -270,27 +470,26 @@ func (s *DuplicateCheckService) CheckBillForDuplicates(
 			continue
 		}
 
-		// Check if note already contains the duplicate message
-		if dup.Notes != nil {
-			if strings.Contains(*dup.Notes, "Esta transação não será considerada") ||
-				strings.Contains(*dup.Notes, "desconsiderada") {
-				continue // Already marked
-			}
+		// Skip transactions whose considered state was already decided (user or detection)
+		if dup.HasConsideredReason() {
+			continue
 		}
 
 		// Update the duplicate transaction
 		considered := false
-		newNotes := DuplicateNote
-		if dup.Notes != nil && *dup.Notes != "" {
-			newNotes = *dup.Notes + " " + DuplicateNote
-		}
-
-		_, err := s.repo.Update(ctx, dup.ID, UpdateTransactionParams{
-			Considered: &considered,
-			Notes:      &newNotes,
+		newNotes := appendNote(dup.Notes, s.notes.BillPayment)
+		reason := ConsideredReasonBillPayment
+
+		err := s.withDBSlot(ctx, func() error {
+			_, err := s.repo.Update(ctx, dup.ID, UpdateTransactionParams{
+				Considered:       &considered,
+				Notes:            &newNotes,
+				ConsideredReason: &reason,
+			})
+			return err
 		})
 		if err != nil {
-			log.Printf("Failed to mark transaction %s as duplicate for bill: %v", dup.ID, err)
+			slog.Warn("Failed to mark transaction as duplicate for bill", "transaction_id", dup.ID, "error", err)
 			continue
 		}
 
@@ -300,6 +499,89 @@ func (s *DuplicateCheckService) CheckBillForDuplicates(
 	return found, marked, nil
 }
 
+// FindBillMatches returns the transactions CheckBillForDuplicates matches against a bill:
+// same account, same absolute amount, within the bill window of the due date.
+// Nothing is marked.
+func (s *DuplicateCheckService) FindBillMatches(
+	ctx context.Context,
+	billAccountID string,
+	billDueDate time.Time,
+	billTotalAmount float64,
+	userID int64,
+) ([]*Transaction, error) {
+	candidates, err := s.findBillCandidates(ctx, billDueDate, billTotalAmount, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*Transaction, 0, len(candidates))
+	for _, txn := range candidates {
+		if txn.AccountID == billAccountID {
+			matches = append(matches, txn)
+		}
+	}
+	return matches, nil
+}
+
+// findBillCandidates returns the user's transactions with the bill's absolute amount (any type)
+// within the bill window of its due date, on any account
+func (s *DuplicateCheckService) findBillCandidates(ctx context.Context, billDueDate time.Time, billTotalAmount float64, userID int64) ([]*Transaction, error) {
+	// Build search criteria (no ExcludeID needed - we're checking all transactions against the bill)
+	criteria := DuplicateCriteria{
+		ExcludeID:      "", // Empty string - we want to check all transactions
+		AbsoluteAmount: money.Round(math.Abs(billTotalAmount)),
+		DateLowerBound: billDueDate.Add(-s.billWindow),
+		DateUpperBound: billDueDate.Add(s.billWindow),
+		UserID:         userID,
+	}
+
+	// Find potential duplicates using bill-specific method (no type restriction)
+	var candidates []*Transaction
+	err := s.withDBSlot(ctx, func() (err error) {
+		candidates, err = s.repo.FindPotentialDuplicatesForBill(ctx, criteria)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// CheckBillPaymentCategory excludes a transaction whose provider category is configured as a
+// credit card bill payment, so payments are caught even when no matching bill was synced.
+// Manipulated transactions and ones whose considered state was already decided are left alone.
+// Returns true when the transaction was marked; txn is updated in place.
+func (s *DuplicateCheckService) CheckBillPaymentCategory(ctx context.Context, txn *Transaction) (bool, error) {
+	if txn.ProviderCategoryID == nil || txn.Manipulated || txn.HasConsideredReason() {
+		return false, nil
+	}
+	if _, ok := s.billPaymentCategories[*txn.ProviderCategoryID]; !ok {
+		return false, nil
+	}
+
+	considered := false
+	newNotes := appendNote(txn.Notes, s.notes.BillPayment)
+	reason := ConsideredReasonBillPayment
+
+	var updated *Transaction
+	err := s.withDBSlot(ctx, func() (err error) {
+		updated, err = s.repo.Update(ctx, txn.ID, UpdateTransactionParams{
+			Considered:       &considered,
+			Notes:            &newNotes,
+			ConsideredReason: &reason,
+		})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	txn.Considered = updated.Considered
+	txn.Notes = updated.Notes
+	txn.ConsideredReason = updated.ConsideredReason
+	return true, nil
+}
+
 // CheckBatchForDuplicatesConcurrent processes duplicate checking with a configurable concurrency level
 // This allows fine-tuning performance based on system resources
 func (s *DuplicateCheckService) CheckBatchForDuplicatesConcurrent(
@@ -321,52 +603,43 @@ func (s *DuplicateCheckService) CheckBatchForDuplicatesConcurrent(
 		return result
 	}
 
-	// Use semaphore pattern for bounded concurrency
-	sem := make(chan struct{}, concurrency)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, txn := range transactions {
-		wg.Add(1)
-		go func(t *Transaction) {
-			defer wg.Done()
-
-			// Acquire semaphore
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-ctx.Done():
-				mu.Lock()
-				result.Errors = append(result.Errors, ctx.Err().Error())
-				mu.Unlock()
-				return
-			}
+	s.collectResults(result, s.checkConcurrently(ctx, transactions, userID, concurrency))
 
-			found, marked, err := s.checkTransactionForDuplicates(ctx, t, userID)
+	slog.Debug("Concurrent duplicate check completed", "user_id", userID, "checked", result.TransactionsChecked,
+		"found", result.DuplicatesFound, "marked", result.DuplicatesMarked, "errors", len(result.Errors))
 
-			mu.Lock()
-			if err != nil {
-				result.Errors = append(result.Errors, err.Error())
-			}
-			result.DuplicatesFound += found
-			result.DuplicatesMarked += marked
-			mu.Unlock()
-		}(txn)
-	}
+	return result
+}
 
-	wg.Wait()
+// ProgressFunc receives the running totals of a full duplicate check
+type ProgressFunc func(processed, found, marked int)
 
-	log.Printf("Concurrent duplicate check completed: checked=%d, found=%d, marked=%d, errors=%d",
-		result.TransactionsChecked, result.DuplicatesFound, result.DuplicatesMarked, len(result.Errors))
+// CheckOption configures CheckAllUserTransactions
+type CheckOption func(*checkOptions)
 
-	return result
+type checkOptions struct {
+	progress ProgressFunc
+}
+
+// WithProgress calls fn with the running totals after each batch of CheckAllUserTransactions.
+// fn runs on the goroutine that called CheckAllUserTransactions, once per batch, so it never
+// runs concurrently with itself within a check.
+func WithProgress(fn ProgressFunc) CheckOption {
+	return func(o *checkOptions) {
+		o.progress = fn
+	}
 }
 
 // CheckAllUserTransactions fetches and checks ALL existing transactions for a user
 // This is useful for running duplicate detection on historical data
 // Transactions are processed in batches to avoid memory issues with large datasets
-func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, userID int64) (*DuplicateCheckResult, error) {
-	log.Printf("Starting full duplicate check for user %d", userID)
+func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, userID int64, opts ...CheckOption) (*DuplicateCheckResult, error) {
+	var options checkOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	slog.Info("Starting full duplicate check", "user_id", userID)
 
 	totalResult := &DuplicateCheckResult{
 		Errors: []string{},
@@ -383,7 +656,11 @@ func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, us
 		}
 
 		// Fetch a batch of transactions
-		transactions, err := s.repo.ListByUserID(ctx, userID, DefaultBatchSize, offset)
+		var transactions []*Transaction
+		err := s.withDBSlot(ctx, func() (err error) {
+			transactions, err = s.repo.ListByUserID(ctx, userID, DefaultBatchSize, offset)
+			return err
+		})
 		if err != nil {
 			return totalResult, err
 		}
@@ -393,7 +670,8 @@ func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, us
 		}
 
 		batchNum++
-		log.Printf("Processing batch %d: %d transactions (offset=%d)", batchNum, len(transactions), offset)
+		slog.Debug("Processing duplicate check batch", "user_id", userID, "batch", batchNum,
+			"transactions", len(transactions), "offset", offset)
 
 		// Process this batch concurrently
 		batchResult := s.CheckBatchForDuplicates(ctx, transactions, userID)
@@ -404,6 +682,11 @@ func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, us
 		totalResult.DuplicatesMarked += batchResult.DuplicatesMarked
 		totalResult.Errors = append(totalResult.Errors, batchResult.Errors...)
 
+		// The batch's workers are done by now, so this runs on the caller's goroutine only
+		if options.progress != nil {
+			options.progress(totalResult.TransactionsChecked, totalResult.DuplicatesFound, totalResult.DuplicatesMarked)
+		}
+
 		// Move to next batch
 		offset += len(transactions)
 
@@ -413,8 +696,8 @@ func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, us
 		}
 	}
 
-	log.Printf("Full duplicate check completed for user %d: checked=%d, found=%d, marked=%d, errors=%d",
-		userID, totalResult.TransactionsChecked, totalResult.DuplicatesFound, totalResult.DuplicatesMarked, len(totalResult.Errors))
+	slog.Info("Full duplicate check completed", "user_id", userID, "checked", totalResult.TransactionsChecked,
+		"found", totalResult.DuplicatesFound, "marked", totalResult.DuplicatesMarked, "errors", len(totalResult.Errors))
 
 	return totalResult, nil
 }
@@ -422,44 +705,76 @@ func (s *DuplicateCheckService) CheckAllUserTransactions(ctx context.Context, us
 // CheckAllUsersTransactions runs duplicate check for all provided user IDs concurrently
 // Returns a map of userID -> result
 func (s *DuplicateCheckService) CheckAllUsersTransactions(ctx context.Context, userIDs []int64) map[int64]*DuplicateCheckResult {
-	results := make(map[int64]*DuplicateCheckResult)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	// Use semaphore to limit concurrent user processing
-	sem := make(chan struct{}, s.workerCount)
-
-	for _, userID := range userIDs {
-		wg.Add(1)
-		go func(uid int64) {
-			defer wg.Done()
-
-			// Acquire semaphore
-			select {
-			case sem <- struct{}{}:
-				defer func() { <-sem }()
-			case <-ctx.Done():
-				mu.Lock()
-				results[uid] = &DuplicateCheckResult{
-					Errors: []string{ctx.Err().Error()},
-				}
-				mu.Unlock()
-				return
+	outcomes := pool.Map(ctx, userIDs, s.workerCount, func(ctx context.Context, userID int64) (*DuplicateCheckResult, error) {
+		return s.CheckAllUserTransactions(ctx, userID)
+	})
+
+	results := make(map[int64]*DuplicateCheckResult, len(userIDs))
+	for i, outcome := range outcomes {
+		result := outcome.Value
+		if outcome.Err != nil {
+			result = &DuplicateCheckResult{
+				Errors: []string{outcome.Err.Error()},
 			}
+		}
+		results[userIDs[i]] = result
+	}
+	return results
+}
 
-			result, err := s.CheckAllUserTransactions(ctx, uid)
-			if err != nil {
-				result = &DuplicateCheckResult{
-					Errors: []string{err.Error()},
+// DuplicateGroup is a transaction and the transactions the duplicate check matches against it
+type DuplicateGroup struct {
+	Source     *Transaction
+	Duplicates []*Transaction
+}
+
+// PreviewAllUserDuplicates runs the duplicate search over all of a user's transactions without
+// marking anything. Matches are symmetric, so each pair is reported once, under whichever of the
+// two transactions comes first; groups are in the order ListByUserID returns their sources.
+func (s *DuplicateCheckService) PreviewAllUserDuplicates(ctx context.Context, userID int64) ([]DuplicateGroup, error) {
+	var groups []DuplicateGroup
+	seen := make(map[[2]string]bool)
+
+	for offset := 0; ; {
+		var transactions []*Transaction
+		err := s.withDBSlot(ctx, func() (err error) {
+			transactions, err = s.repo.ListByUserID(ctx, userID, DefaultBatchSize, offset)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(transactions) == 0 {
+			break
+		}
+
+		outcomes := pool.Map(ctx, transactions, s.workerCount, func(ctx context.Context, txn *Transaction) ([]*Transaction, error) {
+			return s.FindDuplicateCandidates(ctx, txn, userID)
+		})
+		for i, outcome := range outcomes {
+			if outcome.Err != nil {
+				return nil, outcome.Err
+			}
+			source := transactions[i]
+			group := DuplicateGroup{Source: source}
+			for _, dup := range outcome.Value {
+				pair := [2]string{min(source.ID, dup.ID), max(source.ID, dup.ID)}
+				if seen[pair] {
+					continue
 				}
+				seen[pair] = true
+				group.Duplicates = append(group.Duplicates, dup)
+			}
+			if len(group.Duplicates) > 0 {
+				groups = append(groups, group)
 			}
+		}
 
-			mu.Lock()
-			results[uid] = result
-			mu.Unlock()
-		}(userID)
+		offset += len(transactions)
+		if len(transactions) < DefaultBatchSize {
+			break
+		}
 	}
 
-	wg.Wait()
-	return results
+	return groups, nil
 }
@@ -3,6 +3,9 @@ package transaction
 import (
 	"context"
 	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -20,6 +23,9 @@ type MockTransactionRepo struct {
 	FindPotentialDuplicatesForBillFunc func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error)
 	SetTransactionTagsFunc             func(ctx context.Context, transactionID string, tagIDs []string) error
 	GetTransactionTagsFunc             func(ctx context.Context, transactionID string) ([]string, error)
+	ReleaseDetectionFunc               func(ctx context.Context, id string, reason string, notes *string) (bool, error)
+	SetCousinFunc                      func(ctx context.Context, transactionID string, cousinID int64) error
+	SetCousinWithoutRulesFunc          func(ctx context.Context, transactionID string, cousinID int64) error
 }
 
 func (m *MockTransactionRepo) Create(ctx context.Context, params CreateTransactionParams) (*Transaction, error) {
@@ -52,6 +58,51 @@ func (m *MockTransactionRepo) CountByUserID(ctx context.Context, userID int64) (
 	}
 	return 0, nil
 }
+func (m *MockTransactionRepo) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ListByUserIDFiltered(ctx context.Context, userID int64, filter ListFilter, limit, offset int) ([]*Transaction, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) Recategorize(ctx context.Context, userID int64, from, to string, window DateWindow) ([]string, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	if m.ReleaseDetectionFunc != nil {
+		return m.ReleaseDetectionFunc(ctx, id, reason, notes)
+	}
+	return false, nil
+}
+func (m *MockTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	if m.SetCousinFunc != nil {
+		return m.SetCousinFunc(ctx, transactionID, cousinID)
+	}
+	return nil
+}
+func (m *MockTransactionRepo) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	if m.SetCousinWithoutRulesFunc != nil {
+		return m.SetCousinWithoutRulesFunc(ctx, transactionID, cousinID)
+	}
+	return nil
+}
+func (m *MockTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	return nil
+}
+func (m *MockTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter ListFilter) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*Transaction, error) {
+	return nil, nil
+}
+func (m *MockTransactionRepo) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return 0, nil
+}
 func (m *MockTransactionRepo) Update(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(ctx, id, params)
@@ -98,6 +149,33 @@ func (m *MockTransactionRepo) GetTransactionTags(ctx context.Context, transactio
 	return nil, nil
 }
 
+func (m *MockTransactionRepo) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	return nil
+}
+
+func (m *MockTransactionRepo) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]MonthlyTotal, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) CountGroups(ctx context.Context, userID int64, window DateWindow) ([]CountGroup, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]DescriptionSuggestion, error) {
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window DateWindow) (int64, error) {
+	return 0, nil
+}
+func (m *MockTransactionRepo) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window DateWindow) (int64, error) {
+	return 0, nil
+}
+
 func TestNewDuplicateCheckService(t *testing.T) {
 	repo := &MockTransactionRepo{}
 	svc := NewDuplicateCheckService(repo)
@@ -218,11 +296,11 @@ func TestCheckTransactionForDuplicates_FindsDuplicate(t *testing.T) {
 }
 
 func TestCheckTransactionForDuplicates_AlreadyMarkedSkipped(t *testing.T) {
-	existingNote := "Esta transação não será considerada no cálculo"
+	reason := ConsideredReasonDuplicate
 	repo := &MockTransactionRepo{
 		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
 			return []*Transaction{
-				{ID: "tx-dup", Amount: 100.0, Type: "CREDIT", Notes: &existingNote},
+				{ID: "tx-dup", Amount: 100.0, Type: "CREDIT", ConsideredReason: &reason},
 			}, nil
 		},
 	}
@@ -247,6 +325,147 @@ func TestCheckTransactionForDuplicates_AlreadyMarkedSkipped(t *testing.T) {
 	}
 }
 
+func TestCheckTransactionForDuplicates_BillPaymentNotRemarked(t *testing.T) {
+	reason := ConsideredReasonBillPayment
+	notes := "Any note text, in any language"
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			return []*Transaction{
+				{ID: "tx-dup", Amount: 100.0, Type: "CREDIT", Notes: &notes, ConsideredReason: &reason},
+			}, nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+			t.Error("Update should not be called for an already excluded transaction")
+			return nil, nil
+		},
+	}
+	svc := NewDuplicateCheckService(repo)
+
+	txn := &Transaction{ID: "tx-1", Amount: 100.0, Type: "DEBIT", TransactionDate: time.Now()}
+
+	_, marked, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if marked != 0 {
+		t.Errorf("marked = %d, want 0 (already excluded)", marked)
+	}
+}
+
+func TestCheckTransactionForDuplicates_UserDecisionKept(t *testing.T) {
+	reason := ConsideredReasonUser
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			return []*Transaction{
+				{ID: "tx-dup", Amount: 100.0, Type: "CREDIT", Considered: true, ConsideredReason: &reason},
+			}, nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+			t.Error("Update should not be called for a transaction the user decided on")
+			return nil, nil
+		},
+	}
+	svc := NewDuplicateCheckService(repo)
+
+	txn := &Transaction{ID: "tx-1", Amount: 100.0, Type: "DEBIT", TransactionDate: time.Now()}
+
+	_, marked, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if marked != 0 {
+		t.Errorf("marked = %d, want 0 (user decision)", marked)
+	}
+}
+
+func TestRemoveDetectionNotes(t *testing.T) {
+	tests := []struct {
+		name        string
+		notes       string
+		want        string
+		wantChanged bool
+	}{
+		{name: "only duplicate note", notes: DuplicateNote, want: "", wantChanged: true},
+		{name: "user note kept", notes: "paid by Ana " + BillPaymentNote, want: "paid by Ana", wantChanged: true},
+		{name: "both notes", notes: "a\nb " + DuplicateNote + " " + BillPaymentNote, want: "a\nb", wantChanged: true},
+		{name: "no detection note", notes: "  my own note ", want: "  my own note ", wantChanged: false},
+	}
+
+	svc := NewDuplicateCheckService(&MockTransactionRepo{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := svc.RemoveDetectionNotes(tt.notes)
+			if got != tt.want || changed != tt.wantChanged {
+				t.Errorf("RemoveDetectionNotes(%q) = %q, %v, want %q, %v", tt.notes, got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestCheckForDuplicates_WritesNotesAndReason(t *testing.T) {
+	existing := "user note"
+	tests := []struct {
+		name       string
+		check      func(svc *DuplicateCheckService) error
+		want       string
+		wantReason string
+	}{
+		{
+			name: "transaction duplicate",
+			check: func(svc *DuplicateCheckService) error {
+				txn := &Transaction{ID: "tx-1", Amount: 100.0, Type: "DEBIT", TransactionDate: time.Now()}
+				_, _, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1)
+				return err
+			},
+			want:       "user note custom duplicate",
+			wantReason: ConsideredReasonDuplicate,
+		},
+		{
+			name: "bill payment",
+			check: func(svc *DuplicateCheckService) error {
+				_, _, err := svc.CheckBillForDuplicates(context.Background(), "acc-1", time.Now(), 100.0, 1)
+				return err
+			},
+			want:       "user note custom bill",
+			wantReason: ConsideredReasonBillPayment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written, writtenReason string
+			dup := func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+				return []*Transaction{
+					{ID: "tx-dup", AccountID: "acc-1", Amount: 100.0, Type: "CREDIT", Notes: &existing},
+				}, nil
+			}
+			repo := &MockTransactionRepo{
+				FindPotentialDuplicatesFunc:        dup,
+				FindPotentialDuplicatesForBillFunc: dup,
+				UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+					written = *params.Notes
+					if params.ConsideredReason != nil {
+						writtenReason = *params.ConsideredReason
+					}
+					return &Transaction{ID: id}, nil
+				},
+			}
+			svc := NewDuplicateCheckService(repo)
+			svc.notes = Notes{Duplicate: "custom duplicate", BillPayment: "custom bill"}
+
+			if err := tt.check(svc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if written != tt.want {
+				t.Errorf("notes = %q, want %q", written, tt.want)
+			}
+			if writtenReason != tt.wantReason {
+				t.Errorf("auto excluded reason = %q, want %q", writtenReason, tt.wantReason)
+			}
+		})
+	}
+}
+
 func TestCheckTransactionForDuplicates_RepoError(t *testing.T) {
 	repo := &MockTransactionRepo{
 		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
@@ -292,6 +511,188 @@ func TestCheckTransactionForDuplicates_CreditTransaction(t *testing.T) {
 	}
 }
 
+func TestCheckTransactionForDuplicates_SameAccountOnly(t *testing.T) {
+	for _, sameAccountOnly := range []bool{false, true} {
+		repo := &MockTransactionRepo{
+			FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+				if criteria.SameAccountOnly != sameAccountOnly {
+					t.Errorf("SameAccountOnly = %v, want %v", criteria.SameAccountOnly, sameAccountOnly)
+				}
+				if criteria.AccountID != "acc-1" {
+					t.Errorf("AccountID = %q, want acc-1", criteria.AccountID)
+				}
+				return []*Transaction{}, nil
+			},
+		}
+		svc := NewDuplicateCheckService(repo)
+		svc.SetSameAccountOnly(sameAccountOnly)
+
+		txn := &Transaction{
+			ID:              "tx-1",
+			AccountID:       "acc-1",
+			Amount:          -50.0,
+			Type:            "DEBIT",
+			TransactionDate: time.Now(),
+		}
+
+		if _, _, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestCheckTransactionForDuplicates_AmountRoundedToCents(t *testing.T) {
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			if criteria.AbsoluteAmount != 10.30 {
+				t.Errorf("AbsoluteAmount = %v, want 10.30", criteria.AbsoluteAmount)
+			}
+			return []*Transaction{}, nil
+		},
+	}
+	svc := NewDuplicateCheckService(repo)
+
+	a, b := 10.10, 0.20
+	txn := &Transaction{
+		ID:              "tx-1",
+		Amount:          -(a + b), // 10.299999999999999 as float64
+		Type:            "DEBIT",
+		TransactionDate: time.Now(),
+	}
+
+	if _, _, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecheckAfterEdit(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	duplicate := ConsideredReasonDuplicate
+	user := ConsideredReasonUser
+	note := DuplicateNote
+	txn := func(id, typ string, amount float64, reason *string) *Transaction {
+		return &Transaction{ID: id, Type: typ, Amount: amount, TransactionDate: date, ConsideredReason: reason}
+	}
+
+	before := txn("tx-edited", "DEBIT", -50, nil)
+	after := txn("tx-edited", "DEBIT", -70, nil)
+	onlyMatch := txn("tx-only-match", "CREDIT", 50, &duplicate)
+	onlyMatch.Notes = &note
+	userExcluded := txn("tx-user", "CREDIT", 50, &user)
+	otherMatch := txn("tx-other-match", "CREDIT", 50, &duplicate)
+	newMatch := txn("tx-new-match", "CREDIT", 70, nil)
+
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			switch {
+			case criteria.ExcludeID == "tx-edited" && criteria.AbsoluteAmount == 50:
+				return []*Transaction{onlyMatch, userExcluded, otherMatch}, nil
+			case criteria.ExcludeID == "tx-edited" && criteria.AbsoluteAmount == 70:
+				return []*Transaction{newMatch}, nil
+			case criteria.ExcludeID == "tx-other-match":
+				// Still matched by another debit of 50
+				return []*Transaction{txn("tx-other-debit", "DEBIT", -50, nil)}, nil
+			}
+			return []*Transaction{}, nil
+		},
+	}
+	released := map[string]*string{}
+	repo.ReleaseDetectionFunc = func(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+		if reason != ConsideredReasonDuplicate {
+			t.Errorf("released %s with reason %q, want DUPLICATE", id, reason)
+		}
+		released[id] = notes
+		return true, nil
+	}
+	var marked []string
+	repo.UpdateFunc = func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+		marked = append(marked, id)
+		return &Transaction{ID: id}, nil
+	}
+
+	result := NewDuplicateCheckService(repo).RecheckAfterEdit(context.Background(), before, after, 1)
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", result.Errors)
+	}
+	if len(released) != 1 || result.DuplicatesReleased != 1 {
+		t.Fatalf("released = %v (%d), want only tx-only-match", released, result.DuplicatesReleased)
+	}
+	notes, ok := released["tx-only-match"]
+	if !ok {
+		t.Fatalf("tx-only-match was not released: %v", released)
+	}
+	if notes == nil || *notes != "" {
+		t.Errorf("released notes = %v, want the duplicate note removed", notes)
+	}
+	if len(marked) != 1 || marked[0] != "tx-new-match" {
+		t.Errorf("marked = %v, want [tx-new-match]", marked)
+	}
+	if result.DuplicatesFound != 1 || result.DuplicatesMarked != 1 {
+		t.Errorf("found %d, marked %d, want 1 and 1", result.DuplicatesFound, result.DuplicatesMarked)
+	}
+}
+
+func TestRecheckAfterEdit_ReleasesEditedTransaction(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	duplicate := ConsideredReasonDuplicate
+	before := &Transaction{ID: "tx-1", Type: "DEBIT", Amount: -50, TransactionDate: date, ConsideredReason: &duplicate}
+	after := &Transaction{ID: "tx-1", Type: "DEBIT", Amount: -70, TransactionDate: date, ConsideredReason: &duplicate}
+
+	var releasedIDs []string
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			return []*Transaction{}, nil
+		},
+		ReleaseDetectionFunc: func(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+			releasedIDs = append(releasedIDs, id)
+			return true, nil
+		},
+	}
+
+	result := NewDuplicateCheckService(repo).RecheckAfterEdit(context.Background(), before, after, 1)
+
+	if len(releasedIDs) != 1 || releasedIDs[0] != "tx-1" || result.DuplicatesReleased != 1 {
+		t.Errorf("released = %v (%d), want [tx-1]", releasedIDs, result.DuplicatesReleased)
+	}
+	if len(result.Releases) != 1 {
+		t.Fatalf("Releases = %v, want one entry for the audit log", result.Releases)
+	}
+	rel := result.Releases[0]
+	if rel.Before != after || !rel.After.Considered || rel.After.ConsideredReason != nil {
+		t.Errorf("release = %+v -> %+v, want after re-included without a reason", rel.Before, rel.After)
+	}
+}
+
+func TestDuplicateFieldsChanged(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	base := Transaction{Type: "DEBIT", Amount: -50, TransactionDate: date}
+
+	tests := []struct {
+		name   string
+		modify func(txn *Transaction)
+		want   bool
+	}{
+		{"unchanged", func(txn *Transaction) {}, false},
+		{"sign only", func(txn *Transaction) { txn.Amount = 50 }, false},
+		{"description only", func(txn *Transaction) { txn.Description = "Lunch" }, false},
+		{"amount", func(txn *Transaction) { txn.Amount = -50.01 }, true},
+		{"sub-cent drift only", func(txn *Transaction) { txn.Amount = -50.000000001 }, false},
+		{"date", func(txn *Transaction) { txn.TransactionDate = date.Add(time.Hour) }, true},
+		{"type", func(txn *Transaction) { txn.Type = "CREDIT" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			tt.modify(&after)
+			if got := DuplicateFieldsChanged(&base, &after); got != tt.want {
+				t.Errorf("DuplicateFieldsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCheckBatchForDuplicates_WithTransactions(t *testing.T) {
 	repo := &MockTransactionRepo{
 		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
@@ -315,3 +716,501 @@ func TestCheckBatchForDuplicates_WithTransactions(t *testing.T) {
 		t.Errorf("Errors = %v, want empty", result.Errors)
 	}
 }
+
+func TestCheckBillForDuplicates_BillWindow(t *testing.T) {
+	due := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	// Paid six days after the due date: outside the default 5-day window
+	late := &Transaction{ID: "tx-late", AccountID: "acc-1", Amount: 500, Type: "CREDIT", TransactionDate: due.Add(6 * 24 * time.Hour)}
+
+	tests := []struct {
+		name       string
+		window     time.Duration
+		wantMarked int
+	}{
+		{name: "default window", window: 0, wantMarked: 0},
+		{name: "widened window", window: 7 * 24 * time.Hour, wantMarked: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockTransactionRepo{
+				FindPotentialDuplicatesForBillFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+					// Apply the date bounds like the SQL query does
+					if late.TransactionDate.Before(criteria.DateLowerBound) || late.TransactionDate.After(criteria.DateUpperBound) {
+						return nil, nil
+					}
+					return []*Transaction{late}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+					return &Transaction{ID: id}, nil
+				},
+			}
+
+			svc := NewDuplicateCheckService(repo)
+			svc.SetBillWindow(tt.window)
+
+			_, marked, err := svc.CheckBillForDuplicates(context.Background(), "acc-1", due, 500, 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if marked != tt.wantMarked {
+				t.Errorf("marked = %d, want %d", marked, tt.wantMarked)
+			}
+		})
+	}
+
+	svc := NewDuplicateCheckService(&MockTransactionRepo{})
+	if svc.BillWindow() != BillDuplicateTimeDelta {
+		t.Errorf("default BillWindow() = %v, want %v", svc.BillWindow(), BillDuplicateTimeDelta)
+	}
+}
+
+func TestCheckBillPaymentCategory(t *testing.T) {
+	billCategory := "05100000"
+	otherCategory := "01000000"
+	userReason := ConsideredReasonUser
+
+	tests := []struct {
+		name       string
+		txn        *Transaction
+		wantMarked bool
+	}{
+		{
+			name:       "bill payment category is excluded",
+			txn:        &Transaction{ID: "tx-1", ProviderCategoryID: &billCategory, Considered: true},
+			wantMarked: true,
+		},
+		{
+			name: "other category is kept",
+			txn:  &Transaction{ID: "tx-2", ProviderCategoryID: &otherCategory, Considered: true},
+		},
+		{
+			name: "no category is kept",
+			txn:  &Transaction{ID: "tx-3", Considered: true},
+		},
+		{
+			name: "manipulated transaction is left alone",
+			txn:  &Transaction{ID: "tx-4", ProviderCategoryID: &billCategory, Considered: true, Manipulated: true},
+		},
+		{
+			name: "decided transaction is left alone",
+			txn:  &Transaction{ID: "tx-5", ProviderCategoryID: &billCategory, Considered: true, ConsideredReason: &userReason},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var params *UpdateTransactionParams
+			repo := &MockTransactionRepo{
+				UpdateFunc: func(ctx context.Context, id string, p UpdateTransactionParams) (*Transaction, error) {
+					params = &p
+					return &Transaction{ID: id, Considered: *p.Considered, Notes: p.Notes, ConsideredReason: p.ConsideredReason}, nil
+				},
+			}
+			svc := NewDuplicateCheckService(repo)
+			svc.notes = Notes{BillPayment: "custom bill"}
+
+			marked, err := svc.CheckBillPaymentCategory(context.Background(), tt.txn)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if marked != tt.wantMarked {
+				t.Fatalf("marked = %v, want %v", marked, tt.wantMarked)
+			}
+			if !tt.wantMarked {
+				if params != nil {
+					t.Errorf("Update called for a transaction that should be kept")
+				}
+				return
+			}
+			if *params.Considered || *params.ConsideredReason != ConsideredReasonBillPayment || *params.Notes != "custom bill" {
+				t.Errorf("update = (%v, %q, %q), want excluded as %s with the bill note",
+					*params.Considered, *params.ConsideredReason, *params.Notes, ConsideredReasonBillPayment)
+			}
+			if tt.txn.Considered || !tt.txn.HasConsideredReason() {
+				t.Errorf("transaction not updated in place: considered=%v reason=%v", tt.txn.Considered, tt.txn.ConsideredReason)
+			}
+		})
+	}
+}
+
+func TestSetNotes(t *testing.T) {
+	svc := NewDuplicateCheckService(&MockTransactionRepo{})
+	svc.SetNotes(Notes{Duplicate: "duplicate"})
+
+	if svc.notes.Duplicate != "duplicate" || svc.notes.BillPayment != BillPaymentNote {
+		t.Errorf("notes = %+v, want the duplicate override and the built-in bill payment note", svc.notes)
+	}
+	if got, changed := svc.RemoveDetectionNotes("mine duplicate"); !changed || got != "mine" {
+		t.Errorf("RemoveDetectionNotes() = %q, %v, want the configured note removed", got, changed)
+	}
+}
+
+func TestSetBillPaymentCategories(t *testing.T) {
+	category := "05100000"
+	svc := NewDuplicateCheckService(&MockTransactionRepo{})
+	svc.SetBillPaymentCategories(nil)
+	marked, err := svc.CheckBillPaymentCategory(context.Background(), &Transaction{ID: "tx-1", ProviderCategoryID: &category})
+	if err != nil || marked {
+		t.Errorf("CheckBillPaymentCategory() = %v, %v with no categories configured, want false, nil", marked, err)
+	}
+}
+
+func TestSetMaxConcurrentDBOps(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return nil, nil
+		},
+	}
+
+	transactions := make([]*Transaction, 8)
+	for i := range transactions {
+		transactions[i] = &Transaction{ID: "tx", Type: "DEBIT", TransactionDate: time.Now()}
+	}
+
+	// Two concurrent batches of 4 workers each still share the service's limit
+	svc := NewDuplicateCheckServiceWithWorkers(repo, 4)
+	svc.SetMaxConcurrentDBOps(2)
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.CheckBatchForDuplicates(context.Background(), transactions, 1)
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrent repository calls = %d, want at most 2", got)
+	}
+}
+
+func TestCheckAllUsersTransactions(t *testing.T) {
+	repo := &MockTransactionRepo{
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*Transaction, error) {
+			if userID == 2 {
+				return nil, errors.New("db down")
+			}
+			return nil, nil
+		},
+	}
+	svc := NewDuplicateCheckServiceWithWorkers(repo, 2)
+
+	results := svc.CheckAllUsersTransactions(context.Background(), []int64{1, 2, 3})
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+	if len(results[1].Errors) != 0 || len(results[3].Errors) != 0 {
+		t.Errorf("unexpected errors: user 1 = %v, user 3 = %v", results[1].Errors, results[3].Errors)
+	}
+	if len(results[2].Errors) != 1 {
+		t.Errorf("user 2 errors = %v, want the repository error", results[2].Errors)
+	}
+
+	// A cancelled context still yields a result for every user
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = svc.CheckAllUsersTransactions(ctx, []int64{1, 2, 3})
+	for _, uid := range []int64{1, 2, 3} {
+		if results[uid] == nil || len(results[uid].Errors) != 1 {
+			t.Errorf("user %d result = %+v, want a cancellation error", uid, results[uid])
+		}
+	}
+}
+
+func TestCheckAllUserTransactions_Progress(t *testing.T) {
+	batch := make([]*Transaction, DefaultBatchSize)
+	for i := range batch {
+		batch[i] = &Transaction{ID: "tx", Type: "DEBIT", Considered: true}
+	}
+	repo := &MockTransactionRepo{
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*Transaction, error) {
+			switch offset {
+			case 0:
+				return batch, nil
+			case DefaultBatchSize:
+				return batch[:10], nil
+			}
+			return nil, nil
+		},
+	}
+	svc := NewDuplicateCheckServiceWithWorkers(repo, 4)
+
+	var processed []int
+	result, err := svc.CheckAllUserTransactions(context.Background(), 1, WithProgress(func(p, found, marked int) {
+		processed = append(processed, p)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// One call per batch with running totals
+	if len(processed) != 2 || processed[0] != DefaultBatchSize || processed[1] != DefaultBatchSize+10 {
+		t.Errorf("progress = %v, want [%d %d]", processed, DefaultBatchSize, DefaultBatchSize+10)
+	}
+	if result.TransactionsChecked != DefaultBatchSize+10 {
+		t.Errorf("checked = %d, want %d", result.TransactionsChecked, DefaultBatchSize+10)
+	}
+}
+
+func TestPreviewAllUserDuplicates(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	debit := &Transaction{ID: "tx-debit", Type: "DEBIT", Amount: -50, TransactionDate: date}
+	credit := &Transaction{ID: "tx-credit", Type: "CREDIT", Amount: 50, TransactionDate: date}
+	other := &Transaction{ID: "tx-other", Type: "DEBIT", Amount: -20, TransactionDate: date}
+
+	repo := &MockTransactionRepo{
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*Transaction, error) {
+			if offset > 0 {
+				return []*Transaction{}, nil
+			}
+			return []*Transaction{debit, credit, other}, nil
+		},
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			switch criteria.ExcludeID {
+			case "tx-debit":
+				return []*Transaction{credit}, nil
+			case "tx-credit":
+				return []*Transaction{debit}, nil
+			}
+			return []*Transaction{}, nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+			t.Errorf("preview updated transaction %s", id)
+			return nil, nil
+		},
+	}
+
+	groups, err := NewDuplicateCheckService(repo).PreviewAllUserDuplicates(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected the mirrored pair to be reported once, got %d groups", len(groups))
+	}
+	if groups[0].Source.ID != "tx-debit" || len(groups[0].Duplicates) != 1 || groups[0].Duplicates[0].ID != "tx-credit" {
+		t.Errorf("unexpected group: source %s, duplicates %v", groups[0].Source.ID, groups[0].Duplicates)
+	}
+}
+
+func TestCheckTransactionForDuplicates_LinksCousins(t *testing.T) {
+	cousinID := func(id int64) *int64 { return &id }
+	duplicate := ConsideredReasonDuplicate
+
+	tests := []struct {
+		name        string
+		txnCousin   *int64
+		dupCousin   *int64
+		dupReason   *string
+		wantCreated int
+		wantSet     map[string]int64
+	}{
+		{
+			name:        "new pair gets a new cousin",
+			wantCreated: 1,
+			wantSet:     map[string]int64{"tx-debit": 100, "tx-credit": 100},
+		},
+		{
+			name:      "reuses the duplicate's cousin",
+			dupCousin: cousinID(7),
+			wantSet:   map[string]int64{"tx-debit": 7},
+		},
+		{
+			name:      "pair marked by an earlier run is grouped",
+			txnCousin: cousinID(7),
+			dupReason: &duplicate,
+			wantSet:   map[string]int64{"tx-credit": 7},
+		},
+		{
+			name:      "already grouped pair is left alone",
+			txnCousin: cousinID(7),
+			dupCousin: cousinID(8),
+			wantSet:   map[string]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			txn := &Transaction{ID: "tx-debit", Type: "DEBIT", Amount: -50, TransactionDate: date, Description: "PIX Maria", Cousin: tt.txnCousin}
+			document := int64(55)
+			dup := &Transaction{ID: "tx-credit", Type: "CREDIT", Amount: 50, TransactionDate: date, Cousin: tt.dupCousin, ConsideredReason: tt.dupReason, DocumentID: &document}
+
+			created := 0
+			resolve := func(ctx context.Context, userID int64, name string, documentID *int64) (int64, error) {
+				created++
+				if userID != 1 || name != "PIX Maria" || documentID == nil || *documentID != document {
+					t.Errorf("resolved cousin %q with document %v for user %d", name, documentID, userID)
+				}
+				return 100, nil
+			}
+
+			set := map[string]int64{}
+			repo := &MockTransactionRepo{
+				FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+					return []*Transaction{dup}, nil
+				},
+				GetByIDFunc: func(ctx context.Context, id string) (*Transaction, error) {
+					if id == txn.ID {
+						return txn, nil
+					}
+					return dup, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+					return &Transaction{ID: id}, nil
+				},
+				SetCousinFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+					t.Errorf("SetCousin(%s) would apply the cousin's rules to the pair", transactionID)
+					return nil
+				},
+				SetCousinWithoutRulesFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+					set[transactionID] = cousinID
+					return nil
+				},
+			}
+
+			svc := NewDuplicateCheckService(repo)
+			svc.SetCousinResolver(resolve)
+			if _, _, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created %d cousins, want %d", created, tt.wantCreated)
+			}
+			if len(set) != len(tt.wantSet) {
+				t.Fatalf("set cousins %v, want %v", set, tt.wantSet)
+			}
+			for id, want := range tt.wantSet {
+				if set[id] != want {
+					t.Errorf("cousin of %s = %d, want %d", id, set[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTransactionForDuplicates_NoCousinsByDefault(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			return []*Transaction{{ID: "tx-credit", Type: "CREDIT", Amount: 50, TransactionDate: date}}, nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+			return &Transaction{ID: id}, nil
+		},
+		SetCousinWithoutRulesFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+			t.Errorf("set cousin of %s without a cousin resolver", transactionID)
+			return nil
+		},
+	}
+
+	txn := &Transaction{ID: "tx-debit", Type: "DEBIT", Amount: -50, TransactionDate: date}
+	if _, marked, err := NewDuplicateCheckService(repo).CheckTransactionForDuplicates(context.Background(), txn, 1); err != nil || marked != 1 {
+		t.Fatalf("marked %d, err %v", marked, err)
+	}
+}
+
+func TestCheckTransactionForDuplicates_LinkedDuplicateStaysExcluded(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	txn := &Transaction{ID: "tx-debit", Type: "DEBIT", Amount: -50, TransactionDate: date, Description: "PIX Maria", Considered: true}
+	dup := &Transaction{ID: "tx-credit", Type: "CREDIT", Amount: 50, TransactionDate: date, Considered: true}
+	stored := map[string]*Transaction{txn.ID: txn, dup.ID: dup}
+
+	userReason := ConsideredReasonUser
+	repo := &MockTransactionRepo{
+		FindPotentialDuplicatesFunc: func(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error) {
+			return []*Transaction{dup}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, id string) (*Transaction, error) {
+			return stored[id], nil
+		},
+		UpdateFunc: func(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error) {
+			stored[id].Considered = *params.Considered
+			stored[id].ConsideredReason = params.ConsideredReason
+			return stored[id], nil
+		},
+		// The cousin_assigned listener applying a reused cousin's considered=true rule
+		SetCousinFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+			stored[transactionID].Cousin = &cousinID
+			stored[transactionID].Considered = true
+			stored[transactionID].ConsideredReason = &userReason
+			return nil
+		},
+		SetCousinWithoutRulesFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+			stored[transactionID].Cousin = &cousinID
+			return nil
+		},
+	}
+
+	svc := NewDuplicateCheckService(repo)
+	svc.SetCousinResolver(func(ctx context.Context, userID int64, name string, documentID *int64) (int64, error) {
+		return 7, nil
+	})
+	if _, marked, err := svc.CheckTransactionForDuplicates(context.Background(), txn, 1); err != nil || marked != 1 {
+		t.Fatalf("marked %d, err %v", marked, err)
+	}
+
+	if dup.Cousin == nil || *dup.Cousin != 7 {
+		t.Errorf("duplicate cousin = %v, want 7", dup.Cousin)
+	}
+	if dup.Considered || dup.ConsideredReason == nil || *dup.ConsideredReason != ConsideredReasonDuplicate {
+		t.Errorf("duplicate considered = %v, reason %v; want excluded as DUPLICATE", dup.Considered, dup.ConsideredReason)
+	}
+}
+
+func TestPairCousinKey(t *testing.T) {
+	document := int64(55)
+	debit := &Transaction{ID: "tx-debit", Description: "PIX Maria"}
+	credit := &Transaction{ID: "tx-credit", DocumentID: &document}
+
+	for _, pair := range [][2]*Transaction{{debit, credit}, {credit, debit}} {
+		name, documentID := pairCousinKey(pair[0], pair[1])
+		if name != "PIX Maria" || documentID == nil || *documentID != document {
+			t.Errorf("pairCousinKey(%s, %s) = %q, %v; want both sides to resolve PIX Maria and document 55",
+				pair[0].ID, pair[1].ID, name, documentID)
+		}
+	}
+}
+
+func TestLoadNotes(t *testing.T) {
+	notes, err := LoadNotes("", "", "Paid bill")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if notes.Duplicate != DuplicateNote || notes.BillPayment != "Paid bill" {
+		t.Errorf("LoadNotes() = %+v, want the pt-BR duplicate note and the bill payment override", notes)
+	}
+
+	if _, err := LoadNotes("xx", "", ""); err == nil {
+		t.Error("LoadNotes() with an unknown locale succeeded, want an error")
+	}
+}
+
+func TestDuplicateCousinName(t *testing.T) {
+	long := strings.Repeat("é", 200) // 400 bytes
+	tests := []struct {
+		txn  *Transaction
+		want string
+	}{
+		{txn: &Transaction{ID: "tx-1", Description: "  PIX Maria "}, want: "PIX Maria"},
+		{txn: &Transaction{ID: "tx-1"}, want: "Transaction tx-1"},
+		{txn: &Transaction{ID: "tx-1", Description: long}, want: strings.Repeat("é", 127)},
+	}
+
+	for _, tt := range tests {
+		if got := duplicateCousinName(tt.txn); got != tt.want {
+			t.Errorf("duplicateCousinName(%q) = %q, want %q", tt.txn.Description, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package transaction
 
 import (
+	"errors"
 	"time"
 )
 
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrCousinNotFound      = errors.New("cousin not found")
+)
+
 type Transaction struct {
 	ID                  string    `json:"id"` // Provider's transaction id (UUID string)
 	AccountID           string    `json:"accountId"`
@@ -27,6 +33,57 @@ type Transaction struct {
 	Cousin              *int64    `json:"cousin,omitempty"`
 	MerchantID          *int64    `json:"merchantId,omitempty"`
 	DocumentID          *int64    `json:"documentId,omitempty"`
+	ConsideredReason    *string   `json:"consideredReason,omitempty"` // Why considered was set (see ConsideredReason* constants)
+	TransferGroup       *string   `json:"transferGroup,omitempty"`    // Shared by the two sides of a transfer the user marked
+
+	// Credit card installment plan from the provider; loaded by GetByID only
+	Installment *Installment `json:"installment,omitempty"`
+	// Set when the provider stopped returning the transaction; only delta listings return such rows
+	RemovedAt *time.Time `json:"removedAt,omitempty"`
+}
+
+// Installment places a credit card transaction within its installment plan
+type Installment struct {
+	Number       int       `json:"number"` // 1-based
+	Total        int       `json:"total"`
+	PurchaseDate time.Time `json:"purchaseDate"`
+}
+
+// Remaining returns the number of installments still due after this one
+func (i Installment) Remaining() int {
+	if i.Number >= i.Total {
+		return 0
+	}
+	return i.Total - i.Number
+}
+
+// OrphanedCousinRef is a transaction whose cousin references a cousin that no longer exists
+type OrphanedCousinRef struct {
+	TransactionID string
+	CousinID      int64
+}
+
+// Reasons recorded in considered_reason
+const (
+	ConsideredReasonDuplicate   = "DUPLICATE"    // Duplicate check matched an opposite transaction
+	ConsideredReasonBillPayment = "BILL_PAYMENT" // Matched a credit card bill payment
+	ConsideredReasonUser        = "USER"         // User toggled considered directly or through a cousin rule
+	ConsideredReasonTransfer    = "TRANSFER"     // Transfer between the user's own accounts
+)
+
+// IsValidConsideredReason reports whether reason is one of the ConsideredReason* codes
+func IsValidConsideredReason(reason string) bool {
+	switch reason {
+	case ConsideredReasonDuplicate, ConsideredReasonBillPayment, ConsideredReasonUser, ConsideredReasonTransfer:
+		return true
+	}
+	return false
+}
+
+// HasConsideredReason reports whether considered was already decided by the user or a
+// detection service, in which case detection services must not change it again
+func (t *Transaction) HasConsideredReason() bool {
+	return t.ConsideredReason != nil && *t.ConsideredReason != ""
 }
 
 type CreateTransactionParams struct {
@@ -41,15 +98,17 @@ type CreateTransactionParams struct {
 }
 
 type UpdateTransactionParams struct {
-	Amount          *float64
-	Description     *string
-	Category        *string
-	TransactionDate *time.Time
-	Type            *string
-	Status          *string
-	Considered      *bool
-	Notes           *string
-	Tags            *[]string // nil = don't update, empty slice = clear all tags
+	Amount           *float64
+	Description      *string
+	Category         *string
+	TransactionDate  *time.Time
+	Type             *string
+	Status           *string
+	Considered       *bool
+	Notes            *string
+	Tags             *[]string // nil = don't update, empty slice = clear all tags
+	ConsideredReason *string   // nil = don't update
+	Manipulated      bool      // Marks the transaction manipulated even when no edited field changed
 }
 
 // UpsertTransactionParams is used for syncing transactions from the provider
@@ -7,12 +7,31 @@ import (
 
 // DuplicateCriteria defines the search criteria for finding potential duplicates
 type DuplicateCriteria struct {
-	ExcludeID      string    // The transaction ID to exclude from results
-	OppositeType   string    // The opposite type to search for (DEBIT -> CREDIT, CREDIT -> DEBIT). Empty string means any type.
-	AbsoluteAmount float64   // The absolute amount to match
-	DateLowerBound time.Time // Lower bound of transaction date range
-	DateUpperBound time.Time // Upper bound of transaction date range
-	UserID         int64     // User ID to scope the search
+	ExcludeID       string    // The transaction ID to exclude from results
+	OppositeType    string    // The opposite type to search for (DEBIT -> CREDIT, CREDIT -> DEBIT). Empty string means any type.
+	AbsoluteAmount  float64   // The absolute amount to match
+	DateLowerBound  time.Time // Lower bound of transaction date range
+	DateUpperBound  time.Time // Upper bound of transaction date range
+	UserID          int64     // User ID to scope the search
+	AccountID       string    // Account of the source transaction
+	SameAccountOnly bool      // Only match transactions in AccountID instead of across the user's accounts
+}
+
+// DateWindow is an inclusive range of transaction dates
+type DateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ListFilter narrows a user's transaction list. Nil fields are not filtered on.
+type ListFilter struct {
+	Considered       *bool
+	ConsideredReason *string
+}
+
+// IsEmpty reports whether the filter has no conditions
+func (f ListFilter) IsEmpty() bool {
+	return f.Considered == nil && f.ConsideredReason == nil
 }
 
 // Repository defines the interface for transaction data access
@@ -20,8 +39,17 @@ type Repository interface {
 	Create(ctx context.Context, params CreateTransactionParams) (*Transaction, error)
 	GetByID(ctx context.Context, id string) (*Transaction, error)
 	ListByAccountID(ctx context.Context, accountID string, limit, offset int) ([]*Transaction, error)
+	CountByAccountID(ctx context.Context, accountID string) (int64, error)
 	ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*Transaction, error)
 	CountByUserID(ctx context.Context, userID int64) (int64, error)
+	// ListByUserIDFiltered is ListByUserID restricted by filter
+	ListByUserIDFiltered(ctx context.Context, userID int64, filter ListFilter, limit, offset int) ([]*Transaction, error)
+	// CountByUserIDFiltered counts the transactions matched by ListByUserIDFiltered
+	CountByUserIDFiltered(ctx context.Context, userID int64, filter ListFilter) (int64, error)
+	// ListByUserIDUpdatedSince returns the user's transactions updated after since, oldest change first
+	ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*Transaction, error)
+	// CountByUserIDUpdatedSince counts the transactions matched by ListByUserIDUpdatedSince
+	CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error)
 	Update(ctx context.Context, id string, params UpdateTransactionParams) (*Transaction, error)
 	Delete(ctx context.Context, id string) error
 	DeleteByAccountID(ctx context.Context, accountID string) error
@@ -34,8 +62,56 @@ type Repository interface {
 	// Returns transactions with different ID, same absolute amount (any type),
 	// and transaction date within the specified range for the same user
 	FindPotentialDuplicatesForBill(ctx context.Context, criteria DuplicateCriteria) ([]*Transaction, error)
+	// Reconsider sets considered=true with considered_reason USER on the given transactions
+	// that are currently not considered, in a single statement, replacing the notes of those
+	// with an entry in notes. Returns the IDs updated.
+	Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error)
+	// Recategorize moves the user's transactions in category from that the user has not edited
+	// (manipulated=false) to category to, dated within window (zero bounds are open), and marks
+	// them manipulated so syncs keep the new category. Single statement; returns the IDs updated.
+	Recategorize(ctx context.Context, userID int64, from, to string, window DateWindow) ([]string, error)
+	// MarkTransfer sets considered=false with considered_reason TRANSFER on the given
+	// transactions and links them under group, in one database transaction. Counterparts they
+	// were linked to before lose their group and, when still excluded as a transfer, are
+	// re-included. Returns ErrTransactionNotFound, changing nothing, unless every transaction
+	// exists and is not removed; otherwise the number of transactions updated.
+	MarkTransfer(ctx context.Context, ids []string, group string) (int64, error)
+	// ReleaseDetection sets considered=true and clears considered_reason on a transaction whose
+	// reason is still reason, replacing its notes when notes is non-nil. Reports whether it did.
+	ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error)
+	// MarkMissingAsRemoved marks the account's provider-synced transactions dated within window
+	// as removed unless their ID is in presentIDs. Returns the number of transactions marked.
+	MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window DateWindow) (int64, error)
+	// MarkStalePending marks the account's provider-synced PENDING transactions dated within
+	// window as removed unless their ID is in presentIDs. Transactions the user edited are
+	// kept. Returns the number of transactions marked.
+	MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window DateWindow) (int64, error)
+	// SetCousin assigns a transaction to a cousin (counterparty group). Returns ErrCousinNotFound
+	// when the cousin does not exist and ErrTransactionNotFound when the transaction does not.
+	// Grouping must write cousins through SetCousin/ClearCousin so no dangling IDs are stored.
+	SetCousin(ctx context.Context, transactionID string, cousinID int64) error
+	// SetCousinWithoutRules assigns a cousin like SetCousin, but the cousin_assigned listener
+	// does not apply the cousin's rule; for callers that apply it themselves.
+	SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error
+	// ClearCousin removes a transaction from its cousin group
+	ClearCousin(ctx context.Context, transactionID string) error
 	// SetTransactionTags replaces all tags for a transaction
 	SetTransactionTags(ctx context.Context, transactionID string, tagIDs []string) error
 	// GetTransactionTags returns all tag IDs for a transaction
 	GetTransactionTags(ctx context.Context, transactionID string) ([]string, error)
+	// GetTagsForTransactions returns the tag IDs of several transactions in one query, keyed by
+	// transaction ID. Transactions without tags are absent from the map.
+	GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error)
+	// UpdateTags atomically removes and then adds tag IDs on transactions (transaction ID -> tag
+	// IDs); added tags already present are kept
+	UpdateTags(ctx context.Context, add, remove map[string][]string) error
+	// MonthlyTrendByCategory returns the user's monthly totals for a category from since onwards,
+	// counting only considered transactions. Months without transactions are absent.
+	MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]MonthlyTotal, error)
+	// ListDescriptionSuggestions returns up to limit of the user's distinct descriptions starting
+	// with prefix (case-insensitive), most used first
+	ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]DescriptionSuggestion, error)
+	// CountGroups counts the user's transactions per type, status and considered flag, limited to
+	// window. A zero Start or End leaves that side of the window open.
+	CountGroups(ctx context.Context, userID int64, window DateWindow) ([]CountGroup, error)
 }
@@ -0,0 +1,16 @@
+package transaction
+
+const (
+	// MaxDescriptionSuggestions caps the descriptions returned for an autocomplete query
+	MaxDescriptionSuggestions = 20
+	// MaxDescriptionQueryLength caps the prefix accepted by the autocomplete
+	MaxDescriptionQueryLength = 100
+)
+
+// DescriptionSuggestion is a description the user has already used. Descriptions that only
+// differ in case or surrounding spaces are grouped; Description is their most common spelling
+// and Count how many transactions use any of them.
+type DescriptionSuggestion struct {
+	Description string
+	Count       int64
+}
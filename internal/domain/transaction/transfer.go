@@ -0,0 +1,30 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// TransferAmountTolerance is how far apart, in absolute value, the amounts of the two sides
+// of a transfer may be, absorbing rounding differences between institutions
+const TransferAmountTolerance = 0.01
+
+// ErrInvalidTransfer is returned when two transactions cannot be the sides of one transfer
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+// ValidateTransferPair checks that a and b can be linked as the two sides of a transfer
+// between the user's own accounts: distinct transactions of opposite types whose absolute
+// amounts match within TransferAmountTolerance
+func ValidateTransferPair(a, b *Transaction) error {
+	if a.ID == b.ID {
+		return fmt.Errorf("%w: a transaction cannot be its own counterpart", ErrInvalidTransfer)
+	}
+	if a.Type == b.Type {
+		return fmt.Errorf("%w: both transactions are %s", ErrInvalidTransfer, a.Type)
+	}
+	if math.Abs(math.Abs(a.Amount)-math.Abs(b.Amount)) > TransferAmountTolerance+1e-9 {
+		return fmt.Errorf("%w: amounts %.2f and %.2f differ", ErrInvalidTransfer, math.Abs(a.Amount), math.Abs(b.Amount))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package transaction
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTransferPair(t *testing.T) {
+	debit := &Transaction{ID: "tx-1", Type: "DEBIT", Amount: -150.00}
+	tests := []struct {
+		name    string
+		other   *Transaction
+		wantErr bool
+	}{
+		{name: "opposite type and amount", other: &Transaction{ID: "tx-2", Type: "CREDIT", Amount: 150.00}},
+		{name: "within tolerance", other: &Transaction{ID: "tx-2", Type: "CREDIT", Amount: 150.01}},
+		{name: "beyond tolerance", other: &Transaction{ID: "tx-2", Type: "CREDIT", Amount: 150.02}, wantErr: true},
+		{name: "same type", other: &Transaction{ID: "tx-2", Type: "DEBIT", Amount: -150.00}, wantErr: true},
+		{name: "same transaction", other: debit, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransferPair(debit, tt.other)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ValidateTransferPair() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTransfer) {
+				t.Errorf("error %v does not wrap ErrInvalidTransfer", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package transaction
+
+import "time"
+
+const (
+	// DefaultTrendMonths is the length of a monthly trend when none is requested
+	DefaultTrendMonths = 12
+	// MaxTrendMonths caps the length of a monthly trend
+	MaxTrendMonths = 60
+)
+
+// MonthlyTotal is the net spending of a calendar month (UTC): debits minus credits, so
+// refunds reduce the month's total. Month is the first instant of the month.
+type MonthlyTotal struct {
+	Month time.Time
+	Total float64
+}
+
+// TrendStart returns the first instant (UTC) of the earliest month of a trend covering
+// months calendar months up to and including the month of now
+func TrendStart(now time.Time, months int) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// FillMonthlyTrend returns one total per month starting at start, in order, using zero for
+// the months missing from totals
+func FillMonthlyTrend(totals []MonthlyTotal, start time.Time, months int) []MonthlyTotal {
+	byMonth := make(map[time.Time]float64, len(totals))
+	for _, t := range totals {
+		byMonth[t.Month.UTC()] = t.Total
+	}
+
+	series := make([]MonthlyTotal, 0, months)
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0)
+		series = append(series, MonthlyTotal{Month: month, Total: byMonth[month]})
+	}
+	return series
+}
@@ -0,0 +1,56 @@
+package transaction
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTrendStart(t *testing.T) {
+	tests := []struct {
+		name   string
+		now    time.Time
+		months int
+		want   time.Time
+	}{
+		{name: "current month only", now: time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC), months: 1, want: month(2026, time.March)},
+		{name: "crosses year", now: time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC), months: 12, want: month(2025, time.April)},
+		{name: "end of month", now: time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC), months: 2, want: month(2026, time.February)},
+		{name: "converted to UTC", now: time.Date(2026, 2, 28, 22, 0, 0, 0, time.FixedZone("BRT", -3*60*60)), months: 1, want: month(2026, time.March)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrendStart(tt.now, tt.months); !got.Equal(tt.want) {
+				t.Errorf("TrendStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillMonthlyTrend(t *testing.T) {
+	start := month(2025, time.November)
+	totals := []MonthlyTotal{
+		{Month: month(2025, time.November), Total: 120.5},
+		{Month: month(2026, time.January), Total: 80},
+	}
+
+	got := FillMonthlyTrend(totals, start, 4)
+	want := []MonthlyTotal{
+		{Month: month(2025, time.November), Total: 120.5},
+		{Month: month(2025, time.December), Total: 0},
+		{Month: month(2026, time.January), Total: 80},
+		{Month: month(2026, time.February), Total: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FillMonthlyTrend() = %v, want %v", got, want)
+	}
+
+	if got := FillMonthlyTrend(nil, start, 2); len(got) != 2 || got[0].Total != 0 || got[1].Total != 0 {
+		t.Errorf("FillMonthlyTrend(nil) = %v, want two zero months", got)
+	}
+}
@@ -0,0 +1,16 @@
+package user
+
+import "time"
+
+// InsightsSince returns the later of since and the user's insights start date, so aggregations
+// leave out transactions from before the user started using the app. A zero since means no
+// lower bound of its own; the result stays zero when the user has no start date either.
+func (u *User) InsightsSince(since time.Time) time.Time {
+	if u == nil || u.InsightsStartDate == nil {
+		return since
+	}
+	if since.IsZero() || u.InsightsStartDate.After(since) {
+		return *u.InsightsStartDate
+	}
+	return since
+}
@@ -0,0 +1,34 @@
+package user
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInsightsSince(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	before := start.AddDate(0, -1, 0)
+	after := start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name  string
+		user  *User
+		since time.Time
+		want  time.Time
+	}{
+		{"nil user", nil, before, before},
+		{"no start date", &User{}, before, before},
+		{"no start date and no bound", &User{}, time.Time{}, time.Time{}},
+		{"start date without bound", &User{InsightsStartDate: &start}, time.Time{}, start},
+		{"start date after bound", &User{InsightsStartDate: &start}, before, start},
+		{"start date before bound", &User{InsightsStartDate: &start}, after, after},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.InsightsSince(tt.since); !got.Equal(tt.want) {
+				t.Errorf("InsightsSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
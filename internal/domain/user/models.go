@@ -18,6 +18,9 @@ type User struct {
 	UpdatedAt        time.Time `json:"updatedAt"`
 	ProviderKey                *string   `json:"-"`                          // Nullable, not exposed in API
 	HasFinishedOpenfinanceFlow bool      `json:"hasFinishedOpenfinanceFlow"`
+	ProviderKeyUpdatedAt       *time.Time `json:"providerKeyUpdatedAt,omitempty"`   // When the key was last set or cleared
+	ProviderKeyLastValidAt     *time.Time `json:"providerKeyLastValidAt,omitempty"` // Last time the provider accepted the key
+	InsightsStartDate          *time.Time `json:"insightsStartDate,omitempty"`      // Transactions before it are left out of aggregations
 	BalanceAvailable           *float64  `json:"balanceAvailable,omitempty"` // Calculated field
 	BalanceTotal               *float64  `json:"balanceTotal,omitempty"`     // Calculated field
 }
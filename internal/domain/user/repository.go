@@ -1,16 +1,24 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the interface for user data access
 type Repository interface {
 	Create(ctx context.Context, params CreateUserParams) (*User, error)
 	GetByID(ctx context.Context, id int64) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetByOAuth returns (nil, nil) when no user is linked to the OAuth identity
 	GetByOAuth(ctx context.Context, provider, oauthID string) (*User, error)
 	List(ctx context.Context) ([]*User, error)
 	Update(ctx context.Context, userID int64, params UpdateUserParams) (*User, error)
 	ListUsersWithProviderKey(ctx context.Context) ([]*User, error)
 	ClearProviderKey(ctx context.Context, userID int64) error
+	MarkProviderKeyValid(ctx context.Context, userID int64) error
+	RecordProviderKeyFailure(ctx context.Context, userID int64) (int, error)
 	SetHasFinishedOpenfinanceFlow(ctx context.Context, userID int64, value bool) error
+	// SetInsightsStartDate sets the user's insights start date; nil clears it
+	SetInsightsStartDate(ctx context.Context, userID int64, date *time.Time) error
 }
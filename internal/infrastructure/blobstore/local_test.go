@@ -0,0 +1,67 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"parsa/internal/domain/attachment"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(filepath.Join(t.TempDir(), "blobs"))
+	if err != nil {
+		t.Fatalf("NewLocalStore() failed: %v", err)
+	}
+
+	n, err := store.Put(ctx, "1/receipt.png", strings.NewReader("receipt"))
+	if err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	if n != int64(len("receipt")) {
+		t.Errorf("Put() wrote %d bytes, want %d", n, len("receipt"))
+	}
+
+	r, err := store.Get(ctx, "1/receipt.png")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "receipt" {
+		t.Errorf("Get() = %q, want %q", data, "receipt")
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(store.root, "1"))
+	if len(entries) != 1 {
+		t.Errorf("Put() left %d files in the key directory, want 1", len(entries))
+	}
+
+	if err := store.Delete(ctx, "1/receipt.png"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "1/receipt.png"); !errors.Is(err, attachment.ErrBlobNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want %v", err, attachment.ErrBlobNotFound)
+	}
+	if err := store.Delete(ctx, "1/receipt.png"); err != nil {
+		t.Errorf("Delete() of a missing key failed: %v", err)
+	}
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() failed: %v", err)
+	}
+
+	for _, key := range []string{"", "../outside.png", "1/../../outside.png", "/etc/passwd", `1\..\outside.png`} {
+		if _, err := store.Put(context.Background(), key, strings.NewReader("x")); !errors.Is(err, errInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want %v", key, err, errInvalidKey)
+		}
+	}
+}
@@ -11,29 +11,86 @@ import (
 )
 
 const (
-	baseURL        = "https://www.pierre.finance/tools/api"
-	defaultTimeout = 180 * time.Second // Increased for large transaction fetches
-	accountsPath   = "/get-accounts"
-	billsPath      = "/get-bills"
+	baseURL      = "https://www.pierre.finance/tools/api"
+	accountsPath = "/get-accounts"
+	billsPath    = "/get-bills"
+
+	// Transaction fetches return the whole history and are much slower than the other calls
+	defaultAccountsTimeout     = 30 * time.Second
+	defaultTransactionsTimeout = 180 * time.Second
+	defaultBillsTimeout        = 30 * time.Second
 )
 
+// Timeouts bounds each Open Finance call separately, so a slow transactions fetch does not
+// force a long timeout on the quick account and bill calls. Zero fields use the defaults.
+type Timeouts struct {
+	Accounts     time.Duration
+	Transactions time.Duration
+	Bills        time.Duration
+}
+
+// withDefaults fills zero timeouts with the defaults
+func (t Timeouts) withDefaults() Timeouts {
+	if t.Accounts <= 0 {
+		t.Accounts = defaultAccountsTimeout
+	}
+	if t.Transactions <= 0 {
+		t.Transactions = defaultTransactionsTimeout
+	}
+	if t.Bills <= 0 {
+		t.Bills = defaultBillsTimeout
+	}
+	return t
+}
+
 // Client handles communication with the Open Finance API
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+	timeouts   Timeouts
+	metrics    Metrics
 }
 
 // Ensure Client implements ClientInterface
 var _ ClientInterface = (*Client)(nil)
 
-// NewClient creates a new Open Finance API client
+// NewClient creates a new Open Finance API client with the default timeouts
 func NewClient() *Client {
+	return NewClientWithTimeouts(Timeouts{})
+}
+
+// NewClientWithTimeouts creates a new Open Finance API client with per-operation timeouts.
+// Each call derives its deadline from the caller's context.
+func NewClientWithTimeouts(timeouts Timeouts) *Client {
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: defaultTimeout,
-		},
-		baseURL: baseURL,
+		httpClient: &http.Client{},
+		baseURL:    baseURL,
+		timeouts:   timeouts.withDefaults(),
+		metrics:    noopMetrics{},
+	}
+}
+
+// SetMetrics records request counts, statuses and latencies of every call. A nil value
+// disables recording.
+func (c *Client) SetMetrics(m Metrics) {
+	if m == nil {
+		m = noopMetrics{}
 	}
+	c.metrics = m
+}
+
+// do executes the request and reports it to the metrics under the given endpoint name
+func (c *Client) do(req *http.Request, endpoint string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	c.metrics.ObserveRequest(req.Context(), endpoint, status, time.Since(start))
+
+	return resp, err
 }
 
 // AccountResponse represents the API response for account data
@@ -66,7 +123,7 @@ func (a *Account) GetBalance() (float64, error) {
 	if a.BalanceString == "" {
 		return 0, nil
 	}
-	balance, err := strconv.ParseFloat(a.BalanceString, 64)
+	balance, err := parseDecimal(a.BalanceString)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse balance '%s': %w", a.BalanceString, err)
 	}
@@ -175,7 +232,7 @@ func (t *Transaction) GetAmount() (float64, error) {
 	if t.AmountString == "" {
 		return 0, nil
 	}
-	amount, err := strconv.ParseFloat(t.AmountString, 64)
+	amount, err := parseDecimal(t.AmountString)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse amount '%s': %w", t.AmountString, err)
 	}
@@ -283,7 +340,7 @@ func (b *Bill) GetTotalAmount() (float64, error) {
 	if b.TotalAmountString == "" {
 		return 0, nil
 	}
-	amount, err := strconv.ParseFloat(b.TotalAmountString, 64)
+	amount, err := parseDecimal(b.TotalAmountString)
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse totalAmount '%s': %w", b.TotalAmountString, err)
 	}
@@ -295,7 +352,7 @@ func (b *Bill) GetMinimumPayment() (*float64, error) {
 	if b.MinimumPaymentString == nil || *b.MinimumPaymentString == "" {
 		return nil, nil
 	}
-	amount, err := strconv.ParseFloat(*b.MinimumPaymentString, 64)
+	amount, err := parseDecimal(*b.MinimumPaymentString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse minimumPayment '%s': %w", *b.MinimumPaymentString, err)
 	}
@@ -372,6 +429,9 @@ type ErrorResponse struct {
 // GetAccountsWithStatus fetches accounts and returns both the response and HTTP status code.
 // This allows callers to handle different status codes (e.g., 401) while still parsing successful responses.
 func (c *Client) GetAccountsWithStatus(ctx context.Context, apiKey string) (*AccountResponse, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.Accounts)
+	defer cancel()
+
 	url := c.baseURL + accountsPath
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -382,7 +442,7 @@ func (c *Client) GetAccountsWithStatus(ctx context.Context, apiKey string) (*Acc
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, EndpointAccounts)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -422,6 +482,9 @@ func (c *Client) GetAccounts(ctx context.Context, apiKey string) (*AccountRespon
 // GetTransactions fetches all transactions for a user using their API key.
 // startDate should be in YYYY-MM-DD format (e.g., "2024-01-01").
 func (c *Client) GetTransactions(ctx context.Context, apiKey string, startDate string) (*TransactionResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.Transactions)
+	defer cancel()
+
 	url := fmt.Sprintf("%s/get-transactions?format=raw&startDate=%s", c.baseURL, startDate)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -433,7 +496,7 @@ func (c *Client) GetTransactions(ctx context.Context, apiKey string, startDate s
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, EndpointTransactions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -467,6 +530,9 @@ func (c *Client) GetTransactions(ctx context.Context, apiKey string, startDate s
 
 // GetBills fetches all bills for a user using their API key
 func (c *Client) GetBills(ctx context.Context, apiKey string) (*BillResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.Bills)
+	defer cancel()
+
 	url := c.baseURL + billsPath
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -477,7 +543,7 @@ func (c *Client) GetBills(ctx context.Context, apiKey string) (*BillResponse, er
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req, EndpointBills)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
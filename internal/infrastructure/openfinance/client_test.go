@@ -0,0 +1,98 @@
+package openfinance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// observation is one call recorded by recordingMetrics
+type observation struct {
+	endpoint string
+	status   int
+}
+
+// recordingMetrics implements Metrics by remembering every observation
+type recordingMetrics struct {
+	mu   sync.Mutex
+	seen []observation
+}
+
+func (m *recordingMetrics) ObserveRequest(ctx context.Context, endpoint string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seen = append(m.seen, observation{endpoint: endpoint, status: status})
+}
+
+func TestClient_RecordsMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case accountsPath:
+			w.Write([]byte(`{"success":true,"data":[]}`))
+		case "/get-transactions":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"success":false,"error":"unauthorized","message":"bad key"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	metrics := &recordingMetrics{}
+	client := NewClient()
+	client.baseURL = srv.URL
+	client.SetMetrics(metrics)
+
+	ctx := context.Background()
+	if _, err := client.GetAccounts(ctx, "key"); err != nil {
+		t.Fatalf("GetAccounts: unexpected error: %v", err)
+	}
+	if _, err := client.GetTransactions(ctx, "key", "2024-01-01"); err == nil {
+		t.Fatal("GetTransactions: expected an error for a 401")
+	}
+	if _, err := client.GetBills(ctx, "key"); err == nil {
+		t.Fatal("GetBills: expected an error for a 500")
+	}
+
+	want := []observation{
+		{endpoint: EndpointAccounts, status: http.StatusOK},
+		{endpoint: EndpointTransactions, status: http.StatusUnauthorized},
+		{endpoint: EndpointBills, status: http.StatusInternalServerError},
+	}
+	if len(metrics.seen) != len(want) {
+		t.Fatalf("recorded %d calls, want %d: %+v", len(metrics.seen), len(want), metrics.seen)
+	}
+	for i, w := range want {
+		if metrics.seen[i] != w {
+			t.Errorf("call %d = %+v, want %+v", i, metrics.seen[i], w)
+		}
+	}
+}
+
+func TestClient_RecordsTransportFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close() // nothing listens, so the request fails before a response
+
+	metrics := &recordingMetrics{}
+	client := NewClient()
+	client.baseURL = srv.URL
+	client.SetMetrics(metrics)
+
+	if _, _, err := client.GetAccountsWithStatus(context.Background(), "key"); err == nil {
+		t.Fatal("expected a transport error")
+	}
+	if len(metrics.seen) != 1 || metrics.seen[0] != (observation{endpoint: EndpointAccounts, status: 0}) {
+		t.Errorf("recorded %+v, want one accounts call with status 0", metrics.seen)
+	}
+}
+
+func TestClient_SetMetricsNil(t *testing.T) {
+	client := NewClient()
+	client.SetMetrics(nil)
+	if _, ok := client.metrics.(noopMetrics); !ok {
+		t.Errorf("metrics = %T, want noopMetrics", client.metrics)
+	}
+}
@@ -0,0 +1,72 @@
+package openfinance
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseDecimal parses a provider amount that may use either a dot or a comma as the decimal
+// separator, with optional thousands separators: "1234.56", "1234,56", "1,234.56" and
+// "1.234,56" all read as 1234.56. When both separators appear, the last one is the decimal
+// separator. A single separator of one kind is the decimal separator ("1.234" is 1.234 and
+// "1,5" is 1.5), while a repeated one is a thousands separator ("1.234.567"). Thousands
+// groups must have three digits, so malformed values fail instead of parsing as a wrong number.
+func parseDecimal(s string) (float64, error) {
+	value := strings.TrimSpace(s)
+
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+	var thousands, decimal string
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			thousands, decimal = ".", ","
+		} else {
+			thousands, decimal = ",", "."
+		}
+	case strings.Count(value, ".") > 1:
+		thousands = "."
+	case strings.Count(value, ",") > 1:
+		thousands = ","
+	case lastComma >= 0:
+		decimal = ","
+	}
+
+	if thousands != "" {
+		intPart := value
+		if decimal != "" {
+			intPart = value[:strings.LastIndex(value, decimal)]
+		}
+		if (decimal != "" && strings.Contains(intPart, decimal)) || !validThousandsGroups(intPart, thousands) {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+		value = strings.ReplaceAll(value, thousands, "")
+	}
+	if decimal == "," {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return amount, nil
+}
+
+// validThousandsGroups reports whether intPart, an optionally signed integer, splits on sep
+// into a leading group of 1 to 3 digits followed by groups of exactly 3
+func validThousandsGroups(intPart, sep string) bool {
+	intPart = strings.TrimLeft(intPart, "+-")
+	groups := strings.Split(intPart, sep)
+	for i, group := range groups {
+		if group == "" || strings.Trim(group, "0123456789") != "" {
+			return false
+		}
+		if (i == 0 && len(group) > 3) || (i > 0 && len(group) != 3) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package openfinance
+
+import "testing"
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "1234.56", want: 1234.56},
+		{in: "-1234.56", want: -1234.56},
+		{in: "0.5", want: 0.5},
+		{in: "100", want: 100},
+		{in: " 42.10 ", want: 42.10},
+		{in: "1234,56", want: 1234.56},
+		{in: "-0,99", want: -0.99},
+		{in: "1.234,56", want: 1234.56},
+		{in: "-1.234.567,89", want: -1234567.89},
+		{in: "1,234.56", want: 1234.56},
+		{in: "1,234,567", want: 1234567},
+		{in: "1.234.567", want: 1234567},
+		{in: "1.234", want: 1.234},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "R$ 10,00", wantErr: true},
+		{in: "12.34.5", wantErr: true},
+		{in: "1.234,5,6", wantErr: true},
+		{in: "12,34.56", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "Inf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDecimal(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseDecimal(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDecimal(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseDecimal(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountGetters(t *testing.T) {
+	account := Account{BalanceString: "1.234,56"}
+	if got, err := account.GetBalance(); err != nil || got != 1234.56 {
+		t.Errorf("GetBalance() = %v, %v, want 1234.56", got, err)
+	}
+	if _, err := (&Account{BalanceString: "1.2.3,4,5"}).GetBalance(); err == nil {
+		t.Error("GetBalance() accepted a malformed balance")
+	}
+
+	txn := Transaction{AmountString: "-89,90"}
+	if got, err := txn.GetAmount(); err != nil || got != -89.90 {
+		t.Errorf("GetAmount() = %v, %v, want -89.90", got, err)
+	}
+	if got, err := (&Transaction{}).GetAmount(); err != nil || got != 0 {
+		t.Errorf("GetAmount() of an empty amount = %v, %v, want 0", got, err)
+	}
+}
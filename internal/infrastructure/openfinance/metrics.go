@@ -0,0 +1,26 @@
+package openfinance
+
+import (
+	"context"
+	"time"
+)
+
+// Endpoint names reported to Metrics
+const (
+	EndpointAccounts     = "accounts"
+	EndpointTransactions = "transactions"
+	EndpointBills        = "bills"
+)
+
+// Metrics records the client's calls to the Open Finance API. Implementations must be safe
+// for concurrent use.
+type Metrics interface {
+	// ObserveRequest records one HTTP call: the endpoint, the response status (0 when no
+	// response was received) and how long the call took
+	ObserveRequest(ctx context.Context, endpoint string, status int, duration time.Duration)
+}
+
+// noopMetrics is used until SetMetrics installs a real implementation
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(context.Context, string, int, time.Duration) {}
@@ -63,13 +63,14 @@ func (r *AccountRepository) Create(ctx context.Context, params account.CreatePar
 func (r *AccountRepository) GetByID(ctx context.Context, id string) (*account.Account, error) {
 	query := `
 		SELECT id, user_id, item_id, name, account_type, subtype, currency, balance, bank_id,
-		       provider_updated_at, provider_created_at, created_at, updated_at
+		       provider_updated_at, provider_created_at, created_at, updated_at,
+		       marketing_name, provider_code, provider_subtype
 		FROM accounts
 		WHERE id = $1
 	`
 
 	var acc account.Account
-	var itemID, subtype sql.NullString
+	var itemID, subtype, marketingName, providerCode, providerSubtype sql.NullString
 	var bankID sql.NullInt64
 	var providerUpdatedAt, providerCreatedAt sql.NullTime
 
@@ -78,6 +79,7 @@ func (r *AccountRepository) GetByID(ctx context.Context, id string) (*account.Ac
 		&acc.AccountType, &subtype, &acc.Currency, &acc.Balance,
 		&bankID, &providerUpdatedAt, &providerCreatedAt,
 		&acc.CreatedAt, &acc.UpdatedAt,
+		&marketingName, &providerCode, &providerSubtype,
 	)
 
 	if err == sql.ErrNoRows {
@@ -102,6 +104,15 @@ func (r *AccountRepository) GetByID(ctx context.Context, id string) (*account.Ac
 	if providerCreatedAt.Valid {
 		acc.ProviderCreatedAt = providerCreatedAt.Time
 	}
+	if marketingName.Valid {
+		acc.MarketingName = marketingName.String
+	}
+	if providerCode.Valid {
+		acc.ProviderCode = providerCode.String
+	}
+	if providerSubtype.Valid {
+		acc.ProviderSubtype = providerSubtype.String
+	}
 
 	return &acc, nil
 }
@@ -284,14 +295,17 @@ func (r *AccountRepository) Upsert(ctx context.Context, params account.UpsertPar
 	query := `
 		INSERT INTO accounts (
 			id, user_id, item_id, name, account_type, subtype, currency, balance, bank_id,
-			provider_updated_at, provider_created_at
+			provider_updated_at, provider_created_at, marketing_name, provider_code, provider_subtype
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		ON CONFLICT (id)
 		DO UPDATE SET
 			name = EXCLUDED.name,
+			marketing_name = EXCLUDED.marketing_name,
+			provider_code = EXCLUDED.provider_code,
 			account_type = EXCLUDED.account_type,
 			subtype = EXCLUDED.subtype,
+			provider_subtype = EXCLUDED.provider_subtype,
 			currency = EXCLUDED.currency,
 			balance = EXCLUDED.balance,
 			item_id = EXCLUDED.item_id,
@@ -299,11 +313,11 @@ func (r *AccountRepository) Upsert(ctx context.Context, params account.UpsertPar
 			updated_at = CURRENT_TIMESTAMP
 		WHERE accounts.user_id = EXCLUDED.user_id
 		RETURNING id, user_id, item_id, name, account_type, subtype, currency, balance, bank_id,
-		          provider_updated_at, provider_created_at, created_at, updated_at
+		          provider_updated_at, provider_created_at, created_at, updated_at, provider_subtype
 	`
 
 	var acc account.Account
-	var itemIDOut, subtypeOut sql.NullString
+	var itemIDOut, subtypeOut, providerSubtypeOut sql.NullString
 	var bankIDOut sql.NullInt64
 	var providerUpdatedAtOut, providerCreatedAtOut sql.NullTime
 
@@ -333,11 +347,12 @@ func (r *AccountRepository) Upsert(ctx context.Context, params account.UpsertPar
 		params.ID, params.UserID, nullString(params.ItemID), params.Name, params.AccountType,
 		subtypeIn, params.Currency, params.Balance, bankIDIn,
 		providerUpdatedAtIn, providerCreatedAtIn,
+		nullString(params.MarketingName), nullString(params.ProviderCode), nullString(params.ProviderSubtype),
 	).Scan(
 		&acc.ID, &acc.UserID, &itemIDOut, &acc.Name,
 		&acc.AccountType, &subtypeOut, &acc.Currency, &acc.Balance,
 		&bankIDOut, &providerUpdatedAtOut, &providerCreatedAtOut,
-		&acc.CreatedAt, &acc.UpdatedAt,
+		&acc.CreatedAt, &acc.UpdatedAt, &providerSubtypeOut,
 	)
 
 	if err == sql.ErrNoRows {
@@ -359,6 +374,9 @@ func (r *AccountRepository) Upsert(ctx context.Context, params account.UpsertPar
 	if subtypeOut.Valid {
 		acc.Subtype = subtypeOut.String
 	}
+	if providerSubtypeOut.Valid {
+		acc.ProviderSubtype = providerSubtypeOut.String
+	}
 	if bankIDOut.Valid {
 		acc.BankID = bankIDOut.Int64
 	}
@@ -380,17 +398,17 @@ func (r *AccountRepository) UpsertBatch(ctx context.Context, params []account.Up
 	}
 
 	// Build the VALUES clause with placeholders
-	// Each account has 11 fields
+	// Each account has 14 fields
 	valueStrings := make([]string, 0, len(params))
-	valueArgs := make([]any, 0, len(params)*11)
+	valueArgs := make([]any, 0, len(params)*14)
 
 	for i, param := range params {
 		// Calculate placeholder positions for this row
-		offset := i * 11
+		offset := i * 14
 		valueStrings = append(valueStrings, fmt.Sprintf(
-			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
 			offset+1, offset+2, offset+3, offset+4, offset+5, offset+6,
-			offset+7, offset+8, offset+9, offset+10, offset+11,
+			offset+7, offset+8, offset+9, offset+10, offset+11, offset+12, offset+13, offset+14,
 		))
 
 		// Convert nullable fields
@@ -419,20 +437,24 @@ func (r *AccountRepository) UpsertBatch(ctx context.Context, params []account.Up
 			param.ID, param.UserID, nullString(param.ItemID), param.Name, param.AccountType,
 			subtypeIn, param.Currency, param.Balance, bankIDIn,
 			providerUpdatedAtIn, providerCreatedAtIn,
+			nullString(param.MarketingName), nullString(param.ProviderCode), nullString(param.ProviderSubtype),
 		)
 	}
 
 	query := fmt.Sprintf(`
 		INSERT INTO accounts (
 			id, user_id, item_id, name, account_type, subtype, currency, balance, bank_id,
-			provider_updated_at, provider_created_at
+			provider_updated_at, provider_created_at, marketing_name, provider_code, provider_subtype
 		)
 		VALUES %s
 		ON CONFLICT (id)
 		DO UPDATE SET
 			name = EXCLUDED.name,
+			marketing_name = EXCLUDED.marketing_name,
+			provider_code = EXCLUDED.provider_code,
 			account_type = EXCLUDED.account_type,
 			subtype = EXCLUDED.subtype,
+			provider_subtype = EXCLUDED.provider_subtype,
 			currency = EXCLUDED.currency,
 			balance = EXCLUDED.balance,
 			item_id = EXCLUDED.item_id,
@@ -441,8 +463,11 @@ func (r *AccountRepository) UpsertBatch(ctx context.Context, params []account.Up
 		WHERE
 			accounts.user_id = EXCLUDED.user_id AND (
 			accounts.name IS DISTINCT FROM EXCLUDED.name OR
+			accounts.marketing_name IS DISTINCT FROM EXCLUDED.marketing_name OR
+			accounts.provider_code IS DISTINCT FROM EXCLUDED.provider_code OR
 			accounts.account_type IS DISTINCT FROM EXCLUDED.account_type OR
 			accounts.subtype IS DISTINCT FROM EXCLUDED.subtype OR
+			accounts.provider_subtype IS DISTINCT FROM EXCLUDED.provider_subtype OR
 			accounts.currency IS DISTINCT FROM EXCLUDED.currency OR
 			accounts.balance IS DISTINCT FROM EXCLUDED.balance OR
 			accounts.item_id IS DISTINCT FROM EXCLUDED.item_id OR
@@ -546,14 +571,99 @@ func (r *AccountRepository) UpdateBankID(ctx context.Context, accountID string,
 	return nil
 }
 
-// ListByUserIDWithBank retrieves all accounts for a specific user with bank data (LEFT JOIN)
-func (r *AccountRepository) ListByUserIDWithBank(ctx context.Context, userID int64) ([]*account.AccountWithBank, error) {
-	query := `
-		SELECT 
+// accountWithBankColumns selects an account and its bank's data from accounts a LEFT JOIN banks b,
+// plus its considered balance. The correlated subquery runs per selected account, so listing
+// a user's accounts stays a single query.
+const accountWithBankColumns = `
 			a.id, a.user_id, a.item_id, a.name, a.account_type, a.subtype, a.currency, a.balance, a.bank_id,
 			a.provider_updated_at, a.provider_created_at, a.created_at, a.updated_at,
 			a.initial_balance, a.is_open_finance_account, a.closed_at, a."order", a.description, a.removed_at, a.hidden_by_user,
-			b.name AS bank_name, b.ui_name AS bank_ui_name, b.connector AS bank_connector, b.primary_color AS bank_primary_color
+			a.marketing_name, a.provider_code, a.provider_subtype,
+			b.name AS bank_name, b.ui_name AS bank_ui_name, b.connector AS bank_connector, b.primary_color AS bank_primary_color,
+			b.logo_url AS bank_logo_url,
+			a.initial_balance + COALESCE((
+				SELECT SUM(CASE WHEN t.type = 'CREDIT' THEN ABS(t.amount) ELSE -ABS(t.amount) END)
+				FROM transactions t
+				WHERE t.account_id = a.id AND t.considered = true AND t.removed_at IS NULL
+			), 0) AS considered_balance`
+
+// scanAccountWithBank scans a row selected with accountWithBankColumns
+func scanAccountWithBank(row interface{ Scan(dest ...any) error }) (*account.AccountWithBank, error) {
+	var acc account.AccountWithBank
+	var itemID, subtype, description, marketingName, providerCode, providerSubtype sql.NullString
+	var bankID sql.NullInt64
+	var providerUpdatedAt, providerCreatedAt, closedAt, removedAt sql.NullTime
+	var bankName, bankUIName, bankConnector, bankPrimaryColor, bankLogoURL sql.NullString
+
+	err := row.Scan(
+		&acc.ID, &acc.UserID, &itemID, &acc.Name,
+		&acc.AccountType, &subtype, &acc.Currency, &acc.Balance, &bankID,
+		&providerUpdatedAt, &providerCreatedAt, &acc.CreatedAt, &acc.UpdatedAt,
+		&acc.InitialBalance, &acc.IsOpenFinanceAccount, &closedAt, &acc.UIOrder, &description, &removedAt, &acc.HiddenByUser,
+		&marketingName, &providerCode, &providerSubtype,
+		&bankName, &bankUIName, &bankConnector, &bankPrimaryColor, &bankLogoURL,
+		&acc.ConsideredBalance,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if itemID.Valid {
+		acc.ItemID = itemID.String
+	}
+	if subtype.Valid {
+		acc.Subtype = subtype.String
+	}
+	if bankID.Valid {
+		acc.BankID = bankID.Int64
+	}
+	if providerUpdatedAt.Valid {
+		acc.ProviderUpdatedAt = providerUpdatedAt.Time
+	}
+	if providerCreatedAt.Valid {
+		acc.ProviderCreatedAt = providerCreatedAt.Time
+	}
+	if closedAt.Valid {
+		acc.ClosedAt = closedAt.Time
+	}
+	if description.Valid {
+		acc.Description = description.String
+	}
+	if removedAt.Valid {
+		acc.RemovedAt = &removedAt.Time
+	}
+	if marketingName.Valid {
+		acc.MarketingName = marketingName.String
+	}
+	if providerCode.Valid {
+		acc.ProviderCode = providerCode.String
+	}
+	if providerSubtype.Valid {
+		acc.ProviderSubtype = providerSubtype.String
+	}
+	if bankName.Valid {
+		acc.BankName = bankName.String
+	}
+	if bankUIName.Valid {
+		acc.BankUIName = bankUIName.String
+	}
+	if bankConnector.Valid {
+		acc.BankConnector = bankConnector.String
+	}
+	if bankPrimaryColor.Valid {
+		acc.BankPrimaryColor = bankPrimaryColor.String
+	}
+	if bankLogoURL.Valid {
+		acc.BankLogoURL = bankLogoURL.String
+	}
+
+	return &acc, nil
+}
+
+// ListByUserIDWithBank retrieves all accounts for a specific user with bank data (LEFT JOIN)
+func (r *AccountRepository) ListByUserIDWithBank(ctx context.Context, userID int64) ([]*account.AccountWithBank, error) {
+	query := `
+		SELECT ` + accountWithBankColumns + `
 		FROM accounts a
 		LEFT JOIN banks b ON a.bank_id = b.id
 		WHERE a.user_id = $1
@@ -568,61 +678,11 @@ func (r *AccountRepository) ListByUserIDWithBank(ctx context.Context, userID int
 
 	var accounts []*account.AccountWithBank
 	for rows.Next() {
-		var acc account.AccountWithBank
-		var itemID, subtype, description sql.NullString
-		var bankID sql.NullInt64
-		var providerUpdatedAt, providerCreatedAt, closedAt, removedAt sql.NullTime
-		var bankName, bankUIName, bankConnector, bankPrimaryColor sql.NullString
-
-		err := rows.Scan(
-			&acc.ID, &acc.UserID, &itemID, &acc.Name,
-			&acc.AccountType, &subtype, &acc.Currency, &acc.Balance, &bankID,
-			&providerUpdatedAt, &providerCreatedAt, &acc.CreatedAt, &acc.UpdatedAt,
-			&acc.InitialBalance, &acc.IsOpenFinanceAccount, &closedAt, &acc.UIOrder, &description, &removedAt, &acc.HiddenByUser,
-			&bankName, &bankUIName, &bankConnector, &bankPrimaryColor,
-		)
+		acc, err := scanAccountWithBank(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan account with bank: %w", err)
 		}
-
-		if itemID.Valid {
-			acc.ItemID = itemID.String
-		}
-		if subtype.Valid {
-			acc.Subtype = subtype.String
-		}
-		if bankID.Valid {
-			acc.BankID = bankID.Int64
-		}
-		if providerUpdatedAt.Valid {
-			acc.ProviderUpdatedAt = providerUpdatedAt.Time
-		}
-		if providerCreatedAt.Valid {
-			acc.ProviderCreatedAt = providerCreatedAt.Time
-		}
-		if closedAt.Valid {
-			acc.ClosedAt = closedAt.Time
-		}
-		if description.Valid {
-			acc.Description = description.String
-		}
-		if removedAt.Valid {
-			acc.RemovedAt = &removedAt.Time
-		}
-		if bankName.Valid {
-			acc.BankName = bankName.String
-		}
-		if bankUIName.Valid {
-			acc.BankUIName = bankUIName.String
-		}
-		if bankConnector.Valid {
-			acc.BankConnector = bankConnector.String
-		}
-		if bankPrimaryColor.Valid {
-			acc.BankPrimaryColor = bankPrimaryColor.String
-		}
-
-		accounts = append(accounts, &acc)
+		accounts = append(accounts, acc)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -632,6 +692,26 @@ func (r *AccountRepository) ListByUserIDWithBank(ctx context.Context, userID int
 	return accounts, nil
 }
 
+// GetByIDWithBank retrieves an account by its ID with bank data (LEFT JOIN)
+func (r *AccountRepository) GetByIDWithBank(ctx context.Context, id string) (*account.AccountWithBank, error) {
+	query := `
+		SELECT ` + accountWithBankColumns + `
+		FROM accounts a
+		LEFT JOIN banks b ON a.bank_id = b.id
+		WHERE a.id = $1
+	`
+
+	acc, err := scanAccountWithBank(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, account.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account with bank: %w", err)
+	}
+
+	return acc, nil
+}
+
 // GetBalanceSumBySubtype calculates the sum of absolute balances for accounts with specific subtypes
 func (r *AccountRepository) GetBalanceSumBySubtype(ctx context.Context, userID int64, subtypes []string) (float64, error) {
 	if len(subtypes) == 0 {
@@ -656,7 +736,7 @@ func (r *AccountRepository) GetBalanceSumBySubtype(ctx context.Context, userID i
 
 // SoftRemove sets removed_at on an account that is not already removed
 func (r *AccountRepository) SoftRemove(ctx context.Context, id string) error {
-	query := `UPDATE accounts SET removed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND removed_at IS NULL`
+	query := `UPDATE accounts SET removed_at = CURRENT_TIMESTAMP, removed_by_provider = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND removed_at IS NULL`
 
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -685,7 +765,7 @@ func (r *AccountRepository) SoftRemove(ctx context.Context, id string) error {
 
 // Restore clears removed_at on an account that is currently removed
 func (r *AccountRepository) Restore(ctx context.Context, id string) error {
-	query := `UPDATE accounts SET removed_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND removed_at IS NOT NULL`
+	query := `UPDATE accounts SET removed_at = NULL, removed_by_provider = false, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND removed_at IS NOT NULL`
 
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -720,7 +800,7 @@ func (r *AccountRepository) DeleteByItemID(ctx context.Context, itemID string) e
 func (r *AccountRepository) ListByItemID(ctx context.Context, itemID string) ([]*account.Account, error) {
 	query := `
 		SELECT id, user_id, item_id, name, account_type, subtype, currency, balance, bank_id,
-		       provider_updated_at, provider_created_at, created_at, updated_at
+		       provider_updated_at, provider_created_at, created_at, updated_at, removed_at
 		FROM accounts
 		WHERE item_id = $1
 	`
@@ -736,13 +816,13 @@ func (r *AccountRepository) ListByItemID(ctx context.Context, itemID string) ([]
 		var acc account.Account
 		var itemIDOut, subtype sql.NullString
 		var bankID sql.NullInt64
-		var providerUpdatedAt, providerCreatedAt sql.NullTime
+		var providerUpdatedAt, providerCreatedAt, removedAt sql.NullTime
 
 		err := rows.Scan(
 			&acc.ID, &acc.UserID, &itemIDOut, &acc.Name,
 			&acc.AccountType, &subtype, &acc.Currency, &acc.Balance,
 			&bankID, &providerUpdatedAt, &providerCreatedAt,
-			&acc.CreatedAt, &acc.UpdatedAt,
+			&acc.CreatedAt, &acc.UpdatedAt, &removedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan account: %w", err)
@@ -763,6 +843,9 @@ func (r *AccountRepository) ListByItemID(ctx context.Context, itemID string) ([]
 		if providerCreatedAt.Valid {
 			acc.ProviderCreatedAt = providerCreatedAt.Time
 		}
+		if removedAt.Valid {
+			acc.RemovedAt = &removedAt.Time
+		}
 
 		accounts = append(accounts, &acc)
 	}
@@ -774,6 +857,155 @@ func (r *AccountRepository) ListByItemID(ctx context.Context, itemID string) ([]
 	return accounts, nil
 }
 
+// MarkRemoved sets removed_at on accounts that are no longer reported by the provider.
+// closed_at is only filled when not already set so a provider-reported closing date is kept.
+func (r *AccountRepository) MarkRemoved(ctx context.Context, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		UPDATE accounts
+		SET removed_at = CURRENT_TIMESTAMP,
+		    closed_at = COALESCE(closed_at, CURRENT_TIMESTAMP),
+		    removed_by_provider = true,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1) AND removed_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark accounts removed: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows, nil
+}
+
+// RestoreProviderRemoved clears removed_at on an account MarkRemoved removed. closed_at is
+// cleared too when MarkRemoved set it, which it did in the same statement as removed_at.
+func (r *AccountRepository) RestoreProviderRemoved(ctx context.Context, id string) (bool, error) {
+	query := `
+		UPDATE accounts
+		SET removed_at = NULL,
+		    closed_at = CASE WHEN closed_at = removed_at THEN NULL ELSE closed_at END,
+		    removed_by_provider = false,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND removed_at IS NOT NULL AND removed_by_provider = true
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore provider-removed account: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// mergeLockAccountsQuery locks both accounts of a merge and returns how many distinct users own
+// them. It returns no row unless both accounts exist, so a missing account surfaces as
+// sql.ErrNoRows rather than a count of one.
+const mergeLockAccountsQuery = `
+		SELECT COUNT(DISTINCT user_id) FROM (
+			SELECT user_id FROM accounts WHERE id IN ($1, $2) FOR UPDATE
+		) locked
+		HAVING COUNT(*) = 2
+	`
+
+// mergeAccountFieldsQuery copies the user-set fields of the duplicate ($1) onto the canonical
+// account ($2) wherever the canonical account still holds the column default.
+const mergeAccountFieldsQuery = `
+		UPDATE accounts c SET
+			description = COALESCE(NULLIF(c.description, ''), d.description),
+			"order" = CASE WHEN c."order" = 90 THEN d."order" ELSE c."order" END,
+			hidden_by_user = c.hidden_by_user OR d.hidden_by_user,
+			initial_balance = CASE WHEN c.initial_balance = 0 THEN d.initial_balance ELSE c.initial_balance END,
+			updated_at = CURRENT_TIMESTAMP
+		FROM accounts d
+		WHERE d.id = $1 AND c.id = $2
+	`
+
+// MergeInto moves the duplicate account's transactions, bills and forecasts to the canonical
+// account, carries over the user-set fields the canonical account lacks and deletes the
+// duplicate in a single transaction. Both rows are locked first so a concurrent sync cannot
+// write to the duplicate mid-merge.
+func (r *AccountRepository) MergeInto(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error) {
+	var counts account.MergeCounts
+	if duplicateID == canonicalID {
+		return counts, fmt.Errorf("cannot merge account %s into itself", duplicateID)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return counts, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var owners int
+	err = tx.QueryRowContext(ctx, mergeLockAccountsQuery, duplicateID, canonicalID).Scan(&owners)
+	if err == sql.ErrNoRows {
+		return counts, account.ErrAccountNotFound
+	}
+	if err != nil {
+		return counts, fmt.Errorf("failed to lock accounts: %w", err)
+	}
+	if owners != 1 {
+		return counts, fmt.Errorf("accounts %s and %s belong to different users", duplicateID, canonicalID)
+	}
+
+	if _, err := tx.ExecContext(ctx, mergeAccountFieldsQuery, duplicateID, canonicalID); err != nil {
+		return counts, fmt.Errorf("failed to copy account fields: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE transactions SET account_id = $2, updated_at = CURRENT_TIMESTAMP WHERE account_id = $1`,
+		duplicateID, canonicalID,
+	)
+	if err != nil {
+		return counts, fmt.Errorf("failed to move transactions: %w", err)
+	}
+	if counts.Transactions, err = result.RowsAffected(); err != nil {
+		return counts, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx,
+		`UPDATE bills SET account_id = $2, updated_at = CURRENT_TIMESTAMP WHERE account_id = $1`,
+		duplicateID, canonicalID,
+	)
+	if err != nil {
+		return counts, fmt.Errorf("failed to move bills: %w", err)
+	}
+	if counts.Bills, err = result.RowsAffected(); err != nil {
+		return counts, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE forecast_transactions SET account_id = $2 WHERE account_id = $1`,
+		duplicateID, canonicalID,
+	); err != nil {
+		return counts, fmt.Errorf("failed to move forecasts: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1`, duplicateID); err != nil {
+		return counts, fmt.Errorf("failed to delete duplicate account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return counts, fmt.Errorf("failed to commit account merge: %w", err)
+	}
+
+	return counts, nil
+}
+
 // DeleteBankData atomically deletes all transactions for the item's accounts,
 // deletes the accounts, and soft-deletes the item in a single transaction.
 func (r *AccountRepository) DeleteBankData(ctx context.Context, itemID string) error {
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeLockAccountsQuery(t *testing.T) {
+	query := strings.Join(strings.Fields(mergeLockAccountsQuery), " ")
+
+	for _, want := range []string{
+		// both rows are locked before anything is moved
+		"WHERE id IN ($1, $2) FOR UPDATE",
+		// ownership is checked across both rows
+		"SELECT COUNT(DISTINCT user_id)",
+		// a missing account yields no row instead of a count of one
+		"HAVING COUNT(*) = 2",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("mergeLockAccountsQuery missing %q:\n%s", want, query)
+		}
+	}
+}
+
+func TestMergeAccountFieldsQuery(t *testing.T) {
+	query := strings.Join(strings.Fields(mergeAccountFieldsQuery), " ")
+
+	for _, want := range []string{
+		"WHERE d.id = $1 AND c.id = $2",
+		"description = COALESCE(NULLIF(c.description, ''), d.description)",
+		`"order" = CASE WHEN c."order" = 90 THEN d."order" ELSE c."order" END`,
+		"hidden_by_user = c.hidden_by_user OR d.hidden_by_user",
+		"initial_balance = CASE WHEN c.initial_balance = 0 THEN d.initial_balance ELSE c.initial_balance END",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("mergeAccountFieldsQuery missing %q:\n%s", want, query)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"parsa/internal/domain/attachment"
+
+	"github.com/lib/pq"
+)
+
+const attachmentColumns = `id, transaction_id, user_id, filename, content_type, size_bytes, storage_key, created_at`
+
+// attachmentLockClass namespaces the per-transaction attachment locks among Postgres advisory locks
+const attachmentLockClass = 0x41544348 // "ATCH"
+
+type AttachmentRepository struct {
+	db *DB
+}
+
+func NewAttachmentRepository(db *DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, params attachment.CreateAttachmentParams) (*attachment.Attachment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialize uploads to the same transaction so concurrent ones can't both pass the cap
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, attachmentLockClass, params.TransactionID); err != nil {
+		return nil, fmt.Errorf("failed to lock transaction attachments: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM attachments WHERE transaction_id = $1`, params.TransactionID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	if params.MaxPerTransaction > 0 && count >= params.MaxPerTransaction {
+		return nil, attachment.ErrTooManyAttachments
+	}
+
+	query := `
+		INSERT INTO attachments (transaction_id, user_id, filename, content_type, size_bytes, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + attachmentColumns
+
+	a, err := scanAttachment(tx.QueryRowContext(ctx, query,
+		params.TransactionID, params.UserID, params.Filename, params.ContentType, params.SizeBytes, params.StorageKey,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *AttachmentRepository) GetByID(ctx context.Context, id int64) (*attachment.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1`
+
+	a, err := scanAttachment(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *AttachmentRepository) ListByTransactionID(ctx context.Context, transactionID string) ([]*attachment.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE transaction_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []*attachment.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) CountByTransactionID(ctx context.Context, transactionID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM attachments WHERE transaction_id = $1`, transactionID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count attachments: %w", err)
+	}
+	return count, nil
+}
+
+func (r *AttachmentRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return attachment.ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+func (r *AttachmentRepository) ListDeletedBlobKeys(ctx context.Context, limit int) ([]string, error) {
+	query := `
+		SELECT storage_key
+		FROM attachment_blob_deletions
+		ORDER BY deleted_at ASC
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted attachment blobs: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted attachment blob: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted attachment blobs: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *AttachmentRepository) ForgetDeletedBlobKeys(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM attachment_blob_deletions WHERE storage_key = ANY($1)`, pq.Array(keys))
+	if err != nil {
+		return fmt.Errorf("failed to forget deleted attachment blobs: %w", err)
+	}
+	return nil
+}
+
+// scanAttachment scans a single attachment row from a *sql.Row or *sql.Rows
+func scanAttachment(row interface{ Scan(dest ...any) error }) (*attachment.Attachment, error) {
+	var a attachment.Attachment
+	if err := row.Scan(&a.ID, &a.TransactionID, &a.UserID, &a.Filename, &a.ContentType, &a.SizeBytes, &a.StorageKey, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
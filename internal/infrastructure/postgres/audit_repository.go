@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"parsa/internal/domain/audit"
+
+	"github.com/lib/pq"
+)
+
+type AuditRepository struct {
+	db *DB
+}
+
+func NewAuditRepository(db *DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *audit.Entry) error {
+	changes := entry.Changes
+	if changes == nil {
+		changes = map[string]audit.Change{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (user_id, entity_type, entity_id, action, source, changes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query,
+		entry.UserID, entry.EntityType, entry.EntityID, entry.Action, auditSource(entry), changesJSON,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBatch inserts all entries with one statement by unnesting parallel arrays, which
+// avoids the bind parameter limit of a multi-row VALUES list
+func (r *AuditRepository) CreateBatch(ctx context.Context, entries []*audit.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, len(entries))
+	entityTypes := make([]string, len(entries))
+	entityIDs := make([]string, len(entries))
+	actions := make([]string, len(entries))
+	sources := make([]string, len(entries))
+	changes := make([]string, len(entries))
+	for i, entry := range entries {
+		entryChanges := entry.Changes
+		if entryChanges == nil {
+			entryChanges = map[string]audit.Change{}
+		}
+		changesJSON, err := json.Marshal(entryChanges)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit changes: %w", err)
+		}
+		userIDs[i] = entry.UserID
+		entityTypes[i] = entry.EntityType
+		entityIDs[i] = entry.EntityID
+		actions[i] = entry.Action
+		sources[i] = auditSource(entry)
+		changes[i] = string(changesJSON)
+	}
+
+	query := `
+		INSERT INTO audit_log (user_id, entity_type, entity_id, action, source, changes)
+		SELECT e.user_id, e.entity_type, e.entity_id, e.action, e.source, e.changes::jsonb
+		FROM unnest($1::bigint[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[])
+			AS e(user_id, entity_type, entity_id, action, source, changes)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		pq.Array(userIDs), pq.Array(entityTypes), pq.Array(entityIDs), pq.Array(actions), pq.Array(sources), pq.Array(changes),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entries: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepository) ListByEntity(ctx context.Context, entityType, entityID string, limit int) ([]*audit.Entry, error) {
+	query := `
+		SELECT id, user_id, entity_type, entity_id, action, source, changes, created_at
+		FROM audit_log
+		WHERE entity_type = $1 AND entity_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, entityType, entityID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*audit.Entry{}
+	for rows.Next() {
+		var e audit.Entry
+		var changesBytes []byte
+
+		if err := rows.Scan(&e.ID, &e.UserID, &e.EntityType, &e.EntityID, &e.Action, &e.Source, &changesBytes, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+
+		if len(changesBytes) > 0 {
+			if err := json.Unmarshal(changesBytes, &e.Changes); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit changes: %w", err)
+			}
+		}
+
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// auditSource returns the entry's source, defaulting to a user edit
+func auditSource(entry *audit.Entry) string {
+	if entry.Source == "" {
+		return audit.SourceUser
+	}
+	return entry.Source
+}
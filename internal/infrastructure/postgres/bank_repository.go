@@ -16,70 +16,59 @@ func NewBankRepository(db *DB) *BankRepository {
 	return &BankRepository{db: db}
 }
 
-// FindOrCreateByConnector finds a bank by connector code or creates it if it doesn't exist
-func (r *BankRepository) FindOrCreateByConnector(ctx context.Context, name, connector string) (*models.Bank, error) {
-	// Try to find existing bank by connector
-	query := `
-		SELECT id, name, ui_name, connector, primary_color
-		FROM banks
-		WHERE connector = $1
-	`
+// bankColumns is the column list read by scanBank
+const bankColumns = `id, name, ui_name, connector, primary_color, logo_url`
 
+// scanBank scans a row selected with bankColumns
+func scanBank(row interface{ Scan(dest ...any) error }) (*models.Bank, error) {
 	var bank models.Bank
-	var uiName, primaryColor sql.NullString
-	err := r.db.QueryRowContext(ctx, query, connector).Scan(
-		&bank.ID, &bank.Name, &uiName, &bank.Connector, &primaryColor,
-	)
-
-	if err == nil {
-		bank.UIName = uiName.String
-		bank.PrimaryColor = primaryColor.String
-		return &bank, nil
+	var uiName, connector, primaryColor, logoURL sql.NullString
+	if err := row.Scan(&bank.ID, &bank.Name, &uiName, &connector, &primaryColor, &logoURL); err != nil {
+		return nil, err
 	}
+	bank.UIName = uiName.String
+	bank.Connector = connector.String
+	bank.PrimaryColor = primaryColor.String
+	bank.LogoURL = logoURL.String
+	return &bank, nil
+}
 
-	if err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to query bank: %w", err)
+// FindOrCreateByConnector finds a bank by connector code or creates it if it doesn't exist
+func (r *BankRepository) FindOrCreateByConnector(ctx context.Context, name, connector string) (*models.Bank, error) {
+	// Try to find existing bank by connector
+	bank, err := r.FindByConnector(ctx, connector)
+	if err != nil {
+		return nil, err
+	}
+	if bank != nil {
+		return bank, nil
 	}
 
 	// Bank not found, create it
 	insertQuery := `
 		INSERT INTO banks (name, connector)
 		VALUES ($1, $2)
-		RETURNING id, name, ui_name, connector, primary_color
-	`
-
-	err = r.db.QueryRowContext(ctx, insertQuery, name, connector).Scan(
-		&bank.ID, &bank.Name, &uiName, &bank.Connector, &primaryColor,
-	)
+		RETURNING ` + bankColumns
 
+	bank, err = scanBank(r.db.QueryRowContext(ctx, insertQuery, name, connector))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bank: %w", err)
 	}
 
-	bank.UIName = uiName.String
-	bank.PrimaryColor = primaryColor.String
-	return &bank, nil
+	return bank, nil
 }
 
 // FindOrCreateByName finds a bank by name or creates it if it doesn't exist
 func (r *BankRepository) FindOrCreateByName(ctx context.Context, name string) (*models.Bank, error) {
 	query := `
-		SELECT id, name, ui_name, connector, primary_color
+		SELECT ` + bankColumns + `
 		FROM banks
 		WHERE name = $1
 	`
 
-	var bank models.Bank
-	var uiName, connector, primaryColor sql.NullString
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
-		&bank.ID, &bank.Name, &uiName, &connector, &primaryColor,
-	)
-
+	bank, err := scanBank(r.db.QueryRowContext(ctx, query, name))
 	if err == nil {
-		bank.UIName = uiName.String
-		bank.Connector = connector.String
-		bank.PrimaryColor = primaryColor.String
-		return &bank, nil
+		return bank, nil
 	}
 
 	if err != sql.ErrNoRows {
@@ -90,37 +79,25 @@ func (r *BankRepository) FindOrCreateByName(ctx context.Context, name string) (*
 	insertQuery := `
 		INSERT INTO banks (name)
 		VALUES ($1)
-		RETURNING id, name, ui_name, connector, primary_color
-	`
-
-	err = r.db.QueryRowContext(ctx, insertQuery, name).Scan(
-		&bank.ID, &bank.Name, &uiName, &connector, &primaryColor,
-	)
+		RETURNING ` + bankColumns
 
+	bank, err = scanBank(r.db.QueryRowContext(ctx, insertQuery, name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bank: %w", err)
 	}
 
-	bank.UIName = uiName.String
-	bank.Connector = connector.String
-	bank.PrimaryColor = primaryColor.String
-	return &bank, nil
+	return bank, nil
 }
 
 // GetByID retrieves a bank by its ID
 func (r *BankRepository) GetByID(ctx context.Context, id int64) (*models.Bank, error) {
 	query := `
-		SELECT id, name, ui_name, connector, primary_color
+		SELECT ` + bankColumns + `
 		FROM banks
 		WHERE id = $1
 	`
 
-	var bank models.Bank
-	var uiName, connector, primaryColor sql.NullString
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&bank.ID, &bank.Name, &uiName, &connector, &primaryColor,
-	)
-
+	bank, err := scanBank(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("bank not found")
 	}
@@ -128,16 +105,13 @@ func (r *BankRepository) GetByID(ctx context.Context, id int64) (*models.Bank, e
 		return nil, fmt.Errorf("failed to get bank: %w", err)
 	}
 
-	bank.UIName = uiName.String
-	bank.Connector = connector.String
-	bank.PrimaryColor = primaryColor.String
-	return &bank, nil
+	return bank, nil
 }
 
 // List retrieves all banks
 func (r *BankRepository) List(ctx context.Context) ([]*models.Bank, error) {
 	query := `
-		SELECT id, name, ui_name, connector, primary_color
+		SELECT ` + bankColumns + `
 		FROM banks
 		ORDER BY name
 	`
@@ -150,16 +124,11 @@ func (r *BankRepository) List(ctx context.Context) ([]*models.Bank, error) {
 
 	var banks []*models.Bank
 	for rows.Next() {
-		var bank models.Bank
-		var uiName, connector, primaryColor sql.NullString
-		err := rows.Scan(&bank.ID, &bank.Name, &uiName, &connector, &primaryColor)
+		bank, err := scanBank(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan bank: %w", err)
 		}
-		bank.UIName = uiName.String
-		bank.Connector = connector.String
-		bank.PrimaryColor = primaryColor.String
-		banks = append(banks, &bank)
+		banks = append(banks, bank)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -168,3 +137,126 @@ func (r *BankRepository) List(ctx context.Context) ([]*models.Bank, error) {
 
 	return banks, nil
 }
+
+// FindByConnector retrieves a bank by connector code, or nil if none exists
+func (r *BankRepository) FindByConnector(ctx context.Context, connector string) (*models.Bank, error) {
+	query := `
+		SELECT ` + bankColumns + `
+		FROM banks
+		WHERE connector = $1
+		ORDER BY id
+		LIMIT 1
+	`
+
+	bank, err := scanBank(r.db.QueryRowContext(ctx, query, connector))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bank: %w", err)
+	}
+
+	return bank, nil
+}
+
+// ApplyBranding sets a bank's primary color and logo URL. Empty values leave the
+// current ones untouched.
+func (r *BankRepository) ApplyBranding(ctx context.Context, id int64, primaryColor, logoURL string) error {
+	query := `
+		UPDATE banks
+		SET primary_color = COALESCE(NULLIF($2, ''), primary_color),
+		    logo_url = COALESCE(NULLIF($3, ''), logo_url),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		  AND (primary_color IS DISTINCT FROM COALESCE(NULLIF($2, ''), primary_color)
+		       OR logo_url IS DISTINCT FROM COALESCE(NULLIF($3, ''), logo_url))
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, primaryColor, logoURL); err != nil {
+		return fmt.Errorf("failed to apply bank branding: %w", err)
+	}
+	return nil
+}
+
+// SetConnector sets the connector code of a bank that has none yet.
+// Returns false when the bank already has a connector.
+func (r *BankRepository) SetConnector(ctx context.Context, id int64, connector string) (bool, error) {
+	query := `
+		UPDATE banks
+		SET connector = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (connector IS NULL OR connector = '')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, connector)
+	if err != nil {
+		return false, fmt.Errorf("failed to set bank connector: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ResolvePlaceholderName gives a bank created from a connector code alone (its name is the
+// code) its real name. If another bank without a connector already has that name, the two
+// are merged: the named bank takes the connector and the placeholder's accounts, and the
+// placeholder is deleted. Returns the bank the accounts end up linked to.
+func (r *BankRepository) ResolvePlaceholderName(ctx context.Context, id int64, name string) (*models.Bank, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var currentName string
+	var connector sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT name, connector FROM banks WHERE id = $1 FOR UPDATE`, id).Scan(&currentName, &connector)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("bank not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bank: %w", err)
+	}
+
+	targetID := id
+	placeholder := connector.Valid && connector.String != "" && currentName == connector.String
+	if placeholder && name != currentName {
+		var namedID int64
+		var namedConnector sql.NullString
+		err = tx.QueryRowContext(ctx, `SELECT id, connector FROM banks WHERE name = $1 FOR UPDATE`, name).Scan(&namedID, &namedConnector)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE banks SET name = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id, name,
+			); err != nil {
+				return nil, fmt.Errorf("failed to rename bank: %w", err)
+			}
+		case err != nil:
+			return nil, fmt.Errorf("failed to query bank: %w", err)
+		case !namedConnector.Valid || namedConnector.String == "":
+			// Merge the placeholder into the bank that already has the name
+			if _, err := tx.ExecContext(ctx, `UPDATE accounts SET bank_id = $2, updated_at = CURRENT_TIMESTAMP WHERE bank_id = $1`, id, namedID); err != nil {
+				return nil, fmt.Errorf("failed to relink accounts: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM banks WHERE id = $1`, id); err != nil {
+				return nil, fmt.Errorf("failed to delete placeholder bank: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE banks SET connector = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, namedID, connector.String,
+			); err != nil {
+				return nil, fmt.Errorf("failed to set bank connector: %w", err)
+			}
+			targetID = namedID
+		}
+		// A bank with the name and a different connector is a different bank; keep both
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, targetID)
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"parsa/internal/domain/cousin"
+)
+
+// cousinLockClass namespaces the per-user cousin lookup locks among Postgres advisory locks
+const cousinLockClass = 0x434f5553 // "COUS"
+
+// findCousinForUserQuery prefers a cousin of the document, then the user's own cousins over
+// shared ones, then the oldest
+const findCousinForUserQuery = `
+	SELECT id, user_id, name, business_name, document_id, created_at, updated_at
+	FROM cousins
+	WHERE ($3::bigint IS NOT NULL AND document_id = $3 AND (user_id = $1 OR user_id IS NULL))
+		OR (user_id = $1 AND lower(name) = lower($2))
+	ORDER BY COALESCE(document_id = $3, false) DESC, user_id IS NULL, id
+	LIMIT 1
+`
+
+type CousinRepository struct {
+	db *DB
+}
+
+func NewCousinRepository(db *DB) *CousinRepository {
+	return &CousinRepository{db: db}
+}
+
+func (r *CousinRepository) Create(ctx context.Context, userID int64, params cousin.CreateCousinParams) (*cousin.Cousin, error) {
+	query := `
+		INSERT INTO cousins (user_id, name, business_name)
+		VALUES ($1, $2, $3)
+		RETURNING id, user_id, name, business_name, document_id, created_at, updated_at
+	`
+
+	c, err := scanCousin(r.db.QueryRowContext(ctx, query, userID, params.Name, params.BusinessName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cousin: %w", err)
+	}
+
+	return c, nil
+}
+
+// FindOrCreate runs under a transaction-level advisory lock on the user, so concurrent
+// lookups for the same pair wait for each other instead of each inserting a cousin. User IDs
+// are truncated to 32 bits for the lock key; a collision only serializes two users' lookups.
+func (r *CousinRepository) FindOrCreate(ctx context.Context, userID int64, name string, documentID *int64) (*cousin.Cousin, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, cousinLockClass, int32(userID)); err != nil {
+		return nil, fmt.Errorf("failed to lock cousins of user: %w", err)
+	}
+
+	c, err := scanCousin(tx.QueryRowContext(ctx, findCousinForUserQuery, userID, name, documentID))
+	if err == sql.ErrNoRows {
+		insertQuery := `
+			INSERT INTO cousins (user_id, name, document_id)
+			VALUES ($1, $2, $3)
+			RETURNING id, user_id, name, business_name, document_id, created_at, updated_at
+		`
+		c, err = scanCousin(tx.QueryRowContext(ctx, insertQuery, userID, name, documentID))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find or create cousin: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *CousinRepository) GetByID(ctx context.Context, id int64) (*cousin.Cousin, error) {
+	query := `
+		SELECT id, user_id, name, business_name, document_id, created_at, updated_at
+		FROM cousins
+		WHERE id = $1
+	`
+
+	c, err := scanCousin(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cousin: %w", err)
+	}
+
+	return c, nil
+}
+
+func (r *CousinRepository) ListByUserID(ctx context.Context, userID int64) ([]*cousin.Cousin, error) {
+	query := `
+		SELECT id, user_id, name, business_name, document_id, created_at, updated_at
+		FROM cousins
+		WHERE user_id = $1
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cousins: %w", err)
+	}
+	defer rows.Close()
+
+	var cousins []*cousin.Cousin
+	for rows.Next() {
+		c, err := scanCousin(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cousin: %w", err)
+		}
+		cousins = append(cousins, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cousins: %w", err)
+	}
+
+	return cousins, nil
+}
+
+func (r *CousinRepository) Delete(ctx context.Context, id int64) error {
+	// transactions.cousin is SET NULL and user_ck_values rows cascade through their foreign keys
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cousins WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete cousin: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return cousin.ErrCousinNotFound
+	}
+
+	return nil
+}
+
+// scanCousin scans a single cousin row from a *sql.Row or *sql.Rows
+func scanCousin(row interface{ Scan(dest ...any) error }) (*cousin.Cousin, error) {
+	var c cousin.Cousin
+	var userID, documentID sql.NullInt64
+	var businessName sql.NullString
+
+	if err := row.Scan(&c.ID, &userID, &c.Name, &businessName, &documentID, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if userID.Valid {
+		c.UserID = &userID.Int64
+	}
+	if businessName.Valid {
+		c.BusinessName = &businessName.String
+	}
+	if documentID.Valid {
+		c.DocumentID = &documentID.Int64
+	}
+
+	return &c, nil
+}
@@ -7,6 +7,9 @@ import (
 	"strings"
 
 	"parsa/internal/domain/cousinrule"
+	"parsa/internal/domain/transaction"
+
+	"github.com/lib/pq"
 )
 
 type CousinRuleRepository struct {
@@ -180,12 +183,31 @@ func (r *CousinRuleRepository) GetByCousinAndType(ctx context.Context, userID, c
 	return &rule, nil
 }
 
-func (r *CousinRuleRepository) ListByUserID(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error) {
+func (r *CousinRuleRepository) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.CousinRule, error) {
+	// id breaks created_at ties so pages never overlap
 	query := `
 		SELECT id, user_id, cousin_id, type, category, description, notes, considered, dont_ask_again, created_at, updated_at
 		FROM user_ck_values
 		WHERE user_id = $1
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cousin rules: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCousinRules(rows)
+}
+
+func (r *CousinRuleRepository) ListAllByUserID(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error) {
+	query := `
+		SELECT id, user_id, cousin_id, type, category, description, notes, considered, dont_ask_again, created_at, updated_at
+		FROM user_ck_values
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, userID)
@@ -197,6 +219,62 @@ func (r *CousinRuleRepository) ListByUserID(ctx context.Context, userID int64) (
 	return scanCousinRules(rows)
 }
 
+func (r *CousinRuleRepository) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	query := `SELECT COUNT(*) FROM user_ck_values WHERE user_id = $1`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count cousin rules: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *CousinRuleRepository) ListByUserIDWithCounts(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.RuleWithCount, error) {
+	// Counts use the same match condition as apply/preview. A rule without a type counts only the
+	// types no type-specific rule of the cousin covers, as those take precedence over it.
+	query := `
+		SELECT r.id, r.user_id, r.cousin_id, r.type, r.category, r.description, r.notes, r.considered,
+		       r.dont_ask_again, r.created_at, r.updated_at,
+		       (
+		           SELECT COUNT(*)
+		           FROM transactions t
+		           JOIN accounts a ON t.account_id = a.id
+		           WHERE ` + cousinMatchCondition("r.cousin_id", "r.user_id", "") + `
+		             AND (t.type = r.type OR (r.type IS NULL AND NOT EXISTS (
+		                 SELECT 1 FROM user_ck_values s
+		                 WHERE s.user_id = r.user_id AND s.cousin_id = r.cousin_id AND s.type = t.type
+		             )))
+		       ) AS transaction_count
+		FROM user_ck_values r
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cousin rules with counts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*cousinrule.RuleWithCount
+	for rows.Next() {
+		var count int64
+		rule, err := scanCousinRuleRow(rows, &count)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &cousinrule.RuleWithCount{Rule: rule, TransactionCount: count})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cousin rules: %w", err)
+	}
+
+	return results, nil
+}
+
 func (r *CousinRuleRepository) ListByCousinID(ctx context.Context, userID, cousinID int64) ([]*cousinrule.CousinRule, error) {
 	query := `
 		SELECT id, user_id, cousin_id, type, category, description, notes, considered, dont_ask_again, created_at, updated_at
@@ -214,40 +292,34 @@ func (r *CousinRuleRepository) ListByCousinID(ctx context.Context, userID, cousi
 	return scanCousinRules(rows)
 }
 
+func (r *CousinRuleRepository) ListByCousinIDs(ctx context.Context, userID int64, cousinIDs []int64) ([]*cousinrule.CousinRule, error) {
+	if len(cousinIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, user_id, cousin_id, type, category, description, notes, considered, dont_ask_again, created_at, updated_at
+		FROM user_ck_values
+		WHERE user_id = $1 AND cousin_id = ANY($2)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(cousinIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cousin rules by cousins: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCousinRules(rows)
+}
+
 func scanCousinRules(rows *sql.Rows) ([]*cousinrule.CousinRule, error) {
 	var rules []*cousinrule.CousinRule
 	for rows.Next() {
-		var rule cousinrule.CousinRule
-		var txType, category, description, notes sql.NullString
-		var considered sql.NullBool
-
-		err := rows.Scan(
-			&rule.ID, &rule.UserID, &rule.CousinID, &txType,
-			&category, &description, &notes, &considered,
-			&rule.DontAskAgain, &rule.CreatedAt, &rule.UpdatedAt,
-		)
+		rule, err := scanCousinRuleRow(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan cousin rule: %w", err)
-		}
-
-		if txType.Valid {
-			rule.Type = &txType.String
+			return nil, err
 		}
-		if category.Valid {
-			rule.Category = &category.String
-		}
-		if description.Valid {
-			rule.Description = &description.String
-		}
-		if notes.Valid {
-			rule.Notes = &notes.String
-		}
-		if considered.Valid {
-			rule.Considered = &considered.Bool
-		}
-		rule.Tags = []string{}
-
-		rules = append(rules, &rule)
+		rules = append(rules, rule)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -257,6 +329,41 @@ func scanCousinRules(rows *sql.Rows) ([]*cousinrule.CousinRule, error) {
 	return rules, nil
 }
 
+// scanCousinRuleRow scans the standard rule columns followed by any extra columns into extra
+func scanCousinRuleRow(rows *sql.Rows, extra ...any) (*cousinrule.CousinRule, error) {
+	var rule cousinrule.CousinRule
+	var txType, category, description, notes sql.NullString
+	var considered sql.NullBool
+
+	dest := []any{
+		&rule.ID, &rule.UserID, &rule.CousinID, &txType,
+		&category, &description, &notes, &considered,
+		&rule.DontAskAgain, &rule.CreatedAt, &rule.UpdatedAt,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return nil, fmt.Errorf("failed to scan cousin rule: %w", err)
+	}
+
+	if txType.Valid {
+		rule.Type = &txType.String
+	}
+	if category.Valid {
+		rule.Category = &category.String
+	}
+	if description.Valid {
+		rule.Description = &description.String
+	}
+	if notes.Valid {
+		rule.Notes = &notes.String
+	}
+	if considered.Valid {
+		rule.Considered = &considered.Bool
+	}
+	rule.Tags = []string{}
+
+	return &rule, nil
+}
+
 func (r *CousinRuleRepository) Update(ctx context.Context, id int64, params cousinrule.UpdateCousinRuleParams) (*cousinrule.CousinRule, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -334,6 +441,94 @@ func (r *CousinRuleRepository) Upsert(ctx context.Context, params cousinrule.Cre
 	}
 	defer tx.Rollback()
 
+	rule, wasInserted, err := r.upsertTx(ctx, tx, params)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Set tags if provided
+	if len(params.Tags) > 0 {
+		if err := r.setRuleTagsTx(ctx, tx, rule.ID, params.Tags); err != nil {
+			return nil, false, fmt.Errorf("failed to set rule tags: %w", err)
+		}
+		rule.Tags = params.Tags
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rule, wasInserted, nil
+}
+
+// ImportRules upserts every rule and replaces its tags in a single transaction, so a
+// failing entry leaves none of the import behind
+func (r *CousinRuleRepository) ImportRules(ctx context.Context, rules []cousinrule.CreateCousinRuleParams) (int, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var created, updated int
+	for i, params := range rules {
+		rule, wasInserted, err := r.upsertTx(ctx, tx, params)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to import rule %d: %w", i, err)
+		}
+		tags := params.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		if err := r.setRuleTagsTx(ctx, tx, rule.ID, tags); err != nil {
+			return 0, 0, fmt.Errorf("failed to set tags of imported rule %d: %w", i, err)
+		}
+		if wasInserted {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, updated, nil
+}
+
+// MissingCousinIDs returns the IDs in ids that have no cousin row
+func (r *CousinRuleRepository) MissingCousinIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	query := `
+		SELECT ids.id FROM unnest($1::bigint[]) AS ids(id)
+		WHERE NOT EXISTS (SELECT 1 FROM cousins c WHERE c.id = ids.id)
+		ORDER BY ids.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cousins: %w", err)
+	}
+	defer rows.Close()
+
+	missing := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan cousin id: %w", err)
+		}
+		missing = append(missing, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cousin ids: %w", err)
+	}
+
+	return missing, nil
+}
+
+// upsertTx creates or updates the rule for params' user, cousin and type within an existing
+// transaction, leaving its tags untouched
+func (r *CousinRuleRepository) upsertTx(ctx context.Context, tx *sql.Tx, params cousinrule.CreateCousinRuleParams) (*cousinrule.CousinRule, bool, error) {
 	// Convert *string and *bool to sql.NullString/sql.NullBool for proper NULL handling
 	var typeParam, categoryParam, descriptionParam, notesParam sql.NullString
 	var consideredParam sql.NullBool
@@ -367,7 +562,7 @@ func (r *CousinRuleRepository) Upsert(ctx context.Context, params cousinrule.Cre
 		checkArgs = []any{params.UserID, params.CousinID, *params.Type}
 	}
 
-	err = tx.QueryRowContext(ctx, checkQuery, checkArgs...).Scan(&existingID)
+	err := tx.QueryRowContext(ctx, checkQuery, checkArgs...).Scan(&existingID)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, false, fmt.Errorf("failed to check existing rule: %w", err)
 	}
@@ -459,18 +654,6 @@ func (r *CousinRuleRepository) Upsert(ctx context.Context, params cousinrule.Cre
 	}
 	rule.Tags = []string{}
 
-	// Set tags if provided
-	if len(params.Tags) > 0 {
-		if err := r.setRuleTagsTx(ctx, tx, rule.ID, params.Tags); err != nil {
-			return nil, false, fmt.Errorf("failed to set rule tags: %w", err)
-		}
-		rule.Tags = params.Tags
-	}
-
-	if err := tx.Commit(); err != nil {
-		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return &rule, wasInserted, nil
 }
 
@@ -569,10 +752,35 @@ func (r *CousinRuleRepository) GetRuleTags(ctx context.Context, ruleID int64) ([
 	return tagIDs, nil
 }
 
-func (r *CousinRuleRepository) ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) (int, error) {
+// cousinMatchClause builds the WHERE conditions selecting a user's transactions with a cousin
+// and optional type, numbering placeholders from startIndex. Apply and preview share it so
+// a preview always lists exactly the transactions an apply would change.
+func cousinMatchClause(userID, cousinID int64, txType *string, startIndex int) (string, []any) {
+	args := []any{cousinID, userID}
+	typeExpr := ""
+	if txType != nil {
+		typeExpr = fmt.Sprintf("$%d", startIndex+2)
+		args = append(args, *txType)
+	}
+
+	return cousinMatchCondition(fmt.Sprintf("$%d", startIndex), fmt.Sprintf("$%d", startIndex+1), typeExpr), args
+}
+
+// cousinMatchCondition is the condition behind cousinMatchClause with the cousin, user and
+// type given as SQL expressions (placeholders or columns). An empty typeExpr matches both types.
+// Transactions pointing at a cousin that no longer exists never match.
+func cousinMatchCondition(cousinExpr, userExpr, typeExpr string) string {
+	condition := fmt.Sprintf("t.cousin = %s AND a.user_id = %s AND t.removed_at IS NULL AND EXISTS (SELECT 1 FROM cousins c WHERE c.id = t.cousin)", cousinExpr, userExpr)
+	if typeExpr != "" {
+		condition += fmt.Sprintf(" AND t.type = %s", typeExpr)
+	}
+	return condition
+}
+
+func (r *CousinRuleRepository) ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) ([]string, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
@@ -603,75 +811,52 @@ func (r *CousinRuleRepository) ApplyRuleToTransactions(ctx context.Context, user
 
 	if changes.Considered != nil {
 		setClauses = append(setClauses, fmt.Sprintf("considered = $%d", argIndex))
+		setClauses = append(setClauses, "considered_reason = 'USER'")
 		args = append(args, *changes.Considered)
 		argIndex++
 	}
 
 	// Add WHERE clause parameters
-	args = append(args, cousinID, userID)
-	cousinArgIndex := argIndex
-	userArgIndex := argIndex + 1
+	matchSQL, matchArgs := cousinMatchClause(userID, cousinID, txType, argIndex)
+	args = append(args, matchArgs...)
 
 	query := fmt.Sprintf(`
 		UPDATE transactions t
 		SET %s
 		FROM accounts a
 		WHERE t.account_id = a.id
-		  AND t.cousin = $%d
-		  AND a.user_id = $%d
-	`, strings.Join(setClauses, ", "), cousinArgIndex, userArgIndex)
-
-	if txType != nil {
-		args = append(args, *txType)
-		query += fmt.Sprintf(" AND t.type = $%d", userArgIndex+1)
-	}
+		  AND %s
+		RETURNING t.id
+	`, strings.Join(setClauses, ", "), matchSQL)
 
-	result, err := tx.ExecContext(ctx, query, args...)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to update transactions: %w", err)
+		return nil, fmt.Errorf("failed to update transactions: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	txnIDs := []string{}
+	for rows.Next() {
+		var txnID string
+		if err := rows.Scan(&txnID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan transaction ID: %w", err)
+		}
+		txnIDs = append(txnIDs, txnID)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating updated transactions: %w", err)
 	}
+	rows.Close()
 
 	// Apply tags if specified (nil = don't change, empty = clear, non-empty = set)
 	if changes.Tags != nil {
-		// Get all transaction IDs that match
-		selectQuery := `
-			SELECT t.id FROM transactions t
-			JOIN accounts a ON t.account_id = a.id
-			WHERE t.cousin = $1 AND a.user_id = $2
-		`
-		selectArgs := []any{cousinID, userID}
-		if txType != nil {
-			selectQuery += " AND t.type = $3"
-			selectArgs = append(selectArgs, *txType)
-		}
-
-		rows, err := tx.QueryContext(ctx, selectQuery, selectArgs...)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get transaction IDs: %w", err)
-		}
-
-		var txnIDs []string
-		for rows.Next() {
-			var txnID string
-			if err := rows.Scan(&txnID); err != nil {
-				rows.Close()
-				return 0, fmt.Errorf("failed to scan transaction ID: %w", err)
-			}
-			txnIDs = append(txnIDs, txnID)
-		}
-		rows.Close()
-
 		// For each transaction, clear existing tags and add new ones
 		for _, txnID := range txnIDs {
 			// Clear existing tags for this transaction
 			_, err = tx.ExecContext(ctx, `DELETE FROM transaction_tags WHERE transaction_id = $1`, txnID)
 			if err != nil {
-				return 0, fmt.Errorf("failed to clear transaction tags: %w", err)
+				return nil, fmt.Errorf("failed to clear transaction tags: %w", err)
 			}
 
 			// Add new tags
@@ -681,37 +866,39 @@ func (r *CousinRuleRepository) ApplyRuleToTransactions(ctx context.Context, user
 					txnID, tagID,
 				)
 				if err != nil {
-					return 0, fmt.Errorf("failed to add tag to transaction: %w", err)
+					return nil, fmt.Errorf("failed to add tag to transaction: %w", err)
 				}
 			}
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return int(rowsAffected), nil
+	return txnIDs, nil
 }
 
-func (r *CousinRuleRepository) CheckDontAskAgain(ctx context.Context, userID, cousinID int64, txType string) (bool, error) {
-	// Check for type-specific rule first, then fall back to type-agnostic rule
+func (r *CousinRuleRepository) ListMatchingTransactions(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error) {
+	matchSQL, args := cousinMatchClause(userID, cousinID, txType, 1)
+
 	query := `
-		SELECT dont_ask_again FROM user_ck_values
-		WHERE user_id = $1 AND cousin_id = $2 AND (type = $3 OR type IS NULL)
-		ORDER BY type NULLS LAST
-		LIMIT 1
+		SELECT t.id, t.account_id, t.amount, t.description, t.category, t.original_description,
+		       t.provider_category_id, t.transaction_date, t.type, t.status,
+		       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
+		       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
+		       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE ` + matchSQL + `
+		ORDER BY t.transaction_date DESC, t.created_at DESC
 	`
 
-	var dontAskAgain bool
-	err := r.db.QueryRowContext(ctx, query, userID, cousinID, txType).Scan(&dontAskAgain)
-
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return false, fmt.Errorf("failed to check dont_ask_again: %w", err)
+		return nil, fmt.Errorf("failed to list matching transactions: %w", err)
 	}
+	defer rows.Close()
 
-	return dontAskAgain, nil
+	return scanTransactions(rows)
 }
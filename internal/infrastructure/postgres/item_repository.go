@@ -30,13 +30,14 @@ func (r *ItemRepository) FindOrCreate(ctx context.Context, id string, userID int
 		INSERT INTO items (id, user_id)
 		VALUES ($1, $2)
 		ON CONFLICT (id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP
-		RETURNING id, user_id, created_at, updated_at, deleted_at
+		RETURNING id, user_id, created_at, updated_at, deleted_at, needs_reconnect, disabled
 	`
 
 	var item models.Item
 	var deletedAt sql.NullTime
 	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(
 		&item.ID, &item.UserID, &item.CreatedAt, &item.UpdatedAt, &deletedAt,
+		&item.NeedsReconnect, &item.Disabled,
 	)
 
 	if err != nil {
@@ -50,10 +51,38 @@ func (r *ItemRepository) FindOrCreate(ctx context.Context, id string, userID int
 	return &item, nil
 }
 
+// GetByID retrieves an item by its ID. Returns nil, nil when the item does not exist.
+func (r *ItemRepository) GetByID(ctx context.Context, id string) (*models.Item, error) {
+	query := `
+		SELECT id, user_id, created_at, updated_at, deleted_at, needs_reconnect, disabled
+		FROM items
+		WHERE id = $1
+	`
+
+	var item models.Item
+	var deletedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&item.ID, &item.UserID, &item.CreatedAt, &item.UpdatedAt, &deletedAt,
+		&item.NeedsReconnect, &item.Disabled,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if deletedAt.Valid {
+		item.DeletedAt = &deletedAt.Time
+	}
+
+	return &item, nil
+}
+
 // ListByUserID retrieves all items for a user
 func (r *ItemRepository) ListByUserID(ctx context.Context, userID int64) ([]*models.Item, error) {
 	query := `
-		SELECT id, user_id, created_at, updated_at, deleted_at
+		SELECT id, user_id, created_at, updated_at, deleted_at, needs_reconnect, disabled
 		FROM items
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -71,6 +100,7 @@ func (r *ItemRepository) ListByUserID(ctx context.Context, userID int64) ([]*mod
 		var deletedAt sql.NullTime
 		err := rows.Scan(
 			&item.ID, &item.UserID, &item.CreatedAt, &item.UpdatedAt, &deletedAt,
+			&item.NeedsReconnect, &item.Disabled,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan item: %w", err)
@@ -88,6 +118,123 @@ func (r *ItemRepository) ListByUserID(ctx context.Context, userID int64) ([]*mod
 	return items, nil
 }
 
+// ListSummariesByUserID aggregates the user's active items with their bank data and
+// the number of non-removed accounts. The bank is taken from the item's first account
+// that has one assigned.
+func (r *ItemRepository) ListSummariesByUserID(ctx context.Context, userID int64) ([]*models.ItemSummary, error) {
+	query := `
+		SELECT
+			i.id, i.user_id, i.created_at, i.updated_at, i.deleted_at, i.needs_reconnect, i.disabled,
+			b.name, b.ui_name, b.connector, b.primary_color,
+			COUNT(a.id) FILTER (WHERE a.removed_at IS NULL) AS account_count
+		FROM items i
+		LEFT JOIN accounts a ON a.item_id = i.id
+		LEFT JOIN banks b ON b.id = (
+			SELECT ab.bank_id FROM accounts ab
+			WHERE ab.item_id = i.id AND ab.bank_id IS NOT NULL
+			ORDER BY ab.created_at ASC
+			LIMIT 1
+		)
+		WHERE i.user_id = $1 AND i.deleted_at IS NULL
+		GROUP BY i.id, b.id
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list item summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []*models.ItemSummary
+	for rows.Next() {
+		var s models.ItemSummary
+		var deletedAt sql.NullTime
+		var bankName, bankUIName, bankConnector, bankPrimaryColor sql.NullString
+		err := rows.Scan(
+			&s.ID, &s.UserID, &s.CreatedAt, &s.UpdatedAt, &deletedAt, &s.NeedsReconnect, &s.Disabled,
+			&bankName, &bankUIName, &bankConnector, &bankPrimaryColor,
+			&s.AccountCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item summary: %w", err)
+		}
+		if deletedAt.Valid {
+			s.DeletedAt = &deletedAt.Time
+		}
+		if bankName.Valid {
+			s.BankName = bankName.String
+		}
+		if bankUIName.Valid {
+			s.BankUIName = bankUIName.String
+		}
+		if bankConnector.Valid {
+			s.BankConnector = bankConnector.String
+		}
+		if bankPrimaryColor.Valid {
+			s.BankPrimaryColor = bankPrimaryColor.String
+		}
+		summaries = append(summaries, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating item summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// Update changes the connection state flags of an item.
+// Only non-nil fields are updated; updated_at is left untouched since it tracks the last sync.
+func (r *ItemRepository) Update(ctx context.Context, id string, params models.UpdateItemParams) (*models.Item, error) {
+	query := `
+		UPDATE items
+		SET needs_reconnect = COALESCE($2, needs_reconnect),
+		    disabled = COALESCE($3, disabled)
+		WHERE id = $1
+		RETURNING id, user_id, created_at, updated_at, deleted_at, needs_reconnect, disabled
+	`
+
+	var needsReconnect, disabled sql.NullBool
+	if params.NeedsReconnect != nil {
+		needsReconnect = sql.NullBool{Bool: *params.NeedsReconnect, Valid: true}
+	}
+	if params.Disabled != nil {
+		disabled = sql.NullBool{Bool: *params.Disabled, Valid: true}
+	}
+
+	var item models.Item
+	var deletedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, id, needsReconnect, disabled).Scan(
+		&item.ID, &item.UserID, &item.CreatedAt, &item.UpdatedAt, &deletedAt,
+		&item.NeedsReconnect, &item.Disabled,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("item not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update item: %w", err)
+	}
+
+	if deletedAt.Valid {
+		item.DeletedAt = &deletedAt.Time
+	}
+
+	return &item, nil
+}
+
+// SetNeedsReconnectByUserID sets needs_reconnect on all of a user's active items.
+// Used when the provider rejects the user's key, which affects every connection.
+func (r *ItemRepository) SetNeedsReconnectByUserID(ctx context.Context, userID int64, value bool) error {
+	query := `UPDATE items SET needs_reconnect = $2 WHERE user_id = $1 AND deleted_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, value); err != nil {
+		return fmt.Errorf("failed to set needs_reconnect for user items: %w", err)
+	}
+
+	return nil
+}
+
 // SoftDelete sets deleted_at on an item
 func (r *ItemRepository) SoftDelete(ctx context.Context, id string) error {
 	query := `UPDATE items SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
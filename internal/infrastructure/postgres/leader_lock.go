@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+)
+
+// leaderLockClass and leaderLockKey identify the scheduler leadership advisory lock
+const (
+	leaderLockClass = 0x4c454144 // "LEAD"
+	leaderLockKey   = 1
+)
+
+// LeaderLock elects one API instance as scheduler leader with a session-level Postgres
+// advisory lock. The leader keeps the lock on a dedicated connection; if the instance dies
+// its session ends, the lock is released and a standby acquires it on its next attempt.
+type LeaderLock struct {
+	db   *DB
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewLeaderLock creates a new advisory-lock based leader elector
+func NewLeaderLock(db *DB) *LeaderLock {
+	return &LeaderLock{db: db}
+}
+
+// TryAcquire reports whether this instance is the leader, acquiring the lock if it is free.
+// A leader whose connection was lost gives up leadership and competes again.
+func (l *LeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		// Still the leader as long as the session holding the lock is alive
+		if _, err := l.conn.ExecContext(ctx, `SELECT 1`); err == nil {
+			return true, nil
+		}
+		l.discard()
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection for leader lock: %w", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, leaderLockClass, leaderLockKey).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return false, fmt.Errorf("failed to acquire leader lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Release gives up leadership
+func (l *LeaderLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, leaderLockClass, leaderLockKey)
+	if err != nil {
+		// Ending the session releases the lock anyway
+		l.discard()
+		return fmt.Errorf("failed to release leader lock: %w", err)
+	}
+	l.conn.Close()
+	l.conn = nil
+	return nil
+}
+
+// discard closes the leader connection without returning it to the pool
+func (l *LeaderLock) discard() {
+	_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+	l.conn.Close()
+	l.conn = nil
+}
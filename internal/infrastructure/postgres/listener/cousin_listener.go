@@ -27,21 +27,21 @@ type CousinNotification struct {
 
 // CousinListener listens for PostgreSQL notifications when transactions get cousins assigned
 type CousinListener struct {
-	connStr        string
-	cousinRuleRepo cousinrule.Repository
-	db             *sql.DB
-	shutdownCh     chan struct{}
-	done           chan struct{}
+	connStr           string
+	cousinRuleService *cousinrule.Service
+	db                *sql.DB
+	shutdownCh        chan struct{}
+	done              chan struct{}
 }
 
 // NewCousinListener creates a new listener for cousin assignment notifications
-func NewCousinListener(connStr string, cousinRuleRepo cousinrule.Repository, db *sql.DB) *CousinListener {
+func NewCousinListener(connStr string, cousinRuleService *cousinrule.Service, db *sql.DB) *CousinListener {
 	return &CousinListener{
-		connStr:        connStr,
-		cousinRuleRepo: cousinRuleRepo,
-		db:             db,
-		shutdownCh:     make(chan struct{}),
-		done:           make(chan struct{}),
+		connStr:           connStr,
+		cousinRuleService: cousinRuleService,
+		db:                db,
+		shutdownCh:        make(chan struct{}),
+		done:              make(chan struct{}),
 	}
 }
 
@@ -183,35 +183,15 @@ func (l *CousinListener) applyCousinRule(ctx context.Context, payload CousinNoti
 }
 
 func (l *CousinListener) findMatchingRule(ctx context.Context, userID, cousinID int64, txType string) (*cousinrule.CousinRule, error) {
-	// Try type-specific rule first
-	rule, err := l.cousinRuleRepo.GetByCousinAndType(ctx, userID, cousinID, &txType)
+	// Same precedence as the API: type-specific rule first, then type-agnostic
+	effective, err := l.cousinRuleService.ResolveEffectiveRule(ctx, userID, cousinID, txType)
 	if err != nil {
 		return nil, err
 	}
-	if rule != nil {
-		// Load tags
-		tags, err := l.cousinRuleRepo.GetRuleTags(ctx, rule.ID)
-		if err != nil {
-			return nil, err
-		}
-		rule.Tags = tags
-		return rule, nil
-	}
-
-	// Fall back to type-agnostic rule
-	rule, err = l.cousinRuleRepo.GetByCousinAndType(ctx, userID, cousinID, nil)
-	if err != nil {
-		return nil, err
+	if effective.Rule != nil {
+		log.Printf("Resolved %s cousin rule %d", effective.Tier, effective.Rule.ID)
 	}
-	if rule != nil {
-		tags, err := l.cousinRuleRepo.GetRuleTags(ctx, rule.ID)
-		if err != nil {
-			return nil, err
-		}
-		rule.Tags = tags
-	}
-
-	return rule, nil
+	return effective.Rule, nil
 }
 
 func (l *CousinListener) applyRuleToTransaction(ctx context.Context, transactionID string, rule *cousinrule.CousinRule) error {
@@ -250,7 +230,7 @@ func (l *CousinListener) applyRuleToTransaction(ctx context.Context, transaction
 	}
 
 	if rule.Considered != nil {
-		query += `, considered = $` + itoa(argIndex)
+		query += `, considered = $` + itoa(argIndex) + `, considered_reason = 'USER'`
 		args = append(args, *rule.Considered)
 		argIndex++
 	}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type StatsRepository struct {
+	db *DB
+}
+
+func NewStatsRepository(db *DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+func (r *StatsRepository) CountAccounts(ctx context.Context, userID int64) (int64, error) {
+	query := `SELECT COUNT(*) FROM accounts WHERE user_id = $1 AND removed_at IS NULL`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count accounts: %w", err)
+	}
+	return count, nil
+}
+
+func (r *StatsRepository) SumBalancesByCurrency(ctx context.Context, userID int64) (map[string]float64, error) {
+	query := `
+		SELECT currency, COALESCE(SUM(balance), 0)
+		FROM accounts
+		WHERE user_id = $1 AND removed_at IS NULL
+		GROUP BY currency
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := map[string]float64{}
+	for rows.Next() {
+		var currency string
+		var total float64
+		if err := rows.Scan(&currency, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances[currency] = total
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+func (r *StatsRepository) CountTags(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tags WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tags: %w", err)
+	}
+	return count, nil
+}
+
+func (r *StatsRepository) CountCousinRules(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_ck_values WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count cousin rules: %w", err)
+	}
+	return count, nil
+}
+
+// GetLastSyncAt uses items.updated_at, which only account syncs touch
+func (r *StatsRepository) GetLastSyncAt(ctx context.Context, userID int64) (*time.Time, error) {
+	query := `SELECT MAX(updated_at) FROM items WHERE user_id = $1 AND deleted_at IS NULL`
+
+	var lastSync sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&lastSync); err != nil {
+		return nil, fmt.Errorf("failed to get last sync: %w", err)
+	}
+	if !lastSync.Valid {
+		return nil, nil
+	}
+	return &lastSync.Time, nil
+}
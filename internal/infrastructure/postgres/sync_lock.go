@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"time"
+
+	"parsa/internal/domain/openfinance"
+)
+
+// syncLockClass namespaces the per-user sync locks among Postgres advisory locks
+const syncLockClass = 0x53594e43 // "SYNC"
+
+// SyncLocker implements openfinance.SyncLocker with session-level Postgres advisory locks,
+// so a user's syncs are serialized across API instances. Each held lock pins one pooled
+// connection until it is released. User IDs are truncated to 32 bits for the lock key;
+// a collision only serializes two users' syncs.
+type SyncLocker struct {
+	db *DB
+}
+
+// NewSyncLocker creates a new advisory-lock based sync locker
+func NewSyncLocker(db *DB) *SyncLocker {
+	return &SyncLocker{db: db}
+}
+
+// TryLock takes the user's sync lock without waiting
+func (l *SyncLocker) TryLock(ctx context.Context, userID int64) (func(), error) {
+	return l.lock(ctx, userID, false)
+}
+
+// Lock waits for the user's sync lock until ctx is done
+func (l *SyncLocker) Lock(ctx context.Context, userID int64) (func(), error) {
+	return l.lock(ctx, userID, true)
+}
+
+func (l *SyncLocker) lock(ctx context.Context, userID int64, wait bool) (func(), error) {
+	// Advisory locks belong to the session, so lock and unlock on the same connection
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for sync lock: %w", err)
+	}
+
+	key := int32(userID)
+	acquired := true
+	if wait {
+		_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1, $2)`, syncLockClass, key)
+	} else {
+		err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, syncLockClass, key).Scan(&acquired)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire sync lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, openfinance.ErrSyncInProgress
+	}
+
+	return func() { releaseSyncLock(conn, key) }, nil
+}
+
+// releaseSyncLock unlocks and returns the connection to the pool. It uses its own context
+// so the lock is released even when the sync's context has been cancelled.
+func releaseSyncLock(conn *sql.Conn, key int32) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, syncLockClass, key); err != nil {
+		// Discard the connection instead of pooling it: ending the session releases the lock
+		log.Printf("Failed to release sync lock %d: %v", key, err)
+		_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
@@ -5,8 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"parsa/internal/domain/transaction"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepository struct {
@@ -19,12 +22,12 @@ func NewTransactionRepository(db *DB) *TransactionRepository {
 
 func (r *TransactionRepository) Create(ctx context.Context, params transaction.CreateTransactionParams) (*transaction.Transaction, error) {
 	query := `
-		INSERT INTO transactions (id, account_id, amount, description, category, transaction_date, type, status)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO transactions (id, account_id, amount, description, category, transaction_date, type, status, is_open_finance)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, false)
 		RETURNING id, account_id, amount, description, category, transaction_date, type, status,
 		          provider_created_at, provider_updated_at, created_at, updated_at,
 		          considered, is_open_finance, tags, manipulated, notes, cousin,
-		          merchant_id, document_id
+		          merchant_id, document_id, considered_reason, transfer_group
 	`
 
 	var txn transaction.Transaction
@@ -43,7 +46,7 @@ func (r *TransactionRepository) Create(ctx context.Context, params transaction.C
 		&providerCreatedAt, &providerUpdatedAt,
 		&txn.CreatedAt, &txn.UpdatedAt,
 		&txn.Considered, &txn.IsOpenFinance, &tags, &txn.Manipulated, &txn.Notes,
-		&cousin, &merchantID, &documentID,
+		&cousin, &merchantID, &documentID, &txn.ConsideredReason, &txn.TransferGroup,
 	)
 
 	if providerCreatedAt.Valid {
@@ -72,13 +75,15 @@ func (r *TransactionRepository) Create(ctx context.Context, params transaction.C
 
 func (r *TransactionRepository) GetByID(ctx context.Context, id string) (*transaction.Transaction, error) {
 	query := `
-		SELECT id, account_id, amount, description, category, original_description,
-		       provider_category_id, transaction_date, type, status,
-		       provider_created_at, provider_updated_at, created_at, updated_at,
-		       considered, is_open_finance, tags, manipulated, notes, cousin,
-		       merchant_id, document_id
-		FROM transactions
-		WHERE id = $1
+		SELECT t.id, t.account_id, t.amount, t.description, t.category, t.original_description,
+		       t.provider_category_id, t.transaction_date, t.type, t.status,
+		       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
+		       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
+		       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group,
+		       c.installment_number, c.total_installments, c.purchase_date
+		FROM transactions t
+		LEFT JOIN credit_card_data c ON c.transaction_id = t.id
+		WHERE t.id = $1
 	`
 
 	var txn transaction.Transaction
@@ -86,6 +91,8 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id string) (*transa
 	var tags []byte
 	var originalDescription sql.NullString
 	var cousin, merchantID, documentID sql.NullInt64
+	var installmentNumber, totalInstallments sql.NullInt64
+	var purchaseDate sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&txn.ID, &txn.AccountID, &txn.Amount,
@@ -95,7 +102,8 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id string) (*transa
 		&providerCreatedAt, &providerUpdatedAt,
 		&txn.CreatedAt, &txn.UpdatedAt,
 		&txn.Considered, &txn.IsOpenFinance, &tags, &txn.Manipulated, &txn.Notes,
-		&cousin, &merchantID, &documentID,
+		&cousin, &merchantID, &documentID, &txn.ConsideredReason, &txn.TransferGroup,
+		&installmentNumber, &totalInstallments, &purchaseDate,
 	)
 
 	if providerCreatedAt.Valid {
@@ -117,6 +125,14 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id string) (*transa
 	if documentID.Valid {
 		txn.DocumentID = &documentID.Int64
 	}
+	// Single-payment card purchases also have credit card data; only a plan gets installment context
+	if installmentNumber.Valid && totalInstallments.Valid && totalInstallments.Int64 > 1 {
+		txn.Installment = &transaction.Installment{
+			Number:       int(installmentNumber.Int64),
+			Total:        int(totalInstallments.Int64),
+			PurchaseDate: purchaseDate.Time,
+		}
+	}
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -134,9 +150,9 @@ func (r *TransactionRepository) ListByAccountID(ctx context.Context, accountID s
 		       provider_category_id, transaction_date, type, status,
 		       provider_created_at, provider_updated_at, created_at, updated_at,
 		       considered, is_open_finance, tags, manipulated, notes, cousin,
-		       merchant_id, document_id
+		       merchant_id, document_id, considered_reason, transfer_group
 		FROM transactions
-		WHERE account_id = $1
+		WHERE account_id = $1 AND removed_at IS NULL
 		ORDER BY transaction_date DESC, created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -150,6 +166,18 @@ func (r *TransactionRepository) ListByAccountID(ctx context.Context, accountID s
 	return scanTransactions(rows)
 }
 
+// CountByAccountID returns the number of transactions in an account
+func (r *TransactionRepository) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	query := `SELECT COUNT(*) FROM transactions WHERE account_id = $1 AND removed_at IS NULL`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query, accountID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %w", err)
+	}
+
+	return count, nil
+}
+
 // ListByUserID returns all transactions for a user across all accounts
 func (r *TransactionRepository) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*transaction.Transaction, error) {
 	query := `
@@ -157,10 +185,10 @@ func (r *TransactionRepository) ListByUserID(ctx context.Context, userID int64,
 		       t.provider_category_id, t.transaction_date, t.type, t.status,
 		       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
 		       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
-		       t.merchant_id, t.document_id
+		       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group
 		FROM transactions t
 		JOIN accounts a ON t.account_id = a.id
-		WHERE a.user_id = $1 AND a.removed_at IS NULL
+		WHERE a.user_id = $1 AND a.removed_at IS NULL AND t.removed_at IS NULL
 		ORDER BY t.transaction_date DESC, t.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -180,7 +208,7 @@ func (r *TransactionRepository) CountByUserID(ctx context.Context, userID int64)
 		SELECT COUNT(*)
 		FROM transactions t
 		JOIN accounts a ON t.account_id = a.id
-		WHERE a.user_id = $1 AND a.removed_at IS NULL
+		WHERE a.user_id = $1 AND a.removed_at IS NULL AND t.removed_at IS NULL
 	`
 
 	var count int64
@@ -192,17 +220,137 @@ func (r *TransactionRepository) CountByUserID(ctx context.Context, userID int64)
 	return count, nil
 }
 
+// listFilterClause builds the extra WHERE conditions for filter, numbering placeholders from
+// startIndex. Returns the SQL (starting with " AND" when non-empty) and its arguments.
+func listFilterClause(filter transaction.ListFilter, startIndex int) (string, []interface{}) {
+	var clause strings.Builder
+	var args []interface{}
+
+	if filter.Considered != nil {
+		args = append(args, *filter.Considered)
+		fmt.Fprintf(&clause, " AND t.considered = $%d", startIndex+len(args)-1)
+	}
+	if filter.ConsideredReason != nil {
+		args = append(args, *filter.ConsideredReason)
+		fmt.Fprintf(&clause, " AND t.considered_reason = $%d", startIndex+len(args)-1)
+	}
+
+	return clause.String(), args
+}
+
+// ListByUserIDFiltered returns a user's transactions matching filter
+func (r *TransactionRepository) ListByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+	filterSQL, filterArgs := listFilterClause(filter, 2)
+	limitIndex := 2 + len(filterArgs)
+
+	query := fmt.Sprintf(`
+		SELECT t.id, t.account_id, t.amount, t.description, t.category, t.original_description,
+		       t.provider_category_id, t.transaction_date, t.type, t.status,
+		       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
+		       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
+		       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1 AND a.removed_at IS NULL AND t.removed_at IS NULL%s
+		ORDER BY t.transaction_date DESC, t.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, filterSQL, limitIndex, limitIndex+1)
+
+	args := append([]interface{}{userID}, filterArgs...)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list filtered transactions by user: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTransactions(rows)
+}
+
+// CountByUserIDFiltered returns the number of a user's transactions matching filter
+func (r *TransactionRepository) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	filterSQL, filterArgs := listFilterClause(filter, 2)
+
+	query := `
+		SELECT COUNT(*)
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1 AND a.removed_at IS NULL AND t.removed_at IS NULL` + filterSQL
+
+	args := append([]interface{}{userID}, filterArgs...)
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count filtered transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListByUserIDUpdatedSince returns a user's transactions with updated_at after since,
+// ordered by updated_at so sync clients can resume from the last change they saw.
+// Provider-removed transactions are included with RemovedAt set as tombstones, since marking
+// them removed bumps updated_at. Hard-deleted transactions cannot be reported.
+func (r *TransactionRepository) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+	query := `
+		SELECT t.id, t.account_id, t.amount, t.description, t.category, t.original_description,
+		       t.provider_category_id, t.transaction_date, t.type, t.status,
+		       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
+		       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
+		       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group, t.removed_at
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1 AND a.removed_at IS NULL AND t.updated_at > $2
+		ORDER BY t.updated_at ASC, t.id ASC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, since, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions updated since: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTransactionRows(rows, true)
+}
+
+// CountByUserIDUpdatedSince returns the number of a user's transactions updated after since,
+// tombstones of provider-removed transactions included
+func (r *TransactionRepository) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1 AND a.removed_at IS NULL AND t.updated_at > $2
+	`
+
+	var count int64
+	err := r.db.QueryRowContext(ctx, query, userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions updated since: %w", err)
+	}
+
+	return count, nil
+}
+
 // scanTransactions is a helper to scan transaction rows
 func scanTransactions(rows *sql.Rows) ([]*transaction.Transaction, error) {
+	return scanTransactionRows(rows, false)
+}
+
+// scanTransactionRows scans transaction rows; withRemoved expects a trailing removed_at column
+func scanTransactionRows(rows *sql.Rows, withRemoved bool) ([]*transaction.Transaction, error) {
 	var transactions []*transaction.Transaction
 	for rows.Next() {
 		var txn transaction.Transaction
-		var providerCreatedAt, providerUpdatedAt sql.NullTime
+		var providerCreatedAt, providerUpdatedAt, removedAt sql.NullTime
 		var tags []byte
 		var originalDescription sql.NullString
 		var cousin, merchantID, documentID sql.NullInt64
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&txn.ID, &txn.AccountID, &txn.Amount,
 			&txn.Description, &txn.Category, &originalDescription,
 			&txn.ProviderCategoryID, &txn.TransactionDate,
@@ -210,8 +358,12 @@ func scanTransactions(rows *sql.Rows) ([]*transaction.Transaction, error) {
 			&providerCreatedAt, &providerUpdatedAt,
 			&txn.CreatedAt, &txn.UpdatedAt,
 			&txn.Considered, &txn.IsOpenFinance, &tags, &txn.Manipulated, &txn.Notes,
-			&cousin, &merchantID, &documentID,
-		)
+			&cousin, &merchantID, &documentID, &txn.ConsideredReason, &txn.TransferGroup,
+		}
+		if withRemoved {
+			dest = append(dest, &removedAt)
+		}
+		err := rows.Scan(dest...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
@@ -235,6 +387,9 @@ func scanTransactions(rows *sql.Rows) ([]*transaction.Transaction, error) {
 		if documentID.Valid {
 			txn.DocumentID = &documentID.Int64
 		}
+		if removedAt.Valid {
+			txn.RemovedAt = &removedAt.Time
+		}
 
 		transactions = append(transactions, &txn)
 	}
@@ -261,7 +416,9 @@ func (r *TransactionRepository) Update(ctx context.Context, id string, params tr
 		    status = COALESCE($6, status),
 		    considered = COALESCE($7, considered),
 		    notes = COALESCE($8, notes),
+		    considered_reason = COALESCE($10, considered_reason),
 		    manipulated = CASE
+		        WHEN $11 THEN true
 		        WHEN $1 IS NOT NULL AND $1 IS DISTINCT FROM amount THEN true
 		        WHEN $2 IS NOT NULL AND $2 IS DISTINCT FROM description THEN true
 		        WHEN $3 IS NOT NULL AND $3 IS DISTINCT FROM category THEN true
@@ -273,7 +430,7 @@ func (r *TransactionRepository) Update(ctx context.Context, id string, params tr
 		          provider_category_id, transaction_date, type, status,
 		          provider_created_at, provider_updated_at, created_at, updated_at,
 		          considered, is_open_finance, tags, manipulated, notes, cousin,
-		          merchant_id, document_id
+		          merchant_id, document_id, considered_reason, transfer_group
 	`
 
 	var txn transaction.Transaction
@@ -285,7 +442,8 @@ func (r *TransactionRepository) Update(ctx context.Context, id string, params tr
 	err := r.db.QueryRowContext(
 		ctx, query,
 		params.Amount, params.Description, params.Category, params.TransactionDate,
-		params.Type, params.Status, params.Considered, params.Notes, id,
+		params.Type, params.Status, params.Considered, params.Notes, id, params.ConsideredReason,
+		params.Manipulated,
 	).Scan(
 		&txn.ID, &txn.AccountID, &txn.Amount,
 		&txn.Description, &txn.Category, &originalDescription,
@@ -294,7 +452,7 @@ func (r *TransactionRepository) Update(ctx context.Context, id string, params tr
 		&providerCreatedAt, &providerUpdatedAt,
 		&txn.CreatedAt, &txn.UpdatedAt,
 		&txn.Considered, &txn.IsOpenFinance, &tags, &txn.Manipulated, &txn.Notes,
-		&cousin, &merchantID, &documentID,
+		&cousin, &merchantID, &documentID, &txn.ConsideredReason, &txn.TransferGroup,
 	)
 
 	if providerCreatedAt.Valid {
@@ -367,7 +525,7 @@ func (r *TransactionRepository) UpdateBatch(ctx context.Context, updates []struc
 		          provider_category_id, transaction_date, type, status,
 		          provider_created_at, provider_updated_at, created_at, updated_at,
 		          considered, is_open_finance, tags, manipulated, notes, cousin,
-		          merchant_id, document_id
+		          merchant_id, document_id, considered_reason, transfer_group
 	`
 
 	for _, u := range updates {
@@ -388,7 +546,7 @@ func (r *TransactionRepository) UpdateBatch(ctx context.Context, updates []struc
 			&providerCreatedAt, &providerUpdatedAt,
 			&txn.CreatedAt, &txn.UpdatedAt,
 			&txn.Considered, &txn.IsOpenFinance, &tags, &txn.Manipulated, &txn.Notes,
-			&cousin, &merchantID, &documentID,
+			&cousin, &merchantID, &documentID, &txn.ConsideredReason, &txn.TransferGroup,
 		)
 
 		if err == sql.ErrNoRows {
@@ -428,6 +586,8 @@ func (r *TransactionRepository) UpdateBatch(ctx context.Context, updates []struc
 	return results, nil
 }
 
+// Delete removes a transaction. Its tag links (transaction_tags), credit card data and
+// attachments go with it through ON DELETE CASCADE foreign keys, in the same statement.
 func (r *TransactionRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM transactions WHERE id = $1`
 
@@ -448,7 +608,7 @@ func (r *TransactionRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// DeleteByAccountID removes all transactions for a given account
+// DeleteByAccountID removes all transactions for a given account, cascading like Delete
 func (r *TransactionRepository) DeleteByAccountID(ctx context.Context, accountID string) error {
 	query := `DELETE FROM transactions WHERE account_id = $1`
 
@@ -480,12 +640,13 @@ func (r *TransactionRepository) Upsert(ctx context.Context, params transaction.U
 		    provider_updated_at = EXCLUDED.provider_updated_at,
 		    merchant_id = COALESCE(EXCLUDED.merchant_id, transactions.merchant_id),
 		    document_id = COALESCE(EXCLUDED.document_id, transactions.document_id),
+		    removed_at = NULL,
 		    updated_at = CURRENT_TIMESTAMP
 		RETURNING id, account_id, amount, description, category, original_description,
 		          provider_category_id, transaction_date, type, status,
 		          provider_created_at, provider_updated_at, created_at, updated_at,
 		          considered, is_open_finance, tags, manipulated, notes, cousin,
-		          merchant_id, document_id
+		          merchant_id, document_id, considered_reason, transfer_group
 	`
 
 	var txn transaction.Transaction
@@ -509,7 +670,7 @@ func (r *TransactionRepository) Upsert(ctx context.Context, params transaction.U
 		&providerCreatedAt, &providerUpdatedAt,
 		&txn.CreatedAt, &txn.UpdatedAt,
 		&txn.Considered, &txn.IsOpenFinance, &tags, &txn.Manipulated, &txn.Notes,
-		&cousin, &merchantID, &documentID,
+		&cousin, &merchantID, &documentID, &txn.ConsideredReason, &txn.TransferGroup,
 	)
 
 	if providerCreatedAt.Valid {
@@ -540,11 +701,14 @@ func (r *TransactionRepository) Upsert(ctx context.Context, params transaction.U
 }
 
 // UpsertBatch inserts or updates multiple transactions in a single query
-// Returns the count of affected rows (inserted + updated)
+// Returns how many rows were inserted and how many existing rows changed; unchanged rows count
+// as neither, so re-running a batch after a failure reports only what it actually wrote.
+// A transaction repeated in params is written once, with its last occurrence.
 // Note: original_description is NOT set here - it's only set when user changes description via API
-func (r *TransactionRepository) UpsertBatch(ctx context.Context, params []transaction.UpsertTransactionParams) (int64, error) {
+func (r *TransactionRepository) UpsertBatch(ctx context.Context, params []transaction.UpsertTransactionParams) (inserted, updated int64, err error) {
+	params = lastUpsertByID(params)
 	if len(params) == 0 {
-		return 0, nil
+		return 0, 0, nil
 	}
 
 	// Each transaction has 13 fields
@@ -570,7 +734,9 @@ func (r *TransactionRepository) UpsertBatch(ctx context.Context, params []transa
 		)
 	}
 
+	// xmax is 0 only on rows this statement inserted; updated rows carry the locking xid
 	query := fmt.Sprintf(`
+		WITH upserted AS (
 		INSERT INTO transactions (id, account_id, amount, description, category,
 		                          provider_category_id, transaction_date, type, status,
 		                          provider_created_at, provider_updated_at, merchant_id, document_id)
@@ -587,6 +753,7 @@ func (r *TransactionRepository) UpsertBatch(ctx context.Context, params []transa
 		    provider_updated_at = EXCLUDED.provider_updated_at,
 		    merchant_id = COALESCE(EXCLUDED.merchant_id, transactions.merchant_id),
 		    document_id = COALESCE(EXCLUDED.document_id, transactions.document_id),
+		    removed_at = NULL,
 		    updated_at = CURRENT_TIMESTAMP
 		WHERE
 		    transactions.amount IS DISTINCT FROM EXCLUDED.amount OR
@@ -599,12 +766,267 @@ func (r *TransactionRepository) UpsertBatch(ctx context.Context, params []transa
 		    transactions.provider_created_at IS DISTINCT FROM EXCLUDED.provider_created_at OR
 		    transactions.provider_updated_at IS DISTINCT FROM EXCLUDED.provider_updated_at OR
 		    transactions.merchant_id IS DISTINCT FROM EXCLUDED.merchant_id OR
-		    transactions.document_id IS DISTINCT FROM EXCLUDED.document_id
+		    transactions.document_id IS DISTINCT FROM EXCLUDED.document_id OR
+		    transactions.removed_at IS NOT NULL
+		RETURNING (xmax = 0) AS inserted
+		)
+		SELECT COUNT(*) FILTER (WHERE inserted), COUNT(*) FILTER (WHERE NOT inserted)
+		FROM upserted
 	`, strings.Join(valueStrings, ", "))
 
-	result, err := r.db.ExecContext(ctx, query, valueArgs...)
+	if err := r.db.QueryRowContext(ctx, query, valueArgs...).Scan(&inserted, &updated); err != nil {
+		return 0, 0, fmt.Errorf("failed to batch upsert transactions: %w", err)
+	}
+
+	return inserted, updated, nil
+}
+
+// lastUpsertByID drops all but the last params of each transaction ID, keeping the order of
+// those last occurrences. ON CONFLICT DO UPDATE rejects a statement that touches a row twice.
+func lastUpsertByID(params []transaction.UpsertTransactionParams) []transaction.UpsertTransactionParams {
+	last := make(map[string]int, len(params))
+	for i, p := range params {
+		last[p.ID] = i
+	}
+	if len(last) == len(params) {
+		return params
+	}
+	unique := make([]transaction.UpsertTransactionParams, 0, len(last))
+	for i, p := range params {
+		if last[p.ID] == i {
+			unique = append(unique, p)
+		}
+	}
+	return unique
+}
+
+// Reconsider re-includes not-considered transactions in a single UPDATE. The reason becomes
+// USER so detection services treat it as a user decision and don't exclude it again.
+// Transactions with an entry in notes get those notes written too.
+func (r *TransactionRepository) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	if len(ids) == 0 {
+		return []string{}, nil
+	}
+
+	noteIDs := make([]string, 0, len(notes))
+	noteTexts := make([]string, 0, len(notes))
+	for id, text := range notes {
+		noteIDs = append(noteIDs, id)
+		noteTexts = append(noteTexts, text)
+	}
+
+	query := `
+		UPDATE transactions t
+		SET considered = true,
+		    considered_reason = 'USER',
+		    notes = COALESCE(n.notes, t.notes),
+		    updated_at = CURRENT_TIMESTAMP
+		FROM unnest($1::text[]) AS i(id)
+		LEFT JOIN unnest($2::text[], $3::text[]) AS n(id, notes) ON n.id = i.id
+		WHERE t.id = i.id AND t.considered = false
+		RETURNING t.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), pq.Array(noteIDs), pq.Array(noteTexts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconsider transactions: %w", err)
+	}
+	defer rows.Close()
+
+	updated := make([]string, 0, len(ids))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan reconsidered transaction id: %w", err)
+		}
+		updated = append(updated, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reconsidered transactions: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Recategorize moves the user's non-manipulated transactions from one category to another in a
+// single UPDATE scoped by the account join, marking them manipulated
+func (r *TransactionRepository) Recategorize(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+	query := `
+		UPDATE transactions t
+		SET category = $3,
+		    manipulated = true,
+		    updated_at = CURRENT_TIMESTAMP
+		FROM accounts a
+		WHERE t.account_id = a.id
+		  AND a.user_id = $1
+		  AND t.category = $2
+		  AND t.manipulated = false
+		  AND t.removed_at IS NULL
+		  AND ($4::timestamptz IS NULL OR t.transaction_date >= $4)
+		  AND ($5::timestamptz IS NULL OR t.transaction_date <= $5)
+		RETURNING t.id
+	`
+
+	var start, end *time.Time
+	if !window.Start.IsZero() {
+		start = &window.Start
+	}
+	if !window.End.IsZero() {
+		end = &window.End
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, userID, from, to, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recategorize transactions: %w", err)
+	}
+	defer rows.Close()
+
+	updated := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan recategorized transaction id: %w", err)
+		}
+		updated = append(updated, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating recategorized transactions: %w", err)
+	}
+
+	return updated, nil
+}
+
+// MarkTransfer excludes the given transactions as the two sides of a transfer in a single
+// database transaction, sharing group so either side leads to the other. Counterparts from an
+// earlier link are unlinked first, and re-included when a transfer was why they were excluded.
+func (r *TransactionRepository) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	unlinkQuery := `
+		UPDATE transactions
+		SET transfer_group = NULL,
+		    considered = CASE WHEN considered_reason = 'TRANSFER' THEN true ELSE considered END,
+		    considered_reason = CASE WHEN considered_reason = 'TRANSFER' THEN NULL ELSE considered_reason END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE transfer_group IN (
+		        SELECT transfer_group FROM transactions
+		        WHERE id = ANY($1) AND transfer_group IS NOT NULL
+		    )
+		  AND NOT (id = ANY($1))
+	`
+	if _, err := tx.ExecContext(ctx, unlinkQuery, pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("failed to unlink previous transfer counterparts: %w", err)
+	}
+
+	query := `
+		UPDATE transactions
+		SET considered = false,
+		    considered_reason = 'TRANSFER',
+		    transfer_group = $2,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1) AND removed_at IS NULL
+	`
+
+	result, err := tx.ExecContext(ctx, query, pq.Array(ids), group)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark transfer: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if updated != int64(len(ids)) {
+		return 0, transaction.ErrTransactionNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	return updated, nil
+}
+
+// ReleaseDetection re-includes a transaction a detection check excluded with reason and writes
+// notes. The reason is cleared rather than set to USER, so later checks may exclude it again.
+// Transactions whose reason changed since (e.g. a user decision) are left alone.
+func (r *TransactionRepository) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	query := `
+		UPDATE transactions
+		SET considered = true,
+		    considered_reason = NULL,
+		    notes = COALESCE($3, notes),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND considered_reason = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, reason, notes)
+	if err != nil {
+		return false, fmt.Errorf("failed to release transaction from detection: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// MarkMissingAsRemoved sets removed_at on the account's synced transactions dated within window
+// whose IDs are not in presentIDs. Manual transactions and anything outside the window are
+// left alone, so a sync that fetched only part of the history can't remove the rest.
+func (r *TransactionRepository) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	query := `
+		UPDATE transactions
+		SET removed_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1
+		  AND is_open_finance = true
+		  AND removed_at IS NULL
+		  AND transaction_date >= $2
+		  AND transaction_date <= $3
+		  AND NOT (id = ANY($4))
+	`
+
+	result, err := r.db.ExecContext(ctx, query, accountID, window.Start, window.End, pq.Array(presentIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark missing transactions as removed: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected, nil
+}
+
+// MarkStalePending soft-deletes PENDING transactions that never posted: provider-synced, not
+// edited by the user, dated within window and missing from the latest fetch (presentIDs)
+func (r *TransactionRepository) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	query := `
+		UPDATE transactions
+		SET removed_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE account_id = $1
+		  AND is_open_finance = true
+		  AND status = 'PENDING'
+		  AND manipulated = false
+		  AND removed_at IS NULL
+		  AND transaction_date >= $2
+		  AND transaction_date <= $3
+		  AND NOT (id = ANY($4))
+	`
+
+	result, err := r.db.ExecContext(ctx, query, accountID, window.Start, window.End, pq.Array(presentIDs))
 	if err != nil {
-		return 0, fmt.Errorf("failed to batch upsert transactions: %w", err)
+		return 0, fmt.Errorf("failed to mark stale pending transactions as removed: %w", err)
 	}
 
 	affected, err := result.RowsAffected()
@@ -615,6 +1037,182 @@ func (r *TransactionRepository) UpsertBatch(ctx context.Context, params []transa
 	return affected, nil
 }
 
+func (r *TransactionRepository) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	return r.setCousin(ctx, transactionID, cousinID, false)
+}
+
+func (r *TransactionRepository) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	return r.setCousin(ctx, transactionID, cousinID, true)
+}
+
+// setCousin assigns the cousin in its own transaction. With skipRules, parsa.skip_cousin_rules
+// is set for that transaction so the cousin_assigned trigger doesn't notify the listener.
+func (r *TransactionRepository) setCousin(ctx context.Context, transactionID string, cousinID int64, skipRules bool) error {
+	if cousinID <= 0 {
+		return transaction.ErrCousinNotFound
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if skipRules {
+		if _, err := tx.ExecContext(ctx, `SELECT set_config('parsa.skip_cousin_rules', 'on', true)`); err != nil {
+			return fmt.Errorf("failed to skip cousin rules: %w", err)
+		}
+	}
+
+	// Only assign cousins that exist, independently of the transactions_cousin_fkey constraint
+	query := `
+		UPDATE transactions
+		SET cousin = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM cousins WHERE id = $2)
+	`
+
+	result, err := tx.ExecContext(ctx, query, transactionID, cousinID)
+	if err != nil {
+		return fmt.Errorf("failed to set transaction cousin: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	}
+
+	// Nothing updated: report which side is missing
+	var cousinExists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM cousins WHERE id = $1)`, cousinID).Scan(&cousinExists); err != nil {
+		return fmt.Errorf("failed to check cousin: %w", err)
+	}
+	if !cousinExists {
+		return transaction.ErrCousinNotFound
+	}
+	return transaction.ErrTransactionNotFound
+}
+
+func (r *TransactionRepository) ClearCousin(ctx context.Context, transactionID string) error {
+	query := `
+		UPDATE transactions
+		SET cousin = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to clear transaction cousin: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return transaction.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// HasCousinForeignKey reports whether the transactions_cousin_fkey constraint exists and is validated
+func (r *TransactionRepository) HasCousinForeignKey(ctx context.Context) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_constraint
+			WHERE conname = 'transactions_cousin_fkey'
+			  AND conrelid = 'public.transactions'::regclass
+			  AND convalidated
+		)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check cousin foreign key: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CountOrphanedCousinRefs returns the number of transactions whose cousin does not exist
+func (r *TransactionRepository) CountOrphanedCousinRefs(ctx context.Context) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM transactions t
+		WHERE t.cousin IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM cousins c WHERE c.id = t.cousin)
+	`
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orphaned cousin references: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListOrphanedCousinRefs returns up to limit transactions whose cousin does not exist
+func (r *TransactionRepository) ListOrphanedCousinRefs(ctx context.Context, limit int) ([]transaction.OrphanedCousinRef, error) {
+	query := `
+		SELECT t.id, t.cousin
+		FROM transactions t
+		WHERE t.cousin IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM cousins c WHERE c.id = t.cousin)
+		ORDER BY t.cousin, t.id
+		LIMIT $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orphaned cousin references: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []transaction.OrphanedCousinRef
+	for rows.Next() {
+		var ref transaction.OrphanedCousinRef
+		if err := rows.Scan(&ref.TransactionID, &ref.CousinID); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned cousin reference: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orphaned cousin references: %w", err)
+	}
+
+	return refs, nil
+}
+
+// ClearOrphanedCousinRefs sets cousin to NULL on every transaction whose cousin does not exist.
+// Returns the number of transactions fixed.
+func (r *TransactionRepository) ClearOrphanedCousinRefs(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE transactions t
+		SET cousin = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE t.cousin IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM cousins c WHERE c.id = t.cousin)
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear orphaned cousin references: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
 // SetTransactionTags replaces all tags for a transaction
 func (r *TransactionRepository) SetTransactionTags(ctx context.Context, transactionID string, tagIDs []string) error {
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -683,28 +1281,246 @@ func (r *TransactionRepository) GetTransactionTags(ctx context.Context, transact
 	return tagIDs, nil
 }
 
+// GetTagsForTransactions returns the tag IDs of several transactions, keyed by transaction ID
+func (r *TransactionRepository) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	tags := make(map[string][]string)
+	if len(transactionIDs) == 0 {
+		return tags, nil
+	}
+
+	query := `SELECT transaction_id, tag_id FROM transaction_tags WHERE transaction_id = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(transactionIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID, tagID string
+		if err := rows.Scan(&transactionID, &tagID); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction tag: %w", err)
+		}
+		tags[transactionID] = append(tags[transactionID], tagID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// UpdateTags removes and adds transaction tags in a single database transaction, one statement
+// each
+func (r *TransactionRepository) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	removeTransactionIDs, removeTagIDs := flattenTransactionTags(remove)
+	addTransactionIDs, addTagIDs := flattenTransactionTags(add)
+	if len(removeTransactionIDs) == 0 && len(addTransactionIDs) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(removeTransactionIDs) > 0 {
+		query := `
+			DELETE FROM transaction_tags tt
+			USING unnest($1::text[], $2::uuid[]) AS r(transaction_id, tag_id)
+			WHERE tt.transaction_id = r.transaction_id AND tt.tag_id = r.tag_id
+		`
+		if _, err := tx.ExecContext(ctx, query, pq.Array(removeTransactionIDs), pq.Array(removeTagIDs)); err != nil {
+			return fmt.Errorf("failed to remove transaction tags: %w", err)
+		}
+	}
+
+	if len(addTransactionIDs) > 0 {
+		query := `
+			INSERT INTO transaction_tags (transaction_id, tag_id)
+			SELECT * FROM unnest($1::text[], $2::uuid[])
+			ON CONFLICT DO NOTHING
+		`
+		if _, err := tx.ExecContext(ctx, query, pq.Array(addTransactionIDs), pq.Array(addTagIDs)); err != nil {
+			return fmt.Errorf("failed to add transaction tags: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// flattenTransactionTags turns transaction ID -> tag IDs into parallel arrays for unnest
+func flattenTransactionTags(tags map[string][]string) (transactionIDs, tagIDs []string) {
+	for transactionID, ids := range tags {
+		for _, tagID := range ids {
+			transactionIDs = append(transactionIDs, transactionID)
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	return transactionIDs, tagIDs
+}
+
+// MonthlyTrendByCategory groups the user's considered transactions in a category by UTC month.
+// Debits add to a month's total and credits (refunds) subtract from it.
+func (r *TransactionRepository) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+	query := `
+		SELECT date_trunc('month', t.transaction_date AT TIME ZONE 'UTC') AS month,
+		       SUM(CASE WHEN t.type = 'DEBIT' THEN ABS(t.amount) ELSE -ABS(t.amount) END) AS total
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1
+		  AND a.removed_at IS NULL
+		  AND t.removed_at IS NULL
+		  AND t.category = $2
+		  AND t.considered = true
+		  AND t.transaction_date >= $3
+		GROUP BY month
+		ORDER BY month
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, category, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly trend: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []transaction.MonthlyTotal
+	for rows.Next() {
+		var t transaction.MonthlyTotal
+		if err := rows.Scan(&t.Month, &t.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan monthly total: %w", err)
+		}
+		t.Month = t.Month.UTC()
+		totals = append(totals, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating monthly totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// CountGroups counts the user's transactions grouped by type, status and considered in one query
+func (r *TransactionRepository) CountGroups(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+	query := `
+		SELECT t.type, t.status, t.considered, COUNT(*)
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1
+		  AND a.removed_at IS NULL
+		  AND t.removed_at IS NULL
+		  AND ($2::timestamptz IS NULL OR t.transaction_date >= $2)
+		  AND ($3::timestamptz IS NULL OR t.transaction_date <= $3)
+		GROUP BY t.type, t.status, t.considered
+	`
+
+	var start, end *time.Time
+	if !window.Start.IsZero() {
+		start = &window.Start
+	}
+	if !window.End.IsZero() {
+		end = &window.End
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count transactions by group: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []transaction.CountGroup
+	for rows.Next() {
+		var g transaction.CountGroup
+		if err := rows.Scan(&g.Type, &g.Status, &g.Considered, &g.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction count: %w", err)
+		}
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating transaction counts: %w", err)
+	}
+
+	return groups, nil
+}
+
+// ListDescriptionSuggestions groups the user's descriptions by their lowercased, trimmed form and
+// returns the most used ones starting with prefix, each under its most common spelling
+func (r *TransactionRepository) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+	query := `
+		SELECT mode() WITHIN GROUP (ORDER BY btrim(t.description)) AS description,
+		       COUNT(*) AS uses
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE a.user_id = $1
+		  AND a.removed_at IS NULL
+		  AND t.removed_at IS NULL
+		  AND lower(btrim(t.description)) LIKE lower($2)
+		  AND btrim(t.description) <> ''
+		GROUP BY lower(btrim(t.description))
+		ORDER BY uses DESC, description
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, likePrefix(strings.TrimSpace(prefix)), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list description suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []transaction.DescriptionSuggestion{}
+	for rows.Next() {
+		var s transaction.DescriptionSuggestion
+		if err := rows.Scan(&s.Description, &s.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan description suggestion: %w", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating description suggestions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// likePrefix returns a LIKE pattern matching values that start with prefix literally
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
 // FindPotentialDuplicates finds transactions that could be duplicates based on criteria:
 // - Different ID from the source transaction
 // - Opposite type (DEBIT <-> CREDIT)
-// - Same absolute amount
+// - Same absolute amount, compared in cents
 // - Transaction date within the specified time range
 // - Same user (through account join)
+// - Same account, when criteria.SameAccountOnly is set
 func (r *TransactionRepository) FindPotentialDuplicates(ctx context.Context, criteria transaction.DuplicateCriteria) ([]*transaction.Transaction, error) {
 	query := `
 		SELECT t.id, t.account_id, t.amount, t.description, t.category, t.original_description,
 		       t.provider_category_id, t.transaction_date, t.type, t.status,
 		       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
 		       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
-		       t.merchant_id, t.document_id
+		       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group
 		FROM transactions t
 		JOIN accounts a ON t.account_id = a.id
 		WHERE t.id != $1
 		  AND t.type = $2
-		  AND ABS(t.amount) = $3
+		  AND ABS(t.amount) = ROUND($3::numeric, 2)
 		  AND t.transaction_date >= $4
 		  AND t.transaction_date <= $5
 		  AND a.user_id = $6
+		  AND (NOT $7::boolean OR t.account_id = $8)
 		  AND a.removed_at IS NULL
+		  AND t.removed_at IS NULL
 	`
 
 	rows, err := r.db.QueryContext(ctx, query,
@@ -714,6 +1530,8 @@ func (r *TransactionRepository) FindPotentialDuplicates(ctx context.Context, cri
 		criteria.DateLowerBound,
 		criteria.DateUpperBound,
 		criteria.UserID,
+		criteria.SameAccountOnly,
+		criteria.AccountID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find potential duplicates: %w", err)
@@ -724,7 +1542,7 @@ func (r *TransactionRepository) FindPotentialDuplicates(ctx context.Context, cri
 }
 
 // FindPotentialDuplicatesForBill finds transactions that could be duplicates related to bills
-// - Same absolute amount (any type)
+// - Same absolute amount (any type), compared in cents
 // - Transaction date within the specified time range
 // - Same user (through account join)
 // If ExcludeID is empty, checks all transactions (useful for bill-based duplicate detection)
@@ -738,15 +1556,16 @@ func (r *TransactionRepository) FindPotentialDuplicatesForBill(ctx context.Conte
 			       t.provider_category_id, t.transaction_date, t.type, t.status,
 			       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
 			       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
-			       t.merchant_id, t.document_id
+			       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group
 			FROM transactions t
 			JOIN accounts a ON t.account_id = a.id
 			WHERE t.id != $1
-			  AND ABS(t.amount) = $2
+			  AND ABS(t.amount) = ROUND($2::numeric, 2)
 			  AND t.transaction_date >= $3
 			  AND t.transaction_date <= $4
 			  AND a.user_id = $5
 			  AND a.removed_at IS NULL
+			  AND t.removed_at IS NULL
 		`
 		args = []interface{}{
 			criteria.ExcludeID,
@@ -761,14 +1580,15 @@ func (r *TransactionRepository) FindPotentialDuplicatesForBill(ctx context.Conte
 			       t.provider_category_id, t.transaction_date, t.type, t.status,
 			       t.provider_created_at, t.provider_updated_at, t.created_at, t.updated_at,
 			       t.considered, t.is_open_finance, t.tags, t.manipulated, t.notes, t.cousin,
-			       t.merchant_id, t.document_id
+			       t.merchant_id, t.document_id, t.considered_reason, t.transfer_group
 			FROM transactions t
 			JOIN accounts a ON t.account_id = a.id
-			WHERE ABS(t.amount) = $1
+			WHERE ABS(t.amount) = ROUND($1::numeric, 2)
 			  AND t.transaction_date >= $2
 			  AND t.transaction_date <= $3
 			  AND a.user_id = $4
 			  AND a.removed_at IS NULL
+			  AND t.removed_at IS NULL
 		`
 		args = []interface{}{
 			criteria.AbsoluteAmount,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"parsa/internal/infrastructure/crypto"
 	"parsa/internal/domain/user"
@@ -52,7 +53,7 @@ func (r *UserRepository) Create(ctx context.Context, params user.CreateUserParam
 	query := `
     INSERT INTO users (email, name, first_name, last_name, avatar_url, oauth_provider, oauth_id, password_hash)
     VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-    RETURNING id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, has_finished_openfinance_flow, created_at, updated_at
+    RETURNING id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 `
 
 	var user user.User
@@ -63,7 +64,7 @@ func (r *UserRepository) Create(ctx context.Context, params user.CreateUserParam
 	).Scan(
 		&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 		&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL,
-		&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+		&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
@@ -75,7 +76,7 @@ func (r *UserRepository) Create(ctx context.Context, params user.CreateUserParam
 
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*user.User, error) {
 	query := `
-		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, created_at, updated_at
+		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
@@ -84,7 +85,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*user.User, err
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 		&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL, &user.ProviderKey,
-		&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+		&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -103,7 +104,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*user.User, err
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
 	query := `
-		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, created_at, updated_at
+		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
@@ -112,7 +113,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 		&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL, &user.ProviderKey,
-		&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+		&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -129,9 +130,10 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 	return &user, nil
 }
 
+// GetByOAuth returns (nil, nil) when no user matches, so callers can tell a miss from a query failure
 func (r *UserRepository) GetByOAuth(ctx context.Context, provider, oauthID string) (*user.User, error) {
 	query := `
-		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, created_at, updated_at
+		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 		FROM users
 		WHERE oauth_provider = $1 AND oauth_id = $2
 	`
@@ -140,11 +142,11 @@ func (r *UserRepository) GetByOAuth(ctx context.Context, provider, oauthID strin
 	err := r.db.QueryRowContext(ctx, query, provider, oauthID).Scan(
 		&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 		&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL, &user.ProviderKey,
-		&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+		&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -159,7 +161,7 @@ func (r *UserRepository) GetByOAuth(ctx context.Context, provider, oauthID strin
 
 func (r *UserRepository) List(ctx context.Context) ([]*user.User, error) {
 	query := `
-		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, created_at, updated_at
+		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 	`
@@ -176,7 +178,7 @@ func (r *UserRepository) List(ctx context.Context) ([]*user.User, error) {
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 			&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL, &user.ProviderKey,
-			&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+			&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -210,9 +212,11 @@ func (r *UserRepository) Update(ctx context.Context, userID int64, params user.U
 		    last_name = COALESCE($4, last_name),
 		    avatar_url = COALESCE($5, avatar_url),
 		    provider_key = COALESCE($6, provider_key),
+		    provider_key_updated_at = CASE WHEN $6 IS NOT NULL THEN NOW() ELSE provider_key_updated_at END,
+		    provider_key_failure_count = CASE WHEN $6 IS NOT NULL THEN 0 ELSE provider_key_failure_count END,
 		    updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, created_at, updated_at
+		RETURNING id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 	`
 
 	var user user.User
@@ -222,7 +226,7 @@ func (r *UserRepository) Update(ctx context.Context, userID int64, params user.U
 	).Scan(
 		&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 		&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL, &user.ProviderKey,
-		&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+		&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -240,7 +244,14 @@ func (r *UserRepository) Update(ctx context.Context, userID int64, params user.U
 }
 
 func (r *UserRepository) ClearProviderKey(ctx context.Context, userID int64) error {
-	query := `UPDATE users SET provider_key = NULL, updated_at = NOW() WHERE id = $1`
+	query := `
+		UPDATE users
+		SET provider_key = NULL,
+		    provider_key_updated_at = NOW(),
+		    provider_key_failure_count = 0,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
 	result, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
 		return fmt.Errorf("failed to clear provider key: %w", err)
@@ -255,6 +266,48 @@ func (r *UserRepository) ClearProviderKey(ctx context.Context, userID int64) err
 	return nil
 }
 
+// MarkProviderKeyValid records that the provider accepted the user's key just now
+// and resets the consecutive failure counter
+func (r *UserRepository) MarkProviderKeyValid(ctx context.Context, userID int64) error {
+	query := `
+		UPDATE users
+		SET provider_key_last_valid_at = NOW(),
+		    provider_key_failure_count = 0
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark provider key valid: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// RecordProviderKeyFailure increments the consecutive provider 401 counter and returns the new count
+func (r *UserRepository) RecordProviderKeyFailure(ctx context.Context, userID int64) (int, error) {
+	query := `
+		UPDATE users
+		SET provider_key_failure_count = provider_key_failure_count + 1
+		WHERE id = $1
+		RETURNING provider_key_failure_count
+	`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record provider key failure: %w", err)
+	}
+	return count, nil
+}
+
 func (r *UserRepository) SetHasFinishedOpenfinanceFlow(ctx context.Context, userID int64, value bool) error {
 	query := `UPDATE users SET has_finished_openfinance_flow = $2, updated_at = NOW() WHERE id = $1`
 	result, err := r.db.ExecContext(ctx, query, userID, value)
@@ -271,10 +324,28 @@ func (r *UserRepository) SetHasFinishedOpenfinanceFlow(ctx context.Context, user
 	return nil
 }
 
+// SetInsightsStartDate sets the date before which the user's transactions are left out of
+// aggregations, or clears it when date is nil
+func (r *UserRepository) SetInsightsStartDate(ctx context.Context, userID int64, date *time.Time) error {
+	query := `UPDATE users SET insights_start_date = $2, updated_at = NOW() WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, userID, date)
+	if err != nil {
+		return fmt.Errorf("failed to set insights start date: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
 // ListUsersWithProviderKey retrieves all users that have a provider key set
 func (r *UserRepository) ListUsersWithProviderKey(ctx context.Context) ([]*user.User, error) {
 	query := `
-		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, created_at, updated_at
+		SELECT id, email, name, first_name, last_name, oauth_provider, oauth_id, password_hash, avatar_url, provider_key, has_finished_openfinance_flow, provider_key_updated_at, provider_key_last_valid_at, insights_start_date, created_at, updated_at
 		FROM users
 		WHERE provider_key IS NOT NULL AND provider_key != ''
 		ORDER BY id
@@ -292,7 +363,7 @@ func (r *UserRepository) ListUsersWithProviderKey(ctx context.Context) ([]*user.
 		err := rows.Scan(
 			&user.ID, &user.Email, &user.Name, &user.FirstName, &user.LastName,
 			&user.OAuthProvider, &user.OAuthID, &user.PasswordHash, &user.AvatarURL, &user.ProviderKey,
-			&user.HasFinishedOpenfinanceFlow, &user.CreatedAt, &user.UpdatedAt,
+			&user.HasFinishedOpenfinanceFlow, &user.ProviderKeyUpdatedAt, &user.ProviderKeyLastValidAt, &user.InsightsStartDate, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
@@ -3,12 +3,15 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"parsa/internal/domain/account"
+	"parsa/internal/domain/attachment"
 	"parsa/internal/domain/openfinance"
+	"parsa/internal/shared/branding"
 	"parsa/internal/shared/middleware"
 )
 
@@ -17,6 +20,8 @@ type AccountHandler struct {
 	accountService         *account.Service
 	transactionSyncService *openfinance.TransactionSyncService
 	billSyncService        *openfinance.BillSyncService
+	syncLocker             openfinance.SyncLocker
+	attachmentService      *attachment.Service
 }
 
 // NewAccountHandler creates a new account handler with service layer
@@ -32,6 +37,17 @@ func NewAccountHandler(
 	}
 }
 
+// SetSyncLocker serializes the background sync started by an account restore with other
+// syncs for the same user
+func (h *AccountHandler) SetSyncLocker(locker openfinance.SyncLocker) {
+	h.syncLocker = locker
+}
+
+// SetAttachmentService enables deleting the attachment blobs of deleted accounts' transactions
+func (h *AccountHandler) SetAttachmentService(attachmentService *attachment.Service) {
+	h.attachmentService = attachmentService
+}
+
 // HTTP request/response types (transport layer concerns)
 type CreateAccountRequest struct {
 	ID          string  `json:"id"`
@@ -54,11 +70,13 @@ type AccountResponse struct {
 	AccountType   string   `json:"accountType"` // "normal", "credit", "saving"
 	Number        string   `json:"number"`      // empty for now
 	Name          string   `json:"name"`
+	MarketingName string   `json:"marketingName"` // provider's product name, falls back to name
 	InitialValue  float64  `json:"initialValue"`
 	CreatedAt     string   `json:"createdAt"`
 	UpdatedAt     string   `json:"updatedAt"`
 	ConnectorID   string   `json:"connectorID"`
 	PrimaryColor  string   `json:"primaryColor"`
+	LogoURL       *string  `json:"logoUrl"` // null when the bank has no known logo
 	Balance       *float64 `json:"balance"`
 	IsOpenFinance bool     `json:"isOpenFinance"`
 	ClosedAt      *string  `json:"closedAt"`
@@ -67,25 +85,29 @@ type AccountResponse struct {
 	Removed       bool   `json:"removed"` // true when removed_at has a value, false when null
 	HiddenByUser  bool   `json:"hiddenByUser"`
 	HasMFA        bool     `json:"hasMFA"` // false for now
+
+	// ConsideredBalance is initialValue plus the account's considered transactions, credits
+	// adding and debits subtracting
+	ConsideredBalance float64 `json:"consideredBalance"`
 }
 
 // HandleListAccounts returns all accounts for the authenticated user
 func (h *AccountHandler) HandleListAccounts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	accounts, err := h.accountService.ListAccountsWithBankByUserID(r.Context(), userID)
 	if err != nil {
 		log.Printf("Error listing accounts for user %d: %v", userID, err)
-		http.Error(w, "Failed to list accounts", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list accounts")
 		return
 	}
 
@@ -99,18 +121,49 @@ func (h *AccountHandler) HandleListAccounts(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(response)
 }
 
+// HandleAccountSummary returns balances grouped by account type/subtype (GET /api/accounts/summary)
+// Query params: includeHidden=true and includeRemoved=true widen the default selection.
+func (h *AccountHandler) HandleAccountSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	opts := account.SummaryOptions{
+		IncludeHidden:  query.Get("includeHidden") == "true",
+		IncludeRemoved: query.Get("includeRemoved") == "true",
+	}
+
+	summary, err := h.accountService.GetAccountSummary(r.Context(), userID, opts)
+	if err != nil {
+		log.Printf("Error building account summary for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to build account summary")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
 // HandleAccountByID handles operations on a specific account (GET and DELETE)
 func (h *AccountHandler) HandleAccountByID(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Use PathValue to extract the account ID
 	accountID := r.PathValue("id")
 	if accountID == "" {
-		http.Error(w, "Account ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Account ID is required")
 		return
 	}
 
@@ -122,51 +175,31 @@ func (h *AccountHandler) HandleAccountByID(w http.ResponseWriter, r *http.Reques
 	case http.MethodDelete:
 		h.handleDeleteAccount(w, r, userID, accountID)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
-// handleGetAccountByID returns a specific account
+// handleGetAccountByID returns a specific account with its bank data. Missing accounts and
+// accounts of other users are both reported as not found.
 func (h *AccountHandler) handleGetAccountByID(w http.ResponseWriter, r *http.Request, userID int64, accountID string) {
-	accounts, err := h.accountService.ListAccountsWithBankByUserID(r.Context(), userID)
+	acc, err := h.accountService.GetAccountWithBank(r.Context(), accountID, userID)
 	if err != nil {
-		log.Printf("Error listing accounts for user %d: %v", userID, err)
-		http.Error(w, "Failed to list accounts", http.StatusInternalServerError)
-		return
-	}
-
-	// Find the specific account
-	var found *account.AccountWithBank
-	for _, acc := range accounts {
-		if acc.ID == accountID {
-			found = acc
-			break
-		}
-	}
-	if found == nil {
-		http.Error(w, "Account not found", http.StatusNotFound)
+		writeError(w, err, "Failed to get account")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(toAccountResponse(found))
+	json.NewEncoder(w).Encode(toAccountResponse(acc))
 }
 
 // handleDeleteAccount deletes an account
 func (h *AccountHandler) handleDeleteAccount(w http.ResponseWriter, r *http.Request, userID int64, accountID string) {
 	err := h.accountService.DeleteAccount(r.Context(), accountID, userID)
 	if err != nil {
-		switch err {
-		case account.ErrAccountNotFound:
-			http.Error(w, "Account not found", http.StatusNotFound)
-		case account.ErrForbidden:
-			http.Error(w, "Forbidden", http.StatusForbidden)
-		default:
-			log.Printf("Error deleting account %s: %v", accountID, err)
-			http.Error(w, "Failed to delete account", http.StatusInternalServerError)
-		}
+		writeError(w, err, "Failed to delete account")
 		return
 	}
+	purgeDeletedAttachments(r.Context(), h.attachmentService)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -174,9 +207,9 @@ func (h *AccountHandler) handleDeleteAccount(w http.ResponseWriter, r *http.Requ
 // handlePatchAccount updates specific fields of an account
 func (h *AccountHandler) handlePatchAccount(w http.ResponseWriter, r *http.Request, userID int64, accountID string) {
 	var req UpdateAccountRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		log.Printf("Error decoding patch account request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -189,35 +222,14 @@ func (h *AccountHandler) handlePatchAccount(w http.ResponseWriter, r *http.Reque
 	// Update account
 	updatedAccount, err := h.accountService.UpdateAccount(r.Context(), accountID, updateParams, userID)
 	if err != nil {
-		switch err {
-		case account.ErrAccountNotFound:
-			http.Error(w, "Account not found", http.StatusNotFound)
-		case account.ErrForbidden:
-			http.Error(w, "Forbidden", http.StatusForbidden)
-		default:
-			log.Printf("Error updating account %s: %v", accountID, err)
-			http.Error(w, "Failed to update account", http.StatusInternalServerError)
-		}
+		writeError(w, err, "Failed to update account")
 		return
 	}
 
 	// Get account with bank data for response
-	accounts, err := h.accountService.ListAccountsWithBankByUserID(r.Context(), userID)
+	found, err := h.accountService.GetAccountWithBank(r.Context(), accountID, userID)
 	if err != nil {
-		log.Printf("Error listing accounts for user %d: %v", userID, err)
-		http.Error(w, "Failed to get updated account", http.StatusInternalServerError)
-		return
-	}
-
-	// Find the updated account with bank data
-	var found *account.AccountWithBank
-	for _, acc := range accounts {
-		if acc.ID == accountID {
-			found = acc
-			break
-		}
-	}
-	if found == nil {
+		log.Printf("Error getting account %s with bank data: %v", accountID, err)
 		// Fallback: create response from updated account without bank data
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(updatedAccount)
@@ -245,16 +257,27 @@ func toAccountResponse(acc *account.AccountWithBank) AccountResponse {
 	// Name uses the concatenation logic (ui_name + suffix based on subtype)
 	accountName := buildAccountName(acc)
 
+	// marketingName is the provider's product name (e.g. "Ultravioleta"), or name when unknown
+	marketingName := acc.MarketingName
+	if marketingName == "" {
+		marketingName = accountName
+	}
+
 	// Get connector_id (default to "1" if empty)
 	connectorID := acc.BankConnector
 	if connectorID == "" {
 		connectorID = "1"
 	}
 
-	// Get primary_color (default to "1194F6" if empty)
+	// Get primary_color (default brand color if empty)
 	primaryColor := acc.BankPrimaryColor
 	if primaryColor == "" {
-		primaryColor = "1194F6"
+		primaryColor = branding.DefaultPrimaryColor
+	}
+
+	var logoURL *string
+	if acc.BankLogoURL != "" {
+		logoURL = &acc.BankLogoURL
 	}
 
 	// Format timestamps
@@ -277,11 +300,13 @@ func toAccountResponse(acc *account.AccountWithBank) AccountResponse {
 		AccountType:   accountType,
 		Number:        "", // empty for now
 		Name:          accountName,
+		MarketingName: marketingName,
 		InitialValue:  acc.InitialBalance,
 		CreatedAt:     createdAt,
 		UpdatedAt:     updatedAt,
 		ConnectorID:   connectorID,
 		PrimaryColor:  primaryColor,
+		LogoURL:       logoURL,
 		Balance:       &balance,
 		IsOpenFinance: acc.IsOpenFinanceAccount,
 		ClosedAt:      closedAt,
@@ -290,40 +315,37 @@ func toAccountResponse(acc *account.AccountWithBank) AccountResponse {
 		Removed:       acc.RemovedAt != nil,
 		HiddenByUser:  acc.HiddenByUser,
 		HasMFA:        false, // always false for now
+
+		ConsideredBalance: acc.ConsideredBalance,
 	}
 }
 
 // HandleRemoveAccount soft-removes an account (POST /api/accounts/remove/{id})
 func (h *AccountHandler) HandleRemoveAccount(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	accountID := r.PathValue("id")
 	if accountID == "" {
-		http.Error(w, "Account ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Account ID is required")
 		return
 	}
 
 	err := h.accountService.RemoveAccount(r.Context(), accountID, userID)
 	if err != nil {
-		switch err {
-		case account.ErrAccountNotFound:
-			http.Error(w, "Account not found", http.StatusNotFound)
-		case account.ErrForbidden:
-			http.Error(w, "Forbidden", http.StatusForbidden)
-		case account.ErrAccountAlreadyRemoved:
-			http.Error(w, "Account is already removed", http.StatusConflict)
+		switch {
+		case errors.Is(err, account.ErrAccountAlreadyRemoved):
+			writeJSONError(w, http.StatusConflict, "Account is already removed")
 		default:
-			log.Printf("Error removing account %s: %v", accountID, err)
-			http.Error(w, "Failed to remove account", http.StatusInternalServerError)
+			writeError(w, err, "Failed to remove account")
 		}
 		return
 	}
@@ -335,34 +357,29 @@ func (h *AccountHandler) HandleRemoveAccount(w http.ResponseWriter, r *http.Requ
 // HandleRestoreAccount restores a previously removed account (POST /api/accounts/restore/{id})
 func (h *AccountHandler) HandleRestoreAccount(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	accountID := r.PathValue("id")
 	if accountID == "" {
-		http.Error(w, "Account ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Account ID is required")
 		return
 	}
 
 	err := h.accountService.RestoreAccount(r.Context(), accountID, userID)
 	if err != nil {
-		switch err {
-		case account.ErrAccountNotFound:
-			http.Error(w, "Account not found", http.StatusNotFound)
-		case account.ErrForbidden:
-			http.Error(w, "Forbidden", http.StatusForbidden)
-		case account.ErrAccountNotRemoved:
-			http.Error(w, "Account is not removed", http.StatusConflict)
+		switch {
+		case errors.Is(err, account.ErrAccountNotRemoved):
+			writeJSONError(w, http.StatusConflict, "Account is not removed")
 		default:
-			log.Printf("Error restoring account %s: %v", accountID, err)
-			http.Error(w, "Failed to restore account", http.StatusInternalServerError)
+			writeError(w, err, "Failed to restore account")
 		}
 		return
 	}
@@ -373,6 +390,14 @@ func (h *AccountHandler) HandleRestoreAccount(w http.ResponseWriter, r *http.Req
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 			defer cancel()
 
+			// Wait for a running sync so the restored account's history is still fetched
+			unlock, err := openfinance.LockUserSync(ctx, h.syncLocker, userID, true)
+			if err != nil {
+				log.Printf("Error locking sync for user %d: %v", userID, err)
+				return
+			}
+			defer unlock()
+
 			log.Printf("Starting full transaction sync for user %d after account restore", userID)
 			txResult, err := h.transactionSyncService.SyncUserTransactions(ctx, userID, true)
 			if err != nil {
@@ -398,34 +423,29 @@ func (h *AccountHandler) HandleRestoreAccount(w http.ResponseWriter, r *http.Req
 // HandleDeleteBank deletes all accounts for the bank connection (POST /api/accounts/delete-bank/{id})
 func (h *AccountHandler) HandleDeleteBank(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	accountID := r.PathValue("id")
 	if accountID == "" {
-		http.Error(w, "Account ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Account ID is required")
 		return
 	}
 
 	err := h.accountService.DeleteBank(r.Context(), accountID, userID)
 	if err != nil {
-		switch err {
-		case account.ErrAccountNotFound:
-			http.Error(w, "Account not found", http.StatusNotFound)
-		case account.ErrForbidden:
-			http.Error(w, "Forbidden", http.StatusForbidden)
-		case account.ErrAccountNoItem:
-			http.Error(w, "Account has no associated bank connection", http.StatusBadRequest)
+		switch {
+		case errors.Is(err, account.ErrAccountNoItem):
+			writeJSONError(w, http.StatusBadRequest, "Account has no associated bank connection")
 		default:
-			log.Printf("Error deleting bank for account %s: %v", accountID, err)
-			http.Error(w, "Failed to delete bank", http.StatusInternalServerError)
+			writeError(w, err, "Failed to delete bank")
 		}
 		return
 	}
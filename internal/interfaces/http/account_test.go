@@ -2,10 +2,12 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"parsa/internal/domain/account"
 	"parsa/internal/domain/transaction"
@@ -22,6 +24,18 @@ func (noopItemRepo) FindOrCreate(ctx context.Context, id string, userID int64) (
 func (noopItemRepo) ListByUserID(ctx context.Context, userID int64) ([]*models.Item, error) {
 	return nil, nil
 }
+func (noopItemRepo) ListSummariesByUserID(ctx context.Context, userID int64) ([]*models.ItemSummary, error) {
+	return nil, nil
+}
+func (noopItemRepo) GetByID(ctx context.Context, id string) (*models.Item, error) {
+	return nil, nil
+}
+func (noopItemRepo) Update(ctx context.Context, id string, params models.UpdateItemParams) (*models.Item, error) {
+	return nil, nil
+}
+func (noopItemRepo) SetNeedsReconnectByUserID(ctx context.Context, userID int64, value bool) error {
+	return nil
+}
 func (noopItemRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
@@ -47,6 +61,42 @@ func (noopTransactionRepo) ListByUserID(ctx context.Context, userID int64, limit
 func (noopTransactionRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
 	return 0, nil
 }
+func (noopTransactionRepo) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) ListByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) Recategorize(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	return false, nil
+}
+func (noopTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (noopTransactionRepo) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	return nil
+}
+func (noopTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	return nil
+}
+func (noopTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+	return nil, nil
+}
+func (noopTransactionRepo) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	return 0, nil
+}
 func (noopTransactionRepo) Update(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
 	return nil, nil
 }
@@ -72,12 +122,40 @@ func (noopTransactionRepo) GetTransactionTags(ctx context.Context, transactionID
 	return nil, nil
 }
 
+func (noopTransactionRepo) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	return nil
+}
+
+func (noopTransactionRepo) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) CountGroups(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+	return nil, nil
+}
+
+func (noopTransactionRepo) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	return 0, nil
+}
+func (noopTransactionRepo) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	return 0, nil
+}
+
 // MockAccountRepo implements account.Repository for testing
 type MockAccountRepo struct {
 	CreateFunc                 func(ctx context.Context, params account.CreateParams) (*account.Account, error)
 	GetByIDFunc                func(ctx context.Context, id string) (*account.Account, error)
 	ListByUserIDFunc           func(ctx context.Context, userID int64) ([]*account.Account, error)
 	ListByUserIDWithBankFunc   func(ctx context.Context, userID int64) ([]*account.AccountWithBank, error)
+	GetByIDWithBankFunc        func(ctx context.Context, id string) (*account.AccountWithBank, error)
 	DeleteFunc                 func(ctx context.Context, id string) error
 	UpdateFunc                 func(ctx context.Context, id string, params account.UpdateParams) (*account.Account, error)
 	UpsertFunc                 func(ctx context.Context, params account.UpsertParams) (*account.Account, error)
@@ -89,6 +167,9 @@ type MockAccountRepo struct {
 	RestoreFunc                func(ctx context.Context, id string) error
 	DeleteByItemIDFunc         func(ctx context.Context, itemID string) error
 	ListByItemIDFunc           func(ctx context.Context, itemID string) ([]*account.Account, error)
+	MarkRemovedFunc            func(ctx context.Context, ids []string) (int64, error)
+	RestoreProviderRemovedFunc func(ctx context.Context, id string) (bool, error)
+	MergeIntoFunc              func(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error)
 	DeleteBankDataFunc         func(ctx context.Context, itemID string) error
 }
 
@@ -169,6 +250,13 @@ func (m *MockAccountRepo) ListByUserIDWithBank(ctx context.Context, userID int64
 	return nil, nil
 }
 
+func (m *MockAccountRepo) GetByIDWithBank(ctx context.Context, id string) (*account.AccountWithBank, error) {
+	if m.GetByIDWithBankFunc != nil {
+		return m.GetByIDWithBankFunc(ctx, id)
+	}
+	return nil, nil
+}
+
 func (m *MockAccountRepo) SoftRemove(ctx context.Context, id string) error {
 	if m.SoftRemoveFunc != nil {
 		return m.SoftRemoveFunc(ctx, id)
@@ -197,6 +285,26 @@ func (m *MockAccountRepo) ListByItemID(ctx context.Context, itemID string) ([]*a
 	return nil, nil
 }
 
+func (m *MockAccountRepo) MarkRemoved(ctx context.Context, ids []string) (int64, error) {
+	if m.MarkRemovedFunc != nil {
+		return m.MarkRemovedFunc(ctx, ids)
+	}
+	return 0, nil
+}
+
+func (m *MockAccountRepo) RestoreProviderRemoved(ctx context.Context, id string) (bool, error) {
+	if m.RestoreProviderRemovedFunc != nil {
+		return m.RestoreProviderRemovedFunc(ctx, id)
+	}
+	return false, nil
+}
+
+func (m *MockAccountRepo) MergeInto(ctx context.Context, duplicateID, canonicalID string) (account.MergeCounts, error) {
+	if m.MergeIntoFunc != nil {
+		return m.MergeIntoFunc(ctx, duplicateID, canonicalID)
+	}
+	return account.MergeCounts{}, nil
+}
 func (m *MockAccountRepo) DeleteBankData(ctx context.Context, itemID string) error {
 	if m.DeleteBankDataFunc != nil {
 		return m.DeleteBankDataFunc(ctx, itemID)
@@ -271,6 +379,110 @@ func TestHandleListAccounts(t *testing.T) {
 	}
 }
 
+func TestHandleGetAccountByID(t *testing.T) {
+	tests := []struct {
+		name           string
+		accountID      string
+		getErr         error
+		expectedStatus int
+	}{
+		{name: "Success", accountID: "acc-1", expectedStatus: http.StatusOK},
+		{name: "Other User", accountID: "acc-2", expectedStatus: http.StatusNotFound},
+		{name: "Not Found", accountID: "acc-404", getErr: account.ErrAccountNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Repository Error", accountID: "acc-1", getErr: errors.New("db error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockAccountRepo{
+				GetByIDWithBankFunc: func(ctx context.Context, id string) (*account.AccountWithBank, error) {
+					if tt.getErr != nil {
+						return nil, tt.getErr
+					}
+					owner := int64(1)
+					if id == "acc-2" {
+						owner = 2
+					}
+					return &account.AccountWithBank{
+						Account:  account.Account{ID: id, UserID: owner, Name: "Checking", Subtype: "CHECKING_ACCOUNT"},
+						BankName: "Test Bank",
+					}, nil
+				},
+				ListByUserIDWithBankFunc: func(ctx context.Context, userID int64) ([]*account.AccountWithBank, error) {
+					t.Error("the single account lookup should not list all accounts")
+					return nil, nil
+				},
+			}
+			service := account.NewService(repo, noopItemRepo{}, noopTransactionRepo{})
+			handler := NewAccountHandler(service, nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/accounts/"+tt.accountID, nil)
+			req.SetPathValue("id", tt.accountID)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleAccountByID(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var resp AccountResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.AccountID != tt.accountID || resp.BankName != "Test Bank" {
+				t.Errorf("response = %+v, want account %s with its bank", resp, tt.accountID)
+			}
+		})
+	}
+}
+
+func TestToAccountResponse_MarketingName(t *testing.T) {
+	tests := []struct {
+		name string
+		acc  account.Account
+		want string
+	}{
+		{
+			name: "provider marketing name",
+			acc:  account.Account{Name: "Nubank", Subtype: "CREDIT_CARD", MarketingName: "Ultravioleta"},
+			want: "Ultravioleta",
+		},
+		{
+			name: "falls back to name",
+			acc:  account.Account{Name: "Nubank", Subtype: "CREDIT_CARD"},
+			want: "Nubank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := toAccountResponse(&account.AccountWithBank{Account: tt.acc})
+			if resp.MarketingName != tt.want {
+				t.Errorf("MarketingName = %q, want %q", resp.MarketingName, tt.want)
+			}
+		})
+	}
+}
+
+func TestToAccountResponse_ConsideredBalance(t *testing.T) {
+	acc := &account.AccountWithBank{
+		Account:           account.Account{Balance: 900, InitialBalance: 100},
+		ConsideredBalance: 750.5,
+	}
+
+	resp := toAccountResponse(acc)
+	if resp.ConsideredBalance != 750.5 {
+		t.Errorf("ConsideredBalance = %v, want 750.5", resp.ConsideredBalance)
+	}
+	if resp.Balance == nil || *resp.Balance != 900 {
+		t.Errorf("Balance = %v, want the provider balance 900", resp.Balance)
+	}
+}
+
 func TestHandleListAccounts_MethodNotAllowed(t *testing.T) {
 	repo := &MockAccountRepo{}
 	service := account.NewService(repo, noopItemRepo{}, noopTransactionRepo{})
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"parsa/internal/domain/attachment"
+	"parsa/internal/shared/middleware"
+)
+
+// multipartOverhead is the room left for multipart headers and boundaries on top of the
+// attachment size limit
+const multipartOverhead = 64 << 10 // 64 KiB
+
+// attachmentFormField is the multipart field carrying the uploaded file
+const attachmentFormField = "file"
+
+type AttachmentHandler struct {
+	attachmentService *attachment.Service
+}
+
+func NewAttachmentHandler(attachmentService *attachment.Service) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService}
+}
+
+// purgeDeletedAttachments deletes the blobs of attachments removed along with a transaction
+// or account. Failures leave the blobs queued for the next purge, so they are only logged.
+func purgeDeletedAttachments(ctx context.Context, attachmentService *attachment.Service) {
+	if attachmentService == nil {
+		return
+	}
+	if _, err := attachmentService.PurgeDeletedBlobs(context.WithoutCancel(ctx)); err != nil {
+		log.Printf("Error purging deleted attachment blobs: %v", err)
+	}
+}
+
+// HandleTransactionAttachments handles GET (list) and POST (upload) /api/transactions/{id}/attachments
+func (h *AttachmentHandler) HandleTransactionAttachments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleListAttachments(w, r)
+	case http.MethodPost:
+		h.handleUploadAttachment(w, r)
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleAttachmentByID handles GET (download) and DELETE /api/transactions/{id}/attachments/{attachmentId}
+func (h *AttachmentHandler) HandleAttachmentByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	attachmentID, err := strconv.ParseInt(r.PathValue("attachmentId"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := h.attachmentService.DeleteAttachment(r.Context(), userID, transactionID, attachmentID); err != nil {
+			writeAttachmentError(w, err, "Failed to delete attachment")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	att, content, err := h.attachmentService.OpenAttachment(r.Context(), userID, transactionID, attachmentID)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to get attachment")
+		return
+	}
+	defer content.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": att.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Type", att.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(att.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("Error streaming attachment %d: %v", att.ID, err)
+	}
+}
+
+// handleListAttachments handles GET /api/transactions/{id}/attachments
+func (h *AttachmentHandler) handleListAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to list attachments")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// handleUploadAttachment handles POST /api/transactions/{id}/attachments. The file is read
+// from the "file" field of a multipart/form-data body and streamed to storage.
+func (h *AttachmentHandler) handleUploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentService.MaxSize()+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Expected a multipart/form-data body")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			writeJSONError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, "File too large")
+				return
+			}
+			writeJSONError(w, http.StatusBadRequest, "Invalid multipart body")
+			return
+		}
+		if part.FormName() != attachmentFormField || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		att, err := h.attachmentService.Upload(r.Context(), userID, r.PathValue("id"), part.FileName(), part)
+		part.Close()
+		if err != nil {
+			writeAttachmentError(w, err, "Failed to upload attachment")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(att)
+		return
+	}
+}
+
+// writeAttachmentError maps upload validation errors to their status codes and defers
+// everything else to writeError
+func writeAttachmentError(w http.ResponseWriter, err error, message string) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, attachment.ErrTooManyAttachments):
+		writeJSONError(w, http.StatusConflict, "Transaction has reached the attachment limit")
+	case errors.Is(err, attachment.ErrFileTooLarge), errors.As(err, &maxBytesErr):
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "File too large")
+	case errors.Is(err, attachment.ErrUnsupportedType):
+		writeJSONError(w, http.StatusUnsupportedMediaType, "Unsupported file type (allowed: JPEG, PNG, WebP, PDF)")
+	case errors.Is(err, attachment.ErrEmptyFile):
+		writeJSONError(w, http.StatusBadRequest, "File is empty")
+	default:
+		writeError(w, err, message)
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"parsa/internal/domain/audit"
+)
+
+// AuditHandler exposes the audit log to admins
+type AuditHandler struct {
+	auditService *audit.Service
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService *audit.Service) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// HandleListAudit returns the audit history of a transaction, newest first
+// (GET /api/admin/audit?transactionId=...&limit=...). Admin access is enforced by middleware.
+func (h *AuditHandler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	transactionID := r.URL.Query().Get("transactionId")
+	if transactionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "transactionId is required")
+		return
+	}
+
+	limit := audit.DefaultListLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.auditService.ListEntityHistory(r.Context(), audit.EntityTransaction, transactionID, limit)
+	if err != nil {
+		writeError(w, err, "Failed to list audit entries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -6,19 +6,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
 	"strings"
 	"sync"
 
 	"parsa/internal/domain/user"
-	"parsa/internal/infrastructure/postgres"
 	"parsa/internal/shared/auth"
 	"parsa/internal/web"
 )
 
 type AuthHandler struct {
-	userRepo               *postgres.UserRepository
+	userRepo               user.Repository
 	oauthProvider          auth.OAuthProvider
 	appleOAuthProvider     auth.OAuthProvider
 	jwt                    *auth.JWT
@@ -28,10 +28,21 @@ type AuthHandler struct {
 	appleMobileCallbackURL string
 	mobileAppScheme        string
 	appleCallbackTemplate  *template.Template
+	appleTemplateErr       error
 	templateOnce           sync.Once
+	webFS                  fs.FS
+	cookieOptions          CookieOptions
 }
 
-func NewAuthHandler(userRepo *postgres.UserRepository, oauthProvider auth.OAuthProvider, jwt *auth.JWT, authCodeStore *auth.AuthCodeStore, mobileCallbackURL, webCallbackURL, mobileAppScheme string) *AuthHandler {
+// CookieOptions sets the auth cookie attributes. A nil Secure derives the flag from the
+// request (TLS or X-Forwarded-Proto); SameSite=None always forces Secure.
+type CookieOptions struct {
+	Domain   string
+	SameSite http.SameSite
+	Secure   *bool
+}
+
+func NewAuthHandler(userRepo user.Repository, oauthProvider auth.OAuthProvider, jwt *auth.JWT, authCodeStore *auth.AuthCodeStore, mobileCallbackURL, webCallbackURL, mobileAppScheme string) *AuthHandler {
 	return &AuthHandler{
 		userRepo:          userRepo,
 		oauthProvider:     oauthProvider,
@@ -40,15 +51,55 @@ func NewAuthHandler(userRepo *postgres.UserRepository, oauthProvider auth.OAuthP
 		mobileCallbackURL: mobileCallbackURL,
 		webCallbackURL:    webCallbackURL,
 		mobileAppScheme:   mobileAppScheme,
+		webFS:             web.FS,
+		cookieOptions:     CookieOptions{SameSite: http.SameSiteLaxMode},
 	}
 }
 
+// SetCookieOptions overrides the default auth cookie attributes (optional, called after construction)
+func (h *AuthHandler) SetCookieOptions(opts CookieOptions) {
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	h.cookieOptions = opts
+}
+
 // SetAppleOAuthProvider sets the Apple OAuth provider (optional, called after construction)
 func (h *AuthHandler) SetAppleOAuthProvider(provider auth.OAuthProvider, mobileCallbackURL string) {
 	h.appleOAuthProvider = provider
 	h.appleMobileCallbackURL = mobileCallbackURL
 }
 
+// AppleOAuthEnabled reports whether an Apple OAuth provider has been configured
+func (h *AuthHandler) AppleOAuthEnabled() bool {
+	return h.appleOAuthProvider != nil
+}
+
+// CheckAppleCallbackTemplate loads the Apple callback page template so a missing or broken
+// template is reported at startup instead of on the first sign-in. The callback still
+// works without it by redirecting straight to the app.
+func (h *AuthHandler) CheckAppleCallbackTemplate() error {
+	h.loadAppleCallbackTemplate()
+	return h.appleTemplateErr
+}
+
+func (h *AuthHandler) loadAppleCallbackTemplate() {
+	h.templateOnce.Do(func() {
+		h.appleCallbackTemplate, h.appleTemplateErr = template.ParseFS(h.webFS, "apple-oauth-callback.html")
+		if h.appleTemplateErr != nil {
+			log.Printf("Apple OAuth: Failed to load callback template: %v", h.appleTemplateErr)
+		}
+	})
+}
+
+// writeAppleNotConfigured answers Apple OAuth requests when Apple sign-in is disabled, using
+// the same JSON error shape as the mobile OAuth flow
+func writeAppleNotConfigured(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]string{"error": "apple_oauth_not_configured"})
+}
+
 type AuthURLResponse struct {
 	URL string `json:"url"`
 }
@@ -66,7 +117,7 @@ type AuthResponse struct {
 // HandleAuthURL generates the OAuth authorization URL (for web)
 func (h *AuthHandler) HandleAuthURL(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
@@ -86,7 +137,7 @@ func (h *AuthHandler) HandleAuthURL(w http.ResponseWriter, r *http.Request) {
 // HandleMobileAuthStart generates OAuth URL for mobile app.
 func (h *AuthHandler) HandleMobileAuthStart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
@@ -116,10 +167,17 @@ func (h *AuthHandler) mobileRedirectURL(param, value string) string {
 	return fmt.Sprintf("%s://oauth-callback?%s=%s", h.mobileAppScheme, param, value)
 }
 
+// oauthEmail returns the email the OAuth provider reported, trimmed. Apple private relay
+// addresses (@privaterelay.appleid.com) forward to the user and are kept unchanged. An empty
+// result means the provider did not share an email, so no account can be created.
+func oauthEmail(info *auth.OAuthUserInfo) string {
+	return strings.TrimSpace(info.Email)
+}
+
 // HandleCallback processes the OAuth callback for web (issues a JWT and sets cookie)
 func (h *AuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
@@ -155,10 +213,21 @@ func (h *AuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	// Find or create user
 	userModel, err := h.userRepo.GetByOAuth(ctx, "google", userInfo.ID)
 	if err != nil {
+		log.Printf("Error looking up OAuth user: %v", err)
+		http.Error(w, "Failed to look up user", http.StatusInternalServerError)
+		return
+	}
+	if userModel == nil {
 		// User doesn't exist, create new user
+		email := oauthEmail(userInfo)
+		if email == "" {
+			log.Printf("OAuth: google account %s did not share an email address", userInfo.ID)
+			http.Error(w, "Your Google account did not share an email address. Allow access to your email and try again.", http.StatusBadRequest)
+			return
+		}
 		provider := "google"
 		userModel, err = h.userRepo.Create(ctx, user.CreateUserParams{
-			Email:         userInfo.Email,
+			Email:         email,
 			Name:          userInfo.Name,
 			OAuthProvider: &provider,
 			OAuthID:       &userInfo.ID,
@@ -181,7 +250,7 @@ func (h *AuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set HttpOnly cookie with JWT
-	setAuthCookie(w, r, jwtToken)
+	h.setAuthCookie(w, r, jwtToken)
 
 	// Redirect to callback page
 	http.Redirect(w, r, "/oauth-callback", http.StatusFound)
@@ -190,7 +259,7 @@ func (h *AuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 // HandleMobileAuthCallback processes OAuth callback for mobile (returns JSON with JWT)
 func (h *AuthHandler) HandleMobileAuthCallback(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
@@ -236,10 +305,25 @@ func (h *AuthHandler) HandleMobileAuthCallback(w http.ResponseWriter, r *http.Re
 	// Find or create user
 	userModel, err := h.userRepo.GetByOAuth(ctx, "google", userInfo.ID)
 	if err != nil {
+		log.Printf("Mobile OAuth: Failed to look up user: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "user_lookup_failed"})
+		return
+	}
+	if userModel == nil {
 		// User doesn't exist, create new user
+		email := oauthEmail(userInfo)
+		if email == "" {
+			log.Printf("Mobile OAuth: google account %s did not share an email address", userInfo.ID)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "email_required"})
+			return
+		}
 		provider := "google"
 		userModel, err = h.userRepo.Create(ctx, user.CreateUserParams{
-			Email:         userInfo.Email,
+			Email:         email,
 			Name:          userInfo.Name,
 			OAuthProvider: &provider,
 			OAuthID:       &userInfo.ID,
@@ -280,12 +364,12 @@ func (h *AuthHandler) HandleMobileAuthCallback(w http.ResponseWriter, r *http.Re
 // HandleAppleMobileAuthStart generates Apple OAuth URL for mobile app.
 func (h *AuthHandler) HandleAppleMobileAuthStart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	if h.appleOAuthProvider == nil {
-		http.Error(w, "Apple OAuth not configured", http.StatusServiceUnavailable)
+		writeAppleNotConfigured(w)
 		return
 	}
 
@@ -306,12 +390,12 @@ func (h *AuthHandler) HandleAppleMobileAuthStart(w http.ResponseWriter, r *http.
 // Apple uses form_post response mode, so this handles POST requests
 func (h *AuthHandler) HandleAppleMobileAuthCallback(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	if h.appleOAuthProvider == nil {
-		http.Error(w, "Apple OAuth not configured", http.StatusServiceUnavailable)
+		writeAppleNotConfigured(w)
 		return
 	}
 
@@ -375,9 +459,21 @@ func (h *AuthHandler) HandleAppleMobileAuthCallback(w http.ResponseWriter, r *ht
 	// Find or create user
 	userModel, err := h.userRepo.GetByOAuth(ctx, "apple", userInfo.ID)
 	if err != nil {
+		log.Printf("Apple OAuth: Failed to look up user: %v", err)
+		h.renderAppleCallbackPage(w, r, "", "user_lookup_failed")
+		return
+	}
+	if userModel == nil {
+		// Apple only shares the email on the first authorization; private relay addresses are stored as-is
+		email := oauthEmail(userInfo)
+		if email == "" {
+			log.Printf("Apple OAuth: account %s did not share an email address", userInfo.ID)
+			h.renderAppleCallbackPage(w, r, "", "email_required")
+			return
+		}
 		provider := "apple"
 		userModel, err = h.userRepo.Create(ctx, user.CreateUserParams{
-			Email:         userInfo.Email,
+			Email:         email,
 			Name:          userInfo.Name,
 			OAuthProvider: &provider,
 			OAuthID:       &userInfo.ID,
@@ -422,12 +518,12 @@ type LoginRequest struct {
 // HandleRegister creates a new user with password authentication
 func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -475,7 +571,7 @@ func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	setAuthCookie(w, r, token)
+	h.setAuthCookie(w, r, token)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(AuthResponse{
 		Token: token,
@@ -486,12 +582,12 @@ func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 // HandleLogin authenticates a user with email and password
 func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -506,7 +602,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	// Get user by email
 	userModel, err := h.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
@@ -518,7 +614,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 
 	// Verify password
 	if err := auth.VerifyPassword(*userModel.PasswordHash, req.Password); err != nil {
-		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
@@ -529,7 +625,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	setAuthCookie(w, r, token)
+	h.setAuthCookie(w, r, token)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(AuthResponse{
 		Token: token,
@@ -540,36 +636,19 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 // HandleLogout clears the auth cookie
 func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
-	// Only set Secure flag when actually using HTTPS
-	secure := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
-
-	// Clear the cookie by setting MaxAge to -1
-	http.SetCookie(w, &http.Cookie{
-		Name:     "access_token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   secure,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   -1,
-	})
+	// Clear the cookie by setting MaxAge to -1 (attributes must match the ones it was set with)
+	http.SetCookie(w, h.authCookie(r, "", -1))
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // renderAppleCallbackPage renders the HTML redirect page for Apple OAuth callback.
 func (h *AuthHandler) renderAppleCallbackPage(w http.ResponseWriter, r *http.Request, authCode, errorMsg string) {
-	var templateErr error
-	h.templateOnce.Do(func() {
-		h.appleCallbackTemplate, templateErr = template.ParseFS(web.FS, "apple-oauth-callback.html")
-		if templateErr != nil {
-			log.Printf("Apple OAuth: Failed to load callback template: %v", templateErr)
-		}
-	})
+	h.loadAppleCallbackTemplate()
 
 	// If template failed to load, use fallback redirect
 	if h.appleCallbackTemplate == nil {
@@ -624,12 +703,12 @@ type AuthCodeExchangeRequest struct {
 // The auth code was issued during the OAuth callback redirect and is single-use with TTL.
 func (h *AuthHandler) HandleMobileAuthExchange(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	var req AuthCodeExchangeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -667,17 +746,29 @@ func (h *AuthHandler) HandleMobileAuthExchange(w http.ResponseWriter, r *http.Re
 }
 
 // setAuthCookie sets the JWT as an HttpOnly cookie
-func setAuthCookie(w http.ResponseWriter, r *http.Request, token string) {
-	// Only set Secure flag when actually using HTTPS
+func (h *AuthHandler) setAuthCookie(w http.ResponseWriter, r *http.Request, token string) {
+	http.SetCookie(w, h.authCookie(r, token, 30*24*60*60)) // 30 days (matches JWT expiration)
+}
+
+// authCookie builds the auth cookie with the configured attributes
+func (h *AuthHandler) authCookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	// By default only set Secure flag when actually using HTTPS
 	secure := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+	if h.cookieOptions.Secure != nil {
+		secure = *h.cookieOptions.Secure
+	}
+	if h.cookieOptions.SameSite == http.SameSiteNoneMode {
+		secure = true
+	}
 
-	http.SetCookie(w, &http.Cookie{
+	return &http.Cookie{
 		Name:     "access_token",
-		Value:    token,
+		Value:    value,
 		Path:     "/",
+		Domain:   h.cookieOptions.Domain,
 		HttpOnly: true,
 		Secure:   secure,
-		SameSite: http.SameSiteLaxMode,
-		MaxAge:   30 * 24 * 60 * 60, // 30 days (matches JWT expiration)
-	})
+		SameSite: h.cookieOptions.SameSite,
+		MaxAge:   maxAge,
+	}
 }
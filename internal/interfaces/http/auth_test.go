@@ -0,0 +1,351 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"parsa/internal/domain/user"
+	"parsa/internal/shared/auth"
+)
+
+// MockOAuthProvider implements auth.OAuthProvider for testing
+type MockOAuthProvider struct {
+	UserInfo *auth.OAuthUserInfo
+}
+
+func (m *MockOAuthProvider) GetAuthURL(state string, redirectURI ...string) string {
+	return "https://example.com/auth?state=" + state
+}
+
+func (m *MockOAuthProvider) ExchangeCode(ctx context.Context, code string, redirectURI ...string) (*auth.OAuthToken, error) {
+	return &auth.OAuthToken{AccessToken: "access-token"}, nil
+}
+
+func (m *MockOAuthProvider) GetUserInfo(ctx context.Context, token string) (*auth.OAuthUserInfo, error) {
+	return m.UserInfo, nil
+}
+
+func TestOAuthCallbacks_FindOrCreateUser(t *testing.T) {
+	existing := &user.User{ID: 7, Email: "existing@example.com"}
+
+	tests := []struct {
+		name        string
+		lookup      func(ctx context.Context, provider, oauthID string) (*user.User, error)
+		wantCreate  bool
+		wantFailure bool
+	}{
+		{
+			name: "lookup error does not create a user",
+			lookup: func(ctx context.Context, provider, oauthID string) (*user.User, error) {
+				return nil, errors.New("connection reset")
+			},
+			wantFailure: true,
+		},
+		{
+			name: "missing user is created",
+			lookup: func(ctx context.Context, provider, oauthID string) (*user.User, error) {
+				return nil, nil
+			},
+			wantCreate: true,
+		},
+		{
+			name: "existing user is reused",
+			lookup: func(ctx context.Context, provider, oauthID string) (*user.User, error) {
+				return existing, nil
+			},
+		},
+	}
+
+	callbacks := []struct {
+		name    string
+		request func() *http.Request
+		call    func(h *AuthHandler, w http.ResponseWriter, r *http.Request)
+		failed  func(rr *httptest.ResponseRecorder) bool
+	}{
+		{
+			name: "web",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/api/auth/oauth/callback?code=abc", nil)
+			},
+			call:   (*AuthHandler).HandleCallback,
+			failed: func(rr *httptest.ResponseRecorder) bool { return rr.Code == http.StatusInternalServerError },
+		},
+		{
+			name: "mobile",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/api/auth/oauth/mobile/callback?code=abc", nil)
+			},
+			call:   (*AuthHandler).HandleMobileAuthCallback,
+			failed: func(rr *httptest.ResponseRecorder) bool { return rr.Code == http.StatusInternalServerError },
+		},
+		{
+			name: "apple",
+			request: func() *http.Request {
+				form := url.Values{"code": {"abc"}}
+				req := httptest.NewRequest(http.MethodPost, "/api/auth/oauth/apple/mobile/callback", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			call: (*AuthHandler).HandleAppleMobileAuthCallback,
+			failed: func(rr *httptest.ResponseRecorder) bool {
+				return strings.Contains(rr.Body.String(), "user_lookup_failed")
+			},
+		},
+	}
+
+	for _, cb := range callbacks {
+		for _, tt := range tests {
+			t.Run(cb.name+"/"+tt.name, func(t *testing.T) {
+				created := false
+				userRepo := &MockUserRepo{
+					GetByOAuthFunc: tt.lookup,
+					CreateFunc: func(ctx context.Context, params user.CreateUserParams) (*user.User, error) {
+						created = true
+						return &user.User{ID: 8, Email: params.Email}, nil
+					},
+				}
+				provider := &MockOAuthProvider{UserInfo: &auth.OAuthUserInfo{ID: "oauth-1", Email: "new@example.com"}}
+				codeStore := auth.NewAuthCodeStore(time.Minute)
+				defer codeStore.Stop()
+
+				handler := NewAuthHandler(userRepo, provider, auth.NewJWT("test-secret"), codeStore, "", "", "parsa")
+				handler.SetAppleOAuthProvider(provider, "")
+
+				rr := httptest.NewRecorder()
+				cb.call(handler, rr, cb.request())
+
+				if created != tt.wantCreate {
+					t.Errorf("Create called = %v, want %v", created, tt.wantCreate)
+				}
+				if failed := cb.failed(rr); failed != tt.wantFailure {
+					t.Errorf("lookup failure reported = %v, want %v (status %d)", failed, tt.wantFailure, rr.Code)
+				}
+			})
+		}
+	}
+}
+
+func TestOAuthCallbacks_EmptyEmail(t *testing.T) {
+	callbacks := []struct {
+		name       string
+		request    func() *http.Request
+		call       func(h *AuthHandler, w http.ResponseWriter, r *http.Request)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "web",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/api/auth/oauth/callback?code=abc", nil)
+			},
+			call:       (*AuthHandler).HandleCallback,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "did not share an email address",
+		},
+		{
+			name: "mobile",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/api/auth/oauth/mobile/callback?code=abc", nil)
+			},
+			call:       (*AuthHandler).HandleMobileAuthCallback,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "email_required",
+		},
+		{
+			name: "apple",
+			request: func() *http.Request {
+				form := url.Values{"code": {"abc"}}
+				req := httptest.NewRequest(http.MethodPost, "/api/auth/oauth/apple/mobile/callback", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			call:     (*AuthHandler).HandleAppleMobileAuthCallback,
+			wantBody: "email_required",
+		},
+	}
+
+	for _, cb := range callbacks {
+		for _, email := range []string{"", "   "} {
+			t.Run(fmt.Sprintf("%s/%q", cb.name, email), func(t *testing.T) {
+				userRepo := &MockUserRepo{
+					GetByOAuthFunc: func(ctx context.Context, provider, oauthID string) (*user.User, error) {
+						return nil, nil
+					},
+					CreateFunc: func(ctx context.Context, params user.CreateUserParams) (*user.User, error) {
+						t.Errorf("Create called with email %q, want no user created", params.Email)
+						return &user.User{ID: 8, Email: params.Email}, nil
+					},
+				}
+				provider := &MockOAuthProvider{UserInfo: &auth.OAuthUserInfo{ID: "oauth-1", Email: email}}
+				codeStore := auth.NewAuthCodeStore(time.Minute)
+				defer codeStore.Stop()
+
+				handler := NewAuthHandler(userRepo, provider, auth.NewJWT("test-secret"), codeStore, "", "", "parsa")
+				handler.SetAppleOAuthProvider(provider, "")
+
+				rr := httptest.NewRecorder()
+				cb.call(handler, rr, cb.request())
+
+				if cb.wantStatus != 0 && rr.Code != cb.wantStatus {
+					t.Errorf("status = %d, want %d", rr.Code, cb.wantStatus)
+				}
+				body := rr.Body.String() + rr.Header().Get("Location")
+				if !strings.Contains(body, cb.wantBody) {
+					t.Errorf("response %q does not mention %q", body, cb.wantBody)
+				}
+			})
+		}
+	}
+}
+
+func TestOAuthCallbacks_ExistingUserWithoutEmail(t *testing.T) {
+	// Apple omits the email after the first authorization; known users still sign in
+	userRepo := &MockUserRepo{
+		GetByOAuthFunc: func(ctx context.Context, provider, oauthID string) (*user.User, error) {
+			return &user.User{ID: 7, Email: "abc123@privaterelay.appleid.com"}, nil
+		},
+	}
+	provider := &MockOAuthProvider{UserInfo: &auth.OAuthUserInfo{ID: "oauth-1"}}
+	handler := NewAuthHandler(userRepo, provider, auth.NewJWT("test-secret"), nil, "", "", "parsa")
+
+	rr := httptest.NewRecorder()
+	handler.HandleCallback(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oauth/callback?code=abc", nil))
+
+	if rr.Code != http.StatusFound {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusFound)
+	}
+}
+
+func TestOAuthEmail(t *testing.T) {
+	relay := "abc123@privaterelay.appleid.com"
+	if got := oauthEmail(&auth.OAuthUserInfo{Email: " " + relay + " "}); got != relay {
+		t.Errorf("oauthEmail = %q, want %q", got, relay)
+	}
+	if got := oauthEmail(&auth.OAuthUserInfo{}); got != "" {
+		t.Errorf("oauthEmail(empty) = %q, want empty", got)
+	}
+}
+
+func TestAppleOAuth_NotConfigured(t *testing.T) {
+	handler := NewAuthHandler(&MockUserRepo{}, &MockOAuthProvider{}, auth.NewJWT("test-secret"), nil, "", "", "parsa")
+
+	if handler.AppleOAuthEnabled() {
+		t.Fatal("AppleOAuthEnabled() = true without a provider")
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		call func(w http.ResponseWriter, r *http.Request)
+	}{
+		{name: "start", req: httptest.NewRequest(http.MethodGet, "/api/auth/oauth/apple/mobile/start", nil), call: handler.HandleAppleMobileAuthStart},
+		{name: "callback", req: httptest.NewRequest(http.MethodPost, "/api/auth/oauth/apple/mobile/callback", nil), call: handler.HandleAppleMobileAuthCallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.call(rr, tt.req)
+
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Errorf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("response is not JSON: %v", err)
+			}
+			if body["error"] != "apple_oauth_not_configured" {
+				t.Errorf("error = %q, want apple_oauth_not_configured", body["error"])
+			}
+		})
+	}
+}
+
+func TestCheckAppleCallbackTemplate(t *testing.T) {
+	handler := NewAuthHandler(&MockUserRepo{}, &MockOAuthProvider{}, auth.NewJWT("test-secret"), nil, "", "", "parsa")
+	if err := handler.CheckAppleCallbackTemplate(); err != nil {
+		t.Errorf("CheckAppleCallbackTemplate() with embedded template failed: %v", err)
+	}
+
+	missing := NewAuthHandler(&MockUserRepo{}, &MockOAuthProvider{}, auth.NewJWT("test-secret"), nil, "", "", "parsa")
+	missing.webFS = fstest.MapFS{}
+	if err := missing.CheckAppleCallbackTemplate(); err == nil {
+		t.Error("CheckAppleCallbackTemplate() expected error for missing template, got nil")
+	}
+
+	// Without the template the callback falls back to redirecting to the app
+	rr := httptest.NewRecorder()
+	missing.renderAppleCallbackPage(rr, httptest.NewRequest(http.MethodPost, "/", nil), "code-1", "")
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "parsa://oauth-callback?code=code-1" {
+		t.Errorf("fallback = %d %q, want redirect to parsa://oauth-callback?code=code-1", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestAuthCookie_Options(t *testing.T) {
+	secure, insecure := true, false
+
+	tests := []struct {
+		name         string
+		opts         *CookieOptions
+		https        bool
+		wantDomain   string
+		wantSameSite http.SameSite
+		wantSecure   bool
+	}{
+		{name: "defaults over http", wantSameSite: http.SameSiteLaxMode, wantSecure: false},
+		{name: "defaults over https", https: true, wantSameSite: http.SameSiteLaxMode, wantSecure: true},
+		{
+			name:         "domain and strict",
+			opts:         &CookieOptions{Domain: "example.com", SameSite: http.SameSiteStrictMode},
+			wantDomain:   "example.com",
+			wantSameSite: http.SameSiteStrictMode,
+		},
+		{name: "secure override", opts: &CookieOptions{Secure: &secure}, wantSameSite: http.SameSiteLaxMode, wantSecure: true},
+		{name: "insecure override", opts: &CookieOptions{Secure: &insecure}, https: true, wantSameSite: http.SameSiteLaxMode, wantSecure: false},
+		{name: "samesite none forces secure", opts: &CookieOptions{SameSite: http.SameSiteNoneMode, Secure: &insecure}, wantSameSite: http.SameSiteNoneMode, wantSecure: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuthHandler(&MockUserRepo{}, &MockOAuthProvider{}, auth.NewJWT("test-secret"), nil, "", "", "parsa")
+			if tt.opts != nil {
+				handler.SetCookieOptions(*tt.opts)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+			if tt.https {
+				req.Header.Set("X-Forwarded-Proto", "https")
+			}
+
+			// Setting and clearing the cookie must use the same attributes
+			setRR := httptest.NewRecorder()
+			handler.setAuthCookie(setRR, req, "token")
+			logoutRR := httptest.NewRecorder()
+			handler.HandleLogout(logoutRR, req)
+
+			for name, rr := range map[string]*httptest.ResponseRecorder{"set": setRR, "logout": logoutRR} {
+				cookies := rr.Result().Cookies()
+				if len(cookies) != 1 {
+					t.Fatalf("%s: got %d cookies, want 1", name, len(cookies))
+				}
+				c := cookies[0]
+				if c.Domain != tt.wantDomain || c.SameSite != tt.wantSameSite || c.Secure != tt.wantSecure {
+					t.Errorf("%s: cookie domain=%q samesite=%v secure=%v, want %q %v %v",
+						name, c.Domain, c.SameSite, c.Secure, tt.wantDomain, tt.wantSameSite, tt.wantSecure)
+				}
+			}
+			if c := logoutRR.Result().Cookies()[0]; c.MaxAge >= 0 {
+				t.Errorf("logout cookie MaxAge = %d, want negative", c.MaxAge)
+			}
+		})
+	}
+}
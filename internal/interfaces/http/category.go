@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"parsa/internal/domain/transaction"
+)
+
+// CategoryListResponse is the body of GET /api/categories
+type CategoryListResponse struct {
+	Version    int                         `json:"version"`
+	Categories []transaction.CategoryEntry `json:"categories"`
+	ParsaNames []string                    `json:"parsaNames"`
+}
+
+// CategoryHandler serves the category mapping. The mapping is fixed at build time, so the
+// response is serialized once and identified by an ETag derived from its version.
+type CategoryHandler struct {
+	body []byte
+	etag string
+}
+
+func NewCategoryHandler() (*CategoryHandler, error) {
+	body, err := json.Marshal(CategoryListResponse{
+		Version:    transaction.CategoryMappingVersion,
+		Categories: transaction.Categories(),
+		ParsaNames: transaction.ParsaNames(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode categories: %w", err)
+	}
+	return &CategoryHandler{
+		body: append(body, '\n'),
+		etag: fmt.Sprintf(`"categories-v%d"`, transaction.CategoryMappingVersion),
+	}, nil
+}
+
+// HandleListCategories handles GET /api/categories. Answers 304 when If-None-Match carries
+// the current ETag.
+func (h *CategoryHandler) HandleListCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("ETag", h.etag)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if etagMatches(r.Header.Get("If-None-Match"), h.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.body)
+}
+
+// etagMatches reports whether an If-None-Match header value names etag. Weak validators
+// match too, as RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
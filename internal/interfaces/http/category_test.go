@@ -0,0 +1,86 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"parsa/internal/domain/transaction"
+)
+
+func TestHandleListCategories(t *testing.T) {
+	handler, err := NewCategoryHandler()
+	if err != nil {
+		t.Fatalf("NewCategoryHandler: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleListCategories(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+
+	var resp CategoryListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Version != transaction.CategoryMappingVersion {
+		t.Errorf("version = %d, want %d", resp.Version, transaction.CategoryMappingVersion)
+	}
+	if len(resp.Categories) != len(transaction.CategoryMapping) {
+		t.Errorf("got %d categories, want %d", len(resp.Categories), len(transaction.CategoryMapping))
+	}
+	if len(resp.ParsaNames) == 0 {
+		t.Error("parsaNames is empty")
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{name: "matching etag", ifNoneMatch: etag, want: http.StatusNotModified},
+		{name: "weak matching etag", ifNoneMatch: "W/" + etag, want: http.StatusNotModified},
+		{name: "one of several", ifNoneMatch: `"other", ` + etag, want: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: "*", want: http.StatusNotModified},
+		{name: "stale etag", ifNoneMatch: `"categories-v0"`, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/categories", nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			rr := httptest.NewRecorder()
+			handler.HandleListCategories(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d", rr.Code, tt.want)
+			}
+			if tt.want == http.StatusNotModified && rr.Body.Len() != 0 {
+				t.Errorf("304 response has a body: %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleListCategories_MethodNotAllowed(t *testing.T) {
+	handler, err := NewCategoryHandler()
+	if err != nil {
+		t.Fatalf("NewCategoryHandler: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/categories", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleListCategories(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,245 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"parsa/internal/domain/cousin"
+	"parsa/internal/domain/transaction"
+	"parsa/internal/shared/middleware"
+)
+
+// maxCousinAssignIDs caps the number of transaction IDs accepted in a single assign/unassign request
+const maxCousinAssignIDs = 500
+
+type CousinHandler struct {
+	cousinService *cousin.Service
+}
+
+func NewCousinHandler(cousinService *cousin.Service) *CousinHandler {
+	return &CousinHandler{cousinService: cousinService}
+}
+
+// CreateCousinRequest is the request body for creating a cousin
+type CreateCousinRequest struct {
+	Name         string  `json:"name"`
+	BusinessName *string `json:"businessName,omitempty"`
+}
+
+// CousinAssignRequest lists the transactions to assign to (or remove from) a cousin
+type CousinAssignRequest struct {
+	TransactionIDs []string `json:"transactionIds"`
+}
+
+// CousinResponse is the API response format for a cousin
+type CousinResponse struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	BusinessName *string `json:"businessName,omitempty"`
+	CreatedAt    string  `json:"createdAt"`
+	UpdatedAt    string  `json:"updatedAt"`
+}
+
+// CousinAssignItemResult represents the result for a single transaction ID
+type CousinAssignItemResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CousinAssignResponse is the response for assigning or unassigning transactions
+type CousinAssignResponse struct {
+	TotalCount   int                      `json:"totalCount"`
+	SuccessCount int                      `json:"successCount"`
+	FailureCount int                      `json:"failureCount"`
+	Results      []CousinAssignItemResult `json:"results"`
+}
+
+func toCousinResponse(c *cousin.Cousin) CousinResponse {
+	return CousinResponse{
+		ID:           c.ID,
+		Name:         c.Name,
+		BusinessName: c.BusinessName,
+		CreatedAt:    c.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:    c.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// HandleCousins handles GET /api/cousins/ (list) and POST /api/cousins/ (create)
+func (h *CousinHandler) HandleCousins(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleListCousins(w, r)
+	case http.MethodPost:
+		h.handleCreateCousin(w, r)
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleCousinByID handles GET/DELETE /api/cousins/{id}
+func (h *CousinHandler) HandleCousinByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	cousinID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid cousin ID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		c, err := h.cousinService.GetCousin(r.Context(), cousinID, userID)
+		if err != nil {
+			writeError(w, err, "Failed to get cousin")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(toCousinResponse(c))
+	case http.MethodDelete:
+		if err := h.cousinService.DeleteCousin(r.Context(), cousinID, userID); err != nil {
+			writeError(w, err, "Failed to delete cousin")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleCousinTransactions handles POST (assign) and DELETE (unassign) /api/cousins/{id}/transactions
+func (h *CousinHandler) HandleCousinTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	cousinID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid cousin ID")
+		return
+	}
+
+	var req CousinAssignRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding cousin assign request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.TransactionIDs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "transactionIds is required")
+		return
+	}
+	if len(req.TransactionIDs) > maxCousinAssignIDs {
+		writeJSONError(w, http.StatusBadRequest, "Too many transaction IDs (max 500)")
+		return
+	}
+
+	var results []cousin.AssignResult
+	if r.Method == http.MethodPost {
+		results, err = h.cousinService.AssignTransactions(r.Context(), userID, cousinID, req.TransactionIDs)
+	} else {
+		results, err = h.cousinService.UnassignTransactions(r.Context(), userID, cousinID, req.TransactionIDs)
+	}
+	if err != nil {
+		writeError(w, err, "Failed to update cousin transactions")
+		return
+	}
+
+	response := CousinAssignResponse{
+		TotalCount: len(results),
+		Results:    make([]CousinAssignItemResult, 0, len(results)),
+	}
+	for _, result := range results {
+		item := CousinAssignItemResult{ID: result.TransactionID, Success: result.Err == nil}
+		switch {
+		case result.Err == nil:
+			response.SuccessCount++
+		case errors.Is(result.Err, transaction.ErrTransactionNotFound):
+			item.Error = "Transaction not found"
+		default:
+			log.Printf("Error updating cousin %d for transaction %s: %v", cousinID, result.TransactionID, result.Err)
+			item.Error = "Failed to update transaction"
+		}
+		if !item.Success {
+			response.FailureCount++
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleListCousins handles GET /api/cousins/
+func (h *CousinHandler) handleListCousins(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	cousins, err := h.cousinService.ListCousins(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing cousins for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list cousins")
+		return
+	}
+
+	response := make([]CousinResponse, 0, len(cousins))
+	for _, c := range cousins {
+		response = append(response, toCousinResponse(c))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleCreateCousin handles POST /api/cousins/
+func (h *CousinHandler) handleCreateCousin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req CreateCousinRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding create cousin request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	params := cousin.CreateCousinParams{
+		Name:         req.Name,
+		BusinessName: req.BusinessName,
+	}
+	if err := params.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, err := h.cousinService.CreateCousin(r.Context(), userID, params)
+	if err != nil {
+		log.Printf("Error creating cousin for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create cousin")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCousinResponse(c))
+}
@@ -2,10 +2,12 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"parsa/internal/domain/cousinrule"
 	"parsa/internal/shared/middleware"
@@ -42,10 +44,44 @@ type ApplyRuleChangeRequest struct {
 
 // ApplyRuleResponse is the response for applying a cousin rule
 type ApplyRuleResponse struct {
-	Message             string `json:"message"`
-	TransactionsUpdated int    `json:"transactionsUpdated"`
-	RuleCreated         bool   `json:"ruleCreated"`
-	RuleUpdated         bool   `json:"ruleUpdated"`
+	Message             string   `json:"message"`
+	TransactionsUpdated int      `json:"transactionsUpdated"`
+	TransactionIDs      []string `json:"transactionIds"`
+	RuleCreated         bool     `json:"ruleCreated"`
+	RuleUpdated         bool     `json:"ruleUpdated"`
+}
+
+// CousinRulePreviewRequest is the request body for previewing a cousin rule
+type CousinRulePreviewRequest struct {
+	Type *string `json:"type,omitempty"` // "DEBIT" or "CREDIT", omitted matches both
+}
+
+// CousinRuleApplyRequest is the request body for applying a cousin rule to a cousin's transactions
+type CousinRuleApplyRequest struct {
+	Type         *string                `json:"type,omitempty"` // Same filter as the preview
+	CreateRule   bool                   `json:"createRule"`
+	DontAskAgain bool                   `json:"dontAskAgain"`
+	Changes      ApplyRuleChangeRequest `json:"changes"`
+}
+
+// CousinRulePreviewResponse lists the transactions an apply would change
+type CousinRulePreviewResponse struct {
+	Count        int                        `json:"count"`
+	CountByType  map[string]int             `json:"countByType"`
+	Transactions []CousinRulePreviewTxnItem `json:"transactions"`
+}
+
+// CousinRulePreviewTxnItem is a matching transaction in a preview
+type CousinRulePreviewTxnItem struct {
+	ID              string  `json:"id"`
+	AccountID       string  `json:"accountId"`
+	Amount          float64 `json:"amount"`
+	Description     string  `json:"description"`
+	Category        *string `json:"category,omitempty"`
+	TransactionDate string  `json:"transactionDate"`
+	Type            string  `json:"type"`
+	Considered      bool    `json:"considered"`
+	Notes           *string `json:"notes,omitempty"`
 }
 
 // CousinRuleAPIResponse is the API response format for a cousin rule
@@ -61,6 +97,9 @@ type CousinRuleAPIResponse struct {
 	Tags         []string `json:"tags"`
 	CreatedAt    string   `json:"createdAt"`
 	UpdatedAt    string   `json:"updatedAt"`
+
+	// TransactionCount is only set when listing with ?withCounts=true
+	TransactionCount *int64 `json:"transactionCount,omitempty"`
 }
 
 // HandleCousinRules handles POST /api/cousin-rules/ for applying rules
@@ -71,7 +110,7 @@ func (h *CousinRuleHandler) HandleCousinRules(w http.ResponseWriter, r *http.Req
 	case http.MethodGet:
 		h.handleListRules(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
@@ -79,7 +118,7 @@ func (h *CousinRuleHandler) HandleCousinRules(w http.ResponseWriter, r *http.Req
 func (h *CousinRuleHandler) HandleCousinRuleByID(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -92,7 +131,7 @@ func (h *CousinRuleHandler) HandleCousinRuleByID(w http.ResponseWriter, r *http.
 
 	ruleID, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid rule ID")
 		return
 	}
 
@@ -102,67 +141,163 @@ func (h *CousinRuleHandler) HandleCousinRuleByID(w http.ResponseWriter, r *http.
 	case http.MethodDelete:
 		h.handleDeleteRule(w, r, ruleID, userID)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HandleCousinRuleAction handles POST /api/cousin-rules/{cousinId}/preview and
+// POST /api/cousin-rules/{cousinId}/apply
+func (h *CousinRuleHandler) HandleCousinRuleAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	cousinID, err := strconv.ParseInt(r.PathValue("cousinId"), 10, 64)
+	if err != nil || cousinID <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "Invalid cousin ID")
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "preview":
+		h.handlePreviewRule(w, r, userID, cousinID)
+	case "apply":
+		h.handleApplyCousinRule(w, r, userID, cousinID)
+	default:
+		http.NotFound(w, r)
 	}
 }
 
+// handlePreviewRule handles POST /api/cousin-rules/{cousinId}/preview
+func (h *CousinRuleHandler) handlePreviewRule(w http.ResponseWriter, r *http.Request, userID, cousinID int64) {
+	// The body is optional: without one the preview matches both types
+	var req CousinRulePreviewRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+			log.Printf("Error decoding preview rule request: %v", err)
+			writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	result, err := h.cousinRuleService.PreviewRule(r.Context(), userID, cousinID, req.Type)
+	if err != nil {
+		if err == cousinrule.ErrInvalidType {
+			writeJSONError(w, http.StatusBadRequest, "type must be DEBIT or CREDIT")
+			return
+		}
+		log.Printf("Error previewing cousin rule %d for user %d: %v", cousinID, userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to preview rule")
+		return
+	}
+
+	response := CousinRulePreviewResponse{
+		Count:        result.Count,
+		CountByType:  result.CountByType,
+		Transactions: make([]CousinRulePreviewTxnItem, 0, len(result.Transactions)),
+	}
+	for _, txn := range result.Transactions {
+		response.Transactions = append(response.Transactions, CousinRulePreviewTxnItem{
+			ID:              txn.ID,
+			AccountID:       txn.AccountID,
+			Amount:          txn.Amount,
+			Description:     txn.Description,
+			Category:        txn.Category,
+			TransactionDate: txn.TransactionDate.Format("2006-01-02T15:04:05Z07:00"),
+			Type:            txn.Type,
+			Considered:      txn.Considered,
+			Notes:           txn.Notes,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleApplyCousinRule handles POST /api/cousin-rules/{cousinId}/apply
+func (h *CousinRuleHandler) handleApplyCousinRule(w http.ResponseWriter, r *http.Request, userID, cousinID int64) {
+	var req CousinRuleApplyRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding apply cousin rule request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	params := cousinrule.ApplyRuleParams{
+		CousinID:     cousinID,
+		Type:         req.Type,
+		CreateRule:   req.CreateRule,
+		DontAskAgain: req.DontAskAgain,
+		Changes:      toRuleChanges(req.Changes),
+	}
+
+	h.applyRule(w, r, userID, params)
+}
+
 // handleApplyRule handles POST /api/cousin-rules/ - apply rule to transactions
 func (h *CousinRuleHandler) handleApplyRule(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	var req ApplyRuleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		log.Printf("Error decoding apply rule request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.CousinID == 0 {
-		http.Error(w, "cousinId is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "cousinId is required")
 		return
 	}
 
-	// Convert status to considered boolean (direct considered takes precedence)
-	var considered *bool
-	if req.Changes.Considered != nil {
-		considered = req.Changes.Considered
-	} else if req.Changes.Status != nil {
-		isConsidered := *req.Changes.Status == "reconciled"
-		considered = &isConsidered
-	}
-
 	params := cousinrule.ApplyRuleParams{
 		CousinID:     req.CousinID,
 		TriggeringID: req.TriggeringID,
 		CreateRule:   req.CreateRule,
 		DontAskAgain: req.DontAskAgain,
-		Changes: cousinrule.Changes{
-			Category:    req.Changes.Category,
-			Description: req.Changes.Description,
-			Notes:       req.Changes.Notes,
-			Considered:  considered,
-			Tags:        req.Changes.Tags,
-		},
+		Changes:      toRuleChanges(req.Changes),
 	}
 
+	h.applyRule(w, r, userID, params)
+}
+
+// applyRule runs the apply and writes the shared apply response
+func (h *CousinRuleHandler) applyRule(w http.ResponseWriter, r *http.Request, userID int64, params cousinrule.ApplyRuleParams) {
 	result, err := h.cousinRuleService.ApplyRule(r.Context(), userID, params)
 	if err != nil {
 		if err == cousinrule.ErrNoChanges {
-			http.Error(w, "No changes provided", http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, "No changes provided")
+			return
+		}
+		if err == cousinrule.ErrInvalidType {
+			writeJSONError(w, http.StatusBadRequest, "type must be DEBIT or CREDIT")
 			return
 		}
 		log.Printf("Error applying cousin rule for user %d: %v", userID, err)
-		http.Error(w, "Failed to apply rule", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to apply rule")
 		return
 	}
 
+	transactionIDs := result.TransactionIDs
+	if transactionIDs == nil {
+		transactionIDs = []string{}
+	}
+
 	response := ApplyRuleResponse{
 		Message:             "Rules applied successfully",
 		TransactionsUpdated: result.TransactionsUpdated,
+		TransactionIDs:      transactionIDs,
 		RuleCreated:         result.RuleCreated,
 		RuleUpdated:         result.RuleUpdated,
 	}
@@ -172,44 +307,227 @@ func (h *CousinRuleHandler) handleApplyRule(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleListRules handles GET /api/cousin-rules/ - list all rules for user
+// CousinRuleListResponse is the paginated body of GET /api/cousin-rules/
+type CousinRuleListResponse struct {
+	Count    int64                   `json:"count"`
+	Next     *string                 `json:"next"`
+	Previous *string                 `json:"previous"`
+	Results  []CousinRuleAPIResponse `json:"results"`
+}
+
+// handleListRules handles GET /api/cousin-rules/ - lists the user's rules a page (?page=) at a time
+// (?withCounts=true adds the number of transactions each rule matches)
 func (h *CousinRuleHandler) handleListRules(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	page := parsePage(r)
+	offset := (page - 1) * pageSize
+
+	count, err := h.cousinRuleService.CountRulesByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error counting cousin rules for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to count rules")
 		return
 	}
 
-	rules, err := h.cousinRuleService.ListRulesByUser(r.Context(), userID)
+	var results []CousinRuleAPIResponse
+	if withCounts, _ := strconv.ParseBool(r.URL.Query().Get("withCounts")); withCounts {
+		results, err = h.listRulesWithCounts(r, userID, offset)
+	} else {
+		results, err = h.listRules(r, userID, offset)
+	}
 	if err != nil {
 		log.Printf("Error listing cousin rules for user %d: %v", userID, err)
-		http.Error(w, "Failed to list rules", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list rules")
 		return
 	}
 
+	next, previous, _ := buildPagination(r, count, page, pageSize)
+	setPaginationHeaders(w, r, count, page, pageSize)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CousinRuleListResponse{
+		Count:    count,
+		Next:     next,
+		Previous: previous,
+		Results:  results,
+	})
+}
+
+// listRules returns one page of rules in API form
+func (h *CousinRuleHandler) listRules(r *http.Request, userID int64, offset int) ([]CousinRuleAPIResponse, error) {
+	rules, err := h.cousinRuleService.ListRulesByUser(r.Context(), userID, pageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+
 	results := make([]CousinRuleAPIResponse, 0, len(rules))
 	for _, rule := range rules {
 		results = append(results, toCousinRuleAPIResponse(rule))
 	}
+	return results, nil
+}
+
+// listRulesWithCounts returns one page of rules in API form with their transaction counts
+func (h *CousinRuleHandler) listRulesWithCounts(r *http.Request, userID int64, offset int) ([]CousinRuleAPIResponse, error) {
+	rules, err := h.cousinRuleService.ListRulesByUserWithCounts(r.Context(), userID, pageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CousinRuleAPIResponse, 0, len(rules))
+	for _, rule := range rules {
+		response := toCousinRuleAPIResponse(rule.Rule)
+		count := rule.TransactionCount
+		response.TransactionCount = &count
+		results = append(results, response)
+	}
+	return results, nil
+}
+
+// CousinRuleExport is the body of GET /api/cousin-rules/export and, read back, of
+// POST /api/cousin-rules/import
+type CousinRuleExport struct {
+	ExportedAt string                  `json:"exportedAt"`
+	Rules      []CousinRuleAPIResponse `json:"rules"`
+}
+
+// CousinRuleImportRequest is the body of POST /api/cousin-rules/import. An export file is a
+// valid request; its ids and timestamps are ignored.
+type CousinRuleImportRequest struct {
+	Rules []CousinRuleImportItem `json:"rules"`
+}
+
+// CousinRuleImportItem is one rule to import
+type CousinRuleImportItem struct {
+	CousinID     int64    `json:"cousinId"`
+	Type         *string  `json:"type,omitempty"`
+	Category     *string  `json:"category,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	Notes        *string  `json:"notes,omitempty"`
+	Considered   *bool    `json:"considered,omitempty"`
+	DontAskAgain bool     `json:"dontAskAgain"`
+	Tags         []string `json:"tags"`
+}
+
+// CousinRuleImportResponse reports the outcome of an import
+type CousinRuleImportResponse struct {
+	Created int                    `json:"created"`
+	Updated int                    `json:"updated"`
+	Skipped []CousinRuleImportSkip `json:"skipped"`
+}
+
+// CousinRuleImportSkip is an entry left out of an import, by its index in the request
+type CousinRuleImportSkip struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+// HandleExportRules handles GET /api/cousin-rules/export - every rule of the user, with tags
+func (h *CousinRuleHandler) HandleExportRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	rules, err := h.cousinRuleService.ListAllRulesByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error exporting cousin rules for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to export rules")
+		return
+	}
+
+	export := CousinRuleExport{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Rules:      make([]CousinRuleAPIResponse, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		export.Rules = append(export.Rules, toCousinRuleAPIResponse(rule))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="cousin-rules.json"`)
+	json.NewEncoder(w).Encode(export)
+}
+
+// HandleImportRules handles POST /api/cousin-rules/import - upserts the given rules for the
+// user and reports how many were created, updated and skipped
+func (h *CousinRuleHandler) HandleImportRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req CousinRuleImportRequest
+	if err := decodeJSON(w, r, &req, importDecodeOptions); err != nil {
+		log.Printf("Error decoding cousin rule import: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Rules) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "rules is required")
+		return
+	}
+
+	params := make([]cousinrule.CreateCousinRuleParams, 0, len(req.Rules))
+	for _, item := range req.Rules {
+		params = append(params, cousinrule.CreateCousinRuleParams{
+			CousinID:     item.CousinID,
+			Type:         item.Type,
+			Category:     item.Category,
+			Description:  item.Description,
+			Notes:        item.Notes,
+			Considered:   item.Considered,
+			DontAskAgain: item.DontAskAgain,
+			Tags:         item.Tags,
+		})
+	}
+
+	result, err := h.cousinRuleService.ImportRules(r.Context(), userID, params)
+	if errors.Is(err, cousinrule.ErrUnknownCousin) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Error importing cousin rules for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to import rules")
+		return
+	}
+
+	response := CousinRuleImportResponse{
+		Created: result.Created,
+		Updated: result.Updated,
+		Skipped: make([]CousinRuleImportSkip, 0, len(result.Skipped)),
+	}
+	for _, skip := range result.Skipped {
+		response.Skipped = append(response.Skipped, CousinRuleImportSkip{Index: skip.Index, Reason: skip.Reason})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(results)
+	json.NewEncoder(w).Encode(response)
 }
 
 // handleGetRule handles GET /api/cousin-rules/{id}
 func (h *CousinRuleHandler) handleGetRule(w http.ResponseWriter, r *http.Request, ruleID, userID int64) {
 	rule, err := h.cousinRuleService.GetRule(r.Context(), ruleID, userID)
 	if err != nil {
-		if err == cousinrule.ErrRuleNotFound {
-			http.Error(w, "Rule not found", http.StatusNotFound)
-			return
-		}
-		if err == cousinrule.ErrForbidden {
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
-		}
-		log.Printf("Error getting cousin rule %d for user %d: %v", ruleID, userID, err)
-		http.Error(w, "Failed to get rule", http.StatusInternalServerError)
+		writeError(w, err, "Failed to get rule")
 		return
 	}
 
@@ -221,22 +539,33 @@ func (h *CousinRuleHandler) handleGetRule(w http.ResponseWriter, r *http.Request
 func (h *CousinRuleHandler) handleDeleteRule(w http.ResponseWriter, r *http.Request, ruleID, userID int64) {
 	err := h.cousinRuleService.DeleteRule(r.Context(), ruleID, userID)
 	if err != nil {
-		if err == cousinrule.ErrRuleNotFound {
-			http.Error(w, "Rule not found", http.StatusNotFound)
-			return
-		}
-		if err == cousinrule.ErrForbidden {
-			http.Error(w, "Forbidden", http.StatusForbidden)
-			return
-		}
-		log.Printf("Error deleting cousin rule %d for user %d: %v", ruleID, userID, err)
-		http.Error(w, "Failed to delete rule", http.StatusInternalServerError)
+		writeError(w, err, "Failed to delete rule")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// toRuleChanges converts request changes, mapping status to considered (a direct
+// considered value takes precedence)
+func toRuleChanges(req ApplyRuleChangeRequest) cousinrule.Changes {
+	var considered *bool
+	if req.Considered != nil {
+		considered = req.Considered
+	} else if req.Status != nil {
+		isConsidered := *req.Status == "reconciled"
+		considered = &isConsidered
+	}
+
+	return cousinrule.Changes{
+		Category:    req.Category,
+		Description: req.Description,
+		Notes:       req.Notes,
+		Considered:  considered,
+		Tags:        req.Tags,
+	}
+}
+
 func toCousinRuleAPIResponse(rule *cousinrule.CousinRule) CousinRuleAPIResponse {
 	tags := rule.Tags
 	if tags == nil {
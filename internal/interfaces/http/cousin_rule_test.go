@@ -0,0 +1,356 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"parsa/internal/domain/cousinrule"
+	"parsa/internal/domain/transaction"
+	"parsa/internal/shared/middleware"
+)
+
+func TestHandleCousinRuleAction(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		cousinID       string
+		action         string
+		body           string
+		expectedStatus int
+		check          func(t *testing.T, body []byte)
+	}{
+		{
+			name:           "Preview",
+			method:         http.MethodPost,
+			cousinID:       "42",
+			action:         "preview",
+			body:           `{"type":"DEBIT"}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var resp CousinRulePreviewResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Count != 2 || resp.CountByType["DEBIT"] != 2 || len(resp.Transactions) != 2 {
+					t.Errorf("preview = %+v, want 2 DEBIT transactions", resp)
+				}
+			},
+		},
+		{
+			name:           "Preview Without Body",
+			method:         http.MethodPost,
+			cousinID:       "42",
+			action:         "preview",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Apply Returns Affected IDs",
+			method:         http.MethodPost,
+			cousinID:       "42",
+			action:         "apply",
+			body:           `{"type":"DEBIT","changes":{"category":"Food"}}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var resp ApplyRuleResponse
+				if err := json.Unmarshal(body, &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.TransactionsUpdated != 2 || strings.Join(resp.TransactionIDs, ",") != "tx-1,tx-2" {
+					t.Errorf("apply = %+v, want IDs [tx-1 tx-2]", resp)
+				}
+			},
+		},
+		{
+			name:           "Apply Without Changes",
+			method:         http.MethodPost,
+			cousinID:       "42",
+			action:         "apply",
+			body:           `{"changes":{}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Type",
+			method:         http.MethodPost,
+			cousinID:       "42",
+			action:         "preview",
+			body:           `{"type":"TRANSFER"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Cousin ID",
+			method:         http.MethodPost,
+			cousinID:       "abc",
+			action:         "preview",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown Action",
+			method:         http.MethodPost,
+			cousinID:       "42",
+			action:         "count",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Method Not Allowed",
+			method:         http.MethodGet,
+			cousinID:       "42",
+			action:         "preview",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockCousinRuleRepo{
+				ListMatchingTransactionsFunc: func(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error) {
+					if userID != 1 || cousinID != 42 {
+						t.Errorf("ListMatchingTransactions(%d, %d), want (1, 42)", userID, cousinID)
+					}
+					return []*transaction.Transaction{{ID: "tx-1", Type: "DEBIT"}, {ID: "tx-2", Type: "DEBIT"}}, nil
+				},
+				ApplyRuleToTransactionsFunc: func(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) ([]string, error) {
+					if txType == nil || *txType != "DEBIT" {
+						t.Errorf("apply txType = %v, want DEBIT", txType)
+					}
+					return []string{"tx-1", "tx-2"}, nil
+				},
+			}
+			handler := NewCousinRuleHandler(cousinrule.NewService(repo, &MockTransactionRepo{}))
+
+			path := "/api/cousin-rules/" + tt.cousinID + "/" + tt.action
+			req := httptest.NewRequest(tt.method, path, strings.NewReader(tt.body))
+			req.SetPathValue("cousinId", tt.cousinID)
+			req.SetPathValue("action", tt.action)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+
+			rr := httptest.NewRecorder()
+			handler.HandleCousinRuleAction(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, rr.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestHandleListRules_WithCounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantCount bool
+	}{
+		{name: "Plain List", query: "", wantCount: false},
+		{name: "With Counts", query: "?withCounts=true", wantCount: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockCousinRuleRepo{
+				ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.CousinRule, error) {
+					return []*cousinrule.CousinRule{{ID: 1, CousinID: 42}}, nil
+				},
+				CountByUserIDFunc: func(ctx context.Context, userID int64) (int64, error) {
+					return 1, nil
+				},
+				ListByUserIDWithCountsFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.RuleWithCount, error) {
+					return []*cousinrule.RuleWithCount{{Rule: &cousinrule.CousinRule{ID: 1, CousinID: 42}, TransactionCount: 8}}, nil
+				},
+			}
+			handler := NewCousinRuleHandler(cousinrule.NewService(repo, &MockTransactionRepo{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/cousin-rules/"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+
+			rr := httptest.NewRecorder()
+			handler.HandleCousinRules(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+
+			var resp CousinRuleListResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			results := resp.Results
+			if len(results) != 1 {
+				t.Fatalf("got %d rules, want 1", len(results))
+			}
+			if tt.wantCount {
+				if results[0].TransactionCount == nil || *results[0].TransactionCount != 8 {
+					t.Errorf("transactionCount = %v, want 8", results[0].TransactionCount)
+				}
+			} else if results[0].TransactionCount != nil {
+				t.Errorf("transactionCount = %d, want omitted", *results[0].TransactionCount)
+			}
+		})
+	}
+}
+
+func TestHandleListRules_Pagination(t *testing.T) {
+	var gotLimit, gotOffset int
+	repo := &MockCousinRuleRepo{
+		CountByUserIDFunc: func(ctx context.Context, userID int64) (int64, error) {
+			return 150, nil
+		},
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.CousinRule, error) {
+			gotLimit, gotOffset = limit, offset
+			return []*cousinrule.CousinRule{{ID: 1, CousinID: 42}}, nil
+		},
+	}
+	handler := NewCousinRuleHandler(cousinrule.NewService(repo, &MockTransactionRepo{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cousin-rules/?page=2", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+
+	rr := httptest.NewRecorder()
+	handler.HandleCousinRules(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if gotLimit != pageSize || gotOffset != pageSize {
+		t.Errorf("limit/offset = %d/%d, want %d/%d", gotLimit, gotOffset, pageSize, pageSize)
+	}
+	if got := rr.Header().Get("X-Total-Count"); got != "150" {
+		t.Errorf("X-Total-Count = %q, want 150", got)
+	}
+
+	var resp CousinRuleListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Count != 150 {
+		t.Errorf("count = %d, want 150", resp.Count)
+	}
+	if resp.Next != nil {
+		t.Errorf("next = %q, want nil on the last page", *resp.Next)
+	}
+	if resp.Previous == nil {
+		t.Error("previous = nil, want the first page")
+	}
+}
+
+func TestHandleExportImportRules(t *testing.T) {
+	category := "Groceries"
+	repo := &MockCousinRuleRepo{
+		ListAllByUserIDFunc: func(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error) {
+			return []*cousinrule.CousinRule{
+				{ID: 1, UserID: userID, CousinID: 42, Category: &category},
+				{ID: 2, UserID: userID, CousinID: 43, DontAskAgain: true},
+			}, nil
+		},
+		GetRuleTagsFunc: func(ctx context.Context, ruleID int64) ([]string, error) {
+			return []string{}, nil
+		},
+		ImportRulesFunc: func(ctx context.Context, rules []cousinrule.CreateCousinRuleParams) (int, int, error) {
+			for _, params := range rules {
+				if params.UserID != 1 {
+					t.Errorf("imported rule UserID = %d, want 1", params.UserID)
+				}
+			}
+			return 1, len(rules) - 1, nil
+		},
+	}
+	handler := NewCousinRuleHandler(cousinrule.NewService(repo, &MockTransactionRepo{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/cousin-rules/export", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+	rr := httptest.NewRecorder()
+	handler.HandleExportRules(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("export returned status %d, want %d", rr.Code, http.StatusOK)
+	}
+	exported := rr.Body.Bytes()
+	var export CousinRuleExport
+	if err := json.Unmarshal(exported, &export); err != nil {
+		t.Fatalf("failed to decode export: %v", err)
+	}
+	if len(export.Rules) != 2 {
+		t.Fatalf("exported %d rules, want 2", len(export.Rules))
+	}
+
+	// The export file is imported as-is
+	req = httptest.NewRequest(http.MethodPost, "/api/cousin-rules/import", bytes.NewReader(exported))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+	rr = httptest.NewRecorder()
+	handler.HandleImportRules(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import returned status %d, want %d: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var resp CousinRuleImportResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode import response: %v", err)
+	}
+	if resp.Created != 1 || resp.Updated != 1 || len(resp.Skipped) != 0 {
+		t.Errorf("import = %+v, want 1 created, 1 updated, none skipped", resp)
+	}
+}
+
+func TestHandleImportRules_InvalidEntries(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantSkipped int
+		wantBody    string
+	}{
+		{name: "Empty Rules", body: `{"rules":[]}`, wantStatus: http.StatusBadRequest},
+		{name: "Malformed Body", body: `{"rules":`, wantStatus: http.StatusBadRequest},
+		{name: "Invalid Entries Skipped", body: `{"rules":[{"cousinId":0},{"cousinId":5,"type":"OTHER"},{"cousinId":6}]}`, wantStatus: http.StatusOK, wantSkipped: 2},
+		{name: "Unknown Cousin", body: `{"rules":[{"cousinId":6},{"cousinId":404}]}`, wantStatus: http.StatusBadRequest, wantBody: "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockCousinRuleRepo{
+				MissingCousinIDsFunc: func(ctx context.Context, ids []int64) ([]int64, error) {
+					if slices.Contains(ids, 404) {
+						return []int64{404}, nil
+					}
+					return nil, nil
+				},
+				ImportRulesFunc: func(ctx context.Context, rules []cousinrule.CreateCousinRuleParams) (int, int, error) {
+					return len(rules), 0, nil
+				},
+			}
+			handler := NewCousinRuleHandler(cousinrule.NewService(repo, &MockTransactionRepo{}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/cousin-rules/import", strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleImportRules(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to mention %q", rr.Body.String(), tt.wantBody)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp CousinRuleImportResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Skipped) != tt.wantSkipped {
+				t.Errorf("skipped = %+v, want %d entries", resp.Skipped, tt.wantSkipped)
+			}
+			if resp.Created != 1 {
+				t.Errorf("created = %d, want 1", resp.Created)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// maxRequestBodySize caps regular JSON request bodies
+	maxRequestBodySize = 1 << 20 // 1 MiB
+	// maxBatchRequestBodySize caps batch request bodies, which carry many items at once
+	maxBatchRequestBodySize = 5 << 20 // 5 MiB
+	// maxJSONDepth is the deepest object/array nesting accepted in a request body
+	maxJSONDepth = 32
+)
+
+var (
+	errRequestBodyTooLarge = errors.New("request body too large")
+	errJSONTooDeep         = errors.New("request body nested too deeply")
+	errTrailingData        = errors.New("request body must contain a single JSON value")
+)
+
+// jsonDecodeOptions controls how decodeJSON reads a request body
+type jsonDecodeOptions struct {
+	maxBytes int64
+	strict   bool // reject fields not present in the destination struct
+}
+
+var (
+	defaultDecodeOptions = jsonDecodeOptions{maxBytes: maxRequestBodySize}
+	batchDecodeOptions   = jsonDecodeOptions{maxBytes: maxBatchRequestBodySize, strict: true}
+	// importDecodeOptions accepts export files, which carry read-only fields the import ignores
+	importDecodeOptions = jsonDecodeOptions{maxBytes: maxBatchRequestBodySize}
+)
+
+// decodeJSON reads the request body into dst, enforcing the size limit, the nesting
+// depth limit and, for strict options, that every field is known.
+// Callers should respond with 400 when it returns an error.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any, opts jsonDecodeOptions) error {
+	r.Body = http.MaxBytesReader(w, r.Body, opts.maxBytes)
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return errRequestBodyTooLarge
+		}
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	if err := checkJSONDepth(data, maxJSONDepth); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if opts.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(dst); err != nil {
+		return err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errTrailingData
+	}
+
+	return nil
+}
+
+// checkJSONDepth scans raw JSON and fails once objects/arrays nest deeper than maxDepth.
+// Brackets inside string literals are ignored; syntax errors are left to the decoder.
+func checkJSONDepth(data []byte, maxDepth int) error {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return errJSONTooDeep
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name  string `json:"name"`
+		Items []any  `json:"items"`
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		opts    jsonDecodeOptions
+		wantErr error
+		anyErr  bool
+	}{
+		{name: "valid body", body: `{"name": "ok"}`, opts: defaultDecodeOptions},
+		{name: "unknown field allowed when lenient", body: `{"name": "ok", "extra": 1}`, opts: defaultDecodeOptions},
+		{name: "unknown field rejected when strict", body: `{"name": "ok", "extra": 1}`, opts: batchDecodeOptions, anyErr: true},
+		{name: "malformed body", body: `{"name": `, opts: defaultDecodeOptions, anyErr: true},
+		{name: "trailing data", body: `{"name": "a"}{"name": "b"}`, opts: defaultDecodeOptions, wantErr: errTrailingData},
+		{name: "oversized body", body: `{"name": "` + strings.Repeat("a", 64) + `"}`, opts: jsonDecodeOptions{maxBytes: 32}, wantErr: errRequestBodyTooLarge},
+		{name: "nested too deeply", body: `{"items": ` + strings.Repeat("[", maxJSONDepth) + strings.Repeat("]", maxJSONDepth) + `}`, opts: defaultDecodeOptions, wantErr: errJSONTooDeep},
+		{name: "brackets inside strings do not count", body: `{"name": "` + strings.Repeat("[{", maxJSONDepth) + `\"]"}`, opts: defaultDecodeOptions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			var dst payload
+			err := decodeJSON(rr, req, &dst, tt.opts)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("decodeJSON() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Error("decodeJSON() expected an error, got nil")
+				}
+			default:
+				if err != nil {
+					t.Errorf("decodeJSON() unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
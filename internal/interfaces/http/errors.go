@@ -0,0 +1,83 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/attachment"
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/cousin"
+	"parsa/internal/domain/cousinrule"
+	"parsa/internal/domain/forecast"
+	"parsa/internal/domain/notification"
+	"parsa/internal/domain/tag"
+	"parsa/internal/domain/transaction"
+	"parsa/internal/shared/httperror"
+)
+
+// ErrorResponse is the JSON body written by writeError and the middleware
+type ErrorResponse = httperror.Response
+
+// domainError is the status and message writeError reports for a typed domain error
+type domainError struct {
+	err     error
+	status  int
+	message string
+}
+
+// domainErrors lists the typed domain errors handlers can return as-is. Every package's
+// ErrForbidden maps to the same 403 so ownership failures look alike across resources.
+var domainErrors = []domainError{
+	{account.ErrAccountNotFound, http.StatusNotFound, "Account not found"},
+	{account.ErrItemNotFound, http.StatusNotFound, "Item not found"},
+	{transaction.ErrTransactionNotFound, http.StatusNotFound, "Transaction not found"},
+	{transaction.ErrCousinNotFound, http.StatusNotFound, "Cousin not found"},
+	{cousin.ErrCousinNotFound, http.StatusNotFound, "Cousin not found"},
+	{cousinrule.ErrRuleNotFound, http.StatusNotFound, "Rule not found"},
+	{bill.ErrBillNotFound, http.StatusNotFound, "Bill not found"},
+	{tag.ErrTagNotFound, http.StatusNotFound, "Tag not found"},
+	{attachment.ErrAttachmentNotFound, http.StatusNotFound, "Attachment not found"},
+	{notification.ErrNotificationNotFound, http.StatusNotFound, "Notification not found"},
+	{forecast.ErrForecastNotFound, http.StatusNotFound, "Forecast not found"},
+	{account.ErrForbidden, http.StatusForbidden, "Forbidden"},
+	{attachment.ErrForbidden, http.StatusForbidden, "Forbidden"},
+	{bill.ErrForbidden, http.StatusForbidden, "Forbidden"},
+	{cousin.ErrForbidden, http.StatusForbidden, "Forbidden"},
+	{cousinrule.ErrForbidden, http.StatusForbidden, "Forbidden"},
+	{notification.ErrForbidden, http.StatusForbidden, "Forbidden"},
+	{tag.ErrForbidden, http.StatusForbidden, "Forbidden"},
+}
+
+// writeError maps a typed domain error (wrapped or not) to its status code and a JSON body.
+// Anything else is logged and reported as a 500 carrying message, so database failures are
+// never mistaken for a missing resource.
+func writeError(w http.ResponseWriter, err error, message string) {
+	for _, de := range domainErrors {
+		if errors.Is(err, de.err) {
+			writeJSONError(w, de.status, de.message)
+			return
+		}
+	}
+	log.Printf("%s: %v", message, err)
+	writeJSONError(w, http.StatusInternalServerError, message)
+}
+
+// writeJSONError writes an ErrorResponse with the given status
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	httperror.Write(w, status, message)
+}
+
+// writeMethodNotAllowed answers 405 with a JSON body and an Allow header listing the methods
+// the route accepts
+func writeMethodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+// writeUnauthorized answers 401 with a JSON body, for requests without an authenticated user
+func writeUnauthorized(w http.ResponseWriter) {
+	writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/cousinrule"
+	"parsa/internal/domain/transaction"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "account not found", err: account.ErrAccountNotFound, wantStatus: http.StatusNotFound, wantBody: "Account not found"},
+		{name: "rule not found", err: cousinrule.ErrRuleNotFound, wantStatus: http.StatusNotFound, wantBody: "Rule not found"},
+		{name: "bill not found", err: bill.ErrBillNotFound, wantStatus: http.StatusNotFound, wantBody: "Bill not found"},
+		{name: "wrapped transaction not found", err: fmt.Errorf("lookup: %w", transaction.ErrTransactionNotFound), wantStatus: http.StatusNotFound, wantBody: "Transaction not found"},
+		{name: "account forbidden", err: account.ErrForbidden, wantStatus: http.StatusForbidden, wantBody: "Forbidden"},
+		{name: "rule forbidden", err: cousinrule.ErrForbidden, wantStatus: http.StatusForbidden, wantBody: "Forbidden"},
+		{name: "unmapped error", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError, wantBody: "Failed to do thing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			writeError(rr, tt.err, "Failed to do thing")
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.Error != tt.wantBody {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWriteStatusErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		write      func(w http.ResponseWriter)
+		wantStatus int
+		wantBody   ErrorResponse
+		wantAllow  string
+	}{
+		{
+			name:       "method not allowed",
+			write:      func(w http.ResponseWriter) { writeMethodNotAllowed(w, http.MethodGet, http.MethodPost) },
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   ErrorResponse{Error: "Method not allowed", Code: "method_not_allowed"},
+			wantAllow:  "GET, POST",
+		},
+		{
+			name:       "unauthorized",
+			write:      writeUnauthorized,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   ErrorResponse{Error: "Unauthorized", Code: "unauthorized"},
+		},
+		{
+			name:       "forbidden domain error",
+			write:      func(w http.ResponseWriter) { writeError(w, account.ErrForbidden, "Forbidden") },
+			wantStatus: http.StatusForbidden,
+			wantBody:   ErrorResponse{Error: "Forbidden", Code: "forbidden"},
+		},
+		{
+			name:       "not found has no code",
+			write:      func(w http.ResponseWriter) { writeError(w, account.ErrAccountNotFound, "Failed") },
+			wantStatus: http.StatusNotFound,
+			wantBody:   ErrorResponse{Error: "Account not found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.write(rr)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if allow := rr.Header().Get("Allow"); allow != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", allow, tt.wantAllow)
+			}
+			var body ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %+v, want %+v", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@ package http
 
 import (
 	"encoding/json"
-	"errors"
 	"log"
 	"net/http"
 
@@ -28,7 +27,7 @@ func (h *ForecastHandler) HandleForecasts(w http.ResponseWriter, r *http.Request
 	case http.MethodGet:
 		h.handleListForecasts(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
@@ -37,21 +36,21 @@ func (h *ForecastHandler) HandleForecastByUUID(w http.ResponseWriter, r *http.Re
 	case http.MethodGet:
 		h.handleGetForecast(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
 func (h *ForecastHandler) handleListForecasts(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	forecasts, err := h.forecastRepo.ListByUserID(r.Context(), userID)
 	if err != nil {
 		log.Printf("Error listing forecasts for user %d: %v", userID, err)
-		http.Error(w, "Failed to list forecasts", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list forecasts")
 		return
 	}
 
@@ -72,24 +71,19 @@ func (h *ForecastHandler) handleListForecasts(w http.ResponseWriter, r *http.Req
 func (h *ForecastHandler) handleGetForecast(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	uuid := r.PathValue("uuid")
 	if uuid == "" {
-		http.Error(w, "Forecast UUID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Forecast UUID is required")
 		return
 	}
 
 	f, err := h.forecastRepo.GetByUUID(r.Context(), uuid, userID)
 	if err != nil {
-		if errors.Is(err, forecast.ErrForecastNotFound) {
-			http.Error(w, "Forecast not found", http.StatusNotFound)
-			return
-		}
-		log.Printf("Error getting forecast %s for user %d: %v", uuid, userID, err)
-		http.Error(w, "Failed to get forecast", http.StatusInternalServerError)
+		writeError(w, err, "Failed to get forecast")
 		return
 	}
 
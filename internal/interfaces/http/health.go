@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// LeadershipReporter reports whether this instance runs the scheduled jobs
+type LeadershipReporter interface {
+	IsLeader() bool
+}
+
+// SchedulerReporter reports the scheduler's leadership and whether its runs keep completing
+type SchedulerReporter interface {
+	LeadershipReporter
+	LastRunCompleted() time.Time
+	Stale() bool
+}
+
+// HealthHandler serves the health check
+type HealthHandler struct {
+	scheduler SchedulerReporter
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+// SetScheduler includes the scheduler's leadership and last run in the health response
+func (h *HealthHandler) SetScheduler(scheduler SchedulerReporter) {
+	h.scheduler = scheduler
+}
+
+// SchedulerHealth is the scheduler part of the health response. Leader is only set
+// when the scheduler is enabled on this instance, and LastRunCompletedAt once a run has
+// completed here. Stale means the leader has not completed a run within the expected interval.
+type SchedulerHealth struct {
+	Enabled            bool       `json:"enabled"`
+	Leader             *bool      `json:"leader,omitempty"`
+	LastRunCompletedAt *time.Time `json:"lastRunCompletedAt,omitempty"`
+	Stale              bool       `json:"stale"`
+}
+
+// HealthResponse is the health check response
+type HealthResponse struct {
+	Status    string          `json:"status"`
+	Scheduler SchedulerHealth `json:"scheduler"`
+}
+
+// HandleHealth returns a simple health check response with the scheduler's leadership.
+// A stale scheduler turns the status to "degraded" but keeps the 200, so alerting can catch
+// syncs that silently stopped without load balancers pulling the instance.
+func (h *HealthHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	resp := HealthResponse{Status: "ok"}
+	if h.scheduler != nil {
+		leader := h.scheduler.IsLeader()
+		resp.Scheduler = SchedulerHealth{Enabled: true, Leader: &leader, Stale: h.scheduler.Stale()}
+		if last := h.scheduler.LastRunCompleted(); !last.IsZero() {
+			resp.Scheduler.LastRunCompletedAt = &last
+		}
+		if resp.Scheduler.Stale {
+			resp.Status = "degraded"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type stubScheduler struct {
+	leader  bool
+	lastRun time.Time
+	stale   bool
+}
+
+func (s stubScheduler) IsLeader() bool              { return s.leader }
+func (s stubScheduler) LastRunCompleted() time.Time { return s.lastRun }
+func (s stubScheduler) Stale() bool                 { return s.stale }
+
+func TestHandleHealth(t *testing.T) {
+	leader, standby := true, false
+	lastRun := time.Date(2026, 3, 1, 5, 10, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		scheduler  SchedulerReporter
+		wantStatus string
+		want       SchedulerHealth
+	}{
+		{name: "scheduler disabled", wantStatus: "ok", want: SchedulerHealth{}},
+		{name: "leader", scheduler: stubScheduler{leader: true}, wantStatus: "ok", want: SchedulerHealth{Enabled: true, Leader: &leader}},
+		{name: "standby", scheduler: stubScheduler{leader: false}, wantStatus: "ok", want: SchedulerHealth{Enabled: true, Leader: &standby}},
+		{
+			name:       "leader with a recent run",
+			scheduler:  stubScheduler{leader: true, lastRun: lastRun},
+			wantStatus: "ok",
+			want:       SchedulerHealth{Enabled: true, Leader: &leader, LastRunCompletedAt: &lastRun},
+		},
+		{
+			name:       "stale sync",
+			scheduler:  stubScheduler{leader: true, lastRun: lastRun, stale: true},
+			wantStatus: "degraded",
+			want:       SchedulerHealth{Enabled: true, Leader: &leader, LastRunCompletedAt: &lastRun, Stale: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler()
+			if tt.scheduler != nil {
+				h.SetScheduler(tt.scheduler)
+			}
+
+			w := httptest.NewRecorder()
+			h.HandleHealth(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			var resp HealthResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Status != tt.wantStatus || resp.Scheduler.Enabled != tt.want.Enabled || resp.Scheduler.Stale != tt.want.Stale {
+				t.Errorf("response = %+v, want status %s and scheduler %+v", resp, tt.wantStatus, tt.want)
+			}
+			if (resp.Scheduler.Leader == nil) != (tt.want.Leader == nil) ||
+				(resp.Scheduler.Leader != nil && *resp.Scheduler.Leader != *tt.want.Leader) {
+				t.Errorf("leader = %v, want %v", resp.Scheduler.Leader, tt.want.Leader)
+			}
+			if (resp.Scheduler.LastRunCompletedAt == nil) != (tt.want.LastRunCompletedAt == nil) ||
+				(resp.Scheduler.LastRunCompletedAt != nil && !resp.Scheduler.LastRunCompletedAt.Equal(*tt.want.LastRunCompletedAt)) {
+				t.Errorf("lastRunCompletedAt = %v, want %v", resp.Scheduler.LastRunCompletedAt, tt.want.LastRunCompletedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"parsa/internal/shared/middleware"
+)
+
+// InsightsStartDateRequest is the body of PUT /api/users/me/insights-start-date.
+// A null date clears it.
+type InsightsStartDateRequest struct {
+	InsightsStartDate *string `json:"insightsStartDate"` // YYYY-MM-DD
+}
+
+// InsightsStartDateResponse echoes the stored insights start date, null when unset
+type InsightsStartDateResponse struct {
+	InsightsStartDate *string `json:"insightsStartDate"`
+}
+
+// HandleInsightsStartDate handles PUT /api/users/me/insights-start-date. Transactions dated
+// before the insights start date are left out of the transaction counts and trend, so data
+// imported from before the user onboarded does not skew them.
+func (h *UserHandler) HandleInsightsStartDate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req InsightsStartDateRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding insights start date request for user %d: %v", userID, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var date *time.Time
+	if req.InsightsStartDate != nil {
+		d, err := time.Parse("2006-01-02", *req.InsightsStartDate)
+		if err != nil {
+			http.Error(w, "insightsStartDate must be a YYYY-MM-DD date or null", http.StatusBadRequest)
+			return
+		}
+		date = &d
+	}
+
+	if err := h.userRepo.SetInsightsStartDate(r.Context(), userID, date); err != nil {
+		log.Printf("Error setting insights start date for user %d: %v", userID, err)
+		http.Error(w, "Failed to update insights start date", http.StatusInternalServerError)
+		return
+	}
+
+	response := InsightsStartDateResponse{}
+	if date != nil {
+		formatted := date.Format("2006-01-02")
+		response.InsightsStartDate = &formatted
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"parsa/internal/shared/middleware"
+)
+
+func TestHandleInsightsStartDate(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		wantDate       *time.Time
+		wantStored     bool
+	}{
+		{
+			name:           "Method Not Allowed",
+			method:         http.MethodPost,
+			body:           `{"insightsStartDate":"2024-03-01"}`,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Invalid Date",
+			method:         http.MethodPut,
+			body:           `{"insightsStartDate":"01/03/2024"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Set",
+			method:         http.MethodPut,
+			body:           `{"insightsStartDate":"2024-03-01"}`,
+			expectedStatus: http.StatusOK,
+			wantDate:       func() *time.Time { d := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); return &d }(),
+			wantStored:     true,
+		},
+		{
+			name:           "Clear",
+			method:         http.MethodPut,
+			body:           `{"insightsStartDate":null}`,
+			expectedStatus: http.StatusOK,
+			wantStored:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := false
+			var gotDate *time.Time
+			userRepo := &MockUserRepo{
+				SetInsightsStartDateFunc: func(ctx context.Context, userID int64, date *time.Time) error {
+					stored = true
+					gotDate = date
+					return nil
+				},
+			}
+			handler := newTestUserHandler(userRepo, &MockAccountRepo{})
+
+			req := httptest.NewRequest(tt.method, "/api/users/me/insights-start-date", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleInsightsStartDate(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if stored != tt.wantStored {
+				t.Fatalf("stored = %v, want %v", stored, tt.wantStored)
+			}
+			if !tt.wantStored {
+				return
+			}
+
+			if (gotDate == nil) != (tt.wantDate == nil) || (gotDate != nil && !gotDate.Equal(*tt.wantDate)) {
+				t.Errorf("stored date = %v, want %v", gotDate, tt.wantDate)
+			}
+
+			var resp InsightsStartDateResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if tt.wantDate == nil && resp.InsightsStartDate != nil {
+				t.Errorf("response date = %q, want null", *resp.InsightsStartDate)
+			}
+			if tt.wantDate != nil && (resp.InsightsStartDate == nil || *resp.InsightsStartDate != "2024-03-01") {
+				t.Errorf("response date = %v, want 2024-03-01", resp.InsightsStartDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"parsa/internal/domain/account"
+	"parsa/internal/models"
+	"parsa/internal/shared/middleware"
+)
+
+// ItemHandler exposes bank connections (items) with their accounts grouped
+type ItemHandler struct {
+	accountService *account.Service
+}
+
+// NewItemHandler creates a new item handler
+func NewItemHandler(accountService *account.Service) *ItemHandler {
+	return &ItemHandler{accountService: accountService}
+}
+
+// ItemResponse is a bank connection with its accounts nested
+type ItemResponse struct {
+	ItemID         string            `json:"itemId"`
+	BankName       string            `json:"bankName"`
+	ConnectorID    string            `json:"connectorID"`
+	PrimaryColor   string            `json:"primaryColor"`
+	AccountCount   int               `json:"accountCount"`
+	LastSyncAt     string            `json:"lastSyncAt"`
+	Health         string            `json:"health"`
+	NeedsReconnect bool              `json:"needsReconnect"`
+	Disabled       bool              `json:"disabled"`
+	Accounts       []AccountResponse `json:"accounts"`
+}
+
+// UpdateItemRequest contains the item state flags that can be set via PATCH
+type UpdateItemRequest struct {
+	NeedsReconnect *bool `json:"needsReconnect,omitempty"`
+	Disabled       *bool `json:"disabled,omitempty"`
+}
+
+// HandleListItems returns the user's bank connections with nested accounts (GET /api/items)
+func (h *ItemHandler) HandleListItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	items, err := h.accountService.ListItemsWithAccounts(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing items for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list items")
+		return
+	}
+
+	now := time.Now()
+	response := make([]ItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, toItemResponse(item, now))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleItemByID updates a bank connection's reconnect/disabled flags (PATCH /api/items/{id})
+// and returns it as listed by HandleListItems
+func (h *ItemHandler) HandleItemByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	itemID := r.PathValue("id")
+	if itemID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Item ID is required")
+		return
+	}
+
+	var req UpdateItemRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.NeedsReconnect == nil && req.Disabled == nil {
+		writeJSONError(w, http.StatusBadRequest, "At least one of needsReconnect or disabled is required")
+		return
+	}
+
+	_, err := h.accountService.UpdateItem(r.Context(), itemID, models.UpdateItemParams{
+		NeedsReconnect: req.NeedsReconnect,
+		Disabled:       req.Disabled,
+	}, userID)
+	if err != nil {
+		writeError(w, err, "Failed to update item")
+		return
+	}
+
+	// Answer in the same shape as GET /api/items
+	item, err := h.accountService.GetItemWithAccounts(r.Context(), itemID, userID)
+	if err != nil {
+		writeError(w, err, "Failed to get item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toItemResponse(item, time.Now()))
+}
+
+// toItemResponse transforms an ItemWithAccounts into the API response, applying the
+// same bank name/connector/color fallbacks used for accounts
+func toItemResponse(item *account.ItemWithAccounts, now time.Time) ItemResponse {
+	bankName := item.BankUIName
+	if bankName == "" {
+		bankName = item.BankName
+	}
+	if bankName == "" {
+		bankName = "Unknown Bank"
+	}
+
+	connectorID := item.BankConnector
+	if connectorID == "" {
+		connectorID = "1"
+	}
+
+	primaryColor := item.BankPrimaryColor
+	if primaryColor == "" {
+		primaryColor = "1194F6"
+	}
+
+	accounts := make([]AccountResponse, 0, len(item.Accounts))
+	for _, acc := range item.Accounts {
+		accounts = append(accounts, toAccountResponse(acc))
+	}
+
+	return ItemResponse{
+		ItemID:         item.ID,
+		BankName:       bankName,
+		ConnectorID:    connectorID,
+		PrimaryColor:   primaryColor,
+		AccountCount:   item.AccountCount,
+		LastSyncAt:     item.LastSyncAt().Format(time.RFC3339),
+		Health:         item.Health(now),
+		NeedsReconnect: item.NeedsReconnect,
+		Disabled:       item.Disabled,
+		Accounts:       accounts,
+	}
+}
@@ -70,27 +70,22 @@ type OpenNotificationRequest struct {
 	NotificationID string `json:"notification_id"`
 }
 
-const maxNotificationBodySize = 1 << 20 // 1 MiB
-
 // --- Handlers ---
 
 // HandleNotifications handles GET /api/notifications/ (list)
 func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
-	}
+	page := parsePage(r)
 	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
 	if perPage < 1 || perPage > 100 {
 		perPage = 20
@@ -99,7 +94,7 @@ func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http
 	notifications, total, err := h.notificationService.ListNotifications(r.Context(), userID, page, perPage)
 	if err != nil {
 		log.Printf("Error listing notifications for user %d: %v", userID, err)
-		http.Error(w, "Failed to list notifications", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list notifications")
 		return
 	}
 
@@ -108,10 +103,8 @@ func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http
 		items = append(items, toNotificationResponse(n))
 	}
 
-	pages := 0
-	if total > 0 {
-		pages = (total + perPage - 1) / perPage
-	}
+	_, _, pages := buildPagination(r, int64(total), page, perPage)
+	setPaginationHeaders(w, r, int64(total), page, perPage)
 
 	resp := NotificationListResponse{
 		Notifications: items,
@@ -131,13 +124,13 @@ func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http
 func (h *NotificationHandler) HandleNotificationByID(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	notificationID := r.PathValue("id")
 	if notificationID == "" {
-		http.Error(w, "Notification ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Notification ID is required")
 		return
 	}
 
@@ -145,18 +138,13 @@ func (h *NotificationHandler) HandleNotificationByID(w http.ResponseWriter, r *h
 	case http.MethodPut:
 		// Mark as opened
 		if err := h.notificationService.MarkNotificationOpened(r.Context(), notificationID, userID); err != nil {
-			if err == notification.ErrNotificationNotFound {
-				http.Error(w, "Notification not found", http.StatusNotFound)
-				return
-			}
-			log.Printf("Error marking notification %s as opened: %v", notificationID, err)
-			http.Error(w, "Failed to update notification", http.StatusInternalServerError)
+			writeError(w, err, "Failed to update notification")
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]bool{"success": true})
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
@@ -164,7 +152,7 @@ func (h *NotificationHandler) HandleNotificationByID(w http.ResponseWriter, r *h
 func (h *NotificationHandler) HandlePreferences(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -174,7 +162,7 @@ func (h *NotificationHandler) HandlePreferences(w http.ResponseWriter, r *http.R
 	case http.MethodPost:
 		h.handleUpdatePreferences(w, r, userID)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
@@ -182,7 +170,7 @@ func (h *NotificationHandler) handleGetPreferences(w http.ResponseWriter, r *htt
 	prefs, err := h.notificationService.GetPreferences(r.Context(), userID)
 	if err != nil {
 		log.Printf("Error getting preferences for user %d: %v", userID, err)
-		http.Error(w, "Failed to get preferences", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get preferences")
 		return
 	}
 
@@ -201,10 +189,9 @@ func (h *NotificationHandler) handleGetPreferences(w http.ResponseWriter, r *htt
 }
 
 func (h *NotificationHandler) handleUpdatePreferences(w http.ResponseWriter, r *http.Request, userID int64) {
-	r.Body = http.MaxBytesReader(w, r.Body, maxNotificationBodySize)
 	var req UpdatePreferencesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -218,7 +205,7 @@ func (h *NotificationHandler) handleUpdatePreferences(w http.ResponseWriter, r *
 	prefs, err := h.notificationService.UpdatePreferences(r.Context(), userID, params)
 	if err != nil {
 		log.Printf("Error updating preferences for user %d: %v", userID, err)
-		http.Error(w, "Failed to update preferences", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to update preferences")
 		return
 	}
 
@@ -239,20 +226,19 @@ func (h *NotificationHandler) handleUpdatePreferences(w http.ResponseWriter, r *
 // HandleRegisterDevice handles POST /api/notifications/register-device/
 func (h *NotificationHandler) HandleRegisterDevice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, maxNotificationBodySize)
 	var req RegisterDeviceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -265,11 +251,11 @@ func (h *NotificationHandler) HandleRegisterDevice(w http.ResponseWriter, r *htt
 	token, err := h.notificationService.RegisterDevice(r.Context(), params)
 	if err != nil {
 		if err == notification.ErrInvalidToken || err == notification.ErrInvalidDeviceType {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeJSONError(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		log.Printf("Error registering device for user %d: %v", userID, err)
-		http.Error(w, "Failed to register device", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to register device")
 		return
 	}
 
@@ -284,35 +270,29 @@ func (h *NotificationHandler) HandleRegisterDevice(w http.ResponseWriter, r *htt
 // HandleOpen handles POST /api/notifications/open/
 func (h *NotificationHandler) HandleOpen(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, maxNotificationBodySize)
 	var req OpenNotificationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.NotificationID == "" {
-		http.Error(w, "notification_id is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "notification_id is required")
 		return
 	}
 
 	if err := h.notificationService.MarkNotificationOpened(r.Context(), req.NotificationID, userID); err != nil {
-		if err == notification.ErrNotificationNotFound {
-			http.Error(w, "Notification not found", http.StatusNotFound)
-			return
-		}
-		log.Printf("Error marking notification %s as opened: %v", req.NotificationID, err)
-		http.Error(w, "Failed to mark notification as opened", http.StatusInternalServerError)
+		writeError(w, err, "Failed to mark notification as opened")
 		return
 	}
 
@@ -1,19 +1,11 @@
 package http
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"parsa/internal/web"
 )
 
-// HandleHealth returns a simple health check response.
-func HandleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-}
-
 // HandleLoginPage serves the login page.
 // Dev only - static HTML file serving.
 func HandleLoginPage(w http.ResponseWriter, r *http.Request) {
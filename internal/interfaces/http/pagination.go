@@ -0,0 +1,93 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parsePage reads the page query parameter (default 1)
+func parsePage(r *http.Request) int {
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			return page
+		}
+	}
+	return 1
+}
+
+// buildPagination computes the total number of pages and the next/previous page URLs
+// for a list endpoint. The request's other query parameters are carried over to the links.
+// next is nil on the last page and previous is nil on the first.
+func buildPagination(r *http.Request, count int64, page, pageSize int) (next, previous *string, totalPages int) {
+	if count > 0 && pageSize > 0 {
+		totalPages = int((count + int64(pageSize) - 1) / int64(pageSize))
+	}
+
+	baseURL := requestBaseURL(r)
+
+	if page < totalPages {
+		nextURL := pageURL(baseURL, r.URL.Query(), page+1)
+		next = &nextURL
+	}
+	if page > 1 {
+		prevURL := pageURL(baseURL, r.URL.Query(), page-1)
+		previous = &prevURL
+	}
+
+	return next, previous, totalPages
+}
+
+// setPaginationHeaders sets GitHub-style Link and X-Total-Count headers on a list response so
+// generic HTTP clients can paginate without parsing the body. The Link header always carries
+// rel="first" and rel="last"; rel="next" and rel="prev" follow the same rules as buildPagination.
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, count int64, page, pageSize int) {
+	next, previous, totalPages := buildPagination(r, count, page, pageSize)
+
+	baseURL := requestBaseURL(r)
+	lastPage := max(totalPages, 1)
+
+	links := make([]string, 0, 4)
+	if next != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, *next))
+	}
+	if previous != nil {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, *previous))
+	}
+	links = append(links,
+		fmt.Sprintf(`<%s>; rel="first"`, pageURL(baseURL, r.URL.Query(), 1)),
+		fmt.Sprintf(`<%s>; rel="last"`, pageURL(baseURL, r.URL.Query(), lastPage)),
+	)
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("X-Total-Count", strconv.FormatInt(count, 10))
+}
+
+// requestBaseURL returns the absolute URL of the request without its query string
+func requestBaseURL(r *http.Request) string {
+	return fmt.Sprintf("%s://%s%s", getScheme(r), r.Host, r.URL.Path)
+}
+
+// pageURL returns baseURL with the request's query parameters and the given page
+func pageURL(baseURL string, query url.Values, page int) string {
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+	params.Set("page", strconv.Itoa(page))
+	return baseURL + "?" + params.Encode()
+}
+
+// getScheme returns the request scheme (http or https)
+func getScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	// Check X-Forwarded-Proto header for proxy support
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+	return "http"
+}
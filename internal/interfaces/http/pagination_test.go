@@ -0,0 +1,140 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBuildPagination(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		count          int64
+		page           int
+		wantNext       string
+		wantPrevious   string
+		wantTotalPages int
+	}{
+		{name: "empty", target: "/api/items/", count: 0, page: 1, wantTotalPages: 0},
+		{name: "single page", target: "/api/items/", count: 10, page: 1, wantTotalPages: 1},
+		{name: "first page", target: "/api/items/", count: 25, page: 1, wantNext: "2", wantTotalPages: 3},
+		{name: "middle page", target: "/api/items/?page=2", count: 25, page: 2, wantNext: "3", wantPrevious: "1", wantTotalPages: 3},
+		{name: "last page", target: "/api/items/?page=3", count: 25, page: 3, wantPrevious: "2", wantTotalPages: 3},
+		{name: "exact multiple", target: "/api/items/?page=2", count: 20, page: 2, wantPrevious: "1", wantTotalPages: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+
+			next, previous, totalPages := buildPagination(req, tt.count, tt.page, 10)
+
+			if totalPages != tt.wantTotalPages {
+				t.Errorf("totalPages = %d, want %d", totalPages, tt.wantTotalPages)
+			}
+			assertPageLink(t, "next", next, tt.wantNext)
+			assertPageLink(t, "previous", previous, tt.wantPrevious)
+		})
+	}
+}
+
+func TestBuildPagination_KeepsQueryAndScheme(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/?considered=false&page=1", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	next, _, _ := buildPagination(req, 30, 1, 10)
+	if next == nil {
+		t.Fatal("expected next link")
+	}
+
+	u, err := url.Parse(*next)
+	if err != nil {
+		t.Fatalf("invalid next link %q: %v", *next, err)
+	}
+	if u.Scheme != "https" || u.Path != "/api/transactions/" {
+		t.Errorf("next link = %q, want https scheme and original path", *next)
+	}
+	if got := u.Query().Get("considered"); got != "false" {
+		t.Errorf("next link considered = %q, want false", got)
+	}
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		count     int64
+		page      int
+		wantLinks map[string]string
+	}{
+		{name: "empty", target: "/api/items/", count: 0, page: 1, wantLinks: map[string]string{"first": "1", "last": "1"}},
+		{name: "first page", target: "/api/items/", count: 25, page: 1, wantLinks: map[string]string{"next": "2", "first": "1", "last": "3"}},
+		{name: "middle page", target: "/api/items/?page=2", count: 25, page: 2, wantLinks: map[string]string{"next": "3", "prev": "1", "first": "1", "last": "3"}},
+		{name: "last page", target: "/api/items/?page=3", count: 25, page: 3, wantLinks: map[string]string{"prev": "2", "first": "1", "last": "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rr := httptest.NewRecorder()
+
+			setPaginationHeaders(rr, req, tt.count, tt.page, 10)
+
+			if got, want := rr.Header().Get("X-Total-Count"), strconv.FormatInt(tt.count, 10); got != want {
+				t.Errorf("X-Total-Count = %q, want %q", got, want)
+			}
+
+			links := parseLinkHeader(t, rr.Header().Get("Link"))
+			if len(links) != len(tt.wantLinks) {
+				t.Errorf("Link = %q, want rels %v", rr.Header().Get("Link"), tt.wantLinks)
+			}
+			for rel, wantPage := range tt.wantLinks {
+				link, ok := links[rel]
+				if !ok {
+					t.Errorf("Link is missing rel=%q", rel)
+					continue
+				}
+				assertPageLink(t, rel, &link, wantPage)
+			}
+		})
+	}
+}
+
+// parseLinkHeader maps each rel of a Link header to its URL
+func parseLinkHeader(t *testing.T, header string) map[string]string {
+	t.Helper()
+	links := map[string]string{}
+	for _, part := range strings.Split(header, ", ") {
+		target, params, ok := strings.Cut(part, ">; ")
+		if !ok || !strings.HasPrefix(target, "<") {
+			t.Fatalf("malformed Link entry %q", part)
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(params, `rel="`), `"`)
+		links[rel] = strings.TrimPrefix(target, "<")
+	}
+	return links
+}
+
+func assertPageLink(t *testing.T, name string, link *string, wantPage string) {
+	t.Helper()
+	if wantPage == "" {
+		if link != nil {
+			t.Errorf("%s = %q, want nil", name, *link)
+		}
+		return
+	}
+	if link == nil {
+		t.Fatalf("%s = nil, want page %s", name, wantPage)
+	}
+	u, err := url.Parse(*link)
+	if err != nil {
+		t.Fatalf("invalid %s link %q: %v", name, *link, err)
+	}
+	if got := u.Query().Get("page"); got != wantPage {
+		t.Errorf("%s page = %q, want %q", name, got, wantPage)
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"parsa/internal/domain/openfinance"
+	"parsa/internal/domain/user"
+	ofclient "parsa/internal/infrastructure/openfinance"
+	"parsa/internal/shared/middleware"
+)
+
+// ProviderKeyRequest is the body of PUT /api/provider-key
+type ProviderKeyRequest struct {
+	ProviderKey string `json:"providerKey"`
+	Sync        bool   `json:"sync"` // Start a full sync as soon as the key is stored
+}
+
+// HandleProviderKey handles PUT /api/provider-key. The key is checked against the provider
+// and stored (encrypted) only if it is accepted. The raw key is never logged or returned.
+// Responds 202 when a sync was started, 200 otherwise.
+func (h *UserHandler) HandleProviderKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ProviderKeyRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding provider key request for user %d: %v", userID, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	key := strings.TrimSpace(req.ProviderKey)
+	if key == "" {
+		http.Error(w, "providerKey is required", http.StatusBadRequest)
+		return
+	}
+
+	accountResp, ok := h.verifyProviderKey(w, r, userID, key)
+	if !ok {
+		return
+	}
+
+	updatedUser, err := h.userRepo.Update(r.Context(), userID, user.UpdateUserParams{ProviderKey: &key})
+	if err != nil {
+		log.Printf("Error storing provider key for user %d: %v", userID, err)
+		http.Error(w, "Failed to update provider key", http.StatusInternalServerError)
+		return
+	}
+	h.markProviderKeyValid(r.Context(), userID, updatedUser)
+
+	if !req.Sync {
+		// A new valid key fixes connections previously flagged by a provider 401
+		if err := h.accountSyncService.ResetReconnectFlags(r.Context(), userID); err != nil {
+			log.Printf("Error clearing reconnect flags for user %d: %v", userID, err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updatedUser)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(updatedUser)
+
+	go h.syncAfterKeyUpdate(userID, accountResp)
+}
+
+// verifyProviderKey fetches the user's accounts with the key, which both validates it and
+// returns the data for the first sync. On failure the error response is already written.
+func (h *UserHandler) verifyProviderKey(w http.ResponseWriter, r *http.Request, userID int64, key string) (*ofclient.AccountResponse, bool) {
+	accountResp, statusCode, err := h.ofClient.GetAccountsWithStatus(r.Context(), key)
+	if err != nil {
+		if statusCode == http.StatusUnauthorized {
+			log.Printf("Invalid provider key for user %d: OpenFinance returned 401", userID)
+			http.Error(w, "Invalid provider key: the provider rejected it", http.StatusUnauthorized)
+			return nil, false
+		}
+		log.Printf("Error fetching accounts for user %d (status %d): %v", userID, statusCode, err)
+		http.Error(w, "Failed to verify provider key", http.StatusBadGateway)
+		return nil, false
+	}
+	return accountResp, true
+}
+
+// markProviderKeyValid records that the provider just accepted the user's key
+func (h *UserHandler) markProviderKeyValid(ctx context.Context, userID int64, updatedUser *user.User) {
+	if err := h.userRepo.MarkProviderKeyValid(ctx, userID); err != nil {
+		log.Printf("Error recording provider key validation for user %d: %v", userID, err)
+		return
+	}
+	now := time.Now()
+	updatedUser.ProviderKeyLastValidAt = &now
+}
+
+// syncAfterKeyUpdate runs the full sync for a newly stored key, reusing the accounts already
+// fetched while validating it. Meant to run in its own goroutine after the response is sent.
+func (h *UserHandler) syncAfterKeyUpdate(userID int64, accountResp *ofclient.AccountResponse) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Wait for a running sync (e.g. a scheduled one) so the full-history sync isn't lost
+	unlock, err := openfinance.LockUserSync(ctx, h.syncLocker, userID, true)
+	if err != nil {
+		log.Printf("Error locking sync for user %d: %v", userID, err)
+		return
+	}
+	defer unlock()
+
+	// A new valid key fixes connections previously flagged by a provider 401
+	if err := h.accountSyncService.ResetReconnectFlags(ctx, userID); err != nil {
+		log.Printf("Error clearing reconnect flags for user %d: %v", userID, err)
+	}
+
+	log.Printf("Starting account sync for user %d using pre-fetched data", userID)
+	accountResult, err := h.accountSyncService.SyncUserAccountsWithData(ctx, userID, accountResp)
+	if err != nil {
+		log.Printf("Error syncing accounts for user %d: %v", userID, err)
+		return
+	}
+	log.Printf("Account sync completed for user %d: created=%d, updated=%d", userID, accountResult.Created, accountResult.Updated)
+
+	// New key insertion — always fetch full history
+	log.Printf("Starting transaction sync for user %d after new key insertion (full history)", userID)
+	txResult, err := h.transactionSyncService.SyncUserTransactions(ctx, userID, true)
+	if err != nil {
+		log.Printf("Error syncing transactions for user %d: %v", userID, err)
+		return
+	}
+	log.Printf("Transaction sync completed for user %d: created=%d, updated=%d", userID, txResult.Created, txResult.Updated)
+
+	// After transaction sync, sync bills
+	log.Printf("Starting bill sync for user %d after transaction sync", userID)
+	billResult, err := h.billSyncService.SyncUserBills(ctx, userID)
+	if err != nil {
+		log.Printf("Error syncing bills for user %d: %v", userID, err)
+		return
+	}
+	log.Printf("Bill sync completed for user %d: created=%d, updated=%d", userID, billResult.Created, billResult.Updated)
+
+	if err := h.userRepo.SetHasFinishedOpenfinanceFlow(ctx, userID, true); err != nil {
+		log.Printf("Error setting has_finished_openfinance_flow for user %d: %v", userID, err)
+		return
+	}
+
+	h.notificationService.SendSyncComplete(ctx, userID, h.msgs)
+}
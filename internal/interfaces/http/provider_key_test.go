@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"parsa/internal/domain/user"
+	ofclient "parsa/internal/infrastructure/openfinance"
+	"parsa/internal/shared/middleware"
+)
+
+// mockOFClient implements ofclient.ClientInterface; only key validation is exercised here
+type mockOFClient struct {
+	ofclient.ClientInterface
+	GetAccountsWithStatusFunc func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, int, error)
+}
+
+func (m *mockOFClient) GetAccountsWithStatus(ctx context.Context, apiKey string) (*ofclient.AccountResponse, int, error) {
+	return m.GetAccountsWithStatusFunc(ctx, apiKey)
+}
+
+func TestHandleProviderKey(t *testing.T) {
+	const rawKey = "sk-live-secret"
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		status         int
+		err            error
+		expectedStatus int
+		expectCheck    bool
+	}{
+		{
+			name:           "Method Not Allowed",
+			method:         http.MethodPost,
+			body:           `{"providerKey":"` + rawKey + `"}`,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "Missing Key",
+			method:         http.MethodPut,
+			body:           `{"providerKey":"   "}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Body",
+			method:         http.MethodPut,
+			body:           `{"providerKey":123}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Rejected Key",
+			method:         http.MethodPut,
+			body:           `{"providerKey":"` + rawKey + `","sync":true}`,
+			status:         http.StatusUnauthorized,
+			err:            errors.New("unexpected status code: 401"),
+			expectedStatus: http.StatusUnauthorized,
+			expectCheck:    true,
+		},
+		{
+			name:           "Provider Unavailable",
+			method:         http.MethodPut,
+			body:           `{"providerKey":"` + rawKey + `"}`,
+			status:         http.StatusServiceUnavailable,
+			err:            errors.New("unexpected status code: 503"),
+			expectedStatus: http.StatusBadGateway,
+			expectCheck:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked := false
+			client := &mockOFClient{
+				GetAccountsWithStatusFunc: func(ctx context.Context, apiKey string) (*ofclient.AccountResponse, int, error) {
+					checked = true
+					if apiKey != rawKey {
+						t.Errorf("validated key %q, want %q", apiKey, rawKey)
+					}
+					return nil, tt.status, tt.err
+				},
+			}
+			repo := &MockUserRepo{
+				UpdateFunc: func(ctx context.Context, userID int64, params user.UpdateUserParams) (*user.User, error) {
+					t.Error("key must not be stored when validation fails")
+					return nil, errors.New("unexpected update")
+				},
+			}
+			handler := NewUserHandler(repo, &MockAccountRepo{}, client, nil, nil, nil, nil, nil)
+
+			req := httptest.NewRequest(tt.method, "/api/provider-key", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleProviderKey(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d (body: %s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if checked != tt.expectCheck {
+				t.Errorf("provider check called = %v, want %v", checked, tt.expectCheck)
+			}
+			if strings.Contains(rr.Body.String(), rawKey) {
+				t.Errorf("response leaks the raw key: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleProviderKey_Unauthorized(t *testing.T) {
+	handler := NewUserHandler(&MockUserRepo{}, &MockAccountRepo{}, &mockOFClient{}, nil, nil, nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/provider-key", bytes.NewBufferString(`{"providerKey":"k"}`))
+	rr := httptest.NewRecorder()
+
+	handler.HandleProviderKey(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
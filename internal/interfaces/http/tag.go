@@ -2,7 +2,6 @@ package http
 
 import (
 	"encoding/json"
-	"errors"
 	"log"
 	"net/http"
 
@@ -60,7 +59,7 @@ func (h *TagHandler) HandleTags(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		h.handleCreateTag(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet, http.MethodPost)
 	}
 }
 
@@ -72,7 +71,7 @@ func (h *TagHandler) HandleTagByID(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		h.handleDeleteTag(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPut, http.MethodDelete)
 	}
 }
 
@@ -80,14 +79,14 @@ func (h *TagHandler) HandleTagByID(w http.ResponseWriter, r *http.Request) {
 func (h *TagHandler) handleListTags(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	tags, err := h.tagRepo.ListByUserID(r.Context(), userID)
 	if err != nil {
 		log.Printf("Error listing tags for user %d: %v", userID, err)
-		http.Error(w, "Failed to list tags", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list tags")
 		return
 	}
 
@@ -104,14 +103,14 @@ func (h *TagHandler) handleListTags(w http.ResponseWriter, r *http.Request) {
 func (h *TagHandler) handleCreateTag(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	var req CreateTagRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		log.Printf("Error decoding create tag request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -123,14 +122,14 @@ func (h *TagHandler) handleCreateTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := params.Validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	t, err := h.tagRepo.Create(r.Context(), userID, params)
 	if err != nil {
 		log.Printf("Error creating tag for user %d: %v", userID, err)
-		http.Error(w, "Failed to create tag", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create tag")
 		return
 	}
 
@@ -143,13 +142,13 @@ func (h *TagHandler) handleCreateTag(w http.ResponseWriter, r *http.Request) {
 func (h *TagHandler) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	tagID := r.PathValue("id")
 	if tagID == "" {
-		http.Error(w, "Tag ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Tag ID is required")
 		return
 	}
 
@@ -157,22 +156,22 @@ func (h *TagHandler) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
 	existingTag, err := h.tagRepo.GetByID(r.Context(), tagID)
 	if err != nil {
 		log.Printf("Error getting tag %s: %v", tagID, err)
-		http.Error(w, "Failed to get tag", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get tag")
 		return
 	}
 	if existingTag == nil {
-		http.Error(w, "Tag not found", http.StatusNotFound)
+		writeError(w, tag.ErrTagNotFound, "Failed to get tag")
 		return
 	}
 	if existingTag.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+		writeError(w, tag.ErrForbidden, "Failed to get tag")
 		return
 	}
 
 	var req UpdateTagRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		log.Printf("Error decoding update tag request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
@@ -184,18 +183,13 @@ func (h *TagHandler) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := params.Validate(); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	t, err := h.tagRepo.Update(r.Context(), tagID, params)
 	if err != nil {
-		if errors.Is(err, tag.ErrTagNotFound) {
-			http.Error(w, "Tag not found", http.StatusNotFound)
-			return
-		}
-		log.Printf("Error updating tag %s: %v", tagID, err)
-		http.Error(w, "Failed to update tag", http.StatusInternalServerError)
+		writeError(w, err, "Failed to update tag")
 		return
 	}
 
@@ -207,13 +201,13 @@ func (h *TagHandler) handleUpdateTag(w http.ResponseWriter, r *http.Request) {
 func (h *TagHandler) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	tagID := r.PathValue("id")
 	if tagID == "" {
-		http.Error(w, "Tag ID is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Tag ID is required")
 		return
 	}
 
@@ -221,25 +215,20 @@ func (h *TagHandler) handleDeleteTag(w http.ResponseWriter, r *http.Request) {
 	existingTag, err := h.tagRepo.GetByID(r.Context(), tagID)
 	if err != nil {
 		log.Printf("Error getting tag %s for deletion: %v", tagID, err)
-		http.Error(w, "Failed to get tag", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get tag")
 		return
 	}
 	if existingTag == nil {
-		http.Error(w, "Tag not found", http.StatusNotFound)
+		writeError(w, tag.ErrTagNotFound, "Failed to get tag")
 		return
 	}
 	if existingTag.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+		writeError(w, tag.ErrForbidden, "Failed to get tag")
 		return
 	}
 
 	if err := h.tagRepo.Delete(r.Context(), tagID); err != nil {
-		if errors.Is(err, tag.ErrTagNotFound) {
-			http.Error(w, "Tag not found", http.StatusNotFound)
-			return
-		}
-		log.Printf("Error deleting tag %s: %v", tagID, err)
-		http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
+		writeError(w, err, "Failed to delete tag")
 		return
 	}
 
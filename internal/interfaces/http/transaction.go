@@ -2,18 +2,28 @@ package http
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"parsa/internal/domain/account"
+	"parsa/internal/domain/attachment"
+	"parsa/internal/domain/audit"
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/cousin"
 	"parsa/internal/domain/cousinrule"
 	"parsa/internal/domain/transaction"
+	"parsa/internal/domain/user"
 	"parsa/internal/shared/middleware"
 
 	"github.com/google/uuid"
@@ -29,6 +39,25 @@ type TransactionListResponse struct {
 	Results  []TransactionAPIResponse `json:"results"`
 }
 
+// TransactionSummaryListResponse is the paginated transaction list in the summary shape
+type TransactionSummaryListResponse struct {
+	Count    int64                        `json:"count"`
+	Next     *string                      `json:"next"`
+	Previous *string                      `json:"previous"`
+	Results  []TransactionSummaryResponse `json:"results"`
+}
+
+// TransactionSummaryResponse is the trimmed transaction format list screens ask for with
+// fields=summary
+type TransactionSummaryResponse struct {
+	ID              string  `json:"id"`
+	Amount          float64 `json:"amount"`
+	TransactionDate string  `json:"transactionDate"`
+	Description     string  `json:"description"`
+	Category        string  `json:"category"`
+	RemovedAt       *string `json:"removedAt,omitempty"` // Delta-sync tombstone
+}
+
 // TransactionAPIResponse is the API response format for a transaction
 type TransactionAPIResponse struct {
 	ID                  string   `json:"id"`
@@ -42,28 +71,190 @@ type TransactionAPIResponse struct {
 	TransactionDate     string   `json:"transactionDate"`
 	Status              string   `json:"status"`
 	Considered          bool     `json:"considered"`
+	ConsideredReason    *string  `json:"consideredReason"`
+	TransferGroup       *string  `json:"transferGroup,omitempty"` // Shared with the other side of a transfer
 	IsOpenFinance       bool     `json:"isOpenFinance"`
 	Tags                []string `json:"tags"`
 	Manipulated         bool     `json:"manipulated"`
 	LastUpdateDateParsa string   `json:"lastUpdateDateParsa"`
+	ProviderCreatedAt   *string  `json:"providerCreatedAt,omitempty"` // When the bank recorded it; manual transactions have none
+	ProviderUpdatedAt   *string  `json:"providerUpdatedAt,omitempty"` // When the bank last changed it
 	Cousin              *int64   `json:"cousin"`
 	DontAskAgain        bool     `json:"dont_ask_again"`
+
+	// Installment plan of credit card purchases; omitted for other transactions and in lists
+	InstallmentNumber     *int `json:"installmentNumber,omitempty"`
+	TotalInstallments     *int `json:"totalInstallments,omitempty"`
+	RemainingInstallments *int `json:"remainingInstallments,omitempty"`
+
+	// Tombstone: set in updatedSince listings when the transaction was removed
+	RemovedAt *string `json:"removedAt,omitempty"`
+}
+
+// TransactionTrendResponse is the monthly spending series of a category, oldest month first
+type TransactionTrendResponse struct {
+	Category string             `json:"category"`
+	Months   int                `json:"months"`
+	Results  []MonthlyTrendItem `json:"results"`
+}
+
+// MonthlyTrendItem is one month of a trend; Month is formatted as YYYY-MM
+type MonthlyTrendItem struct {
+	Month string  `json:"month"`
+	Total float64 `json:"total"`
+}
+
+// DescriptionSuggestionsResponse is the body of GET /api/transactions/merchants, most used first
+type DescriptionSuggestionsResponse struct {
+	Query   string                  `json:"query"`
+	Results []DescriptionSuggestion `json:"results"`
+}
+
+// DescriptionSuggestion is a previously used description and how many transactions use it
+type DescriptionSuggestion struct {
+	Description string `json:"description"`
+	Count       int64  `json:"count"`
+}
+
+// TransactionCountsResponse is the body of GET /api/transactions/counts. Each dimension adds up
+// to Total on its own.
+type TransactionCountsResponse struct {
+	From          *string          `json:"from,omitempty"`
+	To            *string          `json:"to,omitempty"`
+	Total         int64            `json:"total"`
+	ByType        map[string]int64 `json:"byType"`
+	ByStatus      map[string]int64 `json:"byStatus"`
+	Considered    int64            `json:"considered"`
+	NotConsidered int64            `json:"notConsidered"`
 }
 
 type TransactionHandler struct {
 	transactionRepo       transaction.Repository
 	accountRepo           account.Repository
-	cousinRuleRepo        cousinrule.Repository
+	cousinRuleService     *cousinrule.Service
 	duplicateCheckService *transaction.DuplicateCheckService
+	auditService          *audit.Service
+	cousinService         *cousin.Service
+	billRepo              bill.Repository
+	userRepo              user.Repository
+	attachmentService     *attachment.Service
+	recheckDuplicates     bool
 }
 
 func NewTransactionHandler(transactionRepo transaction.Repository, accountRepo account.Repository, cousinRuleRepo cousinrule.Repository) *TransactionHandler {
-	return &TransactionHandler{
+	h := &TransactionHandler{
 		transactionRepo:       transactionRepo,
 		accountRepo:           accountRepo,
-		cousinRuleRepo:        cousinRuleRepo,
 		duplicateCheckService: transaction.NewDuplicateCheckService(transactionRepo),
 	}
+	if cousinRuleRepo != nil {
+		h.cousinRuleService = cousinrule.NewService(cousinRuleRepo, transactionRepo)
+	}
+	return h
+}
+
+// SetAuditService enables audit logging of transaction mutations
+func (h *TransactionHandler) SetAuditService(auditService *audit.Service) {
+	h.auditService = auditService
+}
+
+// SetCousinService enables assigning cousins to single transactions
+func (h *TransactionHandler) SetCousinService(cousinService *cousin.Service) {
+	h.cousinService = cousinService
+}
+
+// SetBillRepository enables listing the transactions a bill matches
+func (h *TransactionHandler) SetBillRepository(billRepo bill.Repository) {
+	h.billRepo = billRepo
+}
+
+// SetUserRepository enables honoring the user's insights start date in transaction aggregations
+func (h *TransactionHandler) SetUserRepository(userRepo user.Repository) {
+	h.userRepo = userRepo
+}
+
+// SetAttachmentService enables deleting the attachment blobs of deleted transactions
+func (h *TransactionHandler) SetAttachmentService(attachmentService *attachment.Service) {
+	h.attachmentService = attachmentService
+}
+
+// SetDuplicateCheckService replaces the default duplicate checker used by edit rechecks and
+// duplicate lookups
+func (h *TransactionHandler) SetDuplicateCheckService(duplicateCheckService *transaction.DuplicateCheckService) {
+	h.duplicateCheckService = duplicateCheckService
+}
+
+// SetRecheckDuplicatesOnEdit enables re-running the duplicate check for transactions whose
+// amount, date or type a patch changed
+func (h *TransactionHandler) SetRecheckDuplicatesOnEdit(enabled bool) {
+	h.recheckDuplicates = enabled
+}
+
+// recheckDuplicatesAfterEdit re-runs the duplicate check for an edited transaction when enabled
+// and the edit changed a matched field, auditing the transactions it re-included. Returns the
+// transaction as stored afterwards, which differs from after when the recheck re-included it.
+func (h *TransactionHandler) recheckDuplicatesAfterEdit(ctx context.Context, userID int64, before, after *transaction.Transaction) *transaction.Transaction {
+	if !h.recheckDuplicates || !transaction.DuplicateFieldsChanged(before, after) {
+		return after
+	}
+
+	result := h.duplicateCheckService.RecheckAfterEdit(ctx, before, after, userID)
+	for _, e := range result.Errors {
+		log.Printf("Error rechecking duplicates after editing transaction %s: %s", after.ID, e)
+	}
+	if result.DuplicatesReleased == 0 {
+		return after
+	}
+
+	updates := make([]audit.TransactionUpdate, 0, len(result.Releases))
+	for _, released := range result.Releases {
+		updates = append(updates, audit.TransactionUpdate{Old: released.Before, New: released.After})
+	}
+	h.recordAuditBatch(userID, audit.SourceDetection, audit.ActionUpdate, updates)
+
+	reloaded, err := h.transactionRepo.GetByID(ctx, after.ID)
+	if err != nil || reloaded == nil {
+		log.Printf("Error reloading transaction %s after duplicate recheck: %v", after.ID, err)
+		return after
+	}
+	return reloaded
+}
+
+// insightsSince returns since raised to the user's insights start date, if one is set. A failed
+// lookup is logged and leaves since unchanged rather than failing the aggregation.
+func (h *TransactionHandler) insightsSince(ctx context.Context, userID int64, since time.Time) time.Time {
+	if h.userRepo == nil {
+		return since
+	}
+	u, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting insights start date for user %d: %v", userID, err)
+		return since
+	}
+	return u.InsightsSince(since)
+}
+
+// recordAudit logs a transaction mutation made by the user when audit logging is enabled.
+// The write happens in the background and never affects the response.
+func (h *TransactionHandler) recordAudit(userID int64, action string, old, new *transaction.Transaction) {
+	h.recordAuditFrom(userID, audit.SourceUser, action, old, new)
+}
+
+// recordAuditFrom logs a transaction mutation made by source when audit logging is enabled
+func (h *TransactionHandler) recordAuditFrom(userID int64, source, action string, old, new *transaction.Transaction) {
+	if h.auditService == nil {
+		return
+	}
+	h.auditService.RecordTransactionFrom(userID, source, action, old, new)
+}
+
+// recordAuditBatch logs the same mutation made by source for many transactions with a single
+// background write when audit logging is enabled
+func (h *TransactionHandler) recordAuditBatch(userID int64, source, action string, updates []audit.TransactionUpdate) {
+	if h.auditService == nil {
+		return
+	}
+	h.auditService.RecordTransactionsFrom(userID, source, action, updates)
 }
 
 type CreateTransactionRequest struct {
@@ -97,6 +288,76 @@ type BatchPatchRequest struct {
 	Transactions []PatchTransactionItem `json:"transactions"`
 }
 
+// ReconsiderRequest selects not-considered transactions to re-include, either by ID or by reason
+type ReconsiderRequest struct {
+	IDs                 []string `json:"ids,omitempty"`
+	Reason              *string  `json:"reason,omitempty"`
+	IncludeUserExcluded bool     `json:"includeUserExcluded,omitempty"` // Also re-include transactions the user excluded
+}
+
+// ReconsiderItemResult represents the result for a single transaction ID
+type ReconsiderItemResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReconsiderResponse is the response for POST /api/transactions/reconsider
+type ReconsiderResponse struct {
+	TotalCount   int                    `json:"totalCount"`
+	SuccessCount int                    `json:"successCount"`
+	FailureCount int                    `json:"failureCount"`
+	Results      []ReconsiderItemResult `json:"results"`
+}
+
+// SetTransactionCousinRequest is the body for PATCH /api/transactions/{id}/cousin.
+// CousinID is required; null removes the transaction from its cousin.
+type SetTransactionCousinRequest struct {
+	CousinID   json.RawMessage `json:"cousinId"`
+	ApplyRules bool            `json:"applyRules,omitempty"` // Apply the cousin's effective rule right away
+}
+
+// SetConsideredRequest is the body for POST /api/transactions/{id}/considered
+type SetConsideredRequest struct {
+	Considered *bool `json:"considered"`
+}
+
+// MarkTransferRequest is the body for POST /api/transactions/{id}/transfer
+type MarkTransferRequest struct {
+	CounterpartID string `json:"counterpartId"`
+}
+
+// MarkTransferResponse is the response for POST /api/transactions/{id}/transfer: the shared
+// transfer group and both updated transactions, the requested one first
+type MarkTransferResponse struct {
+	TransferGroup string                   `json:"transferGroup"`
+	Transactions  []TransactionAPIResponse `json:"transactions"`
+}
+
+// RecategorizeRequest is the body for POST /api/transactions/recategorize. Both categories are
+// ParsaNames; dateRange bounds are YYYY-MM-DD and inclusive, either may be omitted.
+type RecategorizeRequest struct {
+	FromCategory string                 `json:"fromCategory"`
+	ToCategory   string                 `json:"toCategory"`
+	DateRange    *RecategorizeDateRange `json:"dateRange,omitempty"`
+	IncludeIDs   bool                   `json:"includeIds,omitempty"` // Return the updated transaction IDs
+}
+
+// RecategorizeDateRange limits a recategorization to transactions dated within it
+type RecategorizeDateRange struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// RecategorizeResponse is the response for POST /api/transactions/recategorize
+type RecategorizeResponse struct {
+	UpdatedCount   int      `json:"updatedCount"`
+	TransactionIDs []string `json:"transactionIds,omitempty"`
+}
+
+// maxReconsiderIDs caps the number of IDs accepted in a single reconsider request
+const maxReconsiderIDs = 500
+
 // BatchItemResult represents the result of a single batch operation item
 type BatchItemResult struct {
 	Index       int                     `json:"index"`
@@ -116,59 +377,194 @@ type BatchResponse struct {
 // HandleListTransactions returns paginated transactions for a user (optionally filtered by account)
 func (h *TransactionHandler) HandleListTransactions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
-	// Parse page parameter (default 1)
-	page := 1
-	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
-		if parsedPage, err := strconv.Atoi(pageStr); err == nil && parsedPage > 0 {
-			page = parsedPage
-		}
+	page := parsePage(r)
+	offset := (page - 1) * pageSize
+
+	filter, err := parseTransactionListFilter(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	offset := (page - 1) * pageSize
+	summary, err := wantsTransactionSummary(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Delta sync: ?updatedSince=RFC3339 returns only the transactions changed after that time
+	var updatedSince *time.Time
+	if sinceStr := r.URL.Query().Get("updatedSince"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "updatedSince must be an RFC3339 timestamp")
+			return
+		}
+		if !filter.IsEmpty() {
+			writeJSONError(w, http.StatusBadRequest, "updatedSince cannot be combined with considered or reason")
+			return
+		}
+		updatedSince = &since
+	}
 
 	// Get total count and transactions
-	count, err := h.transactionRepo.CountByUserID(r.Context(), userID)
+	var count int64
+	var transactions []*transaction.Transaction
+	switch {
+	case updatedSince != nil:
+		count, err = h.transactionRepo.CountByUserIDUpdatedSince(r.Context(), userID, *updatedSince)
+	case filter.IsEmpty():
+		count, err = h.transactionRepo.CountByUserID(r.Context(), userID)
+	default:
+		count, err = h.transactionRepo.CountByUserIDFiltered(r.Context(), userID, filter)
+	}
 	if err != nil {
 		log.Printf("Error counting transactions for user %d: %v", userID, err)
-		http.Error(w, "Failed to count transactions", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to count transactions")
 		return
 	}
 
-	transactions, err := h.transactionRepo.ListByUserID(r.Context(), userID, pageSize, offset)
+	switch {
+	case updatedSince != nil:
+		transactions, err = h.transactionRepo.ListByUserIDUpdatedSince(r.Context(), userID, *updatedSince, pageSize, offset)
+	case filter.IsEmpty():
+		transactions, err = h.transactionRepo.ListByUserID(r.Context(), userID, pageSize, offset)
+	default:
+		transactions, err = h.transactionRepo.ListByUserIDFiltered(r.Context(), userID, filter, pageSize, offset)
+	}
 	if err != nil {
 		log.Printf("Error listing transactions for user %d: %v", userID, err)
-		http.Error(w, "Failed to list transactions", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list transactions")
+		return
+	}
+
+	// Filters are carried over to the other pages
+	h.writeTransactionList(w, r, userID, count, page, transactions, summary)
+}
+
+// HandleListAccountTransactions returns the paginated transactions of one of the user's accounts.
+// Registered as /api/accounts/{id}/{resource} because a literal .../{id}/transactions pattern
+// conflicts with /api/accounts/remove/{id}; any resource other than "transactions" is a 404.
+func (h *TransactionHandler) HandleListAccountTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("resource") != "transactions" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	summary, err := wantsTransactionSummary(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accountID := r.PathValue("id")
+
+	acc, err := h.accountRepo.GetByID(r.Context(), accountID)
+	if err != nil {
+		writeError(w, err, "Failed to get account")
+		return
+	}
+
+	// Other users' accounts are reported as missing so their IDs are not disclosed
+	if acc.UserID != userID {
+		writeError(w, account.ErrAccountNotFound, "Failed to get account")
+		return
+	}
+
+	page := parsePage(r)
+	offset := (page - 1) * pageSize
+
+	count, err := h.transactionRepo.CountByAccountID(r.Context(), accountID)
+	if err != nil {
+		log.Printf("Error counting transactions for account %s: %v", accountID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to count transactions")
+		return
+	}
+
+	transactions, err := h.transactionRepo.ListByAccountID(r.Context(), accountID, pageSize, offset)
+	if err != nil {
+		log.Printf("Error listing transactions for account %s: %v", accountID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list transactions")
 		return
 	}
 
-	// Build pagination URLs
-	baseURL := fmt.Sprintf("%s://%s%s", getScheme(r), r.Host, r.URL.Path)
-	totalPages := int(math.Ceil(float64(count) / float64(pageSize)))
+	h.writeTransactionList(w, r, userID, count, page, transactions, summary)
+}
+
+// writeTransactionList writes a page of transactions with its pagination links and headers, in
+// the summary shape when summary is set and in the full shape otherwise
+func (h *TransactionHandler) writeTransactionList(w http.ResponseWriter, r *http.Request, userID int64, count int64, page int, transactions []*transaction.Transaction, summary bool) {
+	next, previous, _ := buildPagination(r, count, page, pageSize)
+	setPaginationHeaders(w, r, count, page, pageSize)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Vary", "Prefer")
 
-	var next, previous *string
-	if page < totalPages {
-		nextURL := fmt.Sprintf("%s?page=%d", baseURL, page+1)
-		next = &nextURL
+	if summary {
+		results := make([]TransactionSummaryResponse, 0, len(transactions))
+		for _, txn := range transactions {
+			results = append(results, toTransactionSummaryResponse(txn))
+		}
+		// Only acknowledge the Prefer header when it, not fields=summary, chose the shape
+		if r.URL.Query().Get("fields") == "" {
+			w.Header().Set("Preference-Applied", "return=minimal")
+		}
+		json.NewEncoder(w).Encode(TransactionSummaryListResponse{
+			Count:    count,
+			Next:     next,
+			Previous: previous,
+			Results:  results,
+		})
+		return
 	}
-	if page > 1 {
-		prevURL := fmt.Sprintf("%s?page=%d", baseURL, page-1)
-		previous = &prevURL
+
+	json.NewEncoder(w).Encode(TransactionListResponse{
+		Count:    count,
+		Next:     next,
+		Previous: previous,
+		Results:  h.toListResults(r.Context(), userID, transactions),
+	})
+}
+
+// toListResults fetches tags for each transaction and converts them to the API format.
+// Account currencies and dont_ask_again flags are loaded once for the whole page.
+func (h *TransactionHandler) toListResults(ctx context.Context, userID int64, transactions []*transaction.Transaction) []TransactionAPIResponse {
+	currencies := h.accountCurrencies(ctx, userID)
+
+	dontAskAgain := map[string]bool{}
+	if h.cousinRuleService != nil {
+		flags, err := h.cousinRuleService.DontAskAgainForTransactions(ctx, userID, transactions)
+		if err != nil {
+			log.Printf("Error getting dont_ask_again for user %d: %v", userID, err)
+		} else {
+			dontAskAgain = flags
+		}
 	}
 
-	// Fetch tags for each transaction and transform to API response format
 	results := make([]TransactionAPIResponse, 0, len(transactions))
 	for _, txn := range transactions {
-		tags, err := h.transactionRepo.GetTransactionTags(r.Context(), txn.ID)
+		tags, err := h.transactionRepo.GetTransactionTags(ctx, txn.ID)
 		if err != nil {
 			log.Printf("Error getting tags for transaction %s: %v", txn.ID, err)
 			txn.Tags = []string{}
@@ -178,33 +574,96 @@ func (h *TransactionHandler) HandleListTransactions(w http.ResponseWriter, r *ht
 			txn.Tags = []string{}
 		}
 
-		// Check dont_ask_again status if transaction has a cousin
-		dontAskAgain := false
-		if txn.Cousin != nil && *txn.Cousin != 0 && h.cousinRuleRepo != nil {
-			dontAskAgain, _ = h.cousinRuleRepo.CheckDontAskAgain(r.Context(), userID, *txn.Cousin, txn.Type)
+		results = append(results, toTransactionAPIResponseWithDontAsk(txn, currencies[txn.AccountID], dontAskAgain[txn.ID]))
+	}
+	return results
+}
+
+// accountCurrencies maps each of the user's account IDs to its currency. On failure the map is
+// empty and responses fall back to account.DefaultCurrency.
+func (h *TransactionHandler) accountCurrencies(ctx context.Context, userID int64) map[string]string {
+	accounts, err := h.accountRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("Error listing accounts of user %d for transaction currencies: %v", userID, err)
+		return map[string]string{}
+	}
+
+	currencies := make(map[string]string, len(accounts))
+	for _, acc := range accounts {
+		currencies[acc.ID] = acc.Currency
+	}
+	return currencies
+}
+
+// parseTransactionListFilter reads the optional considered and reason query parameters
+func parseTransactionListFilter(r *http.Request) (transaction.ListFilter, error) {
+	var filter transaction.ListFilter
+
+	if consideredStr := r.URL.Query().Get("considered"); consideredStr != "" {
+		considered, err := strconv.ParseBool(consideredStr)
+		if err != nil {
+			return filter, fmt.Errorf("considered must be true or false")
+		}
+		filter.Considered = &considered
+	}
+
+	if reason := r.URL.Query().Get("reason"); reason != "" {
+		reason = strings.ToUpper(reason)
+		if !transaction.IsValidConsideredReason(reason) {
+			return filter, fmt.Errorf("invalid reason: %s", reason)
 		}
+		filter.ConsideredReason = &reason
+	}
+
+	return filter, nil
+}
 
-		results = append(results, toTransactionAPIResponseWithDontAsk(txn, dontAskAgain))
+// wantsTransactionSummary reports whether the client asked for the summary shape, with
+// fields=summary or a Prefer: return=minimal header. fields=full forces the full shape, which
+// stays the default.
+func wantsTransactionSummary(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("fields") {
+	case "summary":
+		return true, nil
+	case "full":
+		return false, nil
+	case "":
+	default:
+		return false, fmt.Errorf("fields must be summary or full")
 	}
 
-	response := TransactionListResponse{
-		Count:    count,
-		Next:     next,
-		Previous: previous,
-		Results:  results,
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "return=minimal") {
+				return true, nil
+			}
+		}
 	}
+	return false, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// toTransactionSummaryResponse converts a domain Transaction to the summary format. It needs
+// none of the per-transaction lookups of the full format.
+func toTransactionSummaryResponse(txn *transaction.Transaction) TransactionSummaryResponse {
+	full := toTransactionAPIResponse(txn, "")
+	return TransactionSummaryResponse{
+		ID:              full.ID,
+		Amount:          full.Amount,
+		TransactionDate: full.TransactionDate,
+		Description:     full.Description,
+		Category:        full.Category,
+		RemovedAt:       full.RemovedAt,
+	}
 }
 
-// toTransactionAPIResponse converts a domain Transaction to the API response format
-func toTransactionAPIResponse(txn *transaction.Transaction) TransactionAPIResponse {
-	return toTransactionAPIResponseWithDontAsk(txn, false)
+// toTransactionAPIResponse converts a domain Transaction to the API response format.
+// currency is the currency of the transaction's account.
+func toTransactionAPIResponse(txn *transaction.Transaction, currency string) TransactionAPIResponse {
+	return toTransactionAPIResponseWithDontAsk(txn, currency, false)
 }
 
 // toTransactionAPIResponseWithDontAsk converts a domain Transaction to the API response format with dont_ask_again
-func toTransactionAPIResponseWithDontAsk(txn *transaction.Transaction, dontAskAgain bool) TransactionAPIResponse {
+func toTransactionAPIResponseWithDontAsk(txn *transaction.Transaction, currency string, dontAskAgain bool) TransactionAPIResponse {
 	// Amount should be absolute value
 	amount := txn.Amount
 	if txn.Type == "DEBIT" {
@@ -233,81 +692,141 @@ func toTransactionAPIResponseWithDontAsk(txn *transaction.Transaction, dontAskAg
 		tags = []string{}
 	}
 
-	return TransactionAPIResponse{
+	if currency == "" {
+		currency = account.DefaultCurrency
+	}
+
+	// Provider timestamps are zero for manual transactions and are omitted then
+	var providerCreatedAt, providerUpdatedAt *string
+	if !txn.ProviderCreatedAt.IsZero() {
+		formatted := txn.ProviderCreatedAt.Format(time.RFC3339)
+		providerCreatedAt = &formatted
+	}
+	if !txn.ProviderUpdatedAt.IsZero() {
+		formatted := txn.ProviderUpdatedAt.Format(time.RFC3339)
+		providerUpdatedAt = &formatted
+	}
+
+	response := TransactionAPIResponse{
 		ID:                  txn.ID,
 		Description:         txn.Description,
 		Amount:              amount,
 		Notes:               txn.Notes,
-		Currency:            "BRL",
+		Currency:            currency,
 		Account:             txn.AccountID,
 		Category:            category,
 		Type:                strings.ToLower(txn.Type),
 		TransactionDate:     txn.TransactionDate.Format(time.RFC3339),
 		Status:              strings.ToLower(txn.Status),
 		Considered:          txn.Considered,
+		ConsideredReason:    txn.ConsideredReason,
+		TransferGroup:       txn.TransferGroup,
 		IsOpenFinance:       txn.IsOpenFinance,
 		Tags:                tags,
 		Manipulated:         txn.Manipulated,
 		LastUpdateDateParsa: txn.UpdatedAt.Format(time.RFC3339),
+		ProviderCreatedAt:   providerCreatedAt,
+		ProviderUpdatedAt:   providerUpdatedAt,
 		Cousin:              cousin,
 		DontAskAgain:        dontAskAgain,
 	}
+	if txn.RemovedAt != nil {
+		removedAt := txn.RemovedAt.Format(time.RFC3339)
+		response.RemovedAt = &removedAt
+	}
+	if txn.Installment != nil {
+		number, total, remaining := txn.Installment.Number, txn.Installment.Total, txn.Installment.Remaining()
+		response.InstallmentNumber = &number
+		response.TotalInstallments = &total
+		response.RemainingInstallments = &remaining
+	}
+	return response
 }
 
-// getScheme returns the request scheme (http or https)
-func getScheme(r *http.Request) string {
-	if r.TLS != nil {
-		return "https"
+// verifyTransactionOwnership loads a transaction and the account it belongs to, returning
+// transaction.ErrTransactionNotFound when it does not exist and account.ErrForbidden when the
+// account is not userID's. Any other error is a failed lookup.
+func (h *TransactionHandler) verifyTransactionOwnership(ctx context.Context, transactionID string, userID int64) (*transaction.Transaction, *account.Account, error) {
+	txn, err := h.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get transaction %s: %w", transactionID, err)
+	}
+	if txn == nil {
+		return nil, nil, transaction.ErrTransactionNotFound
+	}
+
+	acc, err := h.accountRepo.GetByID(ctx, txn.AccountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get account %s of transaction %s: %w", txn.AccountID, transactionID, err)
 	}
-	// Check X-Forwarded-Proto header for proxy support
-	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
-		return proto
+	if acc.UserID != userID {
+		return nil, nil, account.ErrForbidden
 	}
-	return "http"
+	return txn, acc, nil
+}
+
+// ownershipErrorMessage is the per-item counterpart of writeError for verifyTransactionOwnership
+// in batch results
+func ownershipErrorMessage(err error, transactionID string) string {
+	switch {
+	case errors.Is(err, transaction.ErrTransactionNotFound):
+		return fmt.Sprintf("Transaction %s not found", transactionID)
+	case errors.Is(err, account.ErrForbidden):
+		return "Forbidden: transaction does not belong to user"
+	case errors.Is(err, account.ErrAccountNotFound):
+		return "Account not found"
+	}
+	log.Printf("Error verifying ownership of transaction %s: %v", transactionID, err)
+	return "Failed to get transaction"
 }
 
 // HandleCreateTransaction creates a new transaction
 func (h *TransactionHandler) HandleCreateTransaction(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodPost)
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	var req CreateTransactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
 		log.Printf("Error decoding create transaction request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if req.AccountID == "" || req.Description == "" || req.TransactionDate == "" {
-		http.Error(w, "accountId, description, and transactionDate are required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "accountId, description, and transactionDate are required")
+		return
+	}
+
+	// The sign of a stored amount is carried by Type, so only the magnitude is kept
+	if req.Amount == 0 {
+		writeJSONError(w, http.StatusBadRequest, "amount must be non-zero")
 		return
 	}
 
 	// Verify account ownership
-	account, err := h.accountRepo.GetByID(r.Context(), req.AccountID)
+	acc, err := h.accountRepo.GetByID(r.Context(), req.AccountID)
 	if err != nil {
-		log.Printf("Error getting account %s for transaction creation: %v", req.AccountID, err)
-		http.Error(w, "Account not found", http.StatusNotFound)
+		writeError(w, err, "Failed to get account")
 		return
 	}
 
-	if account.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+	if acc.UserID != userID {
+		writeError(w, account.ErrForbidden, "Forbidden")
 		return
 	}
 
 	// Parse transaction date
 	transactionDate, err := time.Parse("2006-01-02", req.TransactionDate)
 	if err != nil {
-		http.Error(w, "Invalid transactionDate format (use YYYY-MM-DD)", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid transactionDate format (use YYYY-MM-DD)")
 		return
 	}
 
@@ -327,7 +846,7 @@ func (h *TransactionHandler) HandleCreateTransaction(w http.ResponseWriter, r *h
 	txn, err := h.transactionRepo.Create(r.Context(), transaction.CreateTransactionParams{
 		ID:              txID,
 		AccountID:       req.AccountID,
-		Amount:          req.Amount,
+		Amount:          math.Abs(req.Amount),
 		Description:     req.Description,
 		Category:        req.Category,
 		TransactionDate: transactionDate,
@@ -337,10 +856,12 @@ func (h *TransactionHandler) HandleCreateTransaction(w http.ResponseWriter, r *h
 
 	if err != nil {
 		log.Printf("Error creating transaction for account %s: %v", req.AccountID, err)
-		http.Error(w, "Failed to create transaction", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to create transaction")
 		return
 	}
 
+	h.recordAudit(userID, audit.ActionCreate, nil, txn)
+
 	// Run duplicate check after transaction creation
 	go func() {
 		ctx := context.Background()
@@ -358,44 +879,26 @@ func (h *TransactionHandler) HandleCreateTransaction(w http.ResponseWriter, r *h
 // HandleGetTransaction returns a specific transaction
 func (h *TransactionHandler) HandleGetTransaction(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodGet)
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	// Use PathValue instead of TrimPrefix
 	transactionID := r.PathValue("id")
 	if transactionID == "" {
-		http.Error(w, "Transaction ID is required", http.StatusBadRequest)
-		return
-	}
-
-	txn, err := h.transactionRepo.GetByID(r.Context(), transactionID)
-	if err != nil {
-		log.Printf("Error getting transaction %s: %v", transactionID, err)
-		http.Error(w, "Failed to get transaction", http.StatusInternalServerError)
-		return
-	}
-	if txn == nil {
-		http.Error(w, "Transaction not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
 		return
 	}
 
-	// Verify ownership through account
-	account, err := h.accountRepo.GetByID(r.Context(), txn.AccountID)
+	txn, _, err := h.verifyTransactionOwnership(r.Context(), transactionID, userID)
 	if err != nil {
-		log.Printf("Error getting account %s for transaction %s: %v", txn.AccountID, transactionID, err)
-		http.Error(w, "Account not found", http.StatusNotFound)
-		return
-	}
-
-	if account.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+		writeError(w, err, "Failed to get transaction")
 		return
 	}
 
@@ -406,108 +909,927 @@ func (h *TransactionHandler) HandleGetTransaction(w http.ResponseWriter, r *http
 // HandleDeleteTransaction deletes a transaction
 func (h *TransactionHandler) HandleDeleteTransaction(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w, http.MethodDelete)
 		return
 	}
 
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	transactionID := strings.TrimPrefix(r.URL.Path, "/api/transactions/")
 	if transactionID == "" {
-		http.Error(w, "Transaction ID is required", http.StatusBadRequest)
-		return
-	}
-
-	txn, err := h.transactionRepo.GetByID(r.Context(), transactionID)
-	if err != nil {
-		log.Printf("Error getting transaction %s for deletion: %v", transactionID, err)
-		http.Error(w, "Failed to get transaction", http.StatusInternalServerError)
-		return
-	}
-	if txn == nil {
-		http.Error(w, "Transaction not found", http.StatusNotFound)
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
 		return
 	}
 
-	// Verify ownership through account
-	account, err := h.accountRepo.GetByID(r.Context(), txn.AccountID)
+	txn, _, err := h.verifyTransactionOwnership(r.Context(), transactionID, userID)
 	if err != nil {
-		log.Printf("Error getting account %s for transaction deletion: %v", txn.AccountID, err)
-		http.Error(w, "Account not found", http.StatusNotFound)
-		return
-	}
-
-	if account.UserID != userID {
-		http.Error(w, "Forbidden", http.StatusForbidden)
+		writeError(w, err, "Failed to get transaction")
 		return
 	}
 
 	if err := h.transactionRepo.Delete(r.Context(), transactionID); err != nil {
 		log.Printf("Error deleting transaction %s: %v", transactionID, err)
-		http.Error(w, "Failed to delete transaction", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to delete transaction")
 		return
 	}
+	purgeDeletedAttachments(r.Context(), h.attachmentService)
+
+	h.recordAudit(userID, audit.ActionDelete, txn, nil)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleBatchTransactions routes batch requests to POST or PATCH handlers
-func (h *TransactionHandler) HandleBatchTransactions(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		h.handleBatchCreate(w, r)
-	case http.MethodPatch:
-		h.handleBatchPatch(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// HandleTransactionCousin assigns or clears a transaction's cousin (PATCH /api/transactions/{id}/cousin).
+// The cousin must belong to the user. With applyRules, the cousin's effective rule is applied
+// before responding and the cousin_assigned listener is skipped, so the rule is applied once.
+func (h *TransactionHandler) HandleTransactionCousin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		writeMethodNotAllowed(w, http.MethodPatch)
+		return
 	}
-}
 
-// handleBatchCreate creates multiple transactions
-func (h *TransactionHandler) handleBatchCreate(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
-	var req BatchCreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding batch create request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if h.cousinService == nil {
+		writeJSONError(w, http.StatusServiceUnavailable, "Cousins are not available")
 		return
 	}
 
-	if len(req.Transactions) == 0 {
-		http.Error(w, "transactions array is required and cannot be empty", http.StatusBadRequest)
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
 		return
 	}
 
-	// Collect unique account IDs and verify ownership
-	accountIDs := make(map[string]bool)
-	for _, txReq := range req.Transactions {
-		if txReq.AccountID == "" {
-			http.Error(w, "accountId is required for all transactions", http.StatusBadRequest)
-			return
-		}
-		accountIDs[txReq.AccountID] = true
+	var req SetTransactionCousinRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding transaction cousin request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.CousinID) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "cousinId is required (null to clear)")
+		return
+	}
+	var cousinID *int64
+	if err := json.Unmarshal(req.CousinID, &cousinID); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "cousinId must be an integer or null")
+		return
 	}
 
-	// Verify ownership for all accounts
-	for accountID := range accountIDs {
-		acc, err := h.accountRepo.GetByID(r.Context(), accountID)
-		if err != nil || acc == nil {
-			http.Error(w, fmt.Sprintf("Account %s not found", accountID), http.StatusNotFound)
-			return
+	txn, acc, err := h.verifyTransactionOwnership(r.Context(), transactionID, userID)
+	if err != nil {
+		writeError(w, err, "Failed to get transaction")
+		return
+	}
+
+	applyRules := req.ApplyRules && cousinID != nil && h.cousinRuleService != nil
+	if cousinID != nil {
+		if _, err := h.cousinService.GetCousin(r.Context(), *cousinID, userID); err != nil {
+			writeError(w, err, "Failed to get cousin")
+			return
+		}
+		if applyRules {
+			err = h.transactionRepo.SetCousinWithoutRules(r.Context(), transactionID, *cousinID)
+		} else {
+			err = h.transactionRepo.SetCousin(r.Context(), transactionID, *cousinID)
+		}
+	} else {
+		err = h.transactionRepo.ClearCousin(r.Context(), transactionID)
+	}
+	if err != nil {
+		writeError(w, err, "Failed to update transaction cousin")
+		return
+	}
+
+	updated, err := h.transactionRepo.GetByID(r.Context(), transactionID)
+	if err != nil || updated == nil {
+		log.Printf("Error reloading transaction %s after cousin update: %v", transactionID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get transaction")
+		return
+	}
+
+	h.recordAudit(userID, audit.ActionUpdate, txn, updated)
+
+	if applyRules {
+		// The cousin stays assigned on failure; retrying applies the rule without reassigning it
+		assigned := updated
+		updated, err = h.cousinRuleService.ApplyEffectiveRuleToTransaction(r.Context(), userID, assigned)
+		if err != nil {
+			writeError(w, err, "Failed to apply cousin rule")
+			return
+		}
+		h.recordAuditFrom(userID, audit.SourceRule, audit.ActionUpdate, assigned, updated)
+	}
+
+	tags, err := h.transactionRepo.GetTransactionTags(r.Context(), transactionID)
+	if err != nil {
+		log.Printf("Error getting tags for transaction %s: %v", transactionID, err)
+		tags = []string{}
+	}
+	updated.Tags = tags
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTransactionAPIResponse(updated, acc.Currency))
+}
+
+// HandleTransactionConsidered sets whether a transaction is considered (POST
+// /api/transactions/{id}/considered). The reason becomes USER and the notes added by an
+// auto-exclusion are removed, so the duplicate and bill payment checks leave the user's
+// decision alone on later runs.
+func (h *TransactionHandler) HandleTransactionConsidered(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+
+	var req SetConsideredRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding considered request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Considered == nil {
+		writeJSONError(w, http.StatusBadRequest, "considered is required")
+		return
+	}
+
+	txn, acc, err := h.verifyTransactionOwnership(r.Context(), transactionID, userID)
+	if err != nil {
+		writeError(w, err, "Failed to get transaction")
+		return
+	}
+
+	reason := transaction.ConsideredReasonUser
+	params := transaction.UpdateTransactionParams{
+		Considered:       req.Considered,
+		ConsideredReason: &reason,
+	}
+	if txn.Notes != nil {
+		if notes, changed := h.duplicateCheckService.RemoveDetectionNotes(*txn.Notes); changed {
+			params.Notes = &notes
+		}
+	}
+
+	updated, err := h.transactionRepo.Update(r.Context(), transactionID, params)
+	if err != nil {
+		writeError(w, err, "Failed to update transaction")
+		return
+	}
+
+	h.recordAudit(userID, audit.ActionUpdate, txn, updated)
+
+	tags, err := h.transactionRepo.GetTransactionTags(r.Context(), transactionID)
+	if err != nil {
+		log.Printf("Error getting tags for transaction %s: %v", transactionID, err)
+		tags = []string{}
+	}
+	updated.Tags = tags
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toTransactionAPIResponse(updated, acc.Currency))
+}
+
+// HandleMarkTransfer links a transaction to its counterpart as the two sides of a transfer
+// between the user's own accounts (POST /api/transactions/{id}/transfer). Both must belong
+// to the user and have opposite types and matching amounts (see transaction.ValidateTransferPair).
+// Both become considered=false with reason TRANSFER and share a new transfer group; a removed
+// side answers 404 and leaves both unchanged.
+func (h *TransactionHandler) HandleMarkTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+
+	var req MarkTransferRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding transfer request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.CounterpartID == "" {
+		writeJSONError(w, http.StatusBadRequest, "counterpartId is required")
+		return
+	}
+
+	ids := []string{transactionID, req.CounterpartID}
+	originals := make([]*transaction.Transaction, len(ids))
+	currencies := make([]string, len(ids))
+	for i, id := range ids {
+		txn, acc, err := h.verifyTransactionOwnership(r.Context(), id, userID)
+		if err != nil {
+			writeError(w, err, "Failed to get transaction")
+			return
+		}
+		originals[i], currencies[i] = txn, acc.Currency
+	}
+
+	if err := transaction.ValidateTransferPair(originals[0], originals[1]); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	group := uuid.New().String()
+	if _, err := h.transactionRepo.MarkTransfer(r.Context(), ids, group); err != nil {
+		writeError(w, err, "Failed to mark transfer")
+		return
+	}
+
+	resp := MarkTransferResponse{TransferGroup: group, Transactions: make([]TransactionAPIResponse, 0, len(ids))}
+	for i, id := range ids {
+		updated, err := h.transactionRepo.GetByID(r.Context(), id)
+		if err != nil || updated == nil {
+			log.Printf("Error reloading transaction %s after transfer: %v", id, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get transaction")
+			return
+		}
+		h.recordAudit(userID, audit.ActionUpdate, originals[i], updated)
+
+		tags, err := h.transactionRepo.GetTransactionTags(r.Context(), id)
+		if err != nil {
+			log.Printf("Error getting tags for transaction %s: %v", id, err)
+			tags = []string{}
+		}
+		updated.Tags = tags
+		resp.Transactions = append(resp.Transactions, toTransactionAPIResponse(updated, currencies[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandleDescriptionSuggestions returns the user's distinct descriptions starting with q, most
+// used first (GET /api/transactions/merchants?q=), to autocomplete manual transactions.
+// An empty q returns the most used descriptions overall.
+func (h *TransactionHandler) HandleDescriptionSuggestions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if utf8.RuneCountInString(query) > transaction.MaxDescriptionQueryLength {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("q must be at most %d characters", transaction.MaxDescriptionQueryLength))
+		return
+	}
+
+	suggestions, err := h.transactionRepo.ListDescriptionSuggestions(r.Context(), userID, query, transaction.MaxDescriptionSuggestions)
+	if err != nil {
+		log.Printf("Error listing description suggestions for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to list suggestions")
+		return
+	}
+
+	results := make([]DescriptionSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		results = append(results, DescriptionSuggestion{Description: s.Description, Count: s.Count})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DescriptionSuggestionsResponse{Query: query, Results: results})
+}
+
+// HandleTransactionCounts returns the user's transaction counts by type, status and considered
+// flag (GET /api/transactions/counts?from=&to=), optionally limited to transaction dates between
+// from and to (YYYY-MM-DD, both inclusive) and never before the user's insights start date.
+// Responses carry an ETag of their content, so clients can revalidate with If-None-Match and
+// get a 304 while nothing changed.
+func (h *TransactionHandler) HandleTransactionCounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	response := TransactionCountsResponse{}
+	var window transaction.DateWindow
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "from must be a YYYY-MM-DD date")
+			return
+		}
+		window.Start = from
+		response.From = &fromStr
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "to must be a YYYY-MM-DD date")
+			return
+		}
+		if !window.Start.IsZero() && to.Before(window.Start) {
+			writeJSONError(w, http.StatusBadRequest, "to must not be before from")
+			return
+		}
+		// Include the whole last day
+		window.End = to.AddDate(0, 0, 1).Add(-time.Microsecond)
+		response.To = &toStr
+	}
+
+	window.Start = h.insightsSince(r.Context(), userID, window.Start)
+
+	groups, err := h.transactionRepo.CountGroups(r.Context(), userID, window)
+	if err != nil {
+		log.Printf("Error counting transactions for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to count transactions")
+		return
+	}
+
+	counts := transaction.SummarizeCounts(groups)
+	response.Total = counts.Total
+	response.ByType = counts.ByType
+	response.ByStatus = counts.ByStatus
+	response.Considered = counts.Considered
+	response.NotConsidered = counts.NotConsidered
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Error encoding transaction counts for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to count transactions")
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// HandleTransactionTrend returns a category's net monthly spending over the last N months
+// (GET /api/transactions/trend?category=&months=12), including the current month. Only
+// considered transactions from the user's insights start date on count, and months without
+// spending are reported as zero.
+func (h *TransactionHandler) HandleTransactionTrend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	if category == "" {
+		writeJSONError(w, http.StatusBadRequest, "category is required")
+		return
+	}
+
+	months := transaction.DefaultTrendMonths
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		n, err := strconv.Atoi(monthsStr)
+		if err != nil || n < 1 || n > transaction.MaxTrendMonths {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("months must be between 1 and %d", transaction.MaxTrendMonths))
+			return
+		}
+		months = n
+	}
+
+	start := transaction.TrendStart(time.Now(), months)
+	since := h.insightsSince(r.Context(), userID, start)
+	totals, err := h.transactionRepo.MonthlyTrendByCategory(r.Context(), userID, category, since)
+	if err != nil {
+		log.Printf("Error getting monthly trend of category %q for user %d: %v", category, userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to get trend")
+		return
+	}
+
+	series := transaction.FillMonthlyTrend(totals, start, months)
+	results := make([]MonthlyTrendItem, 0, len(series))
+	for _, m := range series {
+		results = append(results, MonthlyTrendItem{Month: m.Month.Format("2006-01"), Total: m.Total})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TransactionTrendResponse{
+		Category: category,
+		Months:   months,
+		Results:  results,
+	})
+}
+
+// HandleTransactionDuplicates returns the transactions the duplicate check matches against a
+// transaction (GET /api/transactions/{id}/duplicates): the opposite type with the same absolute
+// amount within the duplicate window. Read-only; nothing is marked.
+func (h *TransactionHandler) HandleTransactionDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+
+	txn, _, err := h.verifyTransactionOwnership(r.Context(), transactionID, userID)
+	if err != nil {
+		writeError(w, err, "Failed to get transaction")
+		return
+	}
+
+	duplicates, err := h.duplicateCheckService.FindDuplicateCandidates(r.Context(), txn, userID)
+	if err != nil {
+		log.Printf("Error finding duplicates of transaction %s: %v", transactionID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to find duplicates")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.toListResults(r.Context(), userID, duplicates))
+}
+
+// HandleTransactionHistory returns the audit history of one of the user's transactions, oldest
+// first (GET /api/transactions/{id}/history?limit=...). Empty when audit logging is off.
+func (h *TransactionHandler) HandleTransactionHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	transactionID := r.PathValue("id")
+	if transactionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+
+	limit := audit.DefaultListLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			writeJSONError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	if _, _, err := h.verifyTransactionOwnership(r.Context(), transactionID, userID); err != nil {
+		writeError(w, err, "Failed to get transaction")
+		return
+	}
+
+	entries := []*audit.Entry{}
+	if h.auditService != nil {
+		var err error
+		entries, err = h.auditService.TransactionHistory(r.Context(), transactionID, limit)
+		if err != nil {
+			writeError(w, err, "Failed to list transaction history")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// BillMatchesResponse lists the transactions the bill-payment check matches against a bill
+type BillMatchesResponse struct {
+	BillID               string                   `json:"billId"`
+	AccountID            string                   `json:"accountId"`
+	DueDate              time.Time                `json:"dueDate"`
+	TotalAmount          float64                  `json:"totalAmount"`
+	WindowStart          time.Time                `json:"windowStart"`
+	WindowEnd            time.Time                `json:"windowEnd"`
+	LinkedTransactionIDs []string                 `json:"linkedTransactionIds"` // Matches already excluded as a bill payment
+	Matches              []TransactionAPIResponse `json:"matches"`
+}
+
+// HandleBillMatches returns the transactions the bill-payment check matches against a bill
+// (GET /api/bills/{id}/matches): same account, same absolute amount, within the configured
+// bill window of the due date. Read-only; nothing is marked.
+func (h *TransactionHandler) HandleBillMatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	billID := r.PathValue("id")
+	if billID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Bill ID is required")
+		return
+	}
+
+	b, err := h.billRepo.GetByID(r.Context(), billID)
+	if err != nil {
+		writeError(w, err, "Failed to get bill")
+		return
+	}
+
+	// Verify ownership through account
+	acc, err := h.accountRepo.GetByID(r.Context(), b.AccountID)
+	if err != nil {
+		writeError(w, err, "Failed to get account")
+		return
+	}
+	if acc.UserID != userID {
+		writeError(w, account.ErrForbidden, "Forbidden")
+		return
+	}
+
+	matches, err := h.duplicateCheckService.FindBillMatches(r.Context(), b.AccountID, b.DueDate, b.TotalAmount, userID)
+	if err != nil {
+		log.Printf("Error finding matches of bill %s: %v", billID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to find bill matches")
+		return
+	}
+
+	linked := []string{}
+	for _, txn := range matches {
+		if txn.ConsideredReason != nil && *txn.ConsideredReason == transaction.ConsideredReasonBillPayment {
+			linked = append(linked, txn.ID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BillMatchesResponse{
+		BillID:               b.ID,
+		AccountID:            b.AccountID,
+		DueDate:              b.DueDate,
+		TotalAmount:          b.TotalAmount,
+		WindowStart:          b.DueDate.Add(-h.duplicateCheckService.BillWindow()),
+		WindowEnd:            b.DueDate.Add(h.duplicateCheckService.BillWindow()),
+		LinkedTransactionIDs: linked,
+		Matches:              h.toListResults(r.Context(), userID, matches),
+	})
+}
+
+// HandleReconsider re-includes excluded transactions (POST /api/transactions/reconsider).
+// Transactions the user excluded (reason USER or none) are skipped unless includeUserExcluded is set.
+func (h *TransactionHandler) HandleReconsider(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	var req ReconsiderRequest
+	if err := decodeJSON(w, r, &req, batchDecodeOptions); err != nil {
+		log.Printf("Error decoding reconsider request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if (len(req.IDs) == 0) == (req.Reason == nil) {
+		writeJSONError(w, http.StatusBadRequest, "Exactly one of ids or reason is required")
+		return
+	}
+	if len(req.IDs) > maxReconsiderIDs {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("At most %d ids are allowed", maxReconsiderIDs))
+		return
+	}
+
+	var candidates []*transaction.Transaction
+	results := make(map[string]*ReconsiderItemResult)
+	var order []string
+
+	if req.Reason != nil {
+		reason := strings.ToUpper(*req.Reason)
+		if !transaction.IsValidConsideredReason(reason) {
+			writeJSONError(w, http.StatusBadRequest, "Invalid reason: "+reason)
+			return
+		}
+
+		// Ownership is implied by listing the user's own transactions
+		considered := false
+		filter := transaction.ListFilter{Considered: &considered, ConsideredReason: &reason}
+		for offset := 0; ; offset += maxReconsiderIDs {
+			page, err := h.transactionRepo.ListByUserIDFiltered(r.Context(), userID, filter, maxReconsiderIDs, offset)
+			if err != nil {
+				log.Printf("Error listing transactions to reconsider for user %d: %v", userID, err)
+				writeJSONError(w, http.StatusInternalServerError, "Failed to list transactions")
+				return
+			}
+			candidates = append(candidates, page...)
+			if len(page) < maxReconsiderIDs {
+				break
+			}
+		}
+		for _, txn := range candidates {
+			order = append(order, txn.ID)
+			results[txn.ID] = &ReconsiderItemResult{ID: txn.ID}
+		}
+	} else {
+		for _, id := range req.IDs {
+			if _, seen := results[id]; seen {
+				continue
+			}
+			order = append(order, id)
+			result := &ReconsiderItemResult{ID: id}
+			results[id] = result
+
+			txn, _, err := h.verifyTransactionOwnership(r.Context(), id, userID)
+			if err != nil {
+				result.Error = ownershipErrorMessage(err, id)
+				continue
+			}
+
+			candidates = append(candidates, txn)
+		}
+	}
+
+	// Keep only excluded transactions the request is allowed to touch
+	eligible := make([]string, 0, len(candidates))
+	byID := make(map[string]*transaction.Transaction, len(candidates))
+	// Strip the auto-exclusion notes, as setting considered does
+	notes := make(map[string]string)
+	for _, txn := range candidates {
+		result := results[txn.ID]
+		if txn.Considered {
+			result.Error = "Transaction is already considered"
+			continue
+		}
+		userExcluded := txn.ConsideredReason == nil || *txn.ConsideredReason == transaction.ConsideredReasonUser
+		if userExcluded && !req.IncludeUserExcluded {
+			result.Error = "Transaction was excluded by the user; set includeUserExcluded to reconsider it"
+			continue
+		}
+		eligible = append(eligible, txn.ID)
+		byID[txn.ID] = txn
+		if txn.Notes != nil {
+			if cleaned, changed := h.duplicateCheckService.RemoveDetectionNotes(*txn.Notes); changed {
+				notes[txn.ID] = cleaned
+			}
+		}
+	}
+
+	updated, err := h.transactionRepo.Reconsider(r.Context(), eligible, notes)
+	if err != nil {
+		log.Printf("Error reconsidering transactions for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to reconsider transactions")
+		return
+	}
+
+	userReason := transaction.ConsideredReasonUser
+	updatedSet := make(map[string]struct{}, len(updated))
+	for _, id := range updated {
+		updatedSet[id] = struct{}{}
+	}
+	for _, id := range eligible {
+		if _, ok := updatedSet[id]; !ok {
+			results[id].Error = "Transaction is already considered"
+			continue
+		}
+		results[id].Success = true
+
+		old := byID[id]
+		reconsidered := *old
+		reconsidered.Considered = true
+		reconsidered.ConsideredReason = &userReason
+		if cleaned, ok := notes[id]; ok {
+			reconsidered.Notes = &cleaned
+		}
+		h.recordAudit(userID, audit.ActionUpdate, old, &reconsidered)
+	}
+
+	response := ReconsiderResponse{
+		TotalCount: len(order),
+		Results:    make([]ReconsiderItemResult, 0, len(order)),
+	}
+	for _, id := range order {
+		result := results[id]
+		if result.Success {
+			response.SuccessCount++
+		} else {
+			response.FailureCount++
+		}
+		response.Results = append(response.Results, *result)
+	}
+
+	status := http.StatusOK
+	if response.TotalCount > 0 && response.SuccessCount == 0 {
+		status = http.StatusBadRequest
+	} else if response.FailureCount > 0 {
+		status = 207 // Multi-Status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleRecategorize moves the user's transactions from one category to another in a single
+// update (POST /api/transactions/recategorize). Transactions the user already edited
+// (manipulated) are left alone; updated ones are marked manipulated so syncs keep the change.
+func (h *TransactionHandler) HandleRecategorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w, http.MethodPost)
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	var req RecategorizeRequest
+	if err := decodeJSON(w, r, &req, defaultDecodeOptions); err != nil {
+		log.Printf("Error decoding recategorize request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	from, ok := transaction.LookupParsaName(req.FromCategory)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Invalid fromCategory: "+req.FromCategory)
+		return
+	}
+	to, ok := transaction.LookupParsaName(req.ToCategory)
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, "Invalid toCategory: "+req.ToCategory)
+		return
+	}
+	if from == to {
+		writeJSONError(w, http.StatusBadRequest, "fromCategory and toCategory must differ")
+		return
+	}
+
+	var window transaction.DateWindow
+	if req.DateRange != nil {
+		if req.DateRange.From != "" {
+			start, err := time.Parse("2006-01-02", req.DateRange.From)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "dateRange.from must be a YYYY-MM-DD date")
+				return
+			}
+			window.Start = start
+		}
+		if req.DateRange.To != "" {
+			end, err := time.Parse("2006-01-02", req.DateRange.To)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "dateRange.to must be a YYYY-MM-DD date")
+				return
+			}
+			if !window.Start.IsZero() && end.Before(window.Start) {
+				writeJSONError(w, http.StatusBadRequest, "dateRange.to must not be before dateRange.from")
+				return
+			}
+			// Include the whole last day
+			window.End = end.AddDate(0, 0, 1).Add(-time.Microsecond)
+		}
+	}
+
+	updated, err := h.transactionRepo.Recategorize(r.Context(), userID, from, to, window)
+	if err != nil {
+		log.Printf("Error recategorizing transactions for user %d: %v", userID, err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to recategorize transactions")
+		return
+	}
+
+	updates := make([]audit.TransactionUpdate, 0, len(updated))
+	for _, id := range updated {
+		updates = append(updates, audit.TransactionUpdate{
+			Old: &transaction.Transaction{ID: id, Category: &from},
+			New: &transaction.Transaction{ID: id, Category: &to},
+		})
+	}
+	h.recordAuditBatch(userID, audit.SourceUser, audit.ActionUpdate, updates)
+
+	response := RecategorizeResponse{UpdatedCount: len(updated)}
+	if req.IncludeIDs {
+		response.TransactionIDs = updated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleBatchTransactions routes batch requests to POST or PATCH handlers
+func (h *TransactionHandler) HandleBatchTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handleBatchCreate(w, r)
+	case http.MethodPatch:
+		h.handleBatchPatch(w, r)
+	default:
+		writeMethodNotAllowed(w, http.MethodPost, http.MethodPatch)
+	}
+}
+
+// handleBatchCreate creates multiple transactions
+func (h *TransactionHandler) handleBatchCreate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+
+	var req BatchCreateRequest
+	if err := decodeJSON(w, r, &req, batchDecodeOptions); err != nil {
+		log.Printf("Error decoding batch create request: %v", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Transactions) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "transactions array is required and cannot be empty")
+		return
+	}
+
+	// Collect unique account IDs and verify ownership
+	accountCurrencies := make(map[string]string)
+	for _, txReq := range req.Transactions {
+		if txReq.AccountID == "" {
+			writeJSONError(w, http.StatusBadRequest, "accountId is required for all transactions")
+			return
+		}
+		accountCurrencies[txReq.AccountID] = ""
+	}
+
+	// Verify ownership for all accounts
+	for accountID := range accountCurrencies {
+		acc, err := h.accountRepo.GetByID(r.Context(), accountID)
+		if errors.Is(err, account.ErrAccountNotFound) {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("Account %s not found", accountID))
+			return
+		}
+		if err != nil {
+			log.Printf("Error getting account %s for batch create: %v", accountID, err)
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get account")
+			return
 		}
 		if acc.UserID != userID {
-			http.Error(w, "Forbidden", http.StatusForbidden)
+			writeJSONError(w, http.StatusForbidden, "Forbidden")
 			return
 		}
+		accountCurrencies[accountID] = acc.Currency
 	}
 
 	// Create all transactions, collecting results for each
@@ -524,6 +1846,14 @@ func (h *TransactionHandler) handleBatchCreate(w http.ResponseWriter, r *http.Re
 			})
 			continue
 		}
+		if txReq.Amount == 0 {
+			results = append(results, BatchItemResult{
+				Index:   idx,
+				Success: false,
+				Error:   "amount must be non-zero",
+			})
+			continue
+		}
 
 		transactionDate, err := time.Parse("2006-01-02", txReq.TransactionDate)
 		if err != nil {
@@ -550,7 +1880,7 @@ func (h *TransactionHandler) handleBatchCreate(w http.ResponseWriter, r *http.Re
 		txn, err := h.transactionRepo.Create(r.Context(), transaction.CreateTransactionParams{
 			ID:              txID,
 			AccountID:       txReq.AccountID,
-			Amount:          txReq.Amount,
+			Amount:          math.Abs(txReq.Amount),
 			Description:     txReq.Description,
 			Category:        txReq.Category,
 			TransactionDate: transactionDate,
@@ -569,13 +1899,15 @@ func (h *TransactionHandler) handleBatchCreate(w http.ResponseWriter, r *http.Re
 		}
 
 		successCount++
-		txnResponse := toTransactionAPIResponse(txn)
+		txnResponse := toTransactionAPIResponse(txn, accountCurrencies[txn.AccountID])
 		results = append(results, BatchItemResult{
 			Index:       idx,
 			Success:     true,
 			Transaction: &txnResponse,
 		})
 
+		h.recordAudit(userID, audit.ActionCreate, nil, txn)
+
 		// Run duplicate check after transaction creation
 		go func(createdTxn *transaction.Transaction) {
 			ctx := context.Background()
@@ -604,29 +1936,121 @@ func (h *TransactionHandler) handleBatchCreate(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// batchPatchedItem is a transaction updated by a batch patch, waiting for its tags
+type batchPatchedItem struct {
+	resultIndex int
+	txn         *transaction.Transaction
+	currency    string    // currency of the transaction's account
+	tags        *[]string // requested tags; nil leaves them unchanged
+}
+
+// applyBatchTags replaces the tags of the items that requested it and sets every item's
+// Tags to the final state. Current tags are read in one query and the difference is applied
+// with one UpdateTags call. When that call fails, each transaction's difference is retried on
+// its own so one bad tag ID fails only its item. When a transaction appears more than once, its
+// last requested tags win. Returns the IDs of the transactions whose tags could not be updated;
+// items that did not request tags keep an empty list if loading fails.
+func (h *TransactionHandler) applyBatchTags(ctx context.Context, items []batchPatchedItem) map[string]bool {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(items))
+	desired := make(map[string][]string)
+	for _, item := range items {
+		ids = append(ids, item.txn.ID)
+		if item.tags != nil {
+			desired[item.txn.ID] = *item.tags
+		}
+	}
+
+	current, err := h.transactionRepo.GetTagsForTransactions(ctx, ids)
+	if err != nil {
+		log.Printf("Error getting tags for %d patched transactions: %v", len(ids), err)
+		failed := make(map[string]bool, len(desired))
+		for id := range desired {
+			failed[id] = true
+		}
+		for _, item := range items {
+			item.txn.Tags = []string{}
+		}
+		return failed
+	}
+
+	add := make(map[string][]string)
+	remove := make(map[string][]string)
+	for id, want := range desired {
+		have := current[id]
+		for _, tagID := range want {
+			if !slices.Contains(have, tagID) && !slices.Contains(add[id], tagID) {
+				add[id] = append(add[id], tagID)
+			}
+		}
+		for _, tagID := range have {
+			if !slices.Contains(want, tagID) {
+				remove[id] = append(remove[id], tagID)
+			}
+		}
+	}
+
+	var failed map[string]bool
+	if err := h.transactionRepo.UpdateTags(ctx, add, remove); err != nil {
+		log.Printf("Error updating tags in batch patch, retrying per transaction: %v", err)
+		failed = make(map[string]bool)
+		for id := range desired {
+			err := h.transactionRepo.UpdateTags(ctx,
+				map[string][]string{id: add[id]}, map[string][]string{id: remove[id]})
+			if err != nil {
+				log.Printf("Error updating tags of transaction %s in batch patch: %v", id, err)
+				failed[id] = true
+				desired[id] = current[id]
+			}
+		}
+	}
+
+	setBatchTags(items, current, desired)
+	return failed
+}
+
+// setBatchTags sets each item's Tags to its desired tags when given, else its current ones
+func setBatchTags(items []batchPatchedItem, current, desired map[string][]string) {
+	for _, item := range items {
+		tags, ok := desired[item.txn.ID]
+		if !ok {
+			tags = current[item.txn.ID]
+		}
+		if tags == nil {
+			tags = []string{}
+		}
+		item.txn.Tags = slices.Clone(tags)
+	}
+}
+
 // handleBatchPatch updates multiple transactions
 func (h *TransactionHandler) handleBatchPatch(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(w)
 		return
 	}
 
 	var req BatchPatchRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req, batchDecodeOptions); err != nil {
 		log.Printf("Error decoding batch patch request: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
 	if len(req.Transactions) == 0 {
-		http.Error(w, "transactions array is required and cannot be empty", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, "transactions array is required and cannot be empty")
 		return
 	}
 
-	// Update all transactions, collecting results for each
+	// Update all transactions, collecting results for each. Tags are handled for all
+	// patched transactions at once after the loop.
 	results := make([]BatchItemResult, 0, len(req.Transactions))
 	successCount := 0
+	var patched []batchPatchedItem
 
 	for idx, patchReq := range req.Transactions {
 		// Validate transaction ID
@@ -640,40 +2064,12 @@ func (h *TransactionHandler) handleBatchPatch(w http.ResponseWriter, r *http.Req
 		}
 
 		// Verify transaction exists and ownership
-		txn, err := h.transactionRepo.GetByID(r.Context(), patchReq.ID)
+		txn, acc, err := h.verifyTransactionOwnership(r.Context(), patchReq.ID, userID)
 		if err != nil {
-			log.Printf("Error getting transaction %s for batch patch at index %d: %v", patchReq.ID, idx, err)
-			results = append(results, BatchItemResult{
-				Index:   idx,
-				Success: false,
-				Error:   "Failed to get transaction",
-			})
-			continue
-		}
-		if txn == nil {
 			results = append(results, BatchItemResult{
 				Index:   idx,
 				Success: false,
-				Error:   fmt.Sprintf("Transaction %s not found", patchReq.ID),
-			})
-			continue
-		}
-
-		acc, err := h.accountRepo.GetByID(r.Context(), txn.AccountID)
-		if err != nil || acc == nil {
-			log.Printf("Error getting account %s for transaction %s in batch patch at index %d: %v", txn.AccountID, patchReq.ID, idx, err)
-			results = append(results, BatchItemResult{
-				Index:   idx,
-				Success: false,
-				Error:   "Account not found",
-			})
-			continue
-		}
-		if acc.UserID != userID {
-			results = append(results, BatchItemResult{
-				Index:   idx,
-				Success: false,
-				Error:   "Forbidden: transaction does not belong to user",
+				Error:   ownershipErrorMessage(err, patchReq.ID),
 			})
 			continue
 		}
@@ -685,13 +2081,21 @@ func (h *TransactionHandler) handleBatchPatch(w http.ResponseWriter, r *http.Req
 			amount = &absVal
 		}
 
+		// A manual toggle of considered overrides any detection service decision
+		var consideredReason *string
+		if patchReq.Considered != nil && *patchReq.Considered != txn.Considered {
+			reason := transaction.ConsideredReasonUser
+			consideredReason = &reason
+		}
+
 		// Update transaction
 		updatedTxn, err := h.transactionRepo.Update(r.Context(), patchReq.ID, transaction.UpdateTransactionParams{
-			Amount:      amount,
-			Description: patchReq.Description,
-			Category:    patchReq.Category,
-			Considered:  patchReq.Considered,
-			Notes:       patchReq.Notes,
+			Amount:           amount,
+			Description:      patchReq.Description,
+			Category:         patchReq.Category,
+			Considered:       patchReq.Considered,
+			Notes:            patchReq.Notes,
+			ConsideredReason: consideredReason,
 		})
 
 		if err != nil {
@@ -704,37 +2108,24 @@ func (h *TransactionHandler) handleBatchPatch(w http.ResponseWriter, r *http.Req
 			continue
 		}
 
-		// Update tags if provided
-		if patchReq.Tags != nil {
-			if err := h.transactionRepo.SetTransactionTags(r.Context(), patchReq.ID, *patchReq.Tags); err != nil {
-				log.Printf("Error setting tags for transaction %s in batch at index %d: %v", patchReq.ID, idx, err)
-				results = append(results, BatchItemResult{
-					Index:   idx,
-					Success: false,
-					Error:   "Failed to update tags",
-				})
-				continue
-			}
-			// Update the Tags field in response
-			updatedTxn.Tags = *patchReq.Tags
-		} else {
-			// Fetch current tags for response
-			tags, err := h.transactionRepo.GetTransactionTags(r.Context(), patchReq.ID)
-			if err != nil {
-				log.Printf("Error getting tags for transaction %s: %v", patchReq.ID, err)
-				updatedTxn.Tags = []string{}
-			} else {
-				updatedTxn.Tags = tags
-			}
-		}
+		h.recordAudit(userID, audit.ActionUpdate, txn, updatedTxn)
+		updatedTxn = h.recheckDuplicatesAfterEdit(r.Context(), userID, txn, updatedTxn)
+
+		patched = append(patched, batchPatchedItem{resultIndex: len(results), txn: updatedTxn, currency: acc.Currency, tags: patchReq.Tags})
+		results = append(results, BatchItemResult{Index: idx, Success: true})
+	}
 
+	// Apply tag changes and load the final tags of every patched transaction in batch queries
+	tagFailed := h.applyBatchTags(r.Context(), patched)
+	for _, item := range patched {
+		if item.tags != nil && tagFailed[item.txn.ID] {
+			results[item.resultIndex].Success = false
+			results[item.resultIndex].Error = "Failed to update tags"
+			continue
+		}
 		successCount++
-		txnResponse := toTransactionAPIResponse(updatedTxn)
-		results = append(results, BatchItemResult{
-			Index:       idx,
-			Success:     true,
-			Transaction: &txnResponse,
-		})
+		txnResponse := toTransactionAPIResponse(item.txn, item.currency)
+		results[item.resultIndex].Transaction = &txnResponse
 	}
 
 	// Determine appropriate HTTP status code
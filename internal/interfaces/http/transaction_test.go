@@ -4,13 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"parsa/internal/domain/account"
+	"parsa/internal/domain/audit"
+	"parsa/internal/domain/bill"
+	"parsa/internal/domain/cousin"
 	"parsa/internal/domain/cousinrule"
 	"parsa/internal/domain/transaction"
+	"parsa/internal/domain/user"
 	"parsa/internal/shared/middleware"
 )
 
@@ -21,6 +32,18 @@ type MockTransactionRepo struct {
 	ListByAccountIDFunc                func(ctx context.Context, accountID string, limit, offset int) ([]*transaction.Transaction, error)
 	ListByUserIDFunc                   func(ctx context.Context, userID int64, limit, offset int) ([]*transaction.Transaction, error)
 	CountByUserIDFunc                  func(ctx context.Context, userID int64) (int64, error)
+	CountByAccountIDFunc               func(ctx context.Context, accountID string) (int64, error)
+	ListByUserIDFilteredFunc           func(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error)
+	CountByUserIDFilteredFunc          func(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error)
+	ListByUserIDUpdatedSinceFunc       func(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error)
+	CountByUserIDUpdatedSinceFunc      func(ctx context.Context, userID int64, since time.Time) (int64, error)
+	ReconsiderFunc                     func(ctx context.Context, ids []string, notes map[string]string) ([]string, error)
+	MarkTransferFunc                   func(ctx context.Context, ids []string, group string) (int64, error)
+	RecategorizeFunc                   func(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error)
+	ReleaseDetectionFunc               func(ctx context.Context, id string, reason string, notes *string) (bool, error)
+	SetCousinFunc                      func(ctx context.Context, transactionID string, cousinID int64) error
+	SetCousinWithoutRulesFunc          func(ctx context.Context, transactionID string, cousinID int64) error
+	ClearCousinFunc                    func(ctx context.Context, transactionID string) error
 	UpdateFunc                         func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error)
 	DeleteFunc                         func(ctx context.Context, id string) error
 	UpsertFunc                         func(ctx context.Context, params transaction.UpsertTransactionParams) (*transaction.Transaction, error)
@@ -28,6 +51,13 @@ type MockTransactionRepo struct {
 	FindPotentialDuplicatesForBillFunc func(ctx context.Context, criteria transaction.DuplicateCriteria) ([]*transaction.Transaction, error)
 	SetTransactionTagsFunc             func(ctx context.Context, transactionID string, tagIDs []string) error
 	GetTransactionTagsFunc             func(ctx context.Context, transactionID string) ([]string, error)
+	GetTagsForTransactionsFunc         func(ctx context.Context, transactionIDs []string) (map[string][]string, error)
+	UpdateTagsFunc                     func(ctx context.Context, add, remove map[string][]string) error
+	MonthlyTrendByCategoryFunc         func(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error)
+	CountGroupsFunc                    func(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error)
+	ListDescriptionSuggestionsFunc     func(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error)
+	MarkMissingAsRemovedFunc           func(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error)
+	MarkStalePendingFunc               func(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error)
 }
 
 func (m *MockTransactionRepo) Create(ctx context.Context, params transaction.CreateTransactionParams) (*transaction.Transaction, error) {
@@ -65,6 +95,89 @@ func (m *MockTransactionRepo) CountByUserID(ctx context.Context, userID int64) (
 	return 0, nil
 }
 
+func (m *MockTransactionRepo) CountByAccountID(ctx context.Context, accountID string) (int64, error) {
+	if m.CountByAccountIDFunc != nil {
+		return m.CountByAccountIDFunc(ctx, accountID)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepo) ListByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+	if m.ListByUserIDFilteredFunc != nil {
+		return m.ListByUserIDFilteredFunc(ctx, userID, filter, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) Reconsider(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+	if m.ReconsiderFunc != nil {
+		return m.ReconsiderFunc(ctx, ids, notes)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) Recategorize(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+	if m.RecategorizeFunc != nil {
+		return m.RecategorizeFunc(ctx, userID, from, to, window)
+	}
+	return nil, nil
+}
+func (m *MockTransactionRepo) MarkTransfer(ctx context.Context, ids []string, group string) (int64, error) {
+	if m.MarkTransferFunc != nil {
+		return m.MarkTransferFunc(ctx, ids, group)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepo) ReleaseDetection(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+	if m.ReleaseDetectionFunc != nil {
+		return m.ReleaseDetectionFunc(ctx, id, reason, notes)
+	}
+	return false, nil
+}
+
+func (m *MockTransactionRepo) SetCousin(ctx context.Context, transactionID string, cousinID int64) error {
+	if m.SetCousinFunc != nil {
+		return m.SetCousinFunc(ctx, transactionID, cousinID)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepo) SetCousinWithoutRules(ctx context.Context, transactionID string, cousinID int64) error {
+	if m.SetCousinWithoutRulesFunc != nil {
+		return m.SetCousinWithoutRulesFunc(ctx, transactionID, cousinID)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepo) ClearCousin(ctx context.Context, transactionID string) error {
+	if m.ClearCousinFunc != nil {
+		return m.ClearCousinFunc(ctx, transactionID)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepo) CountByUserIDFiltered(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+	if m.CountByUserIDFilteredFunc != nil {
+		return m.CountByUserIDFilteredFunc(ctx, userID, filter)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepo) ListByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+	if m.ListByUserIDUpdatedSinceFunc != nil {
+		return m.ListByUserIDUpdatedSinceFunc(ctx, userID, since, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) CountByUserIDUpdatedSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	if m.CountByUserIDUpdatedSinceFunc != nil {
+		return m.CountByUserIDUpdatedSinceFunc(ctx, userID, since)
+	}
+	return 0, nil
+}
+
 func (m *MockTransactionRepo) Update(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(ctx, id, params)
@@ -118,20 +231,74 @@ func (m *MockTransactionRepo) GetTransactionTags(ctx context.Context, transactio
 	return nil, nil
 }
 
+func (m *MockTransactionRepo) GetTagsForTransactions(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+	if m.GetTagsForTransactionsFunc != nil {
+		return m.GetTagsForTransactionsFunc(ctx, transactionIDs)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) UpdateTags(ctx context.Context, add, remove map[string][]string) error {
+	if m.UpdateTagsFunc != nil {
+		return m.UpdateTagsFunc(ctx, add, remove)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepo) MonthlyTrendByCategory(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+	if m.MonthlyTrendByCategoryFunc != nil {
+		return m.MonthlyTrendByCategoryFunc(ctx, userID, category, since)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) CountGroups(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+	if m.CountGroupsFunc != nil {
+		return m.CountGroupsFunc(ctx, userID, window)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) ListDescriptionSuggestions(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+	if m.ListDescriptionSuggestionsFunc != nil {
+		return m.ListDescriptionSuggestionsFunc(ctx, userID, prefix, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) MarkMissingAsRemoved(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	if m.MarkMissingAsRemovedFunc != nil {
+		return m.MarkMissingAsRemovedFunc(ctx, accountID, presentIDs, window)
+	}
+	return 0, nil
+}
+func (m *MockTransactionRepo) MarkStalePending(ctx context.Context, accountID string, presentIDs []string, window transaction.DateWindow) (int64, error) {
+	if m.MarkStalePendingFunc != nil {
+		return m.MarkStalePendingFunc(ctx, accountID, presentIDs, window)
+	}
+	return 0, nil
+}
+
 // MockCousinRuleRepo implements cousinrule.Repository for testing
 type MockCousinRuleRepo struct {
-	CreateFunc                 func(ctx context.Context, params cousinrule.CreateCousinRuleParams) (*cousinrule.CousinRule, error)
-	GetByIDFunc                func(ctx context.Context, id int64) (*cousinrule.CousinRule, error)
-	GetByCousinAndTypeFunc     func(ctx context.Context, userID, cousinID int64, txType *string) (*cousinrule.CousinRule, error)
-	ListByUserIDFunc           func(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error)
-	ListByCousinIDFunc         func(ctx context.Context, userID, cousinID int64) ([]*cousinrule.CousinRule, error)
-	UpdateFunc                 func(ctx context.Context, id int64, params cousinrule.UpdateCousinRuleParams) (*cousinrule.CousinRule, error)
-	UpsertFunc                 func(ctx context.Context, params cousinrule.CreateCousinRuleParams) (*cousinrule.CousinRule, bool, error)
-	DeleteFunc                 func(ctx context.Context, id int64) error
-	SetRuleTagsFunc            func(ctx context.Context, ruleID int64, tagIDs []string) error
-	GetRuleTagsFunc            func(ctx context.Context, ruleID int64) ([]string, error)
-	ApplyRuleToTransactionsFunc func(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) (int, error)
-	CheckDontAskAgainFunc      func(ctx context.Context, userID, cousinID int64, txType string) (bool, error)
+	CreateFunc                   func(ctx context.Context, params cousinrule.CreateCousinRuleParams) (*cousinrule.CousinRule, error)
+	GetByIDFunc                  func(ctx context.Context, id int64) (*cousinrule.CousinRule, error)
+	GetByCousinAndTypeFunc       func(ctx context.Context, userID, cousinID int64, txType *string) (*cousinrule.CousinRule, error)
+	ListByUserIDFunc             func(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.CousinRule, error)
+	ListAllByUserIDFunc          func(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error)
+	CountByUserIDFunc            func(ctx context.Context, userID int64) (int64, error)
+	ListByUserIDWithCountsFunc   func(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.RuleWithCount, error)
+	ListByCousinIDFunc           func(ctx context.Context, userID, cousinID int64) ([]*cousinrule.CousinRule, error)
+	ListByCousinIDsFunc          func(ctx context.Context, userID int64, cousinIDs []int64) ([]*cousinrule.CousinRule, error)
+	UpdateFunc                   func(ctx context.Context, id int64, params cousinrule.UpdateCousinRuleParams) (*cousinrule.CousinRule, error)
+	UpsertFunc                   func(ctx context.Context, params cousinrule.CreateCousinRuleParams) (*cousinrule.CousinRule, bool, error)
+	ImportRulesFunc              func(ctx context.Context, rules []cousinrule.CreateCousinRuleParams) (int, int, error)
+	MissingCousinIDsFunc         func(ctx context.Context, ids []int64) ([]int64, error)
+	DeleteFunc                   func(ctx context.Context, id int64) error
+	SetRuleTagsFunc              func(ctx context.Context, ruleID int64, tagIDs []string) error
+	GetRuleTagsFunc              func(ctx context.Context, ruleID int64) ([]string, error)
+	ApplyRuleToTransactionsFunc  func(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) ([]string, error)
+	ListMatchingTransactionsFunc func(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error)
 }
 
 func (m *MockCousinRuleRepo) Create(ctx context.Context, params cousinrule.CreateCousinRuleParams) (*cousinrule.CousinRule, error) {
@@ -155,9 +322,30 @@ func (m *MockCousinRuleRepo) GetByCousinAndType(ctx context.Context, userID, cou
 	return nil, nil
 }
 
-func (m *MockCousinRuleRepo) ListByUserID(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error) {
+func (m *MockCousinRuleRepo) ListByUserID(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.CousinRule, error) {
 	if m.ListByUserIDFunc != nil {
-		return m.ListByUserIDFunc(ctx, userID)
+		return m.ListByUserIDFunc(ctx, userID, limit, offset)
+	}
+	return nil, nil
+}
+
+func (m *MockCousinRuleRepo) ListAllByUserID(ctx context.Context, userID int64) ([]*cousinrule.CousinRule, error) {
+	if m.ListAllByUserIDFunc != nil {
+		return m.ListAllByUserIDFunc(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockCousinRuleRepo) CountByUserID(ctx context.Context, userID int64) (int64, error) {
+	if m.CountByUserIDFunc != nil {
+		return m.CountByUserIDFunc(ctx, userID)
+	}
+	return 0, nil
+}
+
+func (m *MockCousinRuleRepo) ListByUserIDWithCounts(ctx context.Context, userID int64, limit, offset int) ([]*cousinrule.RuleWithCount, error) {
+	if m.ListByUserIDWithCountsFunc != nil {
+		return m.ListByUserIDWithCountsFunc(ctx, userID, limit, offset)
 	}
 	return nil, nil
 }
@@ -169,6 +357,13 @@ func (m *MockCousinRuleRepo) ListByCousinID(ctx context.Context, userID, cousinI
 	return nil, nil
 }
 
+func (m *MockCousinRuleRepo) ListByCousinIDs(ctx context.Context, userID int64, cousinIDs []int64) ([]*cousinrule.CousinRule, error) {
+	if m.ListByCousinIDsFunc != nil {
+		return m.ListByCousinIDsFunc(ctx, userID, cousinIDs)
+	}
+	return nil, nil
+}
+
 func (m *MockCousinRuleRepo) Update(ctx context.Context, id int64, params cousinrule.UpdateCousinRuleParams) (*cousinrule.CousinRule, error) {
 	if m.UpdateFunc != nil {
 		return m.UpdateFunc(ctx, id, params)
@@ -183,6 +378,20 @@ func (m *MockCousinRuleRepo) Upsert(ctx context.Context, params cousinrule.Creat
 	return nil, false, nil
 }
 
+func (m *MockCousinRuleRepo) ImportRules(ctx context.Context, rules []cousinrule.CreateCousinRuleParams) (int, int, error) {
+	if m.ImportRulesFunc != nil {
+		return m.ImportRulesFunc(ctx, rules)
+	}
+	return 0, 0, nil
+}
+
+func (m *MockCousinRuleRepo) MissingCousinIDs(ctx context.Context, ids []int64) ([]int64, error) {
+	if m.MissingCousinIDsFunc != nil {
+		return m.MissingCousinIDsFunc(ctx, ids)
+	}
+	return nil, nil
+}
+
 func (m *MockCousinRuleRepo) Delete(ctx context.Context, id int64) error {
 	if m.DeleteFunc != nil {
 		return m.DeleteFunc(ctx, id)
@@ -204,18 +413,18 @@ func (m *MockCousinRuleRepo) GetRuleTags(ctx context.Context, ruleID int64) ([]s
 	return nil, nil
 }
 
-func (m *MockCousinRuleRepo) ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) (int, error) {
+func (m *MockCousinRuleRepo) ApplyRuleToTransactions(ctx context.Context, userID, cousinID int64, txType *string, changes cousinrule.Changes) ([]string, error) {
 	if m.ApplyRuleToTransactionsFunc != nil {
 		return m.ApplyRuleToTransactionsFunc(ctx, userID, cousinID, txType, changes)
 	}
-	return 0, nil
+	return nil, nil
 }
 
-func (m *MockCousinRuleRepo) CheckDontAskAgain(ctx context.Context, userID, cousinID int64, txType string) (bool, error) {
-	if m.CheckDontAskAgainFunc != nil {
-		return m.CheckDontAskAgainFunc(ctx, userID, cousinID, txType)
+func (m *MockCousinRuleRepo) ListMatchingTransactions(ctx context.Context, userID, cousinID int64, txType *string) ([]*transaction.Transaction, error) {
+	if m.ListMatchingTransactionsFunc != nil {
+		return m.ListMatchingTransactionsFunc(ctx, userID, cousinID, txType)
 	}
-	return false, nil
+	return nil, nil
 }
 
 func TestHandleListTransactions(t *testing.T) {
@@ -270,6 +479,317 @@ func TestHandleListTransactions(t *testing.T) {
 	}
 }
 
+func TestHandleListTransactions_Pagination(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantOffset   int
+		wantNext     string
+		wantPrevious string
+		wantLinkRels []string
+	}{
+		{
+			name:         "first page",
+			query:        "",
+			wantOffset:   0,
+			wantNext:     "http://example.com/api/transactions?page=2",
+			wantLinkRels: []string{`rel="next"`, `rel="first"`, `rel="last"`},
+		},
+		{
+			name:         "middle page keeps filters",
+			query:        "?page=2&considered=true",
+			wantOffset:   pageSize,
+			wantNext:     "http://example.com/api/transactions?considered=true&page=3",
+			wantPrevious: "http://example.com/api/transactions?considered=true&page=1",
+			wantLinkRels: []string{`rel="next"`, `rel="prev"`, `rel="first"`, `rel="last"`},
+		},
+		{
+			name:         "last page",
+			query:        "?page=3",
+			wantOffset:   2 * pageSize,
+			wantPrevious: "http://example.com/api/transactions?page=2",
+			wantLinkRels: []string{`rel="prev"`, `rel="first"`, `rel="last"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOffset int
+			list := func(offset int) ([]*transaction.Transaction, error) {
+				gotOffset = offset
+				return []*transaction.Transaction{{ID: "tx-1", AccountID: "acc-1", Type: "DEBIT", Status: "POSTED"}}, nil
+			}
+			txRepo := &MockTransactionRepo{
+				CountByUserIDFunc: func(ctx context.Context, userID int64) (int64, error) {
+					return 250, nil
+				},
+				CountByUserIDFilteredFunc: func(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+					return 250, nil
+				},
+				ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*transaction.Transaction, error) {
+					return list(offset)
+				},
+				ListByUserIDFilteredFunc: func(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+					return list(offset)
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/api/transactions"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleListTransactions(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			if gotOffset != tt.wantOffset {
+				t.Errorf("offset = %d, want %d", gotOffset, tt.wantOffset)
+			}
+
+			var resp TransactionListResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Count != 250 {
+				t.Errorf("count = %d, want 250", resp.Count)
+			}
+			if got := derefString(resp.Next); got != tt.wantNext {
+				t.Errorf("next = %q, want %q", got, tt.wantNext)
+			}
+			if got := derefString(resp.Previous); got != tt.wantPrevious {
+				t.Errorf("previous = %q, want %q", got, tt.wantPrevious)
+			}
+			if got := rr.Header().Get("X-Total-Count"); got != "250" {
+				t.Errorf("X-Total-Count = %q, want 250", got)
+			}
+			link := rr.Header().Get("Link")
+			for _, rel := range tt.wantLinkRels {
+				if !strings.Contains(link, rel) {
+					t.Errorf("Link %q has no %s", link, rel)
+				}
+			}
+			if strings.Count(link, "rel=") != len(tt.wantLinkRels) {
+				t.Errorf("Link %q, want only %v", link, tt.wantLinkRels)
+			}
+		})
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func TestHandleListTransactions_Summary(t *testing.T) {
+	category := "food"
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		prefer         string
+		expectedStatus int
+		wantSummary    bool
+	}{
+		{name: "default is full", expectedStatus: http.StatusOK},
+		{name: "fields summary", query: "?fields=summary", expectedStatus: http.StatusOK, wantSummary: true},
+		{name: "prefer minimal", prefer: "respond-async, return=minimal", expectedStatus: http.StatusOK, wantSummary: true},
+		{name: "fields summary with prefer", query: "?fields=summary", prefer: "return=minimal", expectedStatus: http.StatusOK, wantSummary: true},
+		{name: "fields full overrides prefer", query: "?fields=full", prefer: "return=minimal", expectedStatus: http.StatusOK},
+		{name: "invalid fields", query: "?fields=tiny", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagLookups := 0
+			txRepo := &MockTransactionRepo{
+				CountByUserIDFunc: func(ctx context.Context, userID int64) (int64, error) {
+					return 1, nil
+				},
+				ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*transaction.Transaction, error) {
+					return []*transaction.Transaction{{
+						ID: "tx-1", AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", Amount: 12.5,
+						Description: "Lunch", Category: &category, TransactionDate: date,
+					}}, nil
+				},
+				GetTransactionTagsFunc: func(ctx context.Context, transactionID string) ([]string, error) {
+					tagLookups++
+					return []string{}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/transactions"+tt.query, nil)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleListTransactions(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var raw struct {
+				Results []map[string]any `json:"results"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&raw); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(raw.Results) != 1 {
+				t.Fatalf("got %d results, want 1", len(raw.Results))
+			}
+			result := raw.Results[0]
+
+			if !tt.wantSummary {
+				if _, ok := result["currency"]; !ok {
+					t.Errorf("full response is missing currency: %v", result)
+				}
+				if tagLookups != 1 {
+					t.Errorf("tags looked up %d times, want 1", tagLookups)
+				}
+				return
+			}
+
+			want := map[string]any{
+				"id":              "tx-1",
+				"amount":          -12.5,
+				"transactionDate": date.Format(time.RFC3339),
+				"description":     "Lunch",
+				"category":        "food",
+			}
+			if len(result) != len(want) {
+				t.Errorf("summary fields = %v, want %v", result, want)
+			}
+			for k, v := range want {
+				if result[k] != v {
+					t.Errorf("%s = %v, want %v", k, result[k], v)
+				}
+			}
+			if tagLookups != 0 {
+				t.Errorf("summary looked up tags %d times, want 0", tagLookups)
+			}
+			wantApplied := ""
+			if tt.prefer != "" && tt.query == "" {
+				wantApplied = "return=minimal"
+			}
+			if got := rr.Header().Get("Preference-Applied"); got != wantApplied {
+				t.Errorf("Preference-Applied = %q, want %q", got, wantApplied)
+			}
+		})
+	}
+}
+
+func TestToTransactionAPIResponse_Installment(t *testing.T) {
+	resp := toTransactionAPIResponse(&transaction.Transaction{
+		ID: "tx-1", Type: "DEBIT", Installment: &transaction.Installment{Number: 3, Total: 10},
+	}, "BRL")
+	if resp.InstallmentNumber == nil || *resp.InstallmentNumber != 3 {
+		t.Errorf("installmentNumber = %v, want 3", resp.InstallmentNumber)
+	}
+	if resp.TotalInstallments == nil || *resp.TotalInstallments != 10 {
+		t.Errorf("totalInstallments = %v, want 10", resp.TotalInstallments)
+	}
+	if resp.RemainingInstallments == nil || *resp.RemainingInstallments != 7 {
+		t.Errorf("remainingInstallments = %v, want 7", resp.RemainingInstallments)
+	}
+
+	last := toTransactionAPIResponse(&transaction.Transaction{
+		ID: "tx-2", Type: "DEBIT", Installment: &transaction.Installment{Number: 10, Total: 10},
+	}, "BRL")
+	if last.RemainingInstallments == nil || *last.RemainingInstallments != 0 {
+		t.Errorf("remainingInstallments of the last installment = %v, want 0", last.RemainingInstallments)
+	}
+
+	single, err := json.Marshal(toTransactionAPIResponse(&transaction.Transaction{ID: "tx-3", Type: "DEBIT"}, "BRL"))
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	if bytes.Contains(single, []byte("nstallment")) {
+		t.Errorf("non-installment transaction encoded installment fields: %s", single)
+	}
+}
+
+func TestToTransactionAPIResponse_ProviderTimestamps(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	synced := toTransactionAPIResponse(&transaction.Transaction{
+		ID: "tx-1", Type: "DEBIT", ProviderCreatedAt: created, ProviderUpdatedAt: updated,
+	}, "BRL")
+	if synced.ProviderCreatedAt == nil || *synced.ProviderCreatedAt != "2024-03-01T09:30:00Z" {
+		t.Errorf("providerCreatedAt = %v, want 2024-03-01T09:30:00Z", synced.ProviderCreatedAt)
+	}
+	if synced.ProviderUpdatedAt == nil || *synced.ProviderUpdatedAt != "2024-03-02T10:00:00Z" {
+		t.Errorf("providerUpdatedAt = %v, want 2024-03-02T10:00:00Z", synced.ProviderUpdatedAt)
+	}
+
+	manual, err := json.Marshal(toTransactionAPIResponse(&transaction.Transaction{ID: "tx-2", Type: "DEBIT"}, "BRL"))
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	if bytes.Contains(manual, []byte("providerCreatedAt")) || bytes.Contains(manual, []byte("providerUpdatedAt")) {
+		t.Errorf("manual transaction response %s carries provider timestamps", manual)
+	}
+}
+
+func TestHandleListTransactions_AccountCurrency(t *testing.T) {
+	listAccountsCalls := 0
+	txRepo := &MockTransactionRepo{
+		CountByUserIDFunc: func(ctx context.Context, userID int64) (int64, error) {
+			return 3, nil
+		},
+		ListByUserIDFunc: func(ctx context.Context, userID int64, limit, offset int) ([]*transaction.Transaction, error) {
+			return []*transaction.Transaction{
+				{ID: "tx-1", AccountID: "acc-brl", Type: "DEBIT", Status: "POSTED"},
+				{ID: "tx-2", AccountID: "acc-usd", Type: "CREDIT", Status: "POSTED"},
+				{ID: "tx-3", AccountID: "acc-unknown", Type: "DEBIT", Status: "POSTED"},
+			}, nil
+		},
+	}
+	accRepo := &MockAccountRepo{
+		ListByUserIDFunc: func(ctx context.Context, userID int64) ([]*account.Account, error) {
+			listAccountsCalls++
+			return []*account.Account{
+				{ID: "acc-brl", UserID: 1, Currency: "BRL"},
+				{ID: "acc-usd", UserID: 1, Currency: "USD"},
+			}, nil
+		},
+	}
+	handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/transactions", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+	rr := httptest.NewRecorder()
+	handler.HandleListTransactions(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var resp TransactionListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	want := map[string]string{"tx-1": "BRL", "tx-2": "USD", "tx-3": account.DefaultCurrency}
+	for _, result := range resp.Results {
+		if result.Currency != want[result.ID] {
+			t.Errorf("transaction %s currency = %q, want %q", result.ID, result.Currency, want[result.ID])
+		}
+	}
+	if listAccountsCalls != 1 {
+		t.Errorf("accounts were listed %d times for one page, want 1", listAccountsCalls)
+	}
+}
+
 func TestHandleCreateTransaction(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -346,46 +866,167 @@ func TestHandleCreateTransaction(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			txRepo := tt.mockTxRepo()
-			accRepo := tt.mockAccRepo()
-			cousinRepo := &MockCousinRuleRepo{}
-			handler := NewTransactionHandler(txRepo, accRepo, cousinRepo)
-
-			bodyBytes, _ := json.Marshal(tt.body)
-			req, _ := http.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBuffer(bodyBytes))
-			ctx := context.WithValue(req.Context(), middleware.UserIDKey, tt.userID)
-			req = req.WithContext(ctx)
-
-			rr := httptest.NewRecorder()
-			handler.HandleCreateTransaction(rr, req)
-
-			if rr.Code != tt.expectedStatus {
-				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
-			}
-		})
-	}
-}
-
-func TestHandleGetTransaction(t *testing.T) {
-	tests := []struct {
-		name           string
-		transactionID  string
-		userID         int64
-		mockTxRepo     func() *MockTransactionRepo
-		mockAccRepo    func() *MockAccountRepo
-		expectedStatus int
-	}{
 		{
-			name:          "Success",
-			transactionID: "tx-1",
-			userID:        1,
+			name: "Account Not Found",
+			body: map[string]interface{}{
+				"accountId":       "acc-missing",
+				"amount":          100.0,
+				"description":     "Test Tx",
+				"transactionDate": "2023-01-01",
+			},
+			userID: 1,
 			mockTxRepo: func() *MockTransactionRepo {
-				return &MockTransactionRepo{
-					GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+				return &MockTransactionRepo{}
+			},
+			mockAccRepo: func() *MockAccountRepo {
+				return &MockAccountRepo{
+					GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+						return nil, account.ErrAccountNotFound
+					},
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Zero Amount",
+			body: map[string]interface{}{
+				"accountId":       "acc-1",
+				"amount":          0.0,
+				"description":     "Test Tx",
+				"transactionDate": "2023-01-01",
+			},
+			userID: 1,
+			mockTxRepo: func() *MockTransactionRepo {
+				return &MockTransactionRepo{}
+			},
+			mockAccRepo: func() *MockAccountRepo {
+				return &MockAccountRepo{}
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Missing Description",
+			body: map[string]interface{}{
+				"accountId":       "acc-1",
+				"amount":          100.0,
+				"transactionDate": "2023-01-01",
+			},
+			userID: 1,
+			mockTxRepo: func() *MockTransactionRepo {
+				return &MockTransactionRepo{}
+			},
+			mockAccRepo: func() *MockAccountRepo {
+				return &MockAccountRepo{}
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Account Lookup Error",
+			body: map[string]interface{}{
+				"accountId":       "acc-1",
+				"amount":          100.0,
+				"description":     "Test Tx",
+				"transactionDate": "2023-01-01",
+			},
+			userID: 1,
+			mockTxRepo: func() *MockTransactionRepo {
+				return &MockTransactionRepo{}
+			},
+			mockAccRepo: func() *MockAccountRepo {
+				return &MockAccountRepo{
+					GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+						return nil, errors.New("connection refused")
+					},
+				}
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txRepo := tt.mockTxRepo()
+			accRepo := tt.mockAccRepo()
+			cousinRepo := &MockCousinRuleRepo{}
+			handler := NewTransactionHandler(txRepo, accRepo, cousinRepo)
+
+			bodyBytes, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBuffer(bodyBytes))
+			ctx := context.WithValue(req.Context(), middleware.UserIDKey, tt.userID)
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			handler.HandleCreateTransaction(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestHandleCreateTransaction_NegativeAmount(t *testing.T) {
+	var created transaction.CreateTransactionParams
+	txRepo := &MockTransactionRepo{
+		CreateFunc: func(ctx context.Context, params transaction.CreateTransactionParams) (*transaction.Transaction, error) {
+			created = params
+			return &transaction.Transaction{ID: params.ID, AccountID: params.AccountID, Amount: params.Amount, Type: params.Type, Status: params.Status}, nil
+		},
+	}
+	accRepo := &MockAccountRepo{
+		GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+			return &account.Account{ID: "acc-1", UserID: 1}, nil
+		},
+	}
+	handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+	bodyBytes, _ := json.Marshal(map[string]interface{}{
+		"accountId":       "acc-1",
+		"amount":          -50.0,
+		"type":            "CREDIT",
+		"description":     "Refund",
+		"transactionDate": "2023-01-01",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBuffer(bodyBytes))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+
+	rr := httptest.NewRecorder()
+	handler.HandleCreateTransaction(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+	if created.Amount != 50 {
+		t.Errorf("stored amount = %v, want 50", created.Amount)
+	}
+
+	var resp struct {
+		Amount float64 `json:"amount"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Amount != 50 {
+		t.Errorf("response amount = %v, want 50", resp.Amount)
+	}
+}
+
+func TestHandleGetTransaction(t *testing.T) {
+	tests := []struct {
+		name           string
+		transactionID  string
+		userID         int64
+		mockTxRepo     func() *MockTransactionRepo
+		mockAccRepo    func() *MockAccountRepo
+		expectedStatus int
+	}{
+		{
+			name:          "Success",
+			transactionID: "tx-1",
+			userID:        1,
+			mockTxRepo: func() *MockTransactionRepo {
+				return &MockTransactionRepo{
+					GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
 						return &transaction.Transaction{ID: "tx-1", AccountID: "acc-1", Type: "DEBIT", Status: "POSTED"}, nil
 					},
 					GetTransactionTagsFunc: func(ctx context.Context, transactionID string) ([]string, error) {
@@ -438,6 +1079,26 @@ func TestHandleGetTransaction(t *testing.T) {
 			},
 			expectedStatus: http.StatusForbidden,
 		},
+		{
+			name:          "Account Lookup Error",
+			transactionID: "tx-1",
+			userID:        1,
+			mockTxRepo: func() *MockTransactionRepo {
+				return &MockTransactionRepo{
+					GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+						return &transaction.Transaction{ID: "tx-1", AccountID: "acc-1", Type: "DEBIT", Status: "POSTED"}, nil
+					},
+				}
+			},
+			mockAccRepo: func() *MockAccountRepo {
+				return &MockAccountRepo{
+					GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+						return nil, errors.New("connection refused")
+					},
+				}
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
 	}
 
 	for _, tt := range tests {
@@ -464,3 +1125,1843 @@ func TestHandleGetTransaction(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleBatchPatch_ConsideredReason(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name       string
+		current    bool
+		considered *bool
+		wantReason *string
+	}{
+		{name: "toggle off sets USER", current: true, considered: boolPtr(false), wantReason: strPtr(transaction.ConsideredReasonUser)},
+		{name: "toggle on sets USER", current: false, considered: boolPtr(true), wantReason: strPtr(transaction.ConsideredReasonUser)},
+		{name: "unchanged value keeps reason", current: true, considered: boolPtr(true), wantReason: nil},
+		{name: "considered omitted keeps reason", current: true, considered: nil, wantReason: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReason *string
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", Considered: tt.current}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					gotReason = params.ConsideredReason
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", ConsideredReason: params.ConsideredReason}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					return &account.Account{ID: "acc-1", UserID: 1}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			body, _ := json.Marshal(BatchPatchRequest{Transactions: []PatchTransactionItem{{ID: "tx-1", Considered: tt.considered}}})
+			req, _ := http.NewRequest(http.MethodPatch, "/api/transactions/update", bytes.NewBuffer(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleBatchTransactions(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			if (gotReason == nil) != (tt.wantReason == nil) || (gotReason != nil && *gotReason != *tt.wantReason) {
+				t.Errorf("ConsideredReason = %v, want %v", gotReason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestHandleBatchPatch_RecheckDuplicates(t *testing.T) {
+	amountPtr := func(f float64) *float64 { return &f }
+	descPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		patch       PatchTransactionItem
+		wantRecheck bool
+	}{
+		{name: "amount edit rechecks", enabled: true, patch: PatchTransactionItem{ID: "tx-1", Amount: amountPtr(70)}, wantRecheck: true},
+		{name: "description edit skips recheck", enabled: true, patch: PatchTransactionItem{ID: "tx-1", Description: descPtr("Lunch")}},
+		{name: "disabled", enabled: false, patch: PatchTransactionItem{ID: "tx-1", Amount: amountPtr(70)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duplicate := transaction.ConsideredReasonDuplicate
+			var searched []float64
+			var released []string
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", Amount: 50}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					amount := 50.0
+					if params.Amount != nil {
+						amount = *params.Amount
+					}
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", Amount: amount}, nil
+				},
+				FindPotentialDuplicatesFunc: func(ctx context.Context, criteria transaction.DuplicateCriteria) ([]*transaction.Transaction, error) {
+					searched = append(searched, criteria.AbsoluteAmount)
+					if criteria.ExcludeID == "tx-1" && criteria.AbsoluteAmount == 50 {
+						return []*transaction.Transaction{{ID: "tx-old-match", Type: "CREDIT", Amount: 50, ConsideredReason: &duplicate}}, nil
+					}
+					return []*transaction.Transaction{}, nil
+				},
+				ReleaseDetectionFunc: func(ctx context.Context, id string, reason string, notes *string) (bool, error) {
+					released = append(released, id)
+					return true, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					return &account.Account{ID: "acc-1", UserID: 1}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+			handler.SetRecheckDuplicatesOnEdit(tt.enabled)
+
+			body, _ := json.Marshal(BatchPatchRequest{Transactions: []PatchTransactionItem{tt.patch}})
+			req, _ := http.NewRequest(http.MethodPatch, "/api/transactions/update", bytes.NewBuffer(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleBatchTransactions(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+			}
+			if !tt.wantRecheck {
+				if len(searched) != 0 || len(released) != 0 {
+					t.Errorf("searched %v and released %v, want no recheck", searched, released)
+				}
+				return
+			}
+			if len(released) != 1 || released[0] != "tx-old-match" {
+				t.Errorf("released = %v, want [tx-old-match]", released)
+			}
+		})
+	}
+}
+
+// MockCousinRepo implements cousin.Repository for testing
+type MockCousinRepo struct {
+	CreateFunc       func(ctx context.Context, userID int64, params cousin.CreateCousinParams) (*cousin.Cousin, error)
+	FindOrCreateFunc func(ctx context.Context, userID int64, name string, documentID *int64) (*cousin.Cousin, error)
+	GetByIDFunc      func(ctx context.Context, id int64) (*cousin.Cousin, error)
+	ListByUserIDFunc func(ctx context.Context, userID int64) ([]*cousin.Cousin, error)
+	DeleteFunc       func(ctx context.Context, id int64) error
+}
+
+func (m *MockCousinRepo) Create(ctx context.Context, userID int64, params cousin.CreateCousinParams) (*cousin.Cousin, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, userID, params)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) FindOrCreate(ctx context.Context, userID int64, name string, documentID *int64) (*cousin.Cousin, error) {
+	if m.FindOrCreateFunc != nil {
+		return m.FindOrCreateFunc(ctx, userID, name, documentID)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) GetByID(ctx context.Context, id int64) (*cousin.Cousin, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) ListByUserID(ctx context.Context, userID int64) ([]*cousin.Cousin, error) {
+	if m.ListByUserIDFunc != nil {
+		return m.ListByUserIDFunc(ctx, userID)
+	}
+	return nil, nil
+}
+func (m *MockCousinRepo) Delete(ctx context.Context, id int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	return nil
+}
+
+func TestHandleBatchPatch_Tags(t *testing.T) {
+	tagsPtr := func(tags ...string) *[]string {
+		if tags == nil {
+			tags = []string{} // marshal as [] rather than null
+		}
+		return &tags
+	}
+
+	tests := []struct {
+		name        string
+		items       []PatchTransactionItem
+		updateErr   error
+		badTag      string // UpdateTags fails whenever it adds this tag
+		wantStatus  int
+		wantAdd     map[string][]string
+		wantRemove  map[string][]string
+		wantTags    map[string][]string // transaction ID -> tags in the response
+		wantFailed  []int
+		wantGetCall int
+	}{
+		{
+			name: "replaces tags with one add and one remove",
+			items: []PatchTransactionItem{
+				{ID: "tx-1", Tags: tagsPtr("tag-b", "tag-c")},
+				{ID: "tx-2"},
+			},
+			wantStatus: http.StatusOK,
+			wantAdd:    map[string][]string{"tx-1": {"tag-c"}},
+			wantRemove: map[string][]string{"tx-1": {"tag-a"}},
+			wantTags:   map[string][]string{"tx-1": {"tag-b", "tag-c"}, "tx-2": {"tag-x"}},
+		},
+		{
+			name: "empty list clears tags",
+			items: []PatchTransactionItem{
+				{ID: "tx-1", Tags: tagsPtr()},
+			},
+			wantStatus: http.StatusOK,
+			wantAdd:    map[string][]string{},
+			wantRemove: map[string][]string{"tx-1": {"tag-a", "tag-b"}},
+			wantTags:   map[string][]string{"tx-1": {}},
+		},
+		{
+			name: "tag write failure fails only items that changed tags",
+			items: []PatchTransactionItem{
+				{ID: "tx-1", Tags: tagsPtr("tag-c")},
+				{ID: "tx-2"},
+			},
+			updateErr:  errors.New("db down"),
+			wantStatus: http.StatusMultiStatus,
+			wantTags:   map[string][]string{"tx-2": {"tag-x"}},
+			wantFailed: []int{0},
+		},
+		{
+			name: "bad tag fails only its item",
+			items: []PatchTransactionItem{
+				{ID: "tx-1", Tags: tagsPtr("tag-c")},
+				{ID: "tx-2", Tags: tagsPtr("not-a-tag")},
+				{ID: "tx-3", Tags: tagsPtr("tag-d")},
+			},
+			badTag:     "not-a-tag",
+			wantStatus: http.StatusMultiStatus,
+			wantTags:   map[string][]string{"tx-1": {"tag-c"}, "tx-3": {"tag-d"}},
+			wantFailed: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getCalls := 0
+			var gotAdd, gotRemove map[string][]string
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED"}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED"}, nil
+				},
+				GetTransactionTagsFunc: func(ctx context.Context, transactionID string) ([]string, error) {
+					t.Errorf("GetTransactionTags(%s) called, want one batch lookup", transactionID)
+					return nil, nil
+				},
+				SetTransactionTagsFunc: func(ctx context.Context, transactionID string, tagIDs []string) error {
+					t.Errorf("SetTransactionTags(%s) called, want one batch update", transactionID)
+					return nil
+				},
+				GetTagsForTransactionsFunc: func(ctx context.Context, transactionIDs []string) (map[string][]string, error) {
+					getCalls++
+					return map[string][]string{"tx-1": {"tag-a", "tag-b"}, "tx-2": {"tag-x"}}, nil
+				},
+				UpdateTagsFunc: func(ctx context.Context, add, remove map[string][]string) error {
+					gotAdd, gotRemove = add, remove
+					for _, tags := range add {
+						if tt.badTag != "" && slices.Contains(tags, tt.badTag) {
+							return errors.New("invalid input syntax for type uuid")
+						}
+					}
+					return tt.updateErr
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					return &account.Account{ID: "acc-1", UserID: 1}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			body, _ := json.Marshal(BatchPatchRequest{Transactions: tt.items})
+			req, _ := http.NewRequest(http.MethodPatch, "/api/transactions/update", bytes.NewBuffer(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleBatchTransactions(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.wantStatus, rr.Body.String())
+			}
+			if getCalls != 1 {
+				t.Errorf("GetTagsForTransactions called %d times, want 1", getCalls)
+			}
+			if tt.wantAdd != nil && !reflect.DeepEqual(gotAdd, tt.wantAdd) {
+				t.Errorf("UpdateTags add = %v, want %v", gotAdd, tt.wantAdd)
+			}
+			if tt.wantRemove != nil && !reflect.DeepEqual(gotRemove, tt.wantRemove) {
+				t.Errorf("UpdateTags remove = %v, want %v", gotRemove, tt.wantRemove)
+			}
+
+			var resp BatchResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var failed []int
+			for _, result := range resp.Results {
+				if !result.Success {
+					failed = append(failed, result.Index)
+					continue
+				}
+				want := tt.wantTags[result.Transaction.ID]
+				if !slices.Equal(result.Transaction.Tags, want) {
+					t.Errorf("%s tags = %v, want %v", result.Transaction.ID, result.Transaction.Tags, want)
+				}
+			}
+			if !slices.Equal(failed, tt.wantFailed) {
+				t.Errorf("failed indexes = %v, want %v", failed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestHandleTransactionCousin(t *testing.T) {
+	int64Ptr := func(v int64) *int64 { return &v }
+	category := "food"
+	ownerID := int64(1)
+	otherID := int64(2)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		wantCousin     *int64
+		wantCleared    bool
+		wantCategory   *string
+	}{
+		{name: "assign own cousin", body: `{"cousinId": 7}`, expectedStatus: http.StatusOK, wantCousin: int64Ptr(7)},
+		{name: "assign and apply rules", body: `{"cousinId": 7, "applyRules": true}`, expectedStatus: http.StatusOK, wantCousin: int64Ptr(7), wantCategory: &category},
+		{name: "cousin of another user", body: `{"cousinId": 8}`, expectedStatus: http.StatusForbidden},
+		{name: "unknown cousin", body: `{"cousinId": 9}`, expectedStatus: http.StatusNotFound},
+		{name: "null clears cousin", body: `{"cousinId": null}`, expectedStatus: http.StatusOK, wantCleared: true},
+		{name: "missing cousinId", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "non-integer cousinId", body: `{"cousinId": "7"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var current *int64
+			var setCousin *int64
+			cleared := false
+			skippedListener := false
+			var gotCategory *string
+			gotManipulated := false
+
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", Cousin: current}, nil
+				},
+				SetCousinFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+					setCousin = &cousinID
+					current = &cousinID
+					return nil
+				},
+				SetCousinWithoutRulesFunc: func(ctx context.Context, transactionID string, cousinID int64) error {
+					setCousin = &cousinID
+					current = &cousinID
+					skippedListener = true
+					return nil
+				},
+				ClearCousinFunc: func(ctx context.Context, transactionID string) error {
+					cleared = true
+					current = nil
+					return nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					gotCategory = params.Category
+					gotManipulated = params.Manipulated
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", Cousin: current, Category: params.Category}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					return &account.Account{ID: "acc-1", UserID: ownerID}, nil
+				},
+			}
+			cousinRuleRepo := &MockCousinRuleRepo{
+				GetByCousinAndTypeFunc: func(ctx context.Context, userID, cousinID int64, txType *string) (*cousinrule.CousinRule, error) {
+					if txType != nil {
+						return nil, nil
+					}
+					return &cousinrule.CousinRule{ID: 3, UserID: userID, CousinID: cousinID, Category: &category}, nil
+				},
+			}
+			cousinRepo := &MockCousinRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*cousin.Cousin, error) {
+					switch id {
+					case 7:
+						return &cousin.Cousin{ID: 7, UserID: &ownerID, Name: "Padaria"}, nil
+					case 8:
+						return &cousin.Cousin{ID: 8, UserID: &otherID, Name: "Mercado"}, nil
+					}
+					return nil, nil
+				},
+			}
+
+			handler := NewTransactionHandler(txRepo, accRepo, cousinRuleRepo)
+			handler.SetCousinService(cousin.NewService(cousinRepo, txRepo, accRepo))
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("PATCH /api/transactions/{id}/cousin", handler.HandleTransactionCousin)
+
+			req, _ := http.NewRequest(http.MethodPatch, "/api/transactions/tx-1/cousin", strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, ownerID))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if (setCousin == nil) != (tt.wantCousin == nil) || (setCousin != nil && *setCousin != *tt.wantCousin) {
+				t.Errorf("SetCousin called with %v, want %v", setCousin, tt.wantCousin)
+			}
+			if cleared != tt.wantCleared {
+				t.Errorf("ClearCousin called = %v, want %v", cleared, tt.wantCleared)
+			}
+			if (gotCategory == nil) != (tt.wantCategory == nil) {
+				t.Errorf("rule category applied = %v, want %v", gotCategory, tt.wantCategory)
+			}
+			// A rule applied by the handler must not be applied again by the listener
+			if applied := tt.wantCategory != nil; skippedListener != applied || gotManipulated != applied {
+				t.Errorf("listener skipped = %v, manipulated = %v, want both %v", skippedListener, gotManipulated, applied)
+			}
+
+			if rr.Code == http.StatusOK {
+				var resp TransactionAPIResponse
+				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if (resp.Cousin == nil) != (tt.wantCousin == nil) {
+					t.Errorf("response cousin = %v, want %v", resp.Cousin, tt.wantCousin)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleMarkTransfer(t *testing.T) {
+	tests := []struct {
+		name           string
+		transactionID  string
+		body           string
+		expectedStatus int
+	}{
+		{name: "matching counterpart", transactionID: "tx-debit", body: `{"counterpartId": "tx-credit"}`, expectedStatus: http.StatusOK},
+		{name: "missing counterpart", transactionID: "tx-debit", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "same type", transactionID: "tx-debit", body: `{"counterpartId": "tx-debit-2"}`, expectedStatus: http.StatusBadRequest},
+		{name: "different amount", transactionID: "tx-debit", body: `{"counterpartId": "tx-credit-other"}`, expectedStatus: http.StatusBadRequest},
+		{name: "counterpart of another user", transactionID: "tx-debit", body: `{"counterpartId": "tx-other"}`, expectedStatus: http.StatusForbidden},
+		{name: "unknown counterpart", transactionID: "tx-debit", body: `{"counterpartId": "tx-missing"}`, expectedStatus: http.StatusNotFound},
+		{name: "removed counterpart", transactionID: "tx-debit", body: `{"counterpartId": "tx-credit-removed"}`, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfers := map[string]string{}
+			removedAt := time.Now()
+			var markedIDs []string
+
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					var txn *transaction.Transaction
+					switch id {
+					case "tx-debit":
+						txn = &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Amount: -200, Considered: true}
+					case "tx-debit-2":
+						txn = &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Amount: -200, Considered: true}
+					case "tx-credit":
+						txn = &transaction.Transaction{ID: id, AccountID: "acc-3", Type: "CREDIT", Amount: 200, Considered: true}
+					case "tx-credit-other":
+						txn = &transaction.Transaction{ID: id, AccountID: "acc-3", Type: "CREDIT", Amount: 210, Considered: true}
+					case "tx-credit-removed":
+						txn = &transaction.Transaction{ID: id, AccountID: "acc-3", Type: "CREDIT", Amount: 200, Considered: true, RemovedAt: &removedAt}
+					case "tx-other":
+						txn = &transaction.Transaction{ID: id, AccountID: "acc-2", Type: "CREDIT", Amount: 200}
+					default:
+						return nil, nil
+					}
+					if group, ok := transfers[id]; ok {
+						reason := transaction.ConsideredReasonTransfer
+						txn.Considered, txn.ConsideredReason, txn.TransferGroup = false, &reason, &group
+					}
+					return txn, nil
+				},
+				MarkTransferFunc: func(ctx context.Context, ids []string, group string) (int64, error) {
+					if slices.Contains(ids, "tx-credit-removed") {
+						return 0, transaction.ErrTransactionNotFound
+					}
+					markedIDs = ids
+					for _, id := range ids {
+						transfers[id] = group
+					}
+					return int64(len(ids)), nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "acc-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1, Currency: "BRL"}, nil
+				},
+			}
+
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/transactions/{id}/transfer", handler.HandleMarkTransfer)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/transactions/"+tt.transactionID+"/transfer", strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				if markedIDs != nil {
+					t.Error("MarkTransfer called on a rejected request")
+				}
+				return
+			}
+
+			var resp MarkTransferResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.TransferGroup == "" || transfers["tx-debit"] != resp.TransferGroup || transfers["tx-credit"] != resp.TransferGroup {
+				t.Errorf("transfer group = %q, repo saw %v", resp.TransferGroup, transfers)
+			}
+			if len(resp.Transactions) != 2 || resp.Transactions[0].ID != "tx-debit" || resp.Transactions[1].ID != "tx-credit" {
+				t.Fatalf("transactions = %+v, want tx-debit then tx-credit", resp.Transactions)
+			}
+			for _, txn := range resp.Transactions {
+				if txn.Considered || txn.ConsideredReason == nil || *txn.ConsideredReason != transaction.ConsideredReasonTransfer {
+					t.Errorf("transaction %s considered = %v, reason = %v, want false and TRANSFER", txn.ID, txn.Considered, txn.ConsideredReason)
+				}
+				if txn.TransferGroup == nil || *txn.TransferGroup != resp.TransferGroup {
+					t.Errorf("transaction %s transfer group = %v, want %q", txn.ID, txn.TransferGroup, resp.TransferGroup)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleTransactionConsidered(t *testing.T) {
+	dupReason := transaction.ConsideredReasonDuplicate
+	autoNotes := "split with Ana " + transaction.DuplicateNote
+	userNotes := "split with Ana"
+
+	tests := []struct {
+		name           string
+		transactionID  string
+		body           string
+		expectedStatus int
+		wantNotes      *string
+	}{
+		{name: "override auto exclusion", transactionID: "tx-1", body: `{"considered": true}`, expectedStatus: http.StatusOK, wantNotes: &userNotes},
+		{name: "exclude without detection notes", transactionID: "tx-2", body: `{"considered": false}`, expectedStatus: http.StatusOK},
+		{name: "missing considered", transactionID: "tx-1", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "transaction of another user", transactionID: "tx-other", body: `{"considered": true}`, expectedStatus: http.StatusForbidden},
+		{name: "unknown transaction", transactionID: "tx-missing", body: `{"considered": true}`, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotParams *transaction.UpdateTransactionParams
+
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					switch id {
+					case "tx-1":
+						return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "CREDIT", Notes: &autoNotes, ConsideredReason: &dupReason}, nil
+					case "tx-2":
+						return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Considered: true}, nil
+					case "tx-other":
+						return &transaction.Transaction{ID: id, AccountID: "acc-2", Type: "DEBIT"}, nil
+					}
+					return nil, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					gotParams = &params
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Considered: *params.Considered, Notes: params.Notes, ConsideredReason: params.ConsideredReason}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "acc-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1}, nil
+				},
+			}
+
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/transactions/{id}/considered", handler.HandleTransactionConsidered)
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/transactions/"+tt.transactionID+"/considered", strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				if gotParams != nil {
+					t.Error("Update called on a rejected request")
+				}
+				return
+			}
+
+			if gotParams == nil {
+				t.Fatal("Update was not called")
+			}
+			if gotParams.ConsideredReason == nil || *gotParams.ConsideredReason != transaction.ConsideredReasonUser {
+				t.Errorf("reason = %v, want USER", gotParams.ConsideredReason)
+			}
+			if (gotParams.Notes == nil) != (tt.wantNotes == nil) || (gotParams.Notes != nil && *gotParams.Notes != *tt.wantNotes) {
+				t.Errorf("notes = %v, want %v", gotParams.Notes, tt.wantNotes)
+			}
+
+			var resp TransactionAPIResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.ConsideredReason == nil || *resp.ConsideredReason != transaction.ConsideredReasonUser {
+				t.Errorf("response reason = %v, want USER", resp.ConsideredReason)
+			}
+		})
+	}
+}
+
+func TestHandleDescriptionSuggestions(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantPrefix     string
+	}{
+		{name: "prefix", query: "?q=%20pada", expectedStatus: http.StatusOK, wantPrefix: "pada"},
+		{name: "no query", query: "", expectedStatus: http.StatusOK, wantPrefix: ""},
+		{name: "query too long", query: "?q=" + strings.Repeat("a", transaction.MaxDescriptionQueryLength+1), expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPrefix *string
+			var gotLimit int
+
+			txRepo := &MockTransactionRepo{
+				ListDescriptionSuggestionsFunc: func(ctx context.Context, userID int64, prefix string, limit int) ([]transaction.DescriptionSuggestion, error) {
+					if userID != 1 {
+						t.Errorf("userID = %d, want 1", userID)
+					}
+					gotPrefix, gotLimit = &prefix, limit
+					return []transaction.DescriptionSuggestion{{Description: "Padaria Real", Count: 12}, {Description: "Padaria Sol", Count: 3}}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/transactions/merchants"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleDescriptionSuggestions(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				if gotPrefix != nil {
+					t.Error("repository queried for a rejected request")
+				}
+				return
+			}
+
+			if gotPrefix == nil || *gotPrefix != tt.wantPrefix {
+				t.Errorf("prefix = %v, want %q", gotPrefix, tt.wantPrefix)
+			}
+			if gotLimit != transaction.MaxDescriptionSuggestions {
+				t.Errorf("limit = %d, want %d", gotLimit, transaction.MaxDescriptionSuggestions)
+			}
+
+			var resp DescriptionSuggestionsResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Results) != 2 || resp.Results[0].Description != "Padaria Real" || resp.Results[0].Count != 12 {
+				t.Errorf("results = %+v, want the repository's suggestions in order", resp.Results)
+			}
+		})
+	}
+}
+
+func TestHandleTransactionCounts(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantStart      time.Time
+		wantEnd        time.Time
+	}{
+		{name: "no range", query: "", expectedStatus: http.StatusOK},
+		{
+			name:           "date range",
+			query:          "?from=2024-03-01&to=2024-03-31",
+			expectedStatus: http.StatusOK,
+			wantStart:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:        time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond),
+		},
+		{name: "invalid from", query: "?from=03/01/2024", expectedStatus: http.StatusBadRequest},
+		{name: "to before from", query: "?from=2024-03-10&to=2024-03-01", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotWindow *transaction.DateWindow
+			txRepo := &MockTransactionRepo{
+				CountGroupsFunc: func(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+					gotWindow = &window
+					return []transaction.CountGroup{
+						{Type: "DEBIT", Status: "POSTED", Considered: true, Count: 4},
+						{Type: "CREDIT", Status: "PENDING", Considered: false, Count: 1},
+					}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/transactions/counts"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleTransactionCounts(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			if !gotWindow.Start.Equal(tt.wantStart) || !gotWindow.End.Equal(tt.wantEnd) {
+				t.Errorf("window = %v - %v, want %v - %v", gotWindow.Start, gotWindow.End, tt.wantStart, tt.wantEnd)
+			}
+
+			var resp TransactionCountsResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Total != 5 || resp.ByType["DEBIT"] != 4 || resp.ByStatus["PENDING"] != 1 || resp.Considered != 4 || resp.NotConsidered != 1 {
+				t.Errorf("response = %+v, want the summarized groups", resp)
+			}
+
+			// Revalidating with the returned ETag answers 304 without a body
+			etag := rr.Header().Get("ETag")
+			if etag == "" {
+				t.Fatal("missing ETag")
+			}
+			req = httptest.NewRequest(http.MethodGet, "/api/transactions/counts"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			req.Header.Set("If-None-Match", etag)
+			rr = httptest.NewRecorder()
+			handler.HandleTransactionCounts(rr, req)
+			if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+				t.Errorf("revalidation = %d with %d bytes, want 304 without a body", rr.Code, rr.Body.Len())
+			}
+		})
+	}
+}
+
+func TestHandleTransactionCounts_InsightsStartDate(t *testing.T) {
+	insightsStart := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantStart time.Time
+	}{
+		{name: "no range", query: "", wantStart: insightsStart},
+		{name: "from before start date", query: "?from=2024-03-01", wantStart: insightsStart},
+		{name: "from after start date", query: "?from=2024-03-20", wantStart: time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotWindow transaction.DateWindow
+			txRepo := &MockTransactionRepo{
+				CountGroupsFunc: func(ctx context.Context, userID int64, window transaction.DateWindow) ([]transaction.CountGroup, error) {
+					gotWindow = window
+					return nil, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+			handler.SetUserRepository(&MockUserRepo{
+				GetByIDFunc: func(ctx context.Context, id int64) (*user.User, error) {
+					return &user.User{ID: id, InsightsStartDate: &insightsStart}, nil
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/transactions/counts"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleTransactionCounts(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			if !gotWindow.Start.Equal(tt.wantStart) {
+				t.Errorf("window start = %v, want %v", gotWindow.Start, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestHandleTransactionTrend(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantMonths     int
+	}{
+		{name: "default months", query: "?category=Mercado", expectedStatus: http.StatusOK, wantMonths: 12},
+		{name: "custom months", query: "?category=Mercado&months=3", expectedStatus: http.StatusOK, wantMonths: 3},
+		{name: "missing category", query: "?months=3", expectedStatus: http.StatusBadRequest},
+		{name: "months out of range", query: "?category=Mercado&months=61", expectedStatus: http.StatusBadRequest},
+		{name: "months not a number", query: "?category=Mercado&months=x", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currentMonth := transaction.TrendStart(time.Now(), 1)
+			txRepo := &MockTransactionRepo{
+				MonthlyTrendByCategoryFunc: func(ctx context.Context, userID int64, category string, since time.Time) ([]transaction.MonthlyTotal, error) {
+					if userID != 1 || category != "Mercado" {
+						t.Errorf("MonthlyTrendByCategory(%d, %q), want (1, Mercado)", userID, category)
+					}
+					if want := transaction.TrendStart(time.Now(), tt.wantMonths); !since.Equal(want) {
+						t.Errorf("since = %v, want %v", since, want)
+					}
+					return []transaction.MonthlyTotal{{Month: currentMonth, Total: 250.75}}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/transactions/trend"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			handler.HandleTransactionTrend(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp TransactionTrendResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Results) != tt.wantMonths {
+				t.Fatalf("got %d months, want %d", len(resp.Results), tt.wantMonths)
+			}
+			for i, item := range resp.Results[:tt.wantMonths-1] {
+				if item.Total != 0 {
+					t.Errorf("month %d (%s) total = %v, want 0", i, item.Month, item.Total)
+				}
+			}
+			last := resp.Results[tt.wantMonths-1]
+			if last.Month != currentMonth.Format("2006-01") || last.Total != 250.75 {
+				t.Errorf("last month = %+v, want %s with 250.75", last, currentMonth.Format("2006-01"))
+			}
+		})
+	}
+}
+
+func TestHandleTransactionDuplicates(t *testing.T) {
+	date := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		transactionID  string
+		expectedStatus int
+		wantIDs        []string
+	}{
+		{name: "returns candidates", transactionID: "tx-1", expectedStatus: http.StatusOK, wantIDs: []string{"tx-2"}},
+		{name: "transaction of another user", transactionID: "tx-other", expectedStatus: http.StatusForbidden},
+		{name: "unknown transaction", transactionID: "tx-missing", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCriteria *transaction.DuplicateCriteria
+			updated := false
+
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					switch id {
+					case "tx-1":
+						return &transaction.Transaction{ID: id, AccountID: "acc-1", Amount: -150, Type: "DEBIT", TransactionDate: date}, nil
+					case "tx-other":
+						return &transaction.Transaction{ID: id, AccountID: "acc-2", Amount: -150, Type: "DEBIT", TransactionDate: date}, nil
+					}
+					return nil, nil
+				},
+				FindPotentialDuplicatesFunc: func(ctx context.Context, criteria transaction.DuplicateCriteria) ([]*transaction.Transaction, error) {
+					gotCriteria = &criteria
+					return []*transaction.Transaction{{ID: "tx-2", AccountID: "acc-1", Amount: 150, Type: "CREDIT", TransactionDate: date}}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					updated = true
+					return nil, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "acc-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1}, nil
+				},
+			}
+
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/transactions/{id}/duplicates", handler.HandleTransactionDuplicates)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/transactions/"+tt.transactionID+"/duplicates", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if updated {
+				t.Error("handler marked transactions, want read-only")
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			if gotCriteria == nil {
+				t.Fatal("FindPotentialDuplicates was not called")
+			}
+			if gotCriteria.ExcludeID != "tx-1" || gotCriteria.OppositeType != "CREDIT" || gotCriteria.AbsoluteAmount != 150 || gotCriteria.UserID != 1 {
+				t.Errorf("criteria = %+v, want opposite type CREDIT, amount 150 excluding tx-1 for user 1", *gotCriteria)
+			}
+
+			var resp []TransactionAPIResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var ids []string
+			for _, r := range resp {
+				ids = append(ids, r.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("duplicate IDs = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+// MockAuditRepo implements audit.Repository, returning entries newest first
+type MockAuditRepo struct {
+	entries []*audit.Entry
+}
+
+func (m *MockAuditRepo) Create(ctx context.Context, entry *audit.Entry) error { return nil }
+
+func (m *MockAuditRepo) CreateBatch(ctx context.Context, entries []*audit.Entry) error { return nil }
+
+func (m *MockAuditRepo) ListByEntity(ctx context.Context, entityType, entityID string, limit int) ([]*audit.Entry, error) {
+	var entries []*audit.Entry
+	for _, e := range m.entries {
+		if e.EntityType == entityType && e.EntityID == entityID && len(entries) < limit {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func TestHandleTransactionHistory(t *testing.T) {
+	created := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	auditRepo := &MockAuditRepo{entries: []*audit.Entry{
+		{ID: 3, EntityType: audit.EntityTransaction, EntityID: "tx-1", Action: audit.ActionUpdate, Source: audit.SourceDetection, CreatedAt: created.Add(2 * time.Hour),
+			Changes: map[string]audit.Change{"considered": {Old: true, New: false}}},
+		{ID: 2, EntityType: audit.EntityTransaction, EntityID: "tx-1", Action: audit.ActionUpdate, Source: audit.SourceRule, CreatedAt: created.Add(time.Hour),
+			Changes: map[string]audit.Change{"category": {Old: "food", New: "groceries"}}},
+		{ID: 1, EntityType: audit.EntityTransaction, EntityID: "tx-1", Action: audit.ActionCreate, Source: audit.SourceUser, CreatedAt: created},
+		{ID: 4, EntityType: audit.EntityTransaction, EntityID: "tx-other", Action: audit.ActionCreate, CreatedAt: created},
+	}}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		wantIDs        []int64
+		wantSources    []string
+	}{
+		{name: "oldest first", path: "/api/transactions/tx-1/history", expectedStatus: http.StatusOK, wantIDs: []int64{1, 2, 3},
+			wantSources: []string{audit.SourceUser, audit.SourceRule, audit.SourceDetection}},
+		{name: "limit keeps the latest entries", path: "/api/transactions/tx-1/history?limit=2", expectedStatus: http.StatusOK, wantIDs: []int64{2, 3}},
+		{name: "invalid limit", path: "/api/transactions/tx-1/history?limit=0", expectedStatus: http.StatusBadRequest},
+		{name: "transaction of another user", path: "/api/transactions/tx-other/history", expectedStatus: http.StatusForbidden},
+		{name: "unknown transaction", path: "/api/transactions/tx-missing/history", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					switch id {
+					case "tx-1":
+						return &transaction.Transaction{ID: id, AccountID: "acc-1"}, nil
+					case "tx-other":
+						return &transaction.Transaction{ID: id, AccountID: "acc-2"}, nil
+					}
+					return nil, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "acc-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1}, nil
+				},
+			}
+
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+			handler.SetAuditService(audit.NewService(auditRepo))
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/transactions/{id}/history", handler.HandleTransactionHistory)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var resp []audit.Entry
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var ids []int64
+			var sources []string
+			for _, e := range resp {
+				ids = append(ids, e.ID)
+				sources = append(sources, e.Source)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("entry IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if tt.wantSources != nil && !slices.Equal(sources, tt.wantSources) {
+				t.Errorf("entry sources = %v, want %v", sources, tt.wantSources)
+			}
+		})
+	}
+}
+
+// MockBillRepo implements bill.Repository; only GetByID is exercised here
+type MockBillRepo struct {
+	bill.Repository
+	GetByIDFunc func(ctx context.Context, id string) (*bill.Bill, error)
+}
+
+func (m *MockBillRepo) GetByID(ctx context.Context, id string) (*bill.Bill, error) {
+	return m.GetByIDFunc(ctx, id)
+}
+
+func TestHandleBillMatches(t *testing.T) {
+	due := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	billPayment := transaction.ConsideredReasonBillPayment
+
+	tests := []struct {
+		name           string
+		billID         string
+		expectedStatus int
+		wantIDs        []string
+		wantLinked     []string
+	}{
+		{name: "returns same-account matches", billID: "bill-1", expectedStatus: http.StatusOK, wantIDs: []string{"tx-1", "tx-2"}, wantLinked: []string{"tx-2"}},
+		{name: "bill of another user", billID: "bill-other", expectedStatus: http.StatusForbidden},
+		{name: "unknown bill", billID: "bill-missing", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCriteria *transaction.DuplicateCriteria
+			updated := false
+
+			billRepo := &MockBillRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*bill.Bill, error) {
+					switch id {
+					case "bill-1":
+						return &bill.Bill{ID: id, AccountID: "card-1", DueDate: due, TotalAmount: 1234.5}, nil
+					case "bill-other":
+						return &bill.Bill{ID: id, AccountID: "card-2", DueDate: due, TotalAmount: 99}, nil
+					}
+					return nil, bill.ErrBillNotFound
+				},
+			}
+			txRepo := &MockTransactionRepo{
+				FindPotentialDuplicatesForBillFunc: func(ctx context.Context, criteria transaction.DuplicateCriteria) ([]*transaction.Transaction, error) {
+					gotCriteria = &criteria
+					return []*transaction.Transaction{
+						{ID: "tx-1", AccountID: "card-1", Amount: 1234.5, Type: "CREDIT", TransactionDate: due},
+						{ID: "tx-2", AccountID: "card-1", Amount: 1234.5, Type: "CREDIT", TransactionDate: due.Add(48 * time.Hour), ConsideredReason: &billPayment},
+						{ID: "tx-3", AccountID: "checking-1", Amount: -1234.5, Type: "DEBIT", TransactionDate: due},
+					}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					updated = true
+					return nil, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "card-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1}, nil
+				},
+			}
+
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+			handler.SetBillRepository(billRepo)
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/bills/{id}/matches", handler.HandleBillMatches)
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/bills/"+tt.billID+"/matches", nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v (%s)", rr.Code, tt.expectedStatus, rr.Body.String())
+			}
+			if updated {
+				t.Error("handler marked transactions, want read-only")
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			if gotCriteria == nil {
+				t.Fatal("FindPotentialDuplicatesForBill was not called")
+			}
+			if gotCriteria.AbsoluteAmount != 1234.5 || gotCriteria.UserID != 1 ||
+				!gotCriteria.DateLowerBound.Equal(due.Add(-transaction.BillDuplicateTimeDelta)) ||
+				!gotCriteria.DateUpperBound.Equal(due.Add(transaction.BillDuplicateTimeDelta)) {
+				t.Errorf("criteria = %+v, want amount 1234.5 within 120h of the due date for user 1", *gotCriteria)
+			}
+
+			var resp BillMatchesResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var ids []string
+			for _, r := range resp.Matches {
+				ids = append(ids, r.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("match IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if !slices.Equal(resp.LinkedTransactionIDs, tt.wantLinked) {
+				t.Errorf("linked IDs = %v, want %v", resp.LinkedTransactionIDs, tt.wantLinked)
+			}
+		})
+	}
+}
+
+func TestHandleListTransactions_Filters(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantFiltered   bool
+	}{
+		{name: "no filters uses unfiltered list", query: "", expectedStatus: http.StatusOK, wantFiltered: false},
+		{name: "considered and reason", query: "?considered=false&reason=duplicate", expectedStatus: http.StatusOK, wantFiltered: true},
+		{name: "considered only", query: "?considered=true", expectedStatus: http.StatusOK, wantFiltered: true},
+		{name: "invalid considered", query: "?considered=maybe", expectedStatus: http.StatusBadRequest},
+		{name: "invalid reason", query: "?reason=UNKNOWN", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filteredCalls := 0
+			var gotFilter transaction.ListFilter
+			txRepo := &MockTransactionRepo{
+				CountByUserIDFilteredFunc: func(ctx context.Context, userID int64, filter transaction.ListFilter) (int64, error) {
+					filteredCalls++
+					gotFilter = filter
+					return 250, nil
+				},
+				ListByUserIDFilteredFunc: func(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+					filteredCalls++
+					return []*transaction.Transaction{}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/transactions/"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleListTransactions(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if tt.wantFiltered != (filteredCalls == 2) {
+				t.Errorf("filtered repository calls = %d, want filtered=%v", filteredCalls, tt.wantFiltered)
+			}
+			if !tt.wantFiltered {
+				return
+			}
+
+			var resp TransactionListResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Next == nil {
+				t.Fatal("expected next page link")
+			}
+			next, _ := url.Parse(*resp.Next)
+			if next.Query().Get("page") != "2" {
+				t.Errorf("next page = %q, want 2", next.Query().Get("page"))
+			}
+			if gotFilter.Considered != nil && next.Query().Get("considered") == "" {
+				t.Error("next link dropped the considered filter")
+			}
+			if gotFilter.ConsideredReason != nil && next.Query().Get("reason") == "" {
+				t.Error("next link dropped the reason filter")
+			}
+		})
+	}
+}
+
+func TestHandleListTransactions_UpdatedSince(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		wantSince      time.Time
+	}{
+		{name: "valid timestamp", query: "?updatedSince=2024-05-01T12:00:00Z", expectedStatus: http.StatusOK, wantSince: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "timestamp with offset", query: "?updatedSince=2024-05-01T09:00:00-03:00", expectedStatus: http.StatusOK, wantSince: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "date only", query: "?updatedSince=2024-05-01", expectedStatus: http.StatusBadRequest},
+		{name: "combined with filters", query: "?updatedSince=2024-05-01T12:00:00Z&considered=false", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSince time.Time
+			txRepo := &MockTransactionRepo{
+				CountByUserIDUpdatedSinceFunc: func(ctx context.Context, userID int64, since time.Time) (int64, error) {
+					return 1, nil
+				},
+				ListByUserIDUpdatedSinceFunc: func(ctx context.Context, userID int64, since time.Time, limit, offset int) ([]*transaction.Transaction, error) {
+					gotSince = since
+					removedAt := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+					return []*transaction.Transaction{{ID: "tx-1", AccountID: "acc-1", Type: "DEBIT", Status: "POSTED", RemovedAt: &removedAt}}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req, _ := http.NewRequest(http.MethodGet, "/api/transactions/"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleListTransactions(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if !gotSince.Equal(tt.wantSince) {
+				t.Errorf("repository since = %v, want %v", gotSince, tt.wantSince)
+			}
+
+			var resp TransactionListResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Count != 1 || len(resp.Results) != 1 {
+				t.Fatalf("got count %d with %d results, want 1", resp.Count, len(resp.Results))
+			}
+			if got := resp.Results[0].RemovedAt; got == nil || *got != "2024-05-02T08:00:00Z" {
+				t.Errorf("removedAt = %v, want the tombstone timestamp", got)
+			}
+		})
+	}
+}
+
+func TestHandleReconsider(t *testing.T) {
+	dup := transaction.ConsideredReasonDuplicate
+	user := transaction.ConsideredReasonUser
+	dupNotes := "Paid twice " + transaction.DuplicateNote
+	txns := map[string]*transaction.Transaction{
+		"tx-dup":        {ID: "tx-dup", AccountID: "acc-1", Considered: false, ConsideredReason: &dup, Notes: &dupNotes},
+		"tx-user":       {ID: "tx-user", AccountID: "acc-1", Considered: false, ConsideredReason: &user},
+		"tx-considered": {ID: "tx-considered", AccountID: "acc-1", Considered: true},
+		"tx-other":      {ID: "tx-other", AccountID: "acc-2", Considered: false, ConsideredReason: &dup},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		wantUpdated    []string
+		wantSuccess    map[string]bool
+	}{
+		{
+			name:           "ids skip user exclusions by default",
+			body:           `{"ids": ["tx-dup", "tx-user", "tx-considered", "tx-other", "tx-missing"]}`,
+			expectedStatus: 207,
+			wantUpdated:    []string{"tx-dup"},
+			wantSuccess:    map[string]bool{"tx-dup": true, "tx-user": false, "tx-considered": false, "tx-other": false, "tx-missing": false},
+		},
+		{
+			name:           "includeUserExcluded reconsiders user exclusions",
+			body:           `{"ids": ["tx-dup", "tx-user"], "includeUserExcluded": true}`,
+			expectedStatus: http.StatusOK,
+			wantUpdated:    []string{"tx-dup", "tx-user"},
+			wantSuccess:    map[string]bool{"tx-dup": true, "tx-user": true},
+		},
+		{
+			name:           "by reason",
+			body:           `{"reason": "duplicate"}`,
+			expectedStatus: http.StatusOK,
+			wantUpdated:    []string{"tx-dup"},
+			wantSuccess:    map[string]bool{"tx-dup": true},
+		},
+		{name: "ids and reason together", body: `{"ids": ["tx-dup"], "reason": "DUPLICATE"}`, expectedStatus: http.StatusBadRequest},
+		{name: "neither ids nor reason", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid reason", body: `{"reason": "NOPE"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated []string
+			var gotNotes map[string]string
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					return txns[id], nil
+				},
+				ListByUserIDFilteredFunc: func(ctx context.Context, userID int64, filter transaction.ListFilter, limit, offset int) ([]*transaction.Transaction, error) {
+					if offset > 0 {
+						return nil, nil
+					}
+					return []*transaction.Transaction{txns["tx-dup"]}, nil
+				},
+				ReconsiderFunc: func(ctx context.Context, ids []string, notes map[string]string) ([]string, error) {
+					updated = ids
+					gotNotes = notes
+					return ids, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "acc-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/transactions/reconsider", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleReconsider(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.wantSuccess == nil {
+				return
+			}
+			if len(updated) != len(tt.wantUpdated) {
+				t.Errorf("Reconsider called with %v, want %v", updated, tt.wantUpdated)
+			}
+			if got := gotNotes["tx-dup"]; got != "Paid twice" || len(gotNotes) != 1 {
+				t.Errorf("Reconsider notes = %v, want the detection note stripped from tx-dup only", gotNotes)
+			}
+
+			var resp ReconsiderResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			for _, result := range resp.Results {
+				if want, ok := tt.wantSuccess[result.ID]; !ok || want != result.Success {
+					t.Errorf("result for %s: success=%v (%s), want %v", result.ID, result.Success, result.Error, want)
+				}
+			}
+			if resp.TotalCount != len(tt.wantSuccess) {
+				t.Errorf("totalCount = %d, want %d", resp.TotalCount, len(tt.wantSuccess))
+			}
+		})
+	}
+}
+
+func TestHandleRecategorize(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		wantFrom       string
+		wantTo         string
+		wantWindow     transaction.DateWindow
+		wantIDs        bool
+	}{
+		{
+			name:           "normalizes category names",
+			body:           `{"fromCategory": "salario", "toCategory": "PRO-LABORE"}`,
+			expectedStatus: http.StatusOK,
+			wantFrom:       "Salário",
+			wantTo:         "Pro-labore",
+		},
+		{
+			name:           "date range and ids",
+			body:           `{"fromCategory": "Salário", "toCategory": "Pro-labore", "dateRange": {"from": "2024-01-01", "to": "2024-01-31"}, "includeIds": true}`,
+			expectedStatus: http.StatusOK,
+			wantFrom:       "Salário",
+			wantTo:         "Pro-labore",
+			wantWindow: transaction.DateWindow{
+				Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				End:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond),
+			},
+			wantIDs: true,
+		},
+		{name: "unknown fromCategory", body: `{"fromCategory": "Nope", "toCategory": "Salário"}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing toCategory", body: `{"fromCategory": "Salário"}`, expectedStatus: http.StatusBadRequest},
+		{name: "same category", body: `{"fromCategory": "Salário", "toCategory": "SALARIO"}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid date", body: `{"fromCategory": "Salário", "toCategory": "Pro-labore", "dateRange": {"from": "01/01/2024"}}`, expectedStatus: http.StatusBadRequest},
+		{name: "to before from", body: `{"fromCategory": "Salário", "toCategory": "Pro-labore", "dateRange": {"from": "2024-02-01", "to": "2024-01-01"}}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			txRepo := &MockTransactionRepo{
+				RecategorizeFunc: func(ctx context.Context, userID int64, from, to string, window transaction.DateWindow) ([]string, error) {
+					called = true
+					if userID != 1 || from != tt.wantFrom || to != tt.wantTo {
+						t.Errorf("Recategorize(%d, %q, %q), want (1, %q, %q)", userID, from, to, tt.wantFrom, tt.wantTo)
+					}
+					if !window.Start.Equal(tt.wantWindow.Start) || !window.End.Equal(tt.wantWindow.End) {
+						t.Errorf("window = %+v, want %+v", window, tt.wantWindow)
+					}
+					return []string{"tx-1", "tx-2"}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req, _ := http.NewRequest(http.MethodPost, "/api/transactions/recategorize", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleRecategorize(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if called {
+					t.Error("Recategorize should not be called for an invalid request")
+				}
+				return
+			}
+
+			var resp RecategorizeResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.UpdatedCount != 2 {
+				t.Errorf("updatedCount = %d, want 2", resp.UpdatedCount)
+			}
+			if got := len(resp.TransactionIDs) > 0; got != tt.wantIDs {
+				t.Errorf("transactionIds = %v, want present=%v", resp.TransactionIDs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestHandleBatchTransactions_InvalidBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "malformed", body: `{"transactions": [`},
+		{name: "unknown field", body: `{"transactions": [{"id": "tx-1", "bogus": true}]}`},
+		{name: "nested too deeply", body: `{"transactions": ` + strings.Repeat("[", maxJSONDepth) + strings.Repeat("]", maxJSONDepth) + `}`},
+		{name: "oversized", body: `{"transactions": [{"id": "` + strings.Repeat("a", maxBatchRequestBodySize) + `"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTransactionHandler(&MockTransactionRepo{}, &MockAccountRepo{}, &MockCousinRuleRepo{})
+
+			req, _ := http.NewRequest(http.MethodPatch, "/api/transactions/update", bytes.NewBufferString(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleBatchTransactions(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestHandleListAccountTransactions(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		accountErr     error
+		accountOwner   int64
+		expectedStatus int
+		wantCount      int64
+	}{
+		{name: "Success", path: "/api/accounts/acc-1/transactions", accountOwner: 1, expectedStatus: http.StatusOK, wantCount: 2},
+		{name: "Other user's account", path: "/api/accounts/acc-1/transactions", accountOwner: 2, expectedStatus: http.StatusNotFound},
+		{name: "Missing account", path: "/api/accounts/acc-1/transactions", accountErr: account.ErrAccountNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Unknown resource", path: "/api/accounts/acc-1/statements", accountOwner: 1, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listedAccount string
+			txRepo := &MockTransactionRepo{
+				CountByAccountIDFunc: func(ctx context.Context, accountID string) (int64, error) {
+					return 2, nil
+				},
+				ListByAccountIDFunc: func(ctx context.Context, accountID string, limit, offset int) ([]*transaction.Transaction, error) {
+					listedAccount = accountID
+					return []*transaction.Transaction{
+						{ID: "tx-1", AccountID: accountID, Type: "DEBIT", Status: "POSTED"},
+						{ID: "tx-2", AccountID: accountID, Type: "CREDIT", Status: "POSTED"},
+					}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if tt.accountErr != nil {
+						return nil, tt.accountErr
+					}
+					return &account.Account{ID: id, UserID: tt.accountOwner}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/accounts/{id}/{resource}", handler.HandleListAccountTransactions)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				if listedAccount != "" {
+					t.Errorf("transactions were listed for account %s", listedAccount)
+				}
+				return
+			}
+
+			var resp TransactionListResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.Count != tt.wantCount || len(resp.Results) != int(tt.wantCount) {
+				t.Errorf("got count %d with %d results, want %d", resp.Count, len(resp.Results), tt.wantCount)
+			}
+			if listedAccount != "acc-1" {
+				t.Errorf("listed account = %q, want acc-1", listedAccount)
+			}
+		})
+	}
+}
+
+func TestVerifyTransactionOwnership(t *testing.T) {
+	dbErr := errors.New("connection reset")
+	tests := []struct {
+		name        string
+		getTxn      func(ctx context.Context, id string) (*transaction.Transaction, error)
+		getAccount  func(ctx context.Context, id string) (*account.Account, error)
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name: "owned",
+			getTxn: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+				return &transaction.Transaction{ID: id, AccountID: "acc-1"}, nil
+			},
+			getAccount: func(ctx context.Context, id string) (*account.Account, error) {
+				return &account.Account{ID: id, UserID: 1}, nil
+			},
+		},
+		{
+			name: "not found",
+			getTxn: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+				return nil, nil
+			},
+			wantErr:     transaction.ErrTransactionNotFound,
+			wantMessage: "Transaction tx-1 not found",
+		},
+		{
+			name: "forbidden",
+			getTxn: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+				return &transaction.Transaction{ID: id, AccountID: "acc-2"}, nil
+			},
+			getAccount: func(ctx context.Context, id string) (*account.Account, error) {
+				return &account.Account{ID: id, UserID: 2}, nil
+			},
+			wantErr:     account.ErrForbidden,
+			wantMessage: "Forbidden: transaction does not belong to user",
+		},
+		{
+			name: "lookup failure",
+			getTxn: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+				return nil, dbErr
+			},
+			wantErr:     dbErr,
+			wantMessage: "Failed to get transaction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txRepo := &MockTransactionRepo{GetByIDFunc: tt.getTxn}
+			accRepo := &MockAccountRepo{GetByIDFunc: tt.getAccount}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			txn, acc, err := handler.verifyTransactionOwnership(context.Background(), "tx-1", 1)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if txn.ID != "tx-1" || acc.ID != "acc-1" {
+					t.Errorf("got transaction %s in account %s", txn.ID, acc.ID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if txn != nil || acc != nil {
+				t.Error("expected no transaction or account on error")
+			}
+			if got := ownershipErrorMessage(err, "tx-1"); got != tt.wantMessage {
+				t.Errorf("ownershipErrorMessage() = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHandleBatchCreate(t *testing.T) {
+	valid := CreateTransactionRequest{AccountID: "acc-1", Amount: -10, Description: "Coffee", TransactionDate: "2024-03-01"}
+	invalidDate := CreateTransactionRequest{AccountID: "acc-1", Amount: 10, Description: "Coffee", TransactionDate: "01/03/2024"}
+	zeroAmount := CreateTransactionRequest{AccountID: "acc-1", Description: "Coffee", TransactionDate: "2024-03-01"}
+	otherUser := CreateTransactionRequest{AccountID: "acc-2", Amount: 10, Description: "Coffee", TransactionDate: "2024-03-01"}
+	missingAccount := CreateTransactionRequest{AccountID: "acc-404", Amount: 10, Description: "Coffee", TransactionDate: "2024-03-01"}
+
+	tests := []struct {
+		name           string
+		transactions   []CreateTransactionRequest
+		expectedStatus int
+		wantSuccess    []bool
+	}{
+		{name: "all created", transactions: []CreateTransactionRequest{valid, valid}, expectedStatus: http.StatusCreated, wantSuccess: []bool{true, true}},
+		{name: "some failed", transactions: []CreateTransactionRequest{valid, invalidDate, zeroAmount}, expectedStatus: http.StatusMultiStatus, wantSuccess: []bool{true, false, false}},
+		{name: "all failed", transactions: []CreateTransactionRequest{invalidDate}, expectedStatus: http.StatusBadRequest, wantSuccess: []bool{false}},
+		{name: "account of another user", transactions: []CreateTransactionRequest{valid, otherUser}, expectedStatus: http.StatusForbidden},
+		{name: "unknown account", transactions: []CreateTransactionRequest{missingAccount}, expectedStatus: http.StatusNotFound},
+		{name: "empty batch", transactions: []CreateTransactionRequest{}, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created atomic.Int32
+			txRepo := &MockTransactionRepo{
+				CreateFunc: func(ctx context.Context, params transaction.CreateTransactionParams) (*transaction.Transaction, error) {
+					created.Add(1)
+					return &transaction.Transaction{ID: params.ID, AccountID: params.AccountID, Amount: params.Amount, Type: params.Type, Status: params.Status}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					switch id {
+					case "acc-1":
+						return &account.Account{ID: id, UserID: 1, Currency: "BRL"}, nil
+					case "acc-2":
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return nil, account.ErrAccountNotFound
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			body, _ := json.Marshal(BatchCreateRequest{Transactions: tt.transactions})
+			req, _ := http.NewRequest(http.MethodPost, "/api/transactions/batch", bytes.NewBuffer(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleBatchTransactions(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.wantSuccess == nil {
+				if created.Load() != 0 {
+					t.Errorf("created %d transactions for a rejected batch", created.Load())
+				}
+				return
+			}
+
+			var resp BatchResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.TotalCount != len(tt.transactions) || resp.SuccessCount+resp.FailureCount != resp.TotalCount {
+				t.Errorf("counts = %d total, %d ok, %d failed", resp.TotalCount, resp.SuccessCount, resp.FailureCount)
+			}
+			for i, want := range tt.wantSuccess {
+				result := resp.Results[i]
+				if result.Index != i || result.Success != want {
+					t.Errorf("result %d = %+v, want success %t", i, result, want)
+				}
+				if want && (result.Transaction == nil || result.Transaction.Currency != "BRL") {
+					t.Errorf("result %d has transaction %+v", i, result.Transaction)
+				}
+				if !want && result.Error == "" {
+					t.Errorf("result %d failed without an error", i)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleBatchPatch_MultiStatus(t *testing.T) {
+	description := "Renamed"
+	tests := []struct {
+		name           string
+		ids            []string
+		expectedStatus int
+		wantErrors     []string
+	}{
+		{name: "all updated", ids: []string{"tx-1", "tx-2"}, expectedStatus: http.StatusOK, wantErrors: []string{"", ""}},
+		{
+			name:           "some failed",
+			ids:            []string{"tx-1", "tx-missing", "tx-other", ""},
+			expectedStatus: http.StatusMultiStatus,
+			wantErrors:     []string{"", "Transaction tx-missing not found", "Forbidden: transaction does not belong to user", "id is required"},
+		},
+		{name: "all failed", ids: []string{"tx-other"}, expectedStatus: http.StatusBadRequest, wantErrors: []string{"Forbidden: transaction does not belong to user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txRepo := &MockTransactionRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*transaction.Transaction, error) {
+					switch id {
+					case "tx-missing":
+						return nil, nil
+					case "tx-other":
+						return &transaction.Transaction{ID: id, AccountID: "acc-2", Type: "DEBIT", Status: "POSTED"}, nil
+					}
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Type: "DEBIT", Status: "POSTED"}, nil
+				},
+				UpdateFunc: func(ctx context.Context, id string, params transaction.UpdateTransactionParams) (*transaction.Transaction, error) {
+					return &transaction.Transaction{ID: id, AccountID: "acc-1", Description: *params.Description, Type: "DEBIT", Status: "POSTED"}, nil
+				},
+			}
+			accRepo := &MockAccountRepo{
+				GetByIDFunc: func(ctx context.Context, id string) (*account.Account, error) {
+					if id == "acc-2" {
+						return &account.Account{ID: id, UserID: 2}, nil
+					}
+					return &account.Account{ID: id, UserID: 1}, nil
+				},
+			}
+			handler := NewTransactionHandler(txRepo, accRepo, &MockCousinRuleRepo{})
+
+			items := make([]PatchTransactionItem, 0, len(tt.ids))
+			for _, id := range tt.ids {
+				items = append(items, PatchTransactionItem{ID: id, Description: &description})
+			}
+			body, _ := json.Marshal(BatchPatchRequest{Transactions: items})
+			req, _ := http.NewRequest(http.MethodPatch, "/api/transactions/update", bytes.NewBuffer(body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, int64(1)))
+			rr := httptest.NewRecorder()
+
+			handler.HandleBatchTransactions(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+			}
+
+			var resp BatchResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Results) != len(tt.wantErrors) {
+				t.Fatalf("got %d results, want %d", len(resp.Results), len(tt.wantErrors))
+			}
+			for i, wantErr := range tt.wantErrors {
+				result := resp.Results[i]
+				if result.Index != i || result.Error != wantErr || result.Success != (wantErr == "") {
+					t.Errorf("result %d = %+v, want error %q", i, result, wantErr)
+				}
+				if result.Success && (result.Transaction == nil || result.Transaction.Description != description) {
+					t.Errorf("result %d has transaction %+v", i, result.Transaction)
+				}
+			}
+		})
+	}
+}
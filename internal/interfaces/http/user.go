@@ -1,11 +1,9 @@
 package http
 
 import (
-	"context"
 	"encoding/json"
 	"log"
 	"net/http"
-	"time"
 
 	"parsa/internal/domain/account"
 	"parsa/internal/domain/notification"
@@ -25,6 +23,7 @@ type UserHandler struct {
 	billSyncService        *openfinance.BillSyncService
 	notificationService    *notification.Service
 	msgs                   *messages.Messages
+	syncLocker             openfinance.SyncLocker
 }
 
 func NewUserHandler(
@@ -49,6 +48,12 @@ func NewUserHandler(
 	}
 }
 
+// SetSyncLocker serializes the background sync started by a provider key update with other
+// syncs for the same user
+func (h *UserHandler) SetSyncLocker(locker openfinance.SyncLocker) {
+	h.syncLocker = locker
+}
+
 // HandleMe handles both GET and PATCH requests for the current user
 func (h *UserHandler) HandleMe(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context (set by auth middleware)
@@ -120,7 +125,7 @@ func (h *UserHandler) handleGetMe(w http.ResponseWriter, r *http.Request, userID
 
 func (h *UserHandler) handleUpdateMe(w http.ResponseWriter, r *http.Request, userID int64) {
 	var params user.UpdateUserParams
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+	if err := decodeJSON(w, r, &params, defaultDecodeOptions); err != nil {
 		log.Printf("Error decoding user update request: %v", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
@@ -129,16 +134,8 @@ func (h *UserHandler) handleUpdateMe(w http.ResponseWriter, r *http.Request, use
 	// If provider_key is being updated, verify it and fetch accounts in one call
 	if params.ProviderKey != nil && *params.ProviderKey != "" {
 		// Fetch accounts - this verifies the key AND gets the data we need
-		accountResp, statusCode, err := h.ofClient.GetAccountsWithStatus(r.Context(), *params.ProviderKey)
-		if err != nil {
-			// Check if it's a 401 (unauthorized)
-			if statusCode == http.StatusUnauthorized {
-				log.Printf("Invalid provider key for user %d: OpenFinance returned 401", userID)
-				http.Error(w, "Invalid provider key", http.StatusUnauthorized)
-				return
-			}
-			log.Printf("Error fetching accounts for user %d (status %d): %v", userID, statusCode, err)
-			http.Error(w, "Failed to verify provider key", http.StatusBadGateway)
+		accountResp, ok := h.verifyProviderKey(w, r, userID, *params.ProviderKey)
+		if !ok {
 			return
 		}
 
@@ -150,6 +147,7 @@ func (h *UserHandler) handleUpdateMe(w http.ResponseWriter, r *http.Request, use
 			http.Error(w, "Failed to update user", http.StatusInternalServerError)
 			return
 		}
+		h.markProviderKeyValid(r.Context(), userID, updatedUser)
 
 		// Return 202 Accepted immediately with the updated user
 		w.Header().Set("Content-Type", "application/json")
@@ -158,43 +156,7 @@ func (h *UserHandler) handleUpdateMe(w http.ResponseWriter, r *http.Request, use
 
 		// Use the already-fetched account data to sync in background
 		// This avoids making another API call - we parse AND use the data concurrently
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-			defer cancel()
-
-			log.Printf("Starting account sync for user %d using pre-fetched data", userID)
-			accountResult, err := h.accountSyncService.SyncUserAccountsWithData(ctx, userID, accountResp)
-			if err != nil {
-				log.Printf("Error syncing accounts for user %d: %v", userID, err)
-				return
-			}
-			log.Printf("Account sync completed for user %d: created=%d, updated=%d", userID, accountResult.Created, accountResult.Updated)
-
-			// New key insertion — always fetch full history
-			log.Printf("Starting transaction sync for user %d after new key insertion (full history)", userID)
-			txResult, err := h.transactionSyncService.SyncUserTransactions(ctx, userID, true)
-			if err != nil {
-				log.Printf("Error syncing transactions for user %d: %v", userID, err)
-				return
-			}
-			log.Printf("Transaction sync completed for user %d: created=%d, updated=%d", userID, txResult.Created, txResult.Updated)
-
-			// After transaction sync, sync bills
-			log.Printf("Starting bill sync for user %d after transaction sync", userID)
-			billResult, err := h.billSyncService.SyncUserBills(ctx, userID)
-			if err != nil {
-				log.Printf("Error syncing bills for user %d: %v", userID, err)
-				return
-			}
-			log.Printf("Bill sync completed for user %d: created=%d, updated=%d", userID, billResult.Created, billResult.Updated)
-
-			if err := h.userRepo.SetHasFinishedOpenfinanceFlow(ctx, userID, true); err != nil {
-				log.Printf("Error setting has_finished_openfinance_flow for user %d: %v", userID, err)
-				return
-			}
-
-			h.notificationService.SendSyncComplete(ctx, userID, h.msgs)
-		}()
+		go h.syncAfterKeyUpdate(userID, accountResp)
 
 		return
 	}
@@ -24,7 +24,10 @@ type MockUserRepo struct {
 	UpdateFunc                          func(ctx context.Context, userID int64, params user.UpdateUserParams) (*user.User, error)
 	ListUsersWithProviderKeyFunc        func(ctx context.Context) ([]*user.User, error)
 	ClearProviderKeyFunc                func(ctx context.Context, userID int64) error
+	MarkProviderKeyValidFunc            func(ctx context.Context, userID int64) error
+	RecordProviderKeyFailureFunc        func(ctx context.Context, userID int64) (int, error)
 	SetHasFinishedOpenfinanceFlowFunc   func(ctx context.Context, userID int64, value bool) error
+	SetInsightsStartDateFunc            func(ctx context.Context, userID int64, date *time.Time) error
 }
 
 func (m *MockUserRepo) Create(ctx context.Context, params user.CreateUserParams) (*user.User, error) {
@@ -83,6 +86,20 @@ func (m *MockUserRepo) ClearProviderKey(ctx context.Context, userID int64) error
 	return nil
 }
 
+func (m *MockUserRepo) MarkProviderKeyValid(ctx context.Context, userID int64) error {
+	if m.MarkProviderKeyValidFunc != nil {
+		return m.MarkProviderKeyValidFunc(ctx, userID)
+	}
+	return nil
+}
+
+func (m *MockUserRepo) RecordProviderKeyFailure(ctx context.Context, userID int64) (int, error) {
+	if m.RecordProviderKeyFailureFunc != nil {
+		return m.RecordProviderKeyFailureFunc(ctx, userID)
+	}
+	return 0, nil
+}
+
 func (m *MockUserRepo) SetHasFinishedOpenfinanceFlow(ctx context.Context, userID int64, value bool) error {
 	if m.SetHasFinishedOpenfinanceFlowFunc != nil {
 		return m.SetHasFinishedOpenfinanceFlowFunc(ctx, userID, value)
@@ -90,6 +107,13 @@ func (m *MockUserRepo) SetHasFinishedOpenfinanceFlow(ctx context.Context, userID
 	return nil
 }
 
+func (m *MockUserRepo) SetInsightsStartDate(ctx context.Context, userID int64, date *time.Time) error {
+	if m.SetInsightsStartDateFunc != nil {
+		return m.SetInsightsStartDateFunc(ctx, userID, date)
+	}
+	return nil
+}
+
 func newTestUserHandler(userRepo *MockUserRepo, accountRepo *MockAccountRepo) *UserHandler {
 	return NewUserHandler(userRepo, accountRepo, nil, nil, nil, nil, nil, nil)
 }
@@ -6,9 +6,27 @@ import (
 	"log"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultLeaderCheckInterval is how often a scheduler with a LeaderElector checks or
+// competes for leadership
+const DefaultLeaderCheckInterval = 15 * time.Second
+
+// DefaultStaleGrace is added to the longest gap between schedule times to get the default
+// StaleAfter, leaving a run that long to finish before the scheduler reports itself stale
+const DefaultStaleGrace = time.Hour
+
+// LeaderElector decides which of several instances runs the scheduled jobs.
+type LeaderElector interface {
+	// TryAcquire reports whether this instance is the leader, becoming it if no one else is.
+	TryAcquire(ctx context.Context) (bool, error)
+
+	// Release gives up leadership.
+	Release(ctx context.Context) error
+}
+
 // ScheduleTime represents a specific time of day when the scheduler should run.
 type ScheduleTime struct {
 	Hour   int
@@ -45,6 +63,17 @@ type Scheduler struct {
 	runOnStartup  bool
 	jobProvider   func(context.Context) ([]Job, error)
 
+	elector             LeaderElector
+	leaderCheckInterval time.Duration
+	leader              atomic.Bool
+
+	staleAfter       time.Duration
+	leaderSince      time.Time
+	lastRunCompleted time.Time
+
+	// skipped holds the users whose jobs were dropped last run, so the next run submits them first
+	skipped map[string]bool
+
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
@@ -60,6 +89,20 @@ type SchedulerConfig struct {
 	QueueSize     int
 	RunOnStartup  bool
 	JobProvider   func(context.Context) ([]Job, error)
+
+	// QueueFullPolicy decides what happens to a job that does not fit in the queue.
+	// Defaults to QueueFullDrop.
+	QueueFullPolicy QueueFullPolicy
+	Metrics         Metrics // Optional; records dropped jobs
+
+	// Elector, when set, restricts job runs to the elected leader. Without it, this
+	// instance always runs the jobs.
+	Elector             LeaderElector
+	LeaderCheckInterval time.Duration // Defaults to DefaultLeaderCheckInterval
+
+	// StaleAfter is how long the leader may go without completing a run before Stale
+	// reports true. Defaults to the longest gap between schedule times plus DefaultStaleGrace.
+	StaleAfter time.Duration
 }
 
 // NewScheduler creates a new scheduler with the given configuration.
@@ -85,28 +128,71 @@ func NewScheduler(config SchedulerConfig) (*Scheduler, error) {
 		return nil, fmt.Errorf("at least one schedule time is required")
 	}
 
+	leaderCheckInterval := config.LeaderCheckInterval
+	if leaderCheckInterval <= 0 {
+		leaderCheckInterval = DefaultLeaderCheckInterval
+	}
+
+	staleAfter := config.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = longestGap(scheduleTimes) + DefaultStaleGrace
+	}
+
 	workerPool := NewWorkerPool(config.WorkerCount, config.JobDelay, config.QueueSize)
+	workerPool.SetQueueFullPolicy(config.QueueFullPolicy)
+	workerPool.SetMetrics(config.Metrics)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	log.Printf("Scheduler initialized with %d schedule times: %v", len(scheduleTimes), config.ScheduleTimes)
-	log.Printf("Worker pool: %d workers, %v delay between jobs", config.WorkerCount, config.JobDelay)
+	log.Printf("Worker pool: %d workers, %v delay between jobs, %s when the queue is full",
+		config.WorkerCount, config.JobDelay, workerPool.fullPolicy)
 
 	return &Scheduler{
 		workerPool:    workerPool,
 		scheduleTimes: scheduleTimes,
 		runOnStartup:  config.RunOnStartup,
 		jobProvider:   config.JobProvider,
+		elector:       config.Elector,
 		ctx:           ctx,
 		cancel:        cancel,
+
+		leaderCheckInterval: leaderCheckInterval,
+		staleAfter:          staleAfter,
 	}, nil
 }
 
+// longestGap returns the longest time between consecutive schedule times, wrapping around
+// midnight. scheduleTimes must be sorted and not empty.
+func longestGap(scheduleTimes []ScheduleTime) time.Duration {
+	minutes := func(st ScheduleTime) int { return st.Hour*60 + st.Minute }
+
+	longest := 0
+	for i, st := range scheduleTimes {
+		next := minutes(scheduleTimes[0]) + 24*60
+		if i+1 < len(scheduleTimes) {
+			next = minutes(scheduleTimes[i+1])
+		}
+		longest = max(longest, next-minutes(st))
+	}
+	return time.Duration(longest) * time.Minute
+}
+
 // Start launches the scheduler and worker pool.
 func (s *Scheduler) Start() {
 	log.Println("Starting scheduler...")
 
 	s.workerPool.Start()
 
+	// Settle leadership before the startup run so only the leader fires it
+	if s.elector != nil {
+		s.checkLeadership()
+		s.wg.Add(1)
+		go s.leadershipLoop()
+	} else {
+		s.leader.Store(true)
+		s.setLeaderSince(time.Now())
+	}
+
 	if s.runOnStartup {
 		log.Println("Scheduler: Running initial job batch on startup")
 		s.wg.Add(1)
@@ -146,6 +232,91 @@ func (s *Scheduler) scheduleLoop() {
 	}
 }
 
+// leadershipLoop periodically renews or competes for leadership.
+func (s *Scheduler) leadershipLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.leaderCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkLeadership()
+		}
+	}
+}
+
+// checkLeadership asks the elector whether this instance leads and logs any change.
+func (s *Scheduler) checkLeadership() {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	isLeader, err := s.elector.TryAcquire(ctx)
+	if err != nil {
+		log.Printf("Scheduler: Leader election failed: %v", err)
+		isLeader = false
+	}
+
+	if wasLeader := s.leader.Swap(isLeader); wasLeader != isLeader {
+		if isLeader {
+			s.setLeaderSince(time.Now())
+			log.Println("Scheduler: This instance is now the leader and will run scheduled jobs")
+		} else {
+			log.Println("Scheduler: This instance is on standby (not the leader)")
+		}
+	}
+}
+
+// IsLeader reports whether this instance currently runs the scheduled jobs.
+func (s *Scheduler) IsLeader() bool {
+	return s.leader.Load()
+}
+
+// LastRunCompleted returns when every job of a run last finished on this instance, or the
+// zero time if no run has completed yet.
+func (s *Scheduler) LastRunCompleted() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastRunCompleted
+}
+
+// Stale reports whether this instance leads but has not completed a run within StaleAfter
+// of its last completed run, or of becoming the leader when none has completed yet. A
+// standby instance is never stale.
+func (s *Scheduler) Stale() bool {
+	return s.staleAt(time.Now())
+}
+
+func (s *Scheduler) staleAt(now time.Time) bool {
+	if !s.IsLeader() {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	since := s.leaderSince
+	if s.lastRunCompleted.After(since) {
+		since = s.lastRunCompleted
+	}
+	return now.Sub(since) > s.staleAfter
+}
+
+func (s *Scheduler) setLeaderSince(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaderSince = t
+}
+
+func (s *Scheduler) recordRunCompleted(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRunCompleted = t
+	log.Printf("Scheduler: Run completed at %s", t.Format(time.RFC3339))
+}
+
 // shouldRun checks if the current time matches any scheduled time.
 func (s *Scheduler) shouldRun(now time.Time) bool {
 	currentHour := now.Hour()
@@ -176,6 +347,11 @@ func (s *Scheduler) runJobs() {
 		return
 	}
 
+	if !s.IsLeader() {
+		log.Println("Scheduler: Not the leader, skipping run")
+		return
+	}
+
 	log.Println("Scheduler: Fetching jobs...")
 
 	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Minute)
@@ -189,15 +365,89 @@ func (s *Scheduler) runJobs() {
 
 	if len(jobs) == 0 {
 		log.Println("Scheduler: No jobs to process")
+		s.recordRunCompleted(time.Now())
 		return
 	}
 
+	jobs = s.prioritizeSkipped(jobs)
+
+	run := &runTracker{complete: s.recordRunCompleted}
+	run.pending.Store(int64(len(jobs)))
+	tracked := make([]Job, len(jobs))
+	for i, job := range jobs {
+		tracked[i] = &trackedJob{Job: job, run: run}
+	}
+
 	log.Printf("Scheduler: Submitting %d jobs to worker pool", len(jobs))
-	s.workerPool.SubmitBatch(jobs)
+	// The fetch timeout doesn't apply here: under QueueFullBlock, submitting a large run
+	// takes as long as the workers need to make room, so only shutdown stops it
+	rejected := s.workerPool.SubmitBatch(s.ctx, tracked)
+	s.recordSkipped(rejected)
+	if len(rejected) > 0 {
+		run.incomplete.Store(true)
+		run.finish(len(rejected))
+	}
 }
 
-// Shutdown gracefully stops the scheduler and worker pool.
-func (s *Scheduler) Shutdown(timeout time.Duration) {
+// prioritizeSkipped moves the jobs of users skipped last run to the front, keeping the
+// provider's order otherwise
+func (s *Scheduler) prioritizeSkipped(jobs []Job) []Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.skipped) == 0 {
+		return jobs
+	}
+
+	log.Printf("Scheduler: Prioritizing %d users skipped last run", len(s.skipped))
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return s.skipped[jobs[i].UserID()] && !s.skipped[jobs[j].UserID()]
+	})
+	return jobs
+}
+
+// recordSkipped remembers the users whose jobs did not make it into the queue this run
+func (s *Scheduler) recordSkipped(rejected []Job) {
+	skipped := make(map[string]bool, len(rejected))
+	for _, job := range rejected {
+		skipped[job.UserID()] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped = skipped
+	if len(skipped) > 0 {
+		log.Printf("Scheduler: %d users skipped this run will be prioritized next run", len(skipped))
+	}
+}
+
+// runTracker counts down the jobs of one run and records the run as completed when the last
+// one finishes, unless some of them never made it into the queue
+type runTracker struct {
+	pending    atomic.Int64
+	incomplete atomic.Bool
+	complete   func(time.Time)
+}
+
+func (r *runTracker) finish(n int) {
+	if r.pending.Add(-int64(n)) == 0 && !r.incomplete.Load() {
+		r.complete(time.Now())
+	}
+}
+
+// trackedJob reports to its run when it finishes, successfully or not
+type trackedJob struct {
+	Job
+	run *runTracker
+}
+
+func (j *trackedJob) Execute(ctx context.Context) error {
+	defer j.run.finish(1)
+	return j.Job.Execute(ctx)
+}
+
+// Stop stops scheduling new runs and waits, until ctx is done, for the in-flight sync jobs
+// to finish. Jobs still queued or running at the deadline are dropped; the report counts both.
+func (s *Scheduler) Stop(ctx context.Context) StopReport {
 	log.Println("Scheduler: Initiating graceful shutdown...")
 
 	s.cancel()
@@ -211,13 +461,24 @@ func (s *Scheduler) Shutdown(timeout time.Duration) {
 	select {
 	case <-done:
 		log.Println("Scheduler: Scheduler loop stopped gracefully")
-	case <-time.After(timeout):
+	case <-ctx.Done():
 		log.Println("Scheduler: Timeout waiting for scheduler loop to stop")
 	}
 
-	s.workerPool.ShutdownWithTimeout(timeout)
+	report := s.workerPool.Stop(ctx)
+
+	// Hand leadership over to a standby instance
+	if s.elector != nil {
+		s.leader.Store(false)
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := s.elector.Release(releaseCtx); err != nil {
+			log.Printf("Scheduler: Failed to release leadership: %v", err)
+		}
+		cancel()
+	}
 
-	log.Println("Scheduler: Shutdown complete")
+	log.Printf("Scheduler: Shutdown complete (%d jobs completed, %d dropped)", report.Completed, report.Dropped)
+	return report
 }
 
 // TriggerNow manually triggers a job run immediately.
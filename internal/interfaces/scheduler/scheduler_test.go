@@ -0,0 +1,242 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeElector grants leadership according to its leader flag
+type fakeElector struct {
+	leader   atomic.Bool
+	err      error
+	released atomic.Bool
+}
+
+func (e *fakeElector) TryAcquire(ctx context.Context) (bool, error) {
+	if e.err != nil {
+		return false, e.err
+	}
+	return e.leader.Load(), nil
+}
+
+func (e *fakeElector) Release(ctx context.Context) error {
+	e.released.Store(true)
+	return nil
+}
+
+func TestSchedulerLeadership(t *testing.T) {
+	tests := []struct {
+		name       string
+		elector    *fakeElector
+		leader     bool
+		wantLeader bool
+	}{
+		{name: "no elector always leads", wantLeader: true},
+		{name: "elected leader", elector: &fakeElector{}, leader: true, wantLeader: true},
+		{name: "standby", elector: &fakeElector{}, leader: false, wantLeader: false},
+		{name: "election error means standby", elector: &fakeElector{err: errors.New("db down")}, leader: true, wantLeader: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs atomic.Int32
+			cfg := SchedulerConfig{
+				ScheduleTimes: []string{"05:00"},
+				WorkerCount:   1,
+				QueueSize:     1,
+				JobProvider: func(ctx context.Context) ([]Job, error) {
+					runs.Add(1)
+					return nil, nil
+				},
+			}
+			if tt.elector != nil {
+				tt.elector.leader.Store(tt.leader)
+				cfg.Elector = tt.elector
+			}
+
+			s, err := NewScheduler(cfg)
+			if err != nil {
+				t.Fatalf("NewScheduler() error = %v", err)
+			}
+			s.Start()
+			defer s.Stop(context.Background())
+
+			if got := s.IsLeader(); got != tt.wantLeader {
+				t.Errorf("IsLeader() = %v, want %v", got, tt.wantLeader)
+			}
+
+			s.runJobs()
+			wantRuns := int32(0)
+			if tt.wantLeader {
+				wantRuns = 1
+			}
+			if got := runs.Load(); got != wantRuns {
+				t.Errorf("job provider ran %d times, want %d", got, wantRuns)
+			}
+		})
+	}
+}
+
+func TestSchedulerLeadershipTakeover(t *testing.T) {
+	elector := &fakeElector{}
+	s, err := NewScheduler(SchedulerConfig{
+		ScheduleTimes: []string{"05:00"},
+		WorkerCount:   1,
+		QueueSize:     1,
+		Elector:       elector,
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	s.Start()
+
+	if s.IsLeader() {
+		t.Fatal("IsLeader() = true before the leader lock was free")
+	}
+
+	// The previous leader died and released the lock
+	elector.leader.Store(true)
+	s.checkLeadership()
+	if !s.IsLeader() {
+		t.Error("IsLeader() = false after acquiring the lock")
+	}
+
+	s.Stop(context.Background())
+	if !elector.released.Load() {
+		t.Error("Stop() did not release leadership")
+	}
+	if s.IsLeader() {
+		t.Error("IsLeader() = true after Stop")
+	}
+}
+
+func TestSchedulerRunCompletion(t *testing.T) {
+	jobs := []Job{&testJob{duration: 10 * time.Millisecond}, &testJob{duration: 20 * time.Millisecond, err: errors.New("sync failed")}}
+	s, err := NewScheduler(SchedulerConfig{
+		ScheduleTimes: []string{"05:00"},
+		WorkerCount:   2,
+		QueueSize:     2,
+		JobProvider: func(ctx context.Context) ([]Job, error) {
+			return jobs, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	s.Start()
+	defer s.Stop(context.Background())
+
+	if !s.LastRunCompleted().IsZero() {
+		t.Fatal("LastRunCompleted() set before any run")
+	}
+
+	before := time.Now()
+	s.runJobs()
+	deadline := time.Now().Add(time.Second)
+	for s.LastRunCompleted().IsZero() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if last := s.LastRunCompleted(); last.Before(before) {
+		t.Errorf("LastRunCompleted() = %v, want after %v (a failed job still completes the run)", last, before)
+	}
+}
+
+func TestSchedulerRunCompletion_DroppedJobs(t *testing.T) {
+	s, err := NewScheduler(SchedulerConfig{
+		ScheduleTimes: []string{"05:00"},
+		WorkerCount:   1,
+		QueueSize:     1,
+		JobProvider: func(ctx context.Context) ([]Job, error) {
+			return []Job{&testJob{}, &testJob{}, &testJob{}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	// Workers are not started, so only one job fits in the queue and the rest are dropped
+	s.leader.Store(true)
+	s.runJobs()
+	s.Stop(context.Background())
+
+	if last := s.LastRunCompleted(); !last.IsZero() {
+		t.Errorf("LastRunCompleted() = %v, want zero when jobs were dropped", last)
+	}
+}
+
+func TestSchedulerPrioritizesSkippedUsers(t *testing.T) {
+	var jobs []Job
+	s, err := NewScheduler(SchedulerConfig{
+		ScheduleTimes: []string{"05:00"},
+		WorkerCount:   1,
+		QueueSize:     2,
+		JobProvider: func(ctx context.Context) ([]Job, error) {
+			return jobs, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	s.leader.Store(true)
+
+	// Workers are not started, so users 3 and 4 do not fit in the queue
+	jobs = []Job{&testJob{user: "1"}, &testJob{user: "2"}, &testJob{user: "3"}, &testJob{user: "4"}}
+	s.runJobs()
+	for len(s.workerPool.jobs) > 0 {
+		<-s.workerPool.jobs
+	}
+
+	jobs = []Job{&testJob{user: "1"}, &testJob{user: "2"}, &testJob{user: "3"}, &testJob{user: "4"}, &testJob{user: "5"}}
+	s.runJobs()
+
+	var got []string
+	for len(s.workerPool.jobs) > 0 {
+		got = append(got, (<-s.workerPool.jobs).UserID())
+	}
+	if want := []string{"3", "4"}; !slices.Equal(got, want) {
+		t.Errorf("queued users = %v, want skipped users %v first", got, want)
+	}
+	if !s.skipped["1"] || !s.skipped["2"] || !s.skipped["5"] || s.skipped["3"] {
+		t.Errorf("skipped = %v, want users 1, 2 and 5", s.skipped)
+	}
+	s.Stop(context.Background())
+}
+
+func TestSchedulerStale(t *testing.T) {
+	s, err := NewScheduler(SchedulerConfig{
+		ScheduleTimes: []string{"05:00", "10:00", "14:00", "20:00"},
+		WorkerCount:   1,
+		QueueSize:     1,
+	})
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+	if want := 9*time.Hour + DefaultStaleGrace; s.staleAfter != want {
+		t.Errorf("staleAfter = %v, want %v (20:00 to 05:00 plus grace)", s.staleAfter, want)
+	}
+
+	now := time.Now()
+	s.setLeaderSince(now.Add(-12 * time.Hour))
+	if s.staleAt(now) {
+		t.Error("standby instance reported stale")
+	}
+
+	s.leader.Store(true)
+	if !s.staleAt(now) {
+		t.Error("leader without a run for 12h is not stale")
+	}
+
+	s.recordRunCompleted(now.Add(-time.Hour))
+	if s.staleAt(now) {
+		t.Error("leader with a run an hour ago is stale")
+	}
+
+	s.setLeaderSince(now.Add(-time.Minute))
+	s.recordRunCompleted(time.Time{})
+	if s.staleAt(now) {
+		t.Error("new leader is stale before its first run was due")
+	}
+}
@@ -69,21 +69,40 @@ type UserSyncJob struct {
 	accountSyncService *openfinance.AccountSyncService
 	txSyncService      *openfinance.TransactionSyncService
 	billSyncService    *openfinance.BillSyncService
+	syncLocker         openfinance.SyncLocker
 }
 
-// NewUserSyncJob creates a new composite sync job for a user
-func NewUserSyncJob(userID int64, accountSyncService *openfinance.AccountSyncService, txSyncService *openfinance.TransactionSyncService, billSyncService *openfinance.BillSyncService) *UserSyncJob {
+// NewUserSyncJob creates a new composite sync job for a user.
+// syncLocker may be nil to run without the per-user sync lock.
+func NewUserSyncJob(userID int64, accountSyncService *openfinance.AccountSyncService, txSyncService *openfinance.TransactionSyncService, billSyncService *openfinance.BillSyncService, syncLocker openfinance.SyncLocker) *UserSyncJob {
 	return &UserSyncJob{
 		userID:             userID,
 		accountSyncService: accountSyncService,
 		txSyncService:      txSyncService,
 		billSyncService:    billSyncService,
+		syncLocker:         syncLocker,
 	}
 }
 
 // Execute runs account sync first, then transaction sync, then bill sync on success.
-// Transaction sync uses full history if new accounts were created, otherwise last 7 days.
+// Transaction sync uses full history if new accounts were created, otherwise last 7 days
+// bounded by the sync window.
+// The job is skipped when another sync for the user is still running. The provider key is
+// decrypted once for the three steps and wiped when the job returns.
 func (j *UserSyncJob) Execute(ctx context.Context) error {
+	unlock, err := openfinance.LockUserSync(ctx, j.syncLocker, j.userID, false)
+	if errors.Is(err, openfinance.ErrSyncInProgress) {
+		log.Printf("Skipping full sync for user %d: a sync is already running", j.userID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock sync: %w", err)
+	}
+	defer unlock()
+
+	ctx, releaseKey := openfinance.WithProviderKeyScope(ctx, j.userID)
+	defer releaseKey()
+
 	log.Printf("Starting full sync for user %d", j.userID)
 
 	// Run account sync first — acts as provider key validation gate
@@ -97,14 +116,14 @@ func (j *UserSyncJob) Execute(ctx context.Context) error {
 		return fmt.Errorf("account sync failed, skipping transaction sync: %w", err)
 	}
 
-	log.Printf("Account sync for user %d: Created=%d, Updated=%d, Errors=%d",
-		j.userID, accountResult.Created, accountResult.Updated, len(accountResult.Errors))
+	log.Printf("Account sync for user %d: Created=%d, Updated=%d, Failed=%d, Errors=%d",
+		j.userID, accountResult.Created, accountResult.Updated, accountResult.Failed, len(accountResult.Errors))
 
 	// Determine if new accounts were created
 	hasNewAccounts := accountResult.Created > 0
 
 	// Run transaction sync with appropriate date range
-	txResult, err := j.txSyncService.SyncUserTransactions(ctx, j.userID, hasNewAccounts)
+	txResult, err := j.txSyncService.SyncRecentTransactions(ctx, j.userID, hasNewAccounts)
 	if err != nil {
 		log.Printf("Transaction sync failed for user %d: %v", j.userID, err)
 		return fmt.Errorf("transaction sync failed: %w", err)
@@ -147,12 +166,12 @@ func NewTransactionSyncJob(userID int64, syncService *openfinance.TransactionSyn
 }
 
 // Execute runs the transaction sync job.
-// When run standalone, uses incremental sync (last 7 days).
+// When run standalone, uses incremental sync (last 7 days, bounded by the sync window).
 func (j *TransactionSyncJob) Execute(ctx context.Context) error {
 	log.Printf("Starting transaction sync for user %d", j.userID)
 
 	// Standalone execution uses incremental sync
-	result, err := j.syncService.SyncUserTransactions(ctx, j.userID, false)
+	result, err := j.syncService.SyncRecentTransactions(ctx, j.userID, false)
 	if err != nil {
 		log.Printf("Transaction sync failed for user %d: %v", j.userID, err)
 		return fmt.Errorf("sync failed: %w", err)
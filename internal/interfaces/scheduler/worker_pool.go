@@ -2,12 +2,50 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrPoolStopped is returned by Submit once the pool has been stopped
+var ErrPoolStopped = errors.New("worker pool stopped")
+
+// ErrQueueFull is returned by Submit when the queue is full under QueueFullDrop
+var ErrQueueFull = errors.New("job queue full")
+
+// QueueFullPolicy decides what Submit does when the job queue is full.
+type QueueFullPolicy string
+
+const (
+	// QueueFullDrop drops the job and counts it, so Submit never waits (the default)
+	QueueFullDrop QueueFullPolicy = "drop"
+
+	// QueueFullBlock makes Submit wait for room, slowing the job provider down to the pace of
+	// the workers
+	QueueFullBlock QueueFullPolicy = "block"
+)
+
+// stopCancelGrace bounds how long Stop waits for cancelled jobs to return after its deadline
+const stopCancelGrace = 5 * time.Second
+
+// Metrics records jobs the pool could not queue.
+type Metrics interface {
+	JobDropped(ctx context.Context)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) JobDropped(ctx context.Context) {}
+
+// StopReport summarizes what happened to the jobs that were queued or running when Stop was called.
+type StopReport struct {
+	Completed int // Jobs that ran to the end (successfully or with an error)
+	Dropped   int // Queued jobs that never started and running jobs cut off by the deadline
+}
+
 // WorkerPool manages a pool of concurrent workers that process jobs.
 // It demonstrates Go's concurrency primitives: goroutines, channels,
 // WaitGroups, and context-based cancellation.
@@ -18,6 +56,19 @@ type WorkerPool struct {
 	wg          sync.WaitGroup
 	ctx         context.Context
 	cancel      context.CancelFunc
+
+	fullPolicy QueueFullPolicy
+	metrics    Metrics
+
+	// stopMu guards stopped so Submit never sends on the closed jobs channel. quit is closed
+	// before Stop takes stopMu, so a Submit blocked on a full queue lets go of it.
+	stopMu    sync.RWMutex
+	stopped   bool
+	quit      chan struct{}
+	quitOnce  sync.Once
+	submitted atomic.Int64
+	completed atomic.Int64
+	dropped   atomic.Int64
 }
 
 // NewWorkerPool creates a new worker pool with the specified configuration.
@@ -33,7 +84,32 @@ func NewWorkerPool(workerCount int, jobDelay time.Duration, queueSize int) *Work
 		jobs:        make(chan Job, queueSize),
 		ctx:         ctx,
 		cancel:      cancel,
+		fullPolicy:  QueueFullDrop,
+		metrics:     noopMetrics{},
+		quit:        make(chan struct{}),
+	}
+}
+
+// SetQueueFullPolicy sets what Submit does when the queue is full. Empty restores
+// QueueFullDrop. Call before submitting jobs.
+func (wp *WorkerPool) SetQueueFullPolicy(policy QueueFullPolicy) {
+	if policy == "" {
+		policy = QueueFullDrop
 	}
+	wp.fullPolicy = policy
+}
+
+// SetMetrics records every job the pool could not queue. A nil value disables recording.
+func (wp *WorkerPool) SetMetrics(m Metrics) {
+	if m == nil {
+		m = noopMetrics{}
+	}
+	wp.metrics = m
+}
+
+// Dropped returns how many submitted jobs never made it into the queue
+func (wp *WorkerPool) Dropped() int64 {
+	return wp.dropped.Load()
 }
 
 // Start launches the worker goroutines.
@@ -69,6 +145,11 @@ func (wp *WorkerPool) worker(id int) {
 				return
 			}
 
+			// Don't start queued jobs once the shutdown deadline has passed
+			if wp.ctx.Err() != nil {
+				continue
+			}
+
 			// Process the job
 			wp.processJob(id, job)
 
@@ -96,7 +177,14 @@ func (wp *WorkerPool) processJob(workerID int, job Job) {
 	defer cancel()
 
 	// Execute the job
-	if err := job.Execute(ctx); err != nil {
+	err := job.Execute(ctx)
+
+	// A job cut off by a forced shutdown did not complete
+	if err == nil || wp.ctx.Err() == nil {
+		wp.completed.Add(1)
+	}
+
+	if err != nil {
 		log.Printf("Worker %d: Error processing %s for user %s: %v",
 			workerID, job.Description(), job.UserID(), err)
 		return
@@ -107,64 +195,89 @@ func (wp *WorkerPool) processJob(workerID int, job Job) {
 }
 
 // Submit adds a job to the queue for processing.
-// Returns an error if the context is cancelled.
-// Returns ErrQueueFull if the queue is full (job is dropped).
-// Non-blocking: uses select to respect context cancellation.
+// Returns ErrPoolStopped once Stop has been called.
+// When the queue is full, QueueFullDrop returns ErrQueueFull (the job is dropped) and
+// QueueFullBlock waits for room.
 func (wp *WorkerPool) Submit(job Job) error {
+	return wp.submit(context.Background(), job)
+}
+
+// submit is Submit with a context that bounds how long QueueFullBlock waits
+func (wp *WorkerPool) submit(ctx context.Context, job Job) error {
+	wp.stopMu.RLock()
+	defer wp.stopMu.RUnlock()
+	if wp.stopped {
+		return ErrPoolStopped
+	}
+
 	select {
 	case <-wp.ctx.Done():
 		return wp.ctx.Err()
 	case wp.jobs <- job:
+		wp.submitted.Add(1)
 		return nil
 	default:
-		// Queue is full - could also block here, but we return error for visibility
-		log.Printf("Warning: Job queue full, dropping job for user %s", job.UserID())
-		return fmt.Errorf("job queue full, dropping job for user %s", job.UserID())
+	}
+
+	if wp.fullPolicy != QueueFullBlock {
+		wp.drop(ctx, job)
+		return fmt.Errorf("%w, dropping job for user %s", ErrQueueFull, job.UserID())
+	}
+
+	log.Printf("Job queue full, waiting for room for user %s", job.UserID())
+	select {
+	case wp.jobs <- job:
+		wp.submitted.Add(1)
+		return nil
+	case <-wp.quit:
+		wp.drop(ctx, job)
+		return ErrPoolStopped
+	case <-ctx.Done():
+		wp.drop(ctx, job)
+		return fmt.Errorf("gave up waiting for room for user %s: %w", job.UserID(), ctx.Err())
 	}
 }
 
-// SubmitBatch adds multiple jobs to the queue.
+// drop counts a job that never made it into the queue
+func (wp *WorkerPool) drop(ctx context.Context, job Job) {
+	wp.dropped.Add(1)
+	wp.metrics.JobDropped(context.WithoutCancel(ctx))
+	log.Printf("Warning: Job queue full, dropping job for user %s", job.UserID())
+}
+
+// SubmitBatch adds multiple jobs to the queue and returns the ones that were not accepted.
+// Under QueueFullBlock, jobs wait for room until ctx is done; the whole batch shares ctx,
+// so once it is done the remaining jobs are rejected.
 // Useful for batch processing scenarios (e.g., syncing all users).
-func (wp *WorkerPool) SubmitBatch(jobs []Job) {
-	submitted := 0
+func (wp *WorkerPool) SubmitBatch(ctx context.Context, jobs []Job) (rejected []Job) {
 	for _, job := range jobs {
-		if err := wp.Submit(job); err != nil {
+		if err := wp.submit(ctx, job); err != nil {
 			log.Printf("Failed to submit job for user %s: %v", job.UserID(), err)
-			continue
+			rejected = append(rejected, job)
 		}
-		submitted++
 	}
-	log.Printf("Submitted %d/%d jobs to worker pool", submitted, len(jobs))
+	log.Printf("Submitted %d/%d jobs to worker pool", len(jobs)-len(rejected), len(jobs))
+	return rejected
 }
 
-// Shutdown gracefully stops the worker pool.
-// It closes the job channel, waits for workers to finish, then cancels the context.
-// Demonstrates: graceful shutdown, WaitGroups, context cancellation.
-func (wp *WorkerPool) Shutdown() {
-	log.Println("Worker pool: Initiating graceful shutdown")
-
+// Stop stops accepting new jobs and lets the workers finish the queued and running jobs.
+// If ctx is done first, running jobs are cancelled and queued jobs are dropped.
+// Calling Stop again returns an empty report.
+func (wp *WorkerPool) Stop(ctx context.Context) StopReport {
+	wp.quitOnce.Do(func() { close(wp.quit) })
+	wp.stopMu.Lock()
+	if wp.stopped {
+		wp.stopMu.Unlock()
+		return StopReport{}
+	}
+	wp.stopped = true
+	completedBefore := wp.completed.Load()
 	// Close the job channel to signal no more jobs will be added
 	close(wp.jobs)
+	wp.stopMu.Unlock()
 
-	// Wait for all workers to finish processing current jobs
-	log.Println("Worker pool: Waiting for workers to finish...")
-	wp.wg.Wait()
-
-	// Cancel context to signal any long-running operations
-	wp.cancel()
-
-	log.Println("Worker pool: Shutdown complete")
-}
-
-// ShutdownWithTimeout shuts down the worker pool with a timeout.
-// If workers don't finish within the timeout, it forces shutdown by cancelling context.
-func (wp *WorkerPool) ShutdownWithTimeout(timeout time.Duration) {
-	log.Printf("Worker pool: Initiating graceful shutdown with %v timeout", timeout)
+	log.Printf("Worker pool: Stopping, waiting for %d queued or running jobs", wp.submitted.Load()-completedBefore)
 
-	// Close the job channel
-	close(wp.jobs)
-
-	// Wait for workers with timeout
 	done := make(chan struct{})
 	go func() {
 		wp.wg.Wait()
@@ -174,10 +287,40 @@ func (wp *WorkerPool) ShutdownWithTimeout(timeout time.Duration) {
 	select {
 	case <-done:
 		log.Println("Worker pool: All workers finished gracefully")
-	case <-time.After(timeout):
-		log.Println("Worker pool: Timeout reached, forcing shutdown")
+	case <-ctx.Done():
+		log.Println("Worker pool: Deadline reached, cancelling remaining jobs")
 		wp.cancel()
+		// Let the cancelled jobs return so the counts below are final
+		select {
+		case <-done:
+		case <-time.After(stopCancelGrace):
+			log.Println("Worker pool: Jobs still running after cancellation, counting them as dropped")
+		}
 	}
 
-	log.Println("Worker pool: Shutdown complete")
+	// Cancel context to signal any long-running operations
+	wp.cancel()
+
+	// No job can be submitted after stopped is set, so both counters are final once the workers drained
+	completed := wp.completed.Load() - completedBefore
+	pending := wp.submitted.Load() - completedBefore
+	report := StopReport{
+		Completed: int(completed),
+		Dropped:   int(pending - completed),
+	}
+	log.Printf("Worker pool: Stopped, %d jobs completed, %d dropped", report.Completed, report.Dropped)
+	return report
+}
+
+// Shutdown gracefully stops the worker pool, waiting for all queued jobs to finish.
+func (wp *WorkerPool) Shutdown() {
+	wp.Stop(context.Background())
+}
+
+// ShutdownWithTimeout shuts down the worker pool with a timeout.
+// If workers don't finish within the timeout, it forces shutdown by cancelling context.
+func (wp *WorkerPool) ShutdownWithTimeout(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	wp.Stop(ctx)
 }
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testJob runs for duration, or until its context is cancelled
+type testJob struct {
+	duration     time.Duration
+	err          error
+	user         string // Defaults to "1"
+	ignoreCancel bool   // Runs for the full duration even once cancelled
+}
+
+func (j *testJob) Execute(ctx context.Context) error {
+	if j.ignoreCancel {
+		time.Sleep(j.duration)
+		return j.err
+	}
+	select {
+	case <-time.After(j.duration):
+		return j.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *testJob) UserID() string {
+	if j.user == "" {
+		return "1"
+	}
+	return j.user
+}
+
+func (j *testJob) Description() string { return "test job" }
+
+type countingMetrics struct{ dropped int }
+
+func (m *countingMetrics) JobDropped(ctx context.Context) { m.dropped++ }
+
+func TestWorkerPoolStop(t *testing.T) {
+	tests := []struct {
+		name          string
+		jobs          []*testJob
+		timeout       time.Duration
+		wantCompleted int
+		wantDropped   int
+	}{
+		{
+			name: "waits for queued and running jobs",
+			jobs: []*testJob{
+				{duration: 20 * time.Millisecond},
+				{duration: 20 * time.Millisecond, err: errors.New("sync failed")},
+				{duration: 20 * time.Millisecond},
+			},
+			timeout:       time.Second,
+			wantCompleted: 3,
+		},
+		{
+			name: "drops jobs still pending at the deadline",
+			jobs: []*testJob{
+				{duration: 10 * time.Millisecond},
+				{duration: time.Minute},
+				{duration: 10 * time.Millisecond},
+			},
+			timeout:       100 * time.Millisecond,
+			wantCompleted: 1,
+			wantDropped:   2,
+		},
+		{
+			name: "counts jobs that finish while being cancelled",
+			jobs: []*testJob{
+				{duration: 80 * time.Millisecond, ignoreCancel: true},
+				{duration: time.Minute},
+			},
+			timeout:       20 * time.Millisecond,
+			wantCompleted: 1,
+			wantDropped:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wp := NewWorkerPool(1, 0, len(tt.jobs))
+			wp.Start()
+			for _, job := range tt.jobs {
+				if err := wp.Submit(job); err != nil {
+					t.Fatalf("Submit() error = %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			report := wp.Stop(ctx)
+
+			if report.Completed != tt.wantCompleted || report.Dropped != tt.wantDropped {
+				t.Errorf("Stop() = %+v, want {Completed:%d Dropped:%d}", report, tt.wantCompleted, tt.wantDropped)
+			}
+			if err := wp.Submit(&testJob{}); !errors.Is(err, ErrPoolStopped) {
+				t.Errorf("Submit() after Stop error = %v, want %v", err, ErrPoolStopped)
+			}
+			if second := wp.Stop(context.Background()); second != (StopReport{}) {
+				t.Errorf("second Stop() = %+v, want empty report", second)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolQueueFull(t *testing.T) {
+	t.Run("drop rejects the job and counts it", func(t *testing.T) {
+		metrics := &countingMetrics{}
+		wp := NewWorkerPool(1, 0, 1)
+		wp.SetMetrics(metrics)
+
+		if err := wp.Submit(&testJob{}); err != nil {
+			t.Fatalf("first Submit() error = %v", err)
+		}
+		if err := wp.Submit(&testJob{}); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("Submit() on a full queue error = %v, want ErrQueueFull", err)
+		}
+		if got := wp.Dropped(); got != 1 {
+			t.Errorf("Dropped() = %d, want 1", got)
+		}
+		if metrics.dropped != 1 {
+			t.Errorf("metrics recorded %d drops, want 1", metrics.dropped)
+		}
+		wp.Stop(context.Background())
+	})
+
+	t.Run("block waits for room", func(t *testing.T) {
+		wp := NewWorkerPool(1, 0, 1)
+		wp.SetQueueFullPolicy(QueueFullBlock)
+		if err := wp.Submit(&testJob{}); err != nil {
+			t.Fatalf("first Submit() error = %v", err)
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- wp.Submit(&testJob{}) }()
+		select {
+		case err := <-done:
+			t.Fatalf("Submit() returned %v before the queue had room", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		// Workers drain the queue and make room for the blocked job
+		wp.Start()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("blocked Submit() error = %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("blocked Submit() did not return once the queue had room")
+		}
+		if got := wp.Dropped(); got != 0 {
+			t.Errorf("Dropped() = %d, want 0", got)
+		}
+		wp.Stop(context.Background())
+	})
+
+	t.Run("block gives up when ctx is done", func(t *testing.T) {
+		wp := NewWorkerPool(1, 0, 1)
+		wp.SetQueueFullPolicy(QueueFullBlock)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		rejected := wp.SubmitBatch(ctx, []Job{&testJob{}, &testJob{user: "2"}})
+		if len(rejected) != 1 || rejected[0].UserID() != "2" {
+			t.Errorf("SubmitBatch() rejected %v, want only the job for user 2", rejected)
+		}
+		if got := wp.Dropped(); got != 1 {
+			t.Errorf("Dropped() = %d, want 1", got)
+		}
+		wp.Stop(context.Background())
+	})
+
+	t.Run("block lets go when the pool stops", func(t *testing.T) {
+		wp := NewWorkerPool(1, 0, 1)
+		wp.SetQueueFullPolicy(QueueFullBlock)
+		if err := wp.Submit(&testJob{}); err != nil {
+			t.Fatalf("first Submit() error = %v", err)
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- wp.Submit(&testJob{}) }()
+		time.Sleep(10 * time.Millisecond)
+		wp.Stop(context.Background())
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrPoolStopped) {
+				t.Errorf("blocked Submit() error = %v, want ErrPoolStopped", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("blocked Submit() did not return after Stop")
+		}
+	})
+}
@@ -6,6 +6,7 @@ type Bank struct {
 	UIName       string `json:"uiName"`
 	Connector    string `json:"connectorId"`
 	PrimaryColor string `json:"primaryColor"`
+	LogoURL      string `json:"logoUrl"`
 }
 
 type CreateBankParams struct {
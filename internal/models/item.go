@@ -4,12 +4,71 @@ import (
 	"time"
 )
 
+// Item health states reported to clients
+const (
+	ItemHealthOK             = "OK"              // Synced recently
+	ItemHealthStale          = "STALE"           // No successful sync within ItemStaleAfter
+	ItemHealthEmpty          = "EMPTY"           // Connection has no active accounts
+	ItemHealthNeedsReconnect = "NEEDS_RECONNECT" // Provider rejected the credentials
+	ItemHealthDisabled       = "DISABLED"        // Connection was turned off
+)
+
+// ItemStaleAfter is how long an item can go without a sync before it is reported as stale
+const ItemStaleAfter = 48 * time.Hour
+
 // Item represents a connection/relationship with a financial institution via the provider.
 // One Item can have multiple Accounts (e.g., checking + credit card from same bank).
 type Item struct {
-	ID        string     `json:"id"` // Provider's itemId (UUID string)
-	UserID    int64      `json:"userId"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
-	DeletedAt *time.Time `json:"deletedAt"`
+	ID             string     `json:"id"` // Provider's itemId (UUID string)
+	UserID         int64      `json:"userId"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	DeletedAt      *time.Time `json:"deletedAt"`
+	NeedsReconnect bool       `json:"needsReconnect"` // default to false
+	Disabled       bool       `json:"disabled"`       // default to false
+}
+
+// UpdateItemParams contains the item state fields that can be changed
+type UpdateItemParams struct {
+	NeedsReconnect *bool
+	Disabled       *bool
+}
+
+// ExcludedFromSync reports whether the item's accounts should be skipped during sync
+func (i *Item) ExcludedFromSync() bool {
+	return i.Disabled || i.NeedsReconnect
+}
+
+// ItemSummary is an Item aggregated with its bank data and active account count.
+// The bank is resolved from the item's accounts, which get it from the provider's
+// ItemBankName during transaction sync.
+type ItemSummary struct {
+	Item
+	BankName         string `json:"bankName"`
+	BankUIName       string `json:"bankUIName"`
+	BankConnector    string `json:"bankConnector"`
+	BankPrimaryColor string `json:"bankPrimaryColor"`
+	AccountCount     int    `json:"accountCount"`
+}
+
+// LastSyncAt returns when the item was last touched by an account sync
+func (s *ItemSummary) LastSyncAt() time.Time {
+	return s.UpdatedAt
+}
+
+// Health derives the connection health from the account count and last sync time
+func (s *ItemSummary) Health(now time.Time) string {
+	if s.Disabled {
+		return ItemHealthDisabled
+	}
+	if s.NeedsReconnect {
+		return ItemHealthNeedsReconnect
+	}
+	if s.AccountCount == 0 {
+		return ItemHealthEmpty
+	}
+	if now.Sub(s.LastSyncAt()) > ItemStaleAfter {
+		return ItemHealthStale
+	}
+	return ItemHealthOK
 }
@@ -5,7 +5,11 @@ import "context"
 // ItemRepository defines data access for Items
 type ItemRepository interface {
 	FindOrCreate(ctx context.Context, id string, userID int64) (*Item, error)
+	GetByID(ctx context.Context, id string) (*Item, error)
 	ListByUserID(ctx context.Context, userID int64) ([]*Item, error)
+	ListSummariesByUserID(ctx context.Context, userID int64) ([]*ItemSummary, error)
+	Update(ctx context.Context, id string, params UpdateItemParams) (*Item, error)
+	SetNeedsReconnectByUserID(ctx context.Context, userID int64, value bool) error
 	Delete(ctx context.Context, id string) error
 	SoftDelete(ctx context.Context, id string) error
 }
@@ -13,6 +17,11 @@ type ItemRepository interface {
 // BankRepository defines data access for Banks
 type BankRepository interface {
 	FindOrCreateByName(ctx context.Context, name string) (*Bank, error)
+	FindByConnector(ctx context.Context, connector string) (*Bank, error)
+	FindOrCreateByConnector(ctx context.Context, name, connector string) (*Bank, error)
+	SetConnector(ctx context.Context, id int64, connector string) (bool, error)
+	ResolvePlaceholderName(ctx context.Context, id int64, name string) (*Bank, error)
+	ApplyBranding(ctx context.Context, id int64, primaryColor, logoURL string) error
 }
 
 // CreditCardDataRepository defines data access for Credit Card Data
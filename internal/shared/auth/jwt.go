@@ -1,8 +1,6 @@
 package auth
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -17,18 +15,59 @@ type JWTClaims struct {
 	Iat    int64  `json:"iat"`
 }
 
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// JWT signs tokens with a primary key and verifies them against a keyset keyed by kid,
+// so signing keys can be rotated while tokens issued with older keys stay valid.
 type JWT struct {
-	secret []byte
+	primary   *JWTKey
+	verifiers map[string]*JWTKey
 }
 
+// NewJWT creates an HS256 signer/verifier from a shared secret (no kid header)
 func NewJWT(secret string) *JWT {
-	return &JWT{secret: []byte(secret)}
+	key := &JWTKey{Algorithm: AlgHS256, Secret: []byte(secret)}
+	return &JWT{
+		primary:   key,
+		verifiers: map[string]*JWTKey{"": key},
+	}
+}
+
+// NewJWTWithKeys creates a JWT that signs with primary and also accepts tokens signed by
+// any of the previous keys. Every key must have a distinct ID; only primary needs to be
+// able to sign.
+func NewJWTWithKeys(primary JWTKey, previous ...JWTKey) (*JWT, error) {
+	if !primary.canSign() {
+		return nil, fmt.Errorf("primary JWT key %q cannot sign %s tokens", primary.ID, primary.Algorithm)
+	}
+
+	j := &JWT{verifiers: make(map[string]*JWTKey)}
+	for i, key := range append([]JWTKey{primary}, previous...) {
+		key := key
+		if err := key.validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := j.verifiers[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		j.verifiers[key.ID] = &key
+		if i == 0 {
+			j.primary = &key
+		}
+	}
+
+	return j, nil
 }
 
 func (j *JWT) Generate(userID int64, email string) (string, error) {
-	header := map[string]string{
-		"alg": "HS256",
-		"typ": "JWT",
+	header := jwtHeader{
+		Alg: j.primary.Algorithm,
+		Typ: "JWT",
+		Kid: j.primary.ID,
 	}
 
 	claims := JWTClaims{
@@ -52,7 +91,10 @@ func (j *JWT) Generate(userID int64, email string) (string, error) {
 	claimsB64 := base64.RawURLEncoding.EncodeToString(claimsJSON)
 
 	message := headerB64 + "." + claimsB64
-	signature := j.sign(message)
+	signature, err := j.sign(message)
+	if err != nil {
+		return "", err
+	}
 
 	return message + "." + signature, nil
 }
@@ -63,11 +105,31 @@ func (j *JWT) Validate(token string) (*JWTClaims, error) {
 		return nil, fmt.Errorf("invalid token format")
 	}
 
-	message := parts[0] + "." + parts[1]
-	signature := parts[2]
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode header: %w", err)
+	}
 
-	expectedSignature := j.sign(message)
-	if signature != expectedSignature {
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal header: %w", err)
+	}
+
+	key, ok := j.verifiers[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key")
+	}
+	// The algorithm is pinned by the key, never taken from the token
+	if header.Alg != key.Algorithm {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	if !key.verify([]byte(parts[0]+"."+parts[1]), signature) {
 		return nil, fmt.Errorf("invalid signature")
 	}
 
@@ -88,8 +150,10 @@ func (j *JWT) Validate(token string) (*JWTClaims, error) {
 	return &claims, nil
 }
 
-func (j *JWT) sign(message string) string {
-	h := hmac.New(sha256.New, j.secret)
-	h.Write([]byte(message))
-	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+func (j *JWT) sign(message string) (string, error) {
+	signature, err := j.primary.sign([]byte(message))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(signature), nil
 }
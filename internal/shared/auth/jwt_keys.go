@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// Supported JWT signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// JWTKey is a single entry of the JWT keyset. HS256 keys use Secret; RS256/ES256 keys
+// use PrivateKey to sign and PublicKey to verify (a key loaded only for verification
+// during rotation has no PrivateKey).
+type JWTKey struct {
+	ID         string
+	Algorithm  string
+	Secret     []byte
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// LoadJWTKey reads a PEM file and builds a key for the given algorithm. The file may hold
+// a private key (PKCS#8, PKCS#1 or SEC 1) or, for verification-only keys, a public key.
+// When alg is empty it is inferred from the key type.
+func LoadJWTKey(id, alg, path string) (JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JWTKey{}, fmt.Errorf("failed to read JWT key %q: %w", id, err)
+	}
+	return ParseJWTKey(id, alg, data)
+}
+
+// ParseJWTKey is like LoadJWTKey but takes the PEM contents directly
+func ParseJWTKey(id, alg string, pemData []byte) (JWTKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return JWTKey{}, fmt.Errorf("failed to decode PEM block for JWT key %q", id)
+	}
+
+	key := JWTKey{ID: id, Algorithm: alg}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("failed to parse JWT public key %q: %w", id, err)
+		}
+		key.PublicKey = pub
+	case "RSA PRIVATE KEY":
+		priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("failed to parse JWT private key %q: %w", id, err)
+		}
+		key.PrivateKey = priv
+	case "EC PRIVATE KEY":
+		priv, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("failed to parse JWT private key %q: %w", id, err)
+		}
+		key.PrivateKey = priv
+	default:
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return JWTKey{}, fmt.Errorf("failed to parse JWT private key %q: %w", id, err)
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return JWTKey{}, fmt.Errorf("JWT private key %q cannot sign", id)
+		}
+		key.PrivateKey = signer
+	}
+
+	if key.PrivateKey != nil {
+		key.PublicKey = key.PrivateKey.Public()
+	}
+
+	if key.Algorithm == "" {
+		switch key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			key.Algorithm = AlgRS256
+		case *ecdsa.PublicKey:
+			key.Algorithm = AlgES256
+		}
+	}
+
+	if err := key.validate(); err != nil {
+		return JWTKey{}, err
+	}
+
+	return key, nil
+}
+
+// validate checks that the key material matches the algorithm
+func (k *JWTKey) validate() error {
+	switch k.Algorithm {
+	case AlgHS256:
+		if len(k.Secret) == 0 {
+			return fmt.Errorf("JWT key %q: HS256 requires a secret", k.ID)
+		}
+	case AlgRS256:
+		if _, ok := k.PublicKey.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("JWT key %q: RS256 requires an RSA key", k.ID)
+		}
+	case AlgES256:
+		pub, ok := k.PublicKey.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return fmt.Errorf("JWT key %q: ES256 requires a P-256 ECDSA key", k.ID)
+		}
+	default:
+		return fmt.Errorf("JWT key %q: unsupported algorithm %q", k.ID, k.Algorithm)
+	}
+	return nil
+}
+
+// canSign reports whether the key holds the material needed to sign tokens
+func (k *JWTKey) canSign() bool {
+	if k.Algorithm == AlgHS256 {
+		return len(k.Secret) > 0
+	}
+	return k.PrivateKey != nil
+}
+
+func (k *JWTKey) sign(message []byte) ([]byte, error) {
+	switch k.Algorithm {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.Secret)
+		h.Write(message)
+		return h.Sum(nil), nil
+	case AlgRS256:
+		priv, ok := k.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("JWT key %q has no RSA private key", k.ID)
+		}
+		hash := sha256.Sum256(message)
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hash[:])
+	case AlgES256:
+		priv, ok := k.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("JWT key %q has no ECDSA private key", k.ID)
+		}
+		return signES256(message, priv)
+	}
+	return nil, fmt.Errorf("JWT key %q: unsupported algorithm %q", k.ID, k.Algorithm)
+}
+
+func (k *JWTKey) verify(message, signature []byte) bool {
+	switch k.Algorithm {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.Secret)
+		h.Write(message)
+		return hmac.Equal(signature, h.Sum(nil))
+	case AlgRS256:
+		pub, ok := k.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		hash := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], signature) == nil
+	case AlgES256:
+		pub, ok := k.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return verifyES256(message, signature, pub)
+	}
+	return false
+}
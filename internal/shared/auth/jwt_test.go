@@ -1,8 +1,14 @@
 package auth
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"strings"
 	"testing"
 	"time"
@@ -74,7 +80,7 @@ func TestJWT_ExpiredToken(t *testing.T) {
 	claimsB64 := base64.RawURLEncoding.EncodeToString(claimsJSON)
 
 	message := headerB64 + "." + claimsB64
-	signature := j.sign(message) // Use internal sign method via public API if I could, but I can't access 'sign' directly as it is private?
+	signature, _ := j.sign(message) // Use internal sign method via public API if I could, but I can't access 'sign' directly as it is private?
 	// Wait, 'sign' is private (lowercase 's').
 	// But I am in package 'auth', so I can access it!
 	
@@ -87,3 +93,159 @@ func TestJWT_ExpiredToken(t *testing.T) {
 		t.Errorf("Validate() returned wrong error for expired token: %v", err)
 	}
 }
+
+func TestJWT_AsymmetricAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  JWTKey
+	}{
+		{name: "RS256", key: JWTKey{ID: "rsa-1", Algorithm: AlgRS256, PrivateKey: rsaKey, PublicKey: rsaKey.Public()}},
+		{name: "ES256", key: JWTKey{ID: "ec-1", Algorithm: AlgES256, PrivateKey: ecKey, PublicKey: ecKey.Public()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := NewJWTWithKeys(tt.key)
+			if err != nil {
+				t.Fatalf("NewJWTWithKeys() failed: %v", err)
+			}
+
+			token, err := j.Generate(42, "user@example.com")
+			if err != nil {
+				t.Fatalf("Generate() failed: %v", err)
+			}
+
+			headerJSON, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+			var header jwtHeader
+			json.Unmarshal(headerJSON, &header)
+			if header.Alg != tt.key.Algorithm || header.Kid != tt.key.ID {
+				t.Errorf("header = %+v, want alg %s kid %s", header, tt.key.Algorithm, tt.key.ID)
+			}
+
+			claims, err := j.Validate(token)
+			if err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			if claims.UserID != 42 {
+				t.Errorf("Validate() got UserID %d, want 42", claims.UserID)
+			}
+		})
+	}
+}
+
+func TestJWT_KeyRotation(t *testing.T) {
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	legacy := NewJWT("legacy-secret")
+	legacyToken, _ := legacy.Generate(1, "legacy@example.com")
+
+	before, err := NewJWTWithKeys(JWTKey{ID: "k1", Algorithm: AlgES256, PrivateKey: oldKey, PublicKey: oldKey.Public()})
+	if err != nil {
+		t.Fatalf("NewJWTWithKeys() failed: %v", err)
+	}
+	oldToken, _ := before.Generate(2, "old@example.com")
+
+	// After rotation: k2 signs, k1 (public key only) and the legacy secret still verify
+	after, err := NewJWTWithKeys(
+		JWTKey{ID: "k2", Algorithm: AlgES256, PrivateKey: newKey, PublicKey: newKey.Public()},
+		JWTKey{ID: "k1", Algorithm: AlgES256, PublicKey: oldKey.Public()},
+		JWTKey{Algorithm: AlgHS256, Secret: []byte("legacy-secret")},
+	)
+	if err != nil {
+		t.Fatalf("NewJWTWithKeys() failed: %v", err)
+	}
+
+	for name, token := range map[string]string{"old key": oldToken, "legacy secret": legacyToken} {
+		if _, err := after.Validate(token); err != nil {
+			t.Errorf("Validate() rejected token signed with %s: %v", name, err)
+		}
+	}
+
+	newToken, _ := after.Generate(3, "new@example.com")
+	if _, err := before.Validate(newToken); err == nil || err.Error() != "unknown signing key" {
+		t.Errorf("Validate() with unknown kid error = %v, want unknown signing key", err)
+	}
+}
+
+func TestJWT_RejectsAlgorithmMismatch(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	j, _ := NewJWTWithKeys(JWTKey{ID: "k1", Algorithm: AlgES256, PrivateKey: ecKey, PublicKey: ecKey.Public()})
+
+	// An HS256 token claiming the ES256 key id must not be accepted
+	forged := NewJWT("attacker-secret")
+	forged.primary.ID = "k1"
+	token, _ := forged.Generate(1, "attacker@example.com")
+
+	if _, err := j.Validate(token); err == nil || err.Error() != "invalid signature" {
+		t.Errorf("Validate() error = %v, want invalid signature", err)
+	}
+}
+
+func TestNewJWTWithKeys_Errors(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name     string
+		primary  JWTKey
+		previous []JWTKey
+	}{
+		{name: "primary without private key", primary: JWTKey{ID: "k1", Algorithm: AlgES256, PublicKey: ecKey.Public()}},
+		{name: "unsupported algorithm", primary: JWTKey{ID: "k1", Algorithm: "none", Secret: []byte("x")}},
+		{name: "key type does not match algorithm", primary: JWTKey{ID: "k1", Algorithm: AlgRS256, PrivateKey: ecKey, PublicKey: ecKey.Public()}},
+		{
+			name:     "duplicate key id",
+			primary:  JWTKey{ID: "k1", Algorithm: AlgHS256, Secret: []byte("a")},
+			previous: []JWTKey{{ID: "k1", Algorithm: AlgHS256, Secret: []byte("b")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTWithKeys(tt.primary, tt.previous...); err == nil {
+				t.Error("NewJWTWithKeys() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseJWTKey(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	privDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	pubDER, _ := x509.MarshalPKIXPublicKey(ecKey.Public())
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	priv, err := ParseJWTKey("k1", "", privPEM)
+	if err != nil {
+		t.Fatalf("ParseJWTKey(private) failed: %v", err)
+	}
+	if priv.Algorithm != AlgES256 || !priv.canSign() {
+		t.Errorf("ParseJWTKey(private) = alg %s canSign %v, want ES256 signing key", priv.Algorithm, priv.canSign())
+	}
+
+	pub, err := ParseJWTKey("k1", "", pubPEM)
+	if err != nil {
+		t.Fatalf("ParseJWTKey(public) failed: %v", err)
+	}
+	if pub.canSign() {
+		t.Error("ParseJWTKey(public) returned a key that can sign")
+	}
+
+	if _, err := ParseJWTKey("k1", AlgRS256, pubPEM); err == nil {
+		t.Error("ParseJWTKey() accepted an EC key for RS256")
+	}
+	if _, err := ParseJWTKey("k1", "", []byte("not a pem")); err == nil {
+		t.Error("ParseJWTKey() accepted invalid PEM")
+	}
+}
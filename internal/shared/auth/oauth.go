@@ -41,19 +41,34 @@ type OAuthUserInfo struct {
 	AvatarURL string
 }
 
+// Scopes requested when the provider is given none
+var (
+	DefaultGoogleScopes = []string{"openid", "email", "profile"}
+	DefaultAppleScopes  = []string{"name", "email"}
+)
+
 // GoogleOAuthProvider implements Google OAuth 2.0
 type GoogleOAuthProvider struct {
 	clientID     string
 	clientSecret string
 	redirectURL  string
+	scopes       []string
+	prompt       string
 	httpClient   *http.Client
 }
 
-func NewGoogleOAuthProvider(clientID, clientSecret, redirectURL string) *GoogleOAuthProvider {
+// NewGoogleOAuthProvider creates a Google provider. scopes defaults to DefaultGoogleScopes when
+// empty; prompt (e.g. "consent" or "select_account") is left out of the auth URL when empty.
+func NewGoogleOAuthProvider(clientID, clientSecret, redirectURL string, scopes []string, prompt string) *GoogleOAuthProvider {
+	if len(scopes) == 0 {
+		scopes = DefaultGoogleScopes
+	}
 	return &GoogleOAuthProvider{
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		redirectURL:  redirectURL,
+		scopes:       scopes,
+		prompt:       prompt,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -73,9 +88,12 @@ func (g *GoogleOAuthProvider) GetAuthURL(state string, redirectURI ...string) st
 	params.Add("redirect_uri", targetRedirectURI)
 
 	params.Add("response_type", "code")
-	params.Add("scope", "openid email profile")
+	params.Add("scope", strings.Join(g.scopes, " "))
 	params.Add("state", state)
 	params.Add("access_type", "offline")
+	if g.prompt != "" {
+		params.Add("prompt", g.prompt)
+	}
 
 	return baseURL + "?" + params.Encode()
 }
@@ -175,10 +193,13 @@ type AppleOAuthProvider struct {
 	clientID       string
 	privateKey     *ecdsa.PrivateKey
 	redirectURL    string
+	scopes         []string
 	httpClient     *http.Client
 }
 
-func NewAppleOAuthProvider(teamID, keyID, clientID, privateKeyPath, redirectURL string) (*AppleOAuthProvider, error) {
+// NewAppleOAuthProvider creates an Apple provider signing with the key at privateKeyPath.
+// scopes defaults to DefaultAppleScopes when empty.
+func NewAppleOAuthProvider(teamID, keyID, clientID, privateKeyPath, redirectURL string, scopes []string) (*AppleOAuthProvider, error) {
 	// Read private key file
 	keyData, err := os.ReadFile(privateKeyPath)
 	if err != nil {
@@ -202,12 +223,17 @@ func NewAppleOAuthProvider(teamID, keyID, clientID, privateKeyPath, redirectURL
 		return nil, fmt.Errorf("Apple private key is not an ECDSA key")
 	}
 
+	if len(scopes) == 0 {
+		scopes = DefaultAppleScopes
+	}
+
 	return &AppleOAuthProvider{
 		teamID:      teamID,
 		keyID:       keyID,
 		clientID:    clientID,
 		privateKey:  ecdsaKey,
 		redirectURL: redirectURL,
+		scopes:      scopes,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -261,7 +287,7 @@ func (a *AppleOAuthProvider) GetAuthURL(state string, redirectURI ...string) str
 
 	params.Add("response_type", "code")
 	params.Add("response_mode", "form_post")
-	params.Add("scope", "name email")
+	params.Add("scope", strings.Join(a.scopes, " "))
 	params.Add("state", state)
 
 	return baseURL + "?" + params.Encode()
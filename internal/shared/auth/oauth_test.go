@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestGoogleOAuthProvider_GetAuthURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		scopes     []string
+		prompt     string
+		wantScope  string
+		wantPrompt string
+	}{
+		{name: "defaults", wantScope: "openid email profile"},
+		{
+			name:       "custom scopes and prompt",
+			scopes:     []string{"openid", "email", "https://www.googleapis.com/auth/calendar.readonly"},
+			prompt:     "select_account",
+			wantScope:  "openid email https://www.googleapis.com/auth/calendar.readonly",
+			wantPrompt: "select_account",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewGoogleOAuthProvider("client", "secret", "https://api.example.com/callback", tt.scopes, tt.prompt)
+
+			u, err := url.Parse(provider.GetAuthURL("state-1"))
+			if err != nil {
+				t.Fatalf("GetAuthURL() returned an invalid URL: %v", err)
+			}
+			query := u.Query()
+			if got := query.Get("scope"); got != tt.wantScope {
+				t.Errorf("scope = %q, want %q", got, tt.wantScope)
+			}
+			if got, ok := query["prompt"]; ok != (tt.wantPrompt != "") || query.Get("prompt") != tt.wantPrompt {
+				t.Errorf("prompt = %q, want %q", got, tt.wantPrompt)
+			}
+			if got := query.Get("access_type"); got != "offline" {
+				t.Errorf("access_type = %q, want offline", got)
+			}
+		})
+	}
+}
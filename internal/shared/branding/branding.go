@@ -0,0 +1,94 @@
+// Package branding holds the brand assets (color and logo) of known banks
+package branding
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//go:embed banks.json
+var banksJSON []byte
+
+// DefaultPrimaryColor is used for banks without a known brand color
+const DefaultPrimaryColor = "1194F6"
+
+var colorRE = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+
+// Bank is the branding of one bank. Logo is a file name under the logo base URL,
+// or an absolute http(s) URL used as-is.
+type Bank struct {
+	PrimaryColor string `json:"primaryColor,omitempty"`
+	Logo         string `json:"logo,omitempty"`
+}
+
+// Catalog maps connector codes to bank branding
+type Catalog struct {
+	banks       map[string]Bank
+	logoBaseURL string
+}
+
+// Load returns the embedded catalog with the entries of overrideFile (same JSON format)
+// applied on top, field by field. An empty overrideFile uses the embedded catalog only.
+func Load(overrideFile, logoBaseURL string) (*Catalog, error) {
+	banks := make(map[string]Bank)
+	if err := json.Unmarshal(banksJSON, &banks); err != nil {
+		return nil, fmt.Errorf("failed to parse embedded bank branding: %w", err)
+	}
+
+	if overrideFile != "" {
+		data, err := os.ReadFile(overrideFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bank branding file: %w", err)
+		}
+		var overrides map[string]Bank
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return nil, fmt.Errorf("failed to parse bank branding file %s: %w", overrideFile, err)
+		}
+		for connector, override := range overrides {
+			bank := banks[connector]
+			if override.PrimaryColor != "" {
+				bank.PrimaryColor = override.PrimaryColor
+			}
+			if override.Logo != "" {
+				bank.Logo = override.Logo
+			}
+			banks[connector] = bank
+		}
+	}
+
+	for connector, bank := range banks {
+		bank.PrimaryColor = strings.ToUpper(strings.TrimPrefix(bank.PrimaryColor, "#"))
+		if bank.PrimaryColor != "" && !colorRE.MatchString(bank.PrimaryColor) {
+			return nil, fmt.Errorf("invalid primary color %q for connector %s (want 6 hex digits)", bank.PrimaryColor, connector)
+		}
+		banks[connector] = bank
+	}
+
+	return &Catalog{banks: banks, logoBaseURL: strings.TrimSuffix(logoBaseURL, "/")}, nil
+}
+
+// Lookup returns the primary color and logo URL for a connector. Either is empty when
+// unknown; a logo file name without a configured base URL has no URL.
+func (c *Catalog) Lookup(connector string) (primaryColor, logoURL string, ok bool) {
+	if c == nil || connector == "" {
+		return "", "", false
+	}
+	bank, ok := c.banks[connector]
+	if !ok {
+		return "", "", false
+	}
+
+	switch {
+	case bank.Logo == "":
+	case strings.HasPrefix(bank.Logo, "https://") || strings.HasPrefix(bank.Logo, "http://"):
+		logoURL = bank.Logo
+	case c.logoBaseURL != "":
+		logoURL = c.logoBaseURL + "/" + bank.Logo
+	}
+
+	return bank.PrimaryColor, logoURL, true
+}
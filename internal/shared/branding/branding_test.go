@@ -0,0 +1,99 @@
+package branding
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "banks.json")
+	overrides := `{
+		"260": {"primaryColor": "#00ff00"},
+		"999": {"primaryColor": "123456", "logo": "https://img.example.com/new.png"}
+	}`
+	if err := os.WriteFile(file, []byte(overrides), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := Load(file, "https://cdn.example.com/banks/")
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		connector string
+		wantColor string
+		wantLogo  string
+		wantOK    bool
+	}{
+		// Override replaces only the color; the embedded logo remains
+		{"260", "00FF00", "https://cdn.example.com/banks/nubank.png", true},
+		// Embedded entry without override
+		{"341", "EC7000", "https://cdn.example.com/banks/itau.png", true},
+		// New entry with an absolute logo URL
+		{"999", "123456", "https://img.example.com/new.png", true},
+		{"000", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.connector, func(t *testing.T) {
+			color, logo, ok := catalog.Lookup(tt.connector)
+			if color != tt.wantColor || logo != tt.wantLogo || ok != tt.wantOK {
+				t.Errorf("Lookup(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.connector, color, logo, ok, tt.wantColor, tt.wantLogo, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLoad_NoLogoBaseURL(t *testing.T) {
+	catalog, err := Load("", "")
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	color, logo, ok := catalog.Lookup("260")
+	if !ok || color != "820AD1" {
+		t.Errorf("Lookup(260) = (%q, %v), want (820AD1, true)", color, ok)
+	}
+	if logo != "" {
+		t.Errorf("logo = %q, want empty without a base URL", logo)
+	}
+}
+
+func TestLoad_InvalidOverride(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid JSON", `{`},
+		{"invalid color", `{"260": {"primaryColor": "purple"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, "banks.json")
+			if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(file, ""); err == nil {
+				t.Error("Load() expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json"), ""); err == nil {
+		t.Error("Load() expected error for missing file, got nil")
+	}
+}
+
+func TestLookup_NilCatalog(t *testing.T) {
+	var catalog *Catalog
+	if _, _, ok := catalog.Lookup("260"); ok {
+		t.Error("nil catalog should not find any bank")
+	}
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -22,6 +25,12 @@ type Config struct {
 	OpenFinance OpenFinanceConfig
 	Firebase    FirebaseConfig
 	Telemetry   TelemetryConfig
+	Admin       AdminConfig
+	Notes       NotesConfig
+	Cookie      CookieConfig
+	Branding    BrandingConfig
+	Attachments AttachmentsConfig
+	Logging     LoggingConfig
 }
 
 type ServerConfig struct {
@@ -30,13 +39,18 @@ type ServerConfig struct {
 	AllowedHosts []string
 }
 
+// DatabaseConfig holds the Postgres connection settings. DetectionMaxOps caps the repository
+// calls the duplicate and bill payment checks run at once across the process; it defaults to
+// 40% of MaxOpenConns so detection cannot starve API requests of connections.
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host            string
+	Port            int
+	User            string
+	Password        string
+	DBName          string
+	SSLMode         string
+	MaxOpenConns    int
+	DetectionMaxOps int
 }
 
 type OAuthConfig struct {
@@ -50,6 +64,8 @@ type GoogleOAuthConfig struct {
 	ClientSecret      string
 	WebCallbackURL    string
 	MobileCallbackURL string
+	Scopes            []string
+	Prompt            string // space-separated: none, consent and/or select_account; empty lets Google decide
 }
 
 type AppleOAuthConfig struct {
@@ -58,23 +74,39 @@ type AppleOAuthConfig struct {
 	ClientID          string
 	PrivateKeyPath    string
 	MobileCallbackURL string
+	Scopes            []string
 }
 
+// JWTConfig selects how API tokens are signed. HS256 uses Secret; RS256/ES256 sign with
+// the PEM key at PrivateKeyPath under KeyID. VerifyKeys maps the key IDs of previous
+// signing keys to their PEM files so older tokens stay valid during rotation.
 type JWTConfig struct {
-	Secret string
+	Secret         string
+	Algorithm      string
+	KeyID          string
+	PrivateKeyPath string
+	VerifyKeys     map[string]string
 }
 
 type EncryptionConfig struct {
 	Key string
 }
 
+// SchedulerConfig controls the background sync scheduler. With LeaderElection, replicas
+// elect one leader through a Postgres advisory lock and only the leader runs jobs.
+// StaleAfter is how long the leader may go without completing a run before the health
+// check reports a stale sync; zero derives it from the schedule times. QueueFullPolicy is
+// "drop" (drop and count jobs that do not fit in the queue) or "block" (wait for room).
 type SchedulerConfig struct {
-	Enabled       bool
-	ScheduleTimes []string
-	WorkerCount   int
-	JobDelay      time.Duration
-	QueueSize     int
-	RunOnStartup  bool
+	Enabled         bool
+	ScheduleTimes   []string
+	WorkerCount     int
+	JobDelay        time.Duration
+	QueueSize       int
+	QueueFullPolicy string
+	RunOnStartup    bool
+	LeaderElection  bool
+	StaleAfter      time.Duration
 }
 
 type TLSConfig struct {
@@ -84,9 +116,57 @@ type TLSConfig struct {
 	RedirectHTTP bool
 }
 
+// OpenFinanceConfig controls transaction sync. BillPaymentCategories are the provider category
+// codes excluded as credit card bill payments on import; empty disables the check. The
+// timeouts bound each provider call separately. RemoveMissingTransactions lets full syncs mark
+// transactions the provider no longer returns as removed. SyncWindowDays bounds how far back
+// scheduled incremental syncs fetch (0 = no bound); older history is only refreshed by admin
+// full-sync or a new account's first sync.
+// RecheckDuplicatesOnEdit re-runs the duplicate check for a transaction after a user edits its
+// amount, and re-includes transactions the old amount had marked as duplicates.
+// LinkDuplicateCousins groups each duplicate pair the check marks under a shared cousin.
+// SubtypeAliases map provider account subtypes to canonical ones, on top of the built-in aliases.
+// SyncedSubtypes limits account and transaction sync to accounts of those canonical subtypes
+// (empty = all).
+// PrunePendingDays marks PENDING transactions older than that many days that a sync no longer
+// returns as removed (0 = off).
 type OpenFinanceConfig struct {
-	TransactionSyncStartDate string
-	UpdateSyncDays           int
+	TransactionSyncStartDate  string
+	UpdateSyncDays            int
+	SyncWindowDays            int
+	BillPaymentCategories     []string
+	SubtypeAliases            map[string]string
+	SyncedSubtypes            []string
+	RemoveMissingTransactions bool
+	PrunePendingDays          int
+	RecheckDuplicatesOnEdit   bool
+	LinkDuplicateCousins      bool
+	AccountsTimeout           time.Duration
+	TransactionsTimeout       time.Duration
+	BillsTimeout              time.Duration
+}
+
+// CookieConfig controls the attributes of the auth cookie. SameSite is lax, strict or none.
+// Secure is nil to derive it from the request (TLS or X-Forwarded-Proto), or forces the flag.
+type CookieConfig struct {
+	Domain   string
+	SameSite string
+	Secure   *bool
+}
+
+// BrandingConfig overrides the embedded bank branding catalog. BankFile is a JSON file
+// keyed by connector code; logos given as file names are served under LogoBaseURL.
+type BrandingConfig struct {
+	BankFile    string
+	LogoBaseURL string
+}
+
+// AttachmentsConfig controls transaction attachments. Files are stored on local disk under
+// Dir; MaxBytes and MaxPerTransaction bound what a user can upload.
+type AttachmentsConfig struct {
+	Dir               string
+	MaxBytes          int64
+	MaxPerTransaction int
 }
 
 type FirebaseConfig struct {
@@ -98,6 +178,25 @@ type TelemetryConfig struct {
 	MetricsAddr string
 }
 
+type AdminConfig struct {
+	Emails []string
+}
+
+// NotesConfig selects the transaction note texts written by the duplicate check.
+// Duplicate and BillPayment, when set, override the locale's catalog text.
+type NotesConfig struct {
+	Locale      string
+	Duplicate   string
+	BillPayment string
+}
+
+// LoggingConfig selects the minimum log level (debug, info, warn or error) and the output
+// format (text or json).
+type LoggingConfig struct {
+	Level  string
+	Format string
+}
+
 func Load() (*Config, error) {
 
 	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "5432"))
@@ -105,6 +204,15 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_PORT: %w", err)
 	}
 
+	dbMaxOpenConns, err := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_MAX_OPEN_CONNS: %w", err)
+	}
+	dbDetectionMaxOps, err := strconv.Atoi(getEnv("DB_DETECTION_MAX_OPS", strconv.Itoa(max(1, dbMaxOpenConns*2/5))))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_DETECTION_MAX_OPS: %w", err)
+	}
+
 	// Parse scheduler configuration
 	schedulerEnabled := getBoolEnv("SCHEDULER_ENABLED", true)
 	schedulerTimes := strings.Split(getEnv("SCHEDULER_TIMES", "05:00,10:00,14:00,20:00"), ",")
@@ -120,7 +228,13 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid SCHEDULER_QUEUE_SIZE: %w", err)
 	}
+	schedulerQueueFullPolicy := getEnv("SCHEDULER_QUEUE_FULL_POLICY", "drop")
 	schedulerRunOnStartup := getBoolEnv("SCHEDULER_RUN_ON_STARTUP", false)
+	schedulerLeaderElection := getBoolEnv("SCHEDULER_LEADER_ELECTION", true)
+	schedulerStaleAfter, err := time.ParseDuration(getEnv("SCHEDULER_STALE_AFTER", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCHEDULER_STALE_AFTER: %w", err)
+	}
 
 	// Parse TLS configuration
 	tlsEnabled := getBoolEnv("TLS_ENABLED", false)
@@ -140,6 +254,37 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Parse admin emails (comma-separated list)
+	var adminEmails []string
+	for _, email := range strings.Split(getEnv("ADMIN_EMAILS", ""), ",") {
+		email = strings.TrimSpace(email)
+		if email != "" {
+			adminEmails = append(adminEmails, email)
+		}
+	}
+
+	// Parse JWT verification keys (comma-separated kid=path pairs)
+	jwtVerifyKeys := make(map[string]string)
+	for _, entry := range strings.Split(getEnv("JWT_VERIFY_KEYS", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		kid, path = strings.TrimSpace(kid), strings.TrimSpace(path)
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid JWT_VERIFY_KEYS entry %q (want kid=path)", entry)
+		}
+		jwtVerifyKeys[kid] = path
+	}
+
+	// Parse cookie configuration (COOKIE_SECURE unset means derive from the request)
+	var cookieSecure *bool
+	if secureStr := getEnv("COOKIE_SECURE", ""); secureStr != "" {
+		secure := getBoolEnv("COOKIE_SECURE", false)
+		cookieSecure = &secure
+	}
+
 	// Construct OAuth callback URLs from HOST_URL
 	hostURL := getEnv("HOST_URL", "")
 	buildCallbackURL := func(path string, overrideEnv string) string {
@@ -168,9 +313,64 @@ func Load() (*Config, error) {
 	if err != nil || updateSyncDays <= 0 {
 		updateSyncDays = 7
 	}
+	syncWindowDays, err := strconv.Atoi(getEnv("OPENFINANCE_SYNC_WINDOW_DAYS", "90"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENFINANCE_SYNC_WINDOW_DAYS: %w", err)
+	}
+	prunePendingDays, err := strconv.Atoi(getEnv("OPENFINANCE_PRUNE_PENDING_DAYS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENFINANCE_PRUNE_PENDING_DAYS: %w", err)
+	}
+	// Bill payment categories (comma-separated codes, "none" disables)
+	var billPaymentCategories []string
+	if categories := getEnv("OPENFINANCE_BILL_PAYMENT_CATEGORIES", "05100000"); categories != "none" {
+		for _, code := range strings.Split(categories, ",") {
+			code = strings.TrimSpace(code)
+			if code != "" {
+				billPaymentCategories = append(billPaymentCategories, code)
+			}
+		}
+	}
+	subtypeAliases, err := parseSubtypeAliases(getListEnv("OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES: %w", err)
+	}
+	accountsTimeout, err := time.ParseDuration(getEnv("OPENFINANCE_ACCOUNTS_TIMEOUT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENFINANCE_ACCOUNTS_TIMEOUT: %w", err)
+	}
+	transactionsTimeout, err := time.ParseDuration(getEnv("OPENFINANCE_TRANSACTIONS_TIMEOUT", "180s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENFINANCE_TRANSACTIONS_TIMEOUT: %w", err)
+	}
+	billsTimeout, err := time.ParseDuration(getEnv("OPENFINANCE_BILLS_TIMEOUT", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENFINANCE_BILLS_TIMEOUT: %w", err)
+	}
 	openFinanceConfig := OpenFinanceConfig{
-		TransactionSyncStartDate: getEnv("OPENFINANCE_TRANSACTION_SYNC_START_DATE", "2023-01-01"),
-		UpdateSyncDays:           updateSyncDays,
+		TransactionSyncStartDate:  getEnv("OPENFINANCE_TRANSACTION_SYNC_START_DATE", "2023-01-01"),
+		UpdateSyncDays:            updateSyncDays,
+		SyncWindowDays:            syncWindowDays,
+		BillPaymentCategories:     billPaymentCategories,
+		SubtypeAliases:            subtypeAliases,
+		SyncedSubtypes:            parseSyncedSubtypes(getListEnv("OPENFINANCE_SYNC_ACCOUNT_SUBTYPES", "")),
+		RemoveMissingTransactions: getBoolEnv("OPENFINANCE_REMOVE_MISSING_TRANSACTIONS", false),
+		PrunePendingDays:          prunePendingDays,
+		RecheckDuplicatesOnEdit:   getBoolEnv("OPENFINANCE_RECHECK_DUPLICATES_ON_EDIT", false),
+		LinkDuplicateCousins:      getBoolEnv("OPENFINANCE_LINK_DUPLICATE_COUSINS", false),
+		AccountsTimeout:           accountsTimeout,
+		TransactionsTimeout:       transactionsTimeout,
+		BillsTimeout:              billsTimeout,
+	}
+
+	// Parse attachment limits
+	attachmentMaxBytes, err := strconv.ParseInt(getEnv("ATTACHMENTS_MAX_BYTES", "10485760"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATTACHMENTS_MAX_BYTES: %w", err)
+	}
+	attachmentMaxPerTransaction, err := strconv.Atoi(getEnv("ATTACHMENTS_MAX_PER_TRANSACTION", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATTACHMENTS_MAX_PER_TRANSACTION: %w", err)
 	}
 
 	cfg := &Config{
@@ -180,12 +380,14 @@ func Load() (*Config, error) {
 			AllowedHosts: allowedHosts,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     dbPort,
-			User:     getEnv("DB_USER", "lazaro"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "parsa-go"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:            getEnv("DB_HOST", "localhost"),
+			Port:            dbPort,
+			User:            getEnv("DB_USER", "lazaro"),
+			Password:        getEnv("DB_PASSWORD", ""),
+			DBName:          getEnv("DB_NAME", "parsa-go"),
+			SSLMode:         getEnv("DB_SSLMODE", "disable"),
+			MaxOpenConns:    dbMaxOpenConns,
+			DetectionMaxOps: dbDetectionMaxOps,
 		},
 		OAuth: OAuthConfig{
 			Google: GoogleOAuthConfig{
@@ -193,6 +395,8 @@ func Load() (*Config, error) {
 				ClientSecret:      getEnv("GOOGLE_CLIENT_SECRET", ""),
 				WebCallbackURL:    googleWebURL,
 				MobileCallbackURL: googleMobileURL,
+				Scopes:            getListEnv("GOOGLE_OAUTH_SCOPES", "openid,email,profile"),
+				Prompt:            strings.Join(strings.Fields(getEnv("GOOGLE_OAUTH_PROMPT", "")), " "),
 			},
 			Apple: AppleOAuthConfig{
 				TeamID:            getEnv("APPLE_TEAM_ID", ""),
@@ -200,22 +404,30 @@ func Load() (*Config, error) {
 				ClientID:          getEnv("APPLE_CLIENT_ID", ""),
 				PrivateKeyPath:    getEnv("APPLE_PRIVATE_KEY_PATH", ""),
 				MobileCallbackURL: appleMobileURL,
+				Scopes:            getListEnv("APPLE_OAUTH_SCOPES", "name,email"),
 			},
 			MobileAppScheme: mobileAppScheme,
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", ""),
+			Secret:         getEnv("JWT_SECRET", ""),
+			Algorithm:      strings.ToUpper(getEnv("JWT_ALGORITHM", "HS256")),
+			KeyID:          getEnv("JWT_KEY_ID", ""),
+			PrivateKeyPath: getEnv("JWT_PRIVATE_KEY_PATH", ""),
+			VerifyKeys:     jwtVerifyKeys,
 		},
 		Encryption: EncryptionConfig{
 			Key: getEnv("ENCRYPTION_KEY", ""),
 		},
 		Scheduler: SchedulerConfig{
-			Enabled:       schedulerEnabled,
-			ScheduleTimes: schedulerTimes,
-			WorkerCount:   schedulerWorkers,
-			JobDelay:      schedulerJobDelay,
-			QueueSize:     schedulerQueueSize,
-			RunOnStartup:  schedulerRunOnStartup,
+			Enabled:         schedulerEnabled,
+			ScheduleTimes:   schedulerTimes,
+			WorkerCount:     schedulerWorkers,
+			JobDelay:        schedulerJobDelay,
+			QueueSize:       schedulerQueueSize,
+			QueueFullPolicy: schedulerQueueFullPolicy,
+			RunOnStartup:    schedulerRunOnStartup,
+			LeaderElection:  schedulerLeaderElection,
+			StaleAfter:      schedulerStaleAfter,
 		},
 		TLS: TLSConfig{
 			Enabled:      tlsEnabled,
@@ -231,35 +443,233 @@ func Load() (*Config, error) {
 			Enabled:     otelEnabled,
 			MetricsAddr: metricsAddr,
 		},
+		Admin: AdminConfig{
+			Emails: adminEmails,
+		},
+		Notes: NotesConfig{
+			Locale:      getEnv("NOTES_LOCALE", "pt-BR"),
+			Duplicate:   getEnv("DUPLICATE_NOTE", ""),
+			BillPayment: getEnv("BILL_PAYMENT_NOTE", ""),
+		},
+		Cookie: CookieConfig{
+			Domain:   getEnv("COOKIE_DOMAIN", ""),
+			SameSite: strings.ToLower(getEnv("COOKIE_SAMESITE", "lax")),
+			Secure:   cookieSecure,
+		},
+		Branding: BrandingConfig{
+			BankFile:    getEnv("BANK_BRANDING_FILE", ""),
+			LogoBaseURL: getEnv("BANK_LOGO_BASE_URL", ""),
+		},
+		Attachments: AttachmentsConfig{
+			Dir:               getEnv("ATTACHMENTS_DIR", "./data/attachments"),
+			MaxBytes:          attachmentMaxBytes,
+			MaxPerTransaction: attachmentMaxPerTransaction,
+		},
+		Logging: LoggingConfig{
+			Level:  strings.ToLower(getEnv("LOG_LEVEL", "info")),
+			Format: strings.ToLower(getEnv("LOG_FORMAT", "text")),
+		},
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the loaded configuration and returns a single error listing every
+// problem found, so a misconfigured deployment fails at startup with the full picture.
+func (c *Config) Validate() error {
+	var problems []error
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	// JWT
+	switch c.JWT.Algorithm {
+	case "HS256":
+		if c.JWT.Secret == "" {
+			add("JWT_SECRET is required")
+		}
+	case "RS256", "ES256":
+		if c.JWT.PrivateKeyPath == "" || c.JWT.KeyID == "" {
+			add("JWT_PRIVATE_KEY_PATH and JWT_KEY_ID are required when JWT_ALGORITHM=%s", c.JWT.Algorithm)
+		}
+	default:
+		add("unsupported JWT_ALGORITHM %q (want HS256, RS256 or ES256)", c.JWT.Algorithm)
+	}
+
+	// Encryption
+	if c.Encryption.Key == "" {
+		add("ENCRYPTION_KEY is required")
+	} else if len(c.Encryption.Key) != 32 {
+		add("ENCRYPTION_KEY must be exactly 32 bytes for AES-256 (got %d)", len(c.Encryption.Key))
+	}
+
+	// Database
+	if c.Database.Host == "" {
+		add("DB_HOST is required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		add("DB_PORT must be between 1 and 65535 (got %d)", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		add("DB_USER is required")
+	}
+	if c.Database.DBName == "" {
+		add("DB_NAME is required")
+	}
+	if c.Database.MaxOpenConns < 1 {
+		add("DB_MAX_OPEN_CONNS must be at least 1 (got %d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.DetectionMaxOps < 1 || c.Database.DetectionMaxOps > c.Database.MaxOpenConns {
+		add("DB_DETECTION_MAX_OPS must be between 1 and DB_MAX_OPEN_CONNS (got %d)", c.Database.DetectionMaxOps)
 	}
 
-	// Validate required fields
-	if cfg.JWT.Secret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is required")
+	// OAuth
+	if (c.OAuth.Google.ClientID == "") != (c.OAuth.Google.ClientSecret == "") {
+		add("GOOGLE_CLIENT_ID and GOOGLE_CLIENT_SECRET must be set together")
 	}
-	if cfg.Encryption.Key == "" {
-		return nil, fmt.Errorf("ENCRYPTION_KEY is required")
+	if c.OAuth.Google.ClientID != "" && (c.OAuth.Google.WebCallbackURL == "" || c.OAuth.Google.MobileCallbackURL == "") {
+		add("HOST_URL (or GOOGLE_WEB_CALLBACK_URL and GOOGLE_MOBILE_CALLBACK_URL) is required when Google OAuth is configured")
 	}
-	if len(cfg.Encryption.Key) != 32 {
-		return nil, fmt.Errorf("ENCRYPTION_KEY must be exactly 32 bytes for AES-256")
+	if c.OAuth.Apple.PrivateKeyPath != "" && (c.OAuth.Apple.TeamID == "" || c.OAuth.Apple.KeyID == "" || c.OAuth.Apple.ClientID == "") {
+		add("APPLE_TEAM_ID, APPLE_KEY_ID and APPLE_CLIENT_ID are required when APPLE_PRIVATE_KEY_PATH is set")
+	}
+	// Sign-in reads the user's email, so neither provider may drop that scope
+	if !slices.Contains(c.OAuth.Google.Scopes, "email") {
+		add("GOOGLE_OAUTH_SCOPES must include email (got %q)", strings.Join(c.OAuth.Google.Scopes, ","))
+	}
+	if !slices.Contains(c.OAuth.Apple.Scopes, "email") {
+		add("APPLE_OAUTH_SCOPES must include email (got %q)", strings.Join(c.OAuth.Apple.Scopes, ","))
+	}
+	if prompts := strings.Fields(c.OAuth.Google.Prompt); slices.Contains(prompts, "none") && len(prompts) > 1 {
+		add("GOOGLE_OAUTH_PROMPT none cannot be combined with other values (got %q)", c.OAuth.Google.Prompt)
+	} else {
+		for _, prompt := range prompts {
+			if prompt != "none" && prompt != "consent" && prompt != "select_account" {
+				add("GOOGLE_OAUTH_PROMPT must be none, consent or select_account (got %q)", prompt)
+			}
+		}
+	}
+	// RFC 3986: scheme = ALPHA *( ALPHA / DIGIT / "+" / "-" / "." )
+	if !mobileSchemeRE.MatchString(c.OAuth.MobileAppScheme) {
+		add("MOBILE_APP_CALLBACK_SCHEME must be a valid URI scheme (got %q)", c.OAuth.MobileAppScheme)
 	}
 
-	// Validate mobile app scheme (RFC 3986: scheme = ALPHA *( ALPHA / DIGIT / "+" / "-" / "." ))
-	if !mobileSchemeRE.MatchString(cfg.OAuth.MobileAppScheme) {
-		return nil, fmt.Errorf("MOBILE_APP_CALLBACK_SCHEME must be a valid URI scheme (got %q)", cfg.OAuth.MobileAppScheme)
+	// Scheduler
+	if c.Scheduler.Enabled {
+		for _, t := range c.Scheduler.ScheduleTimes {
+			if _, err := time.Parse("15:04", strings.TrimSpace(t)); err != nil {
+				add("SCHEDULER_TIMES entry %q is not a valid HH:MM time", t)
+			}
+		}
+		if c.Scheduler.WorkerCount < 1 {
+			add("SCHEDULER_WORKERS must be at least 1 (got %d)", c.Scheduler.WorkerCount)
+		}
+		if c.Scheduler.QueueSize < 1 {
+			add("SCHEDULER_QUEUE_SIZE must be at least 1 (got %d)", c.Scheduler.QueueSize)
+		}
+		if p := c.Scheduler.QueueFullPolicy; p != "drop" && p != "block" {
+			add("SCHEDULER_QUEUE_FULL_POLICY must be drop or block (got %q)", p)
+		}
+		if c.Scheduler.JobDelay < 0 {
+			add("SCHEDULER_JOB_DELAY must not be negative (got %s)", c.Scheduler.JobDelay)
+		}
+		if c.Scheduler.StaleAfter < 0 {
+			add("SCHEDULER_STALE_AFTER must not be negative (got %s)", c.Scheduler.StaleAfter)
+		}
 	}
 
-	// Validate TLS configuration
-	if cfg.TLS.Enabled {
-		if cfg.TLS.CertPath == "" {
-			return nil, fmt.Errorf("TLS_CERT_PATH is required when TLS_ENABLED=true")
+	// Open Finance
+	if _, err := time.Parse("2006-01-02", c.OpenFinance.TransactionSyncStartDate); err != nil {
+		add("OPENFINANCE_TRANSACTION_SYNC_START_DATE must be a YYYY-MM-DD date (got %q)", c.OpenFinance.TransactionSyncStartDate)
+	}
+	if c.OpenFinance.SyncWindowDays < 0 {
+		add("OPENFINANCE_SYNC_WINDOW_DAYS must not be negative (got %d)", c.OpenFinance.SyncWindowDays)
+	}
+	if c.OpenFinance.PrunePendingDays < 0 {
+		add("OPENFINANCE_PRUNE_PENDING_DAYS must not be negative (got %d)", c.OpenFinance.PrunePendingDays)
+	}
+	for _, code := range c.OpenFinance.BillPaymentCategories {
+		if !isCategoryCode(code) {
+			add("OPENFINANCE_BILL_PAYMENT_CATEGORIES must be 8-digit category codes (got %q)", code)
 		}
-		if cfg.TLS.KeyPath == "" {
-			return nil, fmt.Errorf("TLS_KEY_PATH is required when TLS_ENABLED=true")
+	}
+	for provider, canonical := range c.OpenFinance.SubtypeAliases {
+		if !slices.Contains(canonicalAccountSubtypes, canonical) {
+			add("OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES entry %q must map to one of %s (got %q)",
+				provider, strings.Join(canonicalAccountSubtypes, ", "), canonical)
 		}
 	}
+	for _, subtype := range c.OpenFinance.SyncedSubtypes {
+		if !slices.Contains(canonicalAccountSubtypes, subtype) {
+			add("OPENFINANCE_SYNC_ACCOUNT_SUBTYPES entries must be one of %s (got %q)",
+				strings.Join(canonicalAccountSubtypes, ", "), subtype)
+		}
+	}
+	if c.OpenFinance.AccountsTimeout <= 0 {
+		add("OPENFINANCE_ACCOUNTS_TIMEOUT must be positive (got %s)", c.OpenFinance.AccountsTimeout)
+	}
+	if c.OpenFinance.TransactionsTimeout <= 0 {
+		add("OPENFINANCE_TRANSACTIONS_TIMEOUT must be positive (got %s)", c.OpenFinance.TransactionsTimeout)
+	}
+	if c.OpenFinance.BillsTimeout <= 0 {
+		add("OPENFINANCE_BILLS_TIMEOUT must be positive (got %s)", c.OpenFinance.BillsTimeout)
+	}
 
-	return cfg, nil
+	// Cookies: browsers reject SameSite=None cookies that are not Secure
+	switch c.Cookie.SameSite {
+	case "lax", "strict":
+	case "none":
+		if c.Cookie.Secure != nil && !*c.Cookie.Secure {
+			add("COOKIE_SAMESITE=none requires secure cookies (COOKIE_SECURE cannot be false)")
+		}
+	default:
+		add("COOKIE_SAMESITE must be lax, strict or none (got %q)", c.Cookie.SameSite)
+	}
+
+	// Branding
+	if base := c.Branding.LogoBaseURL; base != "" {
+		if u, err := url.Parse(base); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("BANK_LOGO_BASE_URL must be an absolute http(s) URL (got %q)", base)
+		}
+	}
+
+	// Attachments
+	if c.Attachments.Dir == "" {
+		add("ATTACHMENTS_DIR is required")
+	}
+	if c.Attachments.MaxBytes < 1 {
+		add("ATTACHMENTS_MAX_BYTES must be at least 1 (got %d)", c.Attachments.MaxBytes)
+	}
+	if c.Attachments.MaxPerTransaction < 1 {
+		add("ATTACHMENTS_MAX_PER_TRANSACTION must be at least 1 (got %d)", c.Attachments.MaxPerTransaction)
+	}
+
+	// TLS
+	if c.TLS.Enabled {
+		if c.TLS.CertPath == "" {
+			add("TLS_CERT_PATH is required when TLS_ENABLED=true")
+		}
+		if c.TLS.KeyPath == "" {
+			add("TLS_KEY_PATH is required when TLS_ENABLED=true")
+		}
+	}
+
+	// Logging
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("LOG_LEVEL must be debug, info, warn or error (got %q)", c.Logging.Level)
+	}
+	switch c.Logging.Format {
+	case "text", "json":
+	default:
+		add("LOG_FORMAT must be text or json (got %q)", c.Logging.Format)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(problems...))
+	}
+	return nil
 }
 
 func (c *DatabaseConfig) ConnectionString() string {
@@ -269,6 +679,50 @@ func (c *DatabaseConfig) ConnectionString() string {
 	)
 }
 
+// canonicalAccountSubtypes are the subtypes OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES may map to and
+// OPENFINANCE_SYNC_ACCOUNT_SUBTYPES may list
+var canonicalAccountSubtypes = []string{"CHECKING_ACCOUNT", "SAVINGS_ACCOUNT", "CREDIT_CARD"}
+
+// parseSubtypeAliases parses PROVIDER=CANONICAL entries, upper-casing both sides
+func parseSubtypeAliases(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	aliases := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		provider, canonical, ok := strings.Cut(entry, "=")
+		provider = strings.ToUpper(strings.TrimSpace(provider))
+		canonical = strings.ToUpper(strings.TrimSpace(canonical))
+		if !ok || provider == "" || canonical == "" {
+			return nil, fmt.Errorf("entry %q is not PROVIDER=CANONICAL", entry)
+		}
+		aliases[provider] = canonical
+	}
+	return aliases, nil
+}
+
+// parseSyncedSubtypes upper-cases the OPENFINANCE_SYNC_ACCOUNT_SUBTYPES entries
+func parseSyncedSubtypes(entries []string) []string {
+	subtypes := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		subtypes = append(subtypes, strings.ToUpper(entry))
+	}
+	return subtypes
+}
+
+// isCategoryCode reports whether code looks like an 8-digit provider category code
+func isCategoryCode(code string) bool {
+	if len(code) != 8 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -276,6 +730,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getListEnv splits a comma-separated variable, dropping blank entries
+func getListEnv(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
 	value := os.Getenv(key)
 	if value == "" {
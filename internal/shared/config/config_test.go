@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -11,6 +12,15 @@ func setRequiredEnvVars(t *testing.T) {
 	t.Setenv("ENCRYPTION_KEY", "01234567890123456789012345678901") // 32 bytes
 }
 
+// loadAndValidate runs Load followed by Validate, as the service does at startup
+func loadAndValidate() (*Config, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, cfg.Validate()
+}
+
 func TestLoad_Success(t *testing.T) {
 	setRequiredEnvVars(t)
 
@@ -35,9 +45,9 @@ func TestLoad_MissingJWTSecret(t *testing.T) {
 	t.Setenv("ENCRYPTION_KEY", "01234567890123456789012345678901")
 	os.Unsetenv("JWT_SECRET")
 
-	_, err := Load()
+	_, err := loadAndValidate()
 	if err == nil {
-		t.Error("Load() expected error for missing JWT_SECRET, got nil")
+		t.Error("Validate() expected error for missing JWT_SECRET, got nil")
 	}
 }
 
@@ -45,9 +55,9 @@ func TestLoad_InvalidEncryptionKeyLength(t *testing.T) {
 	t.Setenv("JWT_SECRET", "test-secret")
 	t.Setenv("ENCRYPTION_KEY", "too-short")
 
-	_, err := Load()
+	_, err := loadAndValidate()
 	if err == nil {
-		t.Error("Load() expected error for invalid ENCRYPTION_KEY length, got nil")
+		t.Error("Validate() expected error for invalid ENCRYPTION_KEY length, got nil")
 	}
 }
 
@@ -56,9 +66,9 @@ func TestLoad_MissingEncryptionKey(t *testing.T) {
 	t.Setenv("ENCRYPTION_KEY", "")
 	os.Unsetenv("ENCRYPTION_KEY")
 
-	_, err := Load()
+	_, err := loadAndValidate()
 	if err == nil {
-		t.Error("Load() expected error for missing ENCRYPTION_KEY, got nil")
+		t.Error("Validate() expected error for missing ENCRYPTION_KEY, got nil")
 	}
 }
 
@@ -78,9 +88,9 @@ func TestLoad_TLSValidation(t *testing.T) {
 	t.Setenv("TLS_CERT_PATH", "")
 	t.Setenv("TLS_KEY_PATH", "")
 
-	_, err := Load()
+	_, err := loadAndValidate()
 	if err == nil {
-		t.Error("Load() expected error for TLS enabled without cert path, got nil")
+		t.Error("Validate() expected error for TLS enabled without cert path, got nil")
 	}
 }
 
@@ -90,9 +100,190 @@ func TestLoad_TLSValidation_MissingKeyPath(t *testing.T) {
 	t.Setenv("TLS_CERT_PATH", "/path/to/cert")
 	t.Setenv("TLS_KEY_PATH", "")
 
-	_, err := Load()
+	_, err := loadAndValidate()
 	if err == nil {
-		t.Error("Load() expected error for TLS enabled without key path, got nil")
+		t.Error("Validate() expected error for TLS enabled without key path, got nil")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr []string
+	}{
+		{name: "defaults are valid", env: map[string]string{}},
+		{
+			name:    "invalid scheduler times",
+			env:     map[string]string{"SCHEDULER_TIMES": "05:00,25:00,noon"},
+			wantErr: []string{`"25:00"`, `"noon"`},
+		},
+		{
+			name:    "scheduler times ignored when disabled",
+			env:     map[string]string{"SCHEDULER_ENABLED": "false", "SCHEDULER_TIMES": "noon"},
+			wantErr: nil,
+		},
+		{
+			name:    "zero workers",
+			env:     map[string]string{"SCHEDULER_WORKERS": "0"},
+			wantErr: []string{"SCHEDULER_WORKERS"},
+		},
+		{
+			name:    "negative scheduler stale after",
+			env:     map[string]string{"SCHEDULER_STALE_AFTER": "-1h"},
+			wantErr: []string{"SCHEDULER_STALE_AFTER"},
+		},
+		{
+			name:    "unknown scheduler queue full policy",
+			env:     map[string]string{"SCHEDULER_QUEUE_FULL_POLICY": "retry"},
+			wantErr: []string{"SCHEDULER_QUEUE_FULL_POLICY"},
+		},
+		{
+			name:    "invalid sync start date",
+			env:     map[string]string{"OPENFINANCE_TRANSACTION_SYNC_START_DATE": "01/01/2023"},
+			wantErr: []string{"OPENFINANCE_TRANSACTION_SYNC_START_DATE"},
+		},
+		{
+			name:    "negative sync window",
+			env:     map[string]string{"OPENFINANCE_SYNC_WINDOW_DAYS": "-1"},
+			wantErr: []string{"OPENFINANCE_SYNC_WINDOW_DAYS"},
+		},
+		{
+			name:    "synced subtype that is not canonical",
+			env:     map[string]string{"OPENFINANCE_SYNC_ACCOUNT_SUBTYPES": "checking_account, INVESTMENT"},
+			wantErr: []string{`"INVESTMENT"`},
+		},
+		{
+			name:    "negative pending pruning age",
+			env:     map[string]string{"OPENFINANCE_PRUNE_PENDING_DAYS": "-1"},
+			wantErr: []string{"OPENFINANCE_PRUNE_PENDING_DAYS"},
+		},
+		{
+			name:    "invalid log settings",
+			env:     map[string]string{"LOG_LEVEL": "verbose", "LOG_FORMAT": "xml"},
+			wantErr: []string{"LOG_LEVEL", "LOG_FORMAT"},
+		},
+		{
+			name:    "invalid bill payment category",
+			env:     map[string]string{"OPENFINANCE_BILL_PAYMENT_CATEGORIES": "05100000, Pagamento"},
+			wantErr: []string{`"Pagamento"`},
+		},
+		{
+			name:    "subtype alias to a non-canonical subtype",
+			env:     map[string]string{"OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES": "CONTA_SALARIO=CHECKING_ACCOUNT,CONTA_INVESTIMENTO=INVESTMENT"},
+			wantErr: []string{`"CONTA_INVESTIMENTO"`},
+		},
+		{
+			name:    "non-positive provider timeout",
+			env:     map[string]string{"OPENFINANCE_ACCOUNTS_TIMEOUT": "0s"},
+			wantErr: []string{"OPENFINANCE_ACCOUNTS_TIMEOUT"},
+		},
+		{
+			name:    "bill payment categories disabled",
+			env:     map[string]string{"OPENFINANCE_BILL_PAYMENT_CATEGORIES": "none"},
+			wantErr: nil,
+		},
+		{
+			name:    "google client id without secret",
+			env:     map[string]string{"GOOGLE_CLIENT_ID": "id", "HOST_URL": "https://api.example.com"},
+			wantErr: []string{"GOOGLE_CLIENT_SECRET"},
+		},
+		{
+			name:    "apple key without identifiers",
+			env:     map[string]string{"APPLE_PRIVATE_KEY_PATH": "/keys/apple.p8"},
+			wantErr: []string{"APPLE_TEAM_ID"},
+		},
+		{
+			name:    "oauth scopes without email",
+			env:     map[string]string{"GOOGLE_OAUTH_SCOPES": "openid,profile", "APPLE_OAUTH_SCOPES": "name"},
+			wantErr: []string{"GOOGLE_OAUTH_SCOPES", "APPLE_OAUTH_SCOPES"},
+		},
+		{
+			name:    "invalid google prompt",
+			env:     map[string]string{"GOOGLE_OAUTH_PROMPT": "login"},
+			wantErr: []string{"GOOGLE_OAUTH_PROMPT"},
+		},
+		{
+			name:    "google prompt none combined",
+			env:     map[string]string{"GOOGLE_OAUTH_PROMPT": "none consent"},
+			wantErr: []string{"GOOGLE_OAUTH_PROMPT"},
+		},
+		{
+			name: "combined google prompts",
+			env:  map[string]string{"GOOGLE_OAUTH_PROMPT": "consent  select_account"},
+		},
+		{
+			name:    "invalid cookie samesite",
+			env:     map[string]string{"COOKIE_SAMESITE": "loose"},
+			wantErr: []string{"COOKIE_SAMESITE"},
+		},
+		{
+			name:    "samesite none with insecure cookies",
+			env:     map[string]string{"COOKIE_SAMESITE": "none", "COOKIE_SECURE": "false"},
+			wantErr: []string{"COOKIE_SECURE"},
+		},
+		{
+			name: "samesite none with secure auto",
+			env:  map[string]string{"COOKIE_SAMESITE": "None"},
+		},
+		{
+			name:    "relative bank logo base URL",
+			env:     map[string]string{"BANK_LOGO_BASE_URL": "cdn/banks"},
+			wantErr: []string{"BANK_LOGO_BASE_URL"},
+		},
+		{
+			name: "https bank logo base URL",
+			env:  map[string]string{"BANK_LOGO_BASE_URL": "https://cdn.example.com/banks"},
+		},
+		{
+			name:    "detection limit above the pool size",
+			env:     map[string]string{"DB_MAX_OPEN_CONNS": "10", "DB_DETECTION_MAX_OPS": "20"},
+			wantErr: []string{"DB_DETECTION_MAX_OPS"},
+		},
+		{
+			name: "detection limit follows the pool size",
+			env:  map[string]string{"DB_MAX_OPEN_CONNS": "2"},
+		},
+		{
+			name:    "zero attachment limits",
+			env:     map[string]string{"ATTACHMENTS_MAX_BYTES": "0", "ATTACHMENTS_MAX_PER_TRANSACTION": "-1"},
+			wantErr: []string{"ATTACHMENTS_MAX_BYTES", "ATTACHMENTS_MAX_PER_TRANSACTION"},
+		},
+		{
+			name:    "reports every problem at once",
+			env:     map[string]string{"ENCRYPTION_KEY": "short", "TLS_ENABLED": "true", "SCHEDULER_QUEUE_SIZE": "0"},
+			wantErr: []string{"ENCRYPTION_KEY", "TLS_CERT_PATH", "TLS_KEY_PATH", "SCHEDULER_QUEUE_SIZE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnvVars(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+
+			err = cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() expected error, got nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error %q does not mention %s", err, want)
+				}
+			}
+		})
 	}
 }
 
@@ -110,6 +301,62 @@ func TestLoad_AllowedHosts(t *testing.T) {
 	}
 }
 
+func TestLoad_AdminEmails(t *testing.T) {
+	setRequiredEnvVars(t)
+	t.Setenv("ADMIN_EMAILS", "admin@example.com, ,ops@example.com")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if len(cfg.Admin.Emails) != 2 {
+		t.Errorf("Admin.Emails length = %d, want 2", len(cfg.Admin.Emails))
+	}
+}
+
+func TestLoad_JWTAlgorithm(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantVerifyKeys int
+		wantErr        bool
+	}{
+		{name: "defaults to HS256", env: map[string]string{}},
+		{name: "RS256 with key", env: map[string]string{"JWT_ALGORITHM": "rs256", "JWT_KEY_ID": "k1", "JWT_PRIVATE_KEY_PATH": "/keys/k1.pem"}},
+		{name: "ES256 without key", env: map[string]string{"JWT_ALGORITHM": "ES256"}, wantErr: true},
+		{name: "unsupported algorithm", env: map[string]string{"JWT_ALGORITHM": "none"}, wantErr: true},
+		{name: "verify keys", env: map[string]string{"JWT_VERIFY_KEYS": "k0=/keys/k0.pem, k1 = /keys/k1.pem"}, wantVerifyKeys: 2},
+		{name: "malformed verify keys", env: map[string]string{"JWT_VERIFY_KEYS": "k0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnvVars(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := loadAndValidate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Load() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if want := strings.ToUpper(tt.env["JWT_ALGORITHM"]); want != "" && cfg.JWT.Algorithm != want {
+				t.Errorf("JWT.Algorithm = %q, want %q", cfg.JWT.Algorithm, want)
+			}
+			if len(cfg.JWT.VerifyKeys) != tt.wantVerifyKeys {
+				t.Errorf("JWT.VerifyKeys = %v, want %d entries", cfg.JWT.VerifyKeys, tt.wantVerifyKeys)
+			}
+		})
+	}
+}
+
 func TestLoad_SchedulerConfig(t *testing.T) {
 	setRequiredEnvVars(t)
 	t.Setenv("SCHEDULER_ENABLED", "false")
@@ -130,6 +377,9 @@ func TestLoad_SchedulerConfig(t *testing.T) {
 	if cfg.Scheduler.RunOnStartup != true {
 		t.Error("Scheduler.RunOnStartup should be true")
 	}
+	if cfg.Scheduler.QueueFullPolicy != "drop" {
+		t.Errorf("Scheduler.QueueFullPolicy = %q, want drop by default", cfg.Scheduler.QueueFullPolicy)
+	}
 }
 
 func TestGetBoolEnv(t *testing.T) {
@@ -204,3 +454,60 @@ func TestLoad_OAuthCallbackURLs(t *testing.T) {
 		t.Errorf("Google MobileCallbackURL = %q", cfg.OAuth.Google.MobileCallbackURL)
 	}
 }
+
+func TestLoad_OAuthScopes(t *testing.T) {
+	setRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := strings.Join(cfg.OAuth.Google.Scopes, " "); got != "openid email profile" {
+		t.Errorf("default Google scopes = %q", got)
+	}
+	if got := strings.Join(cfg.OAuth.Apple.Scopes, " "); got != "name email" {
+		t.Errorf("default Apple scopes = %q", got)
+	}
+	if cfg.OAuth.Google.Prompt != "" {
+		t.Errorf("default Google prompt = %q, want empty", cfg.OAuth.Google.Prompt)
+	}
+
+	t.Setenv("GOOGLE_OAUTH_SCOPES", "openid, email ,profile,https://www.googleapis.com/auth/calendar.readonly")
+	t.Setenv("GOOGLE_OAUTH_PROMPT", " consent   select_account ")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(cfg.OAuth.Google.Scopes) != 4 || cfg.OAuth.Google.Scopes[1] != "email" {
+		t.Errorf("Google scopes = %q", cfg.OAuth.Google.Scopes)
+	}
+	if cfg.OAuth.Google.Prompt != "consent select_account" {
+		t.Errorf("Google prompt = %q", cfg.OAuth.Google.Prompt)
+	}
+}
+
+func TestLoad_SubtypeAliases(t *testing.T) {
+	setRequiredEnvVars(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.OpenFinance.SubtypeAliases != nil {
+		t.Errorf("default SubtypeAliases = %v, want none", cfg.OpenFinance.SubtypeAliases)
+	}
+
+	t.Setenv("OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES", "conta_salario = checking_account, CARTAO=CREDIT_CARD")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := cfg.OpenFinance.SubtypeAliases; len(got) != 2 || got["CONTA_SALARIO"] != "CHECKING_ACCOUNT" || got["CARTAO"] != "CREDIT_CARD" {
+		t.Errorf("SubtypeAliases = %v", got)
+	}
+
+	t.Setenv("OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES", "CONTA_SALARIO")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "OPENFINANCE_ACCOUNT_SUBTYPE_ALIASES") {
+		t.Errorf("Load() with a malformed alias error = %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+// Package httperror writes the JSON error body of the API, so handlers and middleware report
+// failures in the same format
+package httperror
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Response is the JSON body of an error response. Code is a stable identifier for the
+// statuses clients branch on (see statusCodes) and is omitted for the others.
+type Response struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// statusCodes are the Response codes of the statuses every handler can answer with
+var statusCodes = map[int]string{
+	http.StatusUnauthorized:     "unauthorized",
+	http.StatusForbidden:        "forbidden",
+	http.StatusMethodNotAllowed: "method_not_allowed",
+}
+
+// Write writes a Response with the given status
+func Write(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{Error: message, Code: statusCodes[status]})
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", "json")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	logger.Debug("per-transaction detail", "transaction_id", "tx-1")
+	logger.Info("summary")
+	if buf.Len() != 0 {
+		t.Fatalf("records below warn were written: %q", buf.String())
+	}
+
+	logger.Warn("failed to mark transaction", "transaction_id", "tx-1")
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not JSON: %v (%q)", err, buf.String())
+	}
+	if record["level"] != "WARN" || record["transaction_id"] != "tx-1" {
+		t.Errorf("record = %v", record)
+	}
+
+	buf.Reset()
+	logger, err = New(&buf, "DEBUG", "text")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	logger.Debug("detail", "transaction_id", "tx-2")
+	if !strings.Contains(buf.String(), "level=DEBUG") || !strings.Contains(buf.String(), "transaction_id=tx-2") {
+		t.Errorf("text output = %q", buf.String())
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "verbose", "text"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+	if _, err := New(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestSetup_KeepsStandardLogUnfiltered(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	if err := Setup("error", "json"); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if log.Writer() != os.Stderr {
+		t.Errorf("standard log writes to %T, want os.Stderr", log.Writer())
+	}
+	if log.Flags() != log.LstdFlags {
+		t.Errorf("standard log flags = %d, want %d", log.Flags(), log.LstdFlags)
+	}
+}
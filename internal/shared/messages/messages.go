@@ -3,12 +3,19 @@ package messages
 import (
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"sync"
 )
 
 //go:embed notifications.json
 var notificationsJSON []byte
 
+//go:embed notes.json
+var notesJSON []byte
+
+// DefaultLocale is the locale used when none is configured
+const DefaultLocale = "pt-BR"
+
 type MessageText struct {
 	Title string `json:"title"`
 	Body  string `json:"body"`
@@ -19,10 +26,20 @@ type Messages struct {
 	ProviderKeyCleared MessageText `json:"provider_key_cleared"`
 }
 
+// Notes holds the transaction note texts written by the duplicate check
+type Notes struct {
+	Duplicate   string `json:"duplicate"`
+	BillPayment string `json:"bill_payment"`
+}
+
 var (
 	loaded   Messages
 	loadOnce sync.Once
 	loadErr  error
+
+	notesCatalog map[string]Notes
+	notesOnce    sync.Once
+	notesLoadErr error
 )
 
 // Load parses the embedded notifications JSON and caches the result.
@@ -38,3 +55,25 @@ func Load() (*Messages, error) {
 	}
 	return &loaded, nil
 }
+
+// LoadNotes returns the transaction note texts for locale from the embedded catalog.
+// An empty locale selects DefaultLocale.
+func LoadNotes(locale string) (*Notes, error) {
+	notesOnce.Do(func() {
+		if err := json.Unmarshal(notesJSON, &notesCatalog); err != nil {
+			notesLoadErr = err
+		}
+	})
+	if notesLoadErr != nil {
+		return nil, notesLoadErr
+	}
+
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	notes, ok := notesCatalog[locale]
+	if !ok {
+		return nil, fmt.Errorf("no transaction notes for locale %q", locale)
+	}
+	return &notes, nil
+}
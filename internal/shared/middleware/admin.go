@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"parsa/internal/shared/httperror"
+)
+
+// RequireAdmin restricts a route to the configured admin emails.
+// Must be chained after Auth so the email is present in the request context.
+// An empty admin list denies every request.
+func RequireAdmin(adminEmails []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]struct{}, len(adminEmails))
+	for _, email := range adminEmails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email != "" {
+			allowed[email] = struct{}{}
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			email, _ := r.Context().Value(EmailKey).(string)
+			if _, ok := allowed[strings.ToLower(email)]; !ok || email == "" {
+				httperror.Write(w, http.StatusForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
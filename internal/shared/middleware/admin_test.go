@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name        string
+		adminEmails []string
+		email       any
+		wantStatus  int
+	}{
+		{
+			name:        "admin email allowed",
+			adminEmails: []string{"admin@example.com"},
+			email:       "admin@example.com",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "match is case insensitive",
+			adminEmails: []string{" Admin@Example.com "},
+			email:       "admin@EXAMPLE.com",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "non-admin email forbidden",
+			adminEmails: []string{"admin@example.com"},
+			email:       "user@example.com",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "missing email forbidden",
+			adminEmails: []string{"admin@example.com"},
+			email:       nil,
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "empty admin list forbids everyone",
+			adminEmails: nil,
+			email:       "admin@example.com",
+			wantStatus:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireAdmin(tt.adminEmails)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/audit", nil)
+			if tt.email != nil {
+				req = req.WithContext(context.WithValue(req.Context(), EmailKey, tt.email))
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
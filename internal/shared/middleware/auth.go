@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"parsa/internal/shared/auth"
+	"parsa/internal/shared/httperror"
 )
 
 type ContextKey string
@@ -27,12 +28,12 @@ func Auth(jwt *auth.JWT) func(http.Handler) http.Handler {
 				// Fall back to Authorization header (API clients)
 				authHeader := r.Header.Get("Authorization")
 				if authHeader == "" {
-					http.Error(w, "Authentication required", http.StatusUnauthorized)
+					httperror.Write(w, http.StatusUnauthorized, "Authentication required")
 					return
 				}
 				parts := strings.SplitN(authHeader, " ", 2)
 				if len(parts) != 2 || parts[0] != "Bearer" {
-					http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+					httperror.Write(w, http.StatusUnauthorized, "Invalid authorization header format")
 					return
 				}
 				token = parts[1]
@@ -40,7 +41,7 @@ func Auth(jwt *auth.JWT) func(http.Handler) http.Handler {
 
 			claims, err := jwt.Validate(token)
 			if err != nil {
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				httperror.Write(w, http.StatusUnauthorized, "Invalid or expired token")
 				return
 			}
 
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"parsa/internal/shared/httperror"
 )
 
 func CORS(allowedHosts []string) func(http.Handler) http.Handler {
@@ -34,14 +36,15 @@ func CORS(allowedHosts []string) func(http.Handler) http.Handler {
 					} else {
 						// Origin not allowed - don't set CORS headers
 						// Browser will block the response
-						http.Error(w, "Origin not allowed", http.StatusForbidden)
+						httperror.Write(w, http.StatusForbidden, "Origin not allowed")
 						return
 					}
 				}
 			}
 
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "Link, X-Total-Count, ETag")
 			w.Header().Set("Access-Control-Max-Age", "3600")
 
 			// Handle preflight requests
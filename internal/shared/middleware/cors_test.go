@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -134,6 +135,19 @@ func TestCORS_DisallowedOrigin(t *testing.T) {
 	if rr.Code != http.StatusForbidden {
 		t.Errorf("expected 403, got %d", rr.Code)
 	}
+	if got := rr.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON content type, got %q", got)
+	}
+	var body struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode error body: %v", err)
+	}
+	if body.Error != "Origin not allowed" || body.Code != "forbidden" {
+		t.Errorf("unexpected error body: %+v", body)
+	}
 }
 
 func TestCORS_PreflightRequest(t *testing.T) {
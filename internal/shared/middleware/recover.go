@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// RequestIDHeader carries the request ID used to correlate panic logs with responses
+const RequestIDHeader = "X-Request-ID"
+
+// Recover turns a panicking handler into a JSON 500 response instead of dropping the
+// connection. The stack is logged with the request ID (taken from X-Request-ID or
+// generated), which is also returned to the client. It should be the outermost middleware.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wrapped := wrapResponseWriter(w)
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Deliberate aborts must keep propagating so net/http closes the connection
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+
+			log.Printf("panic serving %s %s (request %s): %v\n%s", r.Method, r.URL.Path, requestID, rec, debug.Stack())
+
+			// Headers already sent; nothing meaningful can be written anymore
+			if wrapped.wroteHeader {
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(RequestIDHeader, requestID)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error":     "Internal server error",
+				"requestId": requestID,
+			})
+		}()
+
+		next.ServeHTTP(wrapped, r)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,31 @@
+// Package money does fixed-scale arithmetic on amounts held as float64. Amounts are stored
+// as numeric(15,2) in the database, so sums and comparisons go through integer cents to
+// avoid binary floating point drift (10.10 + 0.20 != 10.30 as float64).
+package money
+
+import "math"
+
+// ToCents converts an amount to integer cents, rounding half away from zero
+func ToCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromCents converts integer cents back to an amount
+func FromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// Round rounds an amount to the nearest cent
+func Round(amount float64) float64 {
+	return FromCents(ToCents(amount))
+}
+
+// Add returns a + b computed in cents
+func Add(a, b float64) float64 {
+	return FromCents(ToCents(a) + ToCents(b))
+}
+
+// Equal reports whether two amounts are the same to the cent
+func Equal(a, b float64) bool {
+	return ToCents(a) == ToCents(b)
+}
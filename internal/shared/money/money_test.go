@@ -0,0 +1,63 @@
+package money
+
+import "testing"
+
+func TestToCents(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   int64
+	}{
+		{0, 0},
+		{10.10, 1010},
+		{0.29, 29}, // 0.29 * 100 = 28.999999999999996
+		{1.005, 100},
+		{-45.67, -4567},
+		{-0.005, -1},
+		{1234567890123.45, 123456789012345},
+	}
+
+	for _, tt := range tests {
+		if got := ToCents(tt.amount); got != tt.want {
+			t.Errorf("ToCents(%v) = %d, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestAdd_DoesNotDrift(t *testing.T) {
+	a, b := 10.10, 0.20 // variables, so the sum is not folded exactly at compile time
+	if a+b == 10.30 {
+		t.Fatal("float64 sum unexpectedly exact; the test no longer demonstrates drift")
+	}
+	if got := Add(a, b); got != 10.30 {
+		t.Errorf("Add(10.10, 0.20) = %v, want 10.30", got)
+	}
+
+	var sum, naive float64
+	for i := 0; i < 1000; i++ {
+		sum = Add(sum, 0.10)
+		naive += 0.10
+	}
+	if naive == 100 {
+		t.Fatal("naive float64 sum unexpectedly exact")
+	}
+	if sum != 100 {
+		t.Errorf("1000 x 0.10 = %v, want 100", sum)
+	}
+
+	if got := Add(-300, 1500.25); got != 1200.25 {
+		t.Errorf("Add(-300, 1500.25) = %v, want 1200.25", got)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, b := 10.10, 0.20
+	if !Equal(a+b, 10.30) {
+		t.Error("Equal(10.10+0.20, 10.30) = false, want true")
+	}
+	if Equal(10.30, 10.31) {
+		t.Error("Equal(10.30, 10.31) = true, want false")
+	}
+	if got := Round(a + b); got != 10.30 {
+		t.Errorf("Round(10.10+0.20) = %v, want 10.30", got)
+	}
+}
@@ -0,0 +1,56 @@
+// Package pool runs a function over a slice of items with bounded concurrency
+package pool
+
+import (
+	"context"
+	"sync"
+)
+
+// Result is the outcome of running the function on one item
+type Result[R any] struct {
+	Value R
+	Err   error
+}
+
+// Map calls fn for each item using at most concurrency goroutines (at least one) and returns
+// the results in input order. Once ctx is cancelled, items that have not started are not run
+// and get ctx.Err(); items already running finish. Map always returns after every item has
+// a result, so no goroutines are left behind.
+func Map[T, R any](ctx context.Context, items []T, concurrency int, fn func(ctx context.Context, item T) (R, error)) []Result[R] {
+	results := make([]Result[R], len(items))
+	if len(items) == 0 {
+		return results
+	}
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(items) {
+		concurrency = len(items)
+	}
+
+	indexes := make(chan int, len(items))
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// Drain the remaining items without running them once cancelled
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Value, results[i].Err = fn(ctx, items[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
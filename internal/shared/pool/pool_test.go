@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap_ResultsInOrder(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	errOdd := errors.New("odd")
+
+	results := Map(context.Background(), items, 3, func(ctx context.Context, n int) (int, error) {
+		if n%2 == 1 {
+			return 0, errOdd
+		}
+		return n * 10, nil
+	})
+
+	if len(results) != len(items) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(items))
+	}
+	for i, n := range items {
+		if n%2 == 1 {
+			if !errors.Is(results[i].Err, errOdd) {
+				t.Errorf("results[%d].Err = %v, want %v", i, results[i].Err, errOdd)
+			}
+			continue
+		}
+		if results[i].Err != nil || results[i].Value != n*10 {
+			t.Errorf("results[%d] = (%d, %v), want (%d, nil)", i, results[i].Value, results[i].Err, n*10)
+		}
+	}
+}
+
+func TestMap_BoundedConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		wantMax     int32
+	}{
+		{name: "limited", concurrency: 2, wantMax: 2},
+		{name: "zero runs one worker", concurrency: 0, wantMax: 1},
+		{name: "more workers than items", concurrency: 50, wantMax: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning int32
+			items := make([]int, 10)
+
+			Map(context.Background(), items, tt.concurrency, func(ctx context.Context, _ int) (struct{}, error) {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					old := atomic.LoadInt32(&maxRunning)
+					if n <= old || atomic.CompareAndSwapInt32(&maxRunning, old, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return struct{}{}, nil
+			})
+
+			if maxRunning > tt.wantMax {
+				t.Errorf("max concurrent calls = %d, want at most %d", maxRunning, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestMap_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	items := make([]int, 20)
+	var calls int32
+
+	results := Map(ctx, items, 1, func(ctx context.Context, _ int) (int, error) {
+		if atomic.AddInt32(&calls, 1) == 3 {
+			cancel()
+		}
+		return 1, nil
+	})
+
+	if calls != 3 {
+		t.Errorf("fn called %d times, want 3", calls)
+	}
+	for i, r := range results {
+		if i < 3 {
+			if r.Err != nil {
+				t.Errorf("results[%d].Err = %v, want nil for a started item", i, r.Err)
+			}
+			continue
+		}
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
+
+func TestMap_Empty(t *testing.T) {
+	results := Map(context.Background(), []int(nil), 4, func(ctx context.Context, n int) (int, error) {
+		t.Fatal("fn called for an empty slice")
+		return 0, nil
+	})
+	if len(results) != 0 {
+		t.Errorf("len(results) = %d, want 0", len(results))
+	}
+}
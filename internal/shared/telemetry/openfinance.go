@@ -0,0 +1,60 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OpenFinanceMetrics records Open Finance client calls: request and error counts by endpoint
+// and status, and a latency histogram by endpoint. It satisfies the client's Metrics interface.
+type OpenFinanceMetrics struct {
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// NewOpenFinanceMetrics creates the Open Finance client instruments. Init must have run first.
+func NewOpenFinanceMetrics() (*OpenFinanceMetrics, error) {
+	if meter == nil {
+		return nil, errors.New("telemetry is not initialized")
+	}
+
+	requests, err := meter.Int64Counter("openfinance_client_request_count",
+		metric.WithDescription("Total Open Finance API requests"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	errs, err := meter.Int64Counter("openfinance_client_error_count",
+		metric.WithDescription("Open Finance API requests that failed or returned a non-200 status"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("openfinance_client_request_duration_seconds",
+		metric.WithDescription("Open Finance API request duration in seconds"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenFinanceMetrics{requests: requests, errors: errs, duration: duration}, nil
+}
+
+// ObserveRequest records one call. Status 0 means no response was received.
+func (m *OpenFinanceMetrics) ObserveRequest(ctx context.Context, endpoint string, status int, duration time.Duration) {
+	attrs := metric.WithAttributes(
+		attribute.String("endpoint", endpoint),
+		attribute.Int("status_code", status),
+	)
+	m.requests.Add(ctx, 1, attrs)
+	if status != http.StatusOK {
+		m.errors.Add(ctx, 1, attrs)
+	}
+	m.duration.Record(ctx, duration.Seconds(), metric.WithAttributes(attribute.String("endpoint", endpoint)))
+}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SchedulerMetrics records sync jobs the scheduler dropped because its queue was full. It
+// satisfies the scheduler's Metrics interface.
+type SchedulerMetrics struct {
+	dropped metric.Int64Counter
+}
+
+// NewSchedulerMetrics creates the scheduler instruments. Init must have run first.
+func NewSchedulerMetrics() (*SchedulerMetrics, error) {
+	if meter == nil {
+		return nil, errors.New("telemetry is not initialized")
+	}
+
+	dropped, err := meter.Int64Counter("scheduler_job_dropped_count",
+		metric.WithDescription("Scheduled sync jobs dropped because the job queue was full"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SchedulerMetrics{dropped: dropped}, nil
+}
+
+// JobDropped records one dropped job.
+func (m *SchedulerMetrics) JobDropped(ctx context.Context) {
+	m.dropped.Add(ctx, 1)
+}
@@ -0,0 +1,4 @@
+-- Rollback migration 000009
+
+ALTER TABLE public.items DROP COLUMN IF EXISTS disabled;
+ALTER TABLE public.items DROP COLUMN IF EXISTS needs_reconnect;
@@ -0,0 +1,6 @@
+-- Migration 000009: Add per-item connection state
+-- needs_reconnect: provider rejected the credentials; accounts are excluded from sync until fixed
+-- disabled: user turned the connection off; accounts are excluded from sync
+
+ALTER TABLE public.items ADD COLUMN needs_reconnect boolean DEFAULT false NOT NULL;
+ALTER TABLE public.items ADD COLUMN disabled boolean DEFAULT false NOT NULL;
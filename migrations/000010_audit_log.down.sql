@@ -0,0 +1,3 @@
+-- Rollback migration 000010
+
+DROP TABLE IF EXISTS public.audit_log;
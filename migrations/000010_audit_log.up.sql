@@ -0,0 +1,17 @@
+-- Migration 000010: Audit log for user-initiated mutations
+-- changes holds {"field": {"old": ..., "new": ...}} for the audited fields
+
+CREATE TABLE public.audit_log (
+    id bigserial NOT NULL,
+    user_id bigint NOT NULL,
+    entity_type character varying(50) NOT NULL,
+    entity_id character varying(255) NOT NULL,
+    action character varying(20) NOT NULL,
+    changes jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT audit_log_pkey PRIMARY KEY (id),
+    CONSTRAINT audit_log_action_check CHECK (action IN ('CREATE', 'UPDATE', 'DELETE'))
+);
+
+CREATE INDEX idx_audit_log_entity ON public.audit_log USING btree (entity_type, entity_id, created_at DESC);
+CREATE INDEX idx_audit_log_user_id ON public.audit_log USING btree (user_id);
@@ -0,0 +1,5 @@
+-- Rollback migration 000011
+
+ALTER TABLE public.users DROP COLUMN IF EXISTS provider_key_failure_count;
+ALTER TABLE public.users DROP COLUMN IF EXISTS provider_key_last_valid_at;
+ALTER TABLE public.users DROP COLUMN IF EXISTS provider_key_updated_at;
@@ -0,0 +1,6 @@
+-- Migration 000011: Provider key validity metadata
+-- provider_key_failure_count tracks consecutive 401s; the key is cleared once it reaches the sync threshold
+
+ALTER TABLE public.users ADD COLUMN provider_key_updated_at timestamp with time zone;
+ALTER TABLE public.users ADD COLUMN provider_key_last_valid_at timestamp with time zone;
+ALTER TABLE public.users ADD COLUMN provider_key_failure_count integer DEFAULT 0 NOT NULL;
@@ -0,0 +1,4 @@
+-- Rollback migration 000012
+
+ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_auto_excluded_reason_check;
+ALTER TABLE public.transactions DROP COLUMN IF EXISTS auto_excluded_reason;
@@ -0,0 +1,31 @@
+-- Migration 000012: Record why a detection service excluded a transaction
+-- Idempotency of the duplicate and bill payment checks is keyed off this column instead of the note text
+
+ALTER TABLE public.transactions ADD COLUMN auto_excluded_reason character varying(20);
+ALTER TABLE public.transactions ADD CONSTRAINT transactions_auto_excluded_reason_check
+    CHECK (auto_excluded_reason IN ('DUPLICATE', 'BILL_PAYMENT'));
+
+-- Backfill rows already marked through the note text. The bill check used to write the
+-- duplicate note too, so marked rows in the bill payment category (05100000) are bill payments.
+UPDATE public.transactions
+SET auto_excluded_reason = 'BILL_PAYMENT'
+WHERE notes LIKE '%corresponde ao pagamento de uma fatura%'
+   OR notes LIKE '%matches a credit card bill payment%'
+   OR (provider_category_id = '05100000'
+       AND (notes LIKE '%[parsa:duplicate]%'
+            OR notes LIKE '%Esta transação não será considerada%'
+            OR notes LIKE '%desconsiderada%'
+            OR notes LIKE '%This transaction will not be considered%'));
+
+UPDATE public.transactions
+SET auto_excluded_reason = 'DUPLICATE'
+WHERE auto_excluded_reason IS NULL
+  AND (notes LIKE '%[parsa:duplicate]%'
+       OR notes LIKE '%Esta transação não será considerada%'
+       OR notes LIKE '%desconsiderada%'
+       OR notes LIKE '%This transaction will not be considered%');
+
+-- The note marker is no longer written
+UPDATE public.transactions
+SET notes = replace(notes, '[parsa:duplicate] ', '')
+WHERE notes LIKE '%[parsa:duplicate] %';
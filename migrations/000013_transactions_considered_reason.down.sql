@@ -0,0 +1,7 @@
+-- Rollback migration 000013
+
+ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_considered_reason_check;
+UPDATE public.transactions SET considered_reason = NULL WHERE considered_reason IN ('USER', 'TRANSFER');
+ALTER TABLE public.transactions RENAME COLUMN considered_reason TO auto_excluded_reason;
+ALTER TABLE public.transactions ADD CONSTRAINT transactions_auto_excluded_reason_check
+    CHECK (auto_excluded_reason IN ('DUPLICATE', 'BILL_PAYMENT'));
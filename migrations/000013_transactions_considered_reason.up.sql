@@ -0,0 +1,8 @@
+-- Migration 000013: Generalize auto_excluded_reason into considered_reason
+-- USER: the user toggled considered (directly or through a cousin rule)
+-- TRANSFER: transfer between the user's own accounts
+
+ALTER TABLE public.transactions DROP CONSTRAINT IF EXISTS transactions_auto_excluded_reason_check;
+ALTER TABLE public.transactions RENAME COLUMN auto_excluded_reason TO considered_reason;
+ALTER TABLE public.transactions ADD CONSTRAINT transactions_considered_reason_check
+    CHECK (considered_reason IN ('DUPLICATE', 'BILL_PAYMENT', 'USER', 'TRANSFER'));
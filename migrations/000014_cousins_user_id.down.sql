@@ -0,0 +1,5 @@
+-- Rollback migration 000014
+
+DROP INDEX IF EXISTS idx_cousins_user_id;
+ALTER TABLE public.cousins DROP CONSTRAINT IF EXISTS cousins_user_id_fkey;
+ALTER TABLE public.cousins DROP COLUMN IF EXISTS user_id;
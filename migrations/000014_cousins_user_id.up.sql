@@ -0,0 +1,8 @@
+-- Migration 000014: Scope user-created cousins by user
+-- user_id is NULL for shared cousins created by transaction grouping
+
+ALTER TABLE public.cousins ADD COLUMN user_id bigint;
+ALTER TABLE public.cousins ADD CONSTRAINT cousins_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_cousins_user_id ON public.cousins USING btree (user_id);
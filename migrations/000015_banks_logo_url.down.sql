@@ -0,0 +1,3 @@
+-- Rollback migration 000015
+
+ALTER TABLE public.banks DROP COLUMN IF EXISTS logo_url;
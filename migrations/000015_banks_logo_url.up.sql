@@ -0,0 +1,4 @@
+-- Migration 000015: Add logo_url to banks
+-- Brand assets for the accounts-with-bank response, filled from the bank branding catalog during sync
+
+ALTER TABLE public.banks ADD COLUMN logo_url character varying(512);
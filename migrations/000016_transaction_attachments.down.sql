@@ -0,0 +1,3 @@
+-- Rollback migration 000016
+
+DROP TABLE IF EXISTS public.attachments;
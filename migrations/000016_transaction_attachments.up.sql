@@ -0,0 +1,19 @@
+-- Migration 000016: Receipt attachments for transactions
+-- The file itself lives in the blob store under storage_key; only the reference is kept here
+
+CREATE TABLE public.attachments (
+    id bigserial NOT NULL,
+    transaction_id character varying(255) NOT NULL,
+    user_id bigint NOT NULL,
+    filename character varying(255) NOT NULL,
+    content_type character varying(100) NOT NULL,
+    size_bytes bigint NOT NULL,
+    storage_key character varying(255) NOT NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT attachments_pkey PRIMARY KEY (id),
+    CONSTRAINT attachments_storage_key_key UNIQUE (storage_key),
+    CONSTRAINT attachments_transaction_id_fkey FOREIGN KEY (transaction_id) REFERENCES public.transactions(id) ON DELETE CASCADE,
+    CONSTRAINT attachments_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_attachments_transaction_id ON public.attachments USING btree (transaction_id);
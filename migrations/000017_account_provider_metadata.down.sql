@@ -0,0 +1,4 @@
+-- Rollback migration 000017
+
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS provider_code;
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS marketing_name;
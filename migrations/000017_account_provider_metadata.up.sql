@@ -0,0 +1,6 @@
+-- Migration 000017: Add marketing_name and provider_code to accounts
+-- The provider's display name for the account (e.g. "Ultravioleta") and its institution code,
+-- both filled during account sync
+
+ALTER TABLE public.accounts ADD COLUMN marketing_name character varying(255);
+ALTER TABLE public.accounts ADD COLUMN provider_code character varying(50);
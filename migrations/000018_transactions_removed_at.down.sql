@@ -0,0 +1,3 @@
+-- Rollback migration 000018
+
+ALTER TABLE public.transactions DROP COLUMN IF EXISTS removed_at;
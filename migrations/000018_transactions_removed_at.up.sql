@@ -0,0 +1,5 @@
+-- Migration 000018: Add removed_at to transactions
+-- Set when a full sync no longer receives a synced transaction from the provider (deleted
+-- upstream); cleared again if the provider returns it later
+
+ALTER TABLE public.transactions ADD COLUMN removed_at timestamp with time zone;
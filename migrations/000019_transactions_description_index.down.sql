@@ -0,0 +1,3 @@
+-- Rollback migration 000019
+
+DROP INDEX IF EXISTS public.idx_transactions_description_prefix;
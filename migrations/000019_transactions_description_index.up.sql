@@ -0,0 +1,4 @@
+-- Migration 000019: Index normalized transaction descriptions for prefix search
+-- Backs the description autocomplete (lower(btrim(description)) LIKE 'prefix%')
+
+CREATE INDEX idx_transactions_description_prefix ON public.transactions USING btree (lower(btrim(description)) text_pattern_ops);
@@ -0,0 +1,4 @@
+-- Rollback migration 000020
+
+DROP INDEX IF EXISTS public.idx_transactions_transfer_group;
+ALTER TABLE public.transactions DROP COLUMN IF EXISTS transfer_group;
@@ -0,0 +1,5 @@
+-- Migration 000020: Add transfer_group to transactions
+-- Links the two sides of a transfer the user marked between their own accounts
+
+ALTER TABLE public.transactions ADD COLUMN transfer_group uuid;
+CREATE INDEX idx_transactions_transfer_group ON public.transactions USING btree (transfer_group) WHERE transfer_group IS NOT NULL;
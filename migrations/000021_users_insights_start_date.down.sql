@@ -0,0 +1,3 @@
+-- Rollback migration 000021
+
+ALTER TABLE public.users DROP COLUMN IF EXISTS insights_start_date;
@@ -0,0 +1,4 @@
+-- Migration 000021: Add insights start date to users
+-- Transactions dated before it are left out of aggregations; NULL means no lower bound
+
+ALTER TABLE public.users ADD COLUMN insights_start_date date;
@@ -0,0 +1,3 @@
+-- Rollback migration 000022
+
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS provider_subtype;
@@ -0,0 +1,7 @@
+-- Migration 000022: Add provider_subtype to accounts
+-- subtype holds the canonical Parsa subtype the provider's value normalizes to during account
+-- sync; provider_subtype keeps the provider's value verbatim. Existing subtypes already passed
+-- the canonical check, so they are copied over as-is.
+
+ALTER TABLE public.accounts ADD COLUMN provider_subtype character varying(50);
+UPDATE public.accounts SET provider_subtype = subtype WHERE subtype IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Rollback migration 000023
+
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS removed_by_provider;
@@ -0,0 +1,7 @@
+-- Migration 000023: Add removed_by_provider to accounts
+-- Account sync sets removed_at on accounts the provider stopped reporting, the same column a
+-- user removal sets. removed_by_provider tells the two apart so sync can restore an account the
+-- provider reports again without undoing a user's removal. Existing removals cannot be told
+-- apart and stay user removals.
+
+ALTER TABLE public.accounts ADD COLUMN removed_by_provider boolean DEFAULT false NOT NULL;
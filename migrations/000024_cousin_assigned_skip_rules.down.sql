@@ -0,0 +1,20 @@
+-- Rollback migration 000024
+
+CREATE OR REPLACE FUNCTION public.notify_cousin_assigned() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF (OLD.cousin IS NULL OR OLD.cousin = 0) AND NEW.cousin IS NOT NULL AND NEW.cousin != 0 THEN
+        PERFORM pg_notify(
+            'cousin_assigned',
+            json_build_object(
+                'transaction_id', NEW.id,
+                'cousin_id', NEW.cousin,
+                'type', NEW.type,
+                'account_id', NEW.account_id
+            )::text
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$;
@@ -0,0 +1,25 @@
+-- Migration 000024: Let a cousin assignment skip the cousin_assigned notification
+-- The API applies the cousin's rule itself when asked to; it sets parsa.skip_cousin_rules for
+-- its transaction so the listener doesn't apply the same rule a second time.
+
+CREATE OR REPLACE FUNCTION public.notify_cousin_assigned() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF current_setting('parsa.skip_cousin_rules', true) = 'on' THEN
+        RETURN NEW;
+    END IF;
+    IF (OLD.cousin IS NULL OR OLD.cousin = 0) AND NEW.cousin IS NOT NULL AND NEW.cousin != 0 THEN
+        PERFORM pg_notify(
+            'cousin_assigned',
+            json_build_object(
+                'transaction_id', NEW.id,
+                'cousin_id', NEW.cousin,
+                'type', NEW.type,
+                'account_id', NEW.account_id
+            )::text
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$;
@@ -0,0 +1,5 @@
+-- Rollback migration 000025
+
+DROP TRIGGER IF EXISTS trigger_queue_attachment_blob_deletion ON public.attachments;
+DROP FUNCTION IF EXISTS public.queue_attachment_blob_deletion();
+DROP TABLE IF EXISTS public.attachment_blob_deletions;
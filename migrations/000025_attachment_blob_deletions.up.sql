@@ -0,0 +1,25 @@
+-- Migration 000025: Queue the blobs of deleted attachments
+-- Attachment rows also go away through ON DELETE CASCADE when a transaction, account or user is
+-- deleted, which the application never sees. The trigger records every deleted row's
+-- storage_key so the attachment service can delete the blob and then the queue entry.
+
+CREATE TABLE public.attachment_blob_deletions (
+    storage_key character varying(255) NOT NULL,
+    deleted_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT attachment_blob_deletions_pkey PRIMARY KEY (storage_key)
+);
+
+CREATE FUNCTION public.queue_attachment_blob_deletion() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    INSERT INTO public.attachment_blob_deletions (storage_key)
+    VALUES (OLD.storage_key)
+    ON CONFLICT (storage_key) DO NOTHING;
+    RETURN OLD;
+END;
+$$;
+
+CREATE TRIGGER trigger_queue_attachment_blob_deletion
+    AFTER DELETE ON public.attachments
+    FOR EACH ROW EXECUTE FUNCTION public.queue_attachment_blob_deletion();
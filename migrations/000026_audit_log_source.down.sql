@@ -0,0 +1,3 @@
+-- Rollback migration 000026
+
+ALTER TABLE public.audit_log DROP COLUMN IF EXISTS source;
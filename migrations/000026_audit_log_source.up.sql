@@ -0,0 +1,7 @@
+-- Migration 000026: Record who made each audited change
+-- source is 'user' for a user's own edits, 'rule' for cousin rule applications and
+-- 'detection' for duplicate and bill-payment checks; existing entries were all user edits
+
+ALTER TABLE public.audit_log
+    ADD COLUMN source character varying(20) DEFAULT 'user' NOT NULL,
+    ADD CONSTRAINT audit_log_source_check CHECK (source IN ('user', 'rule', 'detection'));